@@ -1,10 +1,14 @@
 package main
 
 import (
+	"os"
+
 	"github.com/svartlfheim/ymir/cmd/ymir"
 )
 
 func main() {
-	//nolint:errcheck
-	ymir.Execute()
+	// Commands report their own errors, the exit code is all that's left
+	if err := ymir.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
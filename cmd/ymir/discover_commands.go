@@ -0,0 +1,114 @@
+package ymir
+
+import (
+	"encoding/json"
+
+	"github.com/svartlfheim/ymir/internal/cli"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+func discover(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+
+	style, err := flags.GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	repo, err := flags.GetString("repo")
+
+	if err != nil {
+		o.Error("the 'repo' option was not configured for this command")
+		return nil
+	}
+
+	ref, err := flags.GetString("ref")
+
+	if err != nil {
+		o.Error("the 'ref' option was not configured for this command")
+		return nil
+	}
+
+	ns, err := flags.GetString("namespace")
+
+	if err != nil {
+		o.Error("the 'namespace' option was not configured for this command")
+		return nil
+	}
+
+	provider, err := flags.GetString("provider")
+
+	if err != nil {
+		o.Error("the 'provider' option was not configured for this command")
+		return nil
+	}
+
+	dryRun, err := flags.GetBool("dry-run")
+
+	if err != nil {
+		o.Error("the 'dry-run' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.DiscoverModulesV1(c.cobra.Context(), registry.DiscoverModulesV1DTO{
+		RepositoryURL:        repo,
+		Ref:                  ref,
+		DefaultNamespace:     ns,
+		DefaultProvider:      provider,
+		DryRun:               dryRun,
+		AllowLocalRepository: true,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.Report, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.Report) == 0 {
+			o.Warnln("No modules found!")
+			return nil
+		}
+
+		h, r := registry.BuildDiscoveryReportTable(res.Report)
+		buildTableFactory().CreateAndPrint(h, r)
+
+		o.Successf("New: %d\n", res.CountByStatus(registry.DiscoveryStatuses.New))
+		o.Infof("Existing: %d\n", res.CountByStatus(registry.DiscoveryStatuses.Existing))
+
+		if conflicting := res.CountByStatus(registry.DiscoveryStatuses.Conflicting); conflicting > 0 {
+			o.Errorf("Conflicting: %d\n", conflicting)
+		}
+
+		if dryRun {
+			o.Warnln("Dry run, no modules were registered.")
+		}
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
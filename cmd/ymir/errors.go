@@ -9,3 +9,46 @@ type ErrNoArgAtIndex struct {
 func (e ErrNoArgAtIndex) Error() string {
 	return fmt.Sprintf("not arg was supplied at index: %d", e.index)
 }
+
+type ErrPublishFailed struct {
+	Reason string
+}
+
+func (e ErrPublishFailed) Error() string {
+	return fmt.Sprintf("publish failed: %s", e.Reason)
+}
+
+type ErrUsageCheckFailed struct {
+	Count  int
+	Reason string
+}
+
+func (e ErrUsageCheckFailed) Error() string {
+	return fmt.Sprintf("usage check failed: %d module usage(s) %s", e.Count, e.Reason)
+}
+
+type ErrInvalidOIDCIssuer struct {
+	Issuer string
+	Reason string
+}
+
+func (e ErrInvalidOIDCIssuer) Error() string {
+	return fmt.Sprintf("oidc issuer '%s' is invalid: %s", e.Issuer, e.Reason)
+}
+
+type ErrInvalidTLSConfig struct {
+	Reason string
+}
+
+func (e ErrInvalidTLSConfig) Error() string {
+	return fmt.Sprintf("server.tls is invalid: %s", e.Reason)
+}
+
+type ErrInvalidCertificateRule struct {
+	Subject string
+	Reason  string
+}
+
+func (e ErrInvalidCertificateRule) Error() string {
+	return fmt.Sprintf("certificate rule '%s' is invalid: %s", e.Subject, e.Reason)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/svartlfheim/ymir/internal/config"
 	"github.com/svartlfheim/ymir/internal/output"
 	"github.com/svartlfheim/ymir/internal/repository"
+	"github.com/svartlfheim/ymir/internal/storage"
 	"github.com/svartlfheim/ymir/pkg/gopoint"
 )
 
@@ -98,6 +99,22 @@ var ymirConfig config.Ymir = config.Ymir{
 			},
 		},
 	},
+	Storage: config.StorageConfig{
+		Driver: storage.DriverInMemory,
+	},
+	Worker: config.WorkerConfig{
+		Interval:     5,
+		BuildTimeout: 600,
+	},
+	Deliveries: config.DeliveriesConfig{
+		Interval:    5,
+		MaxAttempts: 8,
+		Timeout:     10,
+	},
+	Proxy: config.ProxyConfig{
+		TTL:     3600,
+		Timeout: 60,
+	},
 	Git: config.GitConfig{
 		Github: config.GithubConfig{
 			AccessToken: "",
@@ -163,6 +180,13 @@ Coming soon, is a UI to manage module versions.`,
 						Type:        clapp.StringFlag,
 						Required:    false,
 					},
+					{
+						Name:        "sync-interval",
+						Description: "Seconds between publishing versions from git tags, see 'ymir sync tags'. Default: 0 (disabled)",
+						ValueRef:    &ymirConfig.Sync.Interval,
+						Type:        clapp.IntFlag,
+						Required:    false,
+					},
 				},
 				Handle: buildHandler(serve),
 			},
@@ -250,6 +274,114 @@ An ID or an FQN must be supplied.`,
 							},
 						},
 					},
+					{
+						Name:   "resolve",
+						Handle: buildHandler(module_resolve),
+						Descriptions: clapp.Descriptions{
+							Short: "Show which version a version constraint resolves to.",
+							Long: `Resolves a terraform version constraint against the ready versions of a module, defined by an ID or a ModuleFQN:
+
+  ymir module resolve aws/platform/vpc '~> 3.2'
+
+The constraint is read as terraform reads it, operators are =, !=, >, >=, <, <= and ~>, and may be joined with commas. The highest matching version is chosen, as terraform would, and every matching version is listed.
+A prerelease only matches a constraint that names a prerelease of the same version.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+					{
+						Name:   "update",
+						Handle: buildHandler(module_update),
+						Descriptions: clapp.Descriptions{
+							Short: "Update the repository details of a module.",
+							Long: `Updates the repository URL, path, default branch, tag pattern and breaking changes policy of a module, defined by an ID or a ModuleFQN.
+Only the options that are supplied are changed. New versions of the module inherit these values.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "repository-url",
+								Description: "The URL of the git repository containing the module.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "path",
+								Description: "The directory containing the module, relative to the root of the repository.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "default-branch",
+								Description: "The branch that is used when no ref is supplied.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "tag-pattern",
+								Description: "The git tags that versions of the module are published from, e.g. modules/vpc/v{version}. Used by 'ymir sync tags'.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "breaking-changes",
+								Description: "What happens to a minor or patch release that breaks the module's interface, one of: reject, warn.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+					{
+						Name:   "publish",
+						Handle: buildHandler(module_publish),
+						Descriptions: clapp.Descriptions{
+							Short: "Publish a version of a module from its repository.",
+							Long: `Publishes a new version of a module, defined by an ID or a ModuleFQN, followed by the version:
+
+  ymir module publish aws/platform/vpc 1.0.0 --ref v1.0.0
+
+The ref (a branch, tag or commit) is resolved to the commit it currently points to, so the version never changes. When no ref is supplied the module's default branch is used.
+An archive of the module is then built by the server in the background, use --wait to wait until the version is ready.
+
+Exits non-zero if the version could not be published, or if the build failed while waiting.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "ref",
+								Description: "The branch, tag or commit to publish.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "wait",
+								Short:       "w",
+								Description: "Wait until the version is ready, or the build has failed.",
+								ValueRef:    gopoint.ToBool(false),
+								Required:    false,
+								Type:        clapp.BoolFlag,
+							},
+							{
+								Name:        "timeout",
+								Description: "Seconds to wait for the version to be ready. Default: 300",
+								ValueRef:    gopoint.ToInt(300),
+								Required:    false,
+								Type:        clapp.IntFlag,
+							},
+						},
+					},
 					{
 						Name:   "delete",
 						Handle: buildHandler(module_delete),
@@ -339,6 +471,40 @@ Output can be tabular, or JSON depending on options provided.`,
 							Short: "Show details about a single module.",
 							Long: `Show information about a single module, defined by an ID or a ModuleFQN.
 An ID or an FQN must be supplied.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "interface",
+								Description: "Show the variables, outputs, providers, resources and module calls parsed from the version.",
+								ValueRef:    gopoint.ToBool(false),
+								Required:    false,
+								Type:        clapp.BoolFlag,
+							},
+						},
+					},
+					{
+						Name:   "diff",
+						Handle: buildHandler(module_version_diff),
+						Descriptions: clapp.Descriptions{
+							Short: "Show how the interface of a module version changed.",
+							Long: `Compares the interface of a module version, defined by an ID or a ModuleVersionFQN, with the release before it:
+
+  ymir module-version diff aws/platform/vpc@1.4.0
+
+Or compares two versions, from the first to the second:
+
+  ymir module-version diff aws/platform/vpc@1.2.0 aws/platform/vpc@1.4.0
+
+Changes to variables, outputs, required providers and the required terraform version are listed, along with whether they break callers of the earlier version.
+Only a major release may contain breaking changes, a minor or patch release that does is rejected, or warned on, according to the module's breaking changes policy.`,
 						},
 						LocalFlags: []clapp.Flag{
 							{
@@ -373,6 +539,760 @@ A ModuleVersion ID or ModuleVersionFQN must be supplied.`,
 					},
 				},
 			},
+			{
+				Name:   "discover",
+				Handle: buildHandler(discover),
+				Descriptions: clapp.Descriptions{
+					Short: "Discover and register the modules in a mono-repo.",
+					Long: `Walks a repository (a git URL or a local path) at the given ref, and registers every module that does not already exist.
+
+A directory containing .tf files is treated as a module. Directories nested within a module are treated as part of it, unless they contain their own ymir.module.yaml.
+The provider, namespace and name of a module are read from a ymir.module.yaml file in its directory when present:
+
+  provider: aws
+  namespace: platform
+  name: vpc
+
+Otherwise the defaults supplied via options are used, and the name is taken from the directory.
+
+A report of new, existing and conflicting modules is output.`,
+				},
+				LocalFlags: []clapp.Flag{
+					{
+						Name:        "repo",
+						Short:       "r",
+						Description: "The git URL, or local path, of the repository to discover modules in.",
+						ValueRef:    gopoint.ToString(""),
+						Required:    true,
+						Type:        clapp.StringFlag,
+					},
+					{
+						Name:        "ref",
+						Description: "The branch, tag or commit to discover modules at. Default: the default branch, or the working tree of a local path.",
+						ValueRef:    gopoint.ToString(""),
+						Required:    false,
+						Type:        clapp.StringFlag,
+					},
+					{
+						Name:        "namespace",
+						Short:       "n",
+						Description: "The namespace to use for modules without one in their marker file.",
+						ValueRef:    gopoint.ToString(""),
+						Required:    false,
+						Type:        clapp.StringFlag,
+					},
+					{
+						Name:        "provider",
+						Short:       "p",
+						Description: "The provider to use for modules without one in their marker file.",
+						ValueRef:    gopoint.ToString(""),
+						Required:    false,
+						Type:        clapp.StringFlag,
+					},
+					{
+						Name:        "dry-run",
+						Description: "Report what would be registered, without registering anything.",
+						ValueRef:    gopoint.ToBool(false),
+						Required:    false,
+						Type:        clapp.BoolFlag,
+					},
+					{
+						Name:        "output",
+						Short:       "o",
+						Description: "The output style to use, one of: json, table. Default: table",
+						ValueRef:    gopoint.ToString(""),
+						Required:    false,
+						Type:        clapp.StringFlag,
+					},
+				},
+			},
+			{
+				Name: "sync",
+				Descriptions: clapp.Descriptions{
+					Short: "Contains commands to sync the registry with git.",
+					Long:  `See help for available commands.`,
+				},
+				Children: []clapp.Command{
+					{
+						Name:   "tags",
+						Handle: buildHandler(sync_tags),
+						Descriptions: clapp.Descriptions{
+							Short: "Publish versions of modules from git tags.",
+							Long: `Lists the tags of every repository containing modules, and creates the versions that are missing.
+
+A module is only synced when it has a tag pattern, containing {version} where the version appears in the tag:
+
+  ymir module update aws/platform/vpc --tag-pattern 'modules/vpc/v{version}'
+
+The tag modules/vpc/v1.4.0 would then publish version 1.4.0 at the commit it points to. Versions are validated as usual, so tags that aren't valid versions are reported as invalid.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "repo",
+								Short:       "r",
+								Description: "Only sync modules in this repository.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "dry-run",
+								Description: "Report the versions that would be created, without creating them.",
+								ValueRef:    gopoint.ToBool(false),
+								Required:    false,
+								Type:        clapp.BoolFlag,
+							},
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "token",
+				Descriptions: clapp.Descriptions{
+					Short: "Contains commands to manage API tokens.",
+					Long: `See help for available commands.
+
+Requests to the management API (/api/v1) must send a token in the Authorization header:
+
+  curl -H "Authorization: Bearer ymir_..." https://<ymir-host>/api/v1/modules
+
+The scopes a token can have are: modules:read, modules:write (which includes modules:read), versions:publish and admin (which includes every other scope). Any scope but admin can be limited to a namespace as <scope>:<namespace>, a token with modules:read:acme can only read the modules in acme.`,
+				},
+				Children: []clapp.Command{
+					{
+						Name:   "create",
+						Handle: buildHandler(token_create),
+						Descriptions: clapp.Descriptions{
+							Short: "Create an API token.",
+							Long: `Creates a token with the given scopes, the token is only shown once.
+
+  ymir token create --name ci --scope versions:publish --expires-in 2160h`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "name",
+								Description: "What the token is for.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    true,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "scope",
+								Short:       "s",
+								Description: "A scope to grant the token, may be repeated.",
+								ValueRef:    &[]string{},
+								Required:    false,
+								Type:        clapp.StringSliceFlag,
+							},
+							{
+								Name:        "expires-in",
+								Description: "How long until the token expires, e.g. 720h. Default: never",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+					{
+						Name:   "list",
+						Handle: buildHandler(token_list),
+						Descriptions: clapp.Descriptions{
+							Short: "List the API tokens.",
+							Long:  `Output can be tabular, or JSON depending on options provided. Revoked and expired tokens are listed too.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+					{
+						Name:   "revoke",
+						Handle: buildHandler(token_revoke),
+						Descriptions: clapp.Descriptions{
+							Short: "Revoke an API token.",
+							Long:  `Revokes the token with the given ID, it can no longer be used. The token is kept, so the audit log can still be attributed to it.`,
+						},
+					},
+				},
+			},
+			{
+				Name: "user",
+				Descriptions: clapp.Descriptions{
+					Short: "Contains commands to manage the users that can log in with terraform login.",
+					Long: `See help for available commands.
+
+With auth.login.enabled set, users can get a token with:
+
+  terraform login <ymir-host>
+
+The token has the scopes the user was created with.`,
+				},
+				Children: []clapp.Command{
+					{
+						Name:   "create",
+						Handle: buildHandler(user_create),
+						Descriptions: clapp.Descriptions{
+							Short: "Create a user.",
+							Long: `Creates a user with the given username, the password is prompted for.
+
+  ymir user create alice --scope modules:read:acme`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "scope",
+								Short:       "s",
+								Description: "A scope to grant the tokens issued to the user, may be repeated.",
+								ValueRef:    &[]string{},
+								Required:    false,
+								Type:        clapp.StringSliceFlag,
+							},
+						},
+					},
+					{
+						Name:   "list",
+						Handle: buildHandler(user_list),
+						Descriptions: clapp.Descriptions{
+							Short: "List the users.",
+							Long:  `Output can be tabular, or JSON depending on options provided.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+					{
+						Name:   "delete",
+						Handle: buildHandler(user_delete),
+						Descriptions: clapp.Descriptions{
+							Short: "Delete a user.",
+							Long:  `Deletes the user with the given ID, the tokens they were issued are revoked and the roles granted to them removed.`,
+						},
+					},
+				},
+			},
+			{
+				Name: "namespace",
+				Descriptions: clapp.Descriptions{
+					Short: "Contains commands to manage namespaces, and the roles granted in them.",
+					Long: `See help for available commands.
+
+Roles are granted to users (user:<username>), teams (team:<name>) or API tokens (token:<id>) in a namespace, on top of the scopes they have:
+
+  reader      can read the modules and providers in the namespace
+  publisher   can also publish versions
+  maintainer  can also add, change and delete modules and versions
+  admin       can also grant roles in the namespace, the namespace's owners have it
+
+Namespaces are added as modules and providers are added to them, without any owners.`,
+				},
+				Children: []clapp.Command{
+					{
+						Name:   "create",
+						Handle: buildHandler(namespace_create),
+						Descriptions: clapp.Descriptions{
+							Short: "Create a namespace.",
+							Long: `Creates a namespace, its owners are granted the admin role in it.
+
+  ymir namespace create platform --owner team:platform`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "owner",
+								Description: "A user, team or token to own the namespace, may be repeated.",
+								ValueRef:    &[]string{},
+								Required:    true,
+								Type:        clapp.StringSliceFlag,
+							},
+						},
+					},
+					{
+						Name:   "list",
+						Handle: buildHandler(namespace_list),
+						Descriptions: clapp.Descriptions{
+							Short: "List the namespaces.",
+							Long:  `Output can be tabular, or JSON depending on options provided.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+					{
+						Name:   "show",
+						Handle: buildHandler(namespace_show),
+						Descriptions: clapp.Descriptions{
+							Short: "Show a namespace.",
+							Long:  `Shows the namespace with the given name, and every role granted in it.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+					{
+						Name:   "delete",
+						Handle: buildHandler(namespace_delete),
+						Descriptions: clapp.Descriptions{
+							Short: "Delete a namespace.",
+							Long:  `Deletes the namespace with the given name, and the roles granted in it. Its modules must be deleted first.`,
+						},
+					},
+					{
+						Name:   "grant",
+						Handle: buildHandler(namespace_grant),
+						Descriptions: clapp.Descriptions{
+							Short: "Grant a role in a namespace.",
+							Long: `Grants a role in the namespace, replacing the role the user, team or token had there.
+
+  ymir namespace grant platform user:alice publisher`,
+						},
+					},
+					{
+						Name:   "revoke",
+						Handle: buildHandler(namespace_revoke),
+						Descriptions: clapp.Descriptions{
+							Short: "Revoke a role in a namespace.",
+							Long: `Removes the role the user, team or token has in the namespace.
+
+  ymir namespace revoke platform user:alice`,
+						},
+					},
+				},
+			},
+			{
+				Name: "team",
+				Descriptions: clapp.Descriptions{
+					Short: "Contains commands to manage teams of users.",
+					Long: `See help for available commands.
+
+A role granted to a team, as team:<name>, is granted to each of its members.`,
+				},
+				Children: []clapp.Command{
+					{
+						Name:   "create",
+						Handle: buildHandler(team_create),
+						Descriptions: clapp.Descriptions{
+							Short: "Create a team.",
+							Long:  `Creates a team with the given name, and no members.`,
+						},
+					},
+					{
+						Name:   "list",
+						Handle: buildHandler(team_list),
+						Descriptions: clapp.Descriptions{
+							Short: "List the teams.",
+							Long:  `Output can be tabular, or JSON depending on options provided.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+					{
+						Name:   "delete",
+						Handle: buildHandler(team_delete),
+						Descriptions: clapp.Descriptions{
+							Short: "Delete a team.",
+							Long:  `Deletes the team with the given name, and the roles granted to it.`,
+						},
+					},
+					{
+						Name:   "add-member",
+						Handle: buildHandler(team_add_member),
+						Descriptions: clapp.Descriptions{
+							Short: "Add a user to a team.",
+							Long: `Adds the user with the given username to the team.
+
+  ymir team add-member platform alice`,
+						},
+					},
+					{
+						Name:   "remove-member",
+						Handle: buildHandler(team_remove_member),
+						Descriptions: clapp.Descriptions{
+							Short: "Remove a user from a team.",
+							Long:  `Removes the user with the given username from the team.`,
+						},
+					},
+				},
+			},
+			{
+				Name: "webhook",
+				Descriptions: clapp.Descriptions{
+					Short: "Contains commands to manage subscriptions to the registry's events.",
+					Long: `See help for available commands.
+
+Subscribers are sent a POST of each event as JSON, signed with the subscription's secret in the X-Ymir-Signature-256 header. The events are: module_version.ready, module_version.failed and module_version.deleted.`,
+				},
+				Children: []clapp.Command{
+					{
+						Name:   "add",
+						Handle: buildHandler(webhook_add),
+						Descriptions: clapp.Descriptions{
+							Short: "Subscribe a URL to the registry's events.",
+							Long: `Subscribes a URL to the events matching the filters, with no filters it is sent every event.
+
+  ymir webhook add --url https://bot.example.com/ymir --event module_version.ready --namespace platform`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "url",
+								Description: "The URL events are posted to.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    true,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "secret",
+								Description: "The secret payloads are signed with, one is generated if not supplied.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "event",
+								Short:       "e",
+								Description: "Only send events of this type, may be repeated.",
+								ValueRef:    &[]string{},
+								Required:    false,
+								Type:        clapp.StringSliceFlag,
+							},
+							{
+								Name:        "namespace",
+								Short:       "n",
+								Description: "Only send events for modules in this namespace, may be repeated.",
+								ValueRef:    &[]string{},
+								Required:    false,
+								Type:        clapp.StringSliceFlag,
+							},
+						},
+					},
+					{
+						Name:   "list",
+						Handle: buildHandler(webhook_list),
+						Descriptions: clapp.Descriptions{
+							Short: "List the webhook subscriptions.",
+							Long:  `Output can be tabular, or JSON depending on options provided.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+					{
+						Name:   "delete",
+						Handle: buildHandler(webhook_delete),
+						Descriptions: clapp.Descriptions{
+							Short: "Delete a webhook subscription.",
+							Long:  `Deletes the subscription with the given ID, along with its deliveries. Pending deliveries are not sent.`,
+						},
+					},
+					{
+						Name:   "deliveries",
+						Handle: buildHandler(webhook_deliveries),
+						Descriptions: clapp.Descriptions{
+							Short: "Show the delivery log of a webhook subscription.",
+							Long:  `Lists the most recent deliveries to the subscription with the given ID, with the outcome of their last attempt.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "limit",
+								Short:       "l",
+								Description: "The number of deliveries to show.",
+								ValueRef:    gopoint.ToInt(20),
+								Required:    false,
+								Type:        clapp.IntFlag,
+							},
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "provider",
+				Descriptions: clapp.Descriptions{
+					Short: "Contains commands to manage terraform providers and their signing keys.",
+					Long: `See help for available commands.
+
+Provider releases are signed by a gpg key registered for their namespace, terraform verifies the signature when installing them.`,
+				},
+				Children: []clapp.Command{
+					{
+						Name:   "upload",
+						Handle: buildHandler(provider_upload),
+						Descriptions: clapp.Descriptions{
+							Short: "Upload a release of a provider.",
+							Long: `Uploads the release zips of a provider version, as built by goreleaser, along with the SHA256SUMS file and its detached signature.
+
+  ymir provider upload platform/internal 1.0.0 terraform-provider-internal_1.0.0_linux_amd64.zip \
+    --shasums terraform-provider-internal_1.0.0_SHA256SUMS \
+    --signature terraform-provider-internal_1.0.0_SHA256SUMS.sig`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "shasums",
+								Description: "Path to the SHA256SUMS file of the release.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    true,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "signature",
+								Description: "Path to the detached gpg signature of the SHA256SUMS file.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    true,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "protocol",
+								Short:       "p",
+								Description: "A plugin protocol version the provider supports, may be repeated. Default: 5.0",
+								ValueRef:    &[]string{},
+								Required:    false,
+								Type:        clapp.StringSliceFlag,
+							},
+						},
+					},
+					{
+						Name:   "versions",
+						Handle: buildHandler(provider_versions),
+						Descriptions: clapp.Descriptions{
+							Short: "List the versions of a provider.",
+							Long:  `The provider is given as {namespace}/{type}. Output can be tabular, or JSON depending on options provided.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+					{
+						Name: "key",
+						Descriptions: clapp.Descriptions{
+							Short: "Contains commands to manage the gpg keys provider releases are signed with.",
+							Long:  `See help for available commands.`,
+						},
+						Children: []clapp.Command{
+							{
+								Name:   "add",
+								Handle: buildHandler(provider_key_add),
+								Descriptions: clapp.Descriptions{
+									Short: "Register a gpg public key for a namespace.",
+									Long: `The key must be ASCII armored, e.g. the output of: gpg --armor --export <key-id>
+
+  ymir provider key add platform --file key.asc`,
+								},
+								LocalFlags: []clapp.Flag{
+									{
+										Name:        "file",
+										Short:       "f",
+										Description: "Path to the ASCII armored public key.",
+										ValueRef:    gopoint.ToString(""),
+										Required:    true,
+										Type:        clapp.StringFlag,
+									},
+								},
+							},
+							{
+								Name:   "list",
+								Handle: buildHandler(provider_key_list),
+								Descriptions: clapp.Descriptions{
+									Short: "List the registered gpg keys.",
+									Long:  `Output can be tabular, or JSON depending on options provided.`,
+								},
+								LocalFlags: []clapp.Flag{
+									{
+										Name:        "namespace",
+										Short:       "n",
+										Description: "Only list the keys of this namespace.",
+										ValueRef:    gopoint.ToString(""),
+										Required:    false,
+										Type:        clapp.StringFlag,
+									},
+									{
+										Name:        "output",
+										Short:       "o",
+										Description: "The output style to use, one of: json, table. Default: table",
+										ValueRef:    gopoint.ToString(""),
+										Required:    false,
+										Type:        clapp.StringFlag,
+									},
+								},
+							},
+							{
+								Name:   "delete",
+								Handle: buildHandler(provider_key_delete),
+								Descriptions: clapp.Descriptions{
+									Short: "Delete a gpg key.",
+									Long:  `Deletes the key with the given ID, a key cannot be deleted while provider versions signed with it remain.`,
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "usage",
+				Descriptions: clapp.Descriptions{
+					Short: "Contains commands to find where registry modules are used.",
+					Long:  `See help for available commands.`,
+				},
+				Children: []clapp.Command{
+					{
+						Name:   "scan",
+						Handle: buildHandler(usage_scan),
+						Descriptions: clapp.Descriptions{
+							Short: "Report outdated versions of registry modules used by a terraform codebase.",
+							Long: `Parses every directory beneath the given one for module blocks, with a source on the registry's hostname:
+
+  module "vpc" {
+    source  = "registry.example.com/platform/vpc/aws"
+    version = "~> 1.0"
+  }
+
+The version constraint of each is resolved against the module's ready versions, and compared with its latest release.
+An upgrade is breaking when the interfaces of the two versions differ in a breaking way, or when they have not been parsed and semver allows it.
+
+Directories that can't be parsed are reported and skipped. With --fail-on the command exits non-zero, for use in CI.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "hostname",
+								Description: "The hostname module sources use for this registry. Default: server.hostname from the config",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "fail-on",
+								Description: "Exit non-zero when any usage is, one of: outdated, breaking.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "mirror",
+				Descriptions: clapp.Descriptions{
+					Short: "Contains commands to populate the provider network mirror.",
+					Long: `See help for available commands.
+
+Terraform can install providers from the mirror, instead of their own registries, with:
+
+  provider_installation {
+    network_mirror {
+      url = "https://<ymir-host>/mirror/v1/providers/"
+    }
+  }`,
+				},
+				Children: []clapp.Command{
+					{
+						Name:   "provider",
+						Handle: buildHandler(mirror_provider),
+						Descriptions: clapp.Descriptions{
+							Short: "Import provider zips from a local directory into the mirror.",
+							Long: `Imports every provider release zip found beneath the directory. The output of 'terraform providers mirror' can be imported as it is:
+
+  terraform providers mirror ./providers
+  ymir mirror provider ./providers
+
+Zips outside of its {hostname}/{namespace}/{type} layout are imported into the namespace given by --namespace.`,
+						},
+						LocalFlags: []clapp.Flag{
+							{
+								Name:        "hostname",
+								Description: "The registry zips outside of the mirror layout come from. Default: registry.terraform.io",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "namespace",
+								Short:       "n",
+								Description: "The namespace zips outside of the mirror layout belong to.",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+							{
+								Name:        "output",
+								Short:       "o",
+								Description: "The output style to use, one of: json, table. Default: table",
+								ValueRef:    gopoint.ToString(""),
+								Required:    false,
+								Type:        clapp.StringFlag,
+							},
+						},
+					},
+				},
+			},
 			{
 				Name: "migrate",
 				Descriptions: clapp.Descriptions{
@@ -3,7 +3,12 @@ package ymir
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/svartlfheim/gomigrator"
 	"github.com/svartlfheim/ymir/internal/db"
@@ -89,12 +94,603 @@ CREATE INDEX idx_audit_logs_action ON audit_logs(action);`
 			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
 				dropTable := `DROP TABLE audit_logs;`
 
+				return tx.Exec(dropTable)
+			},
+		},
+		{
+			Id:   "add-module-source-columns",
+			Name: "add repository url, path and default branch to modules",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `ALTER TABLE modules
+	ADD COLUMN repository_url TEXT NOT NULL DEFAULT '',
+	ADD COLUMN path TEXT NOT NULL DEFAULT '',
+	ADD COLUMN default_branch TEXT NOT NULL DEFAULT '';`
+
+				return tx.Exec(alterTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `ALTER TABLE modules
+	DROP COLUMN repository_url,
+	DROP COLUMN path,
+	DROP COLUMN default_branch;`
+
+				return tx.Exec(alterTable)
+			},
+		},
+		{
+			Id:   "add-module-version-timestamps",
+			Name: "add created and updated timestamps to module versions",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `ALTER TABLE module_versions
+	ADD COLUMN created_at timestamp with time zone NOT NULL DEFAULT now(),
+	ADD COLUMN updated_at timestamp with time zone NOT NULL DEFAULT now();
+CREATE INDEX idx_module_versions_status_created_at ON module_versions(status, created_at);`
+
+				return tx.Exec(alterTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `DROP INDEX idx_module_versions_status_created_at;
+ALTER TABLE module_versions
+	DROP COLUMN created_at,
+	DROP COLUMN updated_at;`
+
+				return tx.Exec(alterTable)
+			},
+		},
+		{
+			Id:   "add-module-tag-pattern",
+			Name: "add tag pattern to modules",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `ALTER TABLE modules
+	ADD COLUMN tag_pattern TEXT NOT NULL DEFAULT '';`
+
+				return tx.Exec(alterTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `ALTER TABLE modules
+	DROP COLUMN tag_pattern;`
+
+				return tx.Exec(alterTable)
+			},
+		},
+		{
+			Id:   "create-webhook-tables",
+			Name: "create webhook subscription, delivery and delivery attempt tables",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTables := `CREATE TABLE webhook_subscriptions(
+	id uuid NOT NULL,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	event_types JSONB DEFAULT '[]'::jsonb,
+	namespaces JSONB DEFAULT '[]'::jsonb,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id)
+);
+CREATE TABLE webhook_deliveries(
+	id uuid NOT NULL,
+	subscription_id uuid NOT NULL,
+	event_id uuid NOT NULL,
+	event_type TEXT NOT NULL,
+	payload JSONB NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at timestamp with time zone NOT NULL DEFAULT now(),
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	updated_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	CONSTRAINT fk_webhook_subscription FOREIGN KEY(subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_webhook_deliveries_status_next_attempt_at ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX idx_webhook_deliveries_subscription_id ON webhook_deliveries(subscription_id);
+CREATE TABLE webhook_delivery_attempts(
+	id uuid NOT NULL,
+	delivery_id uuid NOT NULL,
+	attempted_at timestamp with time zone NOT NULL,
+	response_code INTEGER NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT '',
+	duration_ms BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY(id),
+	CONSTRAINT fk_webhook_delivery FOREIGN KEY(delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts(delivery_id);`
+
+				return tx.Exec(createTables)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTables := `DROP TABLE webhook_delivery_attempts;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;`
+
+				return tx.Exec(dropTables)
+			},
+		},
+		{
+			Id:   "create-provider-tables",
+			Name: "create provider, provider version, provider platform and gpg key tables",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTables := `CREATE TABLE gpg_keys(
+	id uuid NOT NULL,
+	namespace TEXT NOT NULL,
+	key_id TEXT NOT NULL,
+	ascii_armor TEXT NOT NULL,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(namespace, key_id)
+);
+CREATE TABLE providers(
+	id uuid NOT NULL,
+	namespace TEXT NOT NULL,
+	type TEXT NOT NULL,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(namespace, type)
+);
+CREATE TABLE provider_versions(
+	id uuid NOT NULL,
+	provider_id uuid NOT NULL,
+	version TEXT NOT NULL,
+	protocols JSONB DEFAULT '[]'::jsonb,
+	signing_key_id uuid NOT NULL,
+	shasums_key TEXT NOT NULL,
+	shasums_signature_key TEXT NOT NULL,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(provider_id, version),
+	CONSTRAINT fk_provider FOREIGN KEY(provider_id) REFERENCES providers(id) ON DELETE CASCADE,
+	CONSTRAINT fk_gpg_key FOREIGN KEY(signing_key_id) REFERENCES gpg_keys(id) ON DELETE RESTRICT
+);
+CREATE INDEX idx_provider_versions_signing_key_id ON provider_versions(signing_key_id);
+CREATE TABLE provider_platforms(
+	id uuid NOT NULL,
+	provider_version_id uuid NOT NULL,
+	os TEXT NOT NULL,
+	arch TEXT NOT NULL,
+	filename TEXT NOT NULL,
+	shasum TEXT NOT NULL,
+	storage_key TEXT NOT NULL,
+	PRIMARY KEY(id),
+	UNIQUE(provider_version_id, os, arch),
+	CONSTRAINT fk_provider_version FOREIGN KEY(provider_version_id) REFERENCES provider_versions(id) ON DELETE CASCADE
+);`
+
+				return tx.Exec(createTables)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTables := `DROP TABLE provider_platforms;
+DROP TABLE provider_versions;
+DROP TABLE providers;
+DROP TABLE gpg_keys;`
+
+				return tx.Exec(dropTables)
+			},
+		},
+		{
+			Id:   "create-provider-mirror-table",
+			Name: "create mirrored provider packages table",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTable := `CREATE TABLE mirrored_provider_packages(
+	id uuid NOT NULL,
+	hostname TEXT NOT NULL,
+	namespace TEXT NOT NULL,
+	type TEXT NOT NULL,
+	version TEXT NOT NULL,
+	os TEXT NOT NULL,
+	arch TEXT NOT NULL,
+	filename TEXT NOT NULL,
+	shasum TEXT NOT NULL,
+	hash TEXT NOT NULL,
+	storage_key TEXT NOT NULL,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(hostname, namespace, type, version, os, arch)
+);`
+
+				return tx.Exec(createTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTable := `DROP TABLE mirrored_provider_packages;`
+
+				return tx.Exec(dropTable)
+			},
+		},
+		{
+			Id:   "create-upstream-tables",
+			Name: "create upstream module and upstream module version tables",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTables := `CREATE TABLE upstream_modules(
+	id uuid NOT NULL,
+	upstream_url TEXT NOT NULL,
+	namespace TEXT NOT NULL,
+	name TEXT NOT NULL,
+	provider TEXT NOT NULL,
+	versions JSONB DEFAULT '[]'::jsonb,
+	fetched_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(upstream_url, namespace, name, provider)
+);
+CREATE TABLE upstream_module_versions(
+	id uuid NOT NULL,
+	upstream_module_id uuid NOT NULL,
+	version TEXT NOT NULL,
+	source TEXT NOT NULL,
+	storage_key TEXT NOT NULL,
+	subdir TEXT NOT NULL DEFAULT '',
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(upstream_module_id, version),
+	CONSTRAINT fk_upstream_module FOREIGN KEY(upstream_module_id) REFERENCES upstream_modules(id) ON DELETE CASCADE
+);`
+
+				return tx.Exec(createTables)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTables := `DROP TABLE upstream_module_versions;
+DROP TABLE upstream_modules;`
+
+				return tx.Exec(dropTables)
+			},
+		},
+		{
+			Id:   "create-module-version-interfaces-table",
+			Name: "create module version interfaces table",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTable := `CREATE TABLE module_version_interfaces(
+	module_version_id uuid NOT NULL,
+	interface JSONB NOT NULL,
+	parsed_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(module_version_id),
+	CONSTRAINT fk_module_version FOREIGN KEY(module_version_id) REFERENCES module_versions(id) ON DELETE CASCADE
+);`
+
+				return tx.Exec(createTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTable := `DROP TABLE module_version_interfaces;`
+
+				return tx.Exec(dropTable)
+			},
+		},
+		{
+			Id:   "add-module-breaking-changes",
+			Name: "add breaking change policy to modules",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `ALTER TABLE modules
+	ADD COLUMN breaking_changes TEXT NOT NULL DEFAULT 'reject';`
+
+				return tx.Exec(alterTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `ALTER TABLE modules
+	DROP COLUMN breaking_changes;`
+
+				return tx.Exec(alterTable)
+			},
+		},
+		{
+			Id:   "add-module-version-sort-key",
+			Name: "add semver sort key to module versions",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `ALTER TABLE module_versions
+	ADD COLUMN sort_key TEXT NOT NULL DEFAULT '';`
+
+				res, err := tx.Exec(alterTable)
+
+				if err != nil {
+					return res, err
+				}
+
+				if err := backfillVersionSortKeys(tx); err != nil {
+					return nil, err
+				}
+
+				createIndex := `CREATE INDEX idx_module_versions_sort_key ON module_versions (module_id, sort_key COLLATE "C");`
+
+				return tx.Exec(createIndex)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `DROP INDEX idx_module_versions_sort_key;
+ALTER TABLE module_versions
+	DROP COLUMN sort_key;`
+
+				return tx.Exec(alterTable)
+			},
+		},
+		{
+			Id:   "create-api-tokens-table",
+			Name: "create api tokens table",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTable := `CREATE TABLE api_tokens(
+	id uuid NOT NULL,
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	token_hash TEXT NOT NULL,
+	scopes JSONB DEFAULT '[]'::jsonb,
+	expires_at timestamp with time zone,
+	last_used_at timestamp with time zone,
+	revoked_at timestamp with time zone,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(token_hash)
+);`
+
+				return tx.Exec(createTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTable := `DROP TABLE api_tokens;`
+
+				return tx.Exec(dropTable)
+			},
+		},
+		{
+			Id:   "create-users-table",
+			Name: "create users table",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTable := `CREATE TABLE users(
+	id uuid NOT NULL,
+	username TEXT NOT NULL,
+	password_hash TEXT NOT NULL,
+	scopes JSONB DEFAULT '[]'::jsonb,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(username)
+);`
+
+				return tx.Exec(createTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTable := `DROP TABLE users;`
+
+				return tx.Exec(dropTable)
+			},
+		},
+		{
+			Id:   "create-authorization-codes-table",
+			Name: "create authorization codes table",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTable := `CREATE TABLE authorization_codes(
+	code_hash TEXT NOT NULL,
+	user_id uuid NOT NULL,
+	redirect_uri TEXT NOT NULL,
+	code_challenge TEXT NOT NULL,
+	expires_at timestamp with time zone NOT NULL,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(code_hash),
+	CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);`
+
+				return tx.Exec(createTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTable := `DROP TABLE authorization_codes;`
+
+				return tx.Exec(dropTable)
+			},
+		},
+		{
+			Id:   "add-user-id-to-api-tokens",
+			Name: "add user id to api tokens",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `ALTER TABLE api_tokens ADD COLUMN user_id uuid;`
+
+				return tx.Exec(alterTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				alterTable := `ALTER TABLE api_tokens DROP COLUMN user_id;`
+
+				return tx.Exec(alterTable)
+			},
+		},
+		{
+			Id:   "create-namespaces-table",
+			Name: "create namespaces table",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTable := `CREATE TABLE namespaces(
+	id uuid NOT NULL,
+	name TEXT NOT NULL,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(name)
+);`
+
+				res, err := tx.Exec(createTable)
+
+				if err != nil {
+					return res, err
+				}
+
+				if err := backfillNamespaces(tx); err != nil {
+					return nil, err
+				}
+
+				return res, nil
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTable := `DROP TABLE namespaces;`
+
+				return tx.Exec(dropTable)
+			},
+		},
+		{
+			Id:   "create-teams-table",
+			Name: "create teams table",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTable := `CREATE TABLE teams(
+	id uuid NOT NULL,
+	name TEXT NOT NULL,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(name)
+);`
+
+				return tx.Exec(createTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTable := `DROP TABLE teams;`
+
+				return tx.Exec(dropTable)
+			},
+		},
+		{
+			Id:   "create-team-members-table",
+			Name: "create team members table",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTable := `CREATE TABLE team_members(
+	team_id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	PRIMARY KEY(team_id, user_id),
+	CONSTRAINT fk_team FOREIGN KEY(team_id) REFERENCES teams(id) ON DELETE CASCADE,
+	CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);`
+
+				return tx.Exec(createTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTable := `DROP TABLE team_members;`
+
+				return tx.Exec(dropTable)
+			},
+		},
+		{
+			Id:   "create-role-grants-table",
+			Name: "create role grants table",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTable := `CREATE TABLE role_grants(
+	id uuid NOT NULL,
+	namespace TEXT NOT NULL,
+	subject_type TEXT NOT NULL,
+	subject_id uuid NOT NULL,
+	subject_name TEXT NOT NULL,
+	role TEXT NOT NULL,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(id),
+	UNIQUE(namespace, subject_type, subject_id),
+	CONSTRAINT fk_namespace FOREIGN KEY(namespace) REFERENCES namespaces(name) ON DELETE CASCADE
+);`
+
+				res, err := tx.Exec(createTable)
+
+				if err != nil {
+					return res, err
+				}
+
+				createIndex := `CREATE INDEX idx_role_grants_subject ON role_grants (subject_type, subject_id);`
+
+				return tx.Exec(createIndex)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTable := `DROP TABLE role_grants;`
+
+				return tx.Exec(dropTable)
+			},
+		},
+		{
+			Id:   "create-deleted-module-versions-table",
+			Name: "create deleted module versions table",
+			Execute: func(tx *sqlx.Tx) (sql.Result, error) {
+				createTable := `CREATE TABLE deleted_module_versions(
+	module_id uuid NOT NULL,
+	version TEXT NOT NULL,
+	deleted_at timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY(module_id, version),
+	CONSTRAINT fk_module FOREIGN KEY(module_id) REFERENCES modules(id) ON DELETE CASCADE
+);`
+
+				return tx.Exec(createTable)
+			},
+			Rollback: func(tx *sqlx.Tx) (sql.Result, error) {
+				dropTable := `DROP TABLE deleted_module_versions;`
+
 				return tx.Exec(dropTable)
 			},
 		},
 	},
 )
 
+// backfillVersionSortKeys sets the sort key of the versions that existed
+// before it was added, it can't be worked out in SQL.
+func backfillVersionSortKeys(tx *sqlx.Tx) error {
+	versions := []struct {
+		Id      string `db:"id"`
+		Version string `db:"version"`
+	}{}
+
+	if err := tx.Select(&versions, `SELECT id, version FROM module_versions;`); err != nil {
+		return err
+	}
+
+	for _, mv := range versions {
+		if _, err := tx.Exec(`UPDATE module_versions SET sort_key = $1 WHERE id = $2;`, backfilledVersionSortKey(mv.Version), mv.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var backfilledSemVerPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)` +
+	`(?:-((?:0|[1-9][0-9]*|[0-9]*[A-Za-z-][0-9A-Za-z-]*)(?:\.(?:0|[1-9][0-9]*|[0-9]*[A-Za-z-][0-9A-Za-z-]*))*))?` +
+	`(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// backfilledVersionSortKey is a copy of registry.VersionSortKey as it was when
+// the sort key was added, so the migration gives the same keys even if the
+// registry's keys change later.
+func backfilledVersionSortKey(version string) string {
+	parts := backfilledSemVerPattern.FindStringSubmatch(version)
+
+	if parts == nil {
+		return ""
+	}
+
+	numbers := []uint64{}
+
+	for _, part := range parts[1:4] {
+		n, err := strconv.ParseUint(part, 10, 64)
+
+		if err != nil {
+			return ""
+		}
+
+		numbers = append(numbers, n)
+	}
+
+	key := fmt.Sprintf("%020d.%020d.%020d", numbers[0], numbers[1], numbers[2])
+
+	if parts[4] == "" {
+		return key + "~"
+	}
+
+	ids := []string{}
+
+	for _, id := range strings.Split(parts[4], ".") {
+		if strings.Trim(id, "0123456789") == "" {
+			n, _ := strconv.ParseUint(id, 10, 64)
+			ids = append(ids, fmt.Sprintf("0%020d", n))
+		} else {
+			ids = append(ids, "1"+id)
+		}
+	}
+
+	return key + "-" + strings.Join(ids, "!")
+}
+
+// backfillNamespaces adds the namespaces of the modules and providers that
+// existed before namespaces had their own table, without any owners.
+func backfillNamespaces(tx *sqlx.Tx) error {
+	names := []string{}
+
+	if err := tx.Select(&names, `SELECT namespace FROM modules UNION SELECT namespace FROM providers;`); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, err := tx.Exec(`INSERT INTO namespaces (id, name) VALUES ($1, $2);`, uuid.New().String(), name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func shouldMigrateAll(c YmirCommand) bool {
 	val, err := c.cobra.LocalFlags().GetBool("all")
 
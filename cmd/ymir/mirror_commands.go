@@ -0,0 +1,86 @@
+package ymir
+
+import (
+	"encoding/json"
+
+	"github.com/svartlfheim/ymir/internal/cli"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+func mirror_provider(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+
+	style, err := flags.GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	hostname, err := flags.GetString("hostname")
+
+	if err != nil {
+		o.Error("the 'hostname' option was not configured for this command")
+		return nil
+	}
+
+	ns, err := flags.GetString("namespace")
+
+	if err != nil {
+		o.Error("the 'namespace' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ImportProviderMirrorV1(registry.ImportProviderMirrorV1DTO{
+		Directory: c.GetArg(0, ""),
+		Hostname:  hostname,
+		Namespace: ns,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.Report, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.Report) == 0 {
+			o.Warnln("No provider zips found!")
+			return nil
+		}
+
+		h, r := registry.BuildMirrorImportTable(res.Report)
+		buildTableFactory().CreateAndPrint(h, r)
+
+		o.Successf("Imported: %d\n", res.CountByStatus(registry.MirrorImportStatuses.Imported))
+		o.Infof("Existing: %d\n", res.CountByStatus(registry.MirrorImportStatuses.Existing))
+
+		if ignored := res.CountByStatus(registry.MirrorImportStatuses.Ignored); ignored > 0 {
+			o.Warnf("Ignored: %d\n", ignored)
+		}
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
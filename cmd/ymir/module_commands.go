@@ -1,6 +1,12 @@
 package ymir
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/svartlfheim/ymir/internal/cli"
 	"github.com/svartlfheim/ymir/internal/output"
 	"github.com/svartlfheim/ymir/internal/registry"
 )
@@ -21,7 +27,7 @@ func module_add(c YmirCommand) error {
 
 	cb := buildCommandBus(c)
 
-	res, err := cb.AddModuleV1FromCLI(f)
+	res, err := cb.AddModuleV1FromCLI(c.cobra.Context(), f)
 
 	if err != nil {
 		switch err.(type) {
@@ -50,6 +56,11 @@ func module_add(c YmirCommand) error {
 		o.Successf("Name: %s\n", res.Module.Name)
 		o.Successf("Namespace: %s\n", res.Module.Namespace)
 		o.Successf("Provider: %s\n", res.Module.Provider)
+		o.Successf("Repository URL: %s\n", res.Module.RepositoryURL)
+		o.Successf("Path: %s\n", res.Module.Path)
+		o.Successf("Default Branch: %s\n", res.Module.DefaultBranch)
+		o.Successf("Tag Pattern: %s\n", res.Module.TagPattern)
+		o.Successf("Breaking Changes: %s\n", res.Module.BreakingChanges)
 	default:
 		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
 	}
@@ -83,7 +94,7 @@ func module_list(c YmirCommand) error {
 
 	cb := buildCommandBus(c)
 
-	res, err := cb.ListModulesV1FromCLI(provider, ns)
+	res, err := cb.ListModulesV1FromCLI(c.cobra.Context(), provider, ns)
 
 	if err != nil {
 		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
@@ -122,7 +133,7 @@ func module_show(c YmirCommand) error {
 
 	cb := buildCommandBus(c)
 
-	res, err := cb.ShowModuleV1FromCLI(idOrFQN)
+	res, err := cb.ShowModuleV1FromCLI(c.cobra.Context(), idOrFQN)
 
 	if err != nil {
 		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
@@ -142,6 +153,157 @@ func module_show(c YmirCommand) error {
 		o.Successf("Name: %s\n", res.Module.Name)
 		o.Successf("Namespace: %s\n", res.Module.Namespace)
 		o.Successf("Provider: %s\n", res.Module.Provider)
+		o.Successf("Repository URL: %s\n", res.Module.RepositoryURL)
+		o.Successf("Path: %s\n", res.Module.Path)
+		o.Successf("Default Branch: %s\n", res.Module.DefaultBranch)
+		o.Successf("Tag Pattern: %s\n", res.Module.TagPattern)
+		o.Successf("Breaking Changes: %s\n", res.Module.BreakingChanges)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func module_resolve(c YmirCommand) error {
+	o := c.GetOutput()
+
+	style, err := c.cobra.LocalFlags().GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	idOrFQN := c.GetArg(0, "")
+	constraint := c.GetArg(1, "")
+
+	cb := buildCommandBus(c)
+
+	show, err := cb.ShowModuleV1FromCLI(c.cobra.Context(), idOrFQN)
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	if show.Status != registry.STATUS_OKAY {
+		if show.Status == registry.STATUS_NOT_FOUND {
+			o.Errorln("Module not found!")
+			return nil
+		}
+
+		o.Errorln("Data was invalid!")
+		for _, err := range show.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+		return nil
+	}
+
+	res, err := cb.ResolveModuleVersionV1(c.cobra.Context(), registry.ResolveModuleVersionV1DTO{
+		ModuleId:   show.Module.Id,
+		Constraint: constraint,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_NOT_FOUND:
+		o.Errorln("Module not found!")
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.Resolution, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if res.Resolution.Version == nil {
+			o.Warnf("No ready version matches %s!\n", constraint)
+			return nil
+		}
+
+		o.Successf("%s resolves to %s\n", constraint, res.Resolution.Version.Version)
+		o.Successln("Matching versions:")
+
+		h, r := registry.BuildModuleVersionsTable(res.Resolution.Candidates)
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func module_update(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+	dto := registry.UpdateModuleV1DTO{}
+
+	for name, ref := range map[string]**string{
+		"repository-url":   &dto.RepositoryURL,
+		"path":             &dto.Path,
+		"default-branch":   &dto.DefaultBranch,
+		"tag-pattern":      &dto.TagPattern,
+		"breaking-changes": &dto.BreakingChanges,
+	} {
+		if !flags.Changed(name) {
+			continue
+		}
+
+		val, err := flags.GetString(name)
+
+		if err != nil {
+			o.Errorf("the '%s' option was not configured for this command\n", name)
+			return nil
+		}
+
+		*ref = &val
+	}
+
+	idOrFQN := c.GetArg(0, "")
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.UpdateModuleV1FromCLI(c.cobra.Context(), idOrFQN, dto)
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_NOT_FOUND:
+		o.Errorln("Module not found!")
+	case registry.STATUS_MODIFIED:
+		o.Successln("Successfully updated!")
+		o.Successf("Id: %s\n", res.Module.Id)
+		o.Successf("Name: %s\n", res.Module.Name)
+		o.Successf("Namespace: %s\n", res.Module.Namespace)
+		o.Successf("Provider: %s\n", res.Module.Provider)
+		o.Successf("Repository URL: %s\n", res.Module.RepositoryURL)
+		o.Successf("Path: %s\n", res.Module.Path)
+		o.Successf("Default Branch: %s\n", res.Module.DefaultBranch)
+		o.Successf("Tag Pattern: %s\n", res.Module.TagPattern)
+		o.Successf("Breaking Changes: %s\n", res.Module.BreakingChanges)
 	default:
 		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
 	}
@@ -149,6 +311,142 @@ func module_show(c YmirCommand) error {
 	return nil
 }
 
+// silenced stops cobra repeating the error and usage, the handler has already
+// explained what went wrong.
+func silenced(c YmirCommand, err error) error {
+	c.cobra.SilenceUsage = true
+	c.cobra.SilenceErrors = true
+
+	return err
+}
+
+func failed(c YmirCommand, reason string) error {
+	return silenced(c, ErrPublishFailed{
+		Reason: reason,
+	})
+}
+
+func module_publish(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+
+	ref, err := flags.GetString("ref")
+
+	if err != nil {
+		o.Error("the 'ref' option was not configured for this command")
+		return nil
+	}
+
+	wait, err := flags.GetBool("wait")
+
+	if err != nil {
+		o.Error("the 'wait' option was not configured for this command")
+		return nil
+	}
+
+	timeout, err := flags.GetInt("timeout")
+
+	if err != nil {
+		o.Error("the 'timeout' option was not configured for this command")
+		return nil
+	}
+
+	idOrFQN := c.GetArg(0, "")
+	version := c.GetArg(1, "")
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.PublishModuleVersionV1FromCLI(c.cobra.Context(), idOrFQN, version, ref)
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return failed(c, "unexpected error")
+	}
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+		return failed(c, "invalid data")
+	case registry.STATUS_NOT_FOUND:
+		o.Errorln("Module not found!")
+		return failed(c, "module not found")
+	case registry.STATUS_CREATED:
+		o.Successln("Successfully published!")
+		o.Successf("Id: %s\n", res.ModuleVersion.Id)
+		o.Successf("Version: %s\n", res.ModuleVersion.Version)
+		o.Successf("Commit: %s\n", res.ResolvedRef)
+		o.Successf("Status: %s\n", string(res.ModuleVersion.Status))
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return failed(c, "unexpected error")
+	}
+
+	if !wait {
+		return nil
+	}
+
+	mv, err := waitForModuleVersion(c.cobra.Context(), cb, res.ModuleVersion, time.Duration(timeout)*time.Second)
+
+	if err != nil {
+		o.Errorln(err.Error())
+		return failed(c, err.Error())
+	}
+
+	if mv.Status == registry.VersionStatuses.Failed {
+		o.Errorf("Archive build failed: %s\n", mv.StatusReason)
+		return failed(c, mv.StatusReason)
+	}
+
+	o.Successln("Version is ready!")
+	o.Successf("Download URL: %s\n", mv.DownloadURL)
+
+	return nil
+}
+
+func waitForModuleVersion(ctx context.Context, cb *registry.CommandBus, mv registry.ModuleVersion, timeout time.Duration) (registry.ModuleVersion, error) {
+	s := output.NewSpinner(os.Stdout, "Waiting for the archive to be built ("+string(mv.Status)+")")
+	s.Start()
+	defer s.Stop()
+
+	deadline := time.Now().Add(timeout)
+
+	for !mv.IsSettled() {
+		if time.Now().After(deadline) {
+			return mv, ErrPublishFailed{
+				Reason: "timed out waiting for version to be ready, it is still " + string(mv.Status),
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return mv, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+
+		res, err := cb.ShowModuleVersionV1ById(ctx, registry.ShowModuleVersionV1DTO{
+			Id: mv.Id,
+		})
+
+		if err != nil {
+			return mv, err
+		}
+
+		if res.Status != registry.STATUS_OKAY {
+			return mv, ErrPublishFailed{
+				Reason: "version could not be found while waiting, it may have been deleted",
+			}
+		}
+
+		mv = res.ModuleVersion
+		s.SetMessage("Waiting for the archive to be built (" + string(mv.Status) + ")")
+	}
+
+	return mv, nil
+}
+
 func module_delete(c YmirCommand) error {
 	o := c.GetOutput()
 
@@ -170,7 +468,7 @@ func module_delete(c YmirCommand) error {
 
 	cb := buildCommandBus(c)
 
-	res, err := cb.DeleteModuleV1FromCLI(idOrFQN, deleteVersions, forceDelete)
+	res, err := cb.DeleteModuleV1FromCLI(c.cobra.Context(), idOrFQN, deleteVersions, forceDelete)
 
 	if err != nil {
 		if _, ok := err.(registry.ErrFailedToConfirmAction); ok {
@@ -182,6 +480,8 @@ func module_delete(c YmirCommand) error {
 		return nil
 	}
 
+	recordAction(c, res)
+
 	switch res.Status {
 	case registry.STATUS_INVALID:
 		o.Errorln("Data was invalid!")
@@ -1,6 +1,10 @@
 package ymir
 
 import (
+	"encoding/json"
+	"strings"
+
+	"github.com/svartlfheim/ymir/internal/cli"
 	"github.com/svartlfheim/ymir/internal/output"
 	"github.com/svartlfheim/ymir/internal/registry"
 )
@@ -21,7 +25,7 @@ func module_version_add(c YmirCommand) error {
 
 	cb := buildCommandBus(c)
 
-	res, err := cb.AddModuleVersionV1FromCLI(f)
+	res, err := cb.AddModuleVersionV1FromCLI(c.cobra.Context(), f)
 
 	if err != nil {
 		switch err.(type) {
@@ -74,7 +78,7 @@ func module_version_list(c YmirCommand) error {
 
 	cb := buildCommandBus(c)
 
-	res, err := cb.ListModuleVersionsV1FromCLI(idOrFQN)
+	res, err := cb.ListModuleVersionsV1FromCLI(c.cobra.Context(), idOrFQN)
 
 	if err != nil {
 		if _, ok := err.(registry.ErrCouldNotParseModuleFQN); ok {
@@ -108,18 +112,25 @@ func module_version_list(c YmirCommand) error {
 func module_version_show(c YmirCommand) error {
 	o := c.GetOutput()
 
-	_, err := c.cobra.LocalFlags().GetString("output")
+	style, err := c.cobra.LocalFlags().GetString("output")
 
 	if err != nil {
 		o.Error("the 'output' option was not configured for this command")
 		return nil
 	}
 
+	showInterface, err := c.cobra.LocalFlags().GetBool("interface")
+
+	if err != nil {
+		o.Error("the 'interface' option was not configured for this command")
+		return nil
+	}
+
 	idOrFQN := c.GetArg(0, "")
 
 	cb := buildCommandBus(c)
 
-	res, err := cb.ShowModuleVersionV1FromCLI(idOrFQN)
+	res, err := cb.ShowModuleVersionV1FromCLI(c.cobra.Context(), idOrFQN)
 
 	if err != nil {
 		if _, ok := err.(registry.ErrCouldNotParseModuleVersionFQN); ok {
@@ -140,6 +151,10 @@ func module_version_show(c YmirCommand) error {
 			o.Errorf("%s: %s\n", err.Field, err.Message)
 		}
 	case registry.STATUS_OKAY:
+		if showInterface {
+			return module_version_show_interface(c, cb, res.ModuleVersion, style)
+		}
+
 		o.Successf("Id: %s\n", res.ModuleVersion.Id)
 		o.Successf("Module Id: %s\n", res.ModuleVersion.ModuleId)
 		o.Successf("Version: %s\n", res.ModuleVersion.Version)
@@ -153,6 +168,172 @@ func module_version_show(c YmirCommand) error {
 	return nil
 }
 
+func module_version_show_interface(c YmirCommand, cb *registry.CommandBus, mv registry.ModuleVersion, style string) error {
+	o := c.GetOutput()
+
+	res, err := cb.ShowModuleVersionInterfaceV1(c.cobra.Context(), registry.ShowModuleVersionInterfaceV1DTO{
+		Id: mv.Id,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnf("No interface has been parsed for version %s, it is parsed once the archive is built.\n", mv.Version)
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.Interface, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		iface := res.Interface.Interface
+		tf := buildTableFactory()
+
+		if len(iface.RequiredVersion) > 0 {
+			o.Successf("Required Terraform Version: %s\n", strings.Join(iface.RequiredVersion, ", "))
+		}
+
+		o.Successln("Variables:")
+		h, r := registry.BuildModuleVariablesTable(iface.Variables)
+		tf.CreateAndPrint(h, r)
+
+		o.Successln("Outputs:")
+		h, r = registry.BuildModuleOutputsTable(iface.Outputs)
+		tf.CreateAndPrint(h, r)
+
+		o.Successln("Required Providers:")
+		h, r = registry.BuildProviderRequirementsTable(iface.RequiredProviders)
+		tf.CreateAndPrint(h, r)
+
+		o.Successln("Resources:")
+		h, r = registry.BuildModuleResourcesTable(iface.Resources)
+		tf.CreateAndPrint(h, r)
+
+		o.Successln("Module Calls:")
+		h, r = registry.BuildModuleCallsTable(iface.ModuleCalls)
+		tf.CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func module_version_diff(c YmirCommand) error {
+	o := c.GetOutput()
+
+	style, err := c.cobra.LocalFlags().GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	args := c.GetAllArgs()
+
+	if len(args) == 0 || len(args) > 2 {
+		o.Errorln("supply a version, or two versions to compare")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+	ids := []string{}
+
+	for _, idOrFQN := range args {
+		res, err := cb.ShowModuleVersionV1FromCLI(c.cobra.Context(), idOrFQN)
+
+		if err != nil {
+			if _, ok := err.(registry.ErrCouldNotParseModuleVersionFQN); ok {
+				o.Errorln(err.Error())
+				return nil
+			}
+
+			o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+			return nil
+		}
+
+		if res.Status == registry.STATUS_NOT_FOUND {
+			o.Warnf("Module version %s not found!\n", idOrFQN)
+			return nil
+		}
+
+		if res.Status != registry.STATUS_OKAY {
+			o.Errorln("Data was invalid!")
+			for _, err := range res.ValidationErrors {
+				o.Errorf("%s: %s\n", err.Field, err.Message)
+			}
+			return nil
+		}
+
+		ids = append(ids, res.ModuleVersion.Id)
+	}
+
+	dto := registry.DiffModuleVersionsV1DTO{
+		Id: ids[len(ids)-1],
+	}
+
+	if len(ids) == 2 {
+		dto.AgainstId = ids[0]
+	}
+
+	res, err := cb.DiffModuleVersionsV1(c.cobra.Context(), dto)
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("No interface has been parsed for one of the versions, they are parsed once the archive is built.")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.Diff, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		o.Successf("Changes from %s to %s:\n", res.Diff.From.Version, res.Diff.To.Version)
+
+		if len(res.Diff.Changes) == 0 {
+			o.Successln("The interface has not changed.")
+			return nil
+		}
+
+		h, r := registry.BuildInterfaceChangesTable(res.Diff.Changes)
+		buildTableFactory().CreateAndPrint(h, r)
+
+		if breaking := registry.BreakingChanges(res.Diff.Changes); len(breaking) > 0 && !res.Diff.BreakingAllowed {
+			o.Warnf("%d breaking change(s), which only a major release may contain.\n", len(breaking))
+		}
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
 func module_version_delete(c YmirCommand) error {
 	o := c.GetOutput()
 
@@ -167,7 +348,7 @@ func module_version_delete(c YmirCommand) error {
 
 	cb := buildCommandBus(c)
 
-	res, err := cb.DeleteModuleVersionV1FromCLI(idOrFQN, forceDelete)
+	res, err := cb.DeleteModuleVersionV1FromCLI(c.cobra.Context(), idOrFQN, forceDelete)
 
 	if err != nil {
 		if _, ok := err.(registry.ErrCouldNotParseModuleVersionFQN); ok {
@@ -179,6 +360,8 @@ func module_version_delete(c YmirCommand) error {
 		return nil
 	}
 
+	recordAction(c, res)
+
 	switch res.Status {
 	case registry.STATUS_NOT_FOUND:
 		o.Warnln("Module version not found!")
@@ -0,0 +1,261 @@
+package ymir
+
+import (
+	"encoding/json"
+
+	"github.com/svartlfheim/ymir/internal/cli"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+func namespace_create(c YmirCommand) error {
+	o := c.GetOutput()
+
+	owners, err := c.cobra.LocalFlags().GetStringSlice("owner")
+
+	if err != nil {
+		o.Error("the 'owner' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.CreateNamespaceV1(registry.CreateNamespaceV1DTO{
+		Name:   c.GetArg(0, ""),
+		Owners: owners,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_CREATED:
+		o.Successln("Successfully created!")
+
+		h, r := registry.BuildNamespacesTable([]registry.Namespace{res.Namespace})
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func namespace_list(c YmirCommand) error {
+	o := c.GetOutput()
+
+	style, err := c.cobra.LocalFlags().GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ListNamespacesV1()
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.List, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.List) == 0 {
+			o.Warnln("No namespaces found!")
+			return nil
+		}
+
+		h, r := registry.BuildNamespacesTable(res.List)
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func namespace_show(c YmirCommand) error {
+	o := c.GetOutput()
+
+	style, err := c.cobra.LocalFlags().GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ShowNamespaceV1(registry.ShowNamespaceV1DTO{
+		Name: c.GetArg(0, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("Namespace not found!")
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(map[string]interface{}{
+				"namespace": res.Namespace,
+				"grants":    res.Grants,
+			}, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		h, r := registry.BuildNamespacesTable([]registry.Namespace{res.Namespace})
+		buildTableFactory().CreateAndPrint(h, r)
+
+		if len(res.Grants) == 0 {
+			o.Warnln("No roles granted!")
+			return nil
+		}
+
+		h, r = registry.BuildRoleGrantsTable(res.Grants)
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func namespace_delete(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.DeleteNamespaceV1(c.cobra.Context(), registry.DeleteNamespaceV1DTO{
+		Name: c.GetArg(0, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("Namespace not found!")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		o.Successln("Successfully deleted!")
+		o.Successf("Name: %s\n", res.Namespace.Name)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func namespace_grant(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.GrantRoleV1(registry.GrantRoleV1DTO{
+		Namespace: c.GetArg(0, ""),
+		Subject:   c.GetArg(1, ""),
+		Role:      c.GetArg(2, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("Namespace not found!")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_CREATED, registry.STATUS_MODIFIED:
+		o.Successln("Successfully granted!")
+
+		h, r := registry.BuildRoleGrantsTable([]registry.RoleGrant{res.Grant})
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func namespace_revoke(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.RevokeRoleV1(registry.RevokeRoleV1DTO{
+		Namespace: c.GetArg(0, ""),
+		Subject:   c.GetArg(1, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("The subject has no role in the namespace!")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		o.Successln("Successfully revoked!")
+		o.Successf("Role: %s\n", res.Grant.Role)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package ymir
+
+import (
+	"encoding/json"
+
+	"github.com/svartlfheim/ymir/internal/cli"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+func provider_upload(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+
+	shasums, err := flags.GetString("shasums")
+
+	if err != nil {
+		o.Error("the 'shasums' option was not configured for this command")
+		return nil
+	}
+
+	signature, err := flags.GetString("signature")
+
+	if err != nil {
+		o.Error("the 'signature' option was not configured for this command")
+		return nil
+	}
+
+	protocols, err := flags.GetStringSlice("protocol")
+
+	if err != nil {
+		o.Error("the 'protocol' option was not configured for this command")
+		return nil
+	}
+
+	fqn, err := registry.ParseProviderFQN(c.GetArg(0, ""))
+
+	if err != nil {
+		o.Errorln(err.Error())
+		return nil
+	}
+
+	args := c.GetAllArgs()
+	archives := []string{}
+
+	if len(args) > 2 {
+		archives = args[2:]
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.UploadProviderVersionV1FromCLI(fqn, c.GetArg(1, ""), protocols, shasums, signature, archives)
+
+	if err != nil {
+		if _, ok := err.(registry.ErrCouldNotReadFile); ok {
+			o.Errorln(err.Error())
+			return nil
+		}
+
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_CREATED:
+		o.Successln("Successfully uploaded!")
+		o.Successf("Provider: %s\n", res.Provider.FQN().String())
+		o.Successf("Id: %s\n", res.ProviderVersion.Id)
+		o.Successf("Version: %s\n", res.ProviderVersion.Version)
+
+		for _, p := range res.ProviderVersion.Platforms {
+			o.Successf("Platform: %s_%s\n", p.OS, p.Arch)
+		}
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func provider_versions(c YmirCommand) error {
+	o := c.GetOutput()
+
+	style, err := c.cobra.LocalFlags().GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	fqn, err := registry.ParseProviderFQN(c.GetArg(0, ""))
+
+	if err != nil {
+		o.Errorln(err.Error())
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ListProviderVersionsV1(registry.ListProviderVersionsV1DTO{
+		FQN: fqn,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("Provider not found!")
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.List, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.List) == 0 {
+			o.Warnln("No versions found for this provider!")
+			return nil
+		}
+
+		h, r := registry.BuildProviderVersionsTable(res.Provider, res.List)
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func provider_key_add(c YmirCommand) error {
+	o := c.GetOutput()
+
+	file, err := c.cobra.LocalFlags().GetString("file")
+
+	if err != nil {
+		o.Error("the 'file' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.AddGPGKeyV1FromCLI(c.GetArg(0, ""), file)
+
+	if err != nil {
+		if _, ok := err.(registry.ErrCouldNotReadFile); ok {
+			o.Errorln(err.Error())
+			return nil
+		}
+
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_CREATED:
+		o.Successln("Successfully created!")
+		o.Successf("Id: %s\n", res.Key.Id)
+		o.Successf("Namespace: %s\n", res.Key.Namespace)
+		o.Successf("Key ID: %s\n", res.Key.KeyId)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func provider_key_list(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+
+	style, err := flags.GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	namespace, err := flags.GetString("namespace")
+
+	if err != nil {
+		o.Error("the 'namespace' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ListGPGKeysV1(registry.ListGPGKeysV1DTO{
+		Namespace: namespace,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.List, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.List) == 0 {
+			o.Warnln("No gpg keys found!")
+			return nil
+		}
+
+		h, r := registry.BuildGPGKeysTable(res.List)
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func provider_key_delete(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.DeleteGPGKeyV1(registry.DeleteGPGKeyV1DTO{
+		Id: c.GetArg(0, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("GPG key not found!")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		o.Successln("Successfully deleted!")
+		o.Successf("Id: %s\n", res.Key.Id)
+		o.Successf("Key ID: %s\n", res.Key.KeyId)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
@@ -1,11 +1,19 @@
 package ymir
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/handlers"
+	"github.com/svartlfheim/ymir/internal/registry"
 	"github.com/svartlfheim/ymir/internal/server"
 )
 
@@ -13,28 +21,178 @@ func serve(cmd YmirCommand) error {
 	cfg := cmd.GetConfig()
 	l := cmd.GetLogger()
 
+	// Done on SIGTERM, which stops the workers claiming more work
+	ctx, stop := signal.NotifyContext(cmd.cobra.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workers := sync.WaitGroup{}
+	background := func(run func()) {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+			run()
+		}()
+	}
+
 	moduleRepo, err := buildModuleRepository(cmd.GetConfig(), cmd.cobra.Context(), l)
 
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to build module repository")
 	}
 
-	a, err := buildAuditor(cfg, cmd.cobra.Context(), l)
+	webhookRepo, err := buildWebhookRepository(cfg, cmd.cobra.Context(), l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build webhook repository")
+	}
+
+	m, err := buildMetrics(cfg, moduleRepo, webhookRepo)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build metrics")
+	}
+
+	a, err := buildAuditor(cfg, cmd.cobra.Context(), l, registry.WithObserver(m))
 
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to build auditor")
 	}
 
+	store, err := buildStorage(cfg, cmd.cobra.Context())
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build storage")
+	}
+
+	archiveWorker := buildArchiveWorker(cfg, moduleRepo, store, a, l)
+	background(func() { archiveWorker.Run(ctx) })
+
+	deliveryWorker := buildWebhookDeliveryWorker(cfg, webhookRepo, l)
+	background(func() { deliveryWorker.Run(ctx) })
+
 	cb := buildCommandBus(cmd)
-	h := server.NewServer([]server.Controller{
+
+	if cfg.Sync.Interval > 0 {
+		background(func() {
+			syncTagsPeriodically(ctx, cb, a, time.Duration(cfg.Sync.Interval)*time.Second, l)
+		})
+	}
+
+	signer, err := buildArchiveSigner(cfg)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build archive url signer")
+	}
+
+	controllers := []server.Controller{
 		&server.MiscController{},
+		&server.OpenAPIController{},
 		server.NewModulesController(l, cb, a),
-		server.NewModuleRegistryController(l, moduleRepo, cb),
-	})
+		server.NewWebhooksController(l, cb, a, cfg.Webhooks),
+		server.NewWebhookSubscriptionsController(l, cb, a),
+		server.NewProvidersController(l, cb, a),
+		server.NewNamespacesController(l, cb, a),
+		server.NewModuleRegistryController(l, moduleRepo, cb, signer, m),
+		server.NewProviderRegistryController(l, cb),
+		server.NewProviderMirrorController(l, cb),
+		server.NewArchivesController(l, store, signer),
+	}
+
+	healthController, err := buildHealthController(cfg, store, archiveWorker, deliveryWorker, l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build health checks")
+	}
+
+	controllers = append(controllers, healthController)
 
-	fmt.Printf("Listening on %s\n", cfg.Server.Port)
-	err = http.ListenAndServe(":"+cfg.Server.Port, handlers.RecoveryHandler()(handlers.CombinedLoggingHandler(os.Stdout, h)))
+	if cfg.Auth.Login.Enabled {
+		controllers = append(controllers, server.NewLoginController(l, cb, a))
+	}
+
+	metricsSrv := buildMetricsServer(cfg, m)
+
+	if cfg.Metrics.Enabled && metricsSrv == nil {
+		controllers = append(controllers, server.NewMetricsController(m.Handler()))
+	}
+
+	h := server.NewServer(controllers, server.MetricsMiddleware(m))
+
+	reloader, tlsConfig, reloadInterval, err := buildTLSReloader(cfg, cmd.cobra.Context(), l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to load tls config")
+	}
+
+	srv, shutdownTimeout := buildHTTPServer(cfg, handlers.RecoveryHandler()(handlers.CombinedLoggingHandler(os.Stdout, h)), tlsConfig)
+
+	// Requests get their contexts from this rather than the signal, so they
+	// can finish while the server drains
+	requests, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	srv.BaseContext = func(net.Listener) context.Context {
+		return requests
+	}
+
+	if metricsSrv != nil {
+		background(func() {
+			fmt.Printf("Serving metrics on %s\n", cfg.Metrics.Port)
+
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				l.Error().Err(err).Msg("failed to serve metrics")
+			}
+		})
+		background(func() {
+			<-ctx.Done()
+			metricsSrv.Close()
+		})
+	}
+
+	served := make(chan error, 1)
+
+	if reloader == nil {
+		fmt.Printf("Listening on %s\n", cfg.Server.Port)
+
+		go func() { served <- srv.ListenAndServe() }()
+	} else {
+		background(func() { reloader.Run(ctx, reloadInterval) })
+
+		fmt.Printf("Listening on %s (tls)\n", cfg.Server.Port)
+
+		// The certificate comes from the tls config, so that it can be reloaded
+		go func() { served <- srv.ListenAndServeTLS("", "") }()
+	}
+
+	select {
+	case err := <-served:
+		stop()
+		workers.Wait()
+
+		return err
+	case <-ctx.Done():
+	}
+
+	// A second signal kills the process rather than waiting for the drain
+	stop()
+	l.Info().Dur("timeout", shutdownTimeout).Msg("shutting down, waiting for requests in flight")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		l.Warn().Err(err).Msg("cancelling the requests still in flight")
+		cancelRequests()
+		srv.Close()
+	}
+
+	if err := <-served; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
 
-	return err
+	workers.Wait()
+	l.Info().Msg("shut down")
 
+	return nil
 }
@@ -2,23 +2,71 @@ package ymir
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"sync"
+	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog"
 	"github.com/svartlfheim/clapp"
+	"github.com/svartlfheim/gomigrator"
+	"github.com/svartlfheim/ymir/internal/archive"
+	"github.com/svartlfheim/ymir/internal/certs"
 	"github.com/svartlfheim/ymir/internal/cli"
 	"github.com/svartlfheim/ymir/internal/config"
 	"github.com/svartlfheim/ymir/internal/db"
+	"github.com/svartlfheim/ymir/internal/git"
+	"github.com/svartlfheim/ymir/internal/health"
+	"github.com/svartlfheim/ymir/internal/inspect"
+	"github.com/svartlfheim/ymir/internal/metrics"
+	"github.com/svartlfheim/ymir/internal/oidc"
 	"github.com/svartlfheim/ymir/internal/output"
 	"github.com/svartlfheim/ymir/internal/registry"
 	"github.com/svartlfheim/ymir/internal/repository"
 	"github.com/svartlfheim/ymir/internal/server"
+	"github.com/svartlfheim/ymir/internal/storage"
+	"github.com/svartlfheim/ymir/internal/upstream"
+	"github.com/svartlfheim/ymir/internal/webhook"
 )
 
+// defaultSignedURLTTL is how long terraform has to start downloading an
+// archive after asking for it.
+const defaultSignedURLTTL = 5 * time.Minute
+
+var (
+	postgresMu   sync.Mutex
+	postgresConn *sqlx.DB
+)
+
+// postgresConnection is the pool every repository shares, opened the first
+// time one is built.
+func postgresConnection(cfg *config.Ymir) (*sqlx.DB, error) {
+	postgresMu.Lock()
+	defer postgresMu.Unlock()
+
+	if postgresConn != nil {
+		return postgresConn, nil
+	}
+
+	conn, err := db.NewPostgresConnection(cfg.Db.Options.Postgres)
+
+	if err != nil {
+		return nil, err
+	}
+
+	postgresConn = conn
+
+	return conn, nil
+}
+
 func buildModuleRepository(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (registry.ModuleRepository, error) {
 	switch cfg.Db.Driver {
 	case string(repository.PostgresDriver):
-		conn, err := db.NewPostgresConnection(cfg.Db.Options.Postgres)
+		conn, err := postgresConnection(cfg)
 
 		if err != nil {
 			return nil, err
@@ -32,11 +80,456 @@ func buildModuleRepository(cfg *config.Ymir, ctx context.Context, l zerolog.Logg
 	}
 }
 
-func buildAuditor(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (*registry.Auditor, error) {
+func buildWebhookRepository(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (registry.WebhookRepository, error) {
+	switch cfg.Db.Driver {
+	case string(repository.PostgresDriver):
+		conn, err := postgresConnection(cfg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return repository.BuildWebhooksForPostgres(conn, clapp.LoggerFromContext(ctx)), nil
+	default:
+		return nil, repository.ErrDriverNotImplemented{
+			Driver: cfg.Db.Driver,
+		}
+	}
+}
+
+func buildProviderRepository(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (registry.ProviderRepository, error) {
+	switch cfg.Db.Driver {
+	case string(repository.PostgresDriver):
+		conn, err := postgresConnection(cfg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return repository.BuildProvidersForPostgres(conn, clapp.LoggerFromContext(ctx)), nil
+	default:
+		return nil, repository.ErrDriverNotImplemented{
+			Driver: cfg.Db.Driver,
+		}
+	}
+}
+
+func buildProviderMirrorRepository(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (registry.ProviderMirrorRepository, error) {
+	switch cfg.Db.Driver {
+	case string(repository.PostgresDriver):
+		conn, err := postgresConnection(cfg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return repository.BuildProviderMirrorForPostgres(conn, clapp.LoggerFromContext(ctx)), nil
+	default:
+		return nil, repository.ErrDriverNotImplemented{
+			Driver: cfg.Db.Driver,
+		}
+	}
+}
+
+func buildUpstreamRepository(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (registry.UpstreamRepository, error) {
+	switch cfg.Db.Driver {
+	case string(repository.PostgresDriver):
+		conn, err := postgresConnection(cfg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return repository.BuildUpstreamsForPostgres(conn, clapp.LoggerFromContext(ctx)), nil
+	default:
+		return nil, repository.ErrDriverNotImplemented{
+			Driver: cfg.Db.Driver,
+		}
+	}
+}
+
+func buildAPITokenRepository(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (registry.APITokenRepository, error) {
+	switch cfg.Db.Driver {
+	case string(repository.PostgresDriver):
+		conn, err := postgresConnection(cfg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return repository.BuildAPITokensForPostgres(conn, clapp.LoggerFromContext(ctx)), nil
+	default:
+		return nil, repository.ErrDriverNotImplemented{
+			Driver: cfg.Db.Driver,
+		}
+	}
+}
+
+func buildUserRepository(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (registry.UserRepository, error) {
+	switch cfg.Db.Driver {
+	case string(repository.PostgresDriver):
+		conn, err := postgresConnection(cfg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return repository.BuildUsersForPostgres(conn, clapp.LoggerFromContext(ctx)), nil
+	default:
+		return nil, repository.ErrDriverNotImplemented{
+			Driver: cfg.Db.Driver,
+		}
+	}
+}
+
+func buildNamespaceRepository(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (registry.NamespaceRepository, error) {
+	switch cfg.Db.Driver {
+	case string(repository.PostgresDriver):
+		conn, err := postgresConnection(cfg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return repository.BuildNamespacesForPostgres(conn, clapp.LoggerFromContext(ctx)), nil
+	default:
+		return nil, repository.ErrDriverNotImplemented{
+			Driver: cfg.Db.Driver,
+		}
+	}
+}
+
+func buildTeamRepository(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (registry.TeamRepository, error) {
+	switch cfg.Db.Driver {
+	case string(repository.PostgresDriver):
+		conn, err := postgresConnection(cfg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return repository.BuildTeamsForPostgres(conn, clapp.LoggerFromContext(ctx)), nil
+	default:
+		return nil, repository.ErrDriverNotImplemented{
+			Driver: cfg.Db.Driver,
+		}
+	}
+}
+
+// defaultLoginPorts are the ports terraform's own login docs suggest.
+var defaultLoginPorts = [2]int{10000, 10010}
+
+func buildLoginOptions(cfg *config.Ymir) registry.LoginOptions {
+	o := registry.LoginOptions{
+		Ports:    defaultLoginPorts,
+		TokenTTL: time.Duration(cfg.Auth.Login.TokenTTL) * time.Second,
+	}
+
+	if p := cfg.Auth.Login.Ports; len(p) == 2 {
+		o.Ports = [2]int{p[0], p[1]}
+	}
+
+	return o
+}
+
+// defaultOIDCTimeout is how long an issuer has to respond with its keys.
+const defaultOIDCTimeout = 10 * time.Second
+
+// buildOIDC is nil when no issuers are configured, so only Ymir's own tokens
+// are accepted.
+func buildOIDC(cfg *config.Ymir, ctx context.Context) (*oidc.Verifier, []registry.OIDCRule, error) {
+	if len(cfg.Auth.OIDC.Issuers) == 0 {
+		return nil, nil, nil
+	}
+
+	timeout := time.Duration(cfg.Auth.OIDC.Timeout) * time.Second
+
+	if timeout <= 0 {
+		timeout = defaultOIDCTimeout
+	}
+
+	issuers := []oidc.Issuer{}
+	rules := []registry.OIDCRule{}
+
+	for _, i := range cfg.Auth.OIDC.Issuers {
+		if i.Issuer == "" || i.Audience == "" {
+			return nil, nil, ErrInvalidOIDCIssuer{Issuer: i.Issuer, Reason: "issuer and audience are required"}
+		}
+
+		switch {
+		case i.JWKSURL != "" && i.JWKSFile != "":
+			return nil, nil, ErrInvalidOIDCIssuer{Issuer: i.Issuer, Reason: "only one of jwks_url and jwks_file can be set"}
+		case i.JWKSURL != "":
+			issuers = append(issuers, oidc.NewURLIssuer(i.Issuer, i.Audience, i.JWKSURL, timeout))
+		case i.JWKSFile != "":
+			issuers = append(issuers, oidc.NewFileIssuer(i.Issuer, i.Audience, clapp.FsFromContext(ctx), i.JWKSFile))
+		default:
+			return nil, nil, ErrInvalidOIDCIssuer{Issuer: i.Issuer, Reason: "one of jwks_url or jwks_file is required"}
+		}
+
+		for _, r := range i.Rules {
+			scopes := []registry.TokenScope{}
+
+			for _, s := range r.Scopes {
+				if !registry.IsTokenScope(s) || (s == string(registry.TokenScopes.Admin) && len(r.Namespaces) > 0) {
+					return nil, nil, ErrInvalidOIDCIssuer{Issuer: i.Issuer, Reason: fmt.Sprintf("'%s' is not a scope a rule can grant", s)}
+				}
+
+				scopes = append(scopes, registry.TokenScope(s))
+			}
+
+			rules = append(rules, registry.OIDCRule{
+				Issuer:     i.Issuer,
+				Claims:     r.Claims,
+				Scopes:     scopes,
+				Namespaces: r.Namespaces,
+			})
+		}
+	}
+
+	return oidc.NewVerifier(issuers...), rules, nil
+}
+
+// buildCertificateRules grants scopes to the client certificates verified
+// against the server.tls client CA.
+func buildCertificateRules(cfg *config.Ymir) ([]registry.CertificateRule, error) {
+	rules := []registry.CertificateRule{}
+
+	if len(cfg.Auth.Certificates.Rules) > 0 && cfg.Server.TLS.ClientCAFile == "" {
+		return nil, ErrInvalidTLSConfig{Reason: "auth.certificates needs a client_ca_file to verify certificates against"}
+	}
+
+	for _, r := range cfg.Auth.Certificates.Rules {
+		if r.Subject == "" {
+			return nil, ErrInvalidCertificateRule{Subject: r.Subject, Reason: "subject is required"}
+		}
+
+		scopes := []registry.TokenScope{}
+
+		for _, s := range r.Scopes {
+			if !registry.IsTokenScope(s) || (s == string(registry.TokenScopes.Admin) && len(r.Namespaces) > 0) {
+				return nil, ErrInvalidCertificateRule{Subject: r.Subject, Reason: fmt.Sprintf("'%s' is not a scope a rule can grant", s)}
+			}
+
+			scopes = append(scopes, registry.TokenScope(s))
+		}
+
+		rules = append(rules, registry.CertificateRule{
+			Subject:    r.Subject,
+			Scopes:     scopes,
+			Namespaces: r.Namespaces,
+		})
+	}
+
+	return rules, nil
+}
+
+// defaultTLSReloadInterval is how soon a renewed certificate is served.
+const defaultTLSReloadInterval = 30 * time.Second
+
+// buildTLSReloader is nil when no certificate is configured, so the server
+// serves plain HTTP.
+func buildTLSReloader(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (*certs.Reloader, *tls.Config, time.Duration, error) {
+	t := cfg.Server.TLS
+
+	if t.CertFile == "" && t.KeyFile == "" {
+		if t.ClientCAFile != "" {
+			return nil, nil, 0, ErrInvalidTLSConfig{Reason: "client_ca_file needs cert_file and key_file"}
+		}
+
+		return nil, nil, 0, nil
+	}
+
+	if t.CertFile == "" || t.KeyFile == "" {
+		return nil, nil, 0, ErrInvalidTLSConfig{Reason: "cert_file and key_file are both required"}
+	}
+
+	minVersion, err := certs.ParseMinVersion(t.MinVersion)
+
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	r, err := certs.NewReloader(clapp.FsFromContext(ctx), certs.Files{
+		Cert:     t.CertFile,
+		Key:      t.KeyFile,
+		ClientCA: t.ClientCAFile,
+	}, l)
+
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	interval := time.Duration(t.ReloadInterval) * time.Second
+
+	if interval <= 0 {
+		interval = defaultTLSReloadInterval
+	}
+
+	return r, r.TLSConfig(certs.Options{
+		MinVersion:        minVersion,
+		RequireClientCert: t.RequireClientCert,
+	}), interval, nil
+}
+
+// The server timeouts used for any left at 0, the read and write timeouts
+// leave time for large archives.
+var defaultServerTimeouts = config.TimeoutsConfig{
+	ReadHeader: 10,
+	Read:       300,
+	Write:      300,
+	Idle:       120,
+	Shutdown:   30,
+}
+
+func seconds(v int, fallback int) time.Duration {
+	if v <= 0 {
+		v = fallback
+	}
+
+	return time.Duration(v) * time.Second
+}
+
+// buildHTTPServer returns the server, and how long requests in flight are
+// given to finish when it is shut down.
+func buildHTTPServer(cfg *config.Ymir, h http.Handler, tlsConfig *tls.Config) (*http.Server, time.Duration) {
+	t, d := cfg.Server.Timeouts, defaultServerTimeouts
+
+	return &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           h,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: seconds(t.ReadHeader, d.ReadHeader),
+		ReadTimeout:       seconds(t.Read, d.Read),
+		WriteTimeout:      seconds(t.Write, d.Write),
+		IdleTimeout:       seconds(t.Idle, d.Idle),
+	}, seconds(t.Shutdown, d.Shutdown)
+}
+
+// buildMetrics watches the archive queue, the webhook backlog and the
+// connection pool, they are read whenever the metrics are scraped.
+func buildMetrics(cfg *config.Ymir, moduleRepo registry.ModuleRepository, webhookRepo registry.WebhookRepository) (*metrics.Ymir, error) {
+	m := metrics.NewYmir()
+	m.WatchArchiveQueue(moduleRepo)
+	m.WatchDeliveries(webhookRepo)
+
+	if cfg.Db.Driver == string(repository.PostgresDriver) {
+		conn, err := postgresConnection(cfg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m.WatchDB(conn)
+	}
+
+	return m, nil
+}
+
+// buildMetricsServer is nil unless the metrics are served on a port of their
+// own, away from the registry's clients.
+func buildMetricsServer(cfg *config.Ymir, m *metrics.Ymir) *http.Server {
+	if !cfg.Metrics.Enabled || cfg.Metrics.Port == "" {
+		return nil
+	}
+
+	r := http.NewServeMux()
+	r.Handle("/metrics", m.Handler())
+
+	return &http.Server{
+		Addr:              ":" + cfg.Metrics.Port,
+		Handler:           r,
+		ReadHeaderTimeout: seconds(cfg.Server.Timeouts.ReadHeader, defaultServerTimeouts.ReadHeader),
+	}
+}
+
+// The readiness timeouts used for any left at 0. A worker only polls between
+// builds, so it isn't considered stuck until a build could have finished.
+var defaultHealth = config.HealthConfig{
+	Timeout:          5,
+	WorkerStaleAfter: 600,
+}
+
+// migrationProbe lists the migrations over a connection of its own, closed
+// after each listing, as gomigrator leaves the rows of a query open.
+type migrationProbe struct {
+	cfg    *config.Ymir
+	logger zerolog.Logger
+}
+
+func (p migrationProbe) ListMigrations() ([]*gomigrator.MigrationRecord, error) {
+	conn, err := db.NewPostgresConnection(p.cfg.Db.Options.Postgres)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	m, err := gomigrator.NewMigrator(conn, postgresMigrations, gomigrator.Opts{
+		Schema:  p.cfg.Db.Options.Postgres.Schema,
+		Applyer: "readiness-check",
+	}, p.logger)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ListMigrations()
+}
+
+// buildHealthController checks the database, its migrations, the storage and
+// the workers are all usable on each readiness probe.
+func buildHealthController(cfg *config.Ymir, s *storage.AferoStorage, archiveWorker *registry.ArchiveWorker, deliveryWorker *registry.WebhookDeliveryWorker, l zerolog.Logger) (*server.HealthController, error) {
+	if cfg.Db.Driver != string(repository.PostgresDriver) {
+		return nil, repository.ErrDriverNotImplemented{
+			Driver: cfg.Db.Driver,
+		}
+	}
+
+	conn, err := postgresConnection(cfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h, d := cfg.Health, defaultHealth
+	staleAfter := seconds(h.WorkerStaleAfter, d.WorkerStaleAfter)
+
+	return server.NewHealthController([]health.Check{
+		health.Database(conn),
+		health.Migrations(migrationProbe{cfg: cfg, logger: l}),
+		health.Storage(s),
+		health.Worker("archive_worker", archiveWorker, staleAfter),
+		health.Worker("webhook_delivery_worker", deliveryWorker, staleAfter),
+	}, seconds(h.Timeout, d.Timeout)), nil
+}
+
+func buildUpstreams(cfg *config.Ymir) []registry.Upstream {
+	upstreams := []registry.Upstream{}
+
+	for _, u := range cfg.Proxy.Upstreams {
+		ttl := cfg.Proxy.TTL
+
+		if u.TTL > 0 {
+			ttl = u.TTL
+		}
+
+		upstreams = append(upstreams, registry.Upstream{
+			URL:        u.URL,
+			Namespaces: u.Namespaces,
+			TTL:        time.Duration(ttl) * time.Second,
+		})
+	}
+
+	return upstreams
+}
+
+func buildAuditor(cfg *config.Ymir, ctx context.Context, l zerolog.Logger, opts ...registry.AuditorOption) (*registry.Auditor, error) {
 	var repo server.AuditLogRepository
 	switch cfg.Db.Driver {
 	case string(repository.PostgresDriver):
-		conn, err := db.NewPostgresConnection(cfg.Db.Options.Postgres)
+		conn, err := postgresConnection(cfg)
 
 		if err != nil {
 			return nil, err
@@ -49,8 +542,74 @@ func buildAuditor(cfg *config.Ymir, ctx context.Context, l zerolog.Logger) (*reg
 		}
 	}
 
-	return registry.NewAuditor(repo, l), nil
+	webhookRepo, err := buildWebhookRepository(cfg, ctx, l)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, registry.WithEventPublisher(registry.NewWebhookOutbox(webhookRepo, l)))
+
+	return registry.NewAuditor(repo, l, opts...), nil
+
+}
+
+// recordAction audits an action taken from the cli, so any events it produces
+// reach webhook subscribers, as they would had it been taken through the API.
+func recordAction(c YmirCommand, action registry.AuditableAction) {
+	l := c.GetLogger()
+	a, err := buildAuditor(c.GetConfig(), c.cobra.Context(), l)
+
+	if err != nil {
+		l.Error().Err(err).Str("action", action.GetActionName()).Msg("failed to build auditor")
+		return
+	}
 
+	a.Record(action)
+}
+
+func buildStorage(cfg *config.Ymir, ctx context.Context) (*storage.AferoStorage, error) {
+	return storage.New(clapp.FsFromContext(ctx), cfg.Storage)
+}
+
+func buildArchiveWorker(cfg *config.Ymir, repo registry.ModuleRepository, s *storage.AferoStorage, a *registry.Auditor, l zerolog.Logger) *registry.ArchiveWorker {
+	b := archive.NewBuilder(git.NewClient(l), s, l)
+	interval := time.Duration(cfg.Worker.Interval) * time.Second
+	buildTimeout := time.Duration(cfg.Worker.BuildTimeout) * time.Second
+
+	return registry.NewArchiveWorker(repo, b, a, interval, buildTimeout, l)
+}
+
+func buildWebhookDeliveryWorker(cfg *config.Ymir, repo registry.WebhookRepository, l zerolog.Logger) *registry.WebhookDeliveryWorker {
+	sender := webhook.NewSender(time.Duration(cfg.Deliveries.Timeout) * time.Second)
+	interval := time.Duration(cfg.Deliveries.Interval) * time.Second
+
+	return registry.NewWebhookDeliveryWorker(repo, sender, interval, cfg.Deliveries.MaxAttempts, l)
+}
+
+// buildArchiveSigner is nil unless the protocol endpoints require a token.
+// The secret must be configured, every replica has to verify the URLs the
+// others hand out.
+func buildArchiveSigner(cfg *config.Ymir) (*archive.URLSigner, error) {
+	auth := cfg.Auth.Registry
+
+	if !auth.Required {
+		return nil, nil
+	}
+
+	if auth.SigningSecret == "" {
+		return nil, errors.New("auth.registry.signing_secret must be set when auth.registry.required is")
+	}
+
+	secret := []byte(auth.SigningSecret)
+
+	ttl := time.Duration(auth.SignedURLTTL) * time.Second
+
+	if ttl <= 0 {
+		ttl = defaultSignedURLTTL
+	}
+
+	return archive.NewURLSigner(secret, ttl), nil
 }
 
 func buildTableFactory() *output.TableFactory {
@@ -67,13 +626,107 @@ func buildCommandBus(c YmirCommand) *registry.CommandBus {
 		l.Fatal().Err(err).Msg("failed to build module repo")
 	}
 
-	cb := registry.NewCommandBus(
+	webhookRepo, err := buildWebhookRepository(c.GetConfig(), ctx, l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build webhook repo")
+	}
+
+	providerRepo, err := buildProviderRepository(c.GetConfig(), ctx, l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build provider repo")
+	}
+
+	mirrorRepo, err := buildProviderMirrorRepository(c.GetConfig(), ctx, l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build provider mirror repo")
+	}
+
+	upstreamRepo, err := buildUpstreamRepository(c.GetConfig(), ctx, l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build upstream repo")
+	}
+
+	tokenRepo, err := buildAPITokenRepository(c.GetConfig(), ctx, l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build api token repo")
+	}
+
+	userRepo, err := buildUserRepository(c.GetConfig(), ctx, l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build user repo")
+	}
+
+	namespaceRepo, err := buildNamespaceRepository(c.GetConfig(), ctx, l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build namespace repo")
+	}
+
+	teamRepo, err := buildTeamRepository(c.GetConfig(), ctx, l)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build team repo")
+	}
+
+	store, err := buildStorage(c.GetConfig(), ctx)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build storage")
+	}
+
+	upstreamClient := upstream.NewClient(time.Duration(c.GetConfig().Proxy.Timeout)*time.Second, git.NewClient(l))
+
+	opts := []registry.WithDependency{
 		registry.WithFS(clapp.FsFromContext(ctx)),
 		registry.WithModuleRepo(moduleRepo),
+		registry.WithWebhookRepo(webhookRepo),
+		registry.WithProviderRepo(providerRepo),
+		registry.WithProviderMirrorRepo(mirrorRepo),
+		registry.WithUpstreamRepo(upstreamRepo),
+		registry.WithAPITokenRepo(tokenRepo),
+		registry.WithUpstreams(buildUpstreams(c.GetConfig()), upstreamClient),
+		registry.WithObjectStore(store),
 		registry.WithLogger(l),
 		registry.WithPrompter(cli.NewPrompter()),
 		registry.WithCommandValidatorBuilder(registry.NewCommandValidator),
-	)
+		registry.WithSourceCheckout(git.NewClient(l)),
+		registry.WithRefResolver(git.NewClient(l)),
+		registry.WithTagLister(git.NewClient(l)),
+		registry.WithModuleCallScanner(inspect.NewScanner()),
+		registry.WithUserRepo(userRepo),
+		registry.WithNamespaceRepo(namespaceRepo),
+		registry.WithTeamRepo(teamRepo),
+	}
+
+	if c.GetConfig().Auth.Login.Enabled {
+		opts = append(opts, registry.WithLogin(buildLoginOptions(c.GetConfig())))
+	}
+
+	verifier, rules, err := buildOIDC(c.GetConfig(), ctx)
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build oidc verifier")
+	}
+
+	if verifier != nil {
+		opts = append(opts, registry.WithOIDC(verifier, rules))
+	}
+
+	certRules, err := buildCertificateRules(c.GetConfig())
+
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to build certificate rules")
+	}
+
+	if len(certRules) > 0 {
+		opts = append(opts, registry.WithCertificateRules(certRules))
+	}
 
-	return cb
+	return registry.NewCommandBus(opts...)
 }
@@ -0,0 +1,131 @@
+package ymir
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/cli"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+func sync_tags(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+
+	style, err := flags.GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	repo, err := flags.GetString("repo")
+
+	if err != nil {
+		o.Error("the 'repo' option was not configured for this command")
+		return nil
+	}
+
+	dryRun, err := flags.GetBool("dry-run")
+
+	if err != nil {
+		o.Error("the 'dry-run' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.SyncTagsV1(c.cobra.Context(), registry.SyncTagsV1DTO{
+		RepositoryURL: repo,
+		DryRun:        dryRun,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.Report, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.Report) == 0 {
+			o.Warnln("No tags matched a module's tag pattern!")
+			return nil
+		}
+
+		h, r := registry.BuildTagSyncReportTable(res.Report)
+		buildTableFactory().CreateAndPrint(h, r)
+
+		o.Successf("New: %d\n", res.CountByStatus(registry.TagSyncStatuses.New))
+		o.Infof("Existing: %d\n", res.CountByStatus(registry.TagSyncStatuses.Existing))
+
+		if deleted := res.CountByStatus(registry.TagSyncStatuses.Deleted); deleted > 0 {
+			o.Warnf("Deleted: %d\n", deleted)
+		}
+
+		if invalid := res.CountByStatus(registry.TagSyncStatuses.Invalid); invalid > 0 {
+			o.Errorf("Invalid: %d\n", invalid)
+		}
+
+		if failed := res.CountByStatus(registry.TagSyncStatuses.Failed); failed > 0 {
+			o.Errorf("Failed: %d\n", failed)
+		}
+
+		if dryRun {
+			o.Warnln("Dry run, no versions were created.")
+		}
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+// syncTagsPeriodically keeps versions in step with the git tags while the
+// server is running.
+func syncTagsPeriodically(ctx context.Context, cb *registry.CommandBus, a *registry.Auditor, interval time.Duration, l zerolog.Logger) {
+	l.Info().Dur("interval", interval).Msg("tag sync started")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		res, err := cb.SyncTagsV1(ctx, registry.SyncTagsV1DTO{})
+
+		// A sync that found nothing new isn't worth an audit log entry
+		if err != nil || res.CountByStatus(registry.TagSyncStatuses.New) > 0 || res.CountByStatus(registry.TagSyncStatuses.Failed) > 0 {
+			a.Record(res)
+		}
+
+		if err != nil {
+			l.Error().Err(err).Msg("failed to sync tags")
+		} else {
+			l.Info().Int("new", res.CountByStatus(registry.TagSyncStatuses.New)).Int("invalid", res.CountByStatus(registry.TagSyncStatuses.Invalid)).Int("failed", res.CountByStatus(registry.TagSyncStatuses.Failed)).Msg("synced tags")
+		}
+
+		select {
+		case <-ctx.Done():
+			l.Info().Msg("tag sync stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
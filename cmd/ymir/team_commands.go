@@ -0,0 +1,183 @@
+package ymir
+
+import (
+	"encoding/json"
+
+	"github.com/svartlfheim/ymir/internal/cli"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+func team_create(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.CreateTeamV1(registry.CreateTeamV1DTO{
+		Name: c.GetArg(0, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_CREATED:
+		o.Successln("Successfully created!")
+
+		h, r := registry.BuildTeamsTable([]registry.Team{res.Team})
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func team_list(c YmirCommand) error {
+	o := c.GetOutput()
+
+	style, err := c.cobra.LocalFlags().GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ListTeamsV1()
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.List, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.List) == 0 {
+			o.Warnln("No teams found!")
+			return nil
+		}
+
+		h, r := registry.BuildTeamsTable(res.List)
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func team_delete(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.DeleteTeamV1(registry.DeleteTeamV1DTO{
+		Name: c.GetArg(0, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("Team not found!")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		o.Successln("Successfully deleted!")
+		o.Successf("Name: %s\n", res.Team.Name)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func printTeamMemberResponse(c YmirCommand, res registry.TeamMemberV1Response, success string) {
+	o := c.GetOutput()
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("Team or user not found!")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		o.Successln(success)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+}
+
+func team_add_member(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.AddTeamMemberV1(registry.TeamMemberV1DTO{
+		Team:     c.GetArg(0, ""),
+		Username: c.GetArg(1, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+	printTeamMemberResponse(c, res, "Successfully added!")
+
+	return nil
+}
+
+func team_remove_member(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.RemoveTeamMemberV1(registry.TeamMemberV1DTO{
+		Team:     c.GetArg(0, ""),
+		Username: c.GetArg(1, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+	printTeamMemberResponse(c, res, "Successfully removed!")
+
+	return nil
+}
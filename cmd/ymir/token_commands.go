@@ -0,0 +1,173 @@
+package ymir
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/svartlfheim/ymir/internal/cli"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+func token_create(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+
+	name, err := flags.GetString("name")
+
+	if err != nil {
+		o.Error("the 'name' option was not configured for this command")
+		return nil
+	}
+
+	scopes, err := flags.GetStringSlice("scope")
+
+	if err != nil {
+		o.Error("the 'scope' option was not configured for this command")
+		return nil
+	}
+
+	expiresIn, err := flags.GetString("expires-in")
+
+	if err != nil {
+		o.Error("the 'expires-in' option was not configured for this command")
+		return nil
+	}
+
+	var expiresAt *time.Time
+
+	if expiresIn != "" {
+		d, err := time.ParseDuration(expiresIn)
+
+		if err != nil {
+			o.Errorf("expires-in must be a duration, e.g. 720h: %s\n", err.Error())
+			return nil
+		}
+
+		at := time.Now().Add(d)
+		expiresAt = &at
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.CreateAPITokenV1(registry.CreateAPITokenV1DTO{
+		Name:      name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_CREATED:
+		h, r := registry.BuildAPITokensTable([]registry.APIToken{res.APIToken})
+		buildTableFactory().CreateAndPrint(h, r)
+
+		o.Successf("Token: %s\n", res.Secret)
+		o.Warnln("The token will not be shown again, send it in the Authorization header as 'Bearer <token>'.")
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func token_list(c YmirCommand) error {
+	o := c.GetOutput()
+
+	style, err := c.cobra.LocalFlags().GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ListAPITokensV1()
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.List, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.List) == 0 {
+			o.Warnln("No API tokens found!")
+			return nil
+		}
+
+		h, r := registry.BuildAPITokensTable(res.List)
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func token_revoke(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.RevokeAPITokenV1(registry.RevokeAPITokenV1DTO{
+		Id: c.GetArg(0, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("API token not found!")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		scopes := []string{}
+
+		for _, s := range res.APIToken.Scopes {
+			scopes = append(scopes, string(s))
+		}
+
+		o.Successln("Successfully revoked!")
+		o.Successf("Id: %s\n", res.APIToken.Id)
+		o.Successf("Name: %s\n", res.APIToken.Name)
+		o.Successf("Scopes: %s\n", strings.Join(scopes, ", "))
+		o.Successf("Revoked At: %s\n", res.APIToken.RevokedAt.Format(time.RFC3339))
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package ymir
+
+import (
+	"encoding/json"
+
+	"github.com/svartlfheim/ymir/internal/cli"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+const (
+	failOnOutdated string = "outdated"
+	failOnBreaking string = "breaking"
+)
+
+// usageCheck is the error the scan exits with, when the usages found are
+// what --fail-on asked to fail on.
+func usageCheck(report registry.UsageReport, failOn string) error {
+	switch failOn {
+	case failOnOutdated:
+		if n := report.CountByStatus(registry.UsageStatuses.Outdated); n > 0 {
+			return ErrUsageCheckFailed{Count: n, Reason: "outdated"}
+		}
+	case failOnBreaking:
+		if n := report.CountBreaking(); n > 0 {
+			return ErrUsageCheckFailed{Count: n, Reason: "with breaking upgrades"}
+		}
+	}
+
+	return nil
+}
+
+func usage_scan(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+
+	style, err := flags.GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	hostname, err := flags.GetString("hostname")
+
+	if err != nil {
+		o.Error("the 'hostname' option was not configured for this command")
+		return nil
+	}
+
+	failOn, err := flags.GetString("fail-on")
+
+	if err != nil {
+		o.Error("the 'fail-on' option was not configured for this command")
+		return nil
+	}
+
+	if failOn != "" && failOn != failOnOutdated && failOn != failOnBreaking {
+		o.Errorf("fail-on must be one of [%s,%s]\n", failOnOutdated, failOnBreaking)
+		return nil
+	}
+
+	if hostname == "" {
+		hostname = c.GetConfig().Server.Hostname
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ScanModuleUsageV1(c.cobra.Context(), registry.ScanModuleUsageV1DTO{
+		Directory: c.GetArg(0, ""),
+		Hostname:  hostname,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.Report, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+
+			if err := usageCheck(res.Report, failOn); err != nil {
+				return silenced(c, err)
+			}
+
+			return nil
+		}
+
+		for _, reason := range res.Report.Skipped {
+			o.Warnf("Skipped: %s\n", reason)
+		}
+
+		if len(res.Report.Usages) == 0 {
+			o.Warnf("No modules from %s are used!\n", hostname)
+			return nil
+		}
+
+		h, r := registry.BuildUsageReportTable(res.Report.Usages)
+		buildTableFactory().CreateAndPrint(h, r)
+
+		o.Infof("Up to date: %d\n", res.Report.CountByStatus(registry.UsageStatuses.UpToDate))
+
+		if outdated := res.Report.CountByStatus(registry.UsageStatuses.Outdated); outdated > 0 {
+			o.Warnf("Outdated: %d (breaking: %d)\n", outdated, res.Report.CountBreaking())
+		}
+
+		problems := res.Report.CountByStatus(registry.UsageStatuses.Unresolved) +
+			res.Report.CountByStatus(registry.UsageStatuses.UnknownModule) +
+			res.Report.CountByStatus(registry.UsageStatuses.InvalidConstraint)
+
+		if problems > 0 {
+			o.Errorf("Can't be installed: %d\n", problems)
+		}
+
+		if err := usageCheck(res.Report, failOn); err != nil {
+			o.Errorln(err.Error())
+			return silenced(c, err)
+		}
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
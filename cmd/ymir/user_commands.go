@@ -0,0 +1,151 @@
+package ymir
+
+import (
+	"encoding/json"
+
+	"github.com/svartlfheim/ymir/internal/cli"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+func user_create(c YmirCommand) error {
+	o := c.GetOutput()
+
+	scopes, err := c.cobra.LocalFlags().GetStringSlice("scope")
+
+	if err != nil {
+		o.Error("the 'scope' option was not configured for this command")
+		return nil
+	}
+
+	p := cli.NewPrompter()
+	password, err := p.AskSecret("Password")
+
+	if err != nil {
+		return nil
+	}
+
+	confirmed, err := p.AskSecret("Confirm password")
+
+	if err != nil {
+		return nil
+	}
+
+	if password != confirmed {
+		o.Errorln("The passwords did not match!")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.CreateUserV1(registry.CreateUserV1DTO{
+		Username: c.GetArg(0, ""),
+		Password: password,
+		Scopes:   scopes,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_CREATED:
+		o.Successln("Successfully created!")
+
+		h, r := registry.BuildUsersTable([]registry.User{res.User})
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func user_list(c YmirCommand) error {
+	o := c.GetOutput()
+
+	style, err := c.cobra.LocalFlags().GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ListUsersV1()
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.List, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.List) == 0 {
+			o.Warnln("No users found!")
+			return nil
+		}
+
+		h, r := registry.BuildUsersTable(res.List)
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func user_delete(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.DeleteUserV1(registry.DeleteUserV1DTO{
+		Id: c.GetArg(0, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	recordAction(c, res)
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("User not found!")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		o.Successln("Successfully deleted!")
+		o.Successf("Id: %s\n", res.User.Id)
+		o.Successf("Username: %s\n", res.User.Username)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package ymir
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/svartlfheim/ymir/internal/cli"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+// allIfEmpty describes a subscription filter, which matches everything when
+// empty.
+func allIfEmpty(filter []string) string {
+	if len(filter) == 0 {
+		return "all"
+	}
+
+	return strings.Join(filter, ", ")
+}
+
+func webhook_add(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+
+	url, err := flags.GetString("url")
+
+	if err != nil {
+		o.Error("the 'url' option was not configured for this command")
+		return nil
+	}
+
+	secret, err := flags.GetString("secret")
+
+	if err != nil {
+		o.Error("the 'secret' option was not configured for this command")
+		return nil
+	}
+
+	events, err := flags.GetStringSlice("event")
+
+	if err != nil {
+		o.Error("the 'event' option was not configured for this command")
+		return nil
+	}
+
+	namespaces, err := flags.GetStringSlice("namespace")
+
+	if err != nil {
+		o.Error("the 'namespace' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.AddWebhookSubscriptionV1(registry.AddWebhookSubscriptionV1DTO{
+		URL:        url,
+		Secret:     secret,
+		EventTypes: events,
+		Namespaces: namespaces,
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_CREATED:
+		o.Successln("Successfully created!")
+		o.Successf("Id: %s\n", res.Subscription.Id)
+		o.Successf("URL: %s\n", res.Subscription.URL)
+		o.Successf("Event Types: %s\n", allIfEmpty(events))
+		o.Successf("Namespaces: %s\n", allIfEmpty(res.Subscription.Namespaces))
+		o.Successf("Secret: %s\n", res.Subscription.Secret)
+		o.Warnln("The secret will not be shown again, payloads are signed with it in the X-Ymir-Signature-256 header.")
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func webhook_list(c YmirCommand) error {
+	o := c.GetOutput()
+
+	style, err := c.cobra.LocalFlags().GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ListWebhookSubscriptionsV1()
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.List, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.List) == 0 {
+			o.Warnln("No webhook subscriptions found!")
+			return nil
+		}
+
+		h, r := registry.BuildWebhookSubscriptionsTable(res.List)
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func webhook_delete(c YmirCommand) error {
+	o := c.GetOutput()
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.DeleteWebhookSubscriptionV1(registry.DeleteWebhookSubscriptionV1DTO{
+		Id: c.GetArg(0, ""),
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("Webhook subscription not found!")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		o.Successln("Successfully deleted!")
+		o.Successf("Id: %s\n", res.Subscription.Id)
+		o.Successf("URL: %s\n", res.Subscription.URL)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
+
+func webhook_deliveries(c YmirCommand) error {
+	o := c.GetOutput()
+	flags := c.cobra.LocalFlags()
+
+	style, err := flags.GetString("output")
+
+	if err != nil {
+		o.Error("the 'output' option was not configured for this command")
+		return nil
+	}
+
+	limit, err := flags.GetInt("limit")
+
+	if err != nil {
+		o.Error("the 'limit' option was not configured for this command")
+		return nil
+	}
+
+	cb := buildCommandBus(c)
+
+	res, err := cb.ListWebhookDeliveriesV1(registry.ListWebhookDeliveriesV1DTO{
+		SubscriptionId: c.GetArg(0, ""),
+		ChunkOpts: registry.ChunkingOptions{
+			Size: limit,
+		},
+	})
+
+	if err != nil {
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+		return nil
+	}
+
+	switch res.Status {
+	case registry.STATUS_NOT_FOUND:
+		o.Warnln("Webhook subscription not found!")
+	case registry.STATUS_INVALID:
+		o.Errorln("Data was invalid!")
+		for _, err := range res.ValidationErrors {
+			o.Errorf("%s: %s\n", err.Field, err.Message)
+		}
+	case registry.STATUS_OKAY:
+		if style == cli.OutputStyles.JSON {
+			b, err := json.MarshalIndent(res.List, "", "\t")
+
+			if err != nil {
+				o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+				return nil
+			}
+
+			o.Infoln(string(b))
+			return nil
+		}
+
+		if len(res.List) == 0 {
+			o.Warnln("No deliveries found for this subscription!")
+			return nil
+		}
+
+		h, r := registry.BuildWebhookDeliveriesTable(res.List)
+		buildTableFactory().CreateAndPrint(h, r)
+	default:
+		o.Errorln("Whoopsie, an unexpected error occurred, see logs!")
+	}
+
+	return nil
+}
@@ -1,78 +1,274 @@
 package archive
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
 	"fmt"
-	"net/url"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
-	"github.com/svartlfheim/ymir/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/inspect"
+	"github.com/svartlfheim/ymir/internal/registry"
 )
 
-type ArchiveRepository interface {
-	CreateArchive(u string) (location string, err error)
+type sourceCheckout interface {
+	Checkout(ctx context.Context, repo string, ref string) (dir string, cleanup func(), err error)
 }
 
-var (
-	SourceGithub = "github"
-	SourceGitlab = "gitlab"
+type objectStore interface {
+	Put(key string, r io.Reader) error
+}
 
-	GithubHostName = "github.com"
-	GitlabHostName = "github.com"
-)
+// Builder fetches the source of a module version from git, and stores it as
+// a tar.gz archive that terraform can download.
+type Builder struct {
+	checkout sourceCheckout
+	store    objectStore
+	logger   zerolog.Logger
+}
 
-type factory struct {
-	Config config.Ymir
+// Key is the location of the archive for a module version within storage.
+func Key(m registry.Module, mv registry.ModuleVersion) string {
+	return fmt.Sprintf("modules/%s/%s/%s/%s.tar.gz", m.Namespace, m.Name, m.Provider, mv.Version)
 }
 
-type ArchiveFactory interface {
-	New(u string) (ArchiveRepository, error)
+// DownloadPath is the path the archive for the key is served from.
+func DownloadPath(key string) string {
+	return "/archives/" + key
 }
 
-func BuildFactory(c config.Ymir) *factory {
-	return &factory{
-		Config: c,
+// Build stores the archive of the module version, and parses its interface
+// from the same checkout. A module whose HCL can't be parsed is still built,
+// it just has no interface.
+func (b *Builder) Build(ctx context.Context, m registry.Module, mv registry.ModuleVersion) (downloadURL string, iface *registry.ModuleInterface, err error) {
+	dir, cleanup, err := b.checkout.Checkout(ctx, mv.RepositoryURL, mv.Source)
+
+	if err != nil {
+		return "", nil, err
 	}
+
+	defer cleanup()
+
+	src, err := modulePath(dir, m, mv)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if parsed, err := inspect.Module(src); err != nil {
+		b.logger.Warn().Err(err).Str("module_version_id", mv.Id).Msg("failed to parse module interface")
+	} else {
+		iface = &parsed
+	}
+
+	key := Key(m, mv)
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(writeTarGz(src, pw))
+	}()
+
+	if err := b.store.Put(key, pr); err != nil {
+		// Unblock the writer if storage gave up part way through
+		pr.CloseWithError(err)
+
+		return "", nil, err
+	}
+
+	b.logger.Info().Str("key", key).Str("module_version_id", mv.Id).Msg("stored module archive")
+
+	return DownloadPath(key), iface, nil
 }
 
-func (f *factory) New(u string) (ArchiveRepository, error) {
-	src, err := extractSourceFromURL(u)
+// modulePath resolves the module's directory within the checkout. Symlinks
+// in the repository are followed, so the path must still be within it after.
+func modulePath(dir string, m registry.Module, mv registry.ModuleVersion) (string, error) {
+	notFound := ErrModulePathNotFound{
+		Path: m.Path,
+		Ref:  mv.Source,
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
 
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	src, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(m.Path)))
+
+	if err != nil {
+		return "", notFound
 	}
 
-	return buildRepository(src, f.Config)
+	if !isWithin(root, src) {
+		return "", ErrModulePathOutsideRepository{
+			Path: m.Path,
+			Ref:  mv.Source,
+		}
+	}
+
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		return "", notFound
+	}
+
+	return src, nil
 }
 
-func buildRepository(t string, c config.Ymir) (ArchiveRepository, error) {
-	switch t {
-	case SourceGithub:
-		return &GithubArchive{
-			AccessToken: c.Git.Github.AccessToken,
-		}, nil
-	default:
-		return nil, fmt.Errorf("git archive not immplemented for source '%s'", t)
+func isWithin(root string, p string) bool {
+	rel, err := filepath.Rel(root, p)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
 
+// TarGz writes the directory to w as a tar.gz archive, in the same way the
+// archives of module versions are built.
+func TarGz(src string, w io.Writer) error {
+	return writeTarGz(src, w)
+}
+
+func shouldExclude(rel string, info os.FileInfo) bool {
+	if !info.IsDir() {
+		return false
+	}
+
+	name := info.Name()
+
+	return rel != "." && (name == ".git" || name == ".terraform")
+}
+
+// symlinkTarget is where the link at p points, relative to its directory, or
+// false if it points outside of root or nowhere.
+func symlinkTarget(root string, p string) (string, bool) {
+	target, err := filepath.EvalSymlinks(p)
+
+	if err != nil || !isWithin(root, target) {
+		return "", false
 	}
+
+	link, err := filepath.Rel(filepath.Dir(p), target)
+
+	return link, err == nil
 }
 
-// TODO: Add config for extra gitlab domains; it can be privately hosted
-func extractSourceFromURL(u string) (string, error) {
-	parsed, err := url.Parse(u)
+// writeTarGz keeps symlinks as links rather than following them, and leaves
+// out those that point outside of src.
+func writeTarGz(src string, w io.Writer) error {
+	src, err := filepath.EvalSymlinks(src)
 
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	host := parsed.Hostname()
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	paths := []string{}
+	links := map[string]string{}
+
+	err = filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+
+		if err != nil {
+			return err
+		}
+
+		if shouldExclude(rel, info) {
+			return filepath.SkipDir
+		}
+
+		if rel == "." {
+			return nil
+		}
 
-	switch host {
-	case GithubHostName:
-		return SourceGithub, nil
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, ok := symlinkTarget(src, p); ok {
+				links[rel] = link
+				paths = append(paths, rel)
+			}
 
-	case GitlabHostName:
-		return SourceGitlab, nil
+			return nil
+		}
+
+		if info.IsDir() || info.Mode().IsRegular() {
+			paths = append(paths, rel)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// A stable order means the same source produces the same archive
+	sort.Strings(paths)
+
+	for _, rel := range paths {
+		if err := addToTar(tw, src, rel, links[rel]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+func addToTar(tw *tar.Writer, src string, rel string, link string) error {
+	p := filepath.Join(src, rel)
+	info, err := os.Lstat(p)
+
+	if err != nil {
+		return err
+	}
+
+	hdr, err := tar.FileInfoHeader(info, filepath.ToSlash(link))
+
+	if err != nil {
+		return err
+	}
+
+	hdr.Name = filepath.ToSlash(rel)
+
+	if info.IsDir() {
+		hdr.Name = strings.TrimSuffix(hdr.Name, "/") + "/"
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(p)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+
+	return err
+}
 
-	default:
-		return "", fmt.Errorf("git driver not implemented for '%s'", host)
+func NewBuilder(c sourceCheckout, s objectStore, l zerolog.Logger) *Builder {
+	return &Builder{
+		checkout: c,
+		store:    s,
+		logger:   l,
 	}
 }
@@ -0,0 +1,266 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svartlfheim/ymir/internal/registry"
+	"github.com/svartlfheim/ymir/internal/storage"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeCheckout struct {
+	dir string
+	err error
+}
+
+func (c *fakeCheckout) Checkout(ctx context.Context, repo string, ref string) (string, func(), error) {
+	return c.dir, func() {}, c.err
+}
+
+func buildSourceDir(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"README.md":                   "readme",
+		"vpc/main.tf":                 `variable "cidr" {}`,
+		"vpc/modules/subnet/main.tf":  "resource {}",
+		"vpc/.terraform/plugins/blah": "cache",
+		"vpc/.git/HEAD":               "ref",
+	}
+
+	for p, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func readTarGzNames(t *testing.T, r io.Reader) []string {
+	gz, err := gzip.NewReader(r)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := tar.NewReader(gz)
+	names := []string{}
+
+	for {
+		hdr, err := tr.Next()
+
+		if err == io.EOF {
+			return names
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names = append(names, hdr.Name)
+	}
+}
+
+func Test_writeTarGz(t *testing.T) {
+	dir := buildSourceDir(t)
+	buf := new(bytes.Buffer)
+
+	err := writeTarGz(filepath.Join(dir, "vpc"), buf)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"main.tf",
+		"modules/",
+		"modules/subnet/",
+		"modules/subnet/main.tf",
+	}, readTarGzNames(t, buf))
+}
+
+func Test_writeTarGz_Symlinks(t *testing.T) {
+	dir := buildSourceDir(t)
+	outside := t.TempDir()
+	vpc := filepath.Join(dir, "vpc")
+
+	if err := os.WriteFile(filepath.Join(outside, "ymir.yaml"), []byte("db_password: hunter2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for link, target := range map[string]string{
+		"variables.tf": "main.tf",
+		"subnet":       filepath.Join(vpc, "modules", "subnet"),
+		"leak.yaml":    filepath.Join(outside, "ymir.yaml"),
+		"leakdir":      outside,
+		"dangling":     "nowhere",
+	} {
+		if err := os.Symlink(target, filepath.Join(vpc, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	assert.Nil(t, writeTarGz(vpc, buf))
+
+	gz, err := gzip.NewReader(buf)
+	assert.Nil(t, err)
+
+	tr := tar.NewReader(gz)
+	links := map[string]string{}
+	names := []string{}
+
+	for {
+		hdr, err := tr.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		assert.Nil(t, err)
+		names = append(names, hdr.Name)
+
+		if hdr.Typeflag == tar.TypeSymlink {
+			links[hdr.Name] = hdr.Linkname
+		}
+	}
+
+	assert.Equal(t, []string{
+		"main.tf",
+		"modules/",
+		"modules/subnet/",
+		"modules/subnet/main.tf",
+		"subnet",
+		"variables.tf",
+	}, names)
+	assert.Equal(t, map[string]string{
+		"subnet":       "modules/subnet",
+		"variables.tf": "main.tf",
+	}, links)
+}
+
+func Test_writeTarGz_IsDeterministic(t *testing.T) {
+	dir := buildSourceDir(t)
+	first := new(bytes.Buffer)
+	second := new(bytes.Buffer)
+
+	assert.Nil(t, writeTarGz(dir, first))
+	assert.Nil(t, writeTarGz(dir, second))
+	assert.Equal(t, first.Bytes(), second.Bytes())
+}
+
+func Test_Builder_Build(t *testing.T) {
+	dir := buildSourceDir(t)
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	s := storage.NewInMemoryStorage()
+	m := registry.Module{Provider: "aws", Namespace: "platform", Name: "vpc", Path: "vpc"}
+	mv := registry.ModuleVersion{Version: "1.0.0", Source: "abc123"}
+
+	b := NewBuilder(&fakeCheckout{dir: dir}, s, l)
+	url, iface, err := b.Build(context.Background(), m, mv)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/archives/modules/platform/vpc/aws/1.0.0.tar.gz", url)
+	assert.Len(t, iface.Variables, 1)
+	assert.Equal(t, "cidr", iface.Variables[0].Name)
+
+	f, err := s.Open("modules/platform/vpc/aws/1.0.0.tar.gz")
+	assert.Nil(t, err)
+	defer f.Close()
+
+	assert.Contains(t, readTarGzNames(t, f), "main.tf")
+}
+
+func Test_Builder_Build_WithUnparseableModule(t *testing.T) {
+	dir := buildSourceDir(t)
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	s := storage.NewInMemoryStorage()
+	m := registry.Module{Provider: "aws", Namespace: "platform", Name: "subnet", Path: "vpc/modules/subnet"}
+	mv := registry.ModuleVersion{Version: "1.0.0", Source: "abc123"}
+
+	b := NewBuilder(&fakeCheckout{dir: dir}, s, l)
+	url, iface, err := b.Build(context.Background(), m, mv)
+
+	assert.Nil(t, err)
+	assert.Nil(t, iface)
+	assert.Equal(t, "/archives/modules/platform/subnet/aws/1.0.0.tar.gz", url)
+}
+
+func Test_Builder_Build_Errors(t *testing.T) {
+	dir := buildSourceDir(t)
+	outside := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(outside, "secretdir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(outside, "secretdir", "ymir.yaml"), []byte("db_password: hunter2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(dir, "a")); err != nil {
+		t.Fatal(err)
+	}
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	checkoutErr := errors.New("clone failed")
+
+	tests := []struct {
+		name        string
+		checkout    *fakeCheckout
+		path        string
+		expectedErr error
+	}{
+		{
+			name:        "checkout fails",
+			checkout:    &fakeCheckout{err: checkoutErr},
+			path:        "vpc",
+			expectedErr: checkoutErr,
+		},
+		{
+			name:     "path through a symlinked directory outside the repository",
+			checkout: &fakeCheckout{dir: dir},
+			path:     "a/secretdir",
+			expectedErr: ErrModulePathOutsideRepository{
+				Path: "a/secretdir",
+				Ref:  "abc123",
+			},
+		},
+		{
+			name:     "path missing at ref",
+			checkout: &fakeCheckout{dir: dir},
+			path:     "missing",
+			expectedErr: ErrModulePathNotFound{
+				Path: "missing",
+				Ref:  "abc123",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			s := storage.NewInMemoryStorage()
+			b := NewBuilder(test.checkout, s, l)
+			m := registry.Module{Provider: "aws", Namespace: "platform", Name: "vpc", Path: test.path}
+			mv := registry.ModuleVersion{Version: "1.0.0", Source: "abc123"}
+
+			_, _, err := b.Build(context.Background(), m, mv)
+
+			assert.Equal(tt, test.expectedErr, err)
+
+			_, err = s.Open(Key(m, mv))
+			assert.NotNil(tt, err, "nothing is stored")
+		})
+	}
+}
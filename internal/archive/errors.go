@@ -0,0 +1,21 @@
+package archive
+
+import "fmt"
+
+type ErrModulePathNotFound struct {
+	Path string
+	Ref  string
+}
+
+func (e ErrModulePathNotFound) Error() string {
+	return fmt.Sprintf("module path '%s' is not a directory at ref %s", e.Path, e.Ref)
+}
+
+type ErrModulePathOutsideRepository struct {
+	Path string
+	Ref  string
+}
+
+func (e ErrModulePathOutsideRepository) Error() string {
+	return fmt.Sprintf("module path '%s' links outside of the repository at ref %s", e.Path, e.Ref)
+}
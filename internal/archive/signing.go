@@ -0,0 +1,69 @@
+package archive
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLSigner signs the archive URLs handed to terraform, so they can be
+// downloaded without a token until they expire. Terraform doesn't send its
+// credentials when it fetches the URL from X-Terraform-Get.
+type URLSigner struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func (s *URLSigner) signature(path string, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(path + "\n" + expires))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign adds the expiry and signature to a download location, after any
+// `//subdir`, which is where terraform expects the query.
+func (s *URLSigner) Sign(location string, now time.Time) string {
+	path, subdir := location, ""
+
+	if i := strings.Index(location, "//"); i > -1 {
+		path, subdir = location[:i], location[i:]
+	}
+
+	expires := strconv.FormatInt(now.Add(s.ttl).Unix(), 10)
+
+	q := url.Values{}
+	q.Set("expires", expires)
+	q.Set("signature", s.signature(path, expires))
+
+	return path + subdir + "?" + q.Encode()
+}
+
+// Verify is true when the query holds an unexpired signature for the path.
+func (s *URLSigner) Verify(path string, q url.Values, now time.Time) bool {
+	expires := q.Get("expires")
+	at, err := strconv.ParseInt(expires, 10, 64)
+
+	if err != nil || now.Unix() > at {
+		return false
+	}
+
+	return hmac.Equal([]byte(q.Get("signature")), []byte(s.signature(path, expires)))
+}
+
+// IsModuleKey is true for the archives of module versions, local or proxied,
+// as opposed to the files of provider releases.
+func IsModuleKey(key string) bool {
+	return strings.HasPrefix(key, "modules/") || strings.HasPrefix(key, "upstream/")
+}
+
+func NewURLSigner(secret []byte, ttl time.Duration) *URLSigner {
+	return &URLSigner{
+		secret: secret,
+		ttl:    ttl,
+	}
+}
@@ -0,0 +1,78 @@
+package archive
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_URLSigner_Sign(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewURLSigner([]byte("secret"), time.Minute)
+
+	tests := []struct {
+		name     string
+		location string
+		path     string
+		suffix   string
+	}{
+		{
+			name:     "without subdir",
+			location: "/archives/modules/acme/vpc/aws/1.0.0.tar.gz",
+			path:     "/archives/modules/acme/vpc/aws/1.0.0.tar.gz",
+			suffix:   "",
+		},
+		{
+			name:     "with subdir",
+			location: "/archives/upstream/registry.terraform.io/acme/vpc/aws/1.0.0.tar.gz//modules/vpc",
+			path:     "/archives/upstream/registry.terraform.io/acme/vpc/aws/1.0.0.tar.gz",
+			suffix:   "//modules/vpc",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			signed := s.Sign(test.location, now)
+			parts := strings.SplitN(signed, "?", 2)
+
+			assert.Len(tt, parts, 2)
+			assert.Equal(tt, test.path+test.suffix, parts[0])
+
+			q, err := url.ParseQuery(parts[1])
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, "1700000060", q.Get("expires"))
+			assert.True(tt, s.Verify(test.path, q, now))
+		})
+	}
+}
+
+func Test_URLSigner_Verify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	path := "/archives/modules/acme/vpc/aws/1.0.0.tar.gz"
+	s := NewURLSigner([]byte("secret"), time.Minute)
+
+	signed, err := url.ParseQuery(strings.SplitN(s.Sign(path, now), "?", 2)[1])
+
+	assert.Nil(t, err)
+
+	tampered := url.Values{}
+	tampered.Set("expires", "1800000000")
+	tampered.Set("signature", signed.Get("signature"))
+
+	assert.True(t, s.Verify(path, signed, now.Add(time.Minute)))
+	assert.False(t, s.Verify(path, signed, now.Add(time.Minute+time.Second)), "expired")
+	assert.False(t, s.Verify("/archives/modules/acme/vpc/aws/2.0.0.tar.gz", signed, now), "other path")
+	assert.False(t, s.Verify(path, tampered, now), "other expiry")
+	assert.False(t, s.Verify(path, url.Values{}, now), "unsigned")
+	assert.False(t, NewURLSigner([]byte("other"), time.Minute).Verify(path, signed, now), "other secret")
+}
+
+func Test_IsModuleKey(t *testing.T) {
+	assert.True(t, IsModuleKey("modules/acme/vpc/aws/1.0.0.tar.gz"))
+	assert.True(t, IsModuleKey("upstream/registry.terraform.io/acme/vpc/aws/1.0.0.tar.gz"))
+	assert.False(t, IsModuleKey("providers/acme/aws/1.0.0/terraform-provider-aws_1.0.0_linux_amd64.zip"))
+}
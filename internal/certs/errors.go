@@ -0,0 +1,19 @@
+package certs
+
+import "fmt"
+
+type ErrUnsupportedVersion struct {
+	Version string
+}
+
+func (e ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("tls version '%s' is not supported, use 1.2 or 1.3", e.Version)
+}
+
+type ErrNoCertificates struct {
+	Path string
+}
+
+func (e ErrNoCertificates) Error() string {
+	return fmt.Sprintf("no PEM certificates were found in %s", e.Path)
+}
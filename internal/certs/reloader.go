@@ -0,0 +1,231 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+)
+
+// ParseMinVersion is the TLS version for a config value such as `1.3`, TLS 1.2
+// when it is empty. Older versions aren't supported.
+func ParseMinVersion(v string) (uint16, error) {
+	switch v {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, ErrUnsupportedVersion{Version: v}
+	}
+}
+
+// Files are the PEM files a server's TLS config is loaded from, the client CA
+// is optional.
+type Files struct {
+	Cert     string
+	Key      string
+	ClientCA string
+}
+
+func (f Files) paths() []string {
+	paths := []string{f.Cert, f.Key}
+
+	if f.ClientCA != "" {
+		paths = append(paths, f.ClientCA)
+	}
+
+	return paths
+}
+
+type Options struct {
+	MinVersion uint16
+	// Rejects clients without a certificate signed by the client CA, otherwise
+	// only the certificates that are presented are verified
+	RequireClientCert bool
+}
+
+// Reloader holds the certificate and client CAs loaded from the files, and
+// loads them again when any of the files change, so that a renewed
+// certificate is used without restarting the server.
+type Reloader struct {
+	fs     afero.Fs
+	files  Files
+	logger zerolog.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+}
+
+// NewReloader loads the files, which must be valid for the server to start.
+func NewReloader(fs afero.Fs, files Files, logger zerolog.Logger) (*Reloader, error) {
+	r := &Reloader{
+		fs:     fs,
+		files:  files,
+		logger: logger,
+	}
+
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Reloader) changed() (map[string]time.Time, bool, error) {
+	modTimes := map[string]time.Time{}
+	changed := r.modTimes == nil
+
+	for _, p := range r.files.paths() {
+		info, err := r.fs.Stat(p)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		modTimes[p] = info.ModTime()
+
+		if !info.ModTime().Equal(r.modTimes[p]) {
+			changed = true
+		}
+	}
+
+	return modTimes, changed, nil
+}
+
+func (r *Reloader) load() (*tls.Certificate, *x509.CertPool, error) {
+	certPEM, err := afero.ReadFile(r.fs, r.files.Cert)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyPEM, err := afero.ReadFile(r.fs, r.files.Key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.files.ClientCA == "" {
+		return &cert, nil, nil
+	}
+
+	caPEM, err := afero.ReadFile(r.fs, r.files.ClientCA)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, nil, ErrNoCertificates{Path: r.files.ClientCA}
+	}
+
+	return &cert, pool, nil
+}
+
+// Reload loads the files again when any of them have changed since they were
+// last loaded. Invalid files are an error, and the files loaded before are
+// kept, as a certificate is often replaced before its key.
+func (r *Reloader) Reload() (bool, error) {
+	modTimes, changed, err := r.changed()
+
+	if err != nil || !changed {
+		return false, err
+	}
+
+	cert, pool, err := r.load()
+
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = cert
+	r.clientCAs = pool
+	r.modTimes = modTimes
+
+	return true, nil
+}
+
+// Run checks for changed files every interval, until the context is done.
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := r.Reload()
+
+		if err != nil {
+			r.logger.Error().Err(err).Str("cert", r.files.Cert).Msg("failed to reload tls files, still using the previous ones")
+			continue
+		}
+
+		if reloaded {
+			r.logger.Info().Str("cert", r.files.Cert).Msg("reloaded tls files")
+		}
+	}
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, r.clientCAs
+}
+
+// TLSConfig is a server config using whichever certificate and client CAs are
+// current when a client connects.
+func (r *Reloader) TLSConfig(o Options) *tls.Config {
+	clientAuth := tls.NoClientCert
+
+	if r.files.ClientCA != "" {
+		clientAuth = tls.VerifyClientCertIfGiven
+
+		if o.RequireClientCert {
+			clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	build := func() *tls.Config {
+		cert, pool := r.current()
+
+		return &tls.Config{
+			MinVersion:   o.MinVersion,
+			Certificates: []tls.Certificate{*cert},
+			ClientCAs:    pool,
+			ClientAuth:   clientAuth,
+			NextProtos:   []string{"h2", "http/1.1"},
+		}
+	}
+
+	cfg := build()
+	// The server only checks the config has a certificate, each handshake
+	// gets the config as it is then
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		return build(), nil
+	}
+
+	return cfg
+}
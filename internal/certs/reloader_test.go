@@ -0,0 +1,303 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// issue creates a certificate for the common name, signed by the parent, or
+// self-signed as a CA without one.
+func issue(t *testing.T, cn string, parent *testCert, usage x509.ExtKeyUsage) testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Acme"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		DNSNames:     []string{cn},
+	}
+
+	signer, signerKey := tpl, key
+
+	if parent == nil {
+		tpl.IsCA = true
+		tpl.BasicConstraintsValid = true
+	} else {
+		tpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, signer, &key.PublicKey, signerKey)
+	assert.Nil(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	return testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeFile(t *testing.T, fs afero.Fs, path string, b []byte, modTime time.Time) {
+	assert.Nil(t, afero.WriteFile(fs, path, b, 0600))
+	assert.Nil(t, fs.Chtimes(path, modTime, modTime))
+}
+
+var testFiles = Files{
+	Cert:     "/tls/tls.crt",
+	Key:      "/tls/tls.key",
+	ClientCA: "/tls/clients.crt",
+}
+
+// handshake connects a client to a server using the config, returning the
+// certificate the server presented and the client certificate it verified.
+func handshake(t *testing.T, cfg *tls.Config, roots *x509.CertPool, client *testCert) (*x509.Certificate, *x509.Certificate, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+
+	type accepted struct {
+		verified *x509.Certificate
+		err      error
+	}
+
+	done := make(chan accepted, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+
+		if err != nil {
+			done <- accepted{err: err}
+			return
+		}
+
+		defer conn.Close()
+
+		s := tls.Server(conn, cfg)
+
+		if err := s.Handshake(); err != nil {
+			done <- accepted{err: err}
+			return
+		}
+
+		// Lets the client see whether its certificate was accepted
+		s.Write([]byte("ok"))
+
+		a := accepted{}
+
+		if chains := s.ConnectionState().VerifiedChains; len(chains) > 0 {
+			a.verified = chains[0][0]
+		}
+
+		done <- a
+	}()
+
+	clientCfg := &tls.Config{
+		RootCAs:    roots,
+		ServerName: "registry.example.com",
+	}
+
+	if client != nil {
+		// Sent even when it isn't signed by a CA the server asks for
+		clientCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &tls.Certificate{
+				Certificate: [][]byte{client.cert.Raw},
+				PrivateKey:  client.key,
+			}, nil
+		}
+	}
+
+	c, err := tls.Dial("tcp", ln.Addr().String(), clientCfg)
+
+	if err != nil {
+		<-done
+		return nil, nil, err
+	}
+
+	defer c.Close()
+
+	_, readErr := c.Read(make([]byte, 2))
+	a := <-done
+
+	if a.err != nil {
+		return nil, nil, a.err
+	}
+
+	if readErr != nil {
+		return nil, nil, readErr
+	}
+
+	return c.ConnectionState().PeerCertificates[0], a.verified, nil
+}
+
+func Test_ParseMinVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       string
+		expect  uint16
+		wantErr error
+	}{
+		{name: "default", v: "", expect: tls.VersionTLS12},
+		{name: "1.2", v: "1.2", expect: tls.VersionTLS12},
+		{name: "1.3", v: "1.3", expect: tls.VersionTLS13},
+		{name: "too old", v: "1.0", wantErr: ErrUnsupportedVersion{Version: "1.0"}},
+		{name: "not a version", v: "tls1.3", wantErr: ErrUnsupportedVersion{Version: "tls1.3"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			v, err := ParseMinVersion(test.v)
+
+			assert.Equal(tt, test.wantErr, err)
+			assert.Equal(tt, test.expect, v)
+		})
+	}
+}
+
+func Test_Reloader(t *testing.T) {
+	ca := issue(t, "Acme CA", nil, 0)
+	first := issue(t, "registry.example.com", &ca, x509.ExtKeyUsageServerAuth)
+	second := issue(t, "registry.example.com", &ca, x509.ExtKeyUsageServerAuth)
+	client := issue(t, "deploy-1", &ca, x509.ExtKeyUsageClientAuth)
+	other := issue(t, "deploy-2", &ca, x509.ExtKeyUsageClientAuth)
+	otherCA := issue(t, "Other CA", nil, 0)
+	stranger := issue(t, "stranger", &otherCA, x509.ExtKeyUsageClientAuth)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	loadedAt := time.Now().Add(-time.Minute)
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, testFiles.Cert, first.certPEM, loadedAt)
+	writeFile(t, fs, testFiles.Key, first.keyPEM, loadedAt)
+	writeFile(t, fs, testFiles.ClientCA, ca.certPEM, loadedAt)
+
+	r, err := NewReloader(fs, testFiles, zerolog.Nop())
+	assert.Nil(t, err)
+
+	cfg := r.TLSConfig(Options{MinVersion: tls.VersionTLS12})
+
+	served, verified, err := handshake(t, cfg, roots, &client)
+	assert.Nil(t, err)
+	assert.Equal(t, first.cert.SerialNumber, served.SerialNumber)
+	assert.Equal(t, "deploy-1", verified.Subject.CommonName)
+
+	_, verified, err = handshake(t, cfg, roots, nil)
+	assert.Nil(t, err, "a certificate is optional unless it is required")
+	assert.Nil(t, verified)
+
+	_, _, err = handshake(t, cfg, roots, &stranger)
+	assert.NotNil(t, err, "a certificate from another CA is rejected")
+
+	reloaded, err := r.Reload()
+	assert.Nil(t, err)
+	assert.False(t, reloaded, "nothing has changed")
+
+	// The certificate is replaced before its key, until both are written the
+	// previous pair is kept
+	writeFile(t, fs, testFiles.Cert, second.certPEM, loadedAt.Add(time.Second))
+
+	reloaded, err = r.Reload()
+	assert.NotNil(t, err)
+	assert.False(t, reloaded)
+
+	served, _, err = handshake(t, cfg, roots, &client)
+	assert.Nil(t, err)
+	assert.Equal(t, first.cert.SerialNumber, served.SerialNumber)
+
+	writeFile(t, fs, testFiles.Key, second.keyPEM, loadedAt.Add(time.Second))
+
+	reloaded, err = r.Reload()
+	assert.Nil(t, err)
+	assert.True(t, reloaded)
+
+	served, _, err = handshake(t, cfg, roots, &other)
+	assert.Nil(t, err)
+	assert.Equal(t, second.cert.SerialNumber, served.SerialNumber)
+
+	// The client CA is reloaded too
+	writeFile(t, fs, testFiles.ClientCA, otherCA.certPEM, loadedAt.Add(2*time.Second))
+
+	reloaded, err = r.Reload()
+	assert.Nil(t, err)
+	assert.True(t, reloaded)
+
+	_, verified, err = handshake(t, cfg, roots, &stranger)
+	assert.Nil(t, err)
+	assert.Equal(t, "stranger", verified.Subject.CommonName)
+}
+
+func Test_Reloader_TLSConfig(t *testing.T) {
+	ca := issue(t, "Acme CA", nil, 0)
+	server := issue(t, "registry.example.com", &ca, x509.ExtKeyUsageServerAuth)
+
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, testFiles.Cert, server.certPEM, time.Now())
+	writeFile(t, fs, testFiles.Key, server.keyPEM, time.Now())
+	writeFile(t, fs, testFiles.ClientCA, ca.certPEM, time.Now())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	r, err := NewReloader(fs, testFiles, zerolog.Nop())
+	assert.Nil(t, err)
+
+	_, _, err = handshake(t, r.TLSConfig(Options{MinVersion: tls.VersionTLS13, RequireClientCert: true}), roots, nil)
+	assert.NotNil(t, err, "a client certificate is required")
+
+	withoutCA, err := NewReloader(fs, Files{Cert: testFiles.Cert, Key: testFiles.Key}, zerolog.Nop())
+	assert.Nil(t, err)
+
+	_, verified, err := handshake(t, withoutCA.TLSConfig(Options{MinVersion: tls.VersionTLS13, RequireClientCert: true}), roots, nil)
+	assert.Nil(t, err, "client certificates aren't requested without a client CA")
+	assert.Nil(t, verified)
+}
+
+func Test_NewReloader_invalid(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, testFiles.Cert, []byte("not a certificate"), time.Now())
+	writeFile(t, fs, testFiles.Key, []byte("not a key"), time.Now())
+
+	r, err := NewReloader(fs, Files{Cert: testFiles.Cert, Key: testFiles.Key}, zerolog.Nop())
+	assert.NotNil(t, err)
+	assert.Nil(t, r)
+
+	ca := issue(t, "Acme CA", nil, 0)
+	server := issue(t, "registry.example.com", &ca, x509.ExtKeyUsageServerAuth)
+	writeFile(t, fs, testFiles.Cert, server.certPEM, time.Now())
+	writeFile(t, fs, testFiles.Key, server.keyPEM, time.Now())
+	writeFile(t, fs, testFiles.ClientCA, []byte("not a certificate"), time.Now())
+
+	r, err = NewReloader(fs, testFiles, zerolog.Nop())
+	assert.Equal(t, ErrNoCertificates{Path: testFiles.ClientCA}, err)
+	assert.Nil(t, r)
+}
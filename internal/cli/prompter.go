@@ -14,6 +14,16 @@ func (p *Prompter) Ask(q string) (a string, err error) {
 	return prompt.Run()
 }
 
+// AskSecret doesn't echo the answer.
+func (p *Prompter) AskSecret(q string) (a string, err error) {
+	prompt := promptui.Prompt{
+		Label: q,
+		Mask:  '*',
+	}
+
+	return prompt.Run()
+}
+
 func NewPrompter() *Prompter {
 	return &Prompter{}
 }
@@ -4,8 +4,42 @@ import (
 	"github.com/svartlfheim/ymir/internal/db"
 )
 
+// TLSConfig serves HTTPS with the certificate and key, which are loaded again
+// when either file changes. With a client CA, the certificates clients present
+// are verified against it.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// The oldest TLS version clients may use, 1.2 when not set
+	MinVersion   string `yaml:"min_version"`
+	ClientCAFile string `yaml:"client_ca_file"`
+	// Rejects clients without a certificate signed by the client CA, rather
+	// than only verifying the certificates that are presented
+	RequireClientCert bool `yaml:"require_client_cert"`
+	// Seconds between checks for a changed certificate, key or client CA
+	ReloadInterval int `yaml:"reload_interval"`
+}
+
+// TimeoutsConfig are in seconds, the defaults are used for any that are 0.
+type TimeoutsConfig struct {
+	ReadHeader int `yaml:"read_header"`
+	// Covers the request body, so bounds how long an archive upload can take
+	Read int `yaml:"read"`
+	// Covers the response, so bounds how long an archive download can take
+	Write int `yaml:"write"`
+	// How long a keep-alive connection is kept open between requests
+	Idle int `yaml:"idle"`
+	// How long requests in flight are given to finish on SIGTERM, before
+	// they are cancelled
+	Shutdown int `yaml:"shutdown"`
+}
+
 type ServerConfig struct {
 	Port string `yaml:"port"`
+	// The hostname terraform addresses the registry by, as in module sources
+	Hostname string         `yaml:"hostname"`
+	TLS      TLSConfig      `yaml:"tls"`
+	Timeouts TimeoutsConfig `yaml:"timeouts"`
 }
 
 type FSDbOptionsConfig struct {
@@ -96,8 +130,165 @@ type DbConfig struct {
 	Options DbOptionsConfig `yaml:"options"`
 }
 
+type FSStorageOptionsConfig struct {
+	Path string `yaml:"path"`
+}
+
+type StorageOptionsConfig struct {
+	FS FSStorageOptionsConfig `yaml:"fs"`
+}
+
+type StorageConfig struct {
+	Driver  string               `yaml:"driver"`
+	Options StorageOptionsConfig `yaml:"options"`
+}
+
+type WorkerConfig struct {
+	// Seconds to wait between polls for pending module versions
+	Interval int `yaml:"interval"`
+	// Seconds a build may take before it is cancelled and the version failed
+	BuildTimeout int `yaml:"build_timeout" split_words:"true"`
+}
+
+type SyncConfig struct {
+	// Seconds to wait between syncing versions from git tags, 0 disables it
+	Interval int `yaml:"interval"`
+}
+
+type WebhookSecretConfig struct {
+	Secret string `yaml:"secret" split_words:"true"`
+}
+
+// WebhooksConfig holds the secret for each forge webhooks are accepted from,
+// a forge without a secret can't send webhooks.
+type WebhooksConfig struct {
+	Github WebhookSecretConfig `yaml:"github"`
+	Gitlab WebhookSecretConfig `yaml:"gitlab"`
+	Gitea  WebhookSecretConfig `yaml:"gitea"`
+}
+
+type DeliveriesConfig struct {
+	// Seconds to wait between polls for due webhook deliveries
+	Interval int `yaml:"interval"`
+	// Attempts before a delivery is marked as failed
+	MaxAttempts int `yaml:"max_attempts"`
+	// Seconds to wait for a subscriber to respond
+	Timeout int `yaml:"timeout"`
+}
+
+type UpstreamConfig struct {
+	URL        string   `yaml:"url"`
+	Namespaces []string `yaml:"namespaces"`
+	// Seconds a module's versions are cached for, 0 uses the proxy's ttl
+	TTL int `yaml:"ttl"`
+}
+
+// ProxyConfig lists the registries modules are proxied from, for the
+// namespaces each of them is configured with.
+type ProxyConfig struct {
+	// Seconds a module's versions are cached for
+	TTL int `yaml:"ttl"`
+	// Seconds to wait for an upstream to respond
+	Timeout   int              `yaml:"timeout"`
+	Upstreams []UpstreamConfig `yaml:"upstreams"`
+}
+
+// RegistryAuthConfig protects the terraform protocol endpoints, terraform
+// sends a token from the `credentials` block in its cli config.
+type RegistryAuthConfig struct {
+	Required bool `yaml:"required"`
+	// Archive URLs handed to terraform are signed with this, terraform
+	// doesn't send its token when downloading them
+	SigningSecret string `yaml:"signing_secret" split_words:"true"`
+	// Seconds a signed archive URL can be used for
+	SignedURLTTL int `yaml:"signed_url_ttl"`
+}
+
+// LoginConfig enables `terraform login`, for the users created with
+// `ymir user create`.
+type LoginConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// The first and last port terraform may listen on for the redirect
+	Ports []int `yaml:"ports"`
+	// Seconds the tokens issued by a login last for, 0 never expires them
+	TokenTTL int `yaml:"token_ttl"`
+}
+
+// OIDCRuleConfig grants scopes to the tokens of an issuer with the claims,
+// `*` in a claim matches anything but a `/`.
+type OIDCRuleConfig struct {
+	Claims map[string]string `yaml:"claims"`
+	Scopes []string          `yaml:"scopes"`
+	// The namespaces the scopes are limited to, every namespace when empty
+	Namespaces []string `yaml:"namespaces"`
+}
+
+// OIDCIssuerConfig trusts the tokens an identity provider, such as a CI
+// system, issues for the audience. Its keys are loaded from jwks_url, or
+// jwks_file.
+type OIDCIssuerConfig struct {
+	Issuer   string           `yaml:"issuer"`
+	Audience string           `yaml:"audience"`
+	JWKSURL  string           `yaml:"jwks_url"`
+	JWKSFile string           `yaml:"jwks_file"`
+	Rules    []OIDCRuleConfig `yaml:"rules"`
+}
+
+type OIDCConfig struct {
+	// Seconds to wait for an issuer's keys
+	Timeout int                `yaml:"timeout"`
+	Issuers []OIDCIssuerConfig `yaml:"issuers"`
+}
+
+// CertificateRuleConfig grants scopes to the verified client certificates
+// whose subject matches, such as `CN=ci-*,O=Acme`. `*` matches anything but a
+// `/`.
+type CertificateRuleConfig struct {
+	Subject string   `yaml:"subject"`
+	Scopes  []string `yaml:"scopes"`
+	// The namespaces the scopes are limited to, every namespace when empty
+	Namespaces []string `yaml:"namespaces"`
+}
+
+// CertificatesConfig accepts the client certificates verified by the
+// server.tls client CA in place of a token on the management API.
+type CertificatesConfig struct {
+	Rules []CertificateRuleConfig `yaml:"rules"`
+}
+
+type AuthConfig struct {
+	Registry     RegistryAuthConfig `yaml:"registry"`
+	Login        LoginConfig        `yaml:"login"`
+	OIDC         OIDCConfig         `yaml:"oidc"`
+	Certificates CertificatesConfig `yaml:"certificates"`
+}
+
+// MetricsConfig serves Prometheus metrics at /metrics, on the registry's port
+// unless a port is given.
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Port    string `yaml:"port"`
+}
+
+// HealthConfig is in seconds, the defaults are used for any that are 0.
+type HealthConfig struct {
+	// How long each readiness check has before it fails
+	Timeout int `yaml:"timeout"`
+	// How long since a worker last polled before it is considered stuck
+	WorkerStaleAfter int `yaml:"worker_stale_after"`
+}
+
 type Ymir struct {
-	Server ServerConfig `yaml:"server"`
-	Db     DbConfig     `yaml:"db"`
-	Git    GitConfig    `yaml:"git"`
+	Server     ServerConfig     `yaml:"server"`
+	Db         DbConfig         `yaml:"db"`
+	Git        GitConfig        `yaml:"git"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Worker     WorkerConfig     `yaml:"worker"`
+	Sync       SyncConfig       `yaml:"sync"`
+	Webhooks   WebhooksConfig   `yaml:"webhooks"`
+	Deliveries DeliveriesConfig `yaml:"deliveries"`
+	Proxy      ProxyConfig      `yaml:"proxy"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+	Health     HealthConfig     `yaml:"health"`
 }
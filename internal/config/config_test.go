@@ -11,6 +11,20 @@ import (
 var happyYAML string = `#empty line to make it more readable
 server:
   port: 9898
+  hostname: registry.example.com
+  tls:
+    cert_file: /etc/ymir/tls.crt
+    key_file: /etc/ymir/tls.key
+    min_version: "1.3"
+    client_ca_file: /etc/ymir/clients.crt
+    require_client_cert: true
+    reload_interval: 15
+  timeouts:
+    read_header: 5
+    read: 120
+    write: 300
+    idle: 90
+    shutdown: 20
 
 git:
   github:
@@ -30,11 +44,90 @@ db:
       schema: "fake_schema"
       host: "fake_host"
       port: "3333"
+
+storage:
+  driver: "fs"
+  options:
+    fs:
+      path: /some/fake/archives
+
+worker:
+  interval: 7
+  build_timeout: 300
+
+sync:
+  interval: 60
+webhooks:
+  github:
+    secret: fake-github-secret
+  gitlab:
+    secret: fake-gitlab-secret
+deliveries:
+  interval: 3
+  max_attempts: 4
+  timeout: 2
+metrics:
+  enabled: true
+  port: "9100"
+health:
+  timeout: 3
+  worker_stale_after: 900
+proxy:
+  ttl: 600
+  timeout: 30
+  upstreams:
+    - url: https://registry.terraform.io
+      namespaces:
+        - hashicorp
+        - terraform-aws-modules
+      ttl: 60
+auth:
+  registry:
+    required: true
+    signing_secret: fake-signing-secret
+    signed_url_ttl: 120
+  login:
+    enabled: true
+    ports: [10000, 10005]
+    token_ttl: 86400
+  oidc:
+    timeout: 5
+    issuers:
+      - issuer: https://token.actions.githubusercontent.com
+        audience: https://registry.example.com
+        jwks_url: https://token.actions.githubusercontent.com/.well-known/jwks
+        rules:
+          - claims:
+              repository: org/infra-modules
+              ref: refs/tags/*
+            scopes: [versions:publish]
+            namespaces: [platform]
+  certificates:
+    rules:
+      - subject: CN=deploy-*,O=Acme
+        scopes: [modules:write]
+        namespaces: [platform]
 `
 
 var happyCfg Ymir = Ymir{
 	Server: ServerConfig{
-		Port: "9898",
+		Port:     "9898",
+		Hostname: "registry.example.com",
+		TLS: TLSConfig{
+			CertFile:          "/etc/ymir/tls.crt",
+			KeyFile:           "/etc/ymir/tls.key",
+			MinVersion:        "1.3",
+			ClientCAFile:      "/etc/ymir/clients.crt",
+			RequireClientCert: true,
+			ReloadInterval:    15,
+		},
+		Timeouts: TimeoutsConfig{
+			ReadHeader: 5,
+			Read:       120,
+			Write:      300,
+			Idle:       90,
+			Shutdown:   20,
+		},
 	},
 	Git: GitConfig{
 		Github: GithubConfig{
@@ -59,6 +152,94 @@ var happyCfg Ymir = Ymir{
 			},
 		},
 	},
+	Storage: StorageConfig{
+		Driver: "fs",
+		Options: StorageOptionsConfig{
+			FS: FSStorageOptionsConfig{
+				Path: "/some/fake/archives",
+			},
+		},
+	},
+	Worker: WorkerConfig{
+		Interval:     7,
+		BuildTimeout: 300,
+	},
+	Sync: SyncConfig{
+		Interval: 60,
+	},
+	Webhooks: WebhooksConfig{
+		Github: WebhookSecretConfig{
+			Secret: "fake-github-secret",
+		},
+		Gitlab: WebhookSecretConfig{
+			Secret: "fake-gitlab-secret",
+		},
+	},
+	Deliveries: DeliveriesConfig{
+		Interval:    3,
+		MaxAttempts: 4,
+		Timeout:     2,
+	},
+	Metrics: MetricsConfig{
+		Enabled: true,
+		Port:    "9100",
+	},
+	Health: HealthConfig{
+		Timeout:          3,
+		WorkerStaleAfter: 900,
+	},
+	Proxy: ProxyConfig{
+		TTL:     600,
+		Timeout: 30,
+		Upstreams: []UpstreamConfig{
+			{
+				URL:        "https://registry.terraform.io",
+				Namespaces: []string{"hashicorp", "terraform-aws-modules"},
+				TTL:        60,
+			},
+		},
+	},
+	Auth: AuthConfig{
+		Registry: RegistryAuthConfig{
+			Required:      true,
+			SigningSecret: "fake-signing-secret",
+			SignedURLTTL:  120,
+		},
+		Login: LoginConfig{
+			Enabled:  true,
+			Ports:    []int{10000, 10005},
+			TokenTTL: 86400,
+		},
+		OIDC: OIDCConfig{
+			Timeout: 5,
+			Issuers: []OIDCIssuerConfig{
+				{
+					Issuer:   "https://token.actions.githubusercontent.com",
+					Audience: "https://registry.example.com",
+					JWKSURL:  "https://token.actions.githubusercontent.com/.well-known/jwks",
+					Rules: []OIDCRuleConfig{
+						{
+							Claims: map[string]string{
+								"repository": "org/infra-modules",
+								"ref":        "refs/tags/*",
+							},
+							Scopes:     []string{"versions:publish"},
+							Namespaces: []string{"platform"},
+						},
+					},
+				},
+			},
+		},
+		Certificates: CertificatesConfig{
+			Rules: []CertificateRuleConfig{
+				{
+					Subject:    "CN=deploy-*,O=Acme",
+					Scopes:     []string{"modules:write"},
+					Namespaces: []string{"platform"},
+				},
+			},
+		},
+	},
 }
 
 func Test_ConfigUnmarshalsFromYAML(t *testing.T) {
@@ -0,0 +1,34 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ErrCommandFailed struct {
+	Args    []string
+	Stderr  string
+	Wrapped error
+}
+
+func (e ErrCommandFailed) Error() string {
+	return fmt.Sprintf("git %s failed: %s: %s", strings.Join(e.Args, " "), e.Wrapped.Error(), e.Stderr)
+}
+
+type ErrRefNotFound struct {
+	Repository string
+	Ref        string
+}
+
+func (e ErrRefNotFound) Error() string {
+	return fmt.Sprintf("ref '%s' could not be found in %s", e.Ref, e.Repository)
+}
+
+type ErrInvalidArgument struct {
+	Name  string
+	Value string
+}
+
+func (e ErrInvalidArgument) Error() string {
+	return fmt.Sprintf("the %s '%s' can't start with '-'", e.Name, e.Value)
+}
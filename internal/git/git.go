@@ -0,0 +1,250 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Client shells out to the git binary, so that any remote (or local path) git
+// can reach, using whatever credentials are configured for it, can be used.
+type Client struct {
+	binary string
+	logger zerolog.Logger
+}
+
+// run kills git once the context is done, so a remote that stops responding
+// can't hold up the caller.
+func (c *Client) run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, c.binary, args...)
+	cmd.Dir = dir
+	// Never block waiting for credentials on a terminal
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		c.logger.Debug().Strs("args", args).Str("stderr", stderr.String()).Msg("git command failed")
+
+		return "", ErrCommandFailed{
+			Args:    args,
+			Stderr:  strings.TrimSpace(stderr.String()),
+			Wrapped: err,
+		}
+	}
+
+	return stdout.String(), nil
+}
+
+// checkArgument rejects a repository or ref that git would read as an option,
+// such as --upload-pack=<cmd>, which runs the command.
+func checkArgument(name string, value string) error {
+	if strings.HasPrefix(value, "-") {
+		return ErrInvalidArgument{
+			Name:  name,
+			Value: value,
+		}
+	}
+
+	return nil
+}
+
+func isLocalDirectory(repo string) bool {
+	info, err := os.Stat(repo)
+
+	return err == nil && info.IsDir()
+}
+
+// Clone clones repo into dir, and checks out ref if one is supplied.
+func (c *Client) Clone(ctx context.Context, repo string, ref string, dir string) error {
+	if err := checkArgument("repository", repo); err != nil {
+		return err
+	}
+
+	if err := checkArgument("ref", ref); err != nil {
+		return err
+	}
+
+	if _, err := c.run(ctx, "", "clone", "--quiet", "--", repo, dir); err != nil {
+		return err
+	}
+
+	if ref == "" {
+		return nil
+	}
+
+	// checkout reads --end-of-options as a pathspec, the trailing -- is what
+	// stops the ref from being read as a path
+	_, err := c.run(ctx, dir, "checkout", "--quiet", ref, "--")
+
+	return err
+}
+
+// Checkout makes the contents of repo at ref available in a local directory.
+// A local directory with no ref is used as is, otherwise the repository is
+// cloned into a temporary directory. The returned cleanup func must be called
+// once the caller is finished with the directory.
+func (c *Client) Checkout(ctx context.Context, repo string, ref string) (dir string, cleanup func(), err error) {
+	if ref == "" && isLocalDirectory(repo) {
+		return repo, func() {}, nil
+	}
+
+	dir, err = os.MkdirTemp("", "ymir-checkout-")
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	cleanup = func() {
+		if err := os.RemoveAll(dir); err != nil {
+			c.logger.Error().Err(err).Str("dir", dir).Msg("failed to remove checkout")
+		}
+	}
+
+	if err := c.Clone(ctx, repo, ref, dir); err != nil {
+		cleanup()
+
+		return "", nil, err
+	}
+
+	return dir, cleanup, nil
+}
+
+// parseLsRemote maps each ref name to the SHA it points to.
+func parseLsRemote(out string) map[string]string {
+	found := map[string]string{}
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		parts := strings.Fields(line)
+
+		if len(parts) != 2 {
+			continue
+		}
+
+		found[parts[1]] = parts[0]
+	}
+
+	return found
+}
+
+// ListTags returns every tag in repo, mapped to the SHA of the commit it
+// points to.
+func (c *Client) ListTags(ctx context.Context, repo string) (map[string]string, error) {
+	if err := checkArgument("repository", repo); err != nil {
+		return nil, err
+	}
+
+	out, err := c.run(ctx, "", "ls-remote", "--tags", "--", repo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{}
+
+	for name, sha := range parseLsRemote(out) {
+		tag := strings.TrimPrefix(name, "refs/tags/")
+
+		if strings.HasSuffix(tag, "^{}") {
+			// The peeled commit of an annotated tag always wins
+			tags[strings.TrimSuffix(tag, "^{}")] = sha
+			continue
+		}
+
+		if _, ok := tags[tag]; !ok {
+			tags[tag] = sha
+		}
+	}
+
+	return tags, nil
+}
+
+var fullShaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+var shortShaPattern = regexp.MustCompile(`^[0-9a-f]{7,39}$`)
+
+// ResolveRef resolves a branch, tag or (abbreviated) commit to the full SHA
+// of the commit it currently points to.
+func (c *Client) ResolveRef(ctx context.Context, repo string, ref string) (string, error) {
+	if err := checkArgument("repository", repo); err != nil {
+		return "", err
+	}
+
+	if err := checkArgument("ref", ref); err != nil {
+		return "", err
+	}
+
+	if fullShaPattern.MatchString(ref) {
+		return ref, nil
+	}
+
+	out, err := c.run(ctx, "", "ls-remote", "--", repo, ref, "refs/tags/"+ref+"^{}")
+
+	if err != nil {
+		return "", err
+	}
+
+	found := parseLsRemote(out)
+
+	// Annotated tags must be peeled to get the commit they point at
+	for _, name := range []string{
+		"refs/tags/" + ref + "^{}",
+		ref,
+		"refs/heads/" + ref,
+		"refs/tags/" + ref,
+	} {
+		if sha, ok := found[name]; ok {
+			return sha, nil
+		}
+	}
+
+	if shortShaPattern.MatchString(ref) {
+		return c.resolveAbbreviatedSha(ctx, repo, ref)
+	}
+
+	return "", ErrRefNotFound{
+		Repository: repo,
+		Ref:        ref,
+	}
+}
+
+// An abbreviated SHA can only be expanded with the objects available, so a
+// bare clone is required.
+func (c *Client) resolveAbbreviatedSha(ctx context.Context, repo string, ref string) (string, error) {
+	dir, err := os.MkdirTemp("", "ymir-resolve-")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer os.RemoveAll(dir)
+
+	if _, err := c.run(ctx, "", "clone", "--quiet", "--bare", "--filter=blob:none", "--", repo, dir); err != nil {
+		return "", err
+	}
+
+	out, err := c.run(ctx, dir, "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}")
+
+	if err != nil {
+		return "", ErrRefNotFound{
+			Repository: repo,
+			Ref:        ref,
+		}
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+func NewClient(l zerolog.Logger) *Client {
+	return &Client{
+		binary: "git",
+		logger: l,
+	}
+}
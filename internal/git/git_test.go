@@ -0,0 +1,185 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=ymir",
+		"GIT_AUTHOR_EMAIL=ymir@example.com",
+		"GIT_COMMITTER_NAME=ymir",
+		"GIT_COMMITTER_EMAIL=ymir@example.com",
+	)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %s", args, out)
+	}
+}
+
+func buildTestRepository(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is not available")
+	}
+
+	dir := t.TempDir()
+
+	runGit(t, dir, "init", "--quiet")
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("# v1"), 0644))
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "--quiet", "-m", "first")
+	runGit(t, dir, "tag", "v1.0.0")
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("# v2"), 0644))
+	runGit(t, dir, "commit", "--quiet", "-am", "second")
+
+	return dir
+}
+
+func TestClient_Checkout_LocalDirectoryWithoutRef(t *testing.T) {
+	repo := buildTestRepository(t)
+	c := NewClient(ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+
+	dir, cleanup, err := c.Checkout(context.Background(), repo, "")
+	defer cleanup()
+
+	assert.Nil(t, err)
+	assert.Equal(t, repo, dir)
+}
+
+func TestClient_Checkout_AtRef(t *testing.T) {
+	repo := buildTestRepository(t)
+	c := NewClient(ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+
+	dir, cleanup, err := c.Checkout(context.Background(), repo, "v1.0.0")
+
+	assert.Nil(t, err)
+	assert.NotEqual(t, repo, dir)
+
+	b, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	assert.Nil(t, err)
+	assert.Equal(t, "# v1", string(b))
+
+	cleanup()
+
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestClient_Checkout_UnknownRef(t *testing.T) {
+	repo := buildTestRepository(t)
+	c := NewClient(ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+
+	_, _, err := c.Checkout(context.Background(), repo, "does-not-exist")
+
+	assert.IsType(t, ErrCommandFailed{}, err)
+}
+
+func revParse(t *testing.T, dir string, ref string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", ref).Output()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(bytes.TrimSpace(out))
+}
+
+func TestClient_ResolveRef(t *testing.T) {
+	repo := buildTestRepository(t)
+	runGit(t, repo, "tag", "-a", "-m", "annotated", "v2.0.0")
+	c := NewClient(ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+
+	first := revParse(t, repo, "v1.0.0")
+	head := revParse(t, repo, "HEAD")
+	branch := strings.TrimSpace(func() string {
+		out, _ := exec.Command("git", "-C", repo, "branch", "--show-current").Output()
+		return string(out)
+	}())
+
+	tests := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{name: "lightweight tag", ref: "v1.0.0", expected: first},
+		{name: "annotated tag", ref: "v2.0.0", expected: head},
+		{name: "branch", ref: branch, expected: head},
+		{name: "full sha", ref: first, expected: first},
+		{name: "abbreviated sha", ref: first[:8], expected: first},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			sha, err := c.ResolveRef(context.Background(), repo, test.ref)
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expected, sha)
+		})
+	}
+
+	_, err := c.ResolveRef(context.Background(), repo, "v9.9.9")
+
+	assert.Equal(t, ErrRefNotFound{Repository: repo, Ref: "v9.9.9"}, err)
+}
+
+func TestClient_ListTags(t *testing.T) {
+	repo := buildTestRepository(t)
+	runGit(t, repo, "tag", "-a", "-m", "annotated", "modules/vpc/v2.0.0")
+	c := NewClient(ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+
+	tags, err := c.ListTags(context.Background(), repo)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"v1.0.0": revParse(t, repo, "v1.0.0"),
+		// Annotated tags resolve to the commit, not the tag object
+		"modules/vpc/v2.0.0": revParse(t, repo, "HEAD"),
+	}, tags)
+}
+
+func TestClient_RejectsOptionsAsArguments(t *testing.T) {
+	repo := buildTestRepository(t)
+	c := NewClient(ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+	marker := filepath.Join(t.TempDir(), "ran")
+	injected := "--upload-pack=touch " + marker
+
+	_, err := c.ListTags(context.Background(), injected)
+	assert.Equal(t, ErrInvalidArgument{Name: "repository", Value: injected}, err)
+
+	_, err = c.ResolveRef(context.Background(), injected, "main")
+	assert.Equal(t, ErrInvalidArgument{Name: "repository", Value: injected}, err)
+
+	_, err = c.ResolveRef(context.Background(), repo, "--output=/tmp/ref")
+	assert.Equal(t, ErrInvalidArgument{Name: "ref", Value: "--output=/tmp/ref"}, err)
+
+	_, _, err = c.Checkout(context.Background(), injected, "v1.0.0")
+	assert.Equal(t, ErrInvalidArgument{Name: "repository", Value: injected}, err)
+
+	_, _, err = c.Checkout(context.Background(), repo, "--orphan=main")
+	assert.Equal(t, ErrInvalidArgument{Name: "ref", Value: "--orphan=main"}, err)
+
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestClient_StopsWhenTheContextIsDone(t *testing.T) {
+	repo := buildTestRepository(t)
+	c := NewClient(ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.ListTags(ctx, repo)
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,27 @@
+package gpg
+
+import "fmt"
+
+type ErrInvalidKey struct {
+	Wrapped error
+}
+
+func (e ErrInvalidKey) Error() string {
+	return fmt.Sprintf("not an ascii armored gpg public key: %s", e.Wrapped.Error())
+}
+
+type ErrMultipleKeys struct {
+	Count int
+}
+
+func (e ErrMultipleKeys) Error() string {
+	return fmt.Sprintf("expected a single gpg public key, found %d", e.Count)
+}
+
+type ErrSignatureNotVerified struct {
+	Wrapped error
+}
+
+func (e ErrSignatureNotVerified) Error() string {
+	return fmt.Sprintf("signature could not be verified with any of the keys: %s", e.Wrapped.Error())
+}
@@ -0,0 +1,73 @@
+package gpg
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+const armorHeader = "-----BEGIN PGP"
+
+func readKeys(armored ...string) (openpgp.EntityList, error) {
+	keyring := openpgp.EntityList{}
+
+	for _, a := range armored {
+		keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(a))
+
+		if err != nil {
+			return nil, ErrInvalidKey{
+				Wrapped: err,
+			}
+		}
+
+		keyring = append(keyring, keys...)
+	}
+
+	return keyring, nil
+}
+
+// KeyId returns the id of the ascii armored public key, in the upper case hex
+// form terraform expects in a provider's signing keys.
+func KeyId(armored string) (string, error) {
+	keys, err := readKeys(armored)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(keys) != 1 {
+		return "", ErrMultipleKeys{
+			Count: len(keys),
+		}
+	}
+
+	return strings.ToUpper(keys[0].PrimaryKey.KeyIdString()), nil
+}
+
+// VerifyDetached checks the signature of the message was made by one of the
+// ascii armored keys, returning the id of that key. Both armored and binary
+// signatures are accepted, goreleaser produces the latter by default.
+func VerifyDetached(armoredKeys []string, message []byte, signature []byte) (string, error) {
+	keyring, err := readKeys(armoredKeys...)
+
+	if err != nil {
+		return "", err
+	}
+
+	var signer *openpgp.Entity
+
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte(armorHeader)) {
+		signer, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(message), bytes.NewReader(signature), nil)
+	} else {
+		signer, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(message), bytes.NewReader(signature), nil)
+	}
+
+	if err != nil {
+		return "", ErrSignatureNotVerified{
+			Wrapped: err,
+		}
+	}
+
+	return strings.ToUpper(signer.PrimaryKey.KeyIdString()), nil
+}
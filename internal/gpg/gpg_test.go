@@ -0,0 +1,110 @@
+package gpg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+)
+
+func armorKeys(t *testing.T, keys ...*openpgp.Entity) string {
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	assert.Nil(t, err)
+
+	for _, e := range keys {
+		assert.Nil(t, e.Serialize(w))
+	}
+
+	assert.Nil(t, w.Close())
+
+	return buf.String()
+}
+
+func buildKey(t *testing.T, name string) (*openpgp.Entity, string) {
+	e, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	assert.Nil(t, err)
+
+	return e, armorKeys(t, e)
+}
+
+func Test_KeyId(t *testing.T) {
+	e, armored := buildKey(t, "release")
+
+	id, err := KeyId(armored)
+
+	assert.Nil(t, err)
+	assert.Len(t, id, 16)
+	assert.Equal(t, strings.ToUpper(e.PrimaryKey.KeyIdString()), id)
+
+	other, _ := buildKey(t, "other")
+	_, err = KeyId(armorKeys(t, e, other))
+	assert.IsType(t, ErrMultipleKeys{}, err)
+
+	_, err = KeyId("not a key")
+	assert.IsType(t, ErrInvalidKey{}, err)
+}
+
+func Test_VerifyDetached(t *testing.T) {
+	signer, signerArmored := buildKey(t, "release")
+	_, otherArmored := buildKey(t, "other")
+	message := []byte("0123abcd  terraform-provider-internal_1.0.0_linux_amd64.zip\n")
+
+	binarySig := new(bytes.Buffer)
+	assert.Nil(t, openpgp.DetachSign(binarySig, signer, bytes.NewReader(message), nil))
+
+	armoredSig := new(bytes.Buffer)
+	assert.Nil(t, openpgp.ArmoredDetachSign(armoredSig, signer, bytes.NewReader(message), nil))
+
+	tests := []struct {
+		name      string
+		keys      []string
+		message   []byte
+		signature []byte
+		expectErr bool
+	}{
+		{
+			name:      "binary signature",
+			keys:      []string{otherArmored, signerArmored},
+			message:   message,
+			signature: binarySig.Bytes(),
+		},
+		{
+			name:      "armored signature",
+			keys:      []string{signerArmored},
+			message:   message,
+			signature: armoredSig.Bytes(),
+		},
+		{
+			name:      "signed by an unknown key",
+			keys:      []string{otherArmored},
+			message:   message,
+			signature: binarySig.Bytes(),
+			expectErr: true,
+		},
+		{
+			name:      "message was tampered with",
+			keys:      []string{signerArmored},
+			message:   append([]byte("ffff"), message...),
+			signature: binarySig.Bytes(),
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			id, err := VerifyDetached(test.keys, test.message, test.signature)
+
+			if test.expectErr {
+				assert.IsType(tt, ErrSignatureNotVerified{}, err)
+				return
+			}
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, strings.ToUpper(signer.PrimaryKey.KeyIdString()), id)
+		})
+	}
+}
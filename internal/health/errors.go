@@ -0,0 +1,37 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ErrTimedOut struct {
+	After time.Duration
+}
+
+func (e ErrTimedOut) Error() string {
+	return fmt.Sprintf("timed out after %s", e.After)
+}
+
+type ErrPendingMigrations struct {
+	Ids []string
+}
+
+func (e ErrPendingMigrations) Error() string {
+	return fmt.Sprintf("%d migrations are pending: %s", len(e.Ids), strings.Join(e.Ids, ", "))
+}
+
+type ErrWorkerNotStarted struct{}
+
+func (e ErrWorkerNotStarted) Error() string {
+	return "the worker hasn't started"
+}
+
+type ErrWorkerStale struct {
+	Since time.Duration
+}
+
+func (e ErrWorkerStale) Error() string {
+	return fmt.Sprintf("the worker last polled %s ago", e.Since.Round(time.Second))
+}
@@ -0,0 +1,196 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/svartlfheim/gomigrator"
+)
+
+const StatusOK = "ok"
+const StatusFailing = "failing"
+
+// Check is one of the things the registry needs to serve requests, such as
+// its database.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+func run(ctx context.Context, c Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	started := time.Now()
+	done := make(chan error, 1)
+
+	go func() {
+		done <- c.Run(ctx)
+	}()
+
+	var err error
+
+	// Not every check can be cancelled, those are left to finish alone
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrTimedOut{After: timeout}
+	}
+
+	res := Result{
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		res.Status = StatusFailing
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// Run runs the checks at once, the report is failing when any of them fail or
+// take longer than the timeout.
+func Run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: map[string]Result{},
+	}
+
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for _, c := range checks {
+		wg.Add(1)
+
+		go func(c Check) {
+			defer wg.Done()
+
+			res := run(ctx, c, timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[c.Name] = res
+
+			if res.Status != StatusOK {
+				report.Status = StatusFailing
+			}
+		}(c)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Database checks a connection to the database can be used.
+func Database(db pinger) Check {
+	return Check{
+		Name: "database",
+		Run:  db.PingContext,
+	}
+}
+
+type migrationLister interface {
+	ListMigrations() ([]*gomigrator.MigrationRecord, error)
+}
+
+// Migrations checks every migration has been applied, as the queries the
+// registry makes expect the latest schema. Once they have all been applied
+// they aren't listed again, as nothing but a rollback unapplies them.
+func Migrations(m migrationLister) Check {
+	applied := int32(0)
+
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) error {
+			if atomic.LoadInt32(&applied) == 1 {
+				return nil
+			}
+
+			migs, err := m.ListMigrations()
+
+			if err != nil {
+				return err
+			}
+
+			pending := []string{}
+
+			for _, mig := range migs {
+				if mig.Status != string(gomigrator.MigrationApplied) {
+					pending = append(pending, mig.Id)
+				}
+			}
+
+			if len(pending) > 0 {
+				return ErrPendingMigrations{Ids: pending}
+			}
+
+			atomic.StoreInt32(&applied, 1)
+
+			return nil
+		},
+	}
+}
+
+type storagePinger interface {
+	Ping() error
+}
+
+// Storage checks the archives can be reached.
+func Storage(s storagePinger) Check {
+	return Check{
+		Name: "storage",
+		Run: func(ctx context.Context) error {
+			return s.Ping()
+		},
+	}
+}
+
+type worker interface {
+	LastPolled() time.Time
+}
+
+// Worker checks the worker has polled for work within the time, so that a
+// worker that is stuck is noticed.
+func Worker(name string, w worker, staleAfter time.Duration) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) error {
+			last := w.LastPolled()
+
+			if last.IsZero() {
+				return ErrWorkerNotStarted{}
+			}
+
+			if since := time.Since(last); since > staleAfter {
+				return ErrWorkerStale{Since: since}
+			}
+
+			return nil
+		},
+	}
+}
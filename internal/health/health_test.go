@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svartlfheim/gomigrator"
+)
+
+type fakeMigrationLister struct {
+	migs []*gomigrator.MigrationRecord
+	err  error
+}
+
+func (l fakeMigrationLister) ListMigrations() ([]*gomigrator.MigrationRecord, error) {
+	return l.migs, l.err
+}
+
+type fakeWorker struct {
+	last time.Time
+}
+
+func (w fakeWorker) LastPolled() time.Time {
+	return w.last
+}
+
+func Test_Run(t *testing.T) {
+	checks := []Check{
+		{Name: "database", Run: func(ctx context.Context) error { return nil }},
+		{Name: "storage", Run: func(ctx context.Context) error { return errors.New("no such directory") }},
+		{Name: "slow", Run: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}},
+	}
+
+	report := Run(context.Background(), checks, 50*time.Millisecond)
+
+	assert.False(t, report.OK())
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["database"].Status)
+	assert.Equal(t, Result{Status: StatusFailing, LatencyMs: report.Checks["storage"].LatencyMs, Error: "no such directory"}, report.Checks["storage"])
+	assert.Equal(t, StatusFailing, report.Checks["slow"].Status)
+	assert.Equal(t, "timed out after 50ms", report.Checks["slow"].Error)
+	assert.Less(t, report.Checks["slow"].LatencyMs, float64(1000), "the report doesn't wait for a check that timed out")
+
+	report = Run(context.Background(), checks[:1], time.Second)
+
+	assert.True(t, report.OK())
+}
+
+func Test_Migrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		lister   fakeMigrationLister
+		expected error
+	}{
+		{
+			name: "all applied",
+			lister: fakeMigrationLister{migs: []*gomigrator.MigrationRecord{
+				{Id: "create-modules-table", Status: string(gomigrator.MigrationApplied)},
+				{Id: "create-audit-logs-table", Status: string(gomigrator.MigrationApplied)},
+			}},
+		},
+		{
+			name: "pending",
+			lister: fakeMigrationLister{migs: []*gomigrator.MigrationRecord{
+				{Id: "create-modules-table", Status: string(gomigrator.MigrationApplied)},
+				{Id: "create-teams-table", Status: string(gomigrator.MigrationPending)},
+				{Id: "create-role-grants-table", Status: string(gomigrator.MigrationPending)},
+			}},
+			expected: ErrPendingMigrations{Ids: []string{"create-teams-table", "create-role-grants-table"}},
+		},
+		{
+			name:     "can't be listed",
+			lister:   fakeMigrationLister{err: errors.New("permission denied")},
+			expected: errors.New("permission denied"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			assert.Equal(tt, test.expected, Migrations(test.lister).Run(context.Background()))
+		})
+	}
+}
+
+type countingMigrationLister struct {
+	fakeMigrationLister
+	calls *int
+}
+
+func (l countingMigrationLister) ListMigrations() ([]*gomigrator.MigrationRecord, error) {
+	*l.calls++
+
+	return l.fakeMigrationLister.ListMigrations()
+}
+
+func Test_Migrations_NotListedOnceApplied(t *testing.T) {
+	calls := 0
+	migs := []*gomigrator.MigrationRecord{
+		{Id: "create-teams-table", Status: string(gomigrator.MigrationPending)},
+	}
+	lister := countingMigrationLister{
+		fakeMigrationLister: fakeMigrationLister{migs: migs},
+		calls:               &calls,
+	}
+	check := Migrations(lister)
+
+	assert.NotNil(t, check.Run(context.Background()))
+
+	migs[0].Status = string(gomigrator.MigrationApplied)
+
+	assert.Nil(t, check.Run(context.Background()))
+	assert.Nil(t, check.Run(context.Background()))
+	assert.Equal(t, 2, calls)
+}
+
+func Test_Worker(t *testing.T) {
+	assert.Equal(t, ErrWorkerNotStarted{}, Worker("archive_worker", fakeWorker{}, time.Minute).Run(context.Background()))
+	assert.Nil(t, Worker("archive_worker", fakeWorker{last: time.Now().Add(-10 * time.Second)}, time.Minute).Run(context.Background()))
+
+	err := Worker("archive_worker", fakeWorker{last: time.Now().Add(-5 * time.Minute)}, time.Minute).Run(context.Background())
+	assert.Equal(t, "the worker last polled 5m0s ago", err.Error())
+}
@@ -0,0 +1,12 @@
+package inspect
+
+import "fmt"
+
+type ErrInvalidModule struct {
+	Dir     string
+	Message string
+}
+
+func (e ErrInvalidModule) Error() string {
+	return fmt.Sprintf("module in %s could not be parsed: %s", e.Dir, e.Message)
+}
@@ -0,0 +1,447 @@
+package inspect
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/svartlfheim/ymir/internal/registry"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+var fileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "terraform"},
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "output", LabelNames: []string{"name"}},
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "module", LabelNames: []string{"name"}},
+	},
+}
+
+var terraformSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "required_version"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "required_providers"},
+	},
+}
+
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "type"},
+		{Name: "default"},
+		{Name: "description"},
+		{Name: "sensitive"},
+	},
+}
+
+var outputSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "description"},
+		{Name: "sensitive"},
+	},
+}
+
+var moduleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "source"},
+		{Name: "version"},
+	},
+}
+
+// inspector collects the interface across all the files of a module, the
+// diagnostics of every file are reported together.
+type inspector struct {
+	dir       string
+	parser    *hclparse.Parser
+	iface     registry.ModuleInterface
+	providers map[string]*registry.ProviderRequirement
+	diags     hcl.Diagnostics
+}
+
+// Module parses the terraform files in dir, not those of any directories
+// within it, into a summary of the module's interface.
+func Module(dir string) (registry.ModuleInterface, error) {
+	files, err := moduleFiles(dir)
+
+	if err != nil {
+		return registry.ModuleInterface{}, err
+	}
+
+	i := &inspector{
+		dir:       dir,
+		parser:    hclparse.NewParser(),
+		providers: map[string]*registry.ProviderRequirement{},
+		iface: registry.ModuleInterface{
+			RequiredVersion:   []string{},
+			RequiredProviders: []registry.ProviderRequirement{},
+			Variables:         []registry.ModuleVariable{},
+			Outputs:           []registry.ModuleOutput{},
+			Resources:         []registry.ModuleResource{},
+			ModuleCalls:       []registry.ModuleCall{},
+		},
+	}
+
+	for _, f := range files {
+		i.inspectFile(f)
+	}
+
+	if i.diags.HasErrors() {
+		return registry.ModuleInterface{}, ErrInvalidModule{
+			Dir:     dir,
+			Message: i.diags.Error(),
+		}
+	}
+
+	return i.result(), nil
+}
+
+func linksWithin(dir string, p string) bool {
+	root, err := filepath.EvalSymlinks(dir)
+
+	if err != nil {
+		return false
+	}
+
+	target, err := filepath.EvalSymlinks(p)
+
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(root, target)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// moduleFiles lists the files terraform would load from the directory,
+// override files are left out as they only change existing blocks.
+func moduleFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+
+	for _, e := range entries {
+		name := e.Name()
+
+		if e.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		if !strings.HasSuffix(name, ".tf") && !strings.HasSuffix(name, ".tf.json") {
+			continue
+		}
+
+		base := strings.TrimSuffix(strings.TrimSuffix(name, ".json"), ".tf")
+
+		if base == "override" || strings.HasSuffix(base, "_override") {
+			continue
+		}
+
+		// A linked file is only read if it is part of the module
+		if e.Type()&os.ModeSymlink != 0 && !linksWithin(dir, filepath.Join(dir, name)) {
+			continue
+		}
+
+		files = append(files, filepath.Join(dir, name))
+	}
+
+	return files, nil
+}
+
+func (i *inspector) inspectFile(path string) {
+	var f *hcl.File
+	var diags hcl.Diagnostics
+
+	if strings.HasSuffix(path, ".json") {
+		f, diags = i.parser.ParseJSONFile(path)
+	} else {
+		f, diags = i.parser.ParseHCLFile(path)
+	}
+
+	i.diags = append(i.diags, diags...)
+
+	if f == nil {
+		return
+	}
+
+	content, _, diags := f.Body.PartialContent(fileSchema)
+	i.diags = append(i.diags, diags...)
+
+	for _, b := range content.Blocks {
+		switch b.Type {
+		case "terraform":
+			i.inspectTerraform(b)
+		case "variable":
+			i.inspectVariable(f, b)
+		case "output":
+			i.inspectOutput(b)
+		case "resource", "data":
+			i.inspectResource(b)
+		case "module":
+			i.inspectModuleCall(b)
+		}
+	}
+}
+
+func (i *inspector) pos(r hcl.Range) registry.SourcePos {
+	filename, err := filepath.Rel(i.dir, r.Filename)
+
+	if err != nil {
+		filename = r.Filename
+	}
+
+	return registry.SourcePos{
+		Filename: filepath.ToSlash(filename),
+		Line:     r.Start.Line,
+	}
+}
+
+func (i *inspector) stringAttr(attrs hcl.Attributes, name string) string {
+	attr, ok := attrs[name]
+
+	if !ok {
+		return ""
+	}
+
+	v, diags := attr.Expr.Value(nil)
+	i.diags = append(i.diags, diags...)
+
+	if diags.HasErrors() || v.IsNull() || !v.Type().Equals(cty.String) || !v.IsKnown() {
+		return ""
+	}
+
+	return v.AsString()
+}
+
+func (i *inspector) boolAttr(attrs hcl.Attributes, name string) bool {
+	attr, ok := attrs[name]
+
+	if !ok {
+		return false
+	}
+
+	v, diags := attr.Expr.Value(nil)
+	i.diags = append(i.diags, diags...)
+
+	if diags.HasErrors() || v.IsNull() || !v.Type().Equals(cty.Bool) || !v.IsKnown() {
+		return false
+	}
+
+	return v.True()
+}
+
+// typeSource is the type constraint as it was written, JSON files give it as
+// a string.
+func typeSource(f *hcl.File, expr hcl.Expression) string {
+	if _, ok := expr.(hclsyntax.Expression); ok {
+		r := expr.Range()
+
+		return string(r.SliceBytes(f.Bytes))
+	}
+
+	v, diags := expr.Value(nil)
+
+	if diags.HasErrors() || v.IsNull() || !v.Type().Equals(cty.String) {
+		return ""
+	}
+
+	return v.AsString()
+}
+
+func (i *inspector) inspectVariable(f *hcl.File, b *hcl.Block) {
+	content, _, diags := b.Body.PartialContent(variableSchema)
+	i.diags = append(i.diags, diags...)
+
+	v := registry.ModuleVariable{
+		Name:        b.Labels[0],
+		Description: i.stringAttr(content.Attributes, "description"),
+		Sensitive:   i.boolAttr(content.Attributes, "sensitive"),
+		Required:    true,
+		Pos:         i.pos(b.DefRange),
+	}
+
+	if attr, ok := content.Attributes["type"]; ok {
+		v.Type = typeSource(f, attr.Expr)
+	}
+
+	if attr, ok := content.Attributes["default"]; ok {
+		val, diags := attr.Expr.Value(nil)
+		i.diags = append(i.diags, diags...)
+
+		if !diags.HasErrors() && val.IsWhollyKnown() {
+			v.Required = false
+			v.Default = []byte("null")
+
+			if !val.IsNull() {
+				if raw, err := ctyjson.Marshal(val, val.Type()); err == nil {
+					v.Default = raw
+				}
+			}
+		}
+	}
+
+	i.iface.Variables = append(i.iface.Variables, v)
+}
+
+func (i *inspector) inspectOutput(b *hcl.Block) {
+	content, _, diags := b.Body.PartialContent(outputSchema)
+	i.diags = append(i.diags, diags...)
+
+	i.iface.Outputs = append(i.iface.Outputs, registry.ModuleOutput{
+		Name:        b.Labels[0],
+		Description: i.stringAttr(content.Attributes, "description"),
+		Sensitive:   i.boolAttr(content.Attributes, "sensitive"),
+		Pos:         i.pos(b.DefRange),
+	})
+}
+
+func (i *inspector) inspectResource(b *hcl.Block) {
+	mode := "managed"
+
+	if b.Type == "data" {
+		mode = "data"
+	}
+
+	i.iface.Resources = append(i.iface.Resources, registry.ModuleResource{
+		Mode: mode,
+		Type: b.Labels[0],
+		Name: b.Labels[1],
+		Pos:  i.pos(b.DefRange),
+	})
+}
+
+func (i *inspector) inspectModuleCall(b *hcl.Block) {
+	content, _, diags := b.Body.PartialContent(moduleSchema)
+	i.diags = append(i.diags, diags...)
+
+	i.iface.ModuleCalls = append(i.iface.ModuleCalls, registry.ModuleCall{
+		Name:    b.Labels[0],
+		Source:  i.stringAttr(content.Attributes, "source"),
+		Version: i.stringAttr(content.Attributes, "version"),
+		Pos:     i.pos(b.DefRange),
+	})
+}
+
+func (i *inspector) inspectTerraform(b *hcl.Block) {
+	content, _, diags := b.Body.PartialContent(terraformSchema)
+	i.diags = append(i.diags, diags...)
+
+	if v := i.stringAttr(content.Attributes, "required_version"); v != "" {
+		i.iface.RequiredVersion = append(i.iface.RequiredVersion, v)
+	}
+
+	for _, rp := range content.Blocks {
+		attrs, diags := rp.Body.JustAttributes()
+		i.diags = append(i.diags, diags...)
+
+		for name, attr := range attrs {
+			i.inspectProviderRequirement(name, attr)
+		}
+	}
+}
+
+// inspectProviderRequirement handles both the object form and the older
+// form, where the requirement is only a version constraint.
+func (i *inspector) inspectProviderRequirement(name string, attr *hcl.Attribute) {
+	p, ok := i.providers[name]
+
+	if !ok {
+		p = &registry.ProviderRequirement{
+			Name:               name,
+			VersionConstraints: []string{},
+		}
+		i.providers[name] = p
+	}
+
+	pairs, diags := hcl.ExprMap(attr.Expr)
+
+	if diags.HasErrors() {
+		v, diags := attr.Expr.Value(nil)
+		i.diags = append(i.diags, diags...)
+
+		if !diags.HasErrors() && !v.IsNull() && v.Type().Equals(cty.String) {
+			p.VersionConstraints = append(p.VersionConstraints, v.AsString())
+		}
+
+		return
+	}
+
+	for _, pair := range pairs {
+		key := hcl.ExprAsKeyword(pair.Key)
+
+		if key == "" {
+			if k, diags := pair.Key.Value(nil); !diags.HasErrors() && k.Type().Equals(cty.String) {
+				key = k.AsString()
+			}
+		}
+
+		if key != "source" && key != "version" {
+			// configuration_aliases refer to providers, they can't be evaluated
+			continue
+		}
+
+		v, diags := pair.Value.Value(nil)
+		i.diags = append(i.diags, diags...)
+
+		if diags.HasErrors() || v.IsNull() || !v.Type().Equals(cty.String) {
+			continue
+		}
+
+		if key == "source" {
+			p.Source = v.AsString()
+		} else {
+			p.VersionConstraints = append(p.VersionConstraints, v.AsString())
+		}
+	}
+}
+
+// result sorts everything by name, so the same module always gives the same
+// interface whatever order it was declared in.
+func (i *inspector) result() registry.ModuleInterface {
+	iface := i.iface
+
+	for _, p := range i.providers {
+		iface.RequiredProviders = append(iface.RequiredProviders, *p)
+	}
+
+	sort.Slice(iface.RequiredProviders, func(a, b int) bool {
+		return iface.RequiredProviders[a].Name < iface.RequiredProviders[b].Name
+	})
+	sort.Slice(iface.Variables, func(a, b int) bool {
+		return iface.Variables[a].Name < iface.Variables[b].Name
+	})
+	sort.Slice(iface.Outputs, func(a, b int) bool {
+		return iface.Outputs[a].Name < iface.Outputs[b].Name
+	})
+	sort.Slice(iface.Resources, func(a, b int) bool {
+		ra, rb := iface.Resources[a], iface.Resources[b]
+
+		if ra.Mode != rb.Mode {
+			return ra.Mode < rb.Mode
+		}
+
+		if ra.Type != rb.Type {
+			return ra.Type < rb.Type
+		}
+
+		return ra.Name < rb.Name
+	})
+	sort.Slice(iface.ModuleCalls, func(a, b int) bool {
+		return iface.ModuleCalls[a].Name < iface.ModuleCalls[b].Name
+	})
+
+	return iface
+}
@@ -0,0 +1,90 @@
+package inspect
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+func TestModule(t *testing.T) {
+	iface, err := Module("testdata/module")
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{">= 1.0"}, iface.RequiredVersion)
+	assert.Equal(t, []registry.ProviderRequirement{
+		{Name: "aws", Source: "hashicorp/aws", VersionConstraints: []string{">= 3.50, < 5.0"}},
+		{Name: "random", VersionConstraints: []string{"~> 3.1"}},
+	}, iface.RequiredProviders)
+
+	assert.Equal(t, []registry.ModuleVariable{
+		{Name: "anything", Required: true, Pos: registry.SourcePos{Filename: "variables.tf", Line: 25}},
+		{Name: "name", Type: "string", Description: "Name given to every resource", Required: true, Pos: registry.SourcePos{Filename: "variables.tf", Line: 1}},
+		{Name: "password", Type: "string", Default: json.RawMessage("null"), Sensitive: true, Pos: registry.SourcePos{Filename: "variables.tf", Line: 19}},
+		{
+			Name:    "subnets",
+			Type:    "list(object({\n    cidr = string\n    az   = string\n  }))",
+			Default: json.RawMessage(`[{"az":"eu-west-1a","cidr":"10.0.0.0/24"}]`),
+			Pos:     registry.SourcePos{Filename: "variables.tf", Line: 11},
+		},
+		{Name: "tags", Type: "map(string)", Default: json.RawMessage("{}"), Pos: registry.SourcePos{Filename: "variables.tf", Line: 6}},
+	}, iface.Variables)
+
+	assert.Equal(t, []registry.ModuleOutput{
+		{Name: "vpc_id", Description: "ID of the VPC", Pos: registry.SourcePos{Filename: "outputs.tf.json", Line: 3}},
+	}, iface.Outputs)
+
+	assert.Equal(t, []registry.ModuleResource{
+		{Mode: "data", Type: "aws_region", Name: "current", Pos: registry.SourcePos{Filename: "main.tf", Line: 6}},
+		{Mode: "managed", Type: "aws_vpc", Name: "this", Pos: registry.SourcePos{Filename: "main.tf", Line: 1}},
+	}, iface.Resources)
+
+	assert.Equal(t, []registry.ModuleCall{
+		{Name: "labels", Source: "cloudposse/label/null", Version: "0.25.0", Pos: registry.SourcePos{Filename: "main.tf", Line: 14}},
+		{Name: "subnets", Source: "./modules/subnets", Pos: registry.SourcePos{Filename: "main.tf", Line: 8}},
+	}, iface.ModuleCalls)
+}
+
+func TestModule_Invalid(t *testing.T) {
+	_, err := Module("testdata/invalid")
+
+	assert.IsType(t, ErrInvalidModule{}, err)
+}
+
+func TestModule_SymlinkedFiles(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(dir, "main.tf"):        `variable "cidr" {}`,
+		filepath.Join(outside, "secrets.tf"): `variable "db_password" { default = "hunter2" }`,
+	}
+
+	for p, content := range files {
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Symlink(filepath.Join(outside, "secrets.tf"), filepath.Join(dir, "secrets.tf")); err != nil {
+		t.Fatal(err)
+	}
+
+	iface, err := Module(dir)
+
+	assert.Nil(t, err)
+	assert.Len(t, iface.Variables, 1)
+	assert.Equal(t, "cidr", iface.Variables[0].Name)
+}
+
+func TestModule_Empty(t *testing.T) {
+	iface, err := Module(t.TempDir())
+
+	assert.Nil(t, err)
+	assert.Empty(t, iface.Variables)
+	assert.NotNil(t, iface.Variables)
+}
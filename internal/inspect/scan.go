@@ -0,0 +1,68 @@
+package inspect
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+// Scanner finds the module blocks throughout a terraform codebase.
+type Scanner struct{}
+
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// ModuleCalls parses every directory beneath root, hidden directories such as
+// .terraform are skipped. A directory that can't be parsed is returned in
+// invalid rather than ending the scan, and positions are relative to root.
+func (s *Scanner) ModuleCalls(root string) (calls []registry.ModuleCall, invalid []error, err error) {
+	calls = []registry.ModuleCall{}
+	invalid = []error{}
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		iface, err := Module(p)
+
+		if _, ok := err.(ErrInvalidModule); ok {
+			invalid = append(invalid, err)
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+
+		if err != nil {
+			return err
+		}
+
+		for _, c := range iface.ModuleCalls {
+			c.Pos.Filename = filepath.ToSlash(filepath.Join(rel, c.Pos.Filename))
+			calls = append(calls, c)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return calls, invalid, nil
+}
@@ -0,0 +1,28 @@
+package inspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+func TestScanner_ModuleCalls(t *testing.T) {
+	calls, invalid, err := NewScanner().ModuleCalls("testdata/codebase")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []registry.ModuleCall{
+		{Name: "labels", Source: "./labels", Pos: registry.SourcePos{Filename: "main.tf", Line: 6}},
+		{Name: "network", Source: "registry.example.com/platform/network/aws", Version: "~> 1.0", Pos: registry.SourcePos{Filename: "main.tf", Line: 1}},
+		{Name: "network", Source: "Registry.Example.com/platform/network/aws//modules/subnets", Version: ">= 1.0, < 3.0", Pos: registry.SourcePos{Filename: "envs/prod/main.tf", Line: 1}},
+	}, calls)
+
+	assert.Len(t, invalid, 1)
+	assert.IsType(t, ErrInvalidModule{}, invalid[0])
+}
+
+func TestScanner_ModuleCalls_MissingDir(t *testing.T) {
+	_, _, err := NewScanner().ModuleCalls("testdata/nope")
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,4 @@
+module "cached" {
+  source  = "registry.example.com/platform/cached/aws"
+  version = "1.0.0"
+}
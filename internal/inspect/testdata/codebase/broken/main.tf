@@ -0,0 +1,2 @@
+module "broken" {
+  source = "registry.example.com/platform/broken/aws"
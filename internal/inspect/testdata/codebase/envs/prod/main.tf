@@ -0,0 +1,5 @@
+module "network" {
+  source  = "Registry.Example.com/platform/network/aws//modules/subnets"
+  version = ">= 1.0, < 3.0"
+  name    = "prod"
+}
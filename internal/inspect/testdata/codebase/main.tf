@@ -0,0 +1,8 @@
+module "network" {
+  source  = "registry.example.com/platform/network/aws"
+  version = "~> 1.0"
+}
+
+module "labels" {
+  source = "./labels"
+}
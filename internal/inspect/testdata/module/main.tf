@@ -0,0 +1,21 @@
+resource "aws_vpc" "this" {
+  cidr_block = "10.0.0.0/16"
+  tags       = merge(var.tags, { Name = var.name })
+}
+
+data "aws_region" "current" {}
+
+module "subnets" {
+  source  = "./modules/subnets"
+  vpc_id  = aws_vpc.this.id
+  subnets = var.subnets
+}
+
+module "labels" {
+  source  = "cloudposse/label/null"
+  version = "0.25.0"
+}
+
+locals {
+  region = data.aws_region.current.name
+}
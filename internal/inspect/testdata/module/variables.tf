@@ -0,0 +1,25 @@
+variable "name" {
+  type        = string
+  description = "Name given to every resource"
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+
+variable "subnets" {
+  type = list(object({
+    cidr = string
+    az   = string
+  }))
+  default = [{ cidr = "10.0.0.0/24", az = "eu-west-1a" }]
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+  default   = null
+}
+
+variable "anything" {}
@@ -0,0 +1,326 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the version of the Prometheus text format Write produces.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type sample struct {
+	suffix string
+	labels []string
+	values []string
+	value  float64
+}
+
+type metric interface {
+	describe() (name string, help string, kind string)
+	collect(ctx context.Context) []sample
+}
+
+// Registry holds metrics in the order they are registered, and writes them in
+// the Prometheus text format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = append(r.metrics, m)
+}
+
+// series are the values of a metric by the values of its labels.
+type series struct {
+	mu     sync.Mutex
+	labels []string
+	values map[string][]string
+}
+
+func newSeries(labels []string) series {
+	return series{
+		labels: labels,
+		values: map[string][]string{},
+	}
+}
+
+// key is where the values of the labels are kept, the values must be given in
+// the order the labels were.
+func (s *series) key(values []string) string {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("metrics: got %d label values for labels %v", len(values), s.labels))
+	}
+
+	k := strings.Join(values, "\xff")
+
+	if _, ok := s.values[k]; !ok {
+		s.values[k] = append([]string{}, values...)
+	}
+
+	return k
+}
+
+func (s *series) sortedKeys() []string {
+	keys := make([]string, 0, len(s.values))
+
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Counter only goes up, such as the number of requests served.
+type Counter struct {
+	name string
+	help string
+	series
+	counts map[string]float64
+}
+
+func (r *Registry) Counter(name string, help string, labels ...string) *Counter {
+	c := &Counter{
+		name:   name,
+		help:   help,
+		series: newSeries(labels),
+		counts: map[string]float64{},
+	}
+
+	r.register(c)
+
+	return c
+}
+
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *Counter) Add(v float64, values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[c.key(values)] += v
+}
+
+func (c *Counter) describe() (string, string, string) {
+	return c.name, c.help, "counter"
+}
+
+func (c *Counter) collect(ctx context.Context) []sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	samples := []sample{}
+
+	for _, k := range c.sortedKeys() {
+		samples = append(samples, sample{labels: c.labels, values: c.values[k], value: c.counts[k]})
+	}
+
+	return samples
+}
+
+// DurationBuckets suit requests, from 5ms to 10s.
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations into buckets, such as how long requests take.
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64
+	series
+	counts map[string][]uint64
+	sums   map[string]float64
+	totals map[string]uint64
+}
+
+// Histogram registers a histogram with the upper bounds of its buckets, in
+// increasing order.
+func (r *Registry) Histogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		series:  newSeries(labels),
+		counts:  map[string][]uint64{},
+		sums:    map[string]float64{},
+		totals:  map[string]uint64{},
+	}
+
+	r.register(h)
+
+	return h
+}
+
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	k := h.key(values)
+
+	if _, ok := h.counts[k]; !ok {
+		h.counts[k] = make([]uint64, len(h.buckets))
+	}
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[k][i]++
+		}
+	}
+
+	h.sums[k] += v
+	h.totals[k]++
+}
+
+func (h *Histogram) describe() (string, string, string) {
+	return h.name, h.help, "histogram"
+}
+
+func (h *Histogram) collect(ctx context.Context) []sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	labels := append(append([]string{}, h.labels...), "le")
+	samples := []sample{}
+
+	for _, k := range h.sortedKeys() {
+		values := h.values[k]
+
+		for i, upper := range h.buckets {
+			samples = append(samples, sample{
+				suffix: "_bucket",
+				labels: labels,
+				values: append(append([]string{}, values...), formatFloat(upper)),
+				value:  float64(h.counts[k][i]),
+			})
+		}
+
+		samples = append(samples,
+			sample{suffix: "_bucket", labels: labels, values: append(append([]string{}, values...), "+Inf"), value: float64(h.totals[k])},
+			sample{suffix: "_sum", labels: h.labels, values: values, value: h.sums[k]},
+			sample{suffix: "_count", labels: h.labels, values: values, value: float64(h.totals[k])},
+		)
+	}
+
+	return samples
+}
+
+// CollectFunc reads a value when the metrics are written, a value that can't
+// be read is left out.
+type CollectFunc func(ctx context.Context) (float64, error)
+
+type funcMetric struct {
+	name string
+	help string
+	kind string
+	read CollectFunc
+}
+
+// GaugeFunc registers a value that can go up and down, read when the metrics
+// are written, such as the number of open connections.
+func (r *Registry) GaugeFunc(name string, help string, f CollectFunc) {
+	r.register(&funcMetric{name: name, help: help, kind: "gauge", read: f})
+}
+
+// CounterFunc registers a counter kept elsewhere, read when the metrics are
+// written.
+func (r *Registry) CounterFunc(name string, help string, f CollectFunc) {
+	r.register(&funcMetric{name: name, help: help, kind: "counter", read: f})
+}
+
+func (m *funcMetric) describe() (string, string, string) {
+	return m.name, m.help, m.kind
+}
+
+func (m *funcMetric) collect(ctx context.Context) []sample {
+	v, err := m.read(ctx)
+
+	if err != nil {
+		return nil
+	}
+
+	return []sample{{value: v}}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func writeSample(w *bufio.Writer, name string, s sample) {
+	w.WriteString(name + s.suffix)
+
+	if len(s.labels) > 0 {
+		pairs := make([]string, len(s.labels))
+
+		for i, l := range s.labels {
+			pairs[i] = l + `="` + labelEscaper.Replace(s.values[i]) + `"`
+		}
+
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+
+	w.WriteString(" " + formatFloat(s.value) + "\n")
+}
+
+// Write writes every metric with at least one value.
+func (r *Registry) Write(ctx context.Context, out io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric{}, r.metrics...)
+	r.mu.Unlock()
+
+	w := bufio.NewWriter(out)
+
+	for _, m := range metrics {
+		samples := m.collect(ctx)
+
+		if len(samples) == 0 {
+			continue
+		}
+
+		name, help, kind := m.describe()
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, helpEscaper.Replace(help), name, kind)
+
+		for _, s := range samples {
+			writeSample(w, name, s)
+		}
+	}
+
+	return w.Flush()
+}
+
+// Handler serves the metrics to a Prometheus scrape.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+
+		//nolint:errcheck
+		r.Write(req.Context(), w)
+	})
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Registry_Write(t *testing.T) {
+	r := NewRegistry()
+
+	requests := r.Counter("http_requests_total", "Requests served.", "route", "code")
+	requests.Inc("/v1/modules/{namespace}", "200")
+	requests.Inc("/v1/modules/{namespace}", "200")
+	requests.Add(3, "/api/v1/modules", "401")
+	requests.Inc(`/a"b\c`, "500")
+
+	r.Counter("unused_total", "Never counted, so never written.")
+
+	latency := r.Histogram("http_request_duration_seconds", "How long requests took.\nIn seconds.", []float64{0.1, 1}, "route")
+	latency.Observe(0.05, "/")
+	latency.Observe(0.5, "/")
+	latency.Observe(2, "/")
+
+	r.GaugeFunc("queue_depth", "Versions waiting.", func(ctx context.Context) (float64, error) {
+		return 7, nil
+	})
+	r.GaugeFunc("unreadable", "Left out when it can't be read.", func(ctx context.Context) (float64, error) {
+		return 0, errors.New("connection refused")
+	})
+
+	out := new(bytes.Buffer)
+	assert.Nil(t, r.Write(context.Background(), out))
+
+	expected := `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{route="/a\"b\\c",code="500"} 1
+http_requests_total{route="/api/v1/modules",code="401"} 3
+http_requests_total{route="/v1/modules/{namespace}",code="200"} 2
+# HELP http_request_duration_seconds How long requests took.\nIn seconds.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{route="/",le="0.1"} 1
+http_request_duration_seconds_bucket{route="/",le="1"} 2
+http_request_duration_seconds_bucket{route="/",le="+Inf"} 3
+http_request_duration_seconds_sum{route="/"} 2.55
+http_request_duration_seconds_count{route="/"} 3
+# HELP queue_depth Versions waiting.
+# TYPE queue_depth gauge
+queue_depth 7
+`
+
+	assert.Equal(t, expected, out.String())
+}
+
+func Test_Registry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("builds_total", "Builds.").Inc()
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "# HELP builds_total Builds.\n# TYPE builds_total counter\nbuilds_total 1\n", w.Body.String())
+}
+
+func Test_Counter_WrongLabelCount(t *testing.T) {
+	c := NewRegistry().Counter("requests_total", "Requests.", "route")
+
+	assert.Panics(t, func() {
+		c.Inc("/", "200")
+	})
+}
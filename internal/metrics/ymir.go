@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+// BuildBuckets suit archive builds, which clone a repository, from 1s to 10m.
+var BuildBuckets = []float64{1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// Ymir holds the registry's metrics, fed by the server, the auditor and the
+// workers, and read from the database when they are scraped.
+type Ymir struct {
+	*Registry
+	requests  *Counter
+	latency   *Histogram
+	downloads *Counter
+	actions   *Counter
+	builds    *Histogram
+}
+
+func NewYmir() *Ymir {
+	r := NewRegistry()
+
+	return &Ymir{
+		Registry: r,
+		requests: r.Counter(
+			"ymir_http_requests_total",
+			"HTTP requests served, by the template of the route they matched.",
+			"method", "route", "code",
+		),
+		latency: r.Histogram(
+			"ymir_http_request_duration_seconds",
+			"How long HTTP requests took to serve, by the template of the route they matched.",
+			DurationBuckets,
+			"method", "route",
+		),
+		downloads: r.Counter(
+			"ymir_module_downloads_total",
+			"Module versions terraform was sent to download, by module.",
+			"namespace", "name", "provider",
+		),
+		actions: r.Counter(
+			"ymir_actions_total",
+			"Audited actions, by the status they ended with.",
+			"action", "status",
+		),
+		builds: r.Histogram(
+			"ymir_publish_build_duration_seconds",
+			"How long building the archive of a published version took, by the status it ended with.",
+			BuildBuckets,
+			"status",
+		),
+	}
+}
+
+func (m *Ymir) ObserveRequest(method string, route string, code int, took time.Duration) {
+	m.requests.Inc(method, route, strconv.Itoa(code))
+	m.latency.Observe(took.Seconds(), method, route)
+}
+
+func (m *Ymir) ModuleDownloaded(namespace string, name string, provider string) {
+	m.downloads.Inc(namespace, name, provider)
+}
+
+// Observe counts each action the auditor records, and times the archive
+// builds of the publish pipeline.
+func (m *Ymir) Observe(action registry.AuditableAction) {
+	m.actions.Inc(action.GetActionName(), string(action.GetResponseStatus()))
+
+	if b, ok := action.(registry.BuildModuleVersionV1Response); ok {
+		m.builds.Observe(b.Duration.Seconds(), string(b.Status))
+	}
+}
+
+type versionCounter interface {
+	CountVersionsByStatus(ctx context.Context, status registry.VersionStatus) (int, error)
+}
+
+// WatchArchiveQueue reports how many versions are waiting for their archive
+// to be built.
+func (m *Ymir) WatchArchiveQueue(r versionCounter) {
+	m.GaugeFunc(
+		"ymir_archive_queue_depth",
+		"Published versions waiting for the archive worker.",
+		func(ctx context.Context) (float64, error) {
+			n, err := r.CountVersionsByStatus(ctx, registry.VersionStatuses.Pending)
+
+			return float64(n), err
+		},
+	)
+}
+
+type deliveryCounter interface {
+	CountDeliveriesByStatus(status registry.DeliveryStatus) (int, error)
+}
+
+// WatchDeliveries reports the backlog of the webhook delivery worker, the
+// deliveries queued for subscribers and not yet sent.
+func (m *Ymir) WatchDeliveries(r deliveryCounter) {
+	m.GaugeFunc(
+		"ymir_webhook_deliveries_pending",
+		"Webhook deliveries queued for subscribers and not yet sent, including those waiting to be retried.",
+		func(ctx context.Context) (float64, error) {
+			n, err := r.CountDeliveriesByStatus(registry.DeliveryStatuses.Pending)
+
+			return float64(n), err
+		},
+	)
+}
+
+type dbStatter interface {
+	Stats() sql.DBStats
+}
+
+// WatchDB reports the stats of the database connection pool.
+func (m *Ymir) WatchDB(db dbStatter) {
+	stat := func(f func(s sql.DBStats) float64) CollectFunc {
+		return func(ctx context.Context) (float64, error) {
+			return f(db.Stats()), nil
+		}
+	}
+
+	m.GaugeFunc("ymir_db_connections_max", "The most connections the pool may open, 0 when unlimited.", stat(func(s sql.DBStats) float64 {
+		return float64(s.MaxOpenConnections)
+	}))
+	m.GaugeFunc("ymir_db_connections_open", "Connections the pool has open.", stat(func(s sql.DBStats) float64 {
+		return float64(s.OpenConnections)
+	}))
+	m.GaugeFunc("ymir_db_connections_in_use", "Connections in use by queries.", stat(func(s sql.DBStats) float64 {
+		return float64(s.InUse)
+	}))
+	m.GaugeFunc("ymir_db_connections_idle", "Connections open and waiting to be used.", stat(func(s sql.DBStats) float64 {
+		return float64(s.Idle)
+	}))
+	m.CounterFunc("ymir_db_connection_waits_total", "Queries that waited for a connection.", stat(func(s sql.DBStats) float64 {
+		return float64(s.WaitCount)
+	}))
+	m.CounterFunc("ymir_db_connection_wait_seconds_total", "How long queries waited for a connection.", stat(func(s sql.DBStats) float64 {
+		return s.WaitDuration.Seconds()
+	}))
+	m.CounterFunc("ymir_db_connections_closed_total", "Connections closed for being idle or too old.", stat(func(s sql.DBStats) float64 {
+		return float64(s.MaxIdleClosed + s.MaxIdleTimeClosed + s.MaxLifetimeClosed)
+	}))
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type fakeVersionCounter struct {
+	counts map[registry.VersionStatus]int
+}
+
+func (c fakeVersionCounter) CountVersionsByStatus(ctx context.Context, status registry.VersionStatus) (int, error) {
+	return c.counts[status], nil
+}
+
+type fakeDeliveryCounter struct{}
+
+func (c fakeDeliveryCounter) CountDeliveriesByStatus(status registry.DeliveryStatus) (int, error) {
+	if status == registry.DeliveryStatuses.Pending {
+		return 12, nil
+	}
+
+	return 0, nil
+}
+
+type fakeDB struct{}
+
+func (db fakeDB) Stats() sql.DBStats {
+	return sql.DBStats{
+		MaxOpenConnections: 10,
+		OpenConnections:    4,
+		InUse:              3,
+		Idle:               1,
+		WaitCount:          5,
+		WaitDuration:       1500 * time.Millisecond,
+	}
+}
+
+func written(t *testing.T, m *Ymir) []string {
+	out := new(bytes.Buffer)
+	assert.Nil(t, m.Write(context.Background(), out))
+
+	lines := []string{}
+
+	for _, l := range strings.Split(out.String(), "\n") {
+		if l != "" && !strings.HasPrefix(l, "#") {
+			lines = append(lines, l)
+		}
+	}
+
+	return lines
+}
+
+func Test_Ymir(t *testing.T) {
+	m := NewYmir()
+
+	m.ObserveRequest("GET", "/v1/modules/{namespace}/{name}/{provider}/{version}/download", 204, 20*time.Millisecond)
+	m.ModuleDownloaded("platform", "vpc", "aws")
+	m.ModuleDownloaded("platform", "vpc", "aws")
+	m.Observe(registry.BuildModuleVersionV1Response{
+		Status:   registry.STATUS_OKAY,
+		Duration: 4 * time.Second,
+	})
+	m.Observe(registry.AuthenticateCertificateV1Response{Status: registry.STATUS_UNAUTHORIZED})
+
+	m.WatchArchiveQueue(fakeVersionCounter{counts: map[registry.VersionStatus]int{
+		registry.VersionStatuses.Pending: 3,
+		registry.VersionStatuses.Ready:   40,
+	}})
+	m.WatchDeliveries(fakeDeliveryCounter{})
+	m.WatchDB(fakeDB{})
+
+	lines := written(t, m)
+
+	for _, expected := range []string{
+		`ymir_http_requests_total{method="GET",route="/v1/modules/{namespace}/{name}/{provider}/{version}/download",code="204"} 1`,
+		`ymir_http_request_duration_seconds_count{method="GET",route="/v1/modules/{namespace}/{name}/{provider}/{version}/download"} 1`,
+		`ymir_module_downloads_total{namespace="platform",name="vpc",provider="aws"} 2`,
+		`ymir_actions_total{action="v1.modules.versions.build",status="OK"} 1`,
+		`ymir_actions_total{action="v1.certificates.authenticate",status="UNAUTHORIZED"} 1`,
+		`ymir_publish_build_duration_seconds_bucket{status="OK",le="2.5"} 0`,
+		`ymir_publish_build_duration_seconds_bucket{status="OK",le="5"} 1`,
+		`ymir_publish_build_duration_seconds_sum{status="OK"} 4`,
+		`ymir_archive_queue_depth 3`,
+		`ymir_webhook_deliveries_pending 12`,
+		`ymir_db_connections_in_use 3`,
+		`ymir_db_connection_wait_seconds_total 1.5`,
+	} {
+		assert.Contains(t, lines, expected)
+	}
+}
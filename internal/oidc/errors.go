@@ -0,0 +1,57 @@
+package oidc
+
+import "fmt"
+
+type ErrInvalidToken struct {
+	Reason string
+}
+
+func (e ErrInvalidToken) Error() string {
+	return fmt.Sprintf("oidc token is invalid: %s", e.Reason)
+}
+
+type ErrUnknownIssuer struct {
+	Issuer string
+}
+
+func (e ErrUnknownIssuer) Error() string {
+	return fmt.Sprintf("oidc issuer '%s' is not trusted", e.Issuer)
+}
+
+type ErrUnknownKey struct {
+	Issuer string
+	KeyId  string
+}
+
+func (e ErrUnknownKey) Error() string {
+	return fmt.Sprintf("oidc issuer '%s' has no key '%s'", e.Issuer, e.KeyId)
+}
+
+// ErrKeysUnavailable is the issuer's fault rather than the token's, the keys
+// are loaded again at most once every keysRetryInterval.
+type ErrKeysUnavailable struct {
+	Issuer string
+	Reason string
+}
+
+func (e ErrKeysUnavailable) Error() string {
+	return fmt.Sprintf("keys of oidc issuer '%s' could not be loaded: %s", e.Issuer, e.Reason)
+}
+
+type ErrUnexpectedStatus struct {
+	URL    string
+	Status int
+}
+
+func (e ErrUnexpectedStatus) Error() string {
+	return fmt.Sprintf("jwks request to %s responded with status %d", e.URL, e.Status)
+}
+
+type ErrUnsupportedKey struct {
+	KeyId  string
+	Reason string
+}
+
+func (e ErrUnsupportedKey) Error() string {
+	return fmt.Sprintf("jwks key '%s' is not supported: %s", e.KeyId, e.Reason)
+}
@@ -0,0 +1,247 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// keysTTL is how long a key set is used before it is loaded again, issuers
+// rotate their keys by publishing the new one alongside the old.
+const keysTTL = time.Hour
+
+// keysRetryInterval stops a token signed with an unknown key, or an issuer
+// that is down, from loading the key set on every request.
+const keysRetryInterval = time.Minute
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwks struct {
+	Keys []json.RawMessage `json:"keys"`
+}
+
+// KeySet is the public keys of an issuer, by key id.
+type KeySet map[string]crypto.PublicKey
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+
+		if err != nil {
+			return nil, ErrUnsupportedKey{KeyId: k.Kid, Reason: "modulus is not base64url"}
+		}
+
+		e, err := decodeBigInt(k.E)
+
+		if err != nil || !e.IsInt64() {
+			return nil, ErrUnsupportedKey{KeyId: k.Kid, Reason: "exponent is not valid"}
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrUnsupportedKey{KeyId: k.Kid, Reason: "curve " + k.Crv}
+		}
+
+		x, err := decodeBigInt(k.X)
+
+		if err != nil {
+			return nil, ErrUnsupportedKey{KeyId: k.Kid, Reason: "x is not base64url"}
+		}
+
+		y, err := decodeBigInt(k.Y)
+
+		if err != nil {
+			return nil, ErrUnsupportedKey{KeyId: k.Kid, Reason: "y is not base64url"}
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, ErrUnsupportedKey{KeyId: k.Kid, Reason: "point is not on the curve"}
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, ErrUnsupportedKey{KeyId: k.Kid, Reason: "key type " + k.Kty}
+	}
+}
+
+// ParseJWKS reads the signing keys from a JSON Web Key Set. Encryption keys,
+// and keys of a type tokens can't be verified with, are skipped.
+func ParseJWKS(b []byte) (KeySet, error) {
+	set := jwks{}
+
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, err
+	}
+
+	keys := KeySet{}
+
+	for _, raw := range set.Keys {
+		k := jwk{}
+
+		if err := json.Unmarshal(raw, &k); err != nil {
+			return nil, err
+		}
+
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		if k.Kty != "RSA" && k.Kty != "EC" {
+			continue
+		}
+
+		pub, err := k.publicKey()
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys[k.Kid] = pub
+	}
+
+	return keys, nil
+}
+
+// keySource caches the key set loaded from a URL or file, loading it again
+// once it is stale or when a token is signed with a key it doesn't have.
+type keySource struct {
+	load func() ([]byte, error)
+
+	mu       sync.Mutex
+	keys     KeySet
+	loadedAt time.Time
+	tried    time.Time
+	// Why the last load failed, until one succeeds
+	err error
+}
+
+func (s *keySource) refresh(at time.Time) {
+	s.tried = at
+	b, err := s.load()
+
+	if err != nil {
+		s.err = err
+
+		return
+	}
+
+	keys, err := ParseJWKS(b)
+
+	if err != nil {
+		s.err = err
+
+		return
+	}
+
+	s.keys = keys
+	s.loadedAt = at
+	s.err = nil
+}
+
+// key is the key with the id, without an id a key set with a single key
+// uses that key.
+func (s *keySource) key(kid string, at time.Time) (crypto.PublicKey, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	canRetry := at.Sub(s.tried) > keysRetryInterval
+
+	if (s.keys == nil || at.Sub(s.loadedAt) > keysTTL) && canRetry {
+		s.refresh(at)
+		canRetry = false
+	}
+
+	k, ok := s.find(kid)
+
+	if !ok && s.err == nil && canRetry {
+		s.refresh(at)
+		k, ok = s.find(kid)
+	}
+
+	if ok {
+		// A stale key set is still better than none
+		return k, true, nil
+	}
+
+	return nil, false, s.err
+}
+
+func (s *keySource) find(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, k := range s.keys {
+			return k, true
+		}
+	}
+
+	k, ok := s.keys[kid]
+
+	return k, ok
+}
+
+func urlKeySource(client *http.Client, u string) *keySource {
+	return &keySource{
+		load: func() ([]byte, error) {
+			resp, err := client.Get(u)
+
+			if err != nil {
+				return nil, err
+			}
+
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				return nil, ErrUnexpectedStatus{URL: u, Status: resp.StatusCode}
+			}
+
+			return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		},
+	}
+}
+
+func fileKeySource(fs afero.Fs, path string) *keySource {
+	return &keySource{
+		load: func() ([]byte, error) {
+			return afero.ReadFile(fs, path)
+		},
+	}
+}
@@ -0,0 +1,334 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const testIssuer = "https://ci.example.com"
+const testAudience = "https://registry.example.com"
+
+func b64(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func rsaJWK(kid string, k *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   b64(k.N),
+		"e":   b64(big.NewInt(int64(k.E))),
+	}
+}
+
+func ecJWK(kid string, k *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   b64(k.X),
+		"y":   b64(k.Y),
+	}
+}
+
+func buildJWKS(t *testing.T, keys ...map[string]string) []byte {
+	b, err := json.Marshal(map[string]interface{}{"keys": keys})
+	assert.Nil(t, err)
+
+	return b
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
+	s, err := token.SignedString(key)
+	assert.Nil(t, err)
+
+	return s
+}
+
+func validClaims(at time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":        testIssuer,
+		"aud":        testAudience,
+		"sub":        "repo:org/infra-modules:ref:refs/tags/v1.0.0",
+		"repository": "org/infra-modules",
+		"exp":        at.Add(5 * time.Minute).Unix(),
+		"iat":        at.Unix(),
+	}
+}
+
+func Test_ParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	keys, err := ParseJWKS(buildJWKS(t,
+		rsaJWK("rsa", rsaKey),
+		ecJWK("ec", ecKey),
+		map[string]string{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		map[string]string{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "AQAB"},
+	))
+
+	assert.Nil(t, err)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, &rsaKey.PublicKey, keys["rsa"])
+	assert.Equal(t, &ecKey.PublicKey, keys["ec"])
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"EC","kid":"bad","crv":"P-256","x":"AQAB","y":"AQAB"}]}`))
+	assert.IsType(t, ErrUnsupportedKey{}, err)
+
+	_, err = ParseJWKS([]byte(`not json`))
+	assert.NotNil(t, err)
+}
+
+func Test_Verifier_Verify(t *testing.T) {
+	at := time.Now()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "/jwks.json", buildJWKS(t, rsaJWK("rsa", rsaKey), ecJWK("ec", ecKey)), 0600))
+
+	v := NewVerifier(NewFileIssuer(testIssuer, testAudience, fs, "/jwks.json"))
+
+	tests := []struct {
+		name    string
+		token   func() string
+		invalid bool
+	}{
+		{
+			name: "signed with an rsa key",
+			token: func() string {
+				return sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims(at))
+			},
+		},
+		{
+			name: "signed with an ec key",
+			token: func() string {
+				return sign(t, jwt.SigningMethodES256, "ec", ecKey, validClaims(at))
+			},
+		},
+		{
+			name: "audience in a list",
+			token: func() string {
+				c := validClaims(at)
+				c["aud"] = []string{"other", testAudience}
+
+				return sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, c)
+			},
+		},
+		{
+			name: "expired",
+			token: func() string {
+				c := validClaims(at)
+				c["exp"] = at.Add(-time.Minute).Unix()
+
+				return sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, c)
+			},
+			invalid: true,
+		},
+		{
+			name: "no expiry",
+			token: func() string {
+				c := validClaims(at)
+				delete(c, "exp")
+
+				return sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, c)
+			},
+			invalid: true,
+		},
+		{
+			name: "not valid yet",
+			token: func() string {
+				c := validClaims(at)
+				c["nbf"] = at.Add(time.Hour).Unix()
+
+				return sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, c)
+			},
+			invalid: true,
+		},
+		{
+			name: "another audience",
+			token: func() string {
+				c := validClaims(at)
+				c["aud"] = "https://other.example.com"
+
+				return sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, c)
+			},
+			invalid: true,
+		},
+		{
+			name: "untrusted issuer",
+			token: func() string {
+				c := validClaims(at)
+				c["iss"] = "https://evil.example.com"
+
+				return sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, c)
+			},
+			invalid: true,
+		},
+		{
+			name: "signed with another key",
+			token: func() string {
+				return sign(t, jwt.SigningMethodRS256, "rsa", otherKey, validClaims(at))
+			},
+			invalid: true,
+		},
+		{
+			name: "unknown key id",
+			token: func() string {
+				return sign(t, jwt.SigningMethodRS256, "missing", rsaKey, validClaims(at))
+			},
+			invalid: true,
+		},
+		{
+			name: "signed with a shared secret",
+			token: func() string {
+				return sign(t, jwt.SigningMethodHS256, "rsa", []byte("secret"), validClaims(at))
+			},
+			invalid: true,
+		},
+		{
+			name: "not a jwt",
+			token: func() string {
+				return "not-a-jwt"
+			},
+			invalid: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			claims, err := v.Verify(test.token(), at)
+
+			if test.invalid {
+				assert.IsType(tt, ErrInvalidToken{}, err)
+				assert.Nil(tt, claims)
+
+				return
+			}
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, "org/infra-modules", claims["repository"])
+		})
+	}
+}
+
+func Test_Verifier_Verify_MissingKeysFile(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	v := NewVerifier(NewFileIssuer(testIssuer, testAudience, afero.NewMemMapFs(), "/missing.json"))
+	_, err = v.Verify(sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims(time.Now())), time.Now())
+
+	// The keys couldn't be loaded, the token may well be valid
+	assert.IsType(t, ErrKeysUnavailable{}, err)
+}
+
+func Test_Verifier_Verify_ThrottlesLoadingWhileIssuerIsDown(t *testing.T) {
+	at := time.Now()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	requests := 0
+	down := true
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if down {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		fmt.Fprint(w, string(buildJWKS(t, rsaJWK("rsa", rsaKey))))
+	}))
+	defer srv.Close()
+
+	v := NewVerifier(NewURLIssuer(testIssuer, testAudience, srv.URL, time.Second))
+	token := sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims(at))
+
+	for i := 0; i < 3; i++ {
+		_, err = v.Verify(token, at.Add(time.Duration(i)*time.Second))
+		assert.Equal(t, ErrKeysUnavailable{
+			Issuer: testIssuer,
+			Reason: fmt.Sprintf("jwks request to %s responded with status 502", srv.URL),
+		}, err)
+	}
+
+	assert.Equal(t, 1, requests, "the keys aren't loaded again until the retry interval has passed")
+
+	down = false
+	later := at.Add(2 * time.Minute)
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims(later)), later)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func Test_Verifier_Verify_LoadsRotatedKeysFromURL(t *testing.T) {
+	at := time.Now()
+
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	requests := 0
+	keys := buildJWKS(t, rsaJWK("old", oldKey))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, string(keys))
+	}))
+	defer srv.Close()
+
+	v := NewVerifier(NewURLIssuer(testIssuer, testAudience, srv.URL, time.Second))
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims(at)), at)
+	assert.Nil(t, err)
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims(at)), at)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, requests)
+
+	keys = buildJWKS(t, rsaJWK("old", oldKey), rsaJWK("new", newKey))
+
+	// Too soon after the last load to look for the new key
+	_, err = v.Verify(sign(t, jwt.SigningMethodRS256, "new", newKey, validClaims(at)), at)
+	assert.IsType(t, ErrInvalidToken{}, err)
+	assert.Equal(t, 1, requests)
+
+	later := at.Add(2 * time.Minute)
+	_, err = v.Verify(sign(t, jwt.SigningMethodRS256, "new", newKey, validClaims(later)), later)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, requests)
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// Spinner redraws a single line with a rotating frame, to show that a long
+// running task is still in progress.
+type Spinner struct {
+	writer   io.Writer
+	interval time.Duration
+	mu       sync.Mutex
+	message  string
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func (s *Spinner) SetMessage(m string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.message = m
+}
+
+func (s *Spinner) draw(frame string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Clear to the end of the line, in case the message got shorter
+	fmt.Fprintf(s.writer, "\r%s %s\033[K", frame, s.message)
+}
+
+func (s *Spinner) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for i := 0; ; i++ {
+			s.draw(spinnerFrames[i%len(spinnerFrames)])
+
+			select {
+			case <-s.stop:
+				fmt.Fprint(s.writer, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop clears the spinner line, so the next output starts on a clean line.
+func (s *Spinner) Stop() {
+	if s.stop == nil {
+		return
+	}
+
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+func NewSpinner(w io.Writer, message string) *Spinner {
+	return &Spinner{
+		writer:   w,
+		interval: 100 * time.Millisecond,
+		message:  message,
+	}
+}
@@ -0,0 +1,72 @@
+package registry
+
+import "context"
+
+type ActorType string
+
+type actorTypesContainer struct {
+	Token       ActorType
+	OIDC        ActorType
+	Certificate ActorType
+}
+
+var ActorTypes actorTypesContainer = actorTypesContainer{
+	Token:       "token",
+	OIDC:        "oidc",
+	Certificate: "certificate",
+}
+
+// Actor is whoever a request was authenticated as.
+type Actor struct {
+	Type   ActorType    `json:"type"`
+	Id     string       `json:"id"`
+	Name   string       `json:"name"`
+	Scopes []TokenScope `json:"scopes"`
+	// The user a token was issued to by `terraform login`, their roles and
+	// those of their teams apply to it
+	UserId string `json:"user_id,omitempty"`
+	// The claims of an OIDC token, as the identity it was issued for
+	Claims map[string]interface{} `json:"claims,omitempty"`
+}
+
+// Allows is true when the actor has the scope in the namespace, or for every
+// namespace when the namespace is empty.
+func (a Actor) Allows(scope TokenScope, namespace string) bool {
+	return scopesAllow(a.Scopes, scope, namespace)
+}
+
+type actorContextKey struct{}
+
+func ContextWithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, a)
+}
+
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	a, ok := ctx.Value(actorContextKey{}).(Actor)
+
+	return a, ok
+}
+
+// AttributedAction records the actor that took an action in its audit meta.
+type AttributedAction struct {
+	AuditableAction
+	Actor Actor
+}
+
+func (a AttributedAction) GetAuditMeta() map[string]interface{} {
+	meta := map[string]interface{}{}
+
+	for k, v := range a.AuditableAction.GetAuditMeta() {
+		meta[k] = v
+	}
+
+	meta["actor"] = a.Actor
+
+	return meta
+}
+
+// Unwrap is the action that was attributed, for the auditor to find its
+// events.
+func (a AttributedAction) Unwrap() AuditableAction {
+	return a.AuditableAction
+}
@@ -0,0 +1,124 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/gpg"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type addGPGKeyRepository interface {
+	GPGKeysByNamespace(namespace string) ([]GPGKey, error)
+	AddGPGKey(GPGKey) (k GPGKey, err error)
+}
+
+type addGPGKeyV1CommandValidator interface {
+	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+// AddGPGKeyV1DTO registers a public key that the provider releases in the
+// namespace are signed with.
+type AddGPGKeyV1DTO struct {
+	Namespace  string `json:"namespace" validate:"required"`
+	ASCIIArmor string `json:"ascii_armor" validate:"required,gpg_public_key"`
+}
+
+type addGPGKeyV1Command struct {
+	DTO AddGPGKeyV1DTO
+}
+
+type AddGPGKeyV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Key              GPGKey
+	ValidationErrors []ValidationError
+}
+
+func (r AddGPGKeyV1Response) GetActionName() string {
+	return "v1.providers.keys.add"
+}
+
+func (r AddGPGKeyV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r AddGPGKeyV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r AddGPGKeyV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"gpg_key_id":        r.Key.Id,
+		"key_id":            r.Key.KeyId,
+		"namespace":         r.Key.Namespace,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (dto AddGPGKeyV1DTO) validate(r addGPGKeyRepository, v addGPGKeyV1CommandValidator, logger zerolog.Logger) []ValidationError {
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		keyId, err := gpg.KeyId(dto.ASCIIArmor)
+
+		if err != nil {
+			// Reported by the field rule
+			return
+		}
+
+		existing, err := r.GPGKeysByNamespace(dto.Namespace)
+
+		if err != nil {
+			logger.Error().Err(err).Msg("unexpected repository error during validation")
+			return
+		}
+
+		for _, k := range existing {
+			if k.KeyId == keyId {
+				sl.ReportError(keyId, "ascii_armor", "ASCIIArmor", uniqueGPGKeyTag, keyId)
+				return
+			}
+		}
+	}, AddGPGKeyV1DTO{})
+
+	return v.Validate(dto)
+}
+
+func (cmd addGPGKeyV1Command) handle(r addGPGKeyRepository, logger zerolog.Logger, v addGPGKeyV1CommandValidator) (AddGPGKeyV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := cmd.DTO.validate(r, v, logger); len(errs) > 0 {
+		return AddGPGKeyV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	// Validation has already parsed the key
+	keyId, _ := gpg.KeyId(cmd.DTO.ASCIIArmor)
+
+	k, err := r.AddGPGKey(GPGKey{
+		Id:         uuid.New().String(),
+		Namespace:  cmd.DTO.Namespace,
+		KeyId:      keyId,
+		ASCIIArmor: cmd.DTO.ASCIIArmor,
+		CreatedAt:  occurred,
+	})
+
+	if err != nil {
+		logger.Error().Err(err).Str("namespace", cmd.DTO.Namespace).Str("key_id", keyId).Msg("failed to add gpg key")
+
+		return AddGPGKeyV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return AddGPGKeyV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_CREATED,
+		Key:        k,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,14 +10,14 @@ import (
 )
 
 type addModuleRepository interface {
-	AddModule(Module) (Module, error)
-	ByFQN(ModuleFQN) (m Module, err error)
+	AddModule(context.Context, Module) (Module, error)
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
 }
 
 type addModuleV1CommandValidator interface {
 	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
 	Validate(cmd interface{}) []ValidationError
-	ModuleNameMustBeUnique(r uniqueModuleNameRepository, sl validator.StructLevel, fqn ModuleFQN)
+	ModuleNameMustBeUnique(ctx context.Context, r uniqueModuleNameRepository, sl validator.StructLevel, fqn ModuleFQN)
 }
 
 type addModuleV1Command struct {
@@ -50,9 +51,17 @@ func (r AddModuleV1Response) GetAuditMeta() map[string]interface{} {
 }
 
 type AddModuleV1DTO struct {
-	Namespace string `validate:"required" json:"namespace"`
-	Name      string `validate:"required" json:"name"`
-	Provider  string `validate:"required" json:"provider"`
+	Namespace     string `validate:"required" json:"namespace"`
+	Name          string `validate:"required" json:"name"`
+	Provider      string `validate:"required" json:"provider"`
+	RepositoryURL string `validate:"omitempty,repository_url" json:"repository_url"`
+	Path          string `validate:"module_path" json:"path"`
+	DefaultBranch string `json:"default_branch"`
+	TagPattern    string `validate:"tag_pattern" json:"tag_pattern"`
+	// Defaults to reject
+	BreakingChanges string `validate:"omitempty,breaking_change_policy" json:"breaking_changes"`
+	// Only set by the cli, the API never clones a path on the server
+	AllowLocalRepository bool `json:"-"`
 }
 
 func (d AddModuleV1DTO) ToFQN() ModuleFQN {
@@ -63,9 +72,9 @@ func (d AddModuleV1DTO) ToFQN() ModuleFQN {
 	}
 }
 
-func (dto AddModuleV1DTO) validate(r addModuleRepository, v addModuleV1CommandValidator) []ValidationError {
+func (dto AddModuleV1DTO) validate(ctx context.Context, r addModuleRepository, v addModuleV1CommandValidator) []ValidationError {
 	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
-		v.ModuleNameMustBeUnique(r, sl, ModuleFQN{
+		v.ModuleNameMustBeUnique(ctx, r, sl, ModuleFQN{
 			Name:      dto.Name,
 			Namespace: dto.Namespace,
 			Provider:  dto.Provider,
@@ -75,9 +84,9 @@ func (dto AddModuleV1DTO) validate(r addModuleRepository, v addModuleV1CommandVa
 	return v.Validate(dto)
 }
 
-func (cmd addModuleV1Command) handle(r addModuleRepository, logger zerolog.Logger, v addModuleV1CommandValidator) (AddModuleV1Response, error) {
+func (cmd addModuleV1Command) handle(ctx context.Context, r addModuleRepository, logger zerolog.Logger, v addModuleV1CommandValidator) (AddModuleV1Response, error) {
 	occurred := time.Now().UTC()
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return AddModuleV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -86,11 +95,22 @@ func (cmd addModuleV1Command) handle(r addModuleRepository, logger zerolog.Logge
 	}
 
 	fqn := cmd.DTO.ToFQN()
-	m, err := r.AddModule(Module{
-		Id:        uuid.NewString(),
-		Name:      fqn.Name,
-		Namespace: fqn.Namespace,
-		Provider:  fqn.Provider,
+	policy := BreakingChangePolicy(cmd.DTO.BreakingChanges)
+
+	if policy == "" {
+		policy = BreakingChangePolicies.Reject
+	}
+
+	m, err := r.AddModule(ctx, Module{
+		Id:              uuid.NewString(),
+		Name:            fqn.Name,
+		Namespace:       fqn.Namespace,
+		Provider:        fqn.Provider,
+		RepositoryURL:   cmd.DTO.RepositoryURL,
+		Path:            CleanModulePath(cmd.DTO.Path),
+		DefaultBranch:   cmd.DTO.DefaultBranch,
+		TagPattern:      cmd.DTO.TagPattern,
+		BreakingChanges: policy,
 	})
 
 	if err != nil {
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,16 +10,16 @@ import (
 )
 
 type addModuleVersionRepository interface {
-	AddVersion(ModuleVersion) (m ModuleVersion, err error)
-	ById(id string) (m Module, err error)
-	VersionByModuleAndValue(moduleId string, version string) (mv ModuleVersion, err error)
+	AddVersion(context.Context, ModuleVersion) (m ModuleVersion, err error)
+	ById(ctx context.Context, id string) (m Module, err error)
+	VersionByModuleAndValue(ctx context.Context, moduleId string, version string) (mv ModuleVersion, err error)
 }
 
 type addModuleVersionV1CommandValidator interface {
 	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
 	Validate(cmd interface{}) []ValidationError
-	ModuleMustExistById(r moduleMustExistByIdRepository, sl validator.StructLevel, id string)
-	ModuleVersionMustBeUniqueForId(r moduleVersionMustBeUniqueForIdRepository, sl validator.StructLevel, id string, version string)
+	ModuleMustExistById(ctx context.Context, r moduleMustExistByIdRepository, sl validator.StructLevel, id string)
+	ModuleVersionMustBeUniqueForId(ctx context.Context, r moduleVersionMustBeUniqueForIdRepository, sl validator.StructLevel, id string, version string)
 }
 
 type addModuleVersionV1Command struct {
@@ -58,19 +59,34 @@ type AddModuleVersionV1DTO struct {
 	RepositoryURL string `json:"repository_url" validate:"required"`
 }
 
-func (dto AddModuleVersionV1DTO) validate(r addModuleVersionRepository, v addModuleVersionV1CommandValidator) []ValidationError {
+func (dto AddModuleVersionV1DTO) validate(ctx context.Context, r addModuleVersionRepository, v addModuleVersionV1CommandValidator) []ValidationError {
 	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
-		v.ModuleMustExistById(r, sl, dto.ModuleId)
-		v.ModuleVersionMustBeUniqueForId(r, sl, dto.ModuleId, dto.Version)
+		v.ModuleMustExistById(ctx, r, sl, dto.ModuleId)
+		v.ModuleVersionMustBeUniqueForId(ctx, r, sl, dto.ModuleId, dto.Version)
 	}, AddModuleVersionV1DTO{})
 
 	return v.Validate(dto)
 }
 
-func (cmd addModuleVersionV1Command) handle(r addModuleVersionRepository, logger zerolog.Logger, v addModuleVersionV1CommandValidator) (AddModuleVersionV1Response, error) {
+// inheritFromModule fills in any fields the module provides a default for.
+func (dto AddModuleVersionV1DTO) inheritFromModule(ctx context.Context, r addModuleVersionRepository) AddModuleVersionV1DTO {
+	if dto.RepositoryURL != "" {
+		return dto
+	}
+
+	// Validation will report a missing module, there's nothing to inherit
+	if m, err := r.ById(ctx, dto.ModuleId); err == nil {
+		dto.RepositoryURL = m.RepositoryURL
+	}
+
+	return dto
+}
+
+func (cmd addModuleVersionV1Command) handle(ctx context.Context, r addModuleVersionRepository, logger zerolog.Logger, v addModuleVersionV1CommandValidator) (AddModuleVersionV1Response, error) {
 	occurred := time.Now().UTC()
+	cmd.DTO = cmd.DTO.inheritFromModule(ctx, r)
 
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return AddModuleVersionV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -78,7 +94,7 @@ func (cmd addModuleVersionV1Command) handle(r addModuleVersionRepository, logger
 		}, nil
 	}
 
-	mv, err := r.AddVersion(ModuleVersion{
+	mv, err := r.AddVersion(ctx, ModuleVersion{
 		Id:            uuid.NewString(),
 		Version:       cmd.DTO.Version,
 		ModuleId:      cmd.DTO.ModuleId,
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -8,20 +9,20 @@ import (
 )
 
 type addModuleVersionByFqnRepository interface {
-	AddVersion(ModuleVersion) (m ModuleVersion, err error)
-	ById(id string) (m Module, err error)
-	ByFQN(ModuleFQN) (m Module, err error)
-	VersionByFQN(fqn ModuleVersionFQN) (mv ModuleVersion, err error)
-	VersionByModuleAndValue(moduleId string, version string) (mv ModuleVersion, err error)
+	AddVersion(context.Context, ModuleVersion) (m ModuleVersion, err error)
+	ById(ctx context.Context, id string) (m Module, err error)
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+	VersionByFQN(ctx context.Context, fqn ModuleVersionFQN) (mv ModuleVersion, err error)
+	VersionByModuleAndValue(ctx context.Context, moduleId string, version string) (mv ModuleVersion, err error)
 }
 
 type addModuleVersionByFqnV1CommandValidator interface {
 	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
 	Validate(cmd interface{}) []ValidationError
-	ModuleMustExistByFQN(r moduleMustExistByFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
-	ModuleMustExistById(r moduleMustExistByIdRepository, sl validator.StructLevel, id string)
-	ModuleVersionMustBeUniqueForFQN(r moduleVersionMustBeUniqueForFQNRepository, sl validator.StructLevel, fqn ModuleVersionFQN)
-	ModuleVersionMustBeUniqueForId(r moduleVersionMustBeUniqueForIdRepository, sl validator.StructLevel, id string, version string)
+	ModuleMustExistByFQN(ctx context.Context, r moduleMustExistByFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
+	ModuleMustExistById(ctx context.Context, r moduleMustExistByIdRepository, sl validator.StructLevel, id string)
+	ModuleVersionMustBeUniqueForFQN(ctx context.Context, r moduleVersionMustBeUniqueForFQNRepository, sl validator.StructLevel, fqn ModuleVersionFQN)
+	ModuleVersionMustBeUniqueForId(ctx context.Context, r moduleVersionMustBeUniqueForIdRepository, sl validator.StructLevel, id string, version string)
 }
 
 type addModuleVersionV1ByModuleFqnCommand struct {
@@ -32,13 +33,13 @@ type AddModuleVersionV1ByModuleFqnDTO struct {
 	Version       string    `json:"version" validate:"required,version"`
 	ModuleFQN     ModuleFQN `json:"required"`
 	Source        string    `json:"source" validate:"required"`
-	RepositoryURL string    `json:"repository_url" validate:"required"`
+	RepositoryURL string    `json:"repository_url"`
 }
 
-func (dto AddModuleVersionV1ByModuleFqnDTO) validate(r addModuleVersionByFqnRepository, v addModuleVersionByFqnV1CommandValidator) []ValidationError {
+func (dto AddModuleVersionV1ByModuleFqnDTO) validate(ctx context.Context, r addModuleVersionByFqnRepository, v addModuleVersionByFqnV1CommandValidator) []ValidationError {
 	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
-		v.ModuleMustExistByFQN(r, sl, dto.ModuleFQN)
-		v.ModuleVersionMustBeUniqueForFQN(r, sl, ModuleVersionFQN{
+		v.ModuleMustExistByFQN(ctx, r, sl, dto.ModuleFQN)
+		v.ModuleVersionMustBeUniqueForFQN(ctx, r, sl, ModuleVersionFQN{
 			ModuleFQN: dto.ModuleFQN,
 			Version:   dto.Version,
 		})
@@ -47,10 +48,10 @@ func (dto AddModuleVersionV1ByModuleFqnDTO) validate(r addModuleVersionByFqnRepo
 	return v.Validate(dto)
 }
 
-func (cmd addModuleVersionV1ByModuleFqnCommand) handle(r addModuleVersionByFqnRepository, logger zerolog.Logger, v addModuleVersionByFqnV1CommandValidator) (AddModuleVersionV1Response, error) {
+func (cmd addModuleVersionV1ByModuleFqnCommand) handle(ctx context.Context, r addModuleVersionByFqnRepository, logger zerolog.Logger, v addModuleVersionByFqnV1CommandValidator) (AddModuleVersionV1Response, error) {
 	occurred := time.Now().UTC()
 
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return AddModuleVersionV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -58,7 +59,7 @@ func (cmd addModuleVersionV1ByModuleFqnCommand) handle(r addModuleVersionByFqnRe
 		}, nil
 	}
 
-	m, err := r.ByFQN(cmd.DTO.ModuleFQN)
+	m, err := r.ByFQN(ctx, cmd.DTO.ModuleFQN)
 
 	if err != nil {
 		logger.Error().Err(err).Str("command", "add_module_version").Str("fqn", cmd.DTO.ModuleFQN.String()).Msg("failed find module")
@@ -78,5 +79,5 @@ func (cmd addModuleVersionV1ByModuleFqnCommand) handle(r addModuleVersionByFqnRe
 		},
 	}
 
-	return byIdCmd.handle(r, logger, v)
+	return byIdCmd.handle(ctx, r, logger, v)
 }
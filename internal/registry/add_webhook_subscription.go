@@ -0,0 +1,133 @@
+package registry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type addWebhookSubscriptionRepository interface {
+	AddSubscription(WebhookSubscription) (s WebhookSubscription, err error)
+}
+
+type addWebhookSubscriptionV1CommandValidator interface {
+	Validate(cmd interface{}) []ValidationError
+}
+
+// AddWebhookSubscriptionV1DTO subscribes a URL to registry events. A secret
+// is generated when one isn't supplied.
+type AddWebhookSubscriptionV1DTO struct {
+	URL        string   `json:"url" validate:"required,webhook_url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types" validate:"dive,event_type"`
+	Namespaces []string `json:"namespaces" validate:"dive,required"`
+}
+
+type addWebhookSubscriptionV1Command struct {
+	DTO AddWebhookSubscriptionV1DTO
+}
+
+type AddWebhookSubscriptionV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Subscription     WebhookSubscription
+	ValidationErrors []ValidationError
+}
+
+func (r AddWebhookSubscriptionV1Response) GetActionName() string {
+	return "v1.webhooks.subscriptions.add"
+}
+
+func (r AddWebhookSubscriptionV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r AddWebhookSubscriptionV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r AddWebhookSubscriptionV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"subscription_id":   r.Subscription.Id,
+		"url":               r.Subscription.URL,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func (cmd addWebhookSubscriptionV1Command) handle(r addWebhookSubscriptionRepository, logger zerolog.Logger, v addWebhookSubscriptionV1CommandValidator) (AddWebhookSubscriptionV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return AddWebhookSubscriptionV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	secret := cmd.DTO.Secret
+
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to generate webhook secret")
+
+			return AddWebhookSubscriptionV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		secret = generated
+	}
+
+	types := []EventType{}
+
+	for _, t := range cmd.DTO.EventTypes {
+		types = append(types, EventType(t))
+	}
+
+	namespaces := cmd.DTO.Namespaces
+
+	if namespaces == nil {
+		namespaces = []string{}
+	}
+
+	s, err := r.AddSubscription(WebhookSubscription{
+		Id:         uuid.New().String(),
+		URL:        cmd.DTO.URL,
+		Secret:     secret,
+		EventTypes: types,
+		Namespaces: namespaces,
+		CreatedAt:  occurred,
+	})
+
+	if err != nil {
+		logger.Error().Err(err).Str("url", cmd.DTO.URL).Msg("failed to add webhook subscription")
+
+		return AddWebhookSubscriptionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return AddWebhookSubscriptionV1Response{
+		occurredAt:   occurred,
+		Status:       STATUS_CREATED,
+		Subscription: s,
+	}, nil
+}
@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+func Test_addWebhookSubscriptionV1Command_handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		dto            AddWebhookSubscriptionV1DTO
+		expectedStatus RegistryHandlerStatus
+		expectedRules  []string
+	}{
+		{
+			name: "subscribes to everything",
+			dto: AddWebhookSubscriptionV1DTO{
+				URL: "https://bot.example.com/ymir",
+			},
+			expectedStatus: STATUS_CREATED,
+		},
+		{
+			name: "subscribes with filters",
+			dto: AddWebhookSubscriptionV1DTO{
+				URL:        "http://chat.internal:8080/hooks",
+				Secret:     "shh",
+				EventTypes: []string{"module_version.ready", "module_version.failed"},
+				Namespaces: []string{"platform"},
+			},
+			expectedStatus: STATUS_CREATED,
+		},
+		{
+			name:           "url is required",
+			dto:            AddWebhookSubscriptionV1DTO{},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"required"},
+		},
+		{
+			name: "url must be http",
+			dto: AddWebhookSubscriptionV1DTO{
+				URL: "ftp://bot.example.com",
+			},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"webhook_url"},
+		},
+		{
+			name: "unknown event types and empty namespaces are rejected",
+			dto: AddWebhookSubscriptionV1DTO{
+				URL:        "https://bot.example.com/ymir",
+				EventTypes: []string{"module_version.ready", "module.exploded"},
+				Namespaces: []string{""},
+			},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"event_type", "required"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := &fakeWebhookRepository{}
+
+			cmd := addWebhookSubscriptionV1Command{
+				DTO: test.dto,
+			}
+
+			res, err := cmd.handle(repo, l, NewCommandValidator(l))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+
+			rules := []string{}
+			for _, e := range res.ValidationErrors {
+				rules = append(rules, e.Rule)
+			}
+
+			if test.expectedRules == nil {
+				test.expectedRules = []string{}
+			}
+
+			assert.Equal(tt, test.expectedRules, rules)
+
+			if res.Status != STATUS_CREATED {
+				assert.Empty(tt, repo.subscriptions)
+				return
+			}
+
+			assert.Len(tt, repo.subscriptions, 1)
+			assert.NotEmpty(tt, res.Subscription.Id)
+			assert.NotEmpty(tt, res.Subscription.Secret)
+			assert.Len(tt, res.Subscription.EventTypes, len(test.dto.EventTypes))
+
+			if test.dto.Secret != "" {
+				assert.Equal(tt, test.dto.Secret, res.Subscription.Secret)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package registry
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APITokenPrefix starts every token, so a leaked one is easy to recognise.
+const APITokenPrefix = "ymir_"
+
+type TokenScope string
+
+type tokenScopesContainer struct {
+	ModulesRead     TokenScope
+	ModulesWrite    TokenScope
+	VersionsPublish TokenScope
+	Admin           TokenScope
+}
+
+var TokenScopes tokenScopesContainer = tokenScopesContainer{
+	ModulesRead:     "modules:read",
+	ModulesWrite:    "modules:write",
+	VersionsPublish: "versions:publish",
+	Admin:           "admin",
+}
+
+func AllTokenScopes() []TokenScope {
+	return []TokenScope{
+		TokenScopes.ModulesRead,
+		TokenScopes.ModulesWrite,
+		TokenScopes.VersionsPublish,
+		TokenScopes.Admin,
+	}
+}
+
+// NamespacedScope limits a scope to the modules in a single namespace, such as
+// `versions:publish:acme`. The admin scope can't be limited.
+func NamespacedScope(scope TokenScope, namespace string) TokenScope {
+	return TokenScope(string(scope) + ":" + namespace)
+}
+
+// NamespaceReadScope is the scope to read only the modules in the namespace.
+func NamespaceReadScope(namespace string) TokenScope {
+	return NamespacedScope(TokenScopes.ModulesRead, namespace)
+}
+
+// splitScope is the scope and the namespace it is limited to, the namespace is
+// empty for a scope that covers every namespace.
+func splitScope(s TokenScope) (TokenScope, string) {
+	for _, scope := range AllTokenScopes() {
+		if scope == TokenScopes.Admin {
+			continue
+		}
+
+		if ns := strings.TrimPrefix(string(s), string(scope)+":"); ns != string(s) {
+			return scope, ns
+		}
+	}
+
+	return s, ""
+}
+
+func IsTokenScope(s string) bool {
+	for _, scope := range AllTokenScopes() {
+		if string(scope) == s {
+			return true
+		}
+	}
+
+	scope, ns := splitScope(TokenScope(s))
+
+	return scope != TokenScope(s) && ns != "" && !strings.ContainsAny(ns, ":/ ")
+}
+
+// scopesAllow is true when one of the scopes is, or includes, the scope in
+// the namespace. The admin scope includes every other, and writing modules
+// includes reading them. Without a namespace only a scope that covers every
+// namespace will do.
+func scopesAllow(scopes []TokenScope, scope TokenScope, namespace string) bool {
+	for _, s := range scopes {
+		if s == TokenScopes.Admin {
+			return true
+		}
+
+		base, ns := splitScope(s)
+
+		if ns != "" && ns != namespace {
+			continue
+		}
+
+		if base == scope || (base == TokenScopes.ModulesWrite && scope == TokenScopes.ModulesRead) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// APIToken authenticates requests to the management API. Only a hash of the
+// token is stored, it is shown once when created.
+type APIToken struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// The start of the token, so it can be recognised in a list
+	Prefix string       `json:"prefix"`
+	Hash   string       `json:"-"`
+	Scopes []TokenScope `json:"scopes"`
+	// The user the token was issued to by `terraform login`
+	UserId     string     `json:"user_id,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Allows is true when the token has the scope, or one that includes it, for
+// every namespace.
+func (t APIToken) Allows(scope TokenScope) bool {
+	return scopesAllow(t.Scopes, scope, "")
+}
+
+// AllowsNamespace is true when the token can read the modules in the
+// namespace, either with a scope for it or one to read every namespace.
+func (t APIToken) AllowsNamespace(namespace string) bool {
+	return scopesAllow(t.Scopes, TokenScopes.ModulesRead, namespace)
+}
+
+// Active is false once the token has been revoked or has expired.
+func (t APIToken) Active(at time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+
+	return t.ExpiresAt == nil || at.Before(*t.ExpiresAt)
+}
+
+func (t APIToken) Actor() Actor {
+	return Actor{
+		Type:   ActorTypes.Token,
+		Id:     t.Id,
+		Name:   t.Name,
+		Scopes: t.Scopes,
+		UserId: t.UserId,
+	}
+}
+
+// HashAPIToken is how tokens are stored and looked up. They are random enough
+// that a fast hash is no easier to reverse than a slow one.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIToken() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return APITokenPrefix + hex.EncodeToString(b), nil
+}
+
+// newAPIToken is a token that hasn't been stored yet, and the secret that
+// authenticates as it.
+func newAPIToken(name string, scopes []TokenScope, expiresAt *time.Time, at time.Time) (APIToken, string, error) {
+	secret, err := generateAPIToken()
+
+	if err != nil {
+		return APIToken{}, "", err
+	}
+
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	return APIToken{
+		Id:        uuid.New().String(),
+		Name:      name,
+		Prefix:    secret[:len(APITokenPrefix)+8],
+		Hash:      HashAPIToken(secret),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: at,
+	}, secret, nil
+}
+
+func formatOptionalTime(t *time.Time, empty string) string {
+	if t == nil {
+		return empty
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func BuildAPITokensTable(tokens []APIToken) (h []string, r [][]string) {
+	h = []string{"ID", "Name", "Prefix", "Scopes", "Expires At", "Last Used At", "Revoked At"}
+
+	for _, t := range tokens {
+		scopes := []string{}
+
+		for _, s := range t.Scopes {
+			scopes = append(scopes, string(s))
+		}
+
+		r = append(r, []string{
+			t.Id,
+			t.Name,
+			t.Prefix,
+			strings.Join(scopes, ", "),
+			formatOptionalTime(t.ExpiresAt, "never"),
+			formatOptionalTime(t.LastUsedAt, "never"),
+			formatOptionalTime(t.RevokedAt, ""),
+		})
+	}
+
+	return
+}
@@ -0,0 +1,299 @@
+package registry
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeAPITokenRepository struct {
+	tokens  []APIToken
+	touched []string
+}
+
+func (r *fakeAPITokenRepository) TokenByHash(hash string) (APIToken, error) {
+	for _, t := range r.tokens {
+		if t.Hash == hash {
+			return t, nil
+		}
+	}
+
+	return APIToken{}, ErrResourceNotFound{Type: "APIToken", URI: "<redacted>"}
+}
+
+func (r *fakeAPITokenRepository) AddToken(t APIToken) (APIToken, error) {
+	r.tokens = append(r.tokens, t)
+
+	return t, nil
+}
+
+func (r *fakeAPITokenRepository) TouchToken(t APIToken, at time.Time) error {
+	r.touched = append(r.touched, t.Id)
+
+	return nil
+}
+
+func Test_APIToken_Allows(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []TokenScope
+		allowed  []TokenScope
+		rejected []TokenScope
+	}{
+		{
+			name:     "read only",
+			scopes:   []TokenScope{TokenScopes.ModulesRead},
+			allowed:  []TokenScope{TokenScopes.ModulesRead},
+			rejected: []TokenScope{TokenScopes.ModulesWrite, TokenScopes.VersionsPublish, TokenScopes.Admin},
+		},
+		{
+			name:     "write includes read",
+			scopes:   []TokenScope{TokenScopes.ModulesWrite},
+			allowed:  []TokenScope{TokenScopes.ModulesRead, TokenScopes.ModulesWrite},
+			rejected: []TokenScope{TokenScopes.VersionsPublish, TokenScopes.Admin},
+		},
+		{
+			name:     "publish only",
+			scopes:   []TokenScope{TokenScopes.VersionsPublish},
+			allowed:  []TokenScope{TokenScopes.VersionsPublish},
+			rejected: []TokenScope{TokenScopes.ModulesRead, TokenScopes.ModulesWrite, TokenScopes.Admin},
+		},
+		{
+			name:    "admin includes everything",
+			scopes:  []TokenScope{TokenScopes.Admin},
+			allowed: AllTokenScopes(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			token := APIToken{Scopes: test.scopes}
+
+			for _, s := range test.allowed {
+				assert.True(tt, token.Allows(s), s)
+			}
+
+			for _, s := range test.rejected {
+				assert.False(tt, token.Allows(s), s)
+			}
+		})
+	}
+}
+
+func Test_APIToken_AllowsNamespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []TokenScope
+		allowed  []string
+		rejected []string
+	}{
+		{
+			name:     "namespace read",
+			scopes:   []TokenScope{NamespaceReadScope("acme"), NamespaceReadScope("infra")},
+			allowed:  []string{"acme", "infra"},
+			rejected: []string{"other", "acm", ""},
+		},
+		{
+			name:    "global read",
+			scopes:  []TokenScope{TokenScopes.ModulesRead},
+			allowed: []string{"acme", "other"},
+		},
+		{
+			name:     "publish only",
+			scopes:   []TokenScope{TokenScopes.VersionsPublish},
+			rejected: []string{"acme"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			token := APIToken{Scopes: test.scopes}
+
+			for _, ns := range test.allowed {
+				assert.True(tt, token.AllowsNamespace(ns), ns)
+			}
+
+			for _, ns := range test.rejected {
+				assert.False(tt, token.AllowsNamespace(ns), ns)
+			}
+		})
+	}
+
+	assert.False(t, APIToken{Scopes: []TokenScope{NamespaceReadScope("acme")}}.Allows(TokenScopes.ModulesRead))
+}
+
+func Test_IsTokenScope(t *testing.T) {
+	assert.True(t, IsTokenScope("modules:read"))
+	assert.True(t, IsTokenScope("modules:read:acme"))
+	assert.False(t, IsTokenScope("modules:read:"))
+	assert.False(t, IsTokenScope("modules:read:acme:vpc"))
+	assert.True(t, IsTokenScope("modules:write:acme"))
+	assert.True(t, IsTokenScope("versions:publish:acme"))
+	assert.False(t, IsTokenScope("admin:acme"))
+	assert.False(t, IsTokenScope("modules:delete"))
+}
+
+func Test_Actor_Allows(t *testing.T) {
+	actor := Actor{
+		Scopes: []TokenScope{
+			NamespacedScope(TokenScopes.VersionsPublish, "platform"),
+			NamespacedScope(TokenScopes.ModulesWrite, "infra"),
+			TokenScopes.ModulesRead,
+		},
+	}
+
+	assert.True(t, actor.Allows(TokenScopes.VersionsPublish, "platform"))
+	assert.False(t, actor.Allows(TokenScopes.VersionsPublish, "infra"))
+	assert.False(t, actor.Allows(TokenScopes.VersionsPublish, ""))
+	assert.True(t, actor.Allows(TokenScopes.ModulesWrite, "infra"))
+	assert.False(t, actor.Allows(TokenScopes.ModulesWrite, "platform"))
+	assert.True(t, actor.Allows(TokenScopes.ModulesRead, ""))
+	assert.True(t, actor.Allows(TokenScopes.ModulesRead, "other"))
+	assert.False(t, actor.Allows(TokenScopes.Admin, ""))
+
+	assert.True(t, Actor{Scopes: []TokenScope{TokenScopes.Admin}}.Allows(TokenScopes.VersionsPublish, "platform"))
+}
+
+func Test_createAPITokenV1Command_handle(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name           string
+		dto            CreateAPITokenV1DTO
+		expectedStatus RegistryHandlerStatus
+		expectedRules  []string
+	}{
+		{
+			name:           "creates a token",
+			dto:            CreateAPITokenV1DTO{Name: "ci", Scopes: []string{"versions:publish", "modules:read:acme"}, ExpiresAt: &future},
+			expectedStatus: STATUS_CREATED,
+		},
+		{
+			name:           "name and scopes are required",
+			dto:            CreateAPITokenV1DTO{},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"required", "required"},
+		},
+		{
+			name:           "scopes must be known",
+			dto:            CreateAPITokenV1DTO{Name: "ci", Scopes: []string{"modules:delete"}},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"token_scope"},
+		},
+		{
+			name:           "expiry must be in the future",
+			dto:            CreateAPITokenV1DTO{Name: "ci", Scopes: []string{"admin"}, ExpiresAt: &past},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"future"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := &fakeAPITokenRepository{}
+
+			cmd := createAPITokenV1Command{
+				DTO: test.dto,
+			}
+
+			res, err := cmd.handle(repo, l, NewCommandValidator(l))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+
+			rules := []string{}
+			for _, e := range res.ValidationErrors {
+				rules = append(rules, e.Rule)
+			}
+
+			if test.expectedRules == nil {
+				test.expectedRules = []string{}
+			}
+
+			assert.Equal(tt, test.expectedRules, rules)
+
+			if res.Status != STATUS_CREATED {
+				assert.Empty(tt, repo.tokens)
+				return
+			}
+
+			assert.Len(tt, repo.tokens, 1)
+			assert.True(tt, strings.HasPrefix(res.Secret, APITokenPrefix))
+			assert.True(tt, strings.HasPrefix(res.Secret, res.APIToken.Prefix))
+			assert.Equal(tt, HashAPIToken(res.Secret), repo.tokens[0].Hash)
+			assert.NotContains(tt, repo.tokens[0].Hash, res.Secret)
+			assert.Equal(tt, []TokenScope{TokenScopes.VersionsPublish, NamespaceReadScope("acme")}, res.APIToken.Scopes)
+			assert.NotContains(tt, res.GetAuditMeta(), "secret")
+		})
+	}
+}
+
+func Test_authenticateAPITokenV1Command_handle(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	repo := &fakeAPITokenRepository{
+		tokens: []APIToken{
+			{Id: "active", Hash: HashAPIToken("ymir_active"), Scopes: []TokenScope{TokenScopes.ModulesRead}},
+			{Id: "expiring", Hash: HashAPIToken("ymir_expiring"), ExpiresAt: &future},
+			{Id: "expired", Hash: HashAPIToken("ymir_expired"), ExpiresAt: &past},
+			{Id: "revoked", Hash: HashAPIToken("ymir_revoked"), RevokedAt: &past},
+		},
+	}
+
+	tests := []struct {
+		token          string
+		expectedStatus RegistryHandlerStatus
+		expectedId     string
+	}{
+		{token: "ymir_active", expectedStatus: STATUS_OKAY, expectedId: "active"},
+		{token: "ymir_expiring", expectedStatus: STATUS_OKAY, expectedId: "expiring"},
+		{token: "ymir_expired", expectedStatus: STATUS_UNAUTHORIZED},
+		{token: "ymir_revoked", expectedStatus: STATUS_UNAUTHORIZED},
+		{token: "ymir_unknown", expectedStatus: STATUS_UNAUTHORIZED},
+		{token: "active", expectedStatus: STATUS_UNAUTHORIZED},
+	}
+
+	for _, test := range tests {
+		t.Run(test.token, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo.touched = nil
+
+			cmd := authenticateAPITokenV1Command{
+				DTO: AuthenticateAPITokenV1DTO{Token: test.token},
+			}
+
+			res, err := cmd.handle(repo, l)
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+			assert.Equal(tt, test.expectedId, res.APIToken.Id)
+
+			if test.expectedId != "" {
+				assert.Equal(tt, []string{test.expectedId}, repo.touched)
+			} else {
+				assert.Empty(tt, repo.touched)
+			}
+		})
+	}
+}
+
+func Test_AttributedAction_GetAuditMeta(t *testing.T) {
+	action := RevokeAPITokenV1Response{
+		Status:   STATUS_OKAY,
+		APIToken: APIToken{Id: "revoked"},
+	}
+	actor := APIToken{Id: "admin", Name: "ops", Scopes: []TokenScope{TokenScopes.Admin}}.Actor()
+
+	meta := AttributedAction{AuditableAction: action, Actor: actor}.GetAuditMeta()
+
+	assert.Equal(t, "revoked", meta["token_id"])
+	assert.Equal(t, Actor{Type: ActorTypes.Token, Id: "admin", Name: "ops", Scopes: []TokenScope{TokenScopes.Admin}}, meta["actor"])
+	assert.NotContains(t, action.GetAuditMeta(), "actor")
+}
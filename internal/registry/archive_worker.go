@@ -0,0 +1,371 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type archiveBuilder interface {
+	Build(ctx context.Context, m Module, mv ModuleVersion) (downloadURL string, iface *ModuleInterface, err error)
+}
+
+type archiveWorkerRepository interface {
+	ById(ctx context.Context, id string) (m Module, err error)
+	VersionsByStatus(ctx context.Context, status VersionStatus, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	ClaimVersion(ctx context.Context, mv ModuleVersion, from VersionStatus, to VersionStatus) (claimed bool, err error)
+	StalledVersions(ctx context.Context, leasedBefore time.Time, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	ReclaimVersion(ctx context.Context, mv ModuleVersion, leasedBefore time.Time) (claimed bool, err error)
+	FinishVersion(ctx context.Context, mv ModuleVersion) (finished bool, err error)
+	SaveVersionInterface(context.Context, ModuleVersionInterface) error
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	VersionInterface(ctx context.Context, moduleVersionId string) (i ModuleVersionInterface, err error)
+}
+
+type actionRecorder interface {
+	Record(action AuditableAction)
+}
+
+type BuildModuleVersionV1Response struct {
+	occurredAt    time.Time
+	Status        RegistryHandlerStatus
+	Module        Module
+	ModuleVersion ModuleVersion
+	Duration      time.Duration
+	// Compared to the previous release, only set for minor and patch releases
+	BreakingChanges  []InterfaceChange
+	ValidationErrors []ValidationError
+}
+
+func (r BuildModuleVersionV1Response) GetActionName() string {
+	return "v1.modules.versions.build"
+}
+
+func (r BuildModuleVersionV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r BuildModuleVersionV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r BuildModuleVersionV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"module_version_id": r.ModuleVersion.Id,
+		"status":            r.ModuleVersion.Status,
+		"status_reason":     r.ModuleVersion.StatusReason,
+		"duration_ms":       r.Duration.Milliseconds(),
+		"breaking_changes":  r.BreakingChanges,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+// ArchiveWorker builds the archives for pending module versions. The status
+// of a module version acts as the queue, so any number of workers can run.
+type ArchiveWorker struct {
+	repo     archiveWorkerRepository
+	builder  archiveBuilder
+	recorder actionRecorder
+	logger   zerolog.Logger
+	interval time.Duration
+	// Builds taking longer are cancelled, so a clone that hangs can't stop
+	// the worker or hold up a shutdown
+	buildTimeout time.Duration
+	heartbeat    heartbeat
+}
+
+const archiveWorkerBatchSize = 10
+
+// archiveLeaseMargin is added to the build timeout, a version preparing for
+// longer than that was claimed by a worker that died part way through.
+const archiveLeaseMargin = time.Minute
+
+func (w *ArchiveWorker) Run(ctx context.Context) {
+	w.logger.Info().Dur("interval", w.interval).Msg("archive worker started")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		for w.ProcessPending(ctx) == archiveWorkerBatchSize {
+			// A full batch means there may be more waiting
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			w.logger.Info().Msg("archive worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPending builds a batch of pending versions, returning how many
+// versions were found. Once the context is done no more versions are claimed,
+// but the version being built is finished so that it isn't left preparing,
+// the build timeout bounds how long that takes.
+func (w *ArchiveWorker) ProcessPending(ctx context.Context) int {
+	w.heartbeat.beat()
+	w.processStalled(ctx)
+
+	pending, err := w.repo.VersionsByStatus(ctx, VersionStatuses.Pending, ChunkingOptions{
+		Size: archiveWorkerBatchSize,
+	})
+
+	if err != nil {
+		w.logger.Error().Err(err).Msg("failed to list pending module versions")
+
+		return 0
+	}
+
+	for _, mv := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+
+		claimed, err := w.repo.ClaimVersion(ctx, mv, VersionStatuses.Pending, VersionStatuses.Preparing)
+
+		if err != nil {
+			w.logger.Error().Err(err).Str("module_version_id", mv.Id).Msg("failed to claim module version")
+			continue
+		}
+
+		if !claimed {
+			// Another worker got there first
+			continue
+		}
+
+		w.buildClaimed(mv)
+	}
+
+	return len(pending)
+}
+
+// processStalled rebuilds the versions left preparing by a worker that was
+// killed, they would otherwise never be built.
+func (w *ArchiveWorker) processStalled(ctx context.Context) {
+	leasedBefore := time.Now().Add(-(w.buildTimeout + archiveLeaseMargin))
+	stalled, err := w.repo.StalledVersions(ctx, leasedBefore, ChunkingOptions{
+		Size: archiveWorkerBatchSize,
+	})
+
+	if err != nil {
+		w.logger.Error().Err(err).Msg("failed to list stalled module versions")
+
+		return
+	}
+
+	for _, mv := range stalled {
+		if ctx.Err() != nil {
+			break
+		}
+
+		claimed, err := w.repo.ReclaimVersion(ctx, mv, leasedBefore)
+
+		if err != nil {
+			w.logger.Error().Err(err).Str("module_version_id", mv.Id).Msg("failed to reclaim module version")
+			continue
+		}
+
+		if !claimed {
+			continue
+		}
+
+		w.logger.Warn().Str("module_version_id", mv.Id).Msg("rebuilding module version left preparing")
+		w.buildClaimed(mv)
+	}
+}
+
+func (w *ArchiveWorker) buildClaimed(mv ModuleVersion) {
+	res := w.build(context.Background(), mv)
+	w.heartbeat.beat()
+
+	if w.recorder != nil {
+		w.recorder.Record(res)
+	}
+}
+
+// LastPolled is when the worker last looked for pending versions or finished
+// building one, zero until it has started.
+func (w *ArchiveWorker) LastPolled() time.Time {
+	return w.heartbeat.last()
+}
+
+// fail records a failed build, finished is false when the version was
+// changed while it was building.
+func (w *ArchiveWorker) fail(ctx context.Context, mv ModuleVersion, reason string) (ModuleVersion, bool) {
+	mv.Status = VersionStatuses.Failed
+	mv.StatusReason = reason
+
+	finished, err := w.repo.FinishVersion(ctx, mv)
+
+	if err != nil {
+		w.logger.Error().Err(err).Str("module_version_id", mv.Id).Msg("failed to mark module version as failed")
+
+		return mv, true
+	}
+
+	return mv, finished
+}
+
+// saveInterface keeps the interface parsed during the build, the version is
+// still ready if it can't be saved as its archive was built.
+func (w *ArchiveWorker) saveInterface(ctx context.Context, mv ModuleVersion, iface *ModuleInterface) {
+	if iface == nil {
+		return
+	}
+
+	err := w.repo.SaveVersionInterface(ctx, ModuleVersionInterface{
+		ModuleVersionId: mv.Id,
+		Interface:       *iface,
+		ParsedAt:        time.Now().UTC(),
+	})
+
+	if err != nil {
+		w.logger.Error().Err(err).Str("module_version_id", mv.Id).Msg("failed to save module interface")
+	}
+}
+
+// breakingChanges compares the interface with the previous release, when the
+// version isn't allowed to break it. Nothing is compared when either version
+// has no interface.
+func (w *ArchiveWorker) breakingChanges(ctx context.Context, mv ModuleVersion, iface *ModuleInterface) (ModuleVersion, []InterfaceChange) {
+	if iface == nil {
+		return ModuleVersion{}, nil
+	}
+
+	versions, err := w.repo.VersionsByModule(ctx, mv.ModuleId, ChunkingOptions{})
+
+	if err != nil {
+		w.logger.Error().Err(err).Str("module_version_id", mv.Id).Msg("failed to list versions to check for breaking changes")
+
+		return ModuleVersion{}, nil
+	}
+
+	prev, ok := PreviousRelease(versions, mv.Version)
+
+	if !ok || AllowsBreakingChanges(prev.Version, mv.Version) {
+		return prev, nil
+	}
+
+	prevIface, err := w.repo.VersionInterface(ctx, prev.Id)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); !ok {
+			w.logger.Error().Err(err).Str("module_version_id", prev.Id).Msg("failed to find interface to check for breaking changes")
+		}
+
+		return prev, nil
+	}
+
+	return prev, BreakingChanges(DiffInterfaces(prevIface.Interface, *iface))
+}
+
+func describeBreakingChanges(prev ModuleVersion, changes []InterfaceChange) string {
+	described := []string{}
+
+	for _, c := range changes {
+		described = append(described, c.String())
+	}
+
+	return fmt.Sprintf("breaking changes since %s: %s", prev.Version, strings.Join(described, "; "))
+}
+
+func (w *ArchiveWorker) buildArchive(ctx context.Context, m Module, mv ModuleVersion) (string, *ModuleInterface, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.buildTimeout)
+	defer cancel()
+
+	downloadURL, iface, err := w.builder.Build(ctx, m, mv)
+
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return "", nil, ErrBuildTimedOut{After: w.buildTimeout}
+	}
+
+	return downloadURL, iface, err
+}
+
+func (w *ArchiveWorker) build(ctx context.Context, mv ModuleVersion) BuildModuleVersionV1Response {
+	started := time.Now()
+	res := BuildModuleVersionV1Response{
+		Status: STATUS_FAILED,
+	}
+
+	m, err := w.repo.ById(ctx, mv.ModuleId)
+	finished := true
+
+	if err != nil {
+		w.logger.Error().Err(err).Str("module_version_id", mv.Id).Msg("failed to find module for version")
+		res.ModuleVersion, finished = w.fail(ctx, mv, "module could not be found")
+	} else if downloadURL, iface, err := w.buildArchive(ctx, m, mv); err != nil {
+		w.logger.Error().Err(err).Str("module_version_id", mv.Id).Msg("failed to build module archive")
+		res.Module = m
+		res.ModuleVersion, finished = w.fail(ctx, mv, err.Error())
+	} else if prev, breaking := w.breakingChanges(ctx, mv, iface); len(breaking) > 0 && m.BreakingChanges != BreakingChangePolicies.Warn {
+		reason := describeBreakingChanges(prev, breaking)
+		w.logger.Info().Str("module_version_id", mv.Id).Str("previous", prev.Version).Msg("rejected module version with breaking changes")
+
+		res.Status = STATUS_INVALID
+		res.Module = m
+		res.BreakingChanges = breaking
+		res.ValidationErrors = []ValidationError{
+			{
+				Message: "only a major release may break the interface, " + reason,
+				Rule:    compatibleInterfaceTag,
+				Field:   "version",
+				Value:   mv.Version,
+			},
+		}
+
+		if res.ModuleVersion, finished = w.fail(ctx, mv, reason); finished {
+			w.saveInterface(ctx, mv, iface)
+		}
+	} else {
+		if len(breaking) > 0 {
+			w.logger.Warn().Str("module_version_id", mv.Id).Str("previous", prev.Version).Msg(describeBreakingChanges(prev, breaking))
+		}
+
+		mv.Status = VersionStatuses.Ready
+		mv.StatusReason = ""
+		mv.DownloadURL = downloadURL
+		res.Module = m
+		res.ModuleVersion = mv
+		res.BreakingChanges = breaking
+
+		if finished, err = w.repo.FinishVersion(ctx, mv); err != nil {
+			w.logger.Error().Err(err).Str("module_version_id", mv.Id).Msg("failed to mark module version as ready")
+			res.ModuleVersion, finished = w.fail(ctx, mv, "archive was built, but the version could not be updated")
+		} else if finished {
+			res.Status = STATUS_OKAY
+			w.saveInterface(ctx, mv, iface)
+		}
+	}
+
+	// A push queued the version to be built again from a newer commit, that
+	// build decides its status
+	if !finished {
+		w.logger.Info().Str("module_version_id", mv.Id).Str("source", mv.Source).Msg("module version was superseded while building")
+		res.Status = STATUS_CONFLICT
+	}
+
+	res.occurredAt = time.Now().UTC()
+	res.Duration = time.Since(started)
+
+	return res
+}
+
+func NewArchiveWorker(r archiveWorkerRepository, b archiveBuilder, rec actionRecorder, interval time.Duration, buildTimeout time.Duration, l zerolog.Logger) *ArchiveWorker {
+	return &ArchiveWorker{
+		repo:         r,
+		builder:      b,
+		recorder:     rec,
+		logger:       l,
+		interval:     interval,
+		buildTimeout: buildTimeout,
+	}
+}
@@ -0,0 +1,331 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeArchiveBuilder struct {
+	err   error
+	hangs bool
+	// Called part way through the build
+	during func()
+}
+
+func (b *fakeArchiveBuilder) Build(ctx context.Context, m Module, mv ModuleVersion) (string, *ModuleInterface, error) {
+	if b.during != nil {
+		b.during()
+	}
+
+	if b.hangs {
+		<-ctx.Done()
+
+		return "", nil, ctx.Err()
+	}
+
+	if b.err != nil {
+		return "", nil, b.err
+	}
+
+	iface := &ModuleInterface{
+		Variables: []ModuleVariable{
+			{Name: "cidr", Required: true},
+		},
+	}
+
+	return "/archives/" + m.Name + "/" + mv.Version + ".tar.gz", iface, nil
+}
+
+type fakeActionRecorder struct {
+	actions []AuditableAction
+}
+
+func (r *fakeActionRecorder) Record(a AuditableAction) {
+	r.actions = append(r.actions, a)
+}
+
+func Test_ArchiveWorker_ProcessPending(t *testing.T) {
+	tests := []struct {
+		name           string
+		buildErr       error
+		expectedStatus VersionStatus
+		expectedURL    string
+		expectedReason string
+		expectedResult RegistryHandlerStatus
+		expectedSaved  []string
+	}{
+		{
+			name:           "build succeeds",
+			expectedStatus: VersionStatuses.Ready,
+			expectedURL:    "/archives/vpc/1.0.0.tar.gz",
+			expectedResult: STATUS_OKAY,
+			expectedSaved:  []string{"mv-1"},
+		},
+		{
+			name:           "build fails",
+			buildErr:       errors.New("path vpc does not exist"),
+			expectedStatus: VersionStatuses.Failed,
+			expectedReason: "path vpc does not exist",
+			expectedResult: STATUS_FAILED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := &fakeVersionRepository{
+				modules: map[string]Module{
+					publishTestModuleId: {Id: publishTestModuleId, Name: "vpc"},
+				},
+				versions: []ModuleVersion{
+					{Id: "mv-1", ModuleId: publishTestModuleId, Version: "1.0.0", Status: VersionStatuses.Pending},
+					{Id: "mv-2", ModuleId: publishTestModuleId, Version: "0.9.0", Status: VersionStatuses.Ready},
+				},
+			}
+			rec := &fakeActionRecorder{}
+
+			w := NewArchiveWorker(repo, &fakeArchiveBuilder{err: test.buildErr}, rec, time.Second, time.Minute, l)
+
+			assert.Equal(tt, 1, w.ProcessPending(context.Background()))
+			assert.Equal(tt, test.expectedStatus, repo.versions[0].Status)
+			assert.Equal(tt, test.expectedURL, repo.versions[0].DownloadURL)
+			assert.Equal(tt, test.expectedReason, repo.versions[0].StatusReason)
+
+			saved := []string{}
+			for _, i := range repo.interfaces {
+				saved = append(saved, i.ModuleVersionId)
+				assert.Equal(tt, "cidr", i.Interface.Variables[0].Name)
+			}
+
+			if test.expectedSaved == nil {
+				test.expectedSaved = []string{}
+			}
+
+			assert.Equal(tt, test.expectedSaved, saved)
+
+			assert.Len(tt, rec.actions, 1)
+			assert.Equal(tt, test.expectedResult, rec.actions[0].GetResponseStatus())
+			assert.Equal(tt, "v1.modules.versions.build", rec.actions[0].GetActionName())
+
+			// Nothing is left pending, so a second pass does nothing
+			assert.Equal(tt, 0, w.ProcessPending(context.Background()))
+			assert.Len(tt, rec.actions, 1)
+		})
+	}
+}
+
+func Test_ArchiveWorker_ProcessPending_BuildTimeout(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := &fakeVersionRepository{
+		modules: map[string]Module{
+			publishTestModuleId: {Id: publishTestModuleId, Name: "vpc"},
+		},
+		versions: []ModuleVersion{
+			{Id: "mv-1", ModuleId: publishTestModuleId, Version: "1.0.0", Status: VersionStatuses.Pending},
+		},
+	}
+	rec := &fakeActionRecorder{}
+
+	w := NewArchiveWorker(repo, &fakeArchiveBuilder{hangs: true}, rec, time.Second, 20*time.Millisecond, l)
+
+	assert.Equal(t, 1, w.ProcessPending(context.Background()))
+	assert.Equal(t, VersionStatuses.Failed, repo.versions[0].Status)
+	assert.Equal(t, "the build was cancelled after 20ms", repo.versions[0].StatusReason)
+	assert.Equal(t, STATUS_FAILED, rec.actions[0].GetResponseStatus())
+}
+
+func Test_ArchiveWorker_ProcessPending_ReclaimsStalledVersions(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := &fakeVersionRepository{
+		modules: map[string]Module{
+			publishTestModuleId: {Id: publishTestModuleId, Name: "vpc"},
+		},
+		versions: []ModuleVersion{
+			{Id: "mv-1", ModuleId: publishTestModuleId, Version: "1.0.0", Status: VersionStatuses.Preparing},
+			{Id: "mv-2", ModuleId: publishTestModuleId, Version: "1.1.0", Status: VersionStatuses.Preparing},
+		},
+		leased: map[string]time.Time{
+			"mv-1": time.Now().Add(-time.Hour),
+			"mv-2": time.Now(),
+		},
+	}
+	rec := &fakeActionRecorder{}
+
+	w := NewArchiveWorker(repo, &fakeArchiveBuilder{}, rec, time.Second, time.Minute, l)
+
+	assert.Equal(t, 0, w.ProcessPending(context.Background()))
+	assert.Equal(t, VersionStatuses.Ready, repo.versions[0].Status)
+	assert.Equal(t, VersionStatuses.Preparing, repo.versions[1].Status)
+	assert.Len(t, rec.actions, 1)
+}
+
+func Test_ArchiveWorker_ProcessPending_SupersededBuild(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := &fakeVersionRepository{
+		modules: map[string]Module{
+			publishTestModuleId: {Id: publishTestModuleId, Name: "vpc"},
+		},
+		versions: []ModuleVersion{
+			{Id: "mv-1", ModuleId: publishTestModuleId, Version: "dev-main", Source: "1a2b3c", Status: VersionStatuses.Pending},
+		},
+	}
+	rec := &fakeActionRecorder{}
+
+	// A push rebuilds the version from a newer commit while it is building
+	b := &fakeArchiveBuilder{
+		during: func() {
+			repo.versions[0].Source = "4d5e6f"
+			repo.versions[0].Status = VersionStatuses.Pending
+		},
+	}
+
+	w := NewArchiveWorker(repo, b, rec, time.Second, time.Minute, l)
+
+	assert.Equal(t, 1, w.ProcessPending(context.Background()))
+	assert.Equal(t, VersionStatuses.Pending, repo.versions[0].Status)
+	assert.Equal(t, "4d5e6f", repo.versions[0].Source)
+	assert.Empty(t, repo.versions[0].DownloadURL)
+	assert.Empty(t, repo.updated)
+	assert.Empty(t, repo.interfaces)
+	assert.Equal(t, STATUS_CONFLICT, rec.actions[0].GetResponseStatus())
+}
+
+func Test_ArchiveWorker_ProcessPending_BreakingChanges(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         BreakingChangePolicy
+		version        string
+		expectedStatus VersionStatus
+		expectedResult RegistryHandlerStatus
+		expectedRules  []string
+		expectedReason string
+	}{
+		{
+			name:           "minor release is rejected",
+			policy:         BreakingChangePolicies.Reject,
+			version:        "1.1.0",
+			expectedStatus: VersionStatuses.Failed,
+			expectedResult: STATUS_INVALID,
+			expectedRules:  []string{"compatible_interface"},
+			expectedReason: `breaking changes since 1.0.0: variable "name" was removed; output "id" was removed`,
+		},
+		{
+			name:           "patch release is rejected",
+			policy:         BreakingChangePolicies.Reject,
+			version:        "1.0.1",
+			expectedStatus: VersionStatuses.Failed,
+			expectedResult: STATUS_INVALID,
+			expectedRules:  []string{"compatible_interface"},
+			expectedReason: `breaking changes since 1.0.0: variable "name" was removed; output "id" was removed`,
+		},
+		{
+			name:           "minor release is allowed with a warning",
+			policy:         BreakingChangePolicies.Warn,
+			version:        "1.1.0",
+			expectedStatus: VersionStatuses.Ready,
+			expectedResult: STATUS_OKAY,
+		},
+		{
+			name:           "major release is allowed",
+			policy:         BreakingChangePolicies.Reject,
+			version:        "2.0.0",
+			expectedStatus: VersionStatuses.Ready,
+			expectedResult: STATUS_OKAY,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := &fakeVersionRepository{
+				modules: map[string]Module{
+					publishTestModuleId: {Id: publishTestModuleId, Name: "vpc", BreakingChanges: test.policy},
+				},
+				versions: []ModuleVersion{
+					{Id: "mv-2", ModuleId: publishTestModuleId, Version: test.version, Status: VersionStatuses.Pending},
+					{Id: "mv-1", ModuleId: publishTestModuleId, Version: "1.0.0", Status: VersionStatuses.Ready},
+					{Id: "mv-0", ModuleId: publishTestModuleId, Version: "0.1.0", Status: VersionStatuses.Ready},
+				},
+				interfaces: []ModuleVersionInterface{
+					{
+						ModuleVersionId: "mv-1",
+						Interface: ModuleInterface{
+							Variables: []ModuleVariable{
+								{Name: "cidr", Required: true},
+								{Name: "name", Required: true},
+							},
+							Outputs: []ModuleOutput{
+								{Name: "id"},
+							},
+						},
+					},
+				},
+			}
+			rec := &fakeActionRecorder{}
+
+			w := NewArchiveWorker(repo, &fakeArchiveBuilder{}, rec, time.Second, time.Minute, l)
+
+			assert.Equal(tt, 1, w.ProcessPending(context.Background()))
+			assert.Equal(tt, test.expectedStatus, repo.versions[0].Status)
+			assert.Equal(tt, test.expectedReason, repo.versions[0].StatusReason)
+
+			res := rec.actions[0].(BuildModuleVersionV1Response)
+			assert.Equal(tt, test.expectedResult, res.Status)
+
+			rules := []string{}
+			for _, e := range res.ValidationErrors {
+				rules = append(rules, e.Rule)
+			}
+
+			if test.expectedRules == nil {
+				test.expectedRules = []string{}
+			}
+
+			assert.Equal(tt, test.expectedRules, rules)
+
+			if test.version == "2.0.0" {
+				assert.Empty(tt, res.BreakingChanges)
+			} else {
+				assert.Len(tt, res.BreakingChanges, 2)
+			}
+
+			// The interface is kept either way, so the versions can be diffed
+			_, err := repo.VersionInterface(context.Background(), "mv-2")
+			assert.Nil(tt, err)
+		})
+	}
+}
+
+func Test_ArchiveWorker_SkipsVersionsClaimedElsewhere(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := &claimedElsewhereRepository{
+		fakeVersionRepository: &fakeVersionRepository{
+			versions: []ModuleVersion{
+				{Id: "mv-1", ModuleId: publishTestModuleId, Version: "1.0.0", Status: VersionStatuses.Pending},
+			},
+		},
+	}
+	rec := &fakeActionRecorder{}
+
+	w := NewArchiveWorker(repo, &fakeArchiveBuilder{}, rec, time.Second, time.Minute, l)
+	assert.True(t, w.LastPolled().IsZero())
+
+	assert.Equal(t, 1, w.ProcessPending(context.Background()))
+	assert.Empty(t, rec.actions)
+	assert.Empty(t, repo.updated)
+	assert.False(t, w.LastPolled().IsZero(), "an idle worker is still polling")
+}
+
+type claimedElsewhereRepository struct {
+	*fakeVersionRepository
+}
+
+func (r *claimedElsewhereRepository) ClaimVersion(ctx context.Context, mv ModuleVersion, from VersionStatus, to VersionStatus) (bool, error) {
+	return false, nil
+}
@@ -17,9 +17,39 @@ type auditLogsRepo interface {
 	Save(action string, respStatus RegistryHandlerStatus, occurred_at time.Time, meta map[string]interface{}) error
 }
 
+type eventPublisher interface {
+	Publish(events []Event) error
+}
+
+type actionObserver interface {
+	Observe(action AuditableAction)
+}
+
 type Auditor struct {
-	repo   auditLogsRepo
-	logger zerolog.Logger
+	repo      auditLogsRepo
+	events    eventPublisher
+	observers []actionObserver
+	logger    zerolog.Logger
+}
+
+type AuditorOption func(*Auditor)
+
+// WithEventPublisher publishes the events produced by any EventfulAction the
+// auditor records. They are published after the action is done, not in the
+// same transaction, so delivery is at most once: events are lost if the
+// process stops in between, or publishing fails.
+func WithEventPublisher(p eventPublisher) AuditorOption {
+	return func(a *Auditor) {
+		a.events = p
+	}
+}
+
+// WithObserver shows the observer every action the auditor records, such as
+// to count them.
+func WithObserver(o actionObserver) AuditorOption {
+	return func(a *Auditor) {
+		a.observers = append(a.observers, o)
+	}
 }
 
 func (a *Auditor) Record(action AuditableAction) {
@@ -29,11 +59,31 @@ func (a *Auditor) Record(action AuditableAction) {
 	if err != nil {
 		a.logger.Error().Err(err).Msg("error during audit log save process")
 	}
+
+	if aa, ok := action.(AttributedAction); ok {
+		action = aa.Unwrap()
+	}
+
+	for _, o := range a.observers {
+		o.Observe(action)
+	}
+
+	if ea, ok := action.(EventfulAction); ok && a.events != nil {
+		if err := a.events.Publish(ea.GetEvents()); err != nil {
+			a.logger.Error().Err(err).Str("action", action.GetActionName()).Msg("error publishing events")
+		}
+	}
 }
 
-func NewAuditor(r auditLogsRepo, l zerolog.Logger) *Auditor {
-	return &Auditor{
+func NewAuditor(r auditLogsRepo, l zerolog.Logger, opts ...AuditorOption) *Auditor {
+	a := &Auditor{
 		repo:   r,
 		logger: l,
 	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
 }
@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type authenticateAPITokenRepository interface {
+	TokenByHash(hash string) (t APIToken, err error)
+	TouchToken(t APIToken, at time.Time) error
+}
+
+type AuthenticateAPITokenV1DTO struct {
+	Token string
+}
+
+type authenticateAPITokenV1Command struct {
+	DTO AuthenticateAPITokenV1DTO
+}
+
+type AuthenticateAPITokenV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	APIToken   APIToken
+}
+
+func (r AuthenticateAPITokenV1Response) GetActionName() string {
+	return "v1.tokens.authenticate"
+}
+
+func (r AuthenticateAPITokenV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r AuthenticateAPITokenV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r AuthenticateAPITokenV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"token_id": r.APIToken.Id,
+	}
+}
+
+// handle is UNAUTHORIZED for a token that is unknown, revoked or expired,
+// without saying which.
+func (cmd authenticateAPITokenV1Command) handle(r authenticateAPITokenRepository, logger zerolog.Logger) (AuthenticateAPITokenV1Response, error) {
+	occurred := time.Now().UTC()
+	unauthorized := AuthenticateAPITokenV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_UNAUTHORIZED,
+	}
+
+	if !strings.HasPrefix(cmd.DTO.Token, APITokenPrefix) {
+		return unauthorized, nil
+	}
+
+	t, err := r.TokenByHash(HashAPIToken(cmd.DTO.Token))
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		return unauthorized, nil
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to look up api token")
+
+		return AuthenticateAPITokenV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if !t.Active(occurred) {
+		return unauthorized, nil
+	}
+
+	if err := r.TouchToken(t, occurred); err != nil {
+		logger.Warn().Err(err).Str("token_id", t.Id).Msg("failed to record api token use")
+	}
+
+	return AuthenticateAPITokenV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		APIToken:   t,
+	}, nil
+}
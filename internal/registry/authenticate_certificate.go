@@ -0,0 +1,246 @@
+package registry
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// CertificateRule grants scopes to the client certificates whose subject
+// matches, such as the certificates a deployment tool is issued.
+type CertificateRule struct {
+	// The subject as in `CN=deploy-1,O=Acme`. The certificate must have the
+	// same attributes in the same order, `*` matches anything but a `/`
+	// within the value of one attribute
+	Subject string
+	Scopes  []TokenScope
+	// The namespaces the scopes are limited to, every namespace when empty
+	Namespaces []string
+}
+
+type subjectAttribute struct {
+	Type  string
+	Value string
+}
+
+// subjectAttributeTypes are the short names pkix.Name.String gives the
+// attribute types, others are written as their OID.
+var subjectAttributeTypes = map[string]string{
+	"2.5.4.3":  "CN",
+	"2.5.4.5":  "SERIALNUMBER",
+	"2.5.4.6":  "C",
+	"2.5.4.7":  "L",
+	"2.5.4.8":  "ST",
+	"2.5.4.9":  "STREET",
+	"2.5.4.10": "O",
+	"2.5.4.11": "OU",
+	"2.5.4.17": "POSTALCODE",
+}
+
+// certificateSubject lists the attributes of the subject in the order
+// pkix.Name.String writes them, so rules are written the same way.
+func certificateSubject(n pkix.Name) []subjectAttribute {
+	rdns := pkix.RDNSequence{}
+
+	if n.ExtraNames == nil {
+		for _, atv := range n.Names {
+			if _, ok := subjectAttributeTypes[atv.Type.String()]; ok {
+				continue
+			}
+
+			rdns = append(rdns, pkix.RelativeDistinguishedNameSET{atv})
+		}
+	}
+
+	rdns = append(rdns, n.ToRDNSequence()...)
+	attrs := []subjectAttribute{}
+
+	for i := len(rdns) - 1; i >= 0; i-- {
+		for _, atv := range rdns[i] {
+			t, ok := subjectAttributeTypes[atv.Type.String()]
+
+			if !ok {
+				t = atv.Type.String()
+			}
+
+			attrs = append(attrs, subjectAttribute{Type: t, Value: fmt.Sprint(atv.Value)})
+		}
+	}
+
+	return attrs
+}
+
+// parseSubjectRule splits a subject such as `CN=deploy-*,O=Acme` into its
+// attributes, at the commas and pluses that aren't escaped.
+func parseSubjectRule(rule string) ([]subjectAttribute, bool) {
+	parts := []string{}
+	current := strings.Builder{}
+
+	for i := 0; i < len(rule); i++ {
+		switch c := rule[i]; {
+		case c == '\\' && i+1 < len(rule):
+			current.WriteByte(c)
+			current.WriteByte(rule[i+1])
+			i++
+		case c == ',' || c == '+':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+
+	parts = append(parts, current.String())
+	attrs := []subjectAttribute{}
+
+	for _, part := range parts {
+		kv := strings.SplitN(part, "=", 2)
+
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, false
+		}
+
+		attrs = append(attrs, subjectAttribute{
+			Type:  strings.ToUpper(strings.TrimSpace(kv[0])),
+			Value: unescapeSubjectValue(kv[1]),
+		})
+	}
+
+	return attrs, true
+}
+
+// unescapeSubjectValue undoes the escaping of RFC 4514, either `\,` or the
+// hex of the byte as in `\2C`.
+func unescapeSubjectValue(v string) string {
+	unescaped := strings.Builder{}
+
+	for i := 0; i < len(v); i++ {
+		if v[i] != '\\' || i+1 >= len(v) {
+			unescaped.WriteByte(v[i])
+			continue
+		}
+
+		if i+2 < len(v) {
+			if b, err := hex.DecodeString(v[i+1 : i+3]); err == nil {
+				unescaped.Write(b)
+				i += 2
+
+				continue
+			}
+		}
+
+		unescaped.WriteByte(v[i+1])
+		i++
+	}
+
+	return unescaped.String()
+}
+
+func (r CertificateRule) matches(subject pkix.Name) bool {
+	rule, ok := parseSubjectRule(r.Subject)
+
+	if !ok {
+		return false
+	}
+
+	attrs := certificateSubject(subject)
+
+	if len(rule) != len(attrs) {
+		return false
+	}
+
+	for i, want := range rule {
+		if want.Type != strings.ToUpper(attrs[i].Type) {
+			return false
+		}
+
+		if matched, err := path.Match(want.Value, attrs[i].Value); err != nil || !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+type AuthenticateCertificateV1DTO struct {
+	// A client certificate the server has verified against its client CA
+	Certificate *x509.Certificate
+}
+
+type authenticateCertificateV1Command struct {
+	DTO AuthenticateCertificateV1DTO
+}
+
+type AuthenticateCertificateV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	Actor      Actor
+}
+
+func (r AuthenticateCertificateV1Response) GetActionName() string {
+	return "v1.certificates.authenticate"
+}
+
+func (r AuthenticateCertificateV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r AuthenticateCertificateV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r AuthenticateCertificateV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"subject": r.Actor.Id,
+	}
+}
+
+// handle is UNAUTHORIZED for a certificate whose subject matches no rules, as
+// a client CA often issues certificates for more than the registry.
+func (cmd authenticateCertificateV1Command) handle(rules []CertificateRule, logger zerolog.Logger) (AuthenticateCertificateV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if cmd.DTO.Certificate == nil {
+		return AuthenticateCertificateV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_UNAUTHORIZED,
+		}, nil
+	}
+
+	subject := cmd.DTO.Certificate.Subject.String()
+	scopes := []TokenScope{}
+	matched := false
+
+	for _, r := range rules {
+		if r.matches(cmd.DTO.Certificate.Subject) {
+			matched = true
+			scopes = appendScopes(scopes, limitScopes(r.Scopes, r.Namespaces))
+		}
+	}
+
+	if !matched {
+		logger.Warn().Str("subject", subject).Msg("rejected client certificate, no rule matches its subject")
+
+		return AuthenticateCertificateV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_UNAUTHORIZED,
+		}, nil
+	}
+
+	return AuthenticateCertificateV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Actor: Actor{
+			Type:   ActorTypes.Certificate,
+			Id:     subject,
+			Name:   cmd.DTO.Certificate.Subject.CommonName,
+			Scopes: scopes,
+		},
+	}, nil
+}
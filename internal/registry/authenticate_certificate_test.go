@@ -0,0 +1,158 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+var testCertificateRules = []CertificateRule{
+	{
+		Subject:    "CN=deploy-*,O=Acme",
+		Scopes:     []TokenScope{TokenScopes.ModulesWrite},
+		Namespaces: []string{"platform"},
+	},
+	{
+		Subject: "CN=*,O=Acme",
+		Scopes:  []TokenScope{TokenScopes.ModulesRead},
+	},
+}
+
+func Test_authenticateCertificateV1Command_handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		subject        *pkix.Name
+		expectedStatus RegistryHandlerStatus
+		expectedActor  Actor
+	}{
+		{
+			name:           "matches every rule",
+			subject:        &pkix.Name{CommonName: "deploy-1", Organization: []string{"Acme"}},
+			expectedStatus: STATUS_OKAY,
+			expectedActor: Actor{
+				Type:   ActorTypes.Certificate,
+				Id:     "CN=deploy-1,O=Acme",
+				Name:   "deploy-1",
+				Scopes: []TokenScope{"modules:write:platform", TokenScopes.ModulesRead},
+			},
+		},
+		{
+			name:           "matches one rule",
+			subject:        &pkix.Name{CommonName: "dashboard", Organization: []string{"Acme"}},
+			expectedStatus: STATUS_OKAY,
+			expectedActor: Actor{
+				Type:   ActorTypes.Certificate,
+				Id:     "CN=dashboard,O=Acme",
+				Name:   "dashboard",
+				Scopes: []TokenScope{TokenScopes.ModulesRead},
+			},
+		},
+		{
+			name:           "matches no rules",
+			subject:        &pkix.Name{CommonName: "deploy-1", Organization: []string{"Other"}},
+			expectedStatus: STATUS_UNAUTHORIZED,
+		},
+		{
+			name: "extra attribute between the rule's",
+			subject: &pkix.Name{
+				CommonName:         "deploy-1",
+				OrganizationalUnit: []string{"anything"},
+				Organization:       []string{"Acme"},
+			},
+			expectedStatus: STATUS_UNAUTHORIZED,
+		},
+		{
+			name:           "escaped comma in the common name",
+			subject:        &pkix.Name{CommonName: "deploy-1,O=Acme"},
+			expectedStatus: STATUS_UNAUTHORIZED,
+		},
+		{
+			name:           "no certificate",
+			expectedStatus: STATUS_UNAUTHORIZED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			dto := AuthenticateCertificateV1DTO{}
+
+			if test.subject != nil {
+				dto.Certificate = &x509.Certificate{Subject: *test.subject}
+			}
+
+			cmd := authenticateCertificateV1Command{
+				DTO: dto,
+			}
+
+			res, err := cmd.handle(testCertificateRules, ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+			assert.Equal(tt, test.expectedActor, res.Actor)
+		})
+	}
+}
+
+func Test_CertificateRule_matches(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		subject  pkix.Name
+		expected bool
+	}{
+		{
+			name:     "glob within a value",
+			rule:     "CN=deploy-*,O=Acme",
+			subject:  pkix.Name{CommonName: "deploy-1", Organization: []string{"Acme"}},
+			expected: true,
+		},
+		{
+			name:     "attribute types are case insensitive",
+			rule:     "cn=deploy-1, o=Acme",
+			subject:  pkix.Name{CommonName: "deploy-1", Organization: []string{"Acme"}},
+			expected: true,
+		},
+		{
+			name:     "escaped comma in the rule",
+			rule:     `CN=Acme\, Inc.,O=Acme`,
+			subject:  pkix.Name{CommonName: "Acme, Inc.", Organization: []string{"Acme"}},
+			expected: true,
+		},
+		{
+			name:     "hex escaped comma in the rule",
+			rule:     `CN=Acme\2C Inc.,O=Acme`,
+			subject:  pkix.Name{CommonName: "Acme, Inc.", Organization: []string{"Acme"}},
+			expected: true,
+		},
+		{
+			name:    "glob doesn't span attributes",
+			rule:    "CN=*",
+			subject: pkix.Name{CommonName: "deploy-1", Organization: []string{"Acme"}},
+		},
+		{
+			name:    "attributes in another order",
+			rule:    "O=Acme,CN=deploy-1",
+			subject: pkix.Name{CommonName: "deploy-1", Organization: []string{"Acme"}},
+		},
+		{
+			name:    "another attribute type",
+			rule:    "CN=deploy-1,OU=Acme",
+			subject: pkix.Name{CommonName: "deploy-1", Organization: []string{"Acme"}},
+		},
+		{
+			name:    "invalid rule",
+			rule:    "deploy-1",
+			subject: pkix.Name{CommonName: "deploy-1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			assert.Equal(tt, test.expected, CertificateRule{Subject: test.rule}.matches(test.subject))
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package registry
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type oidcVerifier interface {
+	Verify(token string, at time.Time) (map[string]interface{}, error)
+}
+
+// OIDCRule grants scopes to the tokens of an issuer that have the claims,
+// such as letting the pipelines of one repository publish to a namespace.
+type OIDCRule struct {
+	Issuer string
+	// The value each claim must have, `*` matches anything but a `/`, as in
+	// `refs/tags/*`
+	Claims map[string]string
+	Scopes []TokenScope
+	// The namespaces the scopes are limited to, every namespace when empty
+	Namespaces []string
+}
+
+func (r OIDCRule) matches(claims map[string]interface{}) bool {
+	if iss, _ := claims["iss"].(string); iss != r.Issuer {
+		return false
+	}
+
+	for name, pattern := range r.Claims {
+		v, ok := claims[name]
+
+		if !ok {
+			return false
+		}
+
+		if matched, err := path.Match(pattern, fmt.Sprint(v)); err != nil || !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// limitScopes is the scopes limited to each of the namespaces, or the scopes
+// as they are without any namespaces.
+func limitScopes(scopes []TokenScope, namespaces []string) []TokenScope {
+	if len(namespaces) == 0 {
+		return scopes
+	}
+
+	limited := []TokenScope{}
+
+	for _, ns := range namespaces {
+		for _, s := range scopes {
+			limited = append(limited, NamespacedScope(s, ns))
+		}
+	}
+
+	return limited
+}
+
+// appendScopes adds the scopes that aren't already in the list.
+func appendScopes(scopes []TokenScope, add []TokenScope) []TokenScope {
+	for _, s := range add {
+		found := false
+
+		for _, existing := range scopes {
+			if existing == s {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			scopes = append(scopes, s)
+		}
+	}
+
+	return scopes
+}
+
+// oidcScopes is every scope granted by the rules the claims match.
+func oidcScopes(rules []OIDCRule, claims map[string]interface{}) []TokenScope {
+	scopes := []TokenScope{}
+
+	for _, r := range rules {
+		if r.matches(claims) {
+			scopes = appendScopes(scopes, limitScopes(r.Scopes, r.Namespaces))
+		}
+	}
+
+	return scopes
+}
+
+type AuthenticateOIDCTokenV1DTO struct {
+	Token string
+}
+
+type authenticateOIDCTokenV1Command struct {
+	DTO AuthenticateOIDCTokenV1DTO
+}
+
+type AuthenticateOIDCTokenV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	Actor      Actor
+}
+
+func (r AuthenticateOIDCTokenV1Response) GetActionName() string {
+	return "v1.oidc.authenticate"
+}
+
+func (r AuthenticateOIDCTokenV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r AuthenticateOIDCTokenV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r AuthenticateOIDCTokenV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"claims": r.Actor.Claims,
+	}
+}
+
+// handle is UNAUTHORIZED for a token that isn't signed by a trusted issuer,
+// has expired, or can't be checked as the issuer's keys couldn't be loaded. A token that matches no rules is authenticated without any
+// scopes, so the request is forbidden rather than unauthorized.
+func (cmd authenticateOIDCTokenV1Command) handle(v oidcVerifier, rules []OIDCRule, logger zerolog.Logger) (AuthenticateOIDCTokenV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if v == nil {
+		return AuthenticateOIDCTokenV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_UNAUTHORIZED,
+		}, nil
+	}
+
+	claims, err := v.Verify(cmd.DTO.Token, occurred)
+
+	if err != nil {
+		logger.Warn().Err(err).Msg("rejected oidc token")
+
+		return AuthenticateOIDCTokenV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_UNAUTHORIZED,
+		}, nil
+	}
+
+	sub, _ := claims["sub"].(string)
+	iss, _ := claims["iss"].(string)
+
+	return AuthenticateOIDCTokenV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Actor: Actor{
+			Type:   ActorTypes.OIDC,
+			Id:     iss + "#" + sub,
+			Name:   sub,
+			Scopes: oidcScopes(rules, claims),
+			Claims: claims,
+		},
+	}, nil
+}
@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeOIDCVerifier struct {
+	claims map[string]interface{}
+	err    error
+}
+
+func (v fakeOIDCVerifier) Verify(token string, at time.Time) (map[string]interface{}, error) {
+	return v.claims, v.err
+}
+
+const testOIDCIssuer = "https://token.actions.githubusercontent.com"
+
+var testOIDCRules = []OIDCRule{
+	{
+		Issuer: testOIDCIssuer,
+		Claims: map[string]string{
+			"repository": "org/infra-modules",
+			"ref":        "refs/tags/*",
+		},
+		Scopes:     []TokenScope{TokenScopes.VersionsPublish},
+		Namespaces: []string{"platform", "network"},
+	},
+	{
+		Issuer: testOIDCIssuer,
+		Claims: map[string]string{
+			"repository_owner": "org",
+		},
+		Scopes: []TokenScope{TokenScopes.ModulesRead},
+	},
+	{
+		Issuer: "https://gitlab.example.com",
+		Claims: map[string]string{
+			"project_path": "org/infra-modules",
+		},
+		Scopes: []TokenScope{TokenScopes.Admin},
+	},
+}
+
+func Test_oidcScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   map[string]interface{}
+		expected []TokenScope
+	}{
+		{
+			name: "tag of the publishing repository",
+			claims: map[string]interface{}{
+				"iss":              testOIDCIssuer,
+				"repository":       "org/infra-modules",
+				"repository_owner": "org",
+				"ref":              "refs/tags/v1.2.0",
+			},
+			expected: []TokenScope{
+				"versions:publish:platform",
+				"versions:publish:network",
+				TokenScopes.ModulesRead,
+			},
+		},
+		{
+			name: "branch of the publishing repository",
+			claims: map[string]interface{}{
+				"iss":              testOIDCIssuer,
+				"repository":       "org/infra-modules",
+				"repository_owner": "org",
+				"ref":              "refs/heads/main",
+			},
+			expected: []TokenScope{TokenScopes.ModulesRead},
+		},
+		{
+			name: "wildcard doesn't cross a slash",
+			claims: map[string]interface{}{
+				"iss":        testOIDCIssuer,
+				"repository": "org/infra-modules",
+				"ref":        "refs/tags/release/v1",
+			},
+			expected: []TokenScope{},
+		},
+		{
+			name: "claims of another issuer",
+			claims: map[string]interface{}{
+				"iss":          testOIDCIssuer,
+				"project_path": "org/infra-modules",
+			},
+			expected: []TokenScope{},
+		},
+		{
+			name: "non string claim",
+			claims: map[string]interface{}{
+				"iss":              testOIDCIssuer,
+				"repository_owner": 42,
+			},
+			expected: []TokenScope{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			assert.Equal(tt, test.expected, oidcScopes(testOIDCRules, test.claims))
+		})
+	}
+}
+
+func Test_authenticateOIDCTokenV1Command_handle(t *testing.T) {
+	claims := map[string]interface{}{
+		"iss":        testOIDCIssuer,
+		"sub":        "repo:org/infra-modules:ref:refs/tags/v1.2.0",
+		"repository": "org/infra-modules",
+		"ref":        "refs/tags/v1.2.0",
+	}
+
+	tests := []struct {
+		name           string
+		verifier       oidcVerifier
+		expectedStatus RegistryHandlerStatus
+		expectedActor  Actor
+	}{
+		{
+			name:           "verified",
+			verifier:       fakeOIDCVerifier{claims: claims},
+			expectedStatus: STATUS_OKAY,
+			expectedActor: Actor{
+				Type:   ActorTypes.OIDC,
+				Id:     testOIDCIssuer + "#repo:org/infra-modules:ref:refs/tags/v1.2.0",
+				Name:   "repo:org/infra-modules:ref:refs/tags/v1.2.0",
+				Scopes: []TokenScope{"versions:publish:platform", "versions:publish:network"},
+				Claims: claims,
+			},
+		},
+		{
+			name:           "rejected",
+			verifier:       fakeOIDCVerifier{err: errors.New("expired")},
+			expectedStatus: STATUS_UNAUTHORIZED,
+		},
+		{
+			name:           "issuer's keys couldn't be loaded",
+			verifier:       fakeOIDCVerifier{err: errors.New("keys of oidc issuer 'https://ci.example.com' could not be loaded")},
+			expectedStatus: STATUS_UNAUTHORIZED,
+		},
+		{
+			name:           "not configured",
+			expectedStatus: STATUS_UNAUTHORIZED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			cmd := authenticateOIDCTokenV1Command{
+				DTO: AuthenticateOIDCTokenV1DTO{Token: "a.b.c"},
+			}
+
+			res, err := cmd.handle(test.verifier, testOIDCRules, ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+			assert.Equal(tt, test.expectedActor, res.Actor)
+		})
+	}
+}
+
+func Test_AttributedAction_RecordsOIDCClaims(t *testing.T) {
+	actor := Actor{
+		Type:   ActorTypes.OIDC,
+		Claims: map[string]interface{}{"repository": "org/infra-modules"},
+	}
+
+	meta := AttributedAction{
+		AuditableAction: AuthenticateOIDCTokenV1Response{Actor: actor},
+		Actor:           actor,
+	}.GetAuditMeta()
+
+	assert.Equal(t, actor, meta["actor"])
+	assert.Equal(t, actor.Claims, meta["claims"])
+}
@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// AuthorizeV1DTO asks whether the actor may use the scope in the namespace,
+// through its scopes or the roles it has been granted there.
+type AuthorizeV1DTO struct {
+	Actor     Actor
+	Scope     TokenScope
+	Namespace string
+}
+
+type authorizeV1Command struct {
+	DTO AuthorizeV1DTO
+}
+
+type AuthorizeV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+}
+
+func (r AuthorizeV1Response) GetActionName() string {
+	return "v1.authorize"
+}
+
+func (r AuthorizeV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r AuthorizeV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r AuthorizeV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+// handle is OK when the actor is permitted, and FORBIDDEN otherwise.
+func (cmd authorizeV1Command) handle(grants actorGrantsRepository, teams actorTeamsRepository, logger zerolog.Logger) (AuthorizeV1Response, error) {
+	occurred := time.Now().UTC()
+
+	ok, err := permits(cmd.DTO.Actor, cmd.DTO.Scope, cmd.DTO.Namespace, grants, teams)
+
+	if err != nil {
+		logger.Error().Err(err).Str("actor", cmd.DTO.Actor.Id).Msg("failed to find the actor's roles")
+
+		return AuthorizeV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if !ok {
+		return AuthorizeV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_FORBIDDEN,
+		}, nil
+	}
+
+	return AuthorizeV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+	}, nil
+}
@@ -0,0 +1,146 @@
+package registry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type authorizeLoginRepository interface {
+	UserByUsername(username string) (u User, err error)
+	AddAuthorizationCode(c AuthorizationCode) error
+}
+
+// AuthorizeLoginV1DTO is the login form, submitted with the authorization
+// request it was shown for.
+type AuthorizeLoginV1DTO struct {
+	LoginRequestV1DTO
+	Username string `validate:"required"`
+	Password string `validate:"required"`
+}
+
+type authorizeLoginV1Command struct {
+	DTO AuthorizeLoginV1DTO
+}
+
+type AuthorizeLoginV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	User       User
+	// Where the browser is sent with the code, back to terraform
+	RedirectURL      string
+	ValidationErrors []ValidationError
+}
+
+func (r AuthorizeLoginV1Response) GetActionName() string {
+	return "v1.login.authorize"
+}
+
+func (r AuthorizeLoginV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r AuthorizeLoginV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r AuthorizeLoginV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":           r.User.Id,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+// handle is UNAUTHORIZED for an unknown user or the wrong password, without
+// saying which.
+func (cmd authorizeLoginV1Command) handle(r authorizeLoginRepository, o LoginOptions, logger zerolog.Logger, v loginRequestV1CommandValidator) (AuthorizeLoginV1Response, error) {
+	occurred := time.Now().UTC()
+
+	registerLoginRequestValidator(o, v)
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return AuthorizeLoginV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	u, err := r.UserByUsername(cmd.DTO.Username)
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		return AuthorizeLoginV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_UNAUTHORIZED,
+		}, nil
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Str("username", cmd.DTO.Username).Msg("failed to find user")
+
+		return AuthorizeLoginV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if !u.CheckPassword(cmd.DTO.Password) {
+		return AuthorizeLoginV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_UNAUTHORIZED,
+			User:       u,
+		}, nil
+	}
+
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		logger.Error().Err(err).Msg("failed to generate authorization code")
+
+		return AuthorizeLoginV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	code := hex.EncodeToString(b)
+
+	err = r.AddAuthorizationCode(AuthorizationCode{
+		Hash:          HashAPIToken(code),
+		UserId:        u.Id,
+		RedirectURI:   cmd.DTO.RedirectURI,
+		CodeChallenge: cmd.DTO.CodeChallenge,
+		ExpiresAt:     occurred.Add(authorizationCodeTTL),
+		CreatedAt:     occurred,
+	})
+
+	if err != nil {
+		logger.Error().Err(err).Str("user_id", u.Id).Msg("failed to add authorization code")
+
+		return AuthorizeLoginV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	// Validated as a loopback url above
+	redirect, _ := url.Parse(cmd.DTO.RedirectURI)
+	q := redirect.Query()
+	q.Set("code", code)
+
+	if cmd.DTO.State != "" {
+		q.Set("state", cmd.DTO.State)
+	}
+
+	redirect.RawQuery = q.Encode()
+
+	return AuthorizeLoginV1Response{
+		occurredAt:  occurred,
+		Status:      STATUS_OKAY,
+		User:        u,
+		RedirectURL: redirect.String(),
+	}, nil
+}
@@ -1,8 +1,12 @@
 package registry
 
 import (
+	"context"
 	"encoding/json"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -19,6 +23,27 @@ type CommandBus struct {
 	buildValidator ValidatorBuilder
 	prompter       cliPrompter
 	fs             afero.Fs
+	checkout       sourceCheckout
+	resolver       refResolver
+	tags           tagLister
+	webhooks       WebhookRepository
+	providers      ProviderRepository
+	store          objectStore
+	mirror         ProviderMirrorRepository
+	upstreamRepo   UpstreamRepository
+	upstreams      []Upstream
+	upstream       upstreamRegistry
+	scanner        moduleCallScanner
+	tokens         APITokenRepository
+	users          UserRepository
+	login          *LoginOptions
+	oidc           oidcVerifier
+	oidcRules      []OIDCRule
+	certRules      []CertificateRule
+	namespaces     NamespaceRepository
+	teams          TeamRepository
+	// The actor commands are run for, see As
+	actor *Actor
 }
 
 type WithDependency func(*CommandBus)
@@ -53,6 +78,115 @@ func WithFS(fs afero.Fs) WithDependency {
 	}
 }
 
+func WithSourceCheckout(c sourceCheckout) WithDependency {
+	return func(cb *CommandBus) {
+		cb.checkout = c
+	}
+}
+
+func WithRefResolver(r refResolver) WithDependency {
+	return func(cb *CommandBus) {
+		cb.resolver = r
+	}
+}
+
+func WithTagLister(t tagLister) WithDependency {
+	return func(cb *CommandBus) {
+		cb.tags = t
+	}
+}
+
+func WithWebhookRepo(r WebhookRepository) WithDependency {
+	return func(cb *CommandBus) {
+		cb.webhooks = r
+	}
+}
+
+func WithProviderRepo(r ProviderRepository) WithDependency {
+	return func(cb *CommandBus) {
+		cb.providers = r
+	}
+}
+
+func WithObjectStore(s objectStore) WithDependency {
+	return func(cb *CommandBus) {
+		cb.store = s
+	}
+}
+
+func WithProviderMirrorRepo(r ProviderMirrorRepository) WithDependency {
+	return func(cb *CommandBus) {
+		cb.mirror = r
+	}
+}
+
+func WithUpstreamRepo(r UpstreamRepository) WithDependency {
+	return func(cb *CommandBus) {
+		cb.upstreamRepo = r
+	}
+}
+
+func WithUpstreams(upstreams []Upstream, client upstreamRegistry) WithDependency {
+	return func(cb *CommandBus) {
+		cb.upstreams = upstreams
+		cb.upstream = client
+	}
+}
+
+func WithModuleCallScanner(s moduleCallScanner) WithDependency {
+	return func(cb *CommandBus) {
+		cb.scanner = s
+	}
+}
+
+func WithAPITokenRepo(r APITokenRepository) WithDependency {
+	return func(cb *CommandBus) {
+		cb.tokens = r
+	}
+}
+
+func WithUserRepo(r UserRepository) WithDependency {
+	return func(cb *CommandBus) {
+		cb.users = r
+	}
+}
+
+// WithLogin enables `terraform login`.
+func WithLogin(o LoginOptions) WithDependency {
+	return func(cb *CommandBus) {
+		cb.login = &o
+	}
+}
+
+// WithOIDC accepts the OIDC tokens the verifier trusts, with the scopes the
+// rules grant them.
+func WithOIDC(v oidcVerifier, rules []OIDCRule) WithDependency {
+	return func(cb *CommandBus) {
+		cb.oidc = v
+		cb.oidcRules = rules
+	}
+}
+
+// WithCertificateRules accepts the verified client certificates the rules
+// match, with the scopes they grant.
+func WithCertificateRules(rules []CertificateRule) WithDependency {
+	return func(cb *CommandBus) {
+		cb.certRules = rules
+	}
+}
+
+func WithNamespaceRepo(r NamespaceRepository) WithDependency {
+	return func(cb *CommandBus) {
+		cb.namespaces = r
+	}
+}
+
+func WithTeamRepo(r TeamRepository) WithDependency {
+	return func(cb *CommandBus) {
+		cb.teams = r
+	}
+}
+
 func NewCommandBus(opts ...WithDependency) *CommandBus {
 	cb := &CommandBus{}
 
@@ -63,7 +197,120 @@ func NewCommandBus(opts ...WithDependency) *CommandBus {
 	return cb
 }
 
-func (cb *CommandBus) AddModuleV1FromCLI(filePath string) (AddModuleV1Response, error) {
+// As is the bus acting for the actor, commands for modules and providers are
+// FORBIDDEN unless the actor's scopes, or the roles it has been granted in
+// their namespace, permit them. The bus isn't restricted otherwise, as for
+// the CLI.
+func (cb *CommandBus) As(a Actor) *CommandBus {
+	acting := *cb
+	acting.actor = &a
+
+	return &acting
+}
+
+// permits is true when the bus isn't acting for an actor, or the actor may use
+// the scope in the namespace.
+func (cb *CommandBus) permits(scope TokenScope, namespace string) (bool, error) {
+	if cb.actor == nil {
+		return true, nil
+	}
+
+	ok, err := permits(*cb.actor, scope, namespace, cb.namespaces, cb.teams)
+
+	if err != nil {
+		cb.logger.Error().Err(err).Str("actor", cb.actor.Id).Msg("failed to find the actor's roles")
+	}
+
+	return ok, err
+}
+
+// permitsModule is permits for the namespace of the module. A module that
+// can't be found is left for the command to respond to.
+func (cb *CommandBus) permitsModule(ctx context.Context, id string, scope TokenScope) (bool, error) {
+	if cb.actor == nil || cb.actor.Allows(scope, "") {
+		return true, nil
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return true, nil
+	}
+
+	m, err := cb.repo.ById(ctx, id)
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		return true, nil
+	}
+
+	if err != nil {
+		cb.logger.Error().Err(err).Str("id", id).Msg("failed to find module")
+
+		return false, err
+	}
+
+	return cb.permits(scope, m.Namespace)
+}
+
+// permitsVersion is permits for the namespace of the version's module.
+func (cb *CommandBus) permitsVersion(ctx context.Context, id string, scope TokenScope) (bool, error) {
+	if cb.actor == nil || cb.actor.Allows(scope, "") {
+		return true, nil
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return true, nil
+	}
+
+	mv, err := cb.repo.VersionById(ctx, id)
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		return true, nil
+	}
+
+	if err != nil {
+		cb.logger.Error().Err(err).Str("id", id).Msg("failed to find module version")
+
+		return false, err
+	}
+
+	return cb.permitsModule(ctx, mv.ModuleId, scope)
+}
+
+// permittedNamespaces is the namespaces the actor may use the scope in, it is
+// nil when the bus isn't acting for an actor or the actor may use the scope
+// in every namespace.
+func (cb *CommandBus) permittedNamespaces(scope TokenScope) (map[string]bool, error) {
+	if cb.actor == nil || cb.actor.Allows(scope, "") {
+		return nil, nil
+	}
+
+	permitted, err := permittedNamespaces(*cb.actor, scope, cb.namespaces, cb.teams)
+
+	if err != nil {
+		cb.logger.Error().Err(err).Str("actor", cb.actor.Id).Msg("failed to find the actor's roles")
+	}
+
+	return permitted, err
+}
+
+// ensureNamespace adds the namespace modules or providers have been added
+// to, so that roles can be granted in it.
+func (cb *CommandBus) ensureNamespace(name string, at time.Time) {
+	if cb.namespaces == nil {
+		return
+	}
+
+	err := cb.namespaces.EnsureNamespace(Namespace{
+		Id:        uuid.New().String(),
+		Name:      name,
+		CreatedAt: at,
+	})
+
+	if err != nil {
+		cb.logger.Error().Err(err).Str("namespace", name).Msg("failed to add namespace")
+	}
+}
+
+func (cb *CommandBus) AddModuleV1FromCLI(ctx context.Context, filePath string) (AddModuleV1Response, error) {
 	dto := AddModuleV1DTO{}
 
 	if filePath != "" {
@@ -85,7 +332,7 @@ func (cb *CommandBus) AddModuleV1FromCLI(filePath string) (AddModuleV1Response,
 			}
 		}
 	} else {
-		var name, ns, provider string
+		var name, ns, provider, repoUrl, path, branch, tagPattern string
 		var err error
 
 		if provider, err = cb.prompter.Ask("Provider: "); err != nil {
@@ -106,55 +353,144 @@ func (cb *CommandBus) AddModuleV1FromCLI(filePath string) (AddModuleV1Response,
 			}
 		}
 
+		if repoUrl, err = cb.prompter.Ask("Repository URL (optional): "); err != nil {
+			return AddModuleV1Response{}, ErrQuestionFailed{
+				Question: "Repository URL (optional): ",
+			}
+		}
+
+		if path, err = cb.prompter.Ask("Path within repository (optional): "); err != nil {
+			return AddModuleV1Response{}, ErrQuestionFailed{
+				Question: "Path within repository (optional): ",
+			}
+		}
+
+		if branch, err = cb.prompter.Ask("Default branch (optional): "); err != nil {
+			return AddModuleV1Response{}, ErrQuestionFailed{
+				Question: "Default branch (optional): ",
+			}
+		}
+
+		if tagPattern, err = cb.prompter.Ask("Tag pattern (optional, e.g. modules/vpc/v{version}): "); err != nil {
+			return AddModuleV1Response{}, ErrQuestionFailed{
+				Question: "Tag pattern (optional, e.g. modules/vpc/v{version}): ",
+			}
+		}
+
 		dto.Name = name
 		dto.Namespace = ns
 		dto.Provider = provider
+		dto.RepositoryURL = repoUrl
+		dto.Path = path
+		dto.DefaultBranch = branch
+		dto.TagPattern = tagPattern
 	}
 
-	return cb.AddModuleV1FromDTO(dto)
+	dto.AllowLocalRepository = true
+
+	return cb.AddModuleV1FromDTO(ctx, dto)
 }
 
-func (cb *CommandBus) AddModuleV1FromDTO(dto AddModuleV1DTO) (AddModuleV1Response, error) {
+func (cb *CommandBus) AddModuleV1FromDTO(ctx context.Context, dto AddModuleV1DTO) (AddModuleV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.ModulesWrite, dto.Namespace); !ok {
+		return AddModuleV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := addModuleV1Command{
 		DTO: dto,
 	}
 
 	v := cb.buildValidator(cb.logger)
+	res, err := cmd.handle(ctx, cb.repo, cb.logger, v)
+
+	if err == nil && res.Status == STATUS_CREATED {
+		cb.ensureNamespace(res.Module.Namespace, res.occurredAt)
+	}
 
-	return cmd.handle(cb.repo, cb.logger, v)
+	return res, err
 }
 
-func (cb *CommandBus) ListModulesV1FromCLI(p string, ns string) (ListModulesV1Response, error) {
+func (cb *CommandBus) ListModulesV1FromCLI(ctx context.Context, p string, ns string) (ListModulesV1Response, error) {
 	dto := ListModulesV1DTO{
 		Provider:  p,
 		Namespace: ns,
 		ChunkOpts: ChunkingOptions{},
 	}
 
-	return cb.ListModulesV1FromDTO(dto)
+	return cb.ListModulesV1FromDTO(ctx, dto)
 }
 
-func (cb *CommandBus) ListModulesV1FromDTO(dto ListModulesV1DTO) (ListModulesV1Response, error) {
+// ListModulesV1FromDTO only lists the modules in the namespaces the actor may
+// read, when the bus is acting for one.
+func (cb *CommandBus) ListModulesV1FromDTO(ctx context.Context, dto ListModulesV1DTO) (ListModulesV1Response, error) {
+	if dto.Namespace != "" {
+		if ok, err := cb.permits(TokenScopes.ModulesRead, dto.Namespace); !ok {
+			return ListModulesV1Response{
+				occurredAt: time.Now().UTC(),
+				Status:     deniedStatus(err),
+			}, err
+		}
+	}
+
+	permitted, err := cb.permittedNamespaces(TokenScopes.ModulesRead)
+
+	if err != nil {
+		return ListModulesV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	// Filtered by the query, so that a page isn't cut short
+	if permitted != nil {
+		dto.Namespaces = []string{}
+
+		for ns := range permitted {
+			dto.Namespaces = append(dto.Namespaces, ns)
+		}
+
+		sort.Strings(dto.Namespaces)
+	}
+
 	cmd := listModulesV1Command{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger)
+	return cmd.handle(ctx, cb.repo, cb.logger)
+}
+
+func (cb *CommandBus) ListPublishedModulesV1(ctx context.Context, dto ListPublishedModulesV1DTO) (ListPublishedModulesV1Response, error) {
+	cmd := listPublishedModulesV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.repo, cb.logger)
 }
 
-func (cb *CommandBus) ShowModuleV1FromCLI(idOrFQN string) (ShowModuleV1Response, error) {
+func (cb *CommandBus) ShowPublishedModuleV1(ctx context.Context, dto ShowPublishedModuleV1DTO) (ShowPublishedModuleV1Response, error) {
+	cmd := showPublishedModuleV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.repo, cb.logger)
+}
+
+func (cb *CommandBus) ShowModuleV1FromCLI(ctx context.Context, idOrFQN string) (ShowModuleV1Response, error) {
 	if fqn, err := ParseModuleFQN(idOrFQN); err == nil {
 		dto := ShowModuleV1ByFqnDTO{
 			FQN: fqn,
 		}
-		return cb.ShowModuleV1ByFQN(dto)
+		return cb.ShowModuleV1ByFQN(ctx, dto)
 	}
 
 	if _, err := uuid.Parse(idOrFQN); err == nil {
 		dto := ShowModuleV1DTO{
 			Id: idOrFQN,
 		}
-		return cb.ShowModuleV1ByID(dto)
+		return cb.ShowModuleV1ByID(ctx, dto)
 	}
 
 	return ShowModuleV1Response{
@@ -170,23 +506,78 @@ func (cb *CommandBus) ShowModuleV1FromCLI(idOrFQN string) (ShowModuleV1Response,
 	}, nil
 }
 
-func (cb *CommandBus) ShowModuleV1ByFQN(dto ShowModuleV1ByFqnDTO) (ShowModuleV1Response, error) {
+func (cb *CommandBus) ShowModuleV1ByFQN(ctx context.Context, dto ShowModuleV1ByFqnDTO) (ShowModuleV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.ModulesRead, dto.FQN.Namespace); !ok {
+		return ShowModuleV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := showModuleV1ByFqnCommand{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger, cb.buildValidator(cb.logger))
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
 }
 
-func (cb *CommandBus) ShowModuleV1ByID(dto ShowModuleV1DTO) (ShowModuleV1Response, error) {
+func (cb *CommandBus) ShowModuleV1ByID(ctx context.Context, dto ShowModuleV1DTO) (ShowModuleV1Response, error) {
+	if ok, err := cb.permitsModule(ctx, dto.Id, TokenScopes.ModulesRead); !ok {
+		return ShowModuleV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := showModuleV1Command{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger, cb.buildValidator(cb.logger))
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) UpdateModuleV1FromCLI(ctx context.Context, idOrFQN string, dto UpdateModuleV1DTO) (UpdateModuleV1Response, error) {
+	dto.Id = idOrFQN
+	dto.AllowLocalRepository = true
+
+	if fqn, err := ParseModuleFQN(idOrFQN); err == nil {
+		res, err := cb.ShowModuleV1ByFQN(ctx, ShowModuleV1ByFqnDTO{
+			FQN: fqn,
+		})
+
+		if err != nil {
+			return UpdateModuleV1Response{}, err
+		}
+
+		if res.Status != STATUS_OKAY {
+			return UpdateModuleV1Response{
+				Status:           res.Status,
+				ValidationErrors: res.ValidationErrors,
+			}, nil
+		}
+
+		dto.Id = res.Module.Id
+	}
+
+	return cb.UpdateModuleV1(ctx, dto)
+}
+
+func (cb *CommandBus) UpdateModuleV1(ctx context.Context, dto UpdateModuleV1DTO) (UpdateModuleV1Response, error) {
+	if ok, err := cb.permitsModule(ctx, dto.Id, TokenScopes.ModulesWrite); !ok {
+		return UpdateModuleV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := updateModuleV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
 }
 
-func (cb *CommandBus) DeleteModuleV1FromCLI(idOrFQN string, deleteVersions bool, force bool) (DeleteModuleV1Response, error) {
+func (cb *CommandBus) DeleteModuleV1FromCLI(ctx context.Context, idOrFQN string, deleteVersions bool, force bool) (DeleteModuleV1Response, error) {
 	fqn, fqnParseErr := ParseModuleFQN(idOrFQN)
 	_, uuidParseErr := uuid.Parse(idOrFQN)
 
@@ -220,7 +611,7 @@ func (cb *CommandBus) DeleteModuleV1FromCLI(idOrFQN string, deleteVersions bool,
 			DeleteVersions: deleteVersions,
 		}
 
-		return cb.DeleteModuleV1ByFqn(dto)
+		return cb.DeleteModuleV1ByFqn(ctx, dto)
 	}
 
 	dto := DeleteModuleV1DTO{
@@ -228,26 +619,40 @@ func (cb *CommandBus) DeleteModuleV1FromCLI(idOrFQN string, deleteVersions bool,
 		DeleteVersions: deleteVersions,
 	}
 
-	return cb.DeleteModuleV1ById(dto)
+	return cb.DeleteModuleV1ById(ctx, dto)
 }
 
-func (cb *CommandBus) DeleteModuleV1ByFqn(dto DeleteModuleV1ByFqnDTO) (DeleteModuleV1Response, error) {
+func (cb *CommandBus) DeleteModuleV1ByFqn(ctx context.Context, dto DeleteModuleV1ByFqnDTO) (DeleteModuleV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.ModulesWrite, dto.FQN.Namespace); !ok {
+		return DeleteModuleV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := deleteModuleV1ByFqnCommand{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger, cb.buildValidator(cb.logger))
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
 }
 
-func (cb *CommandBus) DeleteModuleV1ById(dto DeleteModuleV1DTO) (DeleteModuleV1Response, error) {
+func (cb *CommandBus) DeleteModuleV1ById(ctx context.Context, dto DeleteModuleV1DTO) (DeleteModuleV1Response, error) {
+	if ok, err := cb.permitsModule(ctx, dto.Id, TokenScopes.ModulesWrite); !ok {
+		return DeleteModuleV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := deleteModuleV1Command{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger, cb.buildValidator(cb.logger))
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
 }
 
-func (cb *CommandBus) AddModuleVersionV1FromCLI(filePath string) (AddModuleVersionV1Response, error) {
+func (cb *CommandBus) AddModuleVersionV1FromCLI(ctx context.Context, filePath string) (AddModuleVersionV1Response, error) {
 
 	if filePath != "" {
 		b, err := afero.ReadFile(cb.fs, filePath)
@@ -269,7 +674,7 @@ func (cb *CommandBus) AddModuleVersionV1FromCLI(filePath string) (AddModuleVersi
 			}
 		}
 
-		return cb.AddModuleVersionV1ForModuleId(dto)
+		return cb.AddModuleVersionV1ForModuleId(ctx, dto)
 	}
 
 	var version, source, repoUrl, idOrFQN string
@@ -293,9 +698,9 @@ func (cb *CommandBus) AddModuleVersionV1FromCLI(filePath string) (AddModuleVersi
 		}
 	}
 
-	if repoUrl, err = cb.prompter.Ask("Repository URL: "); err != nil {
+	if repoUrl, err = cb.prompter.Ask("Repository URL (blank to use the module's): "); err != nil {
 		return AddModuleVersionV1Response{}, ErrQuestionFailed{
-			Question: "Repository URL: ",
+			Question: "Repository URL (blank to use the module's): ",
 		}
 	}
 
@@ -324,7 +729,7 @@ func (cb *CommandBus) AddModuleVersionV1FromCLI(filePath string) (AddModuleVersi
 			ModuleFQN:     fqn,
 		}
 
-		return cb.AddModuleVersionV1ForModuleFqn(dto)
+		return cb.AddModuleVersionV1ForModuleFqn(ctx, dto)
 	}
 
 	dto := AddModuleVersionV1DTO{
@@ -334,30 +739,44 @@ func (cb *CommandBus) AddModuleVersionV1FromCLI(filePath string) (AddModuleVersi
 		ModuleId:      idOrFQN,
 	}
 
-	return cb.AddModuleVersionV1ForModuleId(dto)
+	return cb.AddModuleVersionV1ForModuleId(ctx, dto)
 }
 
-func (cb *CommandBus) AddModuleVersionV1ForModuleFqn(dto AddModuleVersionV1ByModuleFqnDTO) (AddModuleVersionV1Response, error) {
+func (cb *CommandBus) AddModuleVersionV1ForModuleFqn(ctx context.Context, dto AddModuleVersionV1ByModuleFqnDTO) (AddModuleVersionV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.VersionsPublish, dto.ModuleFQN.Namespace); !ok {
+		return AddModuleVersionV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := addModuleVersionV1ByModuleFqnCommand{
 		DTO: dto,
 	}
 
 	v := cb.buildValidator(cb.logger)
 
-	return cmd.handle(cb.repo, cb.logger, v)
+	return cmd.handle(ctx, cb.repo, cb.logger, v)
 }
 
-func (cb *CommandBus) AddModuleVersionV1ForModuleId(dto AddModuleVersionV1DTO) (AddModuleVersionV1Response, error) {
+func (cb *CommandBus) AddModuleVersionV1ForModuleId(ctx context.Context, dto AddModuleVersionV1DTO) (AddModuleVersionV1Response, error) {
+	if ok, err := cb.permitsModule(ctx, dto.ModuleId, TokenScopes.VersionsPublish); !ok {
+		return AddModuleVersionV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := addModuleVersionV1Command{
 		DTO: dto,
 	}
 
 	v := cb.buildValidator(cb.logger)
 
-	return cmd.handle(cb.repo, cb.logger, v)
+	return cmd.handle(ctx, cb.repo, cb.logger, v)
 }
 
-func (cb *CommandBus) ListModuleVersionsV1FromCLI(idOrFQN string) (ListModuleVersionsV1Response, error) {
+func (cb *CommandBus) ListModuleVersionsV1FromCLI(ctx context.Context, idOrFQN string) (ListModuleVersionsV1Response, error) {
 	fqn, fqnParseErr := ParseModuleFQN(idOrFQN)
 	_, uuidParseErr := uuid.Parse(idOrFQN)
 
@@ -373,33 +792,47 @@ func (cb *CommandBus) ListModuleVersionsV1FromCLI(idOrFQN string) (ListModuleVer
 			FQN: fqn,
 		}
 
-		return cb.ListModuleVersionsV1ByFqn(dto)
+		return cb.ListModuleVersionsV1ByFqn(ctx, dto)
 	}
 
 	dto := ListModuleVersionsV1DTO{
 		ModuleId: idOrFQN,
 	}
 
-	return cb.ListModuleVersionsV1ById(dto)
+	return cb.ListModuleVersionsV1ById(ctx, dto)
 }
 
-func (cb *CommandBus) ListModuleVersionsV1ByFqn(dto ListModuleVersionsByFqnV1DTO) (ListModuleVersionsV1Response, error) {
+func (cb *CommandBus) ListModuleVersionsV1ByFqn(ctx context.Context, dto ListModuleVersionsByFqnV1DTO) (ListModuleVersionsV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.ModulesRead, dto.FQN.Namespace); !ok {
+		return ListModuleVersionsV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := listModuleVersionsByFqnV1Command{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger)
+	return cmd.handle(ctx, cb.repo, cb.logger)
 }
 
-func (cb *CommandBus) ListModuleVersionsV1ById(dto ListModuleVersionsV1DTO) (ListModuleVersionsV1Response, error) {
+func (cb *CommandBus) ListModuleVersionsV1ById(ctx context.Context, dto ListModuleVersionsV1DTO) (ListModuleVersionsV1Response, error) {
+	if ok, err := cb.permitsModule(ctx, dto.ModuleId, TokenScopes.ModulesRead); !ok {
+		return ListModuleVersionsV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := listModuleVersionsV1Command{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger)
+	return cmd.handle(ctx, cb.repo, cb.logger)
 }
 
-func (cb *CommandBus) ShowModuleVersionV1FromCLI(idOrFQN string) (ShowModuleVersionV1Response, error) {
+func (cb *CommandBus) ShowModuleVersionV1FromCLI(ctx context.Context, idOrFQN string) (ShowModuleVersionV1Response, error) {
 	fqn, fqnParseErr := ParseModuleVersionFQN(idOrFQN)
 	_, uuidParseErr := uuid.Parse(idOrFQN)
 
@@ -422,33 +855,100 @@ func (cb *CommandBus) ShowModuleVersionV1FromCLI(idOrFQN string) (ShowModuleVers
 			FQN: fqn,
 		}
 
-		return cb.ShowModuleVersionV1ByFqn(dto)
+		return cb.ShowModuleVersionV1ByFqn(ctx, dto)
 	}
 
 	dto := ShowModuleVersionV1DTO{
 		Id: idOrFQN,
 	}
 
-	return cb.ShowModuleVersionV1ById(dto)
+	return cb.ShowModuleVersionV1ById(ctx, dto)
 }
 
-func (cb *CommandBus) ShowModuleVersionV1ByFqn(dto ShowModuleVersionByFqnV1DTO) (ShowModuleVersionV1Response, error) {
+func (cb *CommandBus) ShowModuleVersionV1ByFqn(ctx context.Context, dto ShowModuleVersionByFqnV1DTO) (ShowModuleVersionV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.ModulesRead, dto.FQN.Namespace); !ok {
+		return ShowModuleVersionV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := showModuleVersionByFqnV1Command{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger, cb.buildValidator(cb.logger))
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
 }
 
-func (cb *CommandBus) ShowModuleVersionV1ById(dto ShowModuleVersionV1DTO) (ShowModuleVersionV1Response, error) {
+func (cb *CommandBus) ShowModuleVersionV1ById(ctx context.Context, dto ShowModuleVersionV1DTO) (ShowModuleVersionV1Response, error) {
+	if ok, err := cb.permitsVersion(ctx, dto.Id, TokenScopes.ModulesRead); !ok {
+		return ShowModuleVersionV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := showModuleVersionV1Command{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger, cb.buildValidator(cb.logger))
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) ShowModuleVersionInterfaceV1(ctx context.Context, dto ShowModuleVersionInterfaceV1DTO) (ShowModuleVersionInterfaceV1Response, error) {
+	if ok, err := cb.permitsVersion(ctx, dto.Id, TokenScopes.ModulesRead); !ok {
+		return ShowModuleVersionInterfaceV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := showModuleVersionInterfaceV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) ResolveModuleVersionV1(ctx context.Context, dto ResolveModuleVersionV1DTO) (ResolveModuleVersionV1Response, error) {
+	if ok, err := cb.permitsModule(ctx, dto.ModuleId, TokenScopes.ModulesRead); !ok {
+		return ResolveModuleVersionV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := resolveModuleVersionV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) DiffModuleVersionsV1(ctx context.Context, dto DiffModuleVersionsV1DTO) (DiffModuleVersionsV1Response, error) {
+	if ok, err := cb.permitsVersion(ctx, dto.Id, TokenScopes.ModulesRead); !ok {
+		return DiffModuleVersionsV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := diffModuleVersionsV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
 }
 
-func (cb *CommandBus) DeleteModuleVersionV1FromCLI(idOrFQN string, force bool) (DeleteModuleVersionV1Response, error) {
+func (cb *CommandBus) ScanModuleUsageV1(ctx context.Context, dto ScanModuleUsageV1DTO) (ScanModuleUsageV1Response, error) {
+	cmd := scanModuleUsageV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.fs, cb.scanner, cb.repo, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) DeleteModuleVersionV1FromCLI(ctx context.Context, idOrFQN string, force bool) (DeleteModuleVersionV1Response, error) {
 	fqn, fqnParseErr := ParseModuleVersionFQN(idOrFQN)
 	_, uuidParseErr := uuid.Parse(idOrFQN)
 
@@ -481,28 +981,597 @@ func (cb *CommandBus) DeleteModuleVersionV1FromCLI(idOrFQN string, force bool) (
 			FQN: fqn,
 		}
 
-		return cb.DeleteModuleVersionV1ByFqn(dto)
+		return cb.DeleteModuleVersionV1ByFqn(ctx, dto)
 	}
 
 	dto := DeleteModuleVersionV1DTO{
 		Id: idOrFQN,
 	}
 
-	return cb.DeleteModuleVersionV1ById(dto)
+	return cb.DeleteModuleVersionV1ById(ctx, dto)
 }
 
-func (cb *CommandBus) DeleteModuleVersionV1ByFqn(dto DeleteModuleVersionByFqnV1DTO) (DeleteModuleVersionV1Response, error) {
+func (cb *CommandBus) DeleteModuleVersionV1ByFqn(ctx context.Context, dto DeleteModuleVersionByFqnV1DTO) (DeleteModuleVersionV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.ModulesWrite, dto.FQN.Namespace); !ok {
+		return DeleteModuleVersionV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := deleteModuleVersionByFqnV1Command{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger, cb.buildValidator(cb.logger))
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
 }
 
-func (cb *CommandBus) DeleteModuleVersionV1ById(dto DeleteModuleVersionV1DTO) (DeleteModuleVersionV1Response, error) {
+func (cb *CommandBus) DeleteModuleVersionV1ById(ctx context.Context, dto DeleteModuleVersionV1DTO) (DeleteModuleVersionV1Response, error) {
+	if ok, err := cb.permitsVersion(ctx, dto.Id, TokenScopes.ModulesWrite); !ok {
+		return DeleteModuleVersionV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
 	cmd := deleteModuleVersionV1Command{
 		DTO: dto,
 	}
 
-	return cmd.handle(cb.repo, cb.logger, cb.buildValidator(cb.logger))
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator(cb.logger))
+}
+
+// DiscoverModulesV1 adds the namespaces of the modules it adds, as adding
+// them one at a time would.
+func (cb *CommandBus) DiscoverModulesV1(ctx context.Context, dto DiscoverModulesV1DTO) (DiscoverModulesV1Response, error) {
+	cmd := discoverModulesV1Command{
+		DTO: dto,
+	}
+
+	res, err := cmd.handle(ctx, cb.fs, cb.checkout, cb.repo, cb.logger, cb.buildValidator)
+
+	if err != nil {
+		return res, err
+	}
+
+	for _, d := range res.Report {
+		if d.Status == DiscoveryStatuses.New && d.Module.Id != "" {
+			cb.ensureNamespace(d.Module.Namespace, res.occurredAt)
+		}
+	}
+
+	return res, err
+}
+
+func (cb *CommandBus) PublishModuleVersionV1FromCLI(ctx context.Context, idOrFQN string, version string, ref string) (PublishModuleVersionV1Response, error) {
+	dto := PublishModuleVersionV1DTO{
+		ModuleId: idOrFQN,
+		Version:  version,
+		Ref:      ref,
+	}
+
+	if fqn, err := ParseModuleFQN(idOrFQN); err == nil {
+		res, err := cb.ShowModuleV1ByFQN(ctx, ShowModuleV1ByFqnDTO{
+			FQN: fqn,
+		})
+
+		if err != nil {
+			return PublishModuleVersionV1Response{}, err
+		}
+
+		if res.Status != STATUS_OKAY {
+			return PublishModuleVersionV1Response{
+				Status:           res.Status,
+				ValidationErrors: res.ValidationErrors,
+			}, nil
+		}
+
+		dto.ModuleId = res.Module.Id
+	}
+
+	return cb.PublishModuleVersionV1(ctx, dto)
+}
+
+func (cb *CommandBus) PublishModuleVersionV1(ctx context.Context, dto PublishModuleVersionV1DTO) (PublishModuleVersionV1Response, error) {
+	if ok, err := cb.permitsModule(ctx, dto.ModuleId, TokenScopes.VersionsPublish); !ok {
+		return PublishModuleVersionV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := publishModuleVersionV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.repo, cb.resolver, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) SyncTagsV1(ctx context.Context, dto SyncTagsV1DTO) (SyncTagsV1Response, error) {
+	cmd := syncTagsV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.tags, cb.repo, cb.logger, cb.buildValidator)
+}
+
+func (cb *CommandBus) HandlePushV1(ctx context.Context, dto HandlePushV1DTO) (HandlePushV1Response, error) {
+	cmd := handlePushV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.repo, cb.logger, cb.buildValidator)
+}
+
+func (cb *CommandBus) AddWebhookSubscriptionV1(dto AddWebhookSubscriptionV1DTO) (AddWebhookSubscriptionV1Response, error) {
+	cmd := addWebhookSubscriptionV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.webhooks, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) ListWebhookSubscriptionsV1() (ListWebhookSubscriptionsV1Response, error) {
+	cmd := listWebhookSubscriptionsV1Command{}
+
+	return cmd.handle(cb.webhooks, cb.logger)
+}
+
+func (cb *CommandBus) DeleteWebhookSubscriptionV1(dto DeleteWebhookSubscriptionV1DTO) (DeleteWebhookSubscriptionV1Response, error) {
+	cmd := deleteWebhookSubscriptionV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.webhooks, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) ListWebhookDeliveriesV1(dto ListWebhookDeliveriesV1DTO) (ListWebhookDeliveriesV1Response, error) {
+	cmd := listWebhookDeliveriesV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.webhooks, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) AddGPGKeyV1FromCLI(namespace string, filePath string) (AddGPGKeyV1Response, error) {
+	b, err := afero.ReadFile(cb.fs, filePath)
+
+	if err != nil {
+		return AddGPGKeyV1Response{}, ErrCouldNotReadFile{
+			Path: filePath,
+		}
+	}
+
+	return cb.AddGPGKeyV1(AddGPGKeyV1DTO{
+		Namespace:  namespace,
+		ASCIIArmor: string(b),
+	})
+}
+
+func (cb *CommandBus) AddGPGKeyV1(dto AddGPGKeyV1DTO) (AddGPGKeyV1Response, error) {
+	cmd := addGPGKeyV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.providers, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) ListGPGKeysV1(dto ListGPGKeysV1DTO) (ListGPGKeysV1Response, error) {
+	cmd := listGPGKeysV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.providers, cb.logger)
+}
+
+func (cb *CommandBus) DeleteGPGKeyV1(dto DeleteGPGKeyV1DTO) (DeleteGPGKeyV1Response, error) {
+	cmd := deleteGPGKeyV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.providers, cb.logger, cb.buildValidator(cb.logger))
+}
+
+// UploadProviderVersionV1FromCLI reads a release from the paths of its files,
+// the archives are named after the files they're read from.
+func (cb *CommandBus) UploadProviderVersionV1FromCLI(fqn ProviderFQN, version string, protocols []string, shasumsPath string, signaturePath string, archivePaths []string) (UploadProviderVersionV1Response, error) {
+	shasums, err := afero.ReadFile(cb.fs, shasumsPath)
+
+	if err != nil {
+		return UploadProviderVersionV1Response{}, ErrCouldNotReadFile{
+			Path: shasumsPath,
+		}
+	}
+
+	signature, err := afero.ReadFile(cb.fs, signaturePath)
+
+	if err != nil {
+		return UploadProviderVersionV1Response{}, ErrCouldNotReadFile{
+			Path: signaturePath,
+		}
+	}
+
+	archives := []ProviderArchive{}
+
+	for _, p := range archivePaths {
+		f, err := cb.fs.Open(p)
+
+		if err != nil {
+			return UploadProviderVersionV1Response{}, ErrCouldNotReadFile{
+				Path: p,
+			}
+		}
+
+		defer f.Close()
+
+		archives = append(archives, ProviderArchive{
+			Filename: filepath.Base(p),
+			Content:  f,
+		})
+	}
+
+	return cb.UploadProviderVersionV1(UploadProviderVersionV1DTO{
+		Namespace:        fqn.Namespace,
+		Type:             fqn.Type,
+		Version:          version,
+		Protocols:        protocols,
+		Shasums:          shasums,
+		ShasumsSignature: signature,
+		Archives:         archives,
+	})
+}
+
+func (cb *CommandBus) UploadProviderVersionV1(dto UploadProviderVersionV1DTO) (UploadProviderVersionV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.VersionsPublish, dto.Namespace); !ok {
+		return UploadProviderVersionV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := uploadProviderVersionV1Command{
+		DTO: dto,
+	}
+
+	res, err := cmd.handle(cb.providers, cb.store, cb.logger, cb.buildValidator(cb.logger))
+
+	if err == nil && res.Status == STATUS_CREATED {
+		cb.ensureNamespace(res.Provider.Namespace, res.occurredAt)
+	}
+
+	return res, err
+}
+
+func (cb *CommandBus) ListProviderVersionsV1(dto ListProviderVersionsV1DTO) (ListProviderVersionsV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.ModulesRead, dto.FQN.Namespace); !ok {
+		return ListProviderVersionsV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := listProviderVersionsV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.providers, cb.logger)
+}
+
+func (cb *CommandBus) DownloadProviderV1(dto DownloadProviderV1DTO) (DownloadProviderV1Response, error) {
+	cmd := downloadProviderV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.providers, cb.logger)
+}
+
+func (cb *CommandBus) ImportProviderMirrorV1(dto ImportProviderMirrorV1DTO) (ImportProviderMirrorV1Response, error) {
+	cmd := importProviderMirrorV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.fs, cb.mirror, cb.store, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) ListMirroredPackagesV1(dto ListMirroredPackagesV1DTO) (ListMirroredPackagesV1Response, error) {
+	cmd := listMirroredPackagesV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.mirror, cb.logger)
+}
+
+// upstreamModules combines the local modules with the cache of upstream ones,
+// proxied modules are only served when they don't exist locally.
+type upstreamModules struct {
+	ModuleRepository
+	UpstreamRepository
+}
+
+func (cb *CommandBus) ListUpstreamModuleVersionsV1(ctx context.Context, dto ListUpstreamModuleVersionsV1DTO) (ListUpstreamModuleVersionsV1Response, error) {
+	cmd := listUpstreamModuleVersionsV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.upstreams, cb.upstream, upstreamModules{cb.repo, cb.upstreamRepo}, cb.logger)
+}
+
+func (cb *CommandBus) DownloadUpstreamModuleV1(ctx context.Context, dto DownloadUpstreamModuleV1DTO) (DownloadUpstreamModuleV1Response, error) {
+	cmd := downloadUpstreamModuleV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.upstreams, cb.upstream, upstreamModules{cb.repo, cb.upstreamRepo}, cb.store, cb.logger)
+}
+
+func (cb *CommandBus) CreateAPITokenV1(dto CreateAPITokenV1DTO) (CreateAPITokenV1Response, error) {
+	cmd := createAPITokenV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.tokens, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) ListAPITokensV1() (ListAPITokensV1Response, error) {
+	cmd := listAPITokensV1Command{}
+
+	return cmd.handle(cb.tokens, cb.logger)
+}
+
+func (cb *CommandBus) RevokeAPITokenV1(dto RevokeAPITokenV1DTO) (RevokeAPITokenV1Response, error) {
+	cmd := revokeAPITokenV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.tokens, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) AuthenticateAPITokenV1(dto AuthenticateAPITokenV1DTO) (AuthenticateAPITokenV1Response, error) {
+	cmd := authenticateAPITokenV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.tokens, cb.logger)
+}
+
+// AuthenticateOIDCTokenV1 is UNAUTHORIZED for every token unless OIDC issuers
+// are configured.
+func (cb *CommandBus) AuthenticateOIDCTokenV1(dto AuthenticateOIDCTokenV1DTO) (AuthenticateOIDCTokenV1Response, error) {
+	cmd := authenticateOIDCTokenV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.oidc, cb.oidcRules, cb.logger)
+}
+
+// AuthenticateCertificateV1 is UNAUTHORIZED for every certificate unless
+// certificate rules are configured.
+func (cb *CommandBus) AuthenticateCertificateV1(dto AuthenticateCertificateV1DTO) (AuthenticateCertificateV1Response, error) {
+	cmd := authenticateCertificateV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.certRules, cb.logger)
+}
+
+func (cb *CommandBus) CreateUserV1(dto CreateUserV1DTO) (CreateUserV1Response, error) {
+	cmd := createUserV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.users, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) ListUsersV1() (ListUsersV1Response, error) {
+	cmd := listUsersV1Command{}
+
+	return cmd.handle(cb.users, cb.logger)
+}
+
+func (cb *CommandBus) DeleteUserV1(dto DeleteUserV1DTO) (DeleteUserV1Response, error) {
+	cmd := deleteUserV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.users, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) subjects() subjectResolver {
+	return subjectResolver{
+		users:  cb.users,
+		teams:  cb.teams,
+		tokens: cb.tokens,
+	}
+}
+
+// CreateNamespaceV1 needs the admin scope for every namespace.
+func (cb *CommandBus) CreateNamespaceV1(dto CreateNamespaceV1DTO) (CreateNamespaceV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.Admin, ""); !ok {
+		return CreateNamespaceV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := createNamespaceV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.namespaces, cb.subjects(), cb.logger, cb.buildValidator(cb.logger))
+}
+
+// ListNamespacesV1 only lists the namespaces the actor may read, when the bus
+// is acting for one.
+func (cb *CommandBus) ListNamespacesV1() (ListNamespacesV1Response, error) {
+	permitted, err := cb.permittedNamespaces(TokenScopes.ModulesRead)
+
+	if err != nil {
+		return ListNamespacesV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	cmd := listNamespacesV1Command{}
+
+	return cmd.handle(cb.namespaces, permitted, cb.logger)
+}
+
+func (cb *CommandBus) ShowNamespaceV1(dto ShowNamespaceV1DTO) (ShowNamespaceV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.ModulesRead, dto.Name); !ok {
+		return ShowNamespaceV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := showNamespaceV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.namespaces, cb.logger)
+}
+
+func (cb *CommandBus) DeleteNamespaceV1(ctx context.Context, dto DeleteNamespaceV1DTO) (DeleteNamespaceV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.Admin, dto.Name); !ok {
+		return DeleteNamespaceV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := deleteNamespaceV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(ctx, cb.namespaces, cb.repo, cb.logger, cb.buildValidator(cb.logger))
+}
+
+// GrantRoleV1 needs the admin role, or scope, in the namespace.
+func (cb *CommandBus) GrantRoleV1(dto GrantRoleV1DTO) (GrantRoleV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.Admin, dto.Namespace); !ok {
+		return GrantRoleV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := grantRoleV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.namespaces, cb.subjects(), cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) RevokeRoleV1(dto RevokeRoleV1DTO) (RevokeRoleV1Response, error) {
+	if ok, err := cb.permits(TokenScopes.Admin, dto.Namespace); !ok {
+		return RevokeRoleV1Response{
+			occurredAt: time.Now().UTC(),
+			Status:     deniedStatus(err),
+		}, err
+	}
+
+	cmd := revokeRoleV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.namespaces, cb.subjects(), cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) CreateTeamV1(dto CreateTeamV1DTO) (CreateTeamV1Response, error) {
+	cmd := createTeamV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.teams, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) ListTeamsV1() (ListTeamsV1Response, error) {
+	cmd := listTeamsV1Command{}
+
+	return cmd.handle(cb.teams, cb.logger)
+}
+
+func (cb *CommandBus) DeleteTeamV1(dto DeleteTeamV1DTO) (DeleteTeamV1Response, error) {
+	cmd := deleteTeamV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.teams, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) AddTeamMemberV1(dto TeamMemberV1DTO) (TeamMemberV1Response, error) {
+	cmd := addTeamMemberV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.teams, cb.users, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) RemoveTeamMemberV1(dto TeamMemberV1DTO) (TeamMemberV1Response, error) {
+	cmd := removeTeamMemberV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.teams, cb.users, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) AuthorizeV1(dto AuthorizeV1DTO) (AuthorizeV1Response, error) {
+	cmd := authorizeV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.namespaces, cb.teams, cb.logger)
+}
+
+func (cb *CommandBus) ServiceDiscoveryV1() HandleServiceDiscoveryResponse {
+	cmd := ServiceDiscoveryCommand{
+		Login: cb.login,
+	}
+
+	return cmd.Handle()
+}
+
+// ValidateLoginRequestV1 and the other login commands are NOT_FOUND when
+// `terraform login` isn't enabled.
+func (cb *CommandBus) ValidateLoginRequestV1(dto LoginRequestV1DTO) ValidateLoginRequestV1Response {
+	if cb.login == nil {
+		return ValidateLoginRequestV1Response{
+			Status: STATUS_NOT_FOUND,
+		}
+	}
+
+	cmd := validateLoginRequestV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(*cb.login, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) AuthorizeLoginV1(dto AuthorizeLoginV1DTO) (AuthorizeLoginV1Response, error) {
+	if cb.login == nil {
+		return AuthorizeLoginV1Response{
+			Status: STATUS_NOT_FOUND,
+		}, nil
+	}
+
+	cmd := authorizeLoginV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.users, *cb.login, cb.logger, cb.buildValidator(cb.logger))
+}
+
+func (cb *CommandBus) ExchangeLoginCodeV1(dto ExchangeLoginCodeV1DTO) (ExchangeLoginCodeV1Response, error) {
+	if cb.login == nil {
+		return ExchangeLoginCodeV1Response{
+			Status: STATUS_NOT_FOUND,
+		}, nil
+	}
+
+	cmd := exchangeLoginCodeV1Command{
+		DTO: dto,
+	}
+
+	return cmd.handle(cb.users, cb.tokens, *cb.login, cb.logger)
 }
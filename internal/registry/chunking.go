@@ -1,5 +1,8 @@
 package registry
 
+// ChunkingOptions limits a listing to a page of results, a Size of 0 is
+// unlimited.
 type ChunkingOptions struct {
-	Size int
+	Size   int
+	Offset int
 }
@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type createAPITokenRepository interface {
+	AddToken(APIToken) (t APIToken, err error)
+}
+
+type createAPITokenV1CommandValidator interface {
+	Validate(cmd interface{}) []ValidationError
+}
+
+// CreateAPITokenV1DTO creates a token for the management API, it never
+// expires unless given an expiry.
+type CreateAPITokenV1DTO struct {
+	Name      string     `json:"name" validate:"required"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,token_scope"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty,future"`
+}
+
+type createAPITokenV1Command struct {
+	DTO CreateAPITokenV1DTO
+}
+
+type CreateAPITokenV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	APIToken   APIToken
+	// The token itself, it can't be shown again
+	Secret           string
+	ValidationErrors []ValidationError
+}
+
+func (r CreateAPITokenV1Response) GetActionName() string {
+	return "v1.tokens.create"
+}
+
+func (r CreateAPITokenV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r CreateAPITokenV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r CreateAPITokenV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"token_id":          r.APIToken.Id,
+		"scopes":            r.APIToken.Scopes,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (cmd createAPITokenV1Command) handle(r createAPITokenRepository, logger zerolog.Logger, v createAPITokenV1CommandValidator) (CreateAPITokenV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return CreateAPITokenV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	scopes := []TokenScope{}
+
+	for _, s := range cmd.DTO.Scopes {
+		scopes = append(scopes, TokenScope(s))
+	}
+
+	t, secret, err := newAPIToken(cmd.DTO.Name, scopes, cmd.DTO.ExpiresAt, occurred)
+
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to generate api token")
+
+		return CreateAPITokenV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	t, err = r.AddToken(t)
+
+	if err != nil {
+		logger.Error().Err(err).Str("name", cmd.DTO.Name).Msg("failed to add api token")
+
+		return CreateAPITokenV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return CreateAPITokenV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_CREATED,
+		APIToken:   t,
+		Secret:     secret,
+	}, nil
+}
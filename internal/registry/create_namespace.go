@@ -0,0 +1,158 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type createNamespaceRepository interface {
+	NamespaceByName(name string) (n Namespace, err error)
+	AddNamespace(n Namespace, owners []RoleGrant) (Namespace, error)
+}
+
+type createNamespaceV1CommandValidator interface {
+	RegisterStructLevelValidator(f validator.StructLevelFunc, t interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+// CreateNamespaceV1DTO creates a namespace, its owners are given the admin
+// role in it.
+type CreateNamespaceV1DTO struct {
+	Name string `json:"name" validate:"required,namespace"`
+	// As `user:<username>`, `team:<name>` or `token:<id>`
+	Owners []string `json:"owners" validate:"required,min=1,dive,subject"`
+}
+
+type createNamespaceV1Command struct {
+	DTO CreateNamespaceV1DTO
+}
+
+type CreateNamespaceV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Namespace        Namespace
+	ValidationErrors []ValidationError
+}
+
+func (r CreateNamespaceV1Response) GetActionName() string {
+	return "v1.namespaces.create"
+}
+
+func (r CreateNamespaceV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r CreateNamespaceV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r CreateNamespaceV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"namespace_id":      r.Namespace.Id,
+		"namespace":         r.Namespace.Name,
+		"owners":            r.Namespace.Owners,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (dto CreateNamespaceV1DTO) validate(r createNamespaceRepository, s subjectResolver, v createNamespaceV1CommandValidator, logger zerolog.Logger) []ValidationError {
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		_, err := r.NamespaceByName(dto.Name)
+
+		if err == nil {
+			sl.ReportError(dto.Name, "name", "Name", uniqueNamespaceTag, dto.Name)
+		} else if _, ok := err.(ErrResourceNotFound); !ok {
+			logger.Error().Err(err).Msg("unexpected repository error during validation")
+		}
+
+		for i, o := range dto.Owners {
+			if _, _, ok := ParseSubject(o); !ok {
+				continue
+			}
+
+			_, _, err := s.resolve(o)
+
+			if err == nil {
+				continue
+			}
+
+			if _, ok := err.(ErrResourceNotFound); ok {
+				sl.ReportError(o, fmt.Sprintf("owners[%d]", i), fmt.Sprintf("Owners[%d]", i), existingSubjectTag, o)
+				continue
+			}
+
+			logger.Error().Err(err).Msg("unexpected repository error during validation")
+		}
+	}, CreateNamespaceV1DTO{})
+
+	return v.Validate(dto)
+}
+
+func (cmd createNamespaceV1Command) handle(r createNamespaceRepository, s subjectResolver, logger zerolog.Logger, v createNamespaceV1CommandValidator) (CreateNamespaceV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := cmd.DTO.validate(r, s, v, logger); len(errs) > 0 {
+		return CreateNamespaceV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	owners := []RoleGrant{}
+	seen := map[Subject]bool{}
+
+	for _, o := range cmd.DTO.Owners {
+		subject, name, err := s.resolve(o)
+
+		if err != nil {
+			logger.Error().Err(err).Str("subject", o).Msg("failed to find owner")
+
+			return CreateNamespaceV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		if seen[subject] {
+			continue
+		}
+
+		seen[subject] = true
+		owners = append(owners, RoleGrant{
+			Id:          uuid.New().String(),
+			Namespace:   cmd.DTO.Name,
+			Subject:     subject,
+			SubjectName: name,
+			Role:        Roles.Admin,
+			CreatedAt:   occurred,
+		})
+	}
+
+	n, err := r.AddNamespace(Namespace{
+		Id:        uuid.New().String(),
+		Name:      cmd.DTO.Name,
+		CreatedAt: occurred,
+	}, owners)
+
+	if err != nil {
+		logger.Error().Err(err).Str("namespace", cmd.DTO.Name).Msg("failed to add namespace")
+
+		return CreateNamespaceV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	n.Owners = owners
+
+	return CreateNamespaceV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_CREATED,
+		Namespace:  n,
+	}, nil
+}
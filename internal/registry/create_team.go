@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type createTeamRepository interface {
+	TeamByName(name string) (t Team, err error)
+	AddTeam(Team) (t Team, err error)
+}
+
+type createTeamV1CommandValidator interface {
+	RegisterStructLevelValidator(f validator.StructLevelFunc, t interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+type CreateTeamV1DTO struct {
+	// Team names follow the same rules as usernames
+	Name string `json:"name" validate:"required,username"`
+}
+
+type createTeamV1Command struct {
+	DTO CreateTeamV1DTO
+}
+
+type CreateTeamV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Team             Team
+	ValidationErrors []ValidationError
+}
+
+func (r CreateTeamV1Response) GetActionName() string {
+	return "v1.teams.create"
+}
+
+func (r CreateTeamV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r CreateTeamV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r CreateTeamV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"team_id":           r.Team.Id,
+		"team":              r.Team.Name,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (dto CreateTeamV1DTO) validate(r createTeamRepository, v createTeamV1CommandValidator, logger zerolog.Logger) []ValidationError {
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		_, err := r.TeamByName(dto.Name)
+
+		if err == nil {
+			sl.ReportError(dto.Name, "name", "Name", uniqueTeamTag, dto.Name)
+			return
+		}
+
+		if _, ok := err.(ErrResourceNotFound); !ok {
+			logger.Error().Err(err).Msg("unexpected repository error during validation")
+		}
+	}, CreateTeamV1DTO{})
+
+	return v.Validate(dto)
+}
+
+func (cmd createTeamV1Command) handle(r createTeamRepository, logger zerolog.Logger, v createTeamV1CommandValidator) (CreateTeamV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := cmd.DTO.validate(r, v, logger); len(errs) > 0 {
+		return CreateTeamV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	t, err := r.AddTeam(Team{
+		Id:        uuid.New().String(),
+		Name:      cmd.DTO.Name,
+		CreatedAt: occurred,
+		Members:   []User{},
+	})
+
+	if err != nil {
+		logger.Error().Err(err).Str("team", cmd.DTO.Name).Msg("failed to add team")
+
+		return CreateTeamV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return CreateTeamV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_CREATED,
+		Team:       t,
+	}, nil
+}
@@ -0,0 +1,128 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type createUserRepository interface {
+	UserByUsername(username string) (u User, err error)
+	AddUser(User) (u User, err error)
+}
+
+type createUserV1CommandValidator interface {
+	RegisterStructLevelValidator(f validator.StructLevelFunc, t interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+// CreateUserV1DTO creates a user that can log in with `terraform login`, the
+// tokens they are issued have the scopes given here.
+type CreateUserV1DTO struct {
+	Username string   `json:"username" validate:"required,username"`
+	Password string   `json:"-" validate:"required,password"`
+	Scopes   []string `json:"scopes" validate:"required,min=1,dive,token_scope"`
+}
+
+type createUserV1Command struct {
+	DTO CreateUserV1DTO
+}
+
+type CreateUserV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	User             User
+	ValidationErrors []ValidationError
+}
+
+func (r CreateUserV1Response) GetActionName() string {
+	return "v1.users.create"
+}
+
+func (r CreateUserV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r CreateUserV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r CreateUserV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":           r.User.Id,
+		"username":          r.User.Username,
+		"scopes":            r.User.Scopes,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (dto CreateUserV1DTO) validate(r createUserRepository, v createUserV1CommandValidator, logger zerolog.Logger) []ValidationError {
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		_, err := r.UserByUsername(dto.Username)
+
+		if err == nil {
+			sl.ReportError(dto.Username, "username", "Username", uniqueUsernameTag, dto.Username)
+			return
+		}
+
+		if _, ok := err.(ErrResourceNotFound); !ok {
+			logger.Error().Err(err).Msg("unexpected repository error during validation")
+		}
+	}, CreateUserV1DTO{})
+
+	return v.Validate(dto)
+}
+
+func (cmd createUserV1Command) handle(r createUserRepository, logger zerolog.Logger, v createUserV1CommandValidator) (CreateUserV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := cmd.DTO.validate(r, v, logger); len(errs) > 0 {
+		return CreateUserV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	hash, err := HashPassword(cmd.DTO.Password)
+
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to hash password")
+
+		return CreateUserV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	scopes := []TokenScope{}
+
+	for _, s := range cmd.DTO.Scopes {
+		scopes = append(scopes, TokenScope(s))
+	}
+
+	u, err := r.AddUser(User{
+		Id:           uuid.New().String(),
+		Username:     cmd.DTO.Username,
+		PasswordHash: hash,
+		Scopes:       scopes,
+		CreatedAt:    occurred,
+	})
+
+	if err != nil {
+		logger.Error().Err(err).Str("username", cmd.DTO.Username).Msg("failed to add user")
+
+		return CreateUserV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return CreateUserV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_CREATED,
+		User:       u,
+	}, nil
+}
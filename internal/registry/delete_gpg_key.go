@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type deleteGPGKeyRepository interface {
+	GPGKeyById(id string) (k GPGKey, err error)
+	DeleteGPGKey(GPGKey) error
+	CountVersionsSignedBy(gpgKeyId string) (int, error)
+}
+
+type deleteGPGKeyV1CommandValidator interface {
+	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+// DeleteGPGKeyV1DTO removes a key, which is refused while any provider version
+// signed with it remains, as terraform could no longer verify them.
+type DeleteGPGKeyV1DTO struct {
+	Id string `validate:"required,uuid"`
+}
+
+type deleteGPGKeyV1Command struct {
+	DTO DeleteGPGKeyV1DTO
+}
+
+type DeleteGPGKeyV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Key              GPGKey
+	ValidationErrors []ValidationError
+}
+
+func (r DeleteGPGKeyV1Response) GetActionName() string {
+	return "v1.providers.keys.delete"
+}
+
+func (r DeleteGPGKeyV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r DeleteGPGKeyV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r DeleteGPGKeyV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"gpg_key_id":        r.Key.Id,
+		"key_id":            r.Key.KeyId,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (dto DeleteGPGKeyV1DTO) validate(r deleteGPGKeyRepository, v deleteGPGKeyV1CommandValidator, logger zerolog.Logger) []ValidationError {
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		if _, err := uuid.Parse(dto.Id); err != nil {
+			// Reported by the field rule
+			return
+		}
+
+		count, err := r.CountVersionsSignedBy(dto.Id)
+
+		if err != nil {
+			logger.Error().Err(err).Msg("unexpected repository error during validation")
+		}
+
+		if err != nil || count > 0 {
+			sl.ReportError(dto.Id, "id", "Id", gpgKeyNotInUseTag, dto.Id)
+		}
+	}, DeleteGPGKeyV1DTO{})
+
+	return v.Validate(dto)
+}
+
+func (cmd deleteGPGKeyV1Command) handle(r deleteGPGKeyRepository, logger zerolog.Logger, v deleteGPGKeyV1CommandValidator) (DeleteGPGKeyV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := cmd.DTO.validate(r, v, logger); len(errs) > 0 {
+		return DeleteGPGKeyV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	k, err := r.GPGKeyById(cmd.DTO.Id)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return DeleteGPGKeyV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to find gpg key")
+
+		return DeleteGPGKeyV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if err := r.DeleteGPGKey(k); err != nil {
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to delete gpg key")
+
+		return DeleteGPGKeyV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return DeleteGPGKeyV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Key:        k,
+	}, nil
+}
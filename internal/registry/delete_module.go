@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -8,17 +9,17 @@ import (
 )
 
 type deleteModuleRepository interface {
-	ByFQN(ModuleFQN) (m Module, err error)
-	ById(string) (m Module, err error)
-	VersionsByModuleFQN(fqn ModuleFQN, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
-	VersionsByModule(moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
-	DeleteModule(mod Module) error
-	DeleteVersionsForModule(mod Module) error
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+	ById(context.Context, string) (m Module, err error)
+	VersionsByModuleFQN(ctx context.Context, fqn ModuleFQN, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	DeleteModule(ctx context.Context, mod Module) error
+	DeleteVersionsForModule(ctx context.Context, mod Module) error
 }
 
 type deleteModuleV1CommandValidator interface {
-	NoVersionsExistForModuleId(r noVersionsExistForIdRepository, sl validator.StructLevel, id string)
-	NoVersionsExistForModuleFQN(r noVersionsExistForModuleFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
+	NoVersionsExistForModuleId(ctx context.Context, r noVersionsExistForIdRepository, sl validator.StructLevel, id string)
+	NoVersionsExistForModuleFQN(ctx context.Context, r noVersionsExistForModuleFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
 	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
 	Validate(cmd interface{}) []ValidationError
 	SetCustomMessageHandlers(handlers map[string]CustomValidationMessageHandler)
@@ -33,6 +34,7 @@ type DeleteModuleV1Response struct {
 	attemptedFor     string
 	Status           RegistryHandlerStatus
 	Module           Module
+	DeletedVersions  []ModuleVersion
 	ValidationErrors []ValidationError
 }
 
@@ -60,22 +62,22 @@ type DeleteModuleV1DTO struct {
 	DeleteVersions bool   `json:"delete_versions"`
 }
 
-func (dto DeleteModuleV1DTO) validate(r deleteModuleRepository, v deleteModuleV1CommandValidator) []ValidationError {
+func (dto DeleteModuleV1DTO) validate(ctx context.Context, r deleteModuleRepository, v deleteModuleV1CommandValidator) []ValidationError {
 	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
 		if dto.DeleteVersions {
 			return
 		}
 
-		v.NoVersionsExistForModuleId(r, sl, dto.Id)
+		v.NoVersionsExistForModuleId(ctx, r, sl, dto.Id)
 	}, AddModuleV1DTO{})
 
 	return v.Validate(dto)
 }
 
-func (cmd deleteModuleV1Command) handle(r deleteModuleRepository, logger zerolog.Logger, v deleteModuleV1CommandValidator) (DeleteModuleV1Response, error) {
+func (cmd deleteModuleV1Command) handle(ctx context.Context, r deleteModuleRepository, logger zerolog.Logger, v deleteModuleV1CommandValidator) (DeleteModuleV1Response, error) {
 	occurred := time.Now().UTC()
 
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return DeleteModuleV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -83,7 +85,7 @@ func (cmd deleteModuleV1Command) handle(r deleteModuleRepository, logger zerolog
 		}, nil
 	}
 
-	m, err := r.ById(cmd.DTO.Id)
+	m, err := r.ById(ctx, cmd.DTO.Id)
 
 	if err != nil {
 		if _, ok := err.(ErrResourceNotFound); ok {
@@ -101,8 +103,18 @@ func (cmd deleteModuleV1Command) handle(r deleteModuleRepository, logger zerolog
 		}, err
 	}
 
+	deleted := []ModuleVersion{}
+
 	if cmd.DTO.DeleteVersions {
-		if err := r.DeleteVersionsForModule(m); err != nil {
+		// Fetched first, so there is a record of what went with the module
+		if deleted, err = r.VersionsByModule(ctx, m.Id, ChunkingOptions{}); err != nil {
+			return DeleteModuleV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		if err := r.DeleteVersionsForModule(ctx, m); err != nil {
 			return DeleteModuleV1Response{
 				occurredAt: occurred,
 				Status:     STATUS_INTERNAL_ERROR,
@@ -110,7 +122,7 @@ func (cmd deleteModuleV1Command) handle(r deleteModuleRepository, logger zerolog
 		}
 	}
 
-	if err := r.DeleteModule(m); err != nil {
+	if err := r.DeleteModule(ctx, m); err != nil {
 		return DeleteModuleV1Response{
 			occurredAt: occurred,
 			Status:     STATUS_INTERNAL_ERROR,
@@ -118,8 +130,9 @@ func (cmd deleteModuleV1Command) handle(r deleteModuleRepository, logger zerolog
 	}
 
 	return DeleteModuleV1Response{
-		occurredAt: occurred,
-		Status:     STATUS_OKAY,
-		Module:     m,
+		occurredAt:      occurred,
+		Status:          STATUS_OKAY,
+		Module:          m,
+		DeletedVersions: deleted,
 	}, err
 }
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -8,14 +9,14 @@ import (
 )
 
 type deleteModuleByFqnRepository interface {
-	ByFQN(ModuleFQN) (m Module, err error)
-	VersionsByModuleFQN(fqn ModuleFQN, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
-	DeleteModule(mod Module) error
-	DeleteVersionsForModule(mod Module) error
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+	VersionsByModuleFQN(ctx context.Context, fqn ModuleFQN, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	DeleteModule(ctx context.Context, mod Module) error
+	DeleteVersionsForModule(ctx context.Context, mod Module) error
 }
 
 type deleteModuleByFqnV1CommandValidator interface {
-	NoVersionsExistForModuleFQN(r noVersionsExistForModuleFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
+	NoVersionsExistForModuleFQN(ctx context.Context, r noVersionsExistForModuleFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
 	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
 	Validate(cmd interface{}) []ValidationError
 	SetCustomMessageHandlers(handlers map[string]CustomValidationMessageHandler)
@@ -30,22 +31,22 @@ type DeleteModuleV1ByFqnDTO struct {
 	DeleteVersions bool      `json:"delete_versions"`
 }
 
-func (dto DeleteModuleV1ByFqnDTO) validate(r deleteModuleByFqnRepository, v deleteModuleByFqnV1CommandValidator) []ValidationError {
+func (dto DeleteModuleV1ByFqnDTO) validate(ctx context.Context, r deleteModuleByFqnRepository, v deleteModuleByFqnV1CommandValidator) []ValidationError {
 	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
 		if dto.DeleteVersions {
 			return
 		}
 
-		v.NoVersionsExistForModuleFQN(r, sl, dto.FQN)
+		v.NoVersionsExistForModuleFQN(ctx, r, sl, dto.FQN)
 	}, DeleteModuleV1ByFqnDTO{})
 
 	return v.Validate(dto)
 }
 
-func (cmd deleteModuleV1ByFqnCommand) handle(r deleteModuleByFqnRepository, logger zerolog.Logger, v deleteModuleByFqnV1CommandValidator) (DeleteModuleV1Response, error) {
+func (cmd deleteModuleV1ByFqnCommand) handle(ctx context.Context, r deleteModuleByFqnRepository, logger zerolog.Logger, v deleteModuleByFqnV1CommandValidator) (DeleteModuleV1Response, error) {
 	occurred := time.Now().UTC()
 
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return DeleteModuleV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -53,7 +54,7 @@ func (cmd deleteModuleV1ByFqnCommand) handle(r deleteModuleByFqnRepository, logg
 		}, nil
 	}
 
-	m, err := r.ByFQN(cmd.DTO.FQN)
+	m, err := r.ByFQN(ctx, cmd.DTO.FQN)
 
 	if err != nil {
 		if _, ok := err.(ErrResourceNotFound); ok {
@@ -71,8 +72,18 @@ func (cmd deleteModuleV1ByFqnCommand) handle(r deleteModuleByFqnRepository, logg
 		}, err
 	}
 
+	deleted := []ModuleVersion{}
+
 	if cmd.DTO.DeleteVersions {
-		if err := r.DeleteVersionsForModule(m); err != nil {
+		// Fetched first, so there is a record of what went with the module
+		if deleted, err = r.VersionsByModuleFQN(ctx, cmd.DTO.FQN, ChunkingOptions{}); err != nil {
+			return DeleteModuleV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		if err := r.DeleteVersionsForModule(ctx, m); err != nil {
 			return DeleteModuleV1Response{
 				occurredAt: occurred,
 				Status:     STATUS_INTERNAL_ERROR,
@@ -80,7 +91,7 @@ func (cmd deleteModuleV1ByFqnCommand) handle(r deleteModuleByFqnRepository, logg
 		}
 	}
 
-	if err := r.DeleteModule(m); err != nil {
+	if err := r.DeleteModule(ctx, m); err != nil {
 		return DeleteModuleV1Response{
 			occurredAt: occurred,
 			Status:     STATUS_INTERNAL_ERROR,
@@ -88,8 +99,9 @@ func (cmd deleteModuleV1ByFqnCommand) handle(r deleteModuleByFqnRepository, logg
 	}
 
 	return DeleteModuleV1Response{
-		occurredAt: occurred,
-		Status:     STATUS_OKAY,
-		Module:     m,
+		occurredAt:      occurred,
+		Status:          STATUS_OKAY,
+		Module:          m,
+		DeletedVersions: deleted,
 	}, err
 }
@@ -1,14 +1,16 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 type deleteModuleVersionRepository interface {
-	VersionById(string) (m ModuleVersion, err error)
-	DeleteModuleVersion(ModuleVersion) error
+	VersionById(context.Context, string) (m ModuleVersion, err error)
+	ById(context.Context, string) (m Module, err error)
+	DeleteModuleVersion(context.Context, ModuleVersion) error
 }
 
 type deleteModuleVersionV1CommandValidator interface {
@@ -22,6 +24,7 @@ type deleteModuleVersionV1Command struct {
 type DeleteModuleVersionV1Response struct {
 	occurredAt       time.Time
 	Status           RegistryHandlerStatus
+	Module           Module
 	ModuleVersion    ModuleVersion
 	ValidationErrors []ValidationError
 }
@@ -49,13 +52,13 @@ type DeleteModuleVersionV1DTO struct {
 	Id string `validate:"required,uuid"`
 }
 
-func (dto DeleteModuleVersionV1DTO) validate(r deleteModuleVersionRepository, v deleteModuleVersionV1CommandValidator) []ValidationError {
+func (dto DeleteModuleVersionV1DTO) validate(ctx context.Context, r deleteModuleVersionRepository, v deleteModuleVersionV1CommandValidator) []ValidationError {
 	return v.Validate(dto)
 }
 
-func (cmd deleteModuleVersionV1Command) handle(r deleteModuleVersionRepository, logger zerolog.Logger, v deleteModuleVersionV1CommandValidator) (DeleteModuleVersionV1Response, error) {
+func (cmd deleteModuleVersionV1Command) handle(ctx context.Context, r deleteModuleVersionRepository, logger zerolog.Logger, v deleteModuleVersionV1CommandValidator) (DeleteModuleVersionV1Response, error) {
 	occurred := time.Now().UTC()
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return DeleteModuleVersionV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -63,7 +66,7 @@ func (cmd deleteModuleVersionV1Command) handle(r deleteModuleVersionRepository,
 		}, nil
 	}
 
-	mv, err := r.VersionById(cmd.DTO.Id)
+	mv, err := r.VersionById(ctx, cmd.DTO.Id)
 
 	if err != nil {
 		if _, ok := err.(ErrResourceNotFound); ok {
@@ -81,7 +84,18 @@ func (cmd deleteModuleVersionV1Command) handle(r deleteModuleVersionRepository,
 		}, err
 	}
 
-	err = r.DeleteModuleVersion(mv)
+	m, err := r.ById(ctx, mv.ModuleId)
+
+	if err != nil {
+		logger.Error().Err(err).Str("module_id", mv.ModuleId).Msg("failed to find module for version")
+
+		return DeleteModuleVersionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	err = r.DeleteModuleVersion(ctx, mv)
 
 	if err != nil {
 		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to delete module version")
@@ -95,6 +109,7 @@ func (cmd deleteModuleVersionV1Command) handle(r deleteModuleVersionRepository,
 	return DeleteModuleVersionV1Response{
 		occurredAt:    occurred,
 		Status:        STATUS_OKAY,
+		Module:        m,
 		ModuleVersion: mv,
 	}, err
 }
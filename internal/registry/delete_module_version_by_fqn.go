@@ -1,14 +1,16 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 type deleteModuleVersionByFqnRepository interface {
-	VersionByFQN(ModuleVersionFQN) (m ModuleVersion, err error)
-	DeleteModuleVersion(ModuleVersion) error
+	VersionByFQN(context.Context, ModuleVersionFQN) (m ModuleVersion, err error)
+	ById(context.Context, string) (m Module, err error)
+	DeleteModuleVersion(context.Context, ModuleVersion) error
 }
 
 type deleteModuleVersionByFqnV1CommandValidator interface {
@@ -23,13 +25,13 @@ type deleteModuleVersionByFqnV1Command struct {
 	DTO DeleteModuleVersionByFqnV1DTO
 }
 
-func (dto DeleteModuleVersionByFqnV1DTO) validate(r deleteModuleVersionByFqnRepository, v deleteModuleVersionByFqnV1CommandValidator) []ValidationError {
+func (dto DeleteModuleVersionByFqnV1DTO) validate(ctx context.Context, r deleteModuleVersionByFqnRepository, v deleteModuleVersionByFqnV1CommandValidator) []ValidationError {
 	return v.Validate(dto)
 }
 
-func (cmd deleteModuleVersionByFqnV1Command) handle(r deleteModuleVersionByFqnRepository, logger zerolog.Logger, v deleteModuleVersionByFqnV1CommandValidator) (DeleteModuleVersionV1Response, error) {
+func (cmd deleteModuleVersionByFqnV1Command) handle(ctx context.Context, r deleteModuleVersionByFqnRepository, logger zerolog.Logger, v deleteModuleVersionByFqnV1CommandValidator) (DeleteModuleVersionV1Response, error) {
 	occurred := time.Now().UTC()
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return DeleteModuleVersionV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -37,7 +39,7 @@ func (cmd deleteModuleVersionByFqnV1Command) handle(r deleteModuleVersionByFqnRe
 		}, nil
 	}
 
-	mv, err := r.VersionByFQN(cmd.DTO.FQN)
+	mv, err := r.VersionByFQN(ctx, cmd.DTO.FQN)
 
 	if err != nil {
 		if _, ok := err.(ErrResourceNotFound); ok {
@@ -55,7 +57,18 @@ func (cmd deleteModuleVersionByFqnV1Command) handle(r deleteModuleVersionByFqnRe
 		}, err
 	}
 
-	err = r.DeleteModuleVersion(mv)
+	m, err := r.ById(ctx, mv.ModuleId)
+
+	if err != nil {
+		logger.Error().Err(err).Str("module_id", mv.ModuleId).Msg("failed to find module for version")
+
+		return DeleteModuleVersionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	err = r.DeleteModuleVersion(ctx, mv)
 
 	if err != nil {
 		logger.Error().Err(err).Str("fqn", cmd.DTO.FQN.String()).Msg("failed to delete module version")
@@ -69,6 +82,7 @@ func (cmd deleteModuleVersionByFqnV1Command) handle(r deleteModuleVersionByFqnRe
 	return DeleteModuleVersionV1Response{
 		occurredAt:    occurred,
 		Status:        STATUS_OKAY,
+		Module:        m,
 		ModuleVersion: mv,
 	}, err
 }
@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type deleteNamespaceRepository interface {
+	NamespaceByName(name string) (n Namespace, err error)
+	DeleteNamespace(Namespace) error
+}
+
+type deleteNamespaceModuleRepository interface {
+	All(ctx context.Context, chunkOpts ChunkingOptions, filters ModuleFilters) ([]Module, error)
+}
+
+type deleteNamespaceV1CommandValidator interface {
+	RegisterStructLevelValidator(f validator.StructLevelFunc, t interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+// DeleteNamespaceV1DTO deletes a namespace that has no modules left, and the
+// roles granted in it.
+type DeleteNamespaceV1DTO struct {
+	Name string `validate:"required"`
+}
+
+type deleteNamespaceV1Command struct {
+	DTO DeleteNamespaceV1DTO
+}
+
+type DeleteNamespaceV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Namespace        Namespace
+	ValidationErrors []ValidationError
+}
+
+func (r DeleteNamespaceV1Response) GetActionName() string {
+	return "v1.namespaces.delete"
+}
+
+func (r DeleteNamespaceV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r DeleteNamespaceV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r DeleteNamespaceV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"namespace_id":      r.Namespace.Id,
+		"namespace":         r.Namespace.Name,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (dto DeleteNamespaceV1DTO) validate(ctx context.Context, r deleteNamespaceModuleRepository, v deleteNamespaceV1CommandValidator, logger zerolog.Logger) []ValidationError {
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		modules, err := r.All(ctx, ChunkingOptions{Size: 1}, ModuleFilters{Namespace: dto.Name})
+
+		if err != nil {
+			logger.Error().Err(err).Msg("unexpected repository error during validation")
+			return
+		}
+
+		if len(modules) > 0 {
+			sl.ReportError(dto.Name, "name", "Name", emptyNamespaceTag, "")
+		}
+	}, DeleteNamespaceV1DTO{})
+
+	return v.Validate(dto)
+}
+
+func (cmd deleteNamespaceV1Command) handle(ctx context.Context, r deleteNamespaceRepository, modules deleteNamespaceModuleRepository, logger zerolog.Logger, v deleteNamespaceV1CommandValidator) (DeleteNamespaceV1Response, error) {
+	occurred := time.Now().UTC()
+
+	n, err := r.NamespaceByName(cmd.DTO.Name)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return DeleteNamespaceV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("namespace", cmd.DTO.Name).Msg("failed to find namespace")
+
+		return DeleteNamespaceV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if errs := cmd.DTO.validate(ctx, modules, v, logger); len(errs) > 0 {
+		return DeleteNamespaceV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			Namespace:        n,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	if err := r.DeleteNamespace(n); err != nil {
+		logger.Error().Err(err).Str("namespace", n.Name).Msg("failed to delete namespace")
+
+		return DeleteNamespaceV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return DeleteNamespaceV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Namespace:  n,
+	}, nil
+}
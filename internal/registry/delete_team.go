@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type deleteTeamRepository interface {
+	TeamByName(name string) (t Team, err error)
+	DeleteTeam(Team) error
+}
+
+type deleteTeamV1CommandValidator interface {
+	Validate(cmd interface{}) []ValidationError
+}
+
+// DeleteTeamV1DTO deletes a team, and the roles granted to it.
+type DeleteTeamV1DTO struct {
+	Name string `validate:"required"`
+}
+
+type deleteTeamV1Command struct {
+	DTO DeleteTeamV1DTO
+}
+
+type DeleteTeamV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Team             Team
+	ValidationErrors []ValidationError
+}
+
+func (r DeleteTeamV1Response) GetActionName() string {
+	return "v1.teams.delete"
+}
+
+func (r DeleteTeamV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r DeleteTeamV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r DeleteTeamV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"team_id":           r.Team.Id,
+		"team":              r.Team.Name,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (cmd deleteTeamV1Command) handle(r deleteTeamRepository, logger zerolog.Logger, v deleteTeamV1CommandValidator) (DeleteTeamV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return DeleteTeamV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	t, err := r.TeamByName(cmd.DTO.Name)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return DeleteTeamV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("team", cmd.DTO.Name).Msg("failed to find team")
+
+		return DeleteTeamV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if err := r.DeleteTeam(t); err != nil {
+		logger.Error().Err(err).Str("team", t.Name).Msg("failed to delete team")
+
+		return DeleteTeamV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return DeleteTeamV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Team:       t,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type deleteUserRepository interface {
+	UserById(id string) (u User, err error)
+	DeleteUser(u User, at time.Time) error
+}
+
+type deleteUserV1CommandValidator interface {
+	Validate(cmd interface{}) []ValidationError
+}
+
+// DeleteUserV1DTO deletes a user, the tokens they were issued are revoked.
+type DeleteUserV1DTO struct {
+	Id string `validate:"required,uuid"`
+}
+
+type deleteUserV1Command struct {
+	DTO DeleteUserV1DTO
+}
+
+type DeleteUserV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	User             User
+	ValidationErrors []ValidationError
+}
+
+func (r DeleteUserV1Response) GetActionName() string {
+	return "v1.users.delete"
+}
+
+func (r DeleteUserV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r DeleteUserV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r DeleteUserV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":           r.User.Id,
+		"username":          r.User.Username,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (cmd deleteUserV1Command) handle(r deleteUserRepository, logger zerolog.Logger, v deleteUserV1CommandValidator) (DeleteUserV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return DeleteUserV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	u, err := r.UserById(cmd.DTO.Id)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return DeleteUserV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to find user")
+
+		return DeleteUserV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if err := r.DeleteUser(u, occurred); err != nil {
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to delete user")
+
+		return DeleteUserV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return DeleteUserV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		User:       u,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type deleteWebhookSubscriptionRepository interface {
+	SubscriptionById(id string) (s WebhookSubscription, err error)
+	DeleteSubscription(WebhookSubscription) error
+}
+
+type deleteWebhookSubscriptionV1CommandValidator interface {
+	Validate(cmd interface{}) []ValidationError
+}
+
+// DeleteWebhookSubscriptionV1DTO removes a subscription, along with its
+// deliveries, any still pending are never sent.
+type DeleteWebhookSubscriptionV1DTO struct {
+	Id string `validate:"required,uuid"`
+}
+
+type deleteWebhookSubscriptionV1Command struct {
+	DTO DeleteWebhookSubscriptionV1DTO
+}
+
+type DeleteWebhookSubscriptionV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Subscription     WebhookSubscription
+	ValidationErrors []ValidationError
+}
+
+func (r DeleteWebhookSubscriptionV1Response) GetActionName() string {
+	return "v1.webhooks.subscriptions.delete"
+}
+
+func (r DeleteWebhookSubscriptionV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r DeleteWebhookSubscriptionV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r DeleteWebhookSubscriptionV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"subscription_id":   r.Subscription.Id,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (cmd deleteWebhookSubscriptionV1Command) handle(r deleteWebhookSubscriptionRepository, logger zerolog.Logger, v deleteWebhookSubscriptionV1CommandValidator) (DeleteWebhookSubscriptionV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return DeleteWebhookSubscriptionV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	s, err := r.SubscriptionById(cmd.DTO.Id)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return DeleteWebhookSubscriptionV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to find webhook subscription")
+
+		return DeleteWebhookSubscriptionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if err := r.DeleteSubscription(s); err != nil {
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to delete webhook subscription")
+
+		return DeleteWebhookSubscriptionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return DeleteWebhookSubscriptionV1Response{
+		occurredAt:   occurred,
+		Status:       STATUS_OKAY,
+		Subscription: s.Redacted(),
+	}, nil
+}
@@ -0,0 +1,154 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type diffModuleVersionsRepository interface {
+	VersionById(context.Context, string) (m ModuleVersion, err error)
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	VersionInterface(ctx context.Context, moduleVersionId string) (i ModuleVersionInterface, err error)
+}
+
+type diffModuleVersionsV1CommandValidator interface {
+	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+type diffModuleVersionsV1Command struct {
+	DTO DiffModuleVersionsV1DTO
+}
+
+type DiffModuleVersionsV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Diff             ModuleVersionDiff
+	ValidationErrors []ValidationError
+}
+
+func (r DiffModuleVersionsV1Response) GetActionName() string {
+	return "v1.modules.versions.diff"
+}
+
+func (r DiffModuleVersionsV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r DiffModuleVersionsV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r DiffModuleVersionsV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"from_module_version_id": r.Diff.From.Id,
+		"to_module_version_id":   r.Diff.To.Id,
+		"validation_errors":      r.ValidationErrors,
+	}
+}
+
+// DiffModuleVersionsV1DTO compares a version with another, or with the release
+// before it when no other is supplied, as publishing does.
+type DiffModuleVersionsV1DTO struct {
+	Id        string `validate:"required,uuid"`
+	AgainstId string `validate:"omitempty,uuid"`
+}
+
+func (cmd diffModuleVersionsV1Command) against(ctx context.Context, r diffModuleVersionsRepository, to ModuleVersion) (ModuleVersion, bool, error) {
+	if cmd.DTO.AgainstId != "" {
+		mv, err := r.VersionById(ctx, cmd.DTO.AgainstId)
+
+		return mv, err == nil, err
+	}
+
+	versions, err := r.VersionsByModule(ctx, to.ModuleId, ChunkingOptions{})
+
+	if err != nil {
+		return ModuleVersion{}, false, err
+	}
+
+	mv, ok := PreviousRelease(versions, to.Version)
+
+	return mv, ok, nil
+}
+
+func (cmd diffModuleVersionsV1Command) handle(ctx context.Context, r diffModuleVersionsRepository, logger zerolog.Logger, v diffModuleVersionsV1CommandValidator) (DiffModuleVersionsV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return DiffModuleVersionsV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	notFoundOrError := func(err error, msg string) (DiffModuleVersionsV1Response, error) {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return DiffModuleVersionsV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Str("against_id", cmd.DTO.AgainstId).Msg(msg)
+
+		return DiffModuleVersionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	to, err := r.VersionById(ctx, cmd.DTO.Id)
+
+	if err != nil {
+		return notFoundOrError(err, "failed to find module version")
+	}
+
+	from, found, err := cmd.against(ctx, r, to)
+
+	if err != nil {
+		return notFoundOrError(err, "failed to find module version to compare against")
+	}
+
+	if !found {
+		return DiffModuleVersionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INVALID,
+			ValidationErrors: []ValidationError{
+				{
+					Message: "there is no earlier release to compare against, supply a version",
+					Rule:    "previous_release",
+					Field:   "AgainstId",
+					Value:   to.Version,
+				},
+			},
+		}, nil
+	}
+
+	toIface, err := r.VersionInterface(ctx, to.Id)
+
+	if err != nil {
+		return notFoundOrError(err, "failed to find module version interface")
+	}
+
+	fromIface, err := r.VersionInterface(ctx, from.Id)
+
+	if err != nil {
+		return notFoundOrError(err, "failed to find module version interface")
+	}
+
+	return DiffModuleVersionsV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Diff: ModuleVersionDiff{
+			From:            from,
+			To:              to,
+			Changes:         DiffInterfaces(fromIface.Interface, toIface.Interface),
+			BreakingAllowed: AllowsBreakingChanges(from.Version, to.Version),
+		},
+	}, nil
+}
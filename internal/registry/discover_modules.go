@@ -0,0 +1,411 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+const ModuleMarkerFileName = "ymir.module.yaml"
+
+type DiscoveryStatus string
+
+type discoveryStatusesContainer struct {
+	New         DiscoveryStatus
+	Existing    DiscoveryStatus
+	Conflicting DiscoveryStatus
+}
+
+var DiscoveryStatuses discoveryStatusesContainer = discoveryStatusesContainer{
+	New:         "new",
+	Existing:    "existing",
+	Conflicting: "conflicting",
+}
+
+type sourceCheckout interface {
+	Checkout(ctx context.Context, repo string, ref string) (dir string, cleanup func(), err error)
+}
+
+type discoverModulesRepository interface {
+	AddModule(context.Context, Module) (Module, error)
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+}
+
+type moduleMarker struct {
+	Provider  string `yaml:"provider"`
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+}
+
+type DiscoveredModule struct {
+	Path   string          `json:"path"`
+	FQN    ModuleFQN       `json:"fqn"`
+	Status DiscoveryStatus `json:"status"`
+	Reason string          `json:"reason,omitempty"`
+	Module Module          `json:"module"`
+}
+
+type DiscoverModulesV1DTO struct {
+	RepositoryURL    string `json:"repository_url" validate:"required,repository_url"`
+	Ref              string `json:"ref"`
+	DefaultNamespace string `json:"default_namespace"`
+	DefaultProvider  string `json:"default_provider"`
+	DryRun           bool   `json:"dry_run"`
+	// Only set by the cli, the API never clones a path on the server
+	AllowLocalRepository bool `json:"-"`
+}
+
+type discoverModulesV1Command struct {
+	DTO DiscoverModulesV1DTO
+}
+
+type DiscoverModulesV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Report           []DiscoveredModule
+	ValidationErrors []ValidationError
+}
+
+func (r DiscoverModulesV1Response) GetActionName() string {
+	return "v1.modules.discover"
+}
+
+func (r DiscoverModulesV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r DiscoverModulesV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r DiscoverModulesV1Response) GetAuditMeta() map[string]interface{} {
+	created := []string{}
+
+	for _, d := range r.Report {
+		if d.Status == DiscoveryStatuses.New && d.Module.Id != "" {
+			created = append(created, d.Module.Id)
+		}
+	}
+
+	return map[string]interface{}{
+		"total":             len(r.Report),
+		"created_modules":   created,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (r DiscoverModulesV1Response) CountByStatus(s DiscoveryStatus) int {
+	total := 0
+
+	for _, d := range r.Report {
+		if d.Status == s {
+			total++
+		}
+	}
+
+	return total
+}
+
+func BuildDiscoveryReportTable(report []DiscoveredModule) (h []string, r [][]string) {
+	h = []string{"Status", "Path", "Provider", "Namespace", "Name", "Reason"}
+
+	for _, d := range report {
+		r = append(r, []string{
+			string(d.Status),
+			d.Path,
+			d.FQN.Provider,
+			d.FQN.Namespace,
+			d.FQN.Name,
+			d.Reason,
+		})
+	}
+
+	return
+}
+
+func hasTerraformFiles(fs afero.Fs, dir string) (bool, error) {
+	entries, err := afero.ReadDir(fs, dir)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".tf") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func repositoryBaseName(repo string) string {
+	trimmed := strings.TrimSuffix(strings.TrimRight(repo, "/"), ".git")
+
+	return path.Base(filepath.ToSlash(trimmed))
+}
+
+// findModuleDirectories walks the checkout looking for directories containing
+// terraform files. Anything nested beneath a module (examples, submodules)
+// is considered part of that module, unless it has its own marker file.
+func findModuleDirectories(fs afero.Fs, root string) (dirs []string, err error) {
+	roots := []string{}
+
+	err = afero.Walk(fs, root, func(p string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		if p != root && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		hasTf, err := hasTerraformFiles(fs, p)
+
+		if err != nil || !hasTf {
+			return err
+		}
+
+		hasMarker, err := afero.Exists(fs, filepath.Join(p, ModuleMarkerFileName))
+
+		if err != nil {
+			return err
+		}
+
+		for _, r := range roots {
+			if strings.HasPrefix(p, r+string(filepath.Separator)) && !hasMarker {
+				return nil
+			}
+		}
+
+		roots = append(roots, p)
+
+		return nil
+	})
+
+	return roots, err
+}
+
+func (cmd discoverModulesV1Command) resolveFQN(fs afero.Fs, root string, dir string) (ModuleFQN, string) {
+	fqn := ModuleFQN{
+		Provider:  cmd.DTO.DefaultProvider,
+		Namespace: cmd.DTO.DefaultNamespace,
+		Name:      filepath.Base(dir),
+	}
+
+	if dir == root {
+		fqn.Name = repositoryBaseName(cmd.DTO.RepositoryURL)
+	}
+
+	b, err := afero.ReadFile(fs, filepath.Join(dir, ModuleMarkerFileName))
+
+	if err == nil {
+		marker := moduleMarker{}
+
+		if err := yaml.Unmarshal(b, &marker); err != nil {
+			return fqn, "marker file could not be parsed: " + err.Error()
+		}
+
+		if marker.Provider != "" {
+			fqn.Provider = marker.Provider
+		}
+
+		if marker.Namespace != "" {
+			fqn.Namespace = marker.Namespace
+		}
+
+		if marker.Name != "" {
+			fqn.Name = marker.Name
+		}
+	}
+
+	missing := []string{}
+
+	if fqn.Provider == "" {
+		missing = append(missing, "provider")
+	}
+
+	if fqn.Namespace == "" {
+		missing = append(missing, "namespace")
+	}
+
+	if len(missing) > 0 {
+		return fqn, "could not determine " + strings.Join(missing, " and ") + ", add a marker file or supply a default"
+	}
+
+	return fqn, ""
+}
+
+func (cmd discoverModulesV1Command) buildReport(fs afero.Fs, root string, dirs []string) []DiscoveredModule {
+	report := []DiscoveredModule{}
+	seen := map[string][]int{}
+
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(root, dir)
+
+		if err != nil {
+			rel = dir
+		}
+
+		fqn, reason := cmd.resolveFQN(fs, root, dir)
+		d := DiscoveredModule{
+			Path:   filepath.ToSlash(rel),
+			FQN:    fqn,
+			Status: DiscoveryStatuses.New,
+			Reason: reason,
+		}
+
+		if reason != "" {
+			d.Status = DiscoveryStatuses.Conflicting
+		} else {
+			seen[fqn.String()] = append(seen[fqn.String()], len(report))
+		}
+
+		report = append(report, d)
+	}
+
+	for fqn, indexes := range seen {
+		if len(indexes) < 2 {
+			continue
+		}
+
+		for _, i := range indexes {
+			report[i].Status = DiscoveryStatuses.Conflicting
+			report[i].Reason = "multiple directories resolve to " + fqn
+		}
+	}
+
+	sort.SliceStable(report, func(i, j int) bool {
+		return report[i].Path < report[j].Path
+	})
+
+	return report
+}
+
+// handle takes a ValidatorBuilder, as struct level validators are cached by
+// the validator the first time they run. Each module registered needs a fresh
+// validator, or it would be checked against the first module's FQN.
+func (cmd discoverModulesV1Command) handle(ctx context.Context, fs afero.Fs, checkout sourceCheckout, r discoverModulesRepository, logger zerolog.Logger, buildValidator ValidatorBuilder) (DiscoverModulesV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := buildValidator(logger).Validate(cmd.DTO); len(errs) > 0 {
+		return DiscoverModulesV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	dir, cleanup, err := checkout.Checkout(ctx, cmd.DTO.RepositoryURL, cmd.DTO.Ref)
+
+	if err != nil {
+		logger.Error().Err(err).Str("repository", cmd.DTO.RepositoryURL).Str("ref", cmd.DTO.Ref).Msg("failed to checkout repository")
+
+		return DiscoverModulesV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	defer cleanup()
+
+	dirs, err := findModuleDirectories(fs, dir)
+
+	if err != nil {
+		logger.Error().Err(err).Str("dir", dir).Msg("failed to walk repository")
+
+		return DiscoverModulesV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	report := cmd.buildReport(fs, dir, dirs)
+
+	for i, d := range report {
+		if d.Status != DiscoveryStatuses.New {
+			continue
+		}
+
+		m, err := r.ByFQN(ctx, d.FQN)
+
+		if err == nil {
+			report[i].Status = DiscoveryStatuses.Existing
+			report[i].Module = m
+
+			if m.RepositoryURL != "" && (m.RepositoryURL != cmd.DTO.RepositoryURL || m.Path != CleanModulePath(d.Path)) {
+				report[i].Status = DiscoveryStatuses.Conflicting
+				report[i].Reason = fmt.Sprintf("already registered for %s//%s", m.RepositoryURL, m.Path)
+			}
+
+			continue
+		}
+
+		if _, ok := err.(ErrResourceNotFound); !ok {
+			logger.Error().Err(err).Str("fqn", d.FQN.String()).Msg("failed to look up discovered module")
+
+			return DiscoverModulesV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		if cmd.DTO.DryRun {
+			continue
+		}
+
+		addCmd := addModuleV1Command{
+			DTO: AddModuleV1DTO{
+				Name:                 d.FQN.Name,
+				Namespace:            d.FQN.Namespace,
+				Provider:             d.FQN.Provider,
+				RepositoryURL:        cmd.DTO.RepositoryURL,
+				Path:                 d.Path,
+				AllowLocalRepository: cmd.DTO.AllowLocalRepository,
+			},
+		}
+
+		res, err := addCmd.handle(ctx, r, logger, buildValidator(logger))
+
+		if err != nil {
+			return DiscoverModulesV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		if res.Status != STATUS_CREATED {
+			messages := []string{}
+
+			for _, e := range res.ValidationErrors {
+				messages = append(messages, e.Field+": "+e.Message)
+			}
+
+			report[i].Status = DiscoveryStatuses.Conflicting
+			report[i].Reason = strings.Join(messages, "; ")
+
+			continue
+		}
+
+		report[i].Module = res.Module
+	}
+
+	return DiscoverModulesV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Report:     report,
+	}, nil
+}
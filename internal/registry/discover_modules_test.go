@@ -0,0 +1,257 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeCheckout struct {
+	dir string
+}
+
+func (c *fakeCheckout) Checkout(ctx context.Context, repo string, ref string) (string, func(), error) {
+	return c.dir, func() {}, nil
+}
+
+type fakeDiscoverRepository struct {
+	existing map[string]Module
+	added    []Module
+}
+
+func (r *fakeDiscoverRepository) AddModule(ctx context.Context, m Module) (Module, error) {
+	r.added = append(r.added, m)
+	r.existing[m.FQN().String()] = m
+
+	return m, nil
+}
+
+func (r *fakeDiscoverRepository) ByFQN(ctx context.Context, fqn ModuleFQN) (Module, error) {
+	if m, ok := r.existing[fqn.String()]; ok {
+		return m, nil
+	}
+
+	return Module{}, ErrResourceNotFound{Type: "Module", URI: fqn.String()}
+}
+
+func buildDiscoveryFs(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/repo/README.md":                                 "",
+		"/repo/vpc/main.tf":                               "",
+		"/repo/vpc/modules/subnet/main.tf":                "",
+		"/repo/vpc/examples/simple/main.tf":               "",
+		"/repo/network/dns/main.tf":                       "",
+		"/repo/network/dns/ymir.module.yaml":              "provider: aws\nname: route53\n",
+		"/repo/network/dns/modules/zone/main.tf":          "",
+		"/repo/network/dns/modules/zone/ymir.module.yaml": "name: zone\nnamespace: shared\n",
+		"/repo/.github/workflows/main.tf":                 "",
+		"/repo/storage/bucket/variables.tf":               "",
+		"/repo/storage/bucket/ymir.module.yaml":           "name: vpc\n",
+		"/repo/broken/main.tf":                            "",
+		"/repo/broken/ymir.module.yaml":                   "provider: [\n",
+	}
+
+	for p, content := range files {
+		if err := afero.WriteFile(fs, p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return fs
+}
+
+func Test_findModuleDirectories(t *testing.T) {
+	fs := buildDiscoveryFs(t)
+
+	dirs, err := findModuleDirectories(fs, "/repo")
+
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{
+		"/repo/broken",
+		"/repo/network/dns",
+		"/repo/network/dns/modules/zone",
+		"/repo/storage/bucket",
+		"/repo/vpc",
+	}, dirs)
+}
+
+func Test_discoverModulesV1Command_handle(t *testing.T) {
+	fs := buildDiscoveryFs(t)
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := &fakeDiscoverRepository{
+		existing: map[string]Module{
+			"aws/platform/route53": {Id: "existing-id", Provider: "aws", Namespace: "platform", Name: "route53", RepositoryURL: "git@github.com:org/modules.git", Path: "network/dns"},
+		},
+	}
+
+	cmd := discoverModulesV1Command{
+		DTO: DiscoverModulesV1DTO{
+			RepositoryURL:    "git@github.com:org/modules.git",
+			DefaultNamespace: "platform",
+			DefaultProvider:  "aws",
+		},
+	}
+
+	res, err := cmd.handle(context.Background(), fs, &fakeCheckout{dir: "/repo"}, repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_OKAY, res.Status)
+
+	byPath := map[string]DiscoveredModule{}
+	for _, d := range res.Report {
+		byPath[d.Path] = d
+	}
+
+	assert.Equal(t, DiscoveryStatuses.Conflicting, byPath["broken"].Status)
+	assert.Contains(t, byPath["broken"].Reason, "marker file could not be parsed")
+
+	assert.Equal(t, DiscoveryStatuses.Existing, byPath["network/dns"].Status)
+	assert.Equal(t, "existing-id", byPath["network/dns"].Module.Id)
+
+	assert.Equal(t, DiscoveryStatuses.New, byPath["network/dns/modules/zone"].Status)
+	assert.Equal(t, ModuleFQN{Provider: "aws", Namespace: "shared", Name: "zone"}, byPath["network/dns/modules/zone"].FQN)
+	assert.Equal(t, "git@github.com:org/modules.git", byPath["network/dns/modules/zone"].Module.RepositoryURL)
+	assert.Equal(t, "network/dns/modules/zone", byPath["network/dns/modules/zone"].Module.Path)
+
+	assert.Equal(t, DiscoveryStatuses.Conflicting, byPath["storage/bucket"].Status)
+	assert.Equal(t, DiscoveryStatuses.Conflicting, byPath["vpc"].Status)
+	assert.Equal(t, "multiple directories resolve to aws/platform/vpc", byPath["vpc"].Reason)
+
+	assert.Len(t, repo.added, 1)
+	assert.Equal(t, 1, res.CountByStatus(DiscoveryStatuses.New))
+	assert.Equal(t, 1, res.CountByStatus(DiscoveryStatuses.Existing))
+	assert.Equal(t, 3, res.CountByStatus(DiscoveryStatuses.Conflicting))
+}
+
+func Test_discoverModulesV1Command_handle_DryRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := &fakeDiscoverRepository{existing: map[string]Module{}}
+
+	if err := afero.WriteFile(fs, "/repo/main.tf", []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := discoverModulesV1Command{
+		DTO: DiscoverModulesV1DTO{
+			RepositoryURL:    "https://github.com/org/terraform-aws-vpc.git",
+			DefaultNamespace: "platform",
+			DefaultProvider:  "aws",
+			DryRun:           true,
+		},
+	}
+
+	res, err := cmd.handle(context.Background(), fs, &fakeCheckout{dir: "/repo"}, repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Len(t, res.Report, 1)
+	assert.Equal(t, ".", res.Report[0].Path)
+	assert.Equal(t, "terraform-aws-vpc", res.Report[0].FQN.Name)
+	assert.Equal(t, DiscoveryStatuses.New, res.Report[0].Status)
+	assert.Empty(t, repo.added)
+}
+
+func Test_discoverModulesV1Command_handle_RegisteredElsewhere(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := &fakeDiscoverRepository{
+		existing: map[string]Module{
+			"aws/platform/vpc": {Id: "other-repo-id", Provider: "aws", Namespace: "platform", Name: "vpc", RepositoryURL: "git@github.com:org/other.git"},
+		},
+	}
+
+	if err := afero.WriteFile(fs, "/repo/vpc/main.tf", []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := discoverModulesV1Command{
+		DTO: DiscoverModulesV1DTO{
+			RepositoryURL:    "git@github.com:org/modules.git",
+			DefaultNamespace: "platform",
+			DefaultProvider:  "aws",
+		},
+	}
+
+	res, err := cmd.handle(context.Background(), fs, &fakeCheckout{dir: "/repo"}, repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Len(t, res.Report, 1)
+	assert.Equal(t, DiscoveryStatuses.Conflicting, res.Report[0].Status)
+	assert.Equal(t, "already registered for git@github.com:org/other.git//", res.Report[0].Reason)
+}
+
+func Test_discoverModulesV1Command_handle_RegistersEveryNewModule(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := &fakeDiscoverRepository{existing: map[string]Module{}}
+
+	for _, p := range []string{"/repo/dns/main.tf", "/repo/vpc/main.tf", "/repo/zone/main.tf"} {
+		if err := afero.WriteFile(fs, p, []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := discoverModulesV1Command{
+		DTO: DiscoverModulesV1DTO{
+			RepositoryURL:    "git@github.com:org/modules.git",
+			DefaultNamespace: "platform",
+			DefaultProvider:  "aws",
+		},
+	}
+
+	res, err := cmd.handle(context.Background(), fs, &fakeCheckout{dir: "/repo"}, repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, res.CountByStatus(DiscoveryStatuses.New))
+	assert.Len(t, repo.added, 3)
+}
+
+type discoverModuleRepository struct {
+	ModuleRepository
+	discovered *fakeDiscoverRepository
+}
+
+func (r *discoverModuleRepository) AddModule(ctx context.Context, m Module) (Module, error) {
+	return r.discovered.AddModule(ctx, m)
+}
+
+func (r *discoverModuleRepository) ByFQN(ctx context.Context, fqn ModuleFQN) (Module, error) {
+	return r.discovered.ByFQN(ctx, fqn)
+}
+
+func Test_CommandBus_DiscoverModulesV1_AddsNamespaces(t *testing.T) {
+	namespaces := &fakeNamespaceRepository{}
+	cb := NewCommandBus(
+		WithModuleRepo(&discoverModuleRepository{discovered: &fakeDiscoverRepository{existing: map[string]Module{}}}),
+		WithNamespaceRepo(namespaces),
+		WithUserRepo(&fakeUserRepository{users: []User{{Id: "alice-id", Username: "alice"}}}),
+		WithFS(buildDiscoveryFs(t)),
+		WithSourceCheckout(&fakeCheckout{dir: "/repo"}),
+		WithCommandValidatorBuilder(NewCommandValidator),
+		WithLogger(ymirstubs.BuildZerologLogger(new(bytes.Buffer))),
+	)
+
+	res, err := cb.DiscoverModulesV1(context.Background(), DiscoverModulesV1DTO{
+		RepositoryURL:    "git@github.com:org/modules.git",
+		DefaultNamespace: "platform",
+		DefaultProvider:  "aws",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res.CountByStatus(DiscoveryStatuses.New))
+
+	grant, err := cb.GrantRoleV1(GrantRoleV1DTO{Namespace: "shared", Subject: "user:alice", Role: "publisher"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_CREATED, grant.Status)
+
+	grant, err = cb.GrantRoleV1(GrantRoleV1DTO{Namespace: "platform", Subject: "user:alice", Role: "publisher"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_CREATED, grant.Status)
+}
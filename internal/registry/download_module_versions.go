@@ -1,20 +1,20 @@
 package registry
 
 import (
+	"context"
 	"github.com/rs/zerolog"
 	"gopkg.in/go-playground/validator.v9"
 )
 
 type downloadModuleVersionRepository interface {
-	ByFQN(ModuleFQN) (m Module, err error)
-	VersionByFQN(fqn ModuleVersionFQN) (mv ModuleVersion, err error)
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+	VersionByFQN(ctx context.Context, fqn ModuleVersionFQN) (mv ModuleVersion, err error)
 }
 
 type downloadModuleV1CommandValidator interface {
 	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
 	Validate(cmd interface{}) []ValidationError
-	ModuleMustExistByFQN(r moduleMustExistByFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
-	ModuleVersionMustBeUniqueForFQN(r moduleVersionMustBeUniqueForFQNRepository, sl validator.StructLevel, fqn ModuleVersionFQN)
+	ModuleMustExistByFQN(ctx context.Context, r moduleMustExistByFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
 }
 
 type DownloadModuleVersionV1Command struct {
@@ -30,7 +30,7 @@ type HandleDownloadModuleVersionV1Response struct {
 	ValidationErrors []ValidationError
 }
 
-func (cmd DownloadModuleVersionV1Command) validate(r downloadModuleVersionRepository, v downloadModuleV1CommandValidator, logger zerolog.Logger) []ValidationError {
+func (cmd DownloadModuleVersionV1Command) validate(ctx context.Context, r downloadModuleVersionRepository, v downloadModuleV1CommandValidator, logger zerolog.Logger) []ValidationError {
 	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
 		fqn := ModuleVersionFQN{
 			ModuleFQN: ModuleFQN{
@@ -40,15 +40,14 @@ func (cmd DownloadModuleVersionV1Command) validate(r downloadModuleVersionReposi
 			},
 			Version: cmd.Version,
 		}
-		v.ModuleMustExistByFQN(r, sl, fqn.ModuleFQN)
-		v.ModuleVersionMustBeUniqueForFQN(r, sl, fqn)
+		v.ModuleMustExistByFQN(ctx, r, sl, fqn.ModuleFQN)
 	}, DownloadModuleVersionV1Command{})
 
 	return v.Validate(cmd)
 }
 
-func (c DownloadModuleVersionV1Command) Handle(r downloadModuleVersionRepository, val downloadModuleV1CommandValidator, l zerolog.Logger) (HandleDownloadModuleVersionV1Response, error) {
-	if errs := c.validate(r, val, l); len(errs) > 0 {
+func (c DownloadModuleVersionV1Command) Handle(ctx context.Context, r downloadModuleVersionRepository, val downloadModuleV1CommandValidator, l zerolog.Logger) (HandleDownloadModuleVersionV1Response, error) {
+	if errs := c.validate(ctx, r, val, l); len(errs) > 0 {
 		return HandleDownloadModuleVersionV1Response{
 			Status:           STATUS_INVALID,
 			ValidationErrors: errs,
@@ -64,12 +63,15 @@ func (c DownloadModuleVersionV1Command) Handle(r downloadModuleVersionRepository
 		Version: c.Version,
 	}
 
-	version, err := r.VersionByFQN(fqn)
+	version, err := r.VersionByFQN(ctx, fqn)
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		return HandleDownloadModuleVersionV1Response{
+			Status: STATUS_NOT_FOUND,
+		}, nil
+	}
 
 	if err != nil {
-		// We won't bother checking for ResourceNotFound errors here
-		// The validation already checked this should exist
-		// If we got an error, it should be something like a query error
 		l.Error().Err(err).Str("version", c.Version).Str("fqn", fqn.String()).Msg("failed to find module version")
 
 		return HandleDownloadModuleVersionV1Response{
@@ -77,6 +79,13 @@ func (c DownloadModuleVersionV1Command) Handle(r downloadModuleVersionRepository
 		}, err
 	}
 
+	// There's nothing to download until the archive has been built
+	if version.Status != VersionStatuses.Ready {
+		return HandleDownloadModuleVersionV1Response{
+			Status: STATUS_NOT_FOUND,
+		}, nil
+	}
+
 	return HandleDownloadModuleVersionV1Response{
 		Status:      STATUS_OKAY,
 		LocationURI: version.DownloadURL,
@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type downloadProviderRepository interface {
+	ProviderByFQN(fqn ProviderFQN) (p Provider, err error)
+	ProviderVersionByValue(providerId string, version string) (pv ProviderVersion, err error)
+	GPGKeyById(id string) (k GPGKey, err error)
+}
+
+type DownloadProviderV1DTO struct {
+	FQN     ProviderFQN
+	Version string
+	OS      string
+	Arch    string
+}
+
+type downloadProviderV1Command struct {
+	DTO DownloadProviderV1DTO
+}
+
+// DownloadProviderV1Response has everything terraform needs to fetch and
+// verify the release zip of a provider version for one platform.
+type DownloadProviderV1Response struct {
+	occurredAt      time.Time
+	Status          RegistryHandlerStatus
+	Provider        Provider
+	ProviderVersion ProviderVersion
+	Platform        ProviderPlatform
+	SigningKey      GPGKey
+}
+
+func (r DownloadProviderV1Response) GetActionName() string {
+	return "v1.providers.download"
+}
+
+func (r DownloadProviderV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r DownloadProviderV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r DownloadProviderV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"provider_version_id": r.ProviderVersion.Id,
+		"provider_platform":   r.Platform.Id,
+	}
+}
+
+func (cmd downloadProviderV1Command) handle(r downloadProviderRepository, logger zerolog.Logger) (DownloadProviderV1Response, error) {
+	occurred := time.Now().UTC()
+	res := DownloadProviderV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_INTERNAL_ERROR,
+	}
+
+	notFoundOr := func(err error, msg string) (DownloadProviderV1Response, error) {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			res.Status = STATUS_NOT_FOUND
+
+			return res, nil
+		}
+
+		logger.Error().Err(err).Str("provider", cmd.DTO.FQN.String()).Str("version", cmd.DTO.Version).Msg(msg)
+
+		return res, err
+	}
+
+	p, err := r.ProviderByFQN(cmd.DTO.FQN)
+
+	if err != nil {
+		return notFoundOr(err, "failed to find provider")
+	}
+
+	pv, err := r.ProviderVersionByValue(p.Id, cmd.DTO.Version)
+
+	if err != nil {
+		return notFoundOr(err, "failed to find provider version")
+	}
+
+	platform, ok := pv.Platform(cmd.DTO.OS, cmd.DTO.Arch)
+
+	if !ok {
+		res.Status = STATUS_NOT_FOUND
+
+		return res, nil
+	}
+
+	// A key can't be deleted while it has signed versions, so it must exist
+	k, err := r.GPGKeyById(pv.SigningKeyId)
+
+	if err != nil {
+		logger.Error().Err(err).Str("provider", cmd.DTO.FQN.String()).Str("version", cmd.DTO.Version).Msg("failed to find signing key of provider version")
+
+		return res, err
+	}
+
+	return DownloadProviderV1Response{
+		occurredAt:      occurred,
+		Status:          STATUS_OKAY,
+		Provider:        p,
+		ProviderVersion: pv,
+		Platform:        platform,
+		SigningKey:      k,
+	}, nil
+}
@@ -0,0 +1,172 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type downloadUpstreamModuleRepository interface {
+	listUpstreamModuleVersionsRepository
+	UpstreamModuleVersion(upstreamModuleId string, version string) (mv UpstreamModuleVersion, err error)
+	AddUpstreamModuleVersion(UpstreamModuleVersion) (mv UpstreamModuleVersion, err error)
+}
+
+type DownloadUpstreamModuleV1DTO struct {
+	FQN ModuleVersionFQN
+}
+
+type downloadUpstreamModuleV1Command struct {
+	DTO DownloadUpstreamModuleV1DTO
+}
+
+type DownloadUpstreamModuleV1Response struct {
+	occurredAt    time.Time
+	Status        RegistryHandlerStatus
+	Module        UpstreamModule
+	ModuleVersion UpstreamModuleVersion
+}
+
+func (r DownloadUpstreamModuleV1Response) GetActionName() string {
+	return "v1.upstream.modules.download"
+}
+
+func (r DownloadUpstreamModuleV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r DownloadUpstreamModuleV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r DownloadUpstreamModuleV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"upstream_module_id":         r.Module.Id,
+		"upstream_module_version_id": r.ModuleVersion.Id,
+	}
+}
+
+// handle fetches the archive from the upstream the first time a version is
+// downloaded, every download after that is served from storage.
+func (cmd downloadUpstreamModuleV1Command) handle(ctx context.Context, upstreams []Upstream, client upstreamRegistry, r downloadUpstreamModuleRepository, s objectStore, logger zerolog.Logger) (DownloadUpstreamModuleV1Response, error) {
+	occurred := time.Now().UTC()
+	fqn := cmd.DTO.FQN
+
+	list := listUpstreamModuleVersionsV1Command{
+		DTO: ListUpstreamModuleVersionsV1DTO{
+			FQN: fqn.ModuleFQN,
+		},
+	}
+
+	res, err := list.handle(ctx, upstreams, client, r, logger)
+
+	if err != nil || res.Status != STATUS_OKAY {
+		return DownloadUpstreamModuleV1Response{
+			occurredAt: occurred,
+			Status:     res.Status,
+		}, err
+	}
+
+	m := res.Module
+
+	internalError := func(err error, msg string) (DownloadUpstreamModuleV1Response, error) {
+		logger.Error().Err(err).Str("fqn", fqn.String()).Str("upstream", m.UpstreamURL).Msg(msg)
+
+		return DownloadUpstreamModuleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+			Module:     m,
+		}, err
+	}
+
+	mv, err := r.UpstreamModuleVersion(m.Id, fqn.Version)
+
+	if err == nil {
+		return DownloadUpstreamModuleV1Response{
+			occurredAt:    occurred,
+			Status:        STATUS_OKAY,
+			Module:        m,
+			ModuleVersion: mv,
+		}, nil
+	}
+
+	if _, ok := err.(ErrResourceNotFound); !ok {
+		return internalError(err, "failed to look up stored upstream module version")
+	}
+
+	if !m.HasVersion(fqn.Version) {
+		return DownloadUpstreamModuleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_NOT_FOUND,
+			Module:     m,
+		}, nil
+	}
+
+	failed := func(err error, msg string) (DownloadUpstreamModuleV1Response, error) {
+		logger.Error().Err(err).Str("fqn", fqn.String()).Str("upstream", m.UpstreamURL).Msg(msg)
+
+		return DownloadUpstreamModuleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_FAILED,
+			Module:     m,
+		}, nil
+	}
+
+	source, err := client.ModuleSource(m.UpstreamURL, fqn)
+
+	if err != nil {
+		return failed(err, "failed to find upstream module source")
+	}
+
+	a, err := client.FetchSource(source)
+
+	if err != nil {
+		return failed(err, "failed to fetch upstream module source")
+	}
+
+	defer a.Content.Close()
+
+	u, _ := UpstreamFor(upstreams, fqn.Namespace)
+	key := UpstreamStorageKey(u, fqn, a.Extension)
+
+	if err := s.Put(key, a.Content); err != nil {
+		removeStored(s, []string{key}, logger)
+
+		return failed(err, "failed to store upstream module archive")
+	}
+
+	mv, err = r.AddUpstreamModuleVersion(UpstreamModuleVersion{
+		Id:               uuid.New().String(),
+		UpstreamModuleId: m.Id,
+		Version:          fqn.Version,
+		Source:           source,
+		StorageKey:       key,
+		Subdir:           a.Subdir,
+		CreatedAt:        occurred,
+	})
+
+	if err != nil {
+		// Another download of the version may have stored it first
+		if existing, findErr := r.UpstreamModuleVersion(m.Id, fqn.Version); findErr == nil {
+			return DownloadUpstreamModuleV1Response{
+				occurredAt:    occurred,
+				Status:        STATUS_OKAY,
+				Module:        m,
+				ModuleVersion: existing,
+			}, nil
+		}
+
+		return internalError(err, "failed to add upstream module version")
+	}
+
+	logger.Info().Str("fqn", fqn.String()).Str("key", key).Msg("stored upstream module archive")
+
+	return DownloadUpstreamModuleV1Response{
+		occurredAt:    occurred,
+		Status:        STATUS_OKAY,
+		Module:        m,
+		ModuleVersion: mv,
+	}, nil
+}
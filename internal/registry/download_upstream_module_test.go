@@ -0,0 +1,140 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+func Test_downloadUpstreamModuleV1Command_handle(t *testing.T) {
+	key := "upstream/registry.example.com/hashicorp/consul/aws/0.1.0.tar.gz"
+
+	tests := []struct {
+		name           string
+		version        string
+		sources        map[string]string
+		expectedStatus RegistryHandlerStatus
+		expectedStored map[string][]byte
+	}{
+		{
+			name:    "stores the archive on first download",
+			version: "0.1.0",
+			sources: map[string]string{
+				"aws/hashicorp/consul@0.1.0": "https://registry.example.com/consul.tar.gz//modules/agent",
+			},
+			expectedStatus: STATUS_OKAY,
+			expectedStored: map[string][]byte{
+				key: []byte("consul 0.1.0"),
+			},
+		},
+		{
+			name:           "version the upstream doesn't have",
+			version:        "9.9.9",
+			expectedStatus: STATUS_NOT_FOUND,
+			expectedStored: map[string][]byte{},
+		},
+		{
+			name:    "source that can't be fetched",
+			version: "0.2.0",
+			sources: map[string]string{
+				"aws/hashicorp/consul@0.2.0": "https://registry.example.com/gone.tar.gz",
+			},
+			expectedStatus: STATUS_FAILED,
+			expectedStored: map[string][]byte{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			client := &fakeUpstreamRegistry{
+				versions: map[string][]string{
+					consulFQN().String(): {"0.1.0", "0.2.0"},
+				},
+				sources: test.sources,
+				archives: map[string]string{
+					"https://registry.example.com/consul.tar.gz//modules/agent": "consul 0.1.0",
+				},
+			}
+			repo := &fakeUpstreamRepository{}
+			store := &fakeObjectStore{
+				objects: map[string][]byte{},
+			}
+
+			cmd := downloadUpstreamModuleV1Command{
+				DTO: DownloadUpstreamModuleV1DTO{
+					FQN: ModuleVersionFQN{
+						ModuleFQN: consulFQN(),
+						Version:   test.version,
+					},
+				},
+			}
+
+			res, err := cmd.handle(context.Background(), testUpstreams, client, repo, store, l)
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+			assert.Equal(tt, test.expectedStored, store.objects)
+
+			if res.Status != STATUS_OKAY {
+				assert.Empty(tt, repo.versions)
+				return
+			}
+
+			assert.Equal(tt, key, res.ModuleVersion.StorageKey)
+			assert.Equal(tt, "modules/agent", res.ModuleVersion.Subdir)
+			assert.Equal(tt, repo.modules[0].Id, res.ModuleVersion.UpstreamModuleId)
+		})
+	}
+}
+
+func Test_downloadUpstreamModuleV1Command_handle_ServesStoredArchive(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	client := &fakeUpstreamRegistry{
+		versions: map[string][]string{
+			consulFQN().String(): {"0.1.0"},
+		},
+		sources: map[string]string{
+			"aws/hashicorp/consul@0.1.0": "https://registry.example.com/consul.tar.gz//modules/agent",
+		},
+		archives: map[string]string{
+			"https://registry.example.com/consul.tar.gz//modules/agent": "consul 0.1.0",
+		},
+	}
+	repo := &fakeUpstreamRepository{}
+	store := &fakeObjectStore{
+		objects: map[string][]byte{},
+	}
+
+	cmd := downloadUpstreamModuleV1Command{
+		DTO: DownloadUpstreamModuleV1DTO{
+			FQN: ModuleVersionFQN{
+				ModuleFQN: consulFQN(),
+				Version:   "0.1.0",
+			},
+		},
+	}
+
+	first, err := cmd.handle(context.Background(), testUpstreams, client, repo, store, l)
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_OKAY, first.Status)
+
+	// The upstream going away doesn't matter once the archive is stored
+	client.versions = map[string][]string{}
+	client.sources = map[string]string{}
+	repo.modules[0].FetchedAt = time.Now().UTC().Add(-time.Minute)
+
+	second, err := cmd.handle(context.Background(), testUpstreams, client, repo, store, l)
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_OKAY, second.Status)
+	assert.Equal(t, first.ModuleVersion, second.ModuleVersion)
+	assert.Equal(t, 1, client.fetchCalls)
+	assert.Equal(t, 1, client.listCalls)
+	assert.Len(t, repo.versions, 1)
+}
@@ -1,6 +1,9 @@
 package registry
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type ErrResourceNotFound struct {
 	Type string
@@ -70,3 +73,40 @@ type ErrFailedToConfirmAction struct {
 func (e ErrFailedToConfirmAction) Error() string {
 	return fmt.Sprintf("failed to confirm action: %s", e.Action)
 }
+
+type ErrCouldNotParseProviderFQN struct {
+	Value   string
+	Message string
+}
+
+func (e ErrCouldNotParseProviderFQN) Error() string {
+	return fmt.Sprintf("could not parse '%s' as a ProviderFQN: %s", e.Value, e.Message)
+}
+
+type ErrInvalidShasums struct {
+	Line string
+}
+
+func (e ErrInvalidShasums) Error() string {
+	if e.Line == "" {
+		return "SHA256SUMS file lists no files"
+	}
+
+	return fmt.Sprintf("SHA256SUMS file has an invalid line: '%s'", e.Line)
+}
+
+type ErrInvalidSemVer struct {
+	Version string
+}
+
+func (e ErrInvalidSemVer) Error() string {
+	return fmt.Sprintf("version is not valid semver: %s", e.Version)
+}
+
+type ErrBuildTimedOut struct {
+	After time.Duration
+}
+
+func (e ErrBuildTimedOut) Error() string {
+	return fmt.Sprintf("the build was cancelled after %s", e.After)
+}
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+type eventTypesContainer struct {
+	VersionReady   EventType
+	VersionFailed  EventType
+	VersionDeleted EventType
+}
+
+var EventTypes eventTypesContainer = eventTypesContainer{
+	VersionReady:   "module_version.ready",
+	VersionFailed:  "module_version.failed",
+	VersionDeleted: "module_version.deleted",
+}
+
+func AllEventTypes() []EventType {
+	return []EventType{
+		EventTypes.VersionReady,
+		EventTypes.VersionFailed,
+		EventTypes.VersionDeleted,
+	}
+}
+
+func IsEventType(s string) bool {
+	for _, t := range AllEventTypes() {
+		if string(t) == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Event is something that happened in the registry that other systems may
+// want to react to. It is the payload sent to webhook subscribers.
+type Event struct {
+	Id            string        `json:"id"`
+	Type          EventType     `json:"type"`
+	OccurredAt    time.Time     `json:"occurred_at"`
+	Module        ModuleFQN     `json:"module"`
+	ModuleVersion ModuleVersion `json:"module_version"`
+}
+
+func newModuleVersionEvent(t EventType, occurred time.Time, m Module, mv ModuleVersion) Event {
+	return Event{
+		Id:            uuid.New().String(),
+		Type:          t,
+		OccurredAt:    occurred,
+		Module:        m.FQN(),
+		ModuleVersion: mv,
+	}
+}
+
+// EventfulAction is an AuditableAction that may produce events, they are
+// published when the action is recorded by the Auditor.
+type EventfulAction interface {
+	AuditableAction
+	GetEvents() []Event
+}
+
+func (r BuildModuleVersionV1Response) GetEvents() []Event {
+	switch r.ModuleVersion.Status {
+	case VersionStatuses.Ready:
+		return []Event{newModuleVersionEvent(EventTypes.VersionReady, r.occurredAt, r.Module, r.ModuleVersion)}
+	case VersionStatuses.Failed:
+		return []Event{newModuleVersionEvent(EventTypes.VersionFailed, r.occurredAt, r.Module, r.ModuleVersion)}
+	default:
+		return []Event{}
+	}
+}
+
+func (r DeleteModuleVersionV1Response) GetEvents() []Event {
+	if r.Status != STATUS_OKAY {
+		return []Event{}
+	}
+
+	return []Event{newModuleVersionEvent(EventTypes.VersionDeleted, r.occurredAt, r.Module, r.ModuleVersion)}
+}
+
+func (r DeleteModuleV1Response) GetEvents() []Event {
+	events := []Event{}
+
+	if r.Status != STATUS_OKAY {
+		return events
+	}
+
+	for _, mv := range r.DeletedVersions {
+		events = append(events, newModuleVersionEvent(EventTypes.VersionDeleted, r.occurredAt, r.Module, mv))
+	}
+
+	return events
+}
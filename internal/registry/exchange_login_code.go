@@ -0,0 +1,173 @@
+package registry
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type exchangeLoginCodeRepository interface {
+	TakeAuthorizationCode(hash string) (c AuthorizationCode, err error)
+	UserById(id string) (u User, err error)
+}
+
+// OAuthErrors are the error codes of a failed token request, as terraform
+// expects them.
+var OAuthErrors = struct {
+	InvalidRequest       string
+	InvalidClient        string
+	InvalidGrant         string
+	UnsupportedGrantType string
+}{
+	InvalidRequest:       "invalid_request",
+	InvalidClient:        "invalid_client",
+	InvalidGrant:         "invalid_grant",
+	UnsupportedGrantType: "unsupported_grant_type",
+}
+
+// ExchangeLoginCodeV1DTO is the token request terraform makes with the code
+// it was redirected back with.
+type ExchangeLoginCodeV1DTO struct {
+	GrantType    string
+	Code         string
+	ClientId     string
+	RedirectURI  string
+	CodeVerifier string
+}
+
+type exchangeLoginCodeV1Command struct {
+	DTO ExchangeLoginCodeV1DTO
+}
+
+type ExchangeLoginCodeV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	// The OAuth error code when the status is INVALID
+	Error    string
+	User     User
+	APIToken APIToken
+	// The token itself, terraform stores it in its credentials file
+	Secret string
+}
+
+func (r ExchangeLoginCodeV1Response) GetActionName() string {
+	return "v1.login.token"
+}
+
+func (r ExchangeLoginCodeV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ExchangeLoginCodeV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ExchangeLoginCodeV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":  r.User.Id,
+		"token_id": r.APIToken.Id,
+		"error":    r.Error,
+	}
+}
+
+// handle issues a token with the user's scopes. A code can only be taken
+// once, so a failed exchange needs a new login.
+func (cmd exchangeLoginCodeV1Command) handle(r exchangeLoginCodeRepository, tokens createAPITokenRepository, o LoginOptions, logger zerolog.Logger) (ExchangeLoginCodeV1Response, error) {
+	occurred := time.Now().UTC()
+	invalid := func(e string) (ExchangeLoginCodeV1Response, error) {
+		return ExchangeLoginCodeV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INVALID,
+			Error:      e,
+		}, nil
+	}
+
+	if cmd.DTO.GrantType != "authorization_code" {
+		return invalid(OAuthErrors.UnsupportedGrantType)
+	}
+
+	if cmd.DTO.ClientId != LoginClientId {
+		return invalid(OAuthErrors.InvalidClient)
+	}
+
+	if cmd.DTO.Code == "" || cmd.DTO.CodeVerifier == "" || cmd.DTO.RedirectURI == "" {
+		return invalid(OAuthErrors.InvalidRequest)
+	}
+
+	c, err := r.TakeAuthorizationCode(HashAPIToken(cmd.DTO.Code))
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		return invalid(OAuthErrors.InvalidGrant)
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to take authorization code")
+
+		return ExchangeLoginCodeV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	challenge := pkceChallenge(cmd.DTO.CodeVerifier)
+
+	if occurred.After(c.ExpiresAt) || c.RedirectURI != cmd.DTO.RedirectURI || subtle.ConstantTimeCompare([]byte(challenge), []byte(c.CodeChallenge)) != 1 {
+		return invalid(OAuthErrors.InvalidGrant)
+	}
+
+	u, err := r.UserById(c.UserId)
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		// The user was deleted since logging in
+		return invalid(OAuthErrors.InvalidGrant)
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Str("user_id", c.UserId).Msg("failed to find user")
+
+		return ExchangeLoginCodeV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	var expiresAt *time.Time
+
+	if o.TokenTTL > 0 {
+		at := occurred.Add(o.TokenTTL)
+		expiresAt = &at
+	}
+
+	t, secret, err := newAPIToken(fmt.Sprintf("terraform login (%s)", u.Username), u.Scopes, expiresAt, occurred)
+
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to generate api token")
+
+		return ExchangeLoginCodeV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	t.UserId = u.Id
+	t, err = tokens.AddToken(t)
+
+	if err != nil {
+		logger.Error().Err(err).Str("user_id", u.Id).Msg("failed to add api token")
+
+		return ExchangeLoginCodeV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return ExchangeLoginCodeV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		User:       u,
+		APIToken:   t,
+		Secret:     secret,
+	}, nil
+}
@@ -0,0 +1,168 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type grantRoleRepository interface {
+	NamespaceByName(name string) (n Namespace, err error)
+	GrantForSubject(namespace string, s Subject) (g RoleGrant, err error)
+	SaveGrant(RoleGrant) (g RoleGrant, err error)
+}
+
+type grantRoleV1CommandValidator interface {
+	RegisterStructLevelValidator(f validator.StructLevelFunc, t interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+// GrantRoleV1DTO grants a role in the namespace, it replaces the role the
+// subject had there.
+type GrantRoleV1DTO struct {
+	Namespace string `json:"-" validate:"required"`
+	// As `user:<username>`, `team:<name>` or `token:<id>`
+	Subject string `json:"subject" validate:"required,subject"`
+	Role    string `json:"role" validate:"required,role"`
+}
+
+type grantRoleV1Command struct {
+	DTO GrantRoleV1DTO
+}
+
+type GrantRoleV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Grant            RoleGrant
+	ValidationErrors []ValidationError
+}
+
+func (r GrantRoleV1Response) GetActionName() string {
+	return "v1.namespaces.grant"
+}
+
+func (r GrantRoleV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r GrantRoleV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r GrantRoleV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"grant_id":          r.Grant.Id,
+		"namespace":         r.Grant.Namespace,
+		"subject":           r.Grant.Subject,
+		"role":              r.Grant.Role,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (dto GrantRoleV1DTO) validate(s subjectResolver, v grantRoleV1CommandValidator, logger zerolog.Logger) []ValidationError {
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		if _, _, ok := ParseSubject(dto.Subject); !ok {
+			return
+		}
+
+		_, _, err := s.resolve(dto.Subject)
+
+		if err == nil {
+			return
+		}
+
+		if _, ok := err.(ErrResourceNotFound); ok {
+			sl.ReportError(dto.Subject, "subject", "Subject", existingSubjectTag, dto.Subject)
+			return
+		}
+
+		logger.Error().Err(err).Msg("unexpected repository error during validation")
+	}, GrantRoleV1DTO{})
+
+	return v.Validate(dto)
+}
+
+// handle is NOT_FOUND when the namespace doesn't exist, it is CREATED when
+// the subject didn't have a role in the namespace and MODIFIED when it did.
+func (cmd grantRoleV1Command) handle(r grantRoleRepository, s subjectResolver, logger zerolog.Logger, v grantRoleV1CommandValidator) (GrantRoleV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if _, err := r.NamespaceByName(cmd.DTO.Namespace); err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return GrantRoleV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("namespace", cmd.DTO.Namespace).Msg("failed to find namespace")
+
+		return GrantRoleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if errs := cmd.DTO.validate(s, v, logger); len(errs) > 0 {
+		return GrantRoleV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	subject, name, err := s.resolve(cmd.DTO.Subject)
+
+	if err != nil {
+		logger.Error().Err(err).Str("subject", cmd.DTO.Subject).Msg("failed to find subject")
+
+		return GrantRoleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	status := STATUS_MODIFIED
+	g, err := r.GrantForSubject(cmd.DTO.Namespace, subject)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); !ok {
+			logger.Error().Err(err).Str("subject", cmd.DTO.Subject).Msg("failed to find grant")
+
+			return GrantRoleV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		status = STATUS_CREATED
+		g = RoleGrant{
+			Id:        uuid.New().String(),
+			Namespace: cmd.DTO.Namespace,
+			Subject:   subject,
+			CreatedAt: occurred,
+		}
+	}
+
+	g.SubjectName = name
+	g.Role = Role(cmd.DTO.Role)
+
+	saved, err := r.SaveGrant(g)
+
+	if err != nil {
+		logger.Error().Err(err).Str("subject", cmd.DTO.Subject).Msg("failed to save grant")
+
+		return GrantRoleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return GrantRoleV1Response{
+		occurredAt: occurred,
+		Status:     status,
+		Grant:      saved,
+	}, nil
+}
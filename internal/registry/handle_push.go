@@ -0,0 +1,302 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type PushOutcome string
+
+type pushOutcomesContainer struct {
+	Published PushOutcome
+	Rebuilt   PushOutcome
+	Existing  PushOutcome
+	Invalid   PushOutcome
+}
+
+var PushOutcomes pushOutcomesContainer = pushOutcomesContainer{
+	Published: "published",
+	Rebuilt:   "rebuilt",
+	Existing:  "existing",
+	Invalid:   "invalid",
+}
+
+type handlePushRepository interface {
+	All(ctx context.Context, chunkOpts ChunkingOptions, filters ModuleFilters) ([]Module, error)
+	AddVersion(context.Context, ModuleVersion) (m ModuleVersion, err error)
+	ById(ctx context.Context, id string) (m Module, err error)
+	VersionByModuleAndValue(ctx context.Context, moduleId string, version string) (mv ModuleVersion, err error)
+	UpdateVersion(context.Context, ModuleVersion) (m ModuleVersion, err error)
+}
+
+type PushedModuleVersion struct {
+	FQN     ModuleFQN   `json:"fqn"`
+	Version string      `json:"version"`
+	Outcome PushOutcome `json:"outcome"`
+	Reason  string      `json:"reason,omitempty"`
+}
+
+// HandlePushV1DTO describes a push to a git repository, either of a branch or
+// a tag, as reported by a forge's webhook.
+type HandlePushV1DTO struct {
+	RepositoryURLs []string `json:"repository_urls" validate:"required,min=1"`
+	Ref            string   `json:"ref" validate:"required"`
+	Commit         string   `json:"commit" validate:"required"`
+	ChangedPaths   []string `json:"changed_paths"`
+	Deleted        bool     `json:"deleted"`
+}
+
+type handlePushV1Command struct {
+	DTO HandlePushV1DTO
+}
+
+type HandlePushV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Versions         []PushedModuleVersion
+	ValidationErrors []ValidationError
+}
+
+func (r HandlePushV1Response) GetActionName() string {
+	return "v1.webhooks.push"
+}
+
+func (r HandlePushV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r HandlePushV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r HandlePushV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"versions":          r.Versions,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+// DevVersionForBranch is the version a branch is published as, slashes are
+// replaced so the version can be used in a registry URL.
+func DevVersionForBranch(branch string) string {
+	return "dev-" + strings.ReplaceAll(branch, "/", "-")
+}
+
+func (dto HandlePushV1DTO) IsTag() bool {
+	return strings.HasPrefix(dto.Ref, "refs/tags/")
+}
+
+func (dto HandlePushV1DTO) Tag() string {
+	return strings.TrimPrefix(dto.Ref, "refs/tags/")
+}
+
+func (dto HandlePushV1DTO) Branch() string {
+	return strings.TrimPrefix(dto.Ref, "refs/heads/")
+}
+
+func (dto HandlePushV1DTO) matchesRepository(m Module) bool {
+	if m.RepositoryURL == "" {
+		return false
+	}
+
+	normalised := NormaliseRepositoryURL(m.RepositoryURL)
+
+	for _, u := range dto.RepositoryURLs {
+		if u != "" && NormaliseRepositoryURL(u) == normalised {
+			return true
+		}
+	}
+
+	return false
+}
+
+// touches is true if any of the changed paths are within the module. Forges
+// cap how many commits a payload lists, so with no paths we can't rule out
+// any module having changed.
+func (dto HandlePushV1DTO) touches(m Module) bool {
+	if len(dto.ChangedPaths) == 0 || m.Path == "" {
+		return true
+	}
+
+	for _, p := range dto.ChangedPaths {
+		if strings.HasPrefix(CleanModulePath(p)+"/", m.Path+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func invalidPush(pushed PushedModuleVersion, errs []ValidationError) PushedModuleVersion {
+	messages := []string{}
+
+	for _, e := range errs {
+		messages = append(messages, e.Field+": "+e.Message)
+	}
+
+	pushed.Outcome = PushOutcomes.Invalid
+	pushed.Reason = strings.Join(messages, "; ")
+
+	return pushed
+}
+
+func (cmd handlePushV1Command) publish(ctx context.Context, r handlePushRepository, logger zerolog.Logger, v addModuleVersionV1CommandValidator, m Module, version string) (PushedModuleVersion, error) {
+	pushed := PushedModuleVersion{
+		FQN:     m.FQN(),
+		Version: version,
+		Outcome: PushOutcomes.Published,
+	}
+
+	addCmd := addModuleVersionV1Command{
+		DTO: AddModuleVersionV1DTO{
+			Version:       version,
+			ModuleId:      m.Id,
+			Source:        cmd.DTO.Commit,
+			RepositoryURL: m.RepositoryURL,
+		},
+	}
+
+	res, err := addCmd.handle(ctx, r, logger, v)
+
+	if err != nil {
+		return pushed, err
+	}
+
+	if res.Status != STATUS_CREATED {
+		return invalidPush(pushed, res.ValidationErrors), nil
+	}
+
+	return pushed, nil
+}
+
+// rebuild points a dev version at the pushed commit, and queues it to be
+// built again. The first push to a branch creates the version.
+func (cmd handlePushV1Command) rebuild(ctx context.Context, r handlePushRepository, logger zerolog.Logger, v addModuleVersionV1CommandValidator, m Module, version string) (PushedModuleVersion, error) {
+	mv, err := r.VersionByModuleAndValue(ctx, m.Id, version)
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		return cmd.publish(ctx, r, logger, v, m, version)
+	}
+
+	pushed := PushedModuleVersion{
+		FQN:     m.FQN(),
+		Version: version,
+		Outcome: PushOutcomes.Rebuilt,
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Str("module_id", m.Id).Str("version", version).Msg("failed to look up module version")
+
+		return pushed, err
+	}
+
+	if mv.Source == cmd.DTO.Commit {
+		// Redelivered webhook, or a push that didn't move the branch
+		pushed.Outcome = PushOutcomes.Existing
+
+		return pushed, nil
+	}
+
+	mv.Source = cmd.DTO.Commit
+	mv.RepositoryURL = m.RepositoryURL
+	mv.Status = VersionStatuses.Pending
+	mv.StatusReason = ""
+	mv.DownloadURL = ""
+
+	if _, err := r.UpdateVersion(ctx, mv); err != nil {
+		logger.Error().Err(err).Str("module_version_id", mv.Id).Msg("failed to queue module version rebuild")
+
+		return pushed, err
+	}
+
+	return pushed, nil
+}
+
+// handle takes a ValidatorBuilder, as each version needs a fresh validator,
+// see discoverModulesV1Command.
+func (cmd handlePushV1Command) handle(ctx context.Context, r handlePushRepository, logger zerolog.Logger, buildValidator ValidatorBuilder) (HandlePushV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := buildValidator(logger).Validate(cmd.DTO); len(errs) > 0 {
+		return HandlePushV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	versions := []PushedModuleVersion{}
+
+	// Nothing is published for a deleted branch or tag, existing versions
+	// are left alone as they may be in use
+	if cmd.DTO.Deleted {
+		return HandlePushV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_OKAY,
+			Versions:   versions,
+		}, nil
+	}
+
+	mods, err := r.All(ctx, ChunkingOptions{}, ModuleFilters{})
+
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to list modules")
+
+		return HandlePushV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	for _, m := range mods {
+		if !cmd.DTO.matchesRepository(m) {
+			continue
+		}
+
+		var pushed PushedModuleVersion
+
+		if cmd.DTO.IsTag() {
+			version, ok := m.VersionFromTag(cmd.DTO.Tag())
+
+			if !ok {
+				continue
+			}
+
+			if _, err := r.VersionByModuleAndValue(ctx, m.Id, version); err == nil {
+				versions = append(versions, PushedModuleVersion{
+					FQN:     m.FQN(),
+					Version: version,
+					Outcome: PushOutcomes.Existing,
+				})
+
+				continue
+			}
+
+			pushed, err = cmd.publish(ctx, r, logger, buildValidator(logger), m, version)
+		} else {
+			if !cmd.DTO.touches(m) {
+				continue
+			}
+
+			pushed, err = cmd.rebuild(ctx, r, logger, buildValidator(logger), m, DevVersionForBranch(cmd.DTO.Branch()))
+		}
+
+		if err != nil {
+			return HandlePushV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		versions = append(versions, pushed)
+	}
+
+	return HandlePushV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Versions:   versions,
+	}, nil
+}
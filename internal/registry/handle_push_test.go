@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+const pushTestCommit = "9f8e7d6c5b4a39281706f5e4d3c2b1a098765432"
+
+func buildPushRepository() *fakeVersionRepository {
+	return &fakeVersionRepository{
+		modules: map[string]Module{
+			"5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f": {
+				Id: "5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f", Provider: "aws", Namespace: "platform", Name: "vpc",
+				RepositoryURL: "git@github.com:org/modules.git", Path: "modules/vpc", TagPattern: "modules/vpc/v{version}",
+			},
+			"0d9e1f5a-3c57-4f43-8a2e-9b41ad0a4c11": {
+				Id: "0d9e1f5a-3c57-4f43-8a2e-9b41ad0a4c11", Provider: "aws", Namespace: "platform", Name: "dns",
+				RepositoryURL: "https://github.com/org/modules", Path: "modules/dns", TagPattern: "modules/dns/v{version}",
+			},
+			"e2b1f6d8-1c3a-4f7e-9d2b-6a8c0e4f1b22": {
+				Id: "e2b1f6d8-1c3a-4f7e-9d2b-6a8c0e4f1b22", Provider: "aws", Namespace: "platform", Name: "elsewhere",
+				RepositoryURL: "git@github.com:org/elsewhere.git", TagPattern: "v{version}",
+			},
+		},
+		versions: []ModuleVersion{
+			{Id: "mv-1", ModuleId: "5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f", Version: "1.0.0", Status: VersionStatuses.Ready},
+			{
+				Id: "mv-2", ModuleId: "5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f", Version: "dev-main", Status: VersionStatuses.Ready,
+				Source: "0000000000000000000000000000000000000001", DownloadURL: "/archives/vpc-dev-main.tar.gz",
+			},
+		},
+	}
+}
+
+func pushedByName(versions []PushedModuleVersion) map[string]PushedModuleVersion {
+	byName := map[string]PushedModuleVersion{}
+
+	for _, v := range versions {
+		byName[v.FQN.Name] = v
+	}
+
+	return byName
+}
+
+func Test_handlePushV1Command_handle_Tags(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := buildPushRepository()
+
+	cmd := handlePushV1Command{
+		DTO: HandlePushV1DTO{
+			RepositoryURLs: []string{"https://github.com/org/modules.git", "git@github.com:org/modules.git"},
+			Ref:            "refs/tags/modules/dns/v0.2.0",
+			Commit:         pushTestCommit,
+		},
+	}
+
+	res, err := cmd.handle(context.Background(), repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_OKAY, res.Status)
+	assert.Equal(t, []PushedModuleVersion{
+		{FQN: ModuleFQN{Namespace: "platform", Name: "dns", Provider: "aws"}, Version: "0.2.0", Outcome: PushOutcomes.Published},
+	}, res.Versions)
+
+	mv, err := repo.VersionByModuleAndValue(context.Background(), "0d9e1f5a-3c57-4f43-8a2e-9b41ad0a4c11", "0.2.0")
+
+	assert.Nil(t, err)
+	assert.Equal(t, pushTestCommit, mv.Source)
+	assert.Equal(t, VersionStatuses.Pending, mv.Status)
+
+	// The same tag again, e.g. a redelivered webhook
+	cmd.DTO.Ref = "refs/tags/modules/vpc/v1.0.0"
+	res, err = cmd.handle(context.Background(), repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Equal(t, PushOutcomes.Existing, pushedByName(res.Versions)["vpc"].Outcome)
+	assert.Len(t, repo.versions, 3)
+}
+
+func Test_handlePushV1Command_handle_Branches(t *testing.T) {
+	tests := []struct {
+		name             string
+		ref              string
+		commit           string
+		changedPaths     []string
+		expectedOutcomes map[string]PushOutcome
+	}{
+		{
+			name:         "only touched modules are rebuilt",
+			ref:          "refs/heads/main",
+			commit:       pushTestCommit,
+			changedPaths: []string{"modules/vpc/main.tf", "README.md"},
+			expectedOutcomes: map[string]PushOutcome{
+				"vpc": PushOutcomes.Rebuilt,
+			},
+		},
+		{
+			name:   "no changed paths could be any module",
+			ref:    "refs/heads/main",
+			commit: pushTestCommit,
+			expectedOutcomes: map[string]PushOutcome{
+				"vpc": PushOutcomes.Rebuilt,
+				"dns": PushOutcomes.Published,
+			},
+		},
+		{
+			name:         "new branches are published as dev versions",
+			ref:          "refs/heads/feature/ipv6",
+			commit:       pushTestCommit,
+			changedPaths: []string{"modules/vpc/variables.tf"},
+			expectedOutcomes: map[string]PushOutcome{
+				"vpc": PushOutcomes.Published,
+			},
+		},
+		{
+			name:             "a path sharing a prefix with a module is not in it",
+			ref:              "refs/heads/main",
+			commit:           pushTestCommit,
+			changedPaths:     []string{"modules/vpc-legacy/main.tf"},
+			expectedOutcomes: map[string]PushOutcome{},
+		},
+		{
+			name:         "the same commit is not rebuilt",
+			ref:          "refs/heads/main",
+			commit:       "0000000000000000000000000000000000000001",
+			changedPaths: []string{"modules/vpc/main.tf"},
+			expectedOutcomes: map[string]PushOutcome{
+				"vpc": PushOutcomes.Existing,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := buildPushRepository()
+
+			cmd := handlePushV1Command{
+				DTO: HandlePushV1DTO{
+					RepositoryURLs: []string{"https://github.com/org/modules"},
+					Ref:            test.ref,
+					Commit:         test.commit,
+					ChangedPaths:   test.changedPaths,
+				},
+			}
+
+			res, err := cmd.handle(context.Background(), repo, l, NewCommandValidator)
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, STATUS_OKAY, res.Status)
+
+			outcomes := map[string]PushOutcome{}
+			for name, v := range pushedByName(res.Versions) {
+				outcomes[name] = v.Outcome
+			}
+
+			assert.Equal(tt, test.expectedOutcomes, outcomes)
+		})
+	}
+}
+
+func Test_handlePushV1Command_handle_QueuesRebuild(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := buildPushRepository()
+
+	cmd := handlePushV1Command{
+		DTO: HandlePushV1DTO{
+			RepositoryURLs: []string{"git@github.com:org/modules.git"},
+			Ref:            "refs/heads/main",
+			Commit:         pushTestCommit,
+			ChangedPaths:   []string{"modules/vpc/main.tf"},
+		},
+	}
+
+	_, err := cmd.handle(context.Background(), repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Len(t, repo.updated, 1)
+
+	rebuilt := repo.updated[0]
+
+	assert.Equal(t, "mv-2", rebuilt.Id)
+	assert.Equal(t, pushTestCommit, rebuilt.Source)
+	assert.Equal(t, VersionStatuses.Pending, rebuilt.Status)
+	assert.Equal(t, "", rebuilt.DownloadURL)
+}
+
+func Test_handlePushV1Command_handle_Deleted(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := buildPushRepository()
+
+	cmd := handlePushV1Command{
+		DTO: HandlePushV1DTO{
+			RepositoryURLs: []string{"git@github.com:org/modules.git"},
+			Ref:            "refs/heads/main",
+			Commit:         "0000000000000000000000000000000000000000",
+			Deleted:        true,
+		},
+	}
+
+	res, err := cmd.handle(context.Background(), repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_OKAY, res.Status)
+	assert.Empty(t, res.Versions)
+	assert.Len(t, repo.versions, 2)
+	assert.Empty(t, repo.updated)
+}
+
+func Test_HandlePushV1DTO_Refs(t *testing.T) {
+	tag := HandlePushV1DTO{Ref: "refs/tags/modules/vpc/v1.4.0"}
+	assert.True(t, tag.IsTag())
+	assert.Equal(t, "modules/vpc/v1.4.0", tag.Tag())
+
+	branch := HandlePushV1DTO{Ref: "refs/heads/feature/flow-logs"}
+	assert.False(t, branch.IsTag())
+	assert.Equal(t, "feature/flow-logs", branch.Branch())
+}
+
+func Test_DevVersionForBranch(t *testing.T) {
+	assert.Equal(t, "dev-main", DevVersionForBranch("main"))
+	assert.Equal(t, "dev-feature-ipv6", DevVersionForBranch("feature/ipv6"))
+}
@@ -0,0 +1,27 @@
+package registry
+
+import (
+	"sync"
+	"time"
+)
+
+// heartbeat is when a worker last showed it is working, so that a worker that
+// is stuck can be told apart from one with nothing to do.
+type heartbeat struct {
+	mu sync.Mutex
+	at time.Time
+}
+
+func (h *heartbeat) beat() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.at = time.Now()
+}
+
+func (h *heartbeat) last() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.at
+}
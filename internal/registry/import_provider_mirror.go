@@ -0,0 +1,325 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type MirrorImportStatus string
+
+type mirrorImportStatusesContainer struct {
+	Imported MirrorImportStatus
+	Existing MirrorImportStatus
+	Ignored  MirrorImportStatus
+}
+
+var MirrorImportStatuses mirrorImportStatusesContainer = mirrorImportStatusesContainer{
+	Imported: "imported",
+	Existing: "existing",
+	Ignored:  "ignored",
+}
+
+type importProviderMirrorRepository interface {
+	MirroredPackage(a ProviderSourceAddress, version string, os string, arch string) (p MirroredProviderPackage, err error)
+	AddMirroredPackage(MirroredProviderPackage) (p MirroredProviderPackage, err error)
+}
+
+type importProviderMirrorV1CommandValidator interface {
+	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+type MirrorImport struct {
+	Path    string                  `json:"path"`
+	Status  MirrorImportStatus      `json:"status"`
+	Reason  string                  `json:"reason,omitempty"`
+	Package MirroredProviderPackage `json:"package"`
+}
+
+// ImportProviderMirrorV1DTO points at a directory of provider release zips.
+// Zips laid out as `terraform providers mirror` leaves them, under
+// {hostname}/{namespace}/{type}/, are imported as such. Anything else is
+// attributed to the given namespace and hostname.
+type ImportProviderMirrorV1DTO struct {
+	Directory string `json:"directory" validate:"required"`
+	Hostname  string `json:"hostname"`
+	Namespace string `json:"namespace"`
+}
+
+type importProviderMirrorV1Command struct {
+	DTO ImportProviderMirrorV1DTO
+}
+
+type ImportProviderMirrorV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Report           []MirrorImport
+	ValidationErrors []ValidationError
+}
+
+func (r ImportProviderMirrorV1Response) GetActionName() string {
+	return "v1.mirror.providers.import"
+}
+
+func (r ImportProviderMirrorV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ImportProviderMirrorV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ImportProviderMirrorV1Response) GetAuditMeta() map[string]interface{} {
+	imported := []string{}
+
+	for _, i := range r.Report {
+		if i.Status == MirrorImportStatuses.Imported {
+			imported = append(imported, i.Package.Id)
+		}
+	}
+
+	return map[string]interface{}{
+		"total":             len(r.Report),
+		"imported_packages": imported,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (r ImportProviderMirrorV1Response) CountByStatus(s MirrorImportStatus) int {
+	total := 0
+
+	for _, i := range r.Report {
+		if i.Status == s {
+			total++
+		}
+	}
+
+	return total
+}
+
+func findProviderZips(fs afero.Fs, root string) (zips []string, err error) {
+	err = afero.Walk(fs, root, func(p string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		if p != root && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".zip") {
+			zips = append(zips, p)
+		}
+
+		return nil
+	})
+
+	return zips, err
+}
+
+// resolvePackage works out which provider a zip belongs to from its name and
+// where it sits in the directory, the reason is set when it can't.
+func (cmd importProviderMirrorV1Command) resolvePackage(rel string) (MirroredProviderPackage, string) {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	filename := parts[len(parts)-1]
+
+	providerType, version, os, arch, ok := ParseProviderPackageFilename(filename)
+
+	if !ok {
+		return MirroredProviderPackage{}, "not named like a provider release zip"
+	}
+
+	pkg := MirroredProviderPackage{
+		Hostname:  cmd.DTO.Hostname,
+		Namespace: cmd.DTO.Namespace,
+		Type:      providerType,
+		Version:   version,
+		OS:        os,
+		Arch:      arch,
+		Filename:  filename,
+	}
+
+	if pkg.Hostname == "" {
+		pkg.Hostname = DefaultMirrorHostname
+	}
+
+	if len(parts) == 4 {
+		if parts[2] != providerType {
+			return pkg, "filename does not match the provider directory it is in"
+		}
+
+		pkg.Hostname = parts[0]
+		pkg.Namespace = parts[1]
+	}
+
+	if pkg.Namespace == "" {
+		return pkg, "could not determine namespace, supply one or use the layout of terraform providers mirror"
+	}
+
+	return pkg, ""
+}
+
+// importPackage hashes and stores the zip, returning the reason when it turns
+// out not to be one.
+func importPackage(fs afero.Fs, s objectStore, path string, pkg MirroredProviderPackage) (MirroredProviderPackage, string, error) {
+	f, err := fs.Open(path)
+
+	if err != nil {
+		return pkg, "", err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+
+	if err != nil {
+		return pkg, "", err
+	}
+
+	pkg.Hash, err = ProviderPackageHash(f, info.Size())
+
+	if err != nil {
+		return pkg, "not a valid zip: " + err.Error(), nil
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		return pkg, "", err
+	}
+
+	pkg.StorageKey = MirrorStorageKey(pkg.Address(), pkg.Filename)
+	pkg.Shasum, err = storeArchive(s, pkg.StorageKey, ProviderArchive{
+		Filename: pkg.Filename,
+		Content:  f,
+	})
+
+	return pkg, "", err
+}
+
+func (cmd importProviderMirrorV1Command) handle(fs afero.Fs, r importProviderMirrorRepository, s objectStore, logger zerolog.Logger, v importProviderMirrorV1CommandValidator) (ImportProviderMirrorV1Response, error) {
+	occurred := time.Now().UTC()
+
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		if cmd.DTO.Directory == "" {
+			return
+		}
+
+		if exists, _ := afero.DirExists(fs, cmd.DTO.Directory); !exists {
+			sl.ReportError(cmd.DTO.Directory, "directory", "Directory", directoryTag, "")
+		}
+	}, ImportProviderMirrorV1DTO{})
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return ImportProviderMirrorV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	zips, err := findProviderZips(fs, cmd.DTO.Directory)
+
+	if err != nil {
+		logger.Error().Err(err).Str("dir", cmd.DTO.Directory).Msg("failed to walk mirror directory")
+
+		return ImportProviderMirrorV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	report := []MirrorImport{}
+
+	for _, path := range zips {
+		rel, err := filepath.Rel(cmd.DTO.Directory, path)
+
+		if err != nil {
+			rel = path
+		}
+
+		pkg, reason := cmd.resolvePackage(rel)
+		i := MirrorImport{
+			Path:    filepath.ToSlash(rel),
+			Status:  MirrorImportStatuses.Imported,
+			Package: pkg,
+			Reason:  reason,
+		}
+
+		if reason != "" {
+			i.Status = MirrorImportStatuses.Ignored
+			report = append(report, i)
+
+			continue
+		}
+
+		existing, err := r.MirroredPackage(pkg.Address(), pkg.Version, pkg.OS, pkg.Arch)
+
+		if err == nil {
+			i.Status = MirrorImportStatuses.Existing
+			i.Package = existing
+			report = append(report, i)
+
+			continue
+		}
+
+		if _, ok := err.(ErrResourceNotFound); !ok {
+			logger.Error().Err(err).Str("path", path).Msg("failed to look up mirrored package")
+
+			return ImportProviderMirrorV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		pkg.Id = uuid.New().String()
+		pkg.CreatedAt = occurred
+		pkg, reason, err = importPackage(fs, s, path, pkg)
+
+		if err != nil {
+			logger.Error().Err(err).Str("path", path).Msg("failed to store mirrored package")
+
+			return ImportProviderMirrorV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		if reason != "" {
+			i.Status = MirrorImportStatuses.Ignored
+			i.Reason = reason
+			report = append(report, i)
+
+			continue
+		}
+
+		i.Package, err = r.AddMirroredPackage(pkg)
+
+		if err != nil {
+			logger.Error().Err(err).Str("path", path).Msg("failed to add mirrored package")
+			removeStored(s, []string{pkg.StorageKey}, logger)
+
+			return ImportProviderMirrorV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		report = append(report, i)
+	}
+
+	return ImportProviderMirrorV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Report:     report,
+	}, nil
+}
@@ -0,0 +1,196 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeProviderMirrorRepository struct {
+	packages []MirroredProviderPackage
+}
+
+func (r *fakeProviderMirrorRepository) MirroredPackage(a ProviderSourceAddress, version string, os string, arch string) (MirroredProviderPackage, error) {
+	for _, p := range r.packages {
+		if p.Address() == a && p.Version == version && p.OS == os && p.Arch == arch {
+			return p, nil
+		}
+	}
+
+	return MirroredProviderPackage{}, ErrResourceNotFound{Type: "MirroredProviderPackage", URI: a.String()}
+}
+
+func (r *fakeProviderMirrorRepository) AddMirroredPackage(p MirroredProviderPackage) (MirroredProviderPackage, error) {
+	r.packages = append(r.packages, p)
+
+	return p, nil
+}
+
+func buildMirrorFs(t *testing.T) (afero.Fs, []byte) {
+	fs := afero.NewMemMapFs()
+	aws := buildZip(t, "terraform-provider-aws_v4.0.0_x5", "aws")
+	files := map[string][]byte{
+		"/mirror/registry.terraform.io/hashicorp/aws/index.json":                                      []byte(`{"versions":{"4.0.0":{}}}`),
+		"/mirror/registry.terraform.io/hashicorp/aws/4.0.0.json":                                      []byte(`{"archives":{}}`),
+		"/mirror/registry.terraform.io/hashicorp/aws/terraform-provider-aws_4.0.0_linux_amd64.zip":    aws,
+		"/mirror/registry.terraform.io/hashicorp/aws/terraform-provider-aws_4.0.0_darwin_arm64.zip":   aws,
+		"/mirror/registry.terraform.io/hashicorp/aws/terraform-provider-random_3.0.0_linux_amd64.zip": aws,
+		"/mirror/terraform-provider-internal_1.0.0_linux_amd64.zip":                                   buildZip(t, "terraform-provider-internal", "internal"),
+		"/mirror/terraform-provider-broken_1.0.0_linux_amd64.zip":                                     []byte("not a zip"),
+		"/mirror/notes.zip": aws,
+		"/mirror/.cache/terraform-provider-aws_3.0.0_linux_amd64.zip": aws,
+	}
+
+	for p, content := range files {
+		if err := afero.WriteFile(fs, p, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return fs, aws
+}
+
+func Test_importProviderMirrorV1Command_handle(t *testing.T) {
+	existing := MirroredProviderPackage{
+		Id:        "6f3c1b8e-0d6a-4a4e-9f55-3f5b7f0e2a11",
+		Hostname:  "registry.terraform.io",
+		Namespace: "hashicorp",
+		Type:      "aws",
+		Version:   "4.0.0",
+		OS:        "darwin",
+		Arch:      "arm64",
+	}
+
+	tests := []struct {
+		name             string
+		dto              ImportProviderMirrorV1DTO
+		expectedStatus   RegistryHandlerStatus
+		expectedRules    []string
+		expectedStatuses map[string]MirrorImportStatus
+		expectedImported []ProviderSourceAddress
+	}{
+		{
+			name: "imports the terraform providers mirror layout",
+			dto: ImportProviderMirrorV1DTO{
+				Directory: "/mirror",
+			},
+			expectedStatus: STATUS_OKAY,
+			expectedStatuses: map[string]MirrorImportStatus{
+				"notes.zip": MirrorImportStatuses.Ignored,
+				"registry.terraform.io/hashicorp/aws/terraform-provider-aws_4.0.0_darwin_arm64.zip":   MirrorImportStatuses.Existing,
+				"registry.terraform.io/hashicorp/aws/terraform-provider-aws_4.0.0_linux_amd64.zip":    MirrorImportStatuses.Imported,
+				"registry.terraform.io/hashicorp/aws/terraform-provider-random_3.0.0_linux_amd64.zip": MirrorImportStatuses.Ignored,
+				"terraform-provider-broken_1.0.0_linux_amd64.zip":                                     MirrorImportStatuses.Ignored,
+				"terraform-provider-internal_1.0.0_linux_amd64.zip":                                   MirrorImportStatuses.Ignored,
+			},
+			expectedImported: []ProviderSourceAddress{
+				{Hostname: "registry.terraform.io", Namespace: "hashicorp", Type: "aws"},
+			},
+		},
+		{
+			name: "imports loose zips into the namespace",
+			dto: ImportProviderMirrorV1DTO{
+				Directory: "/mirror",
+				Hostname:  "registry.example.com",
+				Namespace: "platform",
+			},
+			expectedStatus: STATUS_OKAY,
+			expectedStatuses: map[string]MirrorImportStatus{
+				"notes.zip": MirrorImportStatuses.Ignored,
+				"registry.terraform.io/hashicorp/aws/terraform-provider-aws_4.0.0_darwin_arm64.zip":   MirrorImportStatuses.Existing,
+				"registry.terraform.io/hashicorp/aws/terraform-provider-aws_4.0.0_linux_amd64.zip":    MirrorImportStatuses.Imported,
+				"registry.terraform.io/hashicorp/aws/terraform-provider-random_3.0.0_linux_amd64.zip": MirrorImportStatuses.Ignored,
+				"terraform-provider-broken_1.0.0_linux_amd64.zip":                                     MirrorImportStatuses.Ignored,
+				"terraform-provider-internal_1.0.0_linux_amd64.zip":                                   MirrorImportStatuses.Imported,
+			},
+			expectedImported: []ProviderSourceAddress{
+				{Hostname: "registry.terraform.io", Namespace: "hashicorp", Type: "aws"},
+				{Hostname: "registry.example.com", Namespace: "platform", Type: "internal"},
+			},
+		},
+		{
+			name:           "directory is required",
+			dto:            ImportProviderMirrorV1DTO{},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"required"},
+		},
+		{
+			name: "directory must exist",
+			dto: ImportProviderMirrorV1DTO{
+				Directory: "/nope",
+			},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"directory"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			fs, aws := buildMirrorFs(tt)
+			repo := &fakeProviderMirrorRepository{
+				packages: []MirroredProviderPackage{existing},
+			}
+			store := &fakeObjectStore{
+				objects: map[string][]byte{},
+			}
+
+			cmd := importProviderMirrorV1Command{
+				DTO: test.dto,
+			}
+
+			res, err := cmd.handle(fs, repo, store, l, NewCommandValidator(l))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+
+			rules := []string{}
+			for _, e := range res.ValidationErrors {
+				rules = append(rules, e.Rule)
+			}
+
+			if test.expectedRules == nil {
+				test.expectedRules = []string{}
+			}
+
+			assert.Equal(tt, test.expectedRules, rules)
+
+			if res.Status != STATUS_OKAY {
+				assert.Empty(tt, store.objects)
+				return
+			}
+
+			statuses := map[string]MirrorImportStatus{}
+			for _, i := range res.Report {
+				statuses[i.Path] = i.Status
+
+				if i.Status == MirrorImportStatuses.Ignored {
+					assert.NotEmpty(tt, i.Reason, i.Path)
+				}
+			}
+
+			assert.Equal(tt, test.expectedStatuses, statuses)
+
+			imported := []ProviderSourceAddress{}
+			for _, p := range repo.packages[1:] {
+				imported = append(imported, p.Address())
+
+				assert.NotEmpty(tt, p.Id)
+				assert.Regexp(tt, `^h1:`, p.Hash)
+				assert.Contains(tt, store.objects, p.StorageKey)
+			}
+
+			assert.Equal(tt, test.expectedImported, imported)
+			assert.Len(tt, store.objects, len(test.expectedImported))
+
+			sum := sha256.Sum256(aws)
+			assert.Equal(tt, hex.EncodeToString(sum[:]), repo.packages[1].Shasum)
+			assert.Equal(tt, aws, store.objects[repo.packages[1].StorageKey])
+		})
+	}
+}
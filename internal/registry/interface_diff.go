@@ -0,0 +1,357 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+)
+
+type InterfaceChangeKind string
+
+type interfaceChangeKindsContainer struct {
+	Variable  InterfaceChangeKind
+	Output    InterfaceChangeKind
+	Provider  InterfaceChangeKind
+	Terraform InterfaceChangeKind
+}
+
+var InterfaceChangeKinds interfaceChangeKindsContainer = interfaceChangeKindsContainer{
+	Variable:  "variable",
+	Output:    "output",
+	Provider:  "provider",
+	Terraform: "terraform",
+}
+
+// InterfaceChange is a difference between the interfaces of two versions of a
+// module, it is breaking when callers of the old version may fail with the
+// new one.
+type InterfaceChange struct {
+	Kind     InterfaceChangeKind `json:"kind"`
+	Name     string              `json:"name"`
+	Change   string              `json:"change"`
+	Breaking bool                `json:"breaking"`
+}
+
+func (c InterfaceChange) String() string {
+	return fmt.Sprintf("%s %q %s", c.Kind, c.Name, c.Change)
+}
+
+// BreakingChanges filters the changes down to those that are breaking.
+func BreakingChanges(changes []InterfaceChange) []InterfaceChange {
+	breaking := []InterfaceChange{}
+
+	for _, c := range changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+
+	return breaking
+}
+
+// DiffInterfaces lists what changed between two interfaces, in the order of
+// variables, outputs, providers and then the terraform version.
+func DiffInterfaces(from ModuleInterface, to ModuleInterface) []InterfaceChange {
+	changes := []InterfaceChange{}
+	changes = append(changes, diffVariables(from.Variables, to.Variables)...)
+	changes = append(changes, diffOutputs(from.Outputs, to.Outputs)...)
+	changes = append(changes, diffProviders(from.RequiredProviders, to.RequiredProviders)...)
+
+	if c, changed := diffConstraints(from.RequiredVersion, to.RequiredVersion); changed {
+		c.Kind = InterfaceChangeKinds.Terraform
+		c.Name = "required_version"
+		changes = append(changes, c)
+	}
+
+	return changes
+}
+
+func diffVariables(from []ModuleVariable, to []ModuleVariable) []InterfaceChange {
+	changes := []InterfaceChange{}
+	old := map[string]ModuleVariable{}
+
+	for _, v := range from {
+		old[v.Name] = v
+	}
+
+	for _, v := range to {
+		change := InterfaceChange{
+			Kind: InterfaceChangeKinds.Variable,
+			Name: v.Name,
+		}
+		prev, existed := old[v.Name]
+		delete(old, v.Name)
+
+		switch {
+		case !existed && v.Required:
+			change.Change = "was added as required"
+			change.Breaking = true
+		case !existed:
+			change.Change = "was added"
+		case !prev.Required && v.Required:
+			change.Change = "became required"
+			change.Breaking = true
+		case prev.Type != v.Type:
+			change.Change = fmt.Sprintf("changed type from %s to %s", typeOrAny(prev.Type), typeOrAny(v.Type))
+			change.Breaking = true
+		case prev.Required && !v.Required:
+			change.Change = "became optional"
+		case string(prev.Default) != string(v.Default):
+			change.Change = fmt.Sprintf("changed default from %s to %s", prev.Default, v.Default)
+		default:
+			continue
+		}
+
+		changes = append(changes, change)
+	}
+
+	for _, v := range from {
+		if _, removed := old[v.Name]; removed {
+			changes = append(changes, InterfaceChange{
+				Kind:     InterfaceChangeKinds.Variable,
+				Name:     v.Name,
+				Change:   "was removed",
+				Breaking: true,
+			})
+		}
+	}
+
+	return changes
+}
+
+func typeOrAny(t string) string {
+	if t == "" {
+		return "any"
+	}
+
+	return t
+}
+
+func diffOutputs(from []ModuleOutput, to []ModuleOutput) []InterfaceChange {
+	changes := []InterfaceChange{}
+	current := map[string]bool{}
+	old := map[string]bool{}
+
+	for _, o := range to {
+		current[o.Name] = true
+	}
+
+	for _, o := range from {
+		old[o.Name] = true
+
+		if !current[o.Name] {
+			changes = append(changes, InterfaceChange{
+				Kind:     InterfaceChangeKinds.Output,
+				Name:     o.Name,
+				Change:   "was removed",
+				Breaking: true,
+			})
+		}
+	}
+
+	for _, o := range to {
+		if !old[o.Name] {
+			changes = append(changes, InterfaceChange{
+				Kind:   InterfaceChangeKinds.Output,
+				Name:   o.Name,
+				Change: "was added",
+			})
+		}
+	}
+
+	return changes
+}
+
+func diffProviders(from []ProviderRequirement, to []ProviderRequirement) []InterfaceChange {
+	changes := []InterfaceChange{}
+	old := map[string]ProviderRequirement{}
+
+	for _, p := range from {
+		old[p.Name] = p
+	}
+
+	for _, p := range to {
+		prev, existed := old[p.Name]
+		delete(old, p.Name)
+
+		if !existed {
+			changes = append(changes, InterfaceChange{
+				Kind:   InterfaceChangeKinds.Provider,
+				Name:   p.Name,
+				Change: "was added",
+			})
+
+			continue
+		}
+
+		if prev.Source != p.Source {
+			changes = append(changes, InterfaceChange{
+				Kind:     InterfaceChangeKinds.Provider,
+				Name:     p.Name,
+				Change:   fmt.Sprintf("changed source from %s to %s", prev.Source, p.Source),
+				Breaking: true,
+			})
+
+			continue
+		}
+
+		if c, changed := diffConstraints(prev.VersionConstraints, p.VersionConstraints); changed {
+			c.Kind = InterfaceChangeKinds.Provider
+			c.Name = p.Name
+			changes = append(changes, c)
+		}
+	}
+
+	for _, p := range from {
+		if _, removed := old[p.Name]; removed {
+			changes = append(changes, InterfaceChange{
+				Kind:   InterfaceChangeKinds.Provider,
+				Name:   p.Name,
+				Change: "was removed",
+			})
+		}
+	}
+
+	return changes
+}
+
+func describeConstraints(c []string) string {
+	if len(c) == 0 {
+		return "any version"
+	}
+
+	return strings.Join(c, ", ")
+}
+
+// diffConstraints compares version constraints, they are breaking when the new
+// constraints no longer allow a version the old ones did.
+func diffConstraints(from []string, to []string) (InterfaceChange, bool) {
+	if describeConstraints(from) == describeConstraints(to) {
+		return InterfaceChange{}, false
+	}
+
+	change := InterfaceChange{
+		Change: fmt.Sprintf("constraint changed from %s to %s", describeConstraints(from), describeConstraints(to)),
+	}
+
+	if constraintsNarrowed(from, to) {
+		change.Change = fmt.Sprintf("constraint narrowed from %s to %s", describeConstraints(from), describeConstraints(to))
+		change.Breaking = true
+	}
+
+	return change, true
+}
+
+var constraintVersionPattern = regexp.MustCompile(`[0-9]+(\.[0-9]+){0,2}`)
+
+// constraintsNarrowed checks the versions either side of every version named
+// in the constraints, which is where the versions they allow start and end.
+func constraintsNarrowed(from []string, to []string) bool {
+	old, err := goversion.NewConstraint(strings.Join(append([]string{">= 0.0.0"}, from...), ", "))
+
+	if err != nil {
+		return false
+	}
+
+	current, err := goversion.NewConstraint(strings.Join(append([]string{">= 0.0.0"}, to...), ", "))
+
+	if err != nil {
+		return false
+	}
+
+	for _, v := range constraintBoundaries(append(append([]string{}, from...), to...)) {
+		if old.Check(v) && !current.Check(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func constraintBoundaries(constraints []string) []*goversion.Version {
+	boundaries := []*goversion.Version{goversion.Must(goversion.NewVersion("0.0.0"))}
+
+	for _, c := range constraints {
+		for _, match := range constraintVersionPattern.FindAllString(c, -1) {
+			v, err := goversion.NewVersion(match)
+
+			if err != nil {
+				continue
+			}
+
+			s := v.Segments()
+			major, minor, patch := s[0], s[1], s[2]
+			candidates := [][3]int{
+				{major, minor, patch},
+				{major, minor, patch + 1},
+				{major, minor + 1, 0},
+				{major + 1, 0, 0},
+			}
+
+			switch {
+			case patch > 0:
+				candidates = append(candidates, [3]int{major, minor, patch - 1})
+			case minor > 0:
+				candidates = append(candidates, [3]int{major, minor - 1, 9999})
+			case major > 0:
+				candidates = append(candidates, [3]int{major - 1, 9999, 9999})
+			}
+
+			for _, c := range candidates {
+				boundaries = append(boundaries, goversion.Must(goversion.NewVersion(fmt.Sprintf("%d.%d.%d", c[0], c[1], c[2]))))
+			}
+		}
+	}
+
+	return boundaries
+}
+
+// AllowsBreakingChanges is true when moving from one release to the other
+// is a major bump, or a minor bump before 1.0.0 when anything may change.
+// Prereleases make no promises about compatibility.
+func AllowsBreakingChanges(from string, to string) bool {
+	prev, err := ParseSemVer(from)
+
+	if err != nil {
+		return true
+	}
+
+	next, err := ParseSemVer(to)
+
+	if err != nil || next.IsPrerelease() {
+		return true
+	}
+
+	if next.Major != prev.Major {
+		return true
+	}
+
+	return next.Major == 0 && next.Minor != prev.Minor
+}
+
+// ModuleVersionDiff is how the interface changed from one version to another.
+type ModuleVersionDiff struct {
+	From    ModuleVersion     `json:"from"`
+	To      ModuleVersion     `json:"to"`
+	Changes []InterfaceChange `json:"changes"`
+	// Whether the versions are far enough apart for breaking changes
+	BreakingAllowed bool `json:"breaking_allowed"`
+}
+
+func BuildInterfaceChangesTable(changes []InterfaceChange) (h []string, r [][]string) {
+	h = []string{"Kind", "Name", "Change", "Breaking"}
+
+	for _, c := range changes {
+		r = append(r, []string{
+			string(c.Kind),
+			c.Name,
+			c.Change,
+			strconv.FormatBool(c.Breaking),
+		})
+	}
+
+	return
+}
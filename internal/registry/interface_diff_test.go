@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffInterfaces(t *testing.T) {
+	from := ModuleInterface{
+		RequiredVersion: []string{">= 0.13"},
+		RequiredProviders: []ProviderRequirement{
+			{Name: "aws", Source: "hashicorp/aws", VersionConstraints: []string{">= 3.0"}},
+			{Name: "random", Source: "hashicorp/random", VersionConstraints: []string{"~> 3.0"}},
+			{Name: "null", Source: "hashicorp/null"},
+		},
+		Variables: []ModuleVariable{
+			{Name: "cidr", Type: "string", Required: true},
+			{Name: "name", Type: "string", Required: true},
+			{Name: "tags", Type: "map(string)", Default: json.RawMessage(`{}`)},
+			{Name: "azs", Type: "number", Default: json.RawMessage(`2`)},
+			{Name: "dns", Type: "bool", Default: json.RawMessage(`true`)},
+			{Name: "region", Type: "string", Required: true},
+		},
+		Outputs: []ModuleOutput{
+			{Name: "id"},
+			{Name: "arn"},
+		},
+	}
+	to := ModuleInterface{
+		RequiredVersion: []string{">= 0.13"},
+		RequiredProviders: []ProviderRequirement{
+			{Name: "aws", Source: "hashicorp/aws", VersionConstraints: []string{">= 4.0"}},
+			{Name: "random", Source: "hashicorp/random", VersionConstraints: []string{">= 3.0"}},
+			{Name: "tls", Source: "hashicorp/tls"},
+		},
+		Variables: []ModuleVariable{
+			{Name: "cidr", Type: "string", Required: true},
+			{Name: "tags", Type: "map(string)", Required: true},
+			{Name: "azs", Type: "list(string)", Default: json.RawMessage(`[]`)},
+			{Name: "dns", Type: "bool", Default: json.RawMessage(`false`)},
+			{Name: "region", Type: "string", Default: json.RawMessage(`"eu-west-1"`)},
+			{Name: "vpc_name", Type: "string", Required: true},
+			{Name: "enabled", Type: "bool", Default: json.RawMessage(`true`)},
+		},
+		Outputs: []ModuleOutput{
+			{Name: "id"},
+			{Name: "vpc_id"},
+		},
+	}
+
+	assert.Equal(t, []InterfaceChange{
+		{Kind: InterfaceChangeKinds.Variable, Name: "tags", Change: "became required", Breaking: true},
+		{Kind: InterfaceChangeKinds.Variable, Name: "azs", Change: "changed type from number to list(string)", Breaking: true},
+		{Kind: InterfaceChangeKinds.Variable, Name: "dns", Change: "changed default from true to false"},
+		{Kind: InterfaceChangeKinds.Variable, Name: "region", Change: "became optional"},
+		{Kind: InterfaceChangeKinds.Variable, Name: "vpc_name", Change: "was added as required", Breaking: true},
+		{Kind: InterfaceChangeKinds.Variable, Name: "enabled", Change: "was added"},
+		{Kind: InterfaceChangeKinds.Variable, Name: "name", Change: "was removed", Breaking: true},
+		{Kind: InterfaceChangeKinds.Output, Name: "arn", Change: "was removed", Breaking: true},
+		{Kind: InterfaceChangeKinds.Output, Name: "vpc_id", Change: "was added"},
+		{Kind: InterfaceChangeKinds.Provider, Name: "aws", Change: "constraint narrowed from >= 3.0 to >= 4.0", Breaking: true},
+		{Kind: InterfaceChangeKinds.Provider, Name: "random", Change: "constraint changed from ~> 3.0 to >= 3.0"},
+		{Kind: InterfaceChangeKinds.Provider, Name: "tls", Change: "was added"},
+		{Kind: InterfaceChangeKinds.Provider, Name: "null", Change: "was removed"},
+	}, DiffInterfaces(from, to))
+
+	assert.Empty(t, DiffInterfaces(from, from))
+}
+
+func Test_constraintsNarrowed(t *testing.T) {
+	tests := []struct {
+		from     []string
+		to       []string
+		narrowed bool
+	}{
+		{from: []string{">= 3.0"}, to: []string{">= 3.5"}, narrowed: true},
+		{from: []string{">= 3.0"}, to: []string{"~> 3.0"}, narrowed: true},
+		{from: []string{"< 5.0"}, to: []string{"< 4.0"}, narrowed: true},
+		{from: []string{}, to: []string{">= 1.0"}, narrowed: true},
+		{from: []string{"~> 3.0"}, to: []string{"~> 3.0.1"}, narrowed: true},
+		{from: []string{">= 3.0, < 4.0"}, to: []string{">= 3.0, != 3.2.0, < 4.0"}, narrowed: true},
+		{from: []string{">= 3.5"}, to: []string{">= 3.0"}, narrowed: false},
+		{from: []string{"~> 3.0"}, to: []string{">= 3.0"}, narrowed: false},
+		{from: []string{">= 1.0"}, to: []string{}, narrowed: false},
+		{from: []string{"~> 3.0"}, to: []string{"~> 3"}, narrowed: false},
+		{from: []string{"not a constraint"}, to: []string{">= 3.0"}, narrowed: false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.narrowed, constraintsNarrowed(test.from, test.to), "%v to %v", test.from, test.to)
+	}
+}
+
+func Test_AllowsBreakingChanges(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		allowed bool
+	}{
+		{from: "1.0.0", to: "2.0.0", allowed: true},
+		{from: "1.0.0", to: "1.1.0", allowed: false},
+		{from: "1.0.0", to: "1.0.1", allowed: false},
+		{from: "0.1.0", to: "0.2.0", allowed: true},
+		{from: "0.1.0", to: "0.1.1", allowed: false},
+		{from: "0.9.0", to: "1.0.0", allowed: true},
+		{from: "1.0.0", to: "dev-main", allowed: true},
+		{from: "1.0.0", to: "1.1.0-rc.1", allowed: true},
+		{from: "1.1.0-rc.1", to: "1.1.0", allowed: false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.allowed, AllowsBreakingChanges(test.from, test.to), "%s to %s", test.from, test.to)
+	}
+}
@@ -0,0 +1,61 @@
+package registry
+
+// releaseVersion parses a version that is a release, dev versions and
+// prereleases are not.
+func releaseVersion(v string) (SemVer, bool) {
+	parsed, err := ParseSemVer(v)
+
+	if err != nil || parsed.IsPrerelease() {
+		return SemVer{}, false
+	}
+
+	return parsed, true
+}
+
+// LatestVersion is the highest release that is ready to download, a
+// prerelease is never the latest version.
+func LatestVersion(versions []ModuleVersion) (ModuleVersion, bool) {
+	var latest ModuleVersion
+	var latestParsed SemVer
+	found := false
+
+	for _, mv := range versions {
+		if mv.Status != VersionStatuses.Ready {
+			continue
+		}
+
+		parsed, ok := releaseVersion(mv.Version)
+
+		if !ok {
+			continue
+		}
+
+		if !found || latestParsed.LessThan(parsed) {
+			latest = mv
+			latestParsed = parsed
+			found = true
+		}
+	}
+
+	return latest, found
+}
+
+// PreviousRelease is the highest ready release lower than the version, which
+// is what the version's interface is compared against.
+func PreviousRelease(versions []ModuleVersion, version string) (ModuleVersion, bool) {
+	target, err := ParseSemVer(version)
+
+	if err != nil {
+		return ModuleVersion{}, false
+	}
+
+	lower := []ModuleVersion{}
+
+	for _, mv := range versions {
+		if parsed, ok := releaseVersion(mv.Version); ok && parsed.LessThan(target) {
+			lower = append(lower, mv)
+		}
+	}
+
+	return LatestVersion(lower)
+}
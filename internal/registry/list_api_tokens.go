@@ -0,0 +1,58 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type listAPITokensRepository interface {
+	AllTokens() ([]APIToken, error)
+}
+
+type listAPITokensV1Command struct{}
+
+type ListAPITokensV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	List       []APIToken
+}
+
+func (r ListAPITokensV1Response) GetActionName() string {
+	return "v1.tokens.list"
+}
+
+func (r ListAPITokensV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListAPITokensV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListAPITokensV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"total": len(r.List),
+	}
+}
+
+func (cmd listAPITokensV1Command) handle(r listAPITokensRepository, l zerolog.Logger) (ListAPITokensV1Response, error) {
+	occurred := time.Now().UTC()
+
+	tokens, err := r.AllTokens()
+
+	if err != nil {
+		l.Error().Err(err).Msg("error listing api tokens")
+
+		return ListAPITokensV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return ListAPITokensV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		List:       tokens,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type listGPGKeysRepository interface {
+	GPGKeysByNamespace(namespace string) ([]GPGKey, error)
+	AllGPGKeys() ([]GPGKey, error)
+}
+
+// ListGPGKeysV1DTO lists the keys of a single namespace, or of every
+// namespace when none is given.
+type ListGPGKeysV1DTO struct {
+	Namespace string `json:"namespace"`
+}
+
+type listGPGKeysV1Command struct {
+	DTO ListGPGKeysV1DTO
+}
+
+type ListGPGKeysV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	List       []GPGKey
+}
+
+func (r ListGPGKeysV1Response) GetActionName() string {
+	return "v1.providers.keys.list"
+}
+
+func (r ListGPGKeysV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListGPGKeysV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListGPGKeysV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"count": len(r.List),
+	}
+}
+
+func (cmd listGPGKeysV1Command) handle(r listGPGKeysRepository, logger zerolog.Logger) (ListGPGKeysV1Response, error) {
+	occurred := time.Now().UTC()
+
+	var keys []GPGKey
+	var err error
+
+	if cmd.DTO.Namespace != "" {
+		keys, err = r.GPGKeysByNamespace(cmd.DTO.Namespace)
+	} else {
+		keys, err = r.AllGPGKeys()
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Str("namespace", cmd.DTO.Namespace).Msg("failed to list gpg keys")
+
+		return ListGPGKeysV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return ListGPGKeysV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		List:       keys,
+	}, nil
+}
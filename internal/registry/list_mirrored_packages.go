@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type listMirroredPackagesRepository interface {
+	MirroredPackages(a ProviderSourceAddress, version string) ([]MirroredProviderPackage, error)
+}
+
+// ListMirroredPackagesV1DTO lists the packages of every version of the
+// provider, unless a version is given.
+type ListMirroredPackagesV1DTO struct {
+	Address ProviderSourceAddress
+	Version string
+}
+
+type listMirroredPackagesV1Command struct {
+	DTO ListMirroredPackagesV1DTO
+}
+
+type ListMirroredPackagesV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	List       []MirroredProviderPackage
+}
+
+func (r ListMirroredPackagesV1Response) GetActionName() string {
+	return "v1.mirror.providers.list"
+}
+
+func (r ListMirroredPackagesV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListMirroredPackagesV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListMirroredPackagesV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"count": len(r.List),
+	}
+}
+
+// Versions are the distinct versions of the packages, in the order they were
+// listed.
+func (r ListMirroredPackagesV1Response) Versions() []string {
+	versions := []string{}
+	seen := map[string]bool{}
+
+	for _, p := range r.List {
+		if !seen[p.Version] {
+			seen[p.Version] = true
+			versions = append(versions, p.Version)
+		}
+	}
+
+	return versions
+}
+
+func (cmd listMirroredPackagesV1Command) handle(r listMirroredPackagesRepository, logger zerolog.Logger) (ListMirroredPackagesV1Response, error) {
+	occurred := time.Now().UTC()
+	pkgs, err := r.MirroredPackages(cmd.DTO.Address, cmd.DTO.Version)
+
+	if err != nil {
+		logger.Error().Err(err).Str("provider", cmd.DTO.Address.String()).Str("version", cmd.DTO.Version).Msg("failed to list mirrored packages")
+
+		return ListMirroredPackagesV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if len(pkgs) == 0 {
+		return ListMirroredPackagesV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_NOT_FOUND,
+		}, nil
+	}
+
+	return ListMirroredPackagesV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		List:       pkgs,
+	}, nil
+}
@@ -1,15 +1,16 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 type listModuleVersionsRepository interface {
-	ByFQN(ModuleFQN) (m Module, err error)
-	ById(id string) (m Module, err error)
-	VersionsByModule(moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+	ById(ctx context.Context, id string) (m Module, err error)
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
 }
 
 type ListModuleVersionsV1DTO struct {
@@ -48,8 +49,8 @@ func (r ListModuleVersionsV1Response) GetAuditMeta() map[string]interface{} {
 	}
 }
 
-func (cmd listModuleVersionsV1Command) handle(r listModuleVersionsRepository, l zerolog.Logger) (ListModuleVersionsV1Response, error) {
-	module, err := r.ById(cmd.DTO.ModuleId)
+func (cmd listModuleVersionsV1Command) handle(ctx context.Context, r listModuleVersionsRepository, l zerolog.Logger) (ListModuleVersionsV1Response, error) {
+	module, err := r.ById(ctx, cmd.DTO.ModuleId)
 
 	occurred := time.Now().UTC()
 
@@ -71,7 +72,7 @@ func (cmd listModuleVersionsV1Command) handle(r listModuleVersionsRepository, l
 
 	var moduleVersions []ModuleVersion
 
-	moduleVersions, err = r.VersionsByModule(module.Id, ChunkingOptions{})
+	moduleVersions, err = r.VersionsByModule(ctx, module.Id, ChunkingOptions{})
 
 	if err != nil {
 		// We won't bother checking for ResourceNotFound errors here
@@ -1,14 +1,15 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 type listModuleVersionsByFqnRepository interface {
-	ByFQN(ModuleFQN) (m Module, err error)
-	VersionsByModule(moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
 }
 
 type ListModuleVersionsByFqnV1DTO struct {
@@ -19,8 +20,8 @@ type listModuleVersionsByFqnV1Command struct {
 	DTO ListModuleVersionsByFqnV1DTO
 }
 
-func (cmd listModuleVersionsByFqnV1Command) handle(r listModuleVersionsByFqnRepository, l zerolog.Logger) (ListModuleVersionsV1Response, error) {
-	module, err := r.ByFQN(cmd.DTO.FQN)
+func (cmd listModuleVersionsByFqnV1Command) handle(ctx context.Context, r listModuleVersionsByFqnRepository, l zerolog.Logger) (ListModuleVersionsV1Response, error) {
+	module, err := r.ByFQN(ctx, cmd.DTO.FQN)
 
 	occurred := time.Now().UTC()
 
@@ -42,7 +43,7 @@ func (cmd listModuleVersionsByFqnV1Command) handle(r listModuleVersionsByFqnRepo
 
 	var moduleVersions []ModuleVersion
 
-	moduleVersions, err = r.VersionsByModule(module.Id, ChunkingOptions{})
+	moduleVersions, err = r.VersionsByModule(ctx, module.Id, ChunkingOptions{})
 
 	if err != nil {
 		// We won't bother checking for ResourceNotFound errors here
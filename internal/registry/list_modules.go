@@ -1,19 +1,22 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 type listModulesRepository interface {
-	All(chunkOpts ChunkingOptions, filters ModuleFilters) ([]Module, error)
+	All(ctx context.Context, chunkOpts ChunkingOptions, filters ModuleFilters) ([]Module, error)
 }
 
 type ListModulesV1DTO struct {
 	Namespace string
 	Provider  string
-	ChunkOpts ChunkingOptions
+	// Set by the bus to the namespaces the actor may read
+	Namespaces []string
+	ChunkOpts  ChunkingOptions
 }
 
 type listModulesV1Command struct {
@@ -44,13 +47,14 @@ func (r ListModulesV1Response) GetAuditMeta() map[string]interface{} {
 	}
 }
 
-func (cmd listModulesV1Command) handle(r listModulesRepository, l zerolog.Logger) (ListModulesV1Response, error) {
+func (cmd listModulesV1Command) handle(ctx context.Context, r listModulesRepository, l zerolog.Logger) (ListModulesV1Response, error) {
 	occurred := time.Now().UTC()
 
 	// empty chunking opts as not used by repo yet
-	modules, err := r.All(cmd.DTO.ChunkOpts, ModuleFilters{
-		Provider:  cmd.DTO.Provider,
-		Namespace: cmd.DTO.Namespace,
+	modules, err := r.All(ctx, cmd.DTO.ChunkOpts, ModuleFilters{
+		Provider:   cmd.DTO.Provider,
+		Namespace:  cmd.DTO.Namespace,
+		Namespaces: cmd.DTO.Namespaces,
 	})
 
 	if err != nil {
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type listNamespacesRepository interface {
+	AllNamespaces() ([]Namespace, error)
+	GrantsByNamespace(namespace string) ([]RoleGrant, error)
+}
+
+type listNamespacesV1Command struct{}
+
+type ListNamespacesV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	List       []Namespace
+}
+
+func (r ListNamespacesV1Response) GetActionName() string {
+	return "v1.namespaces.list"
+}
+
+func (r ListNamespacesV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListNamespacesV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListNamespacesV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"total": len(r.List),
+	}
+}
+
+// owners is the admin grants of the namespace.
+func owners(grants []RoleGrant) []RoleGrant {
+	o := []RoleGrant{}
+
+	for _, g := range grants {
+		if g.Role == Roles.Admin {
+			o = append(o, g)
+		}
+	}
+
+	return o
+}
+
+// handle lists the namespaces that are permitted, every namespace when
+// permitted is nil.
+func (cmd listNamespacesV1Command) handle(r listNamespacesRepository, permitted map[string]bool, l zerolog.Logger) (ListNamespacesV1Response, error) {
+	occurred := time.Now().UTC()
+
+	namespaces, err := r.AllNamespaces()
+
+	if err != nil {
+		l.Error().Err(err).Msg("error listing namespaces")
+
+		return ListNamespacesV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	list := []Namespace{}
+
+	for _, n := range namespaces {
+		if permitted != nil && !permitted[n.Name] {
+			continue
+		}
+
+		grants, err := r.GrantsByNamespace(n.Name)
+
+		if err != nil {
+			l.Error().Err(err).Str("namespace", n.Name).Msg("error listing namespace grants")
+
+			return ListNamespacesV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		n.Owners = owners(grants)
+		list = append(list, n)
+	}
+
+	return ListNamespacesV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		List:       list,
+	}, nil
+}
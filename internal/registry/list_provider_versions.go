@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type listProviderVersionsRepository interface {
+	ProviderByFQN(fqn ProviderFQN) (p Provider, err error)
+	VersionsByProvider(providerId string) ([]ProviderVersion, error)
+}
+
+type ListProviderVersionsV1DTO struct {
+	FQN ProviderFQN
+}
+
+type listProviderVersionsV1Command struct {
+	DTO ListProviderVersionsV1DTO
+}
+
+type ListProviderVersionsV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	Provider   Provider
+	List       []ProviderVersion
+}
+
+func (r ListProviderVersionsV1Response) GetActionName() string {
+	return "v1.providers.versions.list"
+}
+
+func (r ListProviderVersionsV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListProviderVersionsV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListProviderVersionsV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"provider_id": r.Provider.Id,
+		"count":       len(r.List),
+	}
+}
+
+func (cmd listProviderVersionsV1Command) handle(r listProviderVersionsRepository, logger zerolog.Logger) (ListProviderVersionsV1Response, error) {
+	occurred := time.Now().UTC()
+	p, err := r.ProviderByFQN(cmd.DTO.FQN)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return ListProviderVersionsV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("provider", cmd.DTO.FQN.String()).Msg("failed to find provider")
+
+		return ListProviderVersionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	pvs, err := r.VersionsByProvider(p.Id)
+
+	if err != nil {
+		logger.Error().Err(err).Str("provider", cmd.DTO.FQN.String()).Msg("failed to list provider versions")
+
+		return ListProviderVersionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return ListProviderVersionsV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Provider:   p,
+		List:       pvs,
+	}, nil
+}
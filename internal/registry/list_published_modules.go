@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type listPublishedModulesRepository interface {
+	All(ctx context.Context, chunkOpts ChunkingOptions, filters ModuleFilters) ([]Module, error)
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+}
+
+// PublishedModule is a module as consumers see it, at its latest version.
+type PublishedModule struct {
+	Module   Module        `json:"module"`
+	Latest   ModuleVersion `json:"latest"`
+	Versions []string      `json:"versions"`
+}
+
+// publishedModule is false for modules without a version that is ready to
+// download.
+func publishedModule(m Module, versions []ModuleVersion) (PublishedModule, bool) {
+	latest, ok := LatestVersion(versions)
+
+	if !ok {
+		return PublishedModule{}, false
+	}
+
+	p := PublishedModule{
+		Module:   m,
+		Latest:   latest,
+		Versions: []string{},
+	}
+
+	for _, mv := range versions {
+		if mv.Status == VersionStatuses.Ready {
+			p.Versions = append(p.Versions, mv.Version)
+		}
+	}
+
+	return p, true
+}
+
+type ListPublishedModulesV1DTO struct {
+	Namespace string
+	Provider  string
+	Query     string
+	ChunkOpts ChunkingOptions
+}
+
+type listPublishedModulesV1Command struct {
+	DTO ListPublishedModulesV1DTO
+}
+
+type ListPublishedModulesV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	List       []PublishedModule
+	// Whether there are more modules after this page
+	HasMore bool
+}
+
+func (r ListPublishedModulesV1Response) GetActionName() string {
+	return "v1.modules.published.list"
+}
+
+func (r ListPublishedModulesV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListPublishedModulesV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListPublishedModulesV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"total": len(r.List),
+	}
+}
+
+func (cmd listPublishedModulesV1Command) handle(ctx context.Context, r listPublishedModulesRepository, l zerolog.Logger) (ListPublishedModulesV1Response, error) {
+	occurred := time.Now().UTC()
+	chunkOpts := cmd.DTO.ChunkOpts
+
+	// One more than the page is fetched to tell whether there's another page
+	if chunkOpts.Size > 0 {
+		chunkOpts.Size++
+	}
+
+	modules, err := r.All(ctx, chunkOpts, ModuleFilters{
+		Provider:  cmd.DTO.Provider,
+		Namespace: cmd.DTO.Namespace,
+		Query:     cmd.DTO.Query,
+		Published: true,
+	})
+
+	if err != nil {
+		l.Error().Str("provider-filter", cmd.DTO.Provider).Str("namespace-filter", cmd.DTO.Namespace).Str("query", cmd.DTO.Query).Err(err).Msg("error listing published modules")
+
+		return ListPublishedModulesV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	hasMore := cmd.DTO.ChunkOpts.Size > 0 && len(modules) > cmd.DTO.ChunkOpts.Size
+
+	if hasMore {
+		modules = modules[:cmd.DTO.ChunkOpts.Size]
+	}
+
+	list := []PublishedModule{}
+
+	for _, m := range modules {
+		versions, err := r.VersionsByModule(ctx, m.Id, ChunkingOptions{})
+
+		if err != nil {
+			l.Error().Str("fqn", m.FQN().String()).Err(err).Msg("error listing module versions")
+
+			return ListPublishedModulesV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		if p, ok := publishedModule(m, versions); ok {
+			list = append(list, p)
+		}
+	}
+
+	return ListPublishedModulesV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		List:       list,
+		HasMore:    hasMore,
+	}, nil
+}
@@ -0,0 +1,227 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakePublishedModulesRepository struct {
+	modules  []Module
+	versions map[string][]ModuleVersion
+	chunks   []ChunkingOptions
+}
+
+func (r *fakePublishedModulesRepository) All(ctx context.Context, chunkOpts ChunkingOptions, f ModuleFilters) ([]Module, error) {
+	r.chunks = append(r.chunks, chunkOpts)
+	ms := []Module{}
+
+	for _, m := range r.modules {
+		if _, ok := LatestVersion(r.versions[m.Id]); f.Published && !ok {
+			continue
+		}
+
+		ms = append(ms, m)
+	}
+
+	if chunkOpts.Offset >= len(ms) {
+		return []Module{}, nil
+	}
+
+	ms = ms[chunkOpts.Offset:]
+
+	if chunkOpts.Size > 0 && chunkOpts.Size < len(ms) {
+		ms = ms[:chunkOpts.Size]
+	}
+
+	return ms, nil
+}
+
+func (r *fakePublishedModulesRepository) VersionsByModule(ctx context.Context, moduleId string, _ ChunkingOptions) ([]ModuleVersion, error) {
+	return r.versions[moduleId], nil
+}
+
+func (r *fakePublishedModulesRepository) ByFQN(ctx context.Context, fqn ModuleFQN) (Module, error) {
+	for _, m := range r.modules {
+		if m.FQN() == fqn {
+			return m, nil
+		}
+	}
+
+	return Module{}, ErrResourceNotFound{Type: "Module", URI: fqn.String()}
+}
+
+func buildPublishedModulesRepository() *fakePublishedModulesRepository {
+	ready := VersionStatuses.Ready
+
+	return &fakePublishedModulesRepository{
+		modules: []Module{
+			{Id: "a", Namespace: "platform", Name: "network", Provider: "aws"},
+			{Id: "b", Namespace: "platform", Name: "pending", Provider: "aws"},
+			{Id: "c", Namespace: "platform", Name: "storage", Provider: "aws"},
+			{Id: "d", Namespace: "platform", Name: "vpc", Provider: "aws"},
+		},
+		versions: map[string][]ModuleVersion{
+			"a": {
+				{Version: "1.2.0", Status: ready},
+				{Version: "1.10.0", Status: ready},
+				{Version: "2.0.0", Status: VersionStatuses.Pending},
+				{Version: "dev-main", Status: ready},
+			},
+			"b": {
+				{Version: "0.1.0", Status: VersionStatuses.Failed},
+			},
+			"c": {
+				{Version: "0.1.0", Status: ready},
+			},
+			"d": {
+				{Version: "3.0.0", Status: ready},
+			},
+		},
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	r := buildPublishedModulesRepository()
+
+	latest, ok := LatestVersion(r.versions["a"])
+
+	assert.True(t, ok)
+	assert.Equal(t, "1.10.0", latest.Version)
+
+	_, ok = LatestVersion(r.versions["b"])
+
+	assert.False(t, ok)
+
+	_, ok = LatestVersion([]ModuleVersion{{Version: "dev-main", Status: VersionStatuses.Ready}})
+
+	assert.False(t, ok)
+
+	latest, ok = LatestVersion([]ModuleVersion{
+		{Version: "1.2.0", Status: VersionStatuses.Ready},
+		{Version: "1.3.0-rc.1", Status: VersionStatuses.Ready},
+		{Version: "1.2.1+build.5", Status: VersionStatuses.Ready},
+	})
+
+	assert.True(t, ok)
+	assert.Equal(t, "1.2.1+build.5", latest.Version)
+
+	_, ok = LatestVersion([]ModuleVersion{{Version: "1.0.0-beta", Status: VersionStatuses.Ready}})
+
+	assert.False(t, ok)
+}
+
+func TestPreviousRelease(t *testing.T) {
+	versions := []ModuleVersion{
+		{Version: "1.0.0", Status: VersionStatuses.Ready},
+		{Version: "1.1.0-rc.1", Status: VersionStatuses.Ready},
+		{Version: "1.1.0", Status: VersionStatuses.Ready},
+		{Version: "1.2.0", Status: VersionStatuses.Failed},
+		{Version: "2.0.0", Status: VersionStatuses.Ready},
+	}
+
+	for version, expected := range map[string]string{
+		"1.1.1":      "1.1.0",
+		"1.1.0":      "1.0.0",
+		"1.1.0-rc.2": "1.0.0",
+		"1.3.0":      "1.1.0",
+		"3.0.0":      "2.0.0",
+	} {
+		prev, ok := PreviousRelease(versions, version)
+
+		assert.True(t, ok, version)
+		assert.Equal(t, expected, prev.Version, version)
+	}
+
+	_, ok := PreviousRelease(versions, "1.0.0")
+	assert.False(t, ok)
+
+	_, ok = PreviousRelease(versions, "dev-main")
+	assert.False(t, ok)
+}
+
+func Test_listPublishedModulesV1Command_handle(t *testing.T) {
+	tests := []struct {
+		name            string
+		chunkOpts       ChunkingOptions
+		expectedLatest  []string
+		expectedHasMore bool
+	}{
+		{
+			name:           "unpaged",
+			expectedLatest: []string{"network@1.10.0", "storage@0.1.0", "vpc@3.0.0"},
+		},
+		{
+			name:            "first page",
+			chunkOpts:       ChunkingOptions{Size: 2},
+			expectedLatest:  []string{"network@1.10.0", "storage@0.1.0"},
+			expectedHasMore: true,
+		},
+		{
+			name:           "last page",
+			chunkOpts:      ChunkingOptions{Size: 2, Offset: 2},
+			expectedLatest: []string{"vpc@3.0.0"},
+		},
+		{
+			name:           "past the end",
+			chunkOpts:      ChunkingOptions{Size: 2, Offset: 10},
+			expectedLatest: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := buildPublishedModulesRepository()
+
+			cmd := listPublishedModulesV1Command{
+				DTO: ListPublishedModulesV1DTO{
+					ChunkOpts: test.chunkOpts,
+				},
+			}
+
+			res, err := cmd.handle(context.Background(), repo, l)
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, STATUS_OKAY, res.Status)
+			assert.Equal(tt, test.expectedHasMore, res.HasMore)
+
+			latest := []string{}
+			for _, p := range res.List {
+				latest = append(latest, p.Module.Name+"@"+p.Latest.Version)
+			}
+
+			assert.Equal(tt, test.expectedLatest, latest)
+		})
+	}
+}
+
+func Test_showPublishedModuleV1Command_handle(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := buildPublishedModulesRepository()
+
+	res, err := showPublishedModuleV1Command{
+		DTO: ShowPublishedModuleV1DTO{
+			FQN: ModuleFQN{Namespace: "platform", Name: "network", Provider: "aws"},
+		},
+	}.handle(context.Background(), repo, l)
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_OKAY, res.Status)
+	assert.Equal(t, "1.10.0", res.Module.Latest.Version)
+	assert.Equal(t, []string{"1.2.0", "1.10.0", "dev-main"}, res.Module.Versions)
+
+	for _, name := range []string{"pending", "missing"} {
+		res, err = showPublishedModuleV1Command{
+			DTO: ShowPublishedModuleV1DTO{
+				FQN: ModuleFQN{Namespace: "platform", Name: name, Provider: "aws"},
+			},
+		}.handle(context.Background(), repo, l)
+
+		assert.Nil(t, err)
+		assert.Equal(t, STATUS_NOT_FOUND, res.Status, name)
+	}
+}
@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type listTeamsRepository interface {
+	AllTeams() ([]Team, error)
+	TeamMembers(teamId string) ([]User, error)
+}
+
+type listTeamsV1Command struct{}
+
+type ListTeamsV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	List       []Team
+}
+
+func (r ListTeamsV1Response) GetActionName() string {
+	return "v1.teams.list"
+}
+
+func (r ListTeamsV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListTeamsV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListTeamsV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"total": len(r.List),
+	}
+}
+
+func (cmd listTeamsV1Command) handle(r listTeamsRepository, l zerolog.Logger) (ListTeamsV1Response, error) {
+	occurred := time.Now().UTC()
+
+	teams, err := r.AllTeams()
+
+	if err != nil {
+		l.Error().Err(err).Msg("error listing teams")
+
+		return ListTeamsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	for i, t := range teams {
+		members, err := r.TeamMembers(t.Id)
+
+		if err != nil {
+			l.Error().Err(err).Str("team", t.Name).Msg("error listing team members")
+
+			return ListTeamsV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INTERNAL_ERROR,
+			}, err
+		}
+
+		teams[i].Members = members
+	}
+
+	return ListTeamsV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		List:       teams,
+	}, nil
+}
@@ -0,0 +1,157 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type upstreamRegistry interface {
+	ModuleVersions(baseURL string, fqn ModuleFQN) ([]string, error)
+	ModuleSource(baseURL string, fqn ModuleVersionFQN) (string, error)
+	FetchSource(source string) (UpstreamArchive, error)
+}
+
+type listUpstreamModuleVersionsRepository interface {
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+	UpstreamModuleByFQN(upstreamURL string, fqn ModuleFQN) (m UpstreamModule, err error)
+	SaveUpstreamModule(UpstreamModule) (m UpstreamModule, err error)
+}
+
+type ListUpstreamModuleVersionsV1DTO struct {
+	FQN ModuleFQN
+}
+
+type listUpstreamModuleVersionsV1Command struct {
+	DTO ListUpstreamModuleVersionsV1DTO
+}
+
+type ListUpstreamModuleVersionsV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	Module     UpstreamModule
+}
+
+func (r ListUpstreamModuleVersionsV1Response) GetActionName() string {
+	return "v1.upstream.modules.versions.list"
+}
+
+func (r ListUpstreamModuleVersionsV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListUpstreamModuleVersionsV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListUpstreamModuleVersionsV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"upstream_module_id": r.Module.Id,
+		"count":              len(r.Module.Versions),
+	}
+}
+
+// handle is NOT_FOUND for modules that exist locally, or in a namespace that
+// isn't proxied, they are never looked up upstream. A cached list is used
+// until it expires, and after that only if the upstream can't be reached.
+func (cmd listUpstreamModuleVersionsV1Command) handle(ctx context.Context, upstreams []Upstream, client upstreamRegistry, r listUpstreamModuleVersionsRepository, logger zerolog.Logger) (ListUpstreamModuleVersionsV1Response, error) {
+	occurred := time.Now().UTC()
+	fqn := cmd.DTO.FQN
+	u, ok := UpstreamFor(upstreams, fqn.Namespace)
+
+	if !ok {
+		return ListUpstreamModuleVersionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_NOT_FOUND,
+		}, nil
+	}
+
+	internalError := func(err error, msg string) (ListUpstreamModuleVersionsV1Response, error) {
+		logger.Error().Err(err).Str("fqn", fqn.String()).Str("upstream", u.URL).Msg(msg)
+
+		return ListUpstreamModuleVersionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if _, err := r.ByFQN(ctx, fqn); err == nil {
+		return ListUpstreamModuleVersionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_NOT_FOUND,
+		}, nil
+	} else if _, ok := err.(ErrResourceNotFound); !ok {
+		return internalError(err, "failed to look up local module")
+	}
+
+	cached, err := r.UpstreamModuleByFQN(u.URL, fqn)
+	_, notCached := err.(ErrResourceNotFound)
+
+	if err != nil && !notCached {
+		return internalError(err, "failed to look up cached upstream module")
+	}
+
+	if !notCached && !cached.Expired(u.TTL, occurred) {
+		return ListUpstreamModuleVersionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_OKAY,
+			Module:     cached,
+		}, nil
+	}
+
+	versions, err := client.ModuleVersions(u.URL, fqn)
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		return ListUpstreamModuleVersionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_NOT_FOUND,
+		}, nil
+	}
+
+	if err != nil {
+		if notCached {
+			logger.Error().Err(err).Str("fqn", fqn.String()).Str("upstream", u.URL).Msg("failed to list upstream module versions")
+
+			return ListUpstreamModuleVersionsV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_FAILED,
+			}, nil
+		}
+
+		logger.Warn().Err(err).Str("fqn", fqn.String()).Str("upstream", u.URL).Msg("upstream unavailable, serving expired versions")
+
+		return ListUpstreamModuleVersionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_OKAY,
+			Module:     cached,
+		}, nil
+	}
+
+	m := UpstreamModule{
+		Id:          uuid.New().String(),
+		UpstreamURL: u.URL,
+		Namespace:   fqn.Namespace,
+		Name:        fqn.Name,
+		Provider:    fqn.Provider,
+		Versions:    versions,
+		FetchedAt:   occurred,
+	}
+
+	if !notCached {
+		m.Id = cached.Id
+	}
+
+	m, err = r.SaveUpstreamModule(m)
+
+	if err != nil {
+		return internalError(err, "failed to cache upstream module versions")
+	}
+
+	return ListUpstreamModuleVersionsV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Module:     m,
+	}, nil
+}
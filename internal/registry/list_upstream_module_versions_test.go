@@ -0,0 +1,262 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeUpstreamRegistry struct {
+	versions   map[string][]string
+	sources    map[string]string
+	archives   map[string]string
+	err        error
+	listCalls  int
+	fetchCalls int
+}
+
+func (c *fakeUpstreamRegistry) ModuleVersions(baseURL string, fqn ModuleFQN) ([]string, error) {
+	c.listCalls++
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	versions, ok := c.versions[fqn.String()]
+
+	if !ok {
+		return nil, ErrResourceNotFound{Type: "UpstreamModule", URI: fqn.String()}
+	}
+
+	return versions, nil
+}
+
+func (c *fakeUpstreamRegistry) ModuleSource(baseURL string, fqn ModuleVersionFQN) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+
+	source, ok := c.sources[fqn.String()]
+
+	if !ok {
+		return "", ErrResourceNotFound{Type: "UpstreamModuleVersion", URI: fqn.String()}
+	}
+
+	return source, nil
+}
+
+func (c *fakeUpstreamRegistry) FetchSource(source string) (UpstreamArchive, error) {
+	c.fetchCalls++
+
+	content, ok := c.archives[source]
+
+	if !ok {
+		return UpstreamArchive{}, errors.New("source unavailable")
+	}
+
+	return UpstreamArchive{
+		Content:   io.NopCloser(strings.NewReader(content)),
+		Extension: ".tar.gz",
+		Subdir:    "modules/agent",
+	}, nil
+}
+
+type fakeUpstreamRepository struct {
+	local    []Module
+	modules  []UpstreamModule
+	versions []UpstreamModuleVersion
+}
+
+func (r *fakeUpstreamRepository) ByFQN(ctx context.Context, fqn ModuleFQN) (Module, error) {
+	for _, m := range r.local {
+		if m.Namespace == fqn.Namespace && m.Name == fqn.Name && m.Provider == fqn.Provider {
+			return m, nil
+		}
+	}
+
+	return Module{}, ErrResourceNotFound{Type: "Module", URI: fqn.String()}
+}
+
+func (r *fakeUpstreamRepository) UpstreamModuleByFQN(upstreamURL string, fqn ModuleFQN) (UpstreamModule, error) {
+	for _, m := range r.modules {
+		if m.UpstreamURL == upstreamURL && m.FQN() == fqn {
+			return m, nil
+		}
+	}
+
+	return UpstreamModule{}, ErrResourceNotFound{Type: "UpstreamModule", URI: fqn.String()}
+}
+
+func (r *fakeUpstreamRepository) SaveUpstreamModule(m UpstreamModule) (UpstreamModule, error) {
+	for i, existing := range r.modules {
+		if existing.UpstreamURL == m.UpstreamURL && existing.FQN() == m.FQN() {
+			m.Id = existing.Id
+			r.modules[i] = m
+
+			return m, nil
+		}
+	}
+
+	r.modules = append(r.modules, m)
+
+	return m, nil
+}
+
+func (r *fakeUpstreamRepository) UpstreamModuleVersion(upstreamModuleId string, version string) (UpstreamModuleVersion, error) {
+	for _, mv := range r.versions {
+		if mv.UpstreamModuleId == upstreamModuleId && mv.Version == version {
+			return mv, nil
+		}
+	}
+
+	return UpstreamModuleVersion{}, ErrResourceNotFound{Type: "UpstreamModuleVersion", URI: version}
+}
+
+func (r *fakeUpstreamRepository) AddUpstreamModuleVersion(mv UpstreamModuleVersion) (UpstreamModuleVersion, error) {
+	r.versions = append(r.versions, mv)
+
+	return mv, nil
+}
+
+var testUpstreams = []Upstream{
+	{
+		URL:        "https://registry.example.com",
+		Namespaces: []string{"hashicorp"},
+		TTL:        time.Hour,
+	},
+}
+
+func consulFQN() ModuleFQN {
+	return ModuleFQN{
+		Namespace: "hashicorp",
+		Name:      "consul",
+		Provider:  "aws",
+	}
+}
+
+func Test_listUpstreamModuleVersionsV1Command_handle(t *testing.T) {
+	cachedModule := func(fetchedAt time.Time) UpstreamModule {
+		return UpstreamModule{
+			Id:          "0b4a6c2e-55a4-4bde-8f0e-9a3a3a1a7c10",
+			UpstreamURL: "https://registry.example.com",
+			Namespace:   "hashicorp",
+			Name:        "consul",
+			Provider:    "aws",
+			Versions:    []string{"0.1.0"},
+			FetchedAt:   fetchedAt,
+		}
+	}
+
+	tests := []struct {
+		name              string
+		fqn               ModuleFQN
+		local             []Module
+		cached            []UpstreamModule
+		err               error
+		expectedStatus    RegistryHandlerStatus
+		expectedVersions  []string
+		expectedListCalls int
+	}{
+		{
+			name:              "fetches and caches versions",
+			fqn:               consulFQN(),
+			expectedStatus:    STATUS_OKAY,
+			expectedVersions:  []string{"0.1.0", "0.2.0"},
+			expectedListCalls: 1,
+		},
+		{
+			name:              "serves versions from the cache until they expire",
+			fqn:               consulFQN(),
+			cached:            []UpstreamModule{cachedModule(time.Now().UTC().Add(-time.Minute))},
+			expectedStatus:    STATUS_OKAY,
+			expectedVersions:  []string{"0.1.0"},
+			expectedListCalls: 0,
+		},
+		{
+			name:              "fetches versions again once expired",
+			fqn:               consulFQN(),
+			cached:            []UpstreamModule{cachedModule(time.Now().UTC().Add(-2 * time.Hour))},
+			expectedStatus:    STATUS_OKAY,
+			expectedVersions:  []string{"0.1.0", "0.2.0"},
+			expectedListCalls: 1,
+		},
+		{
+			name:              "serves expired versions when the upstream is unavailable",
+			fqn:               consulFQN(),
+			cached:            []UpstreamModule{cachedModule(time.Now().UTC().Add(-2 * time.Hour))},
+			err:               errors.New("connection refused"),
+			expectedStatus:    STATUS_OKAY,
+			expectedVersions:  []string{"0.1.0"},
+			expectedListCalls: 1,
+		},
+		{
+			name:              "fails when the upstream is unavailable and nothing is cached",
+			fqn:               consulFQN(),
+			err:               errors.New("connection refused"),
+			expectedStatus:    STATUS_FAILED,
+			expectedListCalls: 1,
+		},
+		{
+			name:              "not found upstream",
+			fqn:               ModuleFQN{Namespace: "hashicorp", Name: "missing", Provider: "aws"},
+			expectedStatus:    STATUS_NOT_FOUND,
+			expectedListCalls: 1,
+		},
+		{
+			name:              "namespace is not proxied",
+			fqn:               ModuleFQN{Namespace: "platform", Name: "consul", Provider: "aws"},
+			expectedStatus:    STATUS_NOT_FOUND,
+			expectedListCalls: 0,
+		},
+		{
+			name:              "local modules are never proxied",
+			fqn:               consulFQN(),
+			local:             []Module{{Namespace: "hashicorp", Name: "consul", Provider: "aws"}},
+			expectedStatus:    STATUS_NOT_FOUND,
+			expectedListCalls: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			client := &fakeUpstreamRegistry{
+				versions: map[string][]string{
+					consulFQN().String(): {"0.1.0", "0.2.0"},
+				},
+				err: test.err,
+			}
+			repo := &fakeUpstreamRepository{
+				local:   test.local,
+				modules: test.cached,
+			}
+
+			cmd := listUpstreamModuleVersionsV1Command{
+				DTO: ListUpstreamModuleVersionsV1DTO{
+					FQN: test.fqn,
+				},
+			}
+
+			res, err := cmd.handle(context.Background(), testUpstreams, client, repo, l)
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+			assert.Equal(tt, test.expectedListCalls, client.listCalls)
+
+			if res.Status != STATUS_OKAY {
+				return
+			}
+
+			assert.Equal(tt, test.expectedVersions, res.Module.Versions)
+			assert.Len(tt, repo.modules, 1)
+			assert.Equal(tt, repo.modules[0].Id, res.Module.Id)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type listUsersRepository interface {
+	AllUsers() ([]User, error)
+}
+
+type listUsersV1Command struct{}
+
+type ListUsersV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	List       []User
+}
+
+func (r ListUsersV1Response) GetActionName() string {
+	return "v1.users.list"
+}
+
+func (r ListUsersV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListUsersV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListUsersV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"total": len(r.List),
+	}
+}
+
+func (cmd listUsersV1Command) handle(r listUsersRepository, l zerolog.Logger) (ListUsersV1Response, error) {
+	occurred := time.Now().UTC()
+
+	users, err := r.AllUsers()
+
+	if err != nil {
+		l.Error().Err(err).Msg("error listing users")
+
+		return ListUsersV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return ListUsersV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		List:       users,
+	}, nil
+}
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type listWebhookDeliveriesRepository interface {
+	SubscriptionById(id string) (s WebhookSubscription, err error)
+	DeliveriesBySubscription(subscriptionId string, chunkOpts ChunkingOptions) ([]WebhookDelivery, error)
+}
+
+type listWebhookDeliveriesV1CommandValidator interface {
+	Validate(cmd interface{}) []ValidationError
+}
+
+type ListWebhookDeliveriesV1DTO struct {
+	SubscriptionId string `validate:"required,uuid"`
+	ChunkOpts      ChunkingOptions
+}
+
+type listWebhookDeliveriesV1Command struct {
+	DTO ListWebhookDeliveriesV1DTO
+}
+
+type ListWebhookDeliveriesV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	List             []WebhookDelivery
+	ValidationErrors []ValidationError
+}
+
+func (r ListWebhookDeliveriesV1Response) GetActionName() string {
+	return "v1.webhooks.deliveries.list"
+}
+
+func (r ListWebhookDeliveriesV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListWebhookDeliveriesV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListWebhookDeliveriesV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"total":             len(r.List),
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (cmd listWebhookDeliveriesV1Command) handle(r listWebhookDeliveriesRepository, logger zerolog.Logger, v listWebhookDeliveriesV1CommandValidator) (ListWebhookDeliveriesV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return ListWebhookDeliveriesV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	if _, err := r.SubscriptionById(cmd.DTO.SubscriptionId); err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return ListWebhookDeliveriesV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("subscription_id", cmd.DTO.SubscriptionId).Msg("failed to find webhook subscription")
+
+		return ListWebhookDeliveriesV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	deliveries, err := r.DeliveriesBySubscription(cmd.DTO.SubscriptionId, cmd.DTO.ChunkOpts)
+
+	if err != nil {
+		logger.Error().Err(err).Str("subscription_id", cmd.DTO.SubscriptionId).Msg("error listing webhook deliveries")
+
+		return ListWebhookDeliveriesV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return ListWebhookDeliveriesV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		List:       deliveries,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type listWebhookSubscriptionsRepository interface {
+	AllSubscriptions() ([]WebhookSubscription, error)
+}
+
+type listWebhookSubscriptionsV1Command struct{}
+
+type ListWebhookSubscriptionsV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	List       []WebhookSubscription
+}
+
+func (r ListWebhookSubscriptionsV1Response) GetActionName() string {
+	return "v1.webhooks.subscriptions.list"
+}
+
+func (r ListWebhookSubscriptionsV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ListWebhookSubscriptionsV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ListWebhookSubscriptionsV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"total": len(r.List),
+	}
+}
+
+func (cmd listWebhookSubscriptionsV1Command) handle(r listWebhookSubscriptionsRepository, l zerolog.Logger) (ListWebhookSubscriptionsV1Response, error) {
+	occurred := time.Now().UTC()
+
+	subs, err := r.AllSubscriptions()
+
+	if err != nil {
+		l.Error().Err(err).Msg("error listing webhook subscriptions")
+
+		return ListWebhookSubscriptionsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	redacted := []WebhookSubscription{}
+
+	for _, s := range subs {
+		redacted = append(redacted, s.Redacted())
+	}
+
+	return ListWebhookSubscriptionsV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		List:       redacted,
+	}, nil
+}
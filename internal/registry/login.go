@@ -0,0 +1,138 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// LoginClientId is the OAuth client terraform logs in as.
+const LoginClientId = "terraform-cli"
+
+// authorizationCodeTTL is how long terraform has to exchange a code for a
+// token, it does so as soon as the browser is redirected back to it.
+const authorizationCodeTTL = time.Minute
+
+// LoginOptions configure `terraform login`, the tokens it issues never expire
+// without a TokenTTL.
+type LoginOptions struct {
+	// The first and last loopback port terraform may listen on for the
+	// redirect
+	Ports    [2]int
+	TokenTTL time.Duration
+}
+
+func (o LoginOptions) portRange() string {
+	return fmt.Sprintf("%d-%d", o.Ports[0], o.Ports[1])
+}
+
+// allowsRedirect is true for the loopback URLs terraform listens on, plain
+// http is fine as the request never leaves the machine.
+func (o LoginOptions) allowsRedirect(uri string) bool {
+	u, err := url.Parse(uri)
+
+	if err != nil || u.Scheme != "http" || u.User != nil {
+		return false
+	}
+
+	host := u.Hostname()
+
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return false
+	}
+
+	port, err := strconv.Atoi(u.Port())
+
+	return err == nil && port >= o.Ports[0] && port <= o.Ports[1]
+}
+
+// AuthorizationCode is issued once a user logs in, terraform exchanges it
+// for a token by proving it started the login, with the PKCE verifier that
+// matches the challenge.
+type AuthorizationCode struct {
+	Hash          string
+	UserId        string
+	RedirectURI   string
+	CodeChallenge string
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+// pkceChallenge is the S256 challenge for a PKCE verifier.
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// LoginRequestV1DTO is the authorization request terraform opens in the
+// browser, it is carried through the login form.
+type LoginRequestV1DTO struct {
+	ClientId            string `validate:"required"`
+	RedirectURI         string `validate:"required"`
+	ResponseType        string `validate:"required"`
+	State               string
+	CodeChallenge       string `validate:"required"`
+	CodeChallengeMethod string `validate:"required"`
+}
+
+type loginRequestV1CommandValidator interface {
+	RegisterStructLevelValidator(f validator.StructLevelFunc, t interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+// registerLoginRequestValidator checks the request is one terraform would
+// make, anything else can't complete the flow.
+func registerLoginRequestValidator(o LoginOptions, v loginRequestV1CommandValidator) {
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		dto := sl.Current().Interface().(LoginRequestV1DTO)
+
+		if dto.ClientId != "" && dto.ClientId != LoginClientId {
+			sl.ReportError(dto.ClientId, "ClientId", "ClientId", loginClientTag, LoginClientId)
+		}
+
+		if dto.RedirectURI != "" && !o.allowsRedirect(dto.RedirectURI) {
+			sl.ReportError(dto.RedirectURI, "RedirectURI", "RedirectURI", loopbackRedirectTag, o.portRange())
+		}
+
+		if dto.ResponseType != "" && dto.ResponseType != "code" {
+			sl.ReportError(dto.ResponseType, "ResponseType", "ResponseType", responseTypeTag, "code")
+		}
+
+		if dto.CodeChallengeMethod != "" && dto.CodeChallengeMethod != "S256" {
+			sl.ReportError(dto.CodeChallengeMethod, "CodeChallengeMethod", "CodeChallengeMethod", pkceMethodTag, "S256")
+		}
+	}, LoginRequestV1DTO{})
+}
+
+type validateLoginRequestV1Command struct {
+	DTO LoginRequestV1DTO
+}
+
+type ValidateLoginRequestV1Response struct {
+	Status           RegistryHandlerStatus
+	ValidationErrors []ValidationError
+}
+
+// handle checks the request before the login form is shown, so a user isn't
+// asked for a password that can't be used.
+func (cmd validateLoginRequestV1Command) handle(o LoginOptions, v loginRequestV1CommandValidator) ValidateLoginRequestV1Response {
+	registerLoginRequestValidator(o, v)
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return ValidateLoginRequestV1Response{
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}
+	}
+
+	return ValidateLoginRequestV1Response{
+		Status: STATUS_OKAY,
+	}
+}
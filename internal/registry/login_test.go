@@ -0,0 +1,370 @@
+package registry
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeUserRepository struct {
+	users []User
+	codes []AuthorizationCode
+}
+
+func (r *fakeUserRepository) UserById(id string) (User, error) {
+	for _, u := range r.users {
+		if u.Id == id {
+			return u, nil
+		}
+	}
+
+	return User{}, ErrResourceNotFound{Type: "User", URI: id}
+}
+
+func (r *fakeUserRepository) UserByUsername(username string) (User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+
+	return User{}, ErrResourceNotFound{Type: "User", URI: username}
+}
+
+func (r *fakeUserRepository) AllUsers() ([]User, error) {
+	return r.users, nil
+}
+
+func (r *fakeUserRepository) AddUser(u User) (User, error) {
+	r.users = append(r.users, u)
+
+	return u, nil
+}
+
+func (r *fakeUserRepository) DeleteUser(u User, at time.Time) error {
+	return nil
+}
+
+func (r *fakeUserRepository) AddAuthorizationCode(c AuthorizationCode) error {
+	r.codes = append(r.codes, c)
+
+	return nil
+}
+
+func (r *fakeUserRepository) TakeAuthorizationCode(hash string) (AuthorizationCode, error) {
+	for i, c := range r.codes {
+		if c.Hash == hash {
+			r.codes = append(r.codes[:i], r.codes[i+1:]...)
+
+			return c, nil
+		}
+	}
+
+	return AuthorizationCode{}, ErrResourceNotFound{Type: "AuthorizationCode", URI: "<redacted>"}
+}
+
+var testLoginOptions = LoginOptions{
+	Ports:    [2]int{10000, 10010},
+	TokenTTL: time.Hour,
+}
+
+func Test_LoginOptions_allowsRedirect(t *testing.T) {
+	tests := map[string]bool{
+		"http://localhost:10000/login":  true,
+		"http://127.0.0.1:10010/login":  true,
+		"http://[::1]:10005/login":      true,
+		"http://localhost:9999/login":   false,
+		"http://localhost:10011/login":  false,
+		"http://localhost/login":        false,
+		"https://localhost:10000/login": false,
+		"http://example.com:10000/":     false,
+		"http://u@localhost:10000/":     false,
+		"not a url":                     false,
+	}
+
+	for uri, expected := range tests {
+		assert.Equal(t, expected, testLoginOptions.allowsRedirect(uri), uri)
+	}
+}
+
+func Test_ServiceDiscoveryCommand_Handle(t *testing.T) {
+	res := ServiceDiscoveryCommand{}.Handle()
+
+	assert.Nil(t, res.Body.Login)
+
+	res = ServiceDiscoveryCommand{Login: &testLoginOptions}.Handle()
+
+	assert.Equal(t, &LoginServiceDiscovery{
+		Client:     "terraform-cli",
+		GrantTypes: []string{"authz_code"},
+		Authz:      "/oauth/authorization",
+		Token:      "/oauth/token",
+		Ports:      [2]int{10000, 10010},
+	}, res.Body.Login)
+}
+
+func buildLoginRequest(challenge string) LoginRequestV1DTO {
+	return LoginRequestV1DTO{
+		ClientId:            LoginClientId,
+		RedirectURI:         "http://localhost:10001/login",
+		ResponseType:        "code",
+		State:               "some-state",
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func Test_authorizeLoginV1Command_handle(t *testing.T) {
+	hash, err := HashPassword("correct horse battery")
+	assert.Nil(t, err)
+
+	invalidRequest := buildLoginRequest("challenge")
+	invalidRequest.ClientId = "someone-else"
+	invalidRequest.RedirectURI = "https://example.com/steal"
+	invalidRequest.CodeChallengeMethod = "plain"
+
+	tests := []struct {
+		name           string
+		dto            AuthorizeLoginV1DTO
+		expectedStatus RegistryHandlerStatus
+		expectedRules  []string
+	}{
+		{
+			name:           "issues a code",
+			dto:            AuthorizeLoginV1DTO{LoginRequestV1DTO: buildLoginRequest("challenge"), Username: "alice", Password: "correct horse battery"},
+			expectedStatus: STATUS_OKAY,
+		},
+		{
+			name:           "wrong password",
+			dto:            AuthorizeLoginV1DTO{LoginRequestV1DTO: buildLoginRequest("challenge"), Username: "alice", Password: "wrong"},
+			expectedStatus: STATUS_UNAUTHORIZED,
+		},
+		{
+			name:           "unknown user",
+			dto:            AuthorizeLoginV1DTO{LoginRequestV1DTO: buildLoginRequest("challenge"), Username: "bob", Password: "correct horse battery"},
+			expectedStatus: STATUS_UNAUTHORIZED,
+		},
+		{
+			name:           "request terraform wouldn't make",
+			dto:            AuthorizeLoginV1DTO{LoginRequestV1DTO: invalidRequest, Username: "alice", Password: "correct horse battery"},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"login_client", "loopback_redirect", "pkce_method"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := &fakeUserRepository{
+				users: []User{{Id: "alice-id", Username: "alice", PasswordHash: hash}},
+			}
+
+			cmd := authorizeLoginV1Command{
+				DTO: test.dto,
+			}
+
+			res, err := cmd.handle(repo, testLoginOptions, l, NewCommandValidator(l))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+
+			rules := []string{}
+			for _, e := range res.ValidationErrors {
+				rules = append(rules, e.Rule)
+			}
+
+			if test.expectedRules == nil {
+				test.expectedRules = []string{}
+			}
+
+			assert.Equal(tt, test.expectedRules, rules)
+
+			if res.Status != STATUS_OKAY {
+				assert.Empty(tt, repo.codes)
+				return
+			}
+
+			redirect, err := url.Parse(res.RedirectURL)
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, "localhost:10001", redirect.Host)
+			assert.Equal(tt, "some-state", redirect.Query().Get("state"))
+			assert.Len(tt, repo.codes, 1)
+			assert.Equal(tt, HashAPIToken(redirect.Query().Get("code")), repo.codes[0].Hash)
+			assert.Equal(tt, "alice-id", repo.codes[0].UserId)
+		})
+	}
+}
+
+func Test_exchangeLoginCodeV1Command_handle(t *testing.T) {
+	verifier := "a-verifier-long-enough-to-be-a-real-pkce-verifier-0123456789"
+
+	tests := []struct {
+		name          string
+		dto           func(code string) ExchangeLoginCodeV1DTO
+		expired       bool
+		expectedError string
+	}{
+		{
+			name: "issues a token",
+			dto: func(code string) ExchangeLoginCodeV1DTO {
+				return ExchangeLoginCodeV1DTO{GrantType: "authorization_code", Code: code, ClientId: LoginClientId, RedirectURI: "http://localhost:10001/login", CodeVerifier: verifier}
+			},
+		},
+		{
+			name: "wrong verifier",
+			dto: func(code string) ExchangeLoginCodeV1DTO {
+				return ExchangeLoginCodeV1DTO{GrantType: "authorization_code", Code: code, ClientId: LoginClientId, RedirectURI: "http://localhost:10001/login", CodeVerifier: "someone-elses-verifier"}
+			},
+			expectedError: "invalid_grant",
+		},
+		{
+			name: "other redirect",
+			dto: func(code string) ExchangeLoginCodeV1DTO {
+				return ExchangeLoginCodeV1DTO{GrantType: "authorization_code", Code: code, ClientId: LoginClientId, RedirectURI: "http://localhost:10002/login", CodeVerifier: verifier}
+			},
+			expectedError: "invalid_grant",
+		},
+		{
+			name: "expired code",
+			dto: func(code string) ExchangeLoginCodeV1DTO {
+				return ExchangeLoginCodeV1DTO{GrantType: "authorization_code", Code: code, ClientId: LoginClientId, RedirectURI: "http://localhost:10001/login", CodeVerifier: verifier}
+			},
+			expired:       true,
+			expectedError: "invalid_grant",
+		},
+		{
+			name: "unknown code",
+			dto: func(code string) ExchangeLoginCodeV1DTO {
+				return ExchangeLoginCodeV1DTO{GrantType: "authorization_code", Code: "unknown", ClientId: LoginClientId, RedirectURI: "http://localhost:10001/login", CodeVerifier: verifier}
+			},
+			expectedError: "invalid_grant",
+		},
+		{
+			name: "other client",
+			dto: func(code string) ExchangeLoginCodeV1DTO {
+				return ExchangeLoginCodeV1DTO{GrantType: "authorization_code", Code: code, ClientId: "someone-else", RedirectURI: "http://localhost:10001/login", CodeVerifier: verifier}
+			},
+			expectedError: "invalid_client",
+		},
+		{
+			name: "other grant",
+			dto: func(code string) ExchangeLoginCodeV1DTO {
+				return ExchangeLoginCodeV1DTO{GrantType: "password", ClientId: LoginClientId}
+			},
+			expectedError: "unsupported_grant_type",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			expiresAt := time.Now().Add(authorizationCodeTTL)
+
+			if test.expired {
+				expiresAt = time.Now().Add(-time.Second)
+			}
+
+			repo := &fakeUserRepository{
+				users: []User{{Id: "alice-id", Username: "alice", Scopes: []TokenScope{NamespaceReadScope("acme")}}},
+				codes: []AuthorizationCode{{
+					Hash:          HashAPIToken("the-code"),
+					UserId:        "alice-id",
+					RedirectURI:   "http://localhost:10001/login",
+					CodeChallenge: pkceChallenge(verifier),
+					ExpiresAt:     expiresAt,
+				}},
+			}
+			tokens := &fakeAPITokenRepository{}
+
+			cmd := exchangeLoginCodeV1Command{
+				DTO: test.dto("the-code"),
+			}
+
+			res, err := cmd.handle(repo, tokens, testLoginOptions, l)
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedError, res.Error)
+
+			if test.expectedError != "" {
+				assert.Equal(tt, STATUS_INVALID, res.Status)
+				assert.Empty(tt, tokens.tokens)
+				return
+			}
+
+			assert.Equal(tt, STATUS_OKAY, res.Status)
+			assert.Empty(tt, repo.codes, "codes can only be used once")
+			assert.Len(tt, tokens.tokens, 1)
+			assert.Equal(tt, HashAPIToken(res.Secret), tokens.tokens[0].Hash)
+			assert.Equal(tt, "alice-id", res.APIToken.UserId)
+			assert.Equal(tt, "terraform login (alice)", res.APIToken.Name)
+			assert.Equal(tt, []TokenScope{NamespaceReadScope("acme")}, res.APIToken.Scopes)
+			assert.Equal(tt, res.APIToken.CreatedAt.Add(time.Hour), *res.APIToken.ExpiresAt)
+		})
+	}
+}
+
+func Test_createUserV1Command_handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		dto            CreateUserV1DTO
+		expectedStatus RegistryHandlerStatus
+		expectedRules  []string
+	}{
+		{
+			name:           "creates a user",
+			dto:            CreateUserV1DTO{Username: "bob", Password: "correct horse battery", Scopes: []string{"modules:read"}},
+			expectedStatus: STATUS_CREATED,
+		},
+		{
+			name:           "username taken",
+			dto:            CreateUserV1DTO{Username: "alice", Password: "correct horse battery", Scopes: []string{"modules:read"}},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"unique_username"},
+		},
+		{
+			name:           "bad username, short password and unknown scope",
+			dto:            CreateUserV1DTO{Username: "Bob Smith", Password: "short", Scopes: []string{"everything"}},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"username", "password", "token_scope"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := &fakeUserRepository{
+				users: []User{{Id: "alice-id", Username: "alice"}},
+			}
+
+			cmd := createUserV1Command{
+				DTO: test.dto,
+			}
+
+			res, err := cmd.handle(repo, l, NewCommandValidator(l))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+
+			rules := []string{}
+			for _, e := range res.ValidationErrors {
+				rules = append(rules, e.Rule)
+			}
+
+			if test.expectedRules == nil {
+				test.expectedRules = []string{}
+			}
+
+			assert.ElementsMatch(tt, test.expectedRules, rules)
+
+			if res.Status == STATUS_CREATED {
+				assert.True(tt, res.User.CheckPassword(test.dto.Password))
+				assert.NotContains(tt, res.User.PasswordHash, test.dto.Password)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package registry
 
 import (
 	"fmt"
+	"path"
 	"strings"
+	"time"
 )
 
 type VersionStatus string
@@ -40,6 +42,13 @@ type ModuleVersion struct {
 	DownloadURL   string        `json:"downloadURL"`
 	RepositoryURL string        `json:"repositoryURL"`
 	Status        VersionStatus `json:"status"`
+	StatusReason  string        `json:"status_reason,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// IsSettled is true once the archive build has finished, successfully or not.
+func (mv ModuleVersion) IsSettled() bool {
+	return mv.Status == VersionStatuses.Ready || mv.Status == VersionStatuses.Failed
 }
 
 type ModuleFQN struct {
@@ -53,19 +62,103 @@ func (m ModuleFQN) String() string {
 }
 
 type Module struct {
-	Id        string `json:"id"`
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
-	Provider  string `json:"provider"`
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace"`
+	Provider      string `json:"provider"`
+	RepositoryURL string `json:"repository_url"`
+	Path          string `json:"path"`
+	DefaultBranch string `json:"default_branch"`
+	TagPattern    string `json:"tag_pattern"`
+	// What happens to a minor or patch release that breaks the interface
+	BreakingChanges BreakingChangePolicy `json:"breaking_changes"`
+}
+
+type BreakingChangePolicy string
+
+type breakingChangePoliciesContainer struct {
+	Reject BreakingChangePolicy
+	Warn   BreakingChangePolicy
+}
+
+var BreakingChangePolicies breakingChangePoliciesContainer = breakingChangePoliciesContainer{
+	Reject: "reject",
+	Warn:   "warn",
+}
+
+func IsBreakingChangePolicy(p string) bool {
+	return p == string(BreakingChangePolicies.Reject) || p == string(BreakingChangePolicies.Warn)
+}
+
+func (m Module) FQN() ModuleFQN {
+	return ModuleFQN{
+		Name:      m.Name,
+		Namespace: m.Namespace,
+		Provider:  m.Provider,
+	}
+}
+
+// NormaliseRepositoryURL reduces the different URLs a repository is known by
+// to one form, so git@github.com:org/repo.git and https://github.com/org/repo
+// are considered the same repository.
+func NormaliseRepositoryURL(u string) string {
+	u = strings.TrimSpace(u)
+
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	} else if i := strings.Index(u, ":"); i >= 0 && !strings.Contains(u[:i], "/") {
+		// scp-like syntax, e.g. git@github.com:org/repo.git
+		u = u[:i] + "/" + u[i+1:]
+	}
+
+	if i := strings.Index(u, "@"); i >= 0 && i < strings.Index(u+"/", "/") {
+		u = u[i+1:]
+	}
+
+	u = strings.TrimSuffix(strings.TrimRight(u, "/"), ".git")
+
+	if i := strings.Index(u, "/"); i >= 0 {
+		return strings.ToLower(u[:i]) + u[i:]
+	}
+
+	return strings.ToLower(u)
+}
+
+// TagPatternVersionPlaceholder marks where the version appears in a module's
+// tag pattern, e.g. modules/vpc/v{version}.
+const TagPatternVersionPlaceholder = "{version}"
+
+// VersionFromTag extracts the version from a git tag, if the tag matches the
+// module's tag pattern.
+func (m Module) VersionFromTag(tag string) (string, bool) {
+	parts := strings.Split(m.TagPattern, TagPatternVersionPlaceholder)
+
+	if len(parts) != 2 {
+		return "", false
+	}
+
+	prefix, suffix := parts[0], parts[1]
+
+	if len(tag) <= len(prefix)+len(suffix) || !strings.HasPrefix(tag, prefix) || !strings.HasSuffix(tag, suffix) {
+		return "", false
+	}
+
+	return tag[len(prefix) : len(tag)-len(suffix)], true
 }
 
 type ModuleFilters struct {
 	Provider  string
 	Namespace string
+	// Matches modules with the query in their namespace, name or provider
+	Query string
+	// Only modules with a version that is ready to download
+	Published bool
+	// Only modules in one of these namespaces, nil doesn't filter by them
+	Namespaces []string
 }
 
 func BuildModuleTable(mods []Module) (h []string, r [][]string) {
-	h = []string{"Provider", "Namespace", "ID", "Name"}
+	h = []string{"Provider", "Namespace", "ID", "Name", "Repository URL", "Path", "Default Branch", "Tag Pattern"}
 
 	for _, m := range mods {
 		r = append(r, []string{
@@ -73,6 +166,10 @@ func BuildModuleTable(mods []Module) (h []string, r [][]string) {
 			m.Namespace,
 			m.Id,
 			m.Name,
+			m.RepositoryURL,
+			m.Path,
+			m.DefaultBranch,
+			m.TagPattern,
 		})
 	}
 
@@ -97,6 +194,18 @@ func BuildModuleVersionsTable(mvs []ModuleVersion) (h []string, r [][]string) {
 	return
 }
 
+// CleanModulePath normalises the path of a module within its repository, the
+// root of the repository is represented by an empty string.
+func CleanModulePath(p string) string {
+	cleaned := path.Clean(strings.Trim(p, "/"))
+
+	if cleaned == "." {
+		return ""
+	}
+
+	return cleaned
+}
+
 func ParseModuleFQN(s string) (ModuleFQN, error) {
 	if s == "" {
 		return ModuleFQN{}, ErrCouldNotParseModuleFQN{
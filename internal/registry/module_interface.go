@@ -0,0 +1,151 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SourcePos is where a block was declared within a module.
+type SourcePos struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+}
+
+type ModuleVariable struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	// The default as JSON, absent for required variables
+	Default   json.RawMessage `json:"default,omitempty"`
+	Required  bool            `json:"required"`
+	Sensitive bool            `json:"sensitive"`
+	Pos       SourcePos       `json:"pos"`
+}
+
+type ModuleOutput struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Sensitive   bool      `json:"sensitive"`
+	Pos         SourcePos `json:"pos"`
+}
+
+type ProviderRequirement struct {
+	Name               string   `json:"name"`
+	Source             string   `json:"source,omitempty"`
+	VersionConstraints []string `json:"version_constraints"`
+}
+
+type ModuleResource struct {
+	// Either managed or data
+	Mode string    `json:"mode"`
+	Type string    `json:"type"`
+	Name string    `json:"name"`
+	Pos  SourcePos `json:"pos"`
+}
+
+type ModuleCall struct {
+	Name    string    `json:"name"`
+	Source  string    `json:"source"`
+	Version string    `json:"version,omitempty"`
+	Pos     SourcePos `json:"pos"`
+}
+
+// ModuleInterface summarises what a module version takes and provides, it is
+// parsed from the module's HCL when the archive is built.
+type ModuleInterface struct {
+	RequiredVersion   []string              `json:"required_version"`
+	RequiredProviders []ProviderRequirement `json:"required_providers"`
+	Variables         []ModuleVariable      `json:"variables"`
+	Outputs           []ModuleOutput        `json:"outputs"`
+	Resources         []ModuleResource      `json:"resources"`
+	ModuleCalls       []ModuleCall          `json:"module_calls"`
+}
+
+// ModuleVersionInterface is the interface parsed for a module version.
+type ModuleVersionInterface struct {
+	ModuleVersionId string          `json:"module_version_id"`
+	Interface       ModuleInterface `json:"interface"`
+	ParsedAt        time.Time       `json:"parsed_at"`
+}
+
+func (p SourcePos) String() string {
+	return fmt.Sprintf("%s:%d", p.Filename, p.Line)
+}
+
+func BuildModuleVariablesTable(vars []ModuleVariable) (h []string, r [][]string) {
+	h = []string{"Name", "Type", "Required", "Default", "Sensitive", "Description"}
+
+	for _, v := range vars {
+		r = append(r, []string{
+			v.Name,
+			v.Type,
+			strconv.FormatBool(v.Required),
+			string(v.Default),
+			strconv.FormatBool(v.Sensitive),
+			v.Description,
+		})
+	}
+
+	return
+}
+
+func BuildModuleOutputsTable(outputs []ModuleOutput) (h []string, r [][]string) {
+	h = []string{"Name", "Sensitive", "Description"}
+
+	for _, o := range outputs {
+		r = append(r, []string{
+			o.Name,
+			strconv.FormatBool(o.Sensitive),
+			o.Description,
+		})
+	}
+
+	return
+}
+
+func BuildProviderRequirementsTable(providers []ProviderRequirement) (h []string, r [][]string) {
+	h = []string{"Name", "Source", "Version Constraints"}
+
+	for _, p := range providers {
+		r = append(r, []string{
+			p.Name,
+			p.Source,
+			strings.Join(p.VersionConstraints, ", "),
+		})
+	}
+
+	return
+}
+
+func BuildModuleResourcesTable(resources []ModuleResource) (h []string, r [][]string) {
+	h = []string{"Mode", "Type", "Name", "Declared At"}
+
+	for _, res := range resources {
+		r = append(r, []string{
+			res.Mode,
+			res.Type,
+			res.Name,
+			res.Pos.String(),
+		})
+	}
+
+	return
+}
+
+func BuildModuleCallsTable(calls []ModuleCall) (h []string, r [][]string) {
+	h = []string{"Name", "Source", "Version", "Declared At"}
+
+	for _, c := range calls {
+		r = append(r, []string{
+			c.Name,
+			c.Source,
+			c.Version,
+			c.Pos.String(),
+		})
+	}
+
+	return
+}
@@ -0,0 +1,357 @@
+package registry
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+type rolesContainer struct {
+	Reader     Role
+	Publisher  Role
+	Maintainer Role
+	Admin      Role
+}
+
+var Roles rolesContainer = rolesContainer{
+	Reader:     "reader",
+	Publisher:  "publisher",
+	Maintainer: "maintainer",
+	Admin:      "admin",
+}
+
+func AllRoles() []Role {
+	return []Role{
+		Roles.Reader,
+		Roles.Publisher,
+		Roles.Maintainer,
+		Roles.Admin,
+	}
+}
+
+func IsRole(s string) bool {
+	for _, r := range AllRoles() {
+		if string(r) == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// roleScopes is what each role allows in its namespace, every role allows
+// what the one before it does. Only a namespace's admins can grant roles in
+// it.
+var roleScopes = map[Role][]TokenScope{
+	Roles.Reader:     {TokenScopes.ModulesRead},
+	Roles.Publisher:  {TokenScopes.ModulesRead, TokenScopes.VersionsPublish},
+	Roles.Maintainer: {TokenScopes.ModulesRead, TokenScopes.VersionsPublish, TokenScopes.ModulesWrite},
+	Roles.Admin:      {TokenScopes.ModulesRead, TokenScopes.VersionsPublish, TokenScopes.ModulesWrite, TokenScopes.Admin},
+}
+
+func (r Role) Allows(scope TokenScope) bool {
+	for _, s := range roleScopes[r] {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+type SubjectType string
+
+type subjectTypesContainer struct {
+	User  SubjectType
+	Team  SubjectType
+	Token SubjectType
+}
+
+var SubjectTypes subjectTypesContainer = subjectTypesContainer{
+	User:  "user",
+	Team:  "team",
+	Token: "token",
+}
+
+// Subject is who a role is granted to.
+type Subject struct {
+	Type SubjectType `json:"type"`
+	Id   string      `json:"id"`
+}
+
+// ParseSubject reads a subject as given on the command line or to the API,
+// `user:<username>`, `team:<name>` or `token:<id>`. The second value is the
+// username, team name or token id.
+func ParseSubject(s string) (SubjectType, string, bool) {
+	parts := strings.SplitN(s, ":", 2)
+
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", false
+	}
+
+	switch t := SubjectType(parts[0]); t {
+	case SubjectTypes.User, SubjectTypes.Team, SubjectTypes.Token:
+		return t, parts[1], true
+	default:
+		return "", "", false
+	}
+}
+
+// Namespace groups the modules and providers that share it, roles are granted
+// to use them. The owners of a namespace are those with its admin role.
+type Namespace struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	// The admin grants, filled in when namespaces are listed or shown
+	Owners []RoleGrant `json:"owners"`
+}
+
+// RoleGrant gives the subject a role in a namespace, a subject has at most
+// one role in each namespace.
+type RoleGrant struct {
+	Id        string  `json:"id"`
+	Namespace string  `json:"namespace"`
+	Subject   Subject `json:"subject"`
+	// The username, team name or token name at the time of the grant
+	SubjectName string    `json:"subject_name"`
+	Role        Role      `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Team lets roles be granted to several users at once.
+type Team struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	// Filled in when teams are listed
+	Members []User `json:"members"`
+}
+
+type actorGrantsRepository interface {
+	GrantsForSubjects(subjects []Subject) ([]RoleGrant, error)
+}
+
+type actorTeamsRepository interface {
+	TeamsForUser(userId string) ([]Team, error)
+}
+
+// actorGrants is every role granted to the actor, to the token it
+// authenticated with, the user it was issued to, or their teams.
+func actorGrants(a Actor, grants actorGrantsRepository, teams actorTeamsRepository) ([]RoleGrant, error) {
+	if grants == nil {
+		return []RoleGrant{}, nil
+	}
+
+	subjects := []Subject{}
+
+	if a.Type == ActorTypes.Token {
+		subjects = append(subjects, Subject{Type: SubjectTypes.Token, Id: a.Id})
+	}
+
+	if a.UserId != "" {
+		subjects = append(subjects, Subject{Type: SubjectTypes.User, Id: a.UserId})
+
+		if teams != nil {
+			userTeams, err := teams.TeamsForUser(a.UserId)
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, t := range userTeams {
+				subjects = append(subjects, Subject{Type: SubjectTypes.Team, Id: t.Id})
+			}
+		}
+	}
+
+	if len(subjects) == 0 {
+		return []RoleGrant{}, nil
+	}
+
+	return grants.GrantsForSubjects(subjects)
+}
+
+// permits is true when the actor's scopes, or a role it has been granted in
+// the namespace, allow the scope there. Roles only apply within a namespace.
+func permits(a Actor, scope TokenScope, namespace string, grants actorGrantsRepository, teams actorTeamsRepository) (bool, error) {
+	if a.Allows(scope, namespace) {
+		return true, nil
+	}
+
+	if namespace == "" {
+		return false, nil
+	}
+
+	granted, err := actorGrants(a, grants, teams)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, g := range granted {
+		if g.Namespace == namespace && g.Role.Allows(scope) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// permittedNamespaces is every namespace the actor's roles or namespace
+// scopes allow the scope in. It doesn't include the namespaces allowed by a
+// scope for every namespace.
+func permittedNamespaces(a Actor, scope TokenScope, grants actorGrantsRepository, teams actorTeamsRepository) (map[string]bool, error) {
+	namespaces := map[string]bool{}
+
+	for _, s := range a.Scopes {
+		if _, ns := splitScope(s); ns != "" && a.Allows(scope, ns) {
+			namespaces[ns] = true
+		}
+	}
+
+	granted, err := actorGrants(a, grants, teams)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range granted {
+		if g.Role.Allows(scope) {
+			namespaces[g.Namespace] = true
+		}
+	}
+
+	return namespaces, nil
+}
+
+func BuildNamespacesTable(namespaces []Namespace) (h []string, r [][]string) {
+	h = []string{"ID", "Name", "Owners", "Created At"}
+
+	for _, n := range namespaces {
+		names := []string{}
+
+		for _, g := range n.Owners {
+			names = append(names, string(g.Subject.Type)+":"+g.SubjectName)
+		}
+
+		r = append(r, []string{
+			n.Id,
+			n.Name,
+			strings.Join(names, ", "),
+			n.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return
+}
+
+func BuildRoleGrantsTable(grants []RoleGrant) (h []string, r [][]string) {
+	h = []string{"ID", "Subject", "Role", "Created At"}
+
+	for _, g := range grants {
+		r = append(r, []string{
+			g.Id,
+			string(g.Subject.Type) + ":" + g.SubjectName,
+			string(g.Role),
+			g.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return
+}
+
+func BuildTeamsTable(teams []Team) (h []string, r [][]string) {
+	h = []string{"ID", "Name", "Members", "Created At"}
+
+	for _, t := range teams {
+		names := []string{}
+
+		for _, u := range t.Members {
+			names = append(names, u.Username)
+		}
+
+		r = append(r, []string{
+			t.Id,
+			t.Name,
+			strings.Join(names, ", "),
+			t.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return
+}
+
+type subjectUserRepository interface {
+	UserByUsername(username string) (u User, err error)
+}
+
+type subjectTeamRepository interface {
+	TeamByName(name string) (t Team, err error)
+}
+
+type subjectTokenRepository interface {
+	TokenById(id string) (t APIToken, err error)
+}
+
+// subjectResolver finds who a `user:`, `team:` or `token:` subject refers to.
+type subjectResolver struct {
+	users  subjectUserRepository
+	teams  subjectTeamRepository
+	tokens subjectTokenRepository
+}
+
+// resolve is the subject, and its name, it is an ErrResourceNotFound when
+// there is no such user, team or token.
+func (r subjectResolver) resolve(s string) (Subject, string, error) {
+	t, name, ok := ParseSubject(s)
+	notFound := ErrResourceNotFound{Type: "Subject", URI: s}
+
+	if !ok {
+		return Subject{}, "", notFound
+	}
+
+	switch t {
+	case SubjectTypes.User:
+		u, err := r.users.UserByUsername(name)
+
+		if err != nil {
+			return Subject{}, "", err
+		}
+
+		return Subject{Type: t, Id: u.Id}, u.Username, nil
+	case SubjectTypes.Team:
+		team, err := r.teams.TeamByName(name)
+
+		if err != nil {
+			return Subject{}, "", err
+		}
+
+		return Subject{Type: t, Id: team.Id}, team.Name, nil
+	default:
+		if _, err := uuid.Parse(name); err != nil {
+			return Subject{}, "", notFound
+		}
+
+		token, err := r.tokens.TokenById(name)
+
+		if err != nil {
+			return Subject{}, "", err
+		}
+
+		return Subject{Type: t, Id: token.Id}, token.Name, nil
+	}
+}
+
+// deniedStatus is FORBIDDEN for a command the actor may not run, unless
+// finding that out failed.
+func deniedStatus(err error) RegistryHandlerStatus {
+	if err != nil {
+		return STATUS_INTERNAL_ERROR
+	}
+
+	return STATUS_FORBIDDEN
+}
@@ -0,0 +1,413 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+const testUserId = "3b0cb5d1-2f4c-4b7e-8a53-6f3d1a2c9e10"
+const testTeamId = "9d2e7f4a-1c3b-4e5d-8f6a-7b8c9d0e1f2a"
+const testTokenId = "c4f1e2d3-5a6b-4c7d-9e8f-0a1b2c3d4e5f"
+
+type fakeNamespaceRepository struct {
+	namespaces []Namespace
+	grants     []RoleGrant
+}
+
+func (r *fakeNamespaceRepository) NamespaceByName(name string) (Namespace, error) {
+	for _, n := range r.namespaces {
+		if n.Name == name {
+			return n, nil
+		}
+	}
+
+	return Namespace{}, ErrResourceNotFound{Type: "Namespace", URI: name}
+}
+
+func (r *fakeNamespaceRepository) AllNamespaces() ([]Namespace, error) {
+	return r.namespaces, nil
+}
+
+func (r *fakeNamespaceRepository) AddNamespace(n Namespace, owners []RoleGrant) (Namespace, error) {
+	r.namespaces = append(r.namespaces, n)
+	r.grants = append(r.grants, owners...)
+
+	return n, nil
+}
+
+func (r *fakeNamespaceRepository) EnsureNamespace(n Namespace) error {
+	if _, err := r.NamespaceByName(n.Name); err == nil {
+		return nil
+	}
+
+	r.namespaces = append(r.namespaces, n)
+
+	return nil
+}
+
+func (r *fakeNamespaceRepository) DeleteNamespace(n Namespace) error {
+	return nil
+}
+
+func (r *fakeNamespaceRepository) GrantsByNamespace(namespace string) ([]RoleGrant, error) {
+	found := []RoleGrant{}
+
+	for _, g := range r.grants {
+		if g.Namespace == namespace {
+			found = append(found, g)
+		}
+	}
+
+	return found, nil
+}
+
+func (r *fakeNamespaceRepository) GrantsForSubjects(subjects []Subject) ([]RoleGrant, error) {
+	found := []RoleGrant{}
+
+	for _, g := range r.grants {
+		for _, s := range subjects {
+			if g.Subject == s {
+				found = append(found, g)
+			}
+		}
+	}
+
+	return found, nil
+}
+
+func (r *fakeNamespaceRepository) GrantForSubject(namespace string, s Subject) (RoleGrant, error) {
+	for _, g := range r.grants {
+		if g.Namespace == namespace && g.Subject == s {
+			return g, nil
+		}
+	}
+
+	return RoleGrant{}, ErrResourceNotFound{Type: "RoleGrant", URI: namespace}
+}
+
+func (r *fakeNamespaceRepository) SaveGrant(g RoleGrant) (RoleGrant, error) {
+	for i, existing := range r.grants {
+		if existing.Id == g.Id {
+			r.grants[i] = g
+
+			return g, nil
+		}
+	}
+
+	r.grants = append(r.grants, g)
+
+	return g, nil
+}
+
+func (r *fakeNamespaceRepository) DeleteGrant(g RoleGrant) error {
+	return nil
+}
+
+type fakeTeamRepository struct {
+	teams   []Team
+	members map[string][]string
+}
+
+func (r *fakeTeamRepository) TeamByName(name string) (Team, error) {
+	for _, t := range r.teams {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+
+	return Team{}, ErrResourceNotFound{Type: "Team", URI: name}
+}
+
+func (r *fakeTeamRepository) TeamsForUser(userId string) ([]Team, error) {
+	found := []Team{}
+
+	for _, t := range r.teams {
+		for _, id := range r.members[t.Id] {
+			if id == userId {
+				found = append(found, t)
+			}
+		}
+	}
+
+	return found, nil
+}
+
+func Test_Role_Allows(t *testing.T) {
+	tests := []struct {
+		role     Role
+		allowed  []TokenScope
+		rejected []TokenScope
+	}{
+		{
+			role:     Roles.Reader,
+			allowed:  []TokenScope{TokenScopes.ModulesRead},
+			rejected: []TokenScope{TokenScopes.VersionsPublish, TokenScopes.ModulesWrite, TokenScopes.Admin},
+		},
+		{
+			role:     Roles.Publisher,
+			allowed:  []TokenScope{TokenScopes.ModulesRead, TokenScopes.VersionsPublish},
+			rejected: []TokenScope{TokenScopes.ModulesWrite, TokenScopes.Admin},
+		},
+		{
+			role:     Roles.Maintainer,
+			allowed:  []TokenScope{TokenScopes.ModulesRead, TokenScopes.VersionsPublish, TokenScopes.ModulesWrite},
+			rejected: []TokenScope{TokenScopes.Admin},
+		},
+		{
+			role:    Roles.Admin,
+			allowed: AllTokenScopes(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.role), func(tt *testing.T) {
+			for _, s := range test.allowed {
+				assert.True(tt, test.role.Allows(s), s)
+			}
+
+			for _, s := range test.rejected {
+				assert.False(tt, test.role.Allows(s), s)
+			}
+		})
+	}
+}
+
+func Test_ParseSubject(t *testing.T) {
+	tests := []struct {
+		subject      string
+		expectedType SubjectType
+		expectedName string
+		valid        bool
+	}{
+		{subject: "user:alice", expectedType: SubjectTypes.User, expectedName: "alice", valid: true},
+		{subject: "team:platform", expectedType: SubjectTypes.Team, expectedName: "platform", valid: true},
+		{subject: "token:" + testTokenId, expectedType: SubjectTypes.Token, expectedName: testTokenId, valid: true},
+		{subject: "group:platform"},
+		{subject: "user:"},
+		{subject: "alice"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.subject, func(tt *testing.T) {
+			st, name, ok := ParseSubject(test.subject)
+
+			assert.Equal(tt, test.valid, ok)
+			assert.Equal(tt, test.expectedType, st)
+			assert.Equal(tt, test.expectedName, name)
+		})
+	}
+}
+
+func Test_permits(t *testing.T) {
+	grants := &fakeNamespaceRepository{
+		grants: []RoleGrant{
+			{Namespace: "platform", Subject: Subject{Type: SubjectTypes.Team, Id: testTeamId}, Role: Roles.Publisher},
+			{Namespace: "network", Subject: Subject{Type: SubjectTypes.User, Id: testUserId}, Role: Roles.Maintainer},
+			{Namespace: "security", Subject: Subject{Type: SubjectTypes.Token, Id: testTokenId}, Role: Roles.Reader},
+		},
+	}
+	teams := &fakeTeamRepository{
+		teams:   []Team{{Id: testTeamId, Name: "platform"}},
+		members: map[string][]string{testTeamId: {testUserId}},
+	}
+
+	user := Actor{Type: ActorTypes.Token, Id: testTokenId, UserId: testUserId}
+
+	tests := []struct {
+		name      string
+		actor     Actor
+		scope     TokenScope
+		namespace string
+		expected  bool
+	}{
+		{name: "role granted to the user's team", actor: user, scope: TokenScopes.VersionsPublish, namespace: "platform", expected: true},
+		{name: "beyond the team's role", actor: user, scope: TokenScopes.ModulesWrite, namespace: "platform"},
+		{name: "role granted to the user", actor: user, scope: TokenScopes.ModulesWrite, namespace: "network", expected: true},
+		{name: "role granted to the token", actor: user, scope: TokenScopes.ModulesRead, namespace: "security", expected: true},
+		{name: "no role in the namespace", actor: user, scope: TokenScopes.ModulesRead, namespace: "data"},
+		{name: "roles don't apply to every namespace", actor: user, scope: TokenScopes.ModulesRead},
+		{
+			name:      "scope without a role",
+			actor:     Actor{Type: ActorTypes.OIDC, Scopes: []TokenScope{"versions:publish:data"}},
+			scope:     TokenScopes.VersionsPublish,
+			namespace: "data",
+			expected:  true,
+		},
+		{
+			name:      "another user's role",
+			actor:     Actor{Type: ActorTypes.Token, Id: "other", UserId: "other"},
+			scope:     TokenScopes.ModulesRead,
+			namespace: "network",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			ok, err := permits(test.actor, test.scope, test.namespace, grants, teams)
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expected, ok)
+		})
+	}
+
+	permitted, err := permittedNamespaces(user, TokenScopes.ModulesRead, grants, teams)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"platform": true, "network": true, "security": true}, permitted)
+}
+
+func Test_grantRoleV1Command_handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		dto            GrantRoleV1DTO
+		expectedStatus RegistryHandlerStatus
+		expectedRole   Role
+	}{
+		{
+			name:           "new grant",
+			dto:            GrantRoleV1DTO{Namespace: "platform", Subject: "user:alice", Role: "publisher"},
+			expectedStatus: STATUS_CREATED,
+			expectedRole:   Roles.Publisher,
+		},
+		{
+			name:           "replaces the team's role",
+			dto:            GrantRoleV1DTO{Namespace: "platform", Subject: "team:platform", Role: "maintainer"},
+			expectedStatus: STATUS_MODIFIED,
+			expectedRole:   Roles.Maintainer,
+		},
+		{
+			name:           "unknown role",
+			dto:            GrantRoleV1DTO{Namespace: "platform", Subject: "user:alice", Role: "owner"},
+			expectedStatus: STATUS_INVALID,
+		},
+		{
+			name:           "unknown user",
+			dto:            GrantRoleV1DTO{Namespace: "platform", Subject: "user:bob", Role: "reader"},
+			expectedStatus: STATUS_INVALID,
+		},
+		{
+			name:           "unknown namespace",
+			dto:            GrantRoleV1DTO{Namespace: "data", Subject: "user:alice", Role: "reader"},
+			expectedStatus: STATUS_NOT_FOUND,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			repo := &fakeNamespaceRepository{
+				namespaces: []Namespace{{Name: "platform"}},
+				grants: []RoleGrant{
+					{Id: "existing", Namespace: "platform", Subject: Subject{Type: SubjectTypes.Team, Id: testTeamId}, Role: Roles.Reader},
+				},
+			}
+			subjects := subjectResolver{
+				users: &fakeUserRepository{users: []User{{Id: testUserId, Username: "alice"}}},
+				teams: &fakeTeamRepository{teams: []Team{{Id: testTeamId, Name: "platform"}}},
+			}
+
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			cmd := grantRoleV1Command{DTO: test.dto}
+			res, err := cmd.handle(repo, subjects, l, NewCommandValidator(l))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+			assert.Equal(tt, test.expectedRole, res.Grant.Role)
+
+			if res.Status == STATUS_CREATED || res.Status == STATUS_MODIFIED {
+				assert.Len(tt, repo.grants, map[RegistryHandlerStatus]int{STATUS_CREATED: 2, STATUS_MODIFIED: 1}[res.Status])
+			}
+		})
+	}
+}
+
+func Test_createNamespaceV1Command_handle(t *testing.T) {
+	repo := &fakeNamespaceRepository{
+		namespaces: []Namespace{{Name: "network"}},
+	}
+	subjects := subjectResolver{
+		users: &fakeUserRepository{users: []User{{Id: testUserId, Username: "alice"}}},
+		teams: &fakeTeamRepository{teams: []Team{{Id: testTeamId, Name: "platform"}}},
+	}
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+
+	cmd := createNamespaceV1Command{DTO: CreateNamespaceV1DTO{Name: "platform", Owners: []string{"team:platform", "user:alice", "team:platform"}}}
+	res, err := cmd.handle(repo, subjects, l, NewCommandValidator(l))
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_CREATED, res.Status)
+	assert.Len(t, res.Namespace.Owners, 2)
+
+	for _, g := range repo.grants {
+		assert.Equal(t, Roles.Admin, g.Role)
+		assert.Equal(t, "platform", g.Namespace)
+	}
+
+	cmd = createNamespaceV1Command{DTO: CreateNamespaceV1DTO{Name: "network", Owners: []string{"user:bob", "group:x"}}}
+	res, err = cmd.handle(repo, subjects, l, NewCommandValidator(l))
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_INVALID, res.Status)
+	assert.Len(t, res.ValidationErrors, 3)
+}
+
+func Test_CommandBus_As(t *testing.T) {
+	cb := NewCommandBus(
+		WithNamespaceRepo(nil),
+		WithLogger(ymirstubs.BuildZerologLogger(new(bytes.Buffer))),
+	)
+
+	ok, err := cb.permits(TokenScopes.Admin, "platform")
+	assert.Nil(t, err)
+	assert.True(t, ok, "the bus isn't restricted without an actor")
+
+	acting := cb.As(Actor{Scopes: []TokenScope{"modules:write:platform"}})
+
+	ok, err = acting.permits(TokenScopes.ModulesWrite, "platform")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = acting.permits(TokenScopes.ModulesWrite, "network")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	res, err := acting.AddModuleV1FromDTO(context.Background(), AddModuleV1DTO{Namespace: "network", Name: "vpc", Provider: "aws"})
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_FORBIDDEN, res.Status)
+
+	assert.Nil(t, cb.actor, "acting for an actor leaves the bus it came from alone")
+}
+
+type filteredModuleRepository struct {
+	ModuleRepository
+	filters []ModuleFilters
+}
+
+func (r *filteredModuleRepository) All(ctx context.Context, chunkOpts ChunkingOptions, f ModuleFilters) ([]Module, error) {
+	r.filters = append(r.filters, f)
+
+	return []Module{}, nil
+}
+
+func Test_CommandBus_ListModulesV1FromDTO_PermittedNamespaces(t *testing.T) {
+	repo := &filteredModuleRepository{}
+	cb := NewCommandBus(
+		WithModuleRepo(repo),
+		WithNamespaceRepo(nil),
+		WithLogger(ymirstubs.BuildZerologLogger(new(bytes.Buffer))),
+	)
+
+	_, err := cb.ListModulesV1FromDTO(context.Background(), ListModulesV1DTO{})
+	assert.Nil(t, err)
+
+	acting := cb.As(Actor{Scopes: []TokenScope{"modules:read:platform", "modules:read:network"}})
+	_, err = acting.ListModulesV1FromDTO(context.Background(), ListModulesV1DTO{ChunkOpts: ChunkingOptions{Size: 10}})
+	assert.Nil(t, err)
+
+	assert.Len(t, repo.filters, 2)
+	assert.Nil(t, repo.filters[0].Namespaces, "the bus isn't restricted without an actor")
+	assert.Equal(t, []string{"network", "platform"}, repo.filters[1].Namespaces)
+}
@@ -0,0 +1,161 @@
+package registry
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMirrorHostname is the registry providers are assumed to come from
+// when a mirrored package doesn't say otherwise.
+const DefaultMirrorHostname = "registry.terraform.io"
+
+// ProviderSourceAddress is how terraform refers to a provider from any
+// registry, e.g. registry.terraform.io/hashicorp/aws.
+type ProviderSourceAddress struct {
+	Hostname  string `json:"hostname" validate:"required"`
+	Namespace string `json:"namespace" validate:"required"`
+	Type      string `json:"type" validate:"required"`
+}
+
+func (a ProviderSourceAddress) String() string {
+	return fmt.Sprintf("%s/%s/%s", a.Hostname, a.Namespace, a.Type)
+}
+
+// MirroredProviderPackage is a release zip of a provider from another
+// registry, served to terraform through the network mirror protocol.
+type MirroredProviderPackage struct {
+	Id         string    `json:"id"`
+	Hostname   string    `json:"hostname"`
+	Namespace  string    `json:"namespace"`
+	Type       string    `json:"type"`
+	Version    string    `json:"version"`
+	OS         string    `json:"os"`
+	Arch       string    `json:"arch"`
+	Filename   string    `json:"filename"`
+	Shasum     string    `json:"shasum"`
+	Hash       string    `json:"hash"`
+	StorageKey string    `json:"storage_key"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (p MirroredProviderPackage) Address() ProviderSourceAddress {
+	return ProviderSourceAddress{
+		Hostname:  p.Hostname,
+		Namespace: p.Namespace,
+		Type:      p.Type,
+	}
+}
+
+// Hashes are the checksums terraform records in its lock file for the
+// package, the h1 hash of its contents and the zh hash of the zip itself.
+func (p MirroredProviderPackage) Hashes() []string {
+	return []string{p.Hash, "zh:" + p.Shasum}
+}
+
+// MirrorStorageKey is the location of a mirrored package within storage.
+func MirrorStorageKey(a ProviderSourceAddress, filename string) string {
+	return fmt.Sprintf("mirror/%s/%s/%s/%s", a.Hostname, a.Namespace, a.Type, filename)
+}
+
+// ParseProviderPackageFilename extracts everything a release zip's name says
+// about it, e.g. terraform-provider-aws_4.0.0_linux_amd64.zip.
+func ParseProviderPackageFilename(filename string) (providerType string, version string, os string, arch string, ok bool) {
+	if !strings.HasPrefix(filename, "terraform-provider-") || !strings.HasSuffix(filename, ".zip") {
+		return "", "", "", "", false
+	}
+
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(filename, "terraform-provider-"), ".zip"), "_")
+
+	if len(parts) != 4 {
+		return "", "", "", "", false
+	}
+
+	for _, p := range parts {
+		if p == "" {
+			return "", "", "", "", false
+		}
+	}
+
+	return parts[0], parts[1], parts[2], parts[3], true
+}
+
+// ProviderPackageHash is terraform's h1 hash of a release zip: the sha256 of
+// a sorted listing of the sha256 of every file it contains. Unlike the hash
+// of the zip, it matches the provider once terraform has unpacked it.
+func ProviderPackageHash(r io.ReaderAt, size int64) (string, error) {
+	z, err := zip.NewReader(r, size)
+
+	if err != nil {
+		return "", err
+	}
+
+	files := map[string]*zip.File{}
+	names := []string{}
+
+	for _, f := range z.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+
+		if strings.Contains(f.Name, "\n") {
+			return "", fmt.Errorf("filename in zip contains newline: %q", f.Name)
+		}
+
+		files[f.Name] = f
+		names = append(names, f.Name)
+	}
+
+	sort.Strings(names)
+	h := sha256.New()
+
+	for _, name := range names {
+		rc, err := files[name].Open()
+
+		if err != nil {
+			return "", err
+		}
+
+		fh := sha256.New()
+		_, err = io.Copy(fh, rc)
+		rc.Close()
+
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(h, "%x  %s\n", fh.Sum(nil), name)
+	}
+
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+func BuildMirrorImportTable(report []MirrorImport) (h []string, r [][]string) {
+	h = []string{"Status", "Path", "Provider", "Version", "Platform", "Reason"}
+
+	for _, i := range report {
+		provider := ""
+		platform := ""
+
+		if i.Package.Type != "" {
+			provider = i.Package.Address().String()
+			platform = i.Package.OS + "_" + i.Package.Arch
+		}
+
+		r = append(r, []string{
+			string(i.Status),
+			i.Path,
+			provider,
+			i.Package.Version,
+			platform,
+			i.Reason,
+		})
+	}
+
+	return
+}
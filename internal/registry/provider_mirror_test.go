@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildZip(t *testing.T, files ...string) []byte {
+	buf := new(bytes.Buffer)
+	z := zip.NewWriter(buf)
+
+	for i := 0; i < len(files); i += 2 {
+		w, err := z.Create(files[i])
+		assert.Nil(t, err)
+
+		_, err = w.Write([]byte(files[i+1]))
+		assert.Nil(t, err)
+	}
+
+	assert.Nil(t, z.Close())
+
+	return buf.Bytes()
+}
+
+func Test_ParseProviderPackageFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		expected []string
+	}{
+		{
+			name:     "release zip",
+			filename: "terraform-provider-aws_4.0.0_linux_amd64.zip",
+			expected: []string{"aws", "4.0.0", "linux", "amd64"},
+		},
+		{
+			name:     "hyphenated type",
+			filename: "terraform-provider-google-beta_4.1.0-rc1_darwin_arm64.zip",
+			expected: []string{"google-beta", "4.1.0-rc1", "darwin", "arm64"},
+		},
+		{
+			name:     "not a provider",
+			filename: "aws_4.0.0_linux_amd64.zip",
+		},
+		{
+			name:     "missing platform",
+			filename: "terraform-provider-aws_4.0.0.zip",
+		},
+		{
+			name:     "empty part",
+			filename: "terraform-provider-aws__linux_amd64.zip",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			providerType, version, os, arch, ok := ParseProviderPackageFilename(test.filename)
+
+			if test.expected == nil {
+				assert.False(tt, ok)
+				return
+			}
+
+			assert.True(tt, ok)
+			assert.Equal(tt, test.expected, []string{providerType, version, os, arch})
+		})
+	}
+}
+
+func Test_ProviderPackageHash(t *testing.T) {
+	hash := func(b []byte) string {
+		h, err := ProviderPackageHash(bytes.NewReader(b), int64(len(b)))
+		assert.Nil(t, err)
+
+		return h
+	}
+
+	original := hash(buildZip(t, "terraform-provider-aws_v4.0.0_x5", "binary", "LICENSE", "MPL"))
+
+	assert.Regexp(t, `^h1:[A-Za-z0-9+/]{43}=$`, original)
+	assert.Equal(t, original, hash(buildZip(t, "LICENSE", "MPL", "terraform-provider-aws_v4.0.0_x5", "binary")), "order of files in the zip")
+	assert.Equal(t, original, hash(buildZip(t, "terraform-provider-aws_v4.0.0_x5", "binary", "docs/", "", "LICENSE", "MPL")), "directory entries")
+	assert.NotEqual(t, original, hash(buildZip(t, "terraform-provider-aws_v4.0.0_x5", "binary!", "LICENSE", "MPL")))
+	assert.NotEqual(t, original, hash(buildZip(t, "terraform-provider-aws_v4.0.1_x5", "binary", "LICENSE", "MPL")))
+
+	_, err := ProviderPackageHash(bytes.NewReader([]byte("not a zip")), 9)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,198 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultProviderProtocols are assumed for an uploaded provider version when
+// none are given, every provider built with the current plugin SDK speaks 5.0.
+var DefaultProviderProtocols = []string{"5.0"}
+
+type ProviderFQN struct {
+	Namespace string `json:"namespace" validate:"required"`
+	Type      string `json:"type" validate:"required"`
+}
+
+func (p ProviderFQN) String() string {
+	return fmt.Sprintf("%s/%s", p.Namespace, p.Type)
+}
+
+type Provider struct {
+	Id        string    `json:"id"`
+	Namespace string    `json:"namespace"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (p Provider) FQN() ProviderFQN {
+	return ProviderFQN{
+		Namespace: p.Namespace,
+		Type:      p.Type,
+	}
+}
+
+// ProviderPlatform is the release zip of a provider version built for one
+// os and architecture.
+type ProviderPlatform struct {
+	Id                string `json:"id"`
+	ProviderVersionId string `json:"provider_version_id"`
+	OS                string `json:"os"`
+	Arch              string `json:"arch"`
+	Filename          string `json:"filename"`
+	Shasum            string `json:"shasum"`
+	StorageKey        string `json:"storage_key"`
+}
+
+type ProviderVersion struct {
+	Id                  string             `json:"id"`
+	ProviderId          string             `json:"provider_id"`
+	Version             string             `json:"version"`
+	Protocols           []string           `json:"protocols"`
+	SigningKeyId        string             `json:"signing_key_id"`
+	ShasumsKey          string             `json:"shasums_key"`
+	ShasumsSignatureKey string             `json:"shasums_signature_key"`
+	Platforms           []ProviderPlatform `json:"platforms"`
+	CreatedAt           time.Time          `json:"created_at"`
+}
+
+// Platform finds the release zip for the os and architecture.
+func (pv ProviderVersion) Platform(os string, arch string) (ProviderPlatform, bool) {
+	for _, p := range pv.Platforms {
+		if p.OS == os && p.Arch == arch {
+			return p, true
+		}
+	}
+
+	return ProviderPlatform{}, false
+}
+
+// GPGKey is a public key that provider releases in a namespace are signed
+// with, terraform verifies the SHA256SUMS file of a release against it.
+type GPGKey struct {
+	Id         string    `json:"id"`
+	Namespace  string    `json:"namespace"`
+	KeyId      string    `json:"key_id"`
+	ASCIIArmor string    `json:"ascii_armor"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ProviderShasumsFilename is the name terraform and goreleaser give the
+// checksums file of a release.
+func ProviderShasumsFilename(providerType string, version string) string {
+	return fmt.Sprintf("terraform-provider-%s_%s_SHA256SUMS", providerType, version)
+}
+
+// ProviderStorageKey is the location of a file belonging to a provider
+// version within storage.
+func ProviderStorageKey(fqn ProviderFQN, version string, filename string) string {
+	return fmt.Sprintf("providers/%s/%s/%s/%s", fqn.Namespace, fqn.Type, version, filename)
+}
+
+// ParseProviderArchiveFilename extracts the os and architecture from the name
+// of a release zip, e.g. terraform-provider-internal_1.0.0_linux_amd64.zip.
+func ParseProviderArchiveFilename(providerType string, version string, filename string) (os string, arch string, ok bool) {
+	prefix := fmt.Sprintf("terraform-provider-%s_%s_", providerType, version)
+
+	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, ".zip") {
+		return "", "", false
+	}
+
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(filename, prefix), ".zip"), "_")
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+var shasumLine = regexp.MustCompile(`^([0-9a-f]{64})\s+\*?(\S+)$`)
+
+// ParseProviderShasums reads a SHA256SUMS file into the sha256 of each file
+// it lists, keyed by filename.
+func ParseProviderShasums(b []byte) (map[string]string, error) {
+	sums := map[string]string{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+
+		if line == "" {
+			continue
+		}
+
+		m := shasumLine.FindStringSubmatch(line)
+
+		if m == nil {
+			return nil, ErrInvalidShasums{
+				Line: line,
+			}
+		}
+
+		sums[m[2]] = m[1]
+	}
+
+	if len(sums) == 0 {
+		return nil, ErrInvalidShasums{}
+	}
+
+	return sums, nil
+}
+
+func ParseProviderFQN(s string) (ProviderFQN, error) {
+	parts := strings.Split(s, "/")
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ProviderFQN{}, ErrCouldNotParseProviderFQN{
+			Value:   s,
+			Message: "invalid format, expected {namespace}/{type}",
+		}
+	}
+
+	return ProviderFQN{
+		Namespace: parts[0],
+		Type:      parts[1],
+	}, nil
+}
+
+func BuildProviderVersionsTable(p Provider, pvs []ProviderVersion) (h []string, r [][]string) {
+	h = []string{"Provider", "ID", "Version", "Protocols", "Platforms"}
+
+	for _, pv := range pvs {
+		platforms := []string{}
+
+		for _, pl := range pv.Platforms {
+			platforms = append(platforms, pl.OS+"_"+pl.Arch)
+		}
+
+		r = append(r, []string{
+			p.FQN().String(),
+			pv.Id,
+			pv.Version,
+			strings.Join(pv.Protocols, ", "),
+			strings.Join(platforms, ", "),
+		})
+	}
+
+	return
+}
+
+func BuildGPGKeysTable(keys []GPGKey) (h []string, r [][]string) {
+	h = []string{"Namespace", "ID", "Key ID", "Created At"}
+
+	for _, k := range keys {
+		r = append(r, []string{
+			k.Namespace,
+			k.Id,
+			k.KeyId,
+			k.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return
+}
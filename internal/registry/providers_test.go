@@ -0,0 +1,81 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseProviderArchiveFilename(t *testing.T) {
+	tests := []struct {
+		name         string
+		filename     string
+		expectedOS   string
+		expectedArch string
+		expectedOk   bool
+	}{
+		{
+			name:         "release zip",
+			filename:     "terraform-provider-internal_1.0.0_linux_amd64.zip",
+			expectedOS:   "linux",
+			expectedArch: "amd64",
+			expectedOk:   true,
+		},
+		{
+			name:     "other provider",
+			filename: "terraform-provider-external_1.0.0_linux_amd64.zip",
+		},
+		{
+			name:     "other version",
+			filename: "terraform-provider-internal_1.0.1_linux_amd64.zip",
+		},
+		{
+			name:     "not a zip",
+			filename: "terraform-provider-internal_1.0.0_linux_amd64.tar.gz",
+		},
+		{
+			name:     "missing arch",
+			filename: "terraform-provider-internal_1.0.0_linux.zip",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			os, arch, ok := ParseProviderArchiveFilename("internal", "1.0.0", test.filename)
+
+			assert.Equal(tt, test.expectedOk, ok)
+			assert.Equal(tt, test.expectedOS, os)
+			assert.Equal(tt, test.expectedArch, arch)
+		})
+	}
+}
+
+func Test_ParseProviderShasums(t *testing.T) {
+	sha := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	sums, err := ParseProviderShasums([]byte(sha + "  terraform-provider-internal_1.0.0_linux_amd64.zip\n\n" + sha + " *terraform-provider-internal_1.0.0_darwin_arm64.zip\n"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"terraform-provider-internal_1.0.0_linux_amd64.zip":  sha,
+		"terraform-provider-internal_1.0.0_darwin_arm64.zip": sha,
+	}, sums)
+
+	_, err = ParseProviderShasums([]byte("nope  terraform-provider-internal_1.0.0_linux_amd64.zip\n"))
+	assert.Equal(t, ErrInvalidShasums{Line: "nope  terraform-provider-internal_1.0.0_linux_amd64.zip"}, err)
+
+	_, err = ParseProviderShasums([]byte("\n"))
+	assert.Equal(t, ErrInvalidShasums{}, err)
+}
+
+func Test_ParseProviderFQN(t *testing.T) {
+	fqn, err := ParseProviderFQN("platform/internal")
+
+	assert.Nil(t, err)
+	assert.Equal(t, ProviderFQN{Namespace: "platform", Type: "internal"}, fqn)
+
+	for _, invalid := range []string{"", "platform", "platform/", "/internal", "platform/internal/aws"} {
+		_, err := ParseProviderFQN(invalid)
+		assert.IsType(t, ErrCouldNotParseProviderFQN{}, err, invalid)
+	}
+}
@@ -0,0 +1,165 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type refResolver interface {
+	ResolveRef(ctx context.Context, repo string, ref string) (sha string, err error)
+}
+
+type publishModuleVersionRepository interface {
+	AddVersion(context.Context, ModuleVersion) (m ModuleVersion, err error)
+	ById(ctx context.Context, id string) (m Module, err error)
+	VersionByModuleAndValue(ctx context.Context, moduleId string, version string) (mv ModuleVersion, err error)
+}
+
+type publishModuleVersionV1CommandValidator interface {
+	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
+	Validate(cmd interface{}) []ValidationError
+	ModuleMustExistById(ctx context.Context, r moduleMustExistByIdRepository, sl validator.StructLevel, id string)
+	ModuleVersionMustBeUniqueForId(ctx context.Context, r moduleVersionMustBeUniqueForIdRepository, sl validator.StructLevel, id string, version string)
+}
+
+type publishModuleVersionV1Command struct {
+	DTO PublishModuleVersionV1DTO
+}
+
+type PublishModuleVersionV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	ModuleVersion    ModuleVersion
+	ResolvedRef      string
+	ValidationErrors []ValidationError
+}
+
+func (r PublishModuleVersionV1Response) GetActionName() string {
+	return "v1.modules.versions.publish"
+}
+
+func (r PublishModuleVersionV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r PublishModuleVersionV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r PublishModuleVersionV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"module_version_id": r.ModuleVersion.Id,
+		"resolved_ref":      r.ResolvedRef,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+// PublishModuleVersionV1DTO links a version to a commit in the module's
+// repository. The ref may be a branch, tag or commit, it is resolved to the
+// commit it points to at the time of publishing, so the version never changes.
+type PublishModuleVersionV1DTO struct {
+	ModuleId string `json:"-" validate:"required,uuid"`
+	Version  string `json:"version" validate:"required,version"`
+	Ref      string `json:"ref"`
+}
+
+func (dto PublishModuleVersionV1DTO) validate(ctx context.Context, r publishModuleVersionRepository, v publishModuleVersionV1CommandValidator) []ValidationError {
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		v.ModuleMustExistById(ctx, r, sl, dto.ModuleId)
+		v.ModuleVersionMustBeUniqueForId(ctx, r, sl, dto.ModuleId, dto.Version)
+	}, PublishModuleVersionV1DTO{})
+
+	return v.Validate(dto)
+}
+
+func refFor(dto PublishModuleVersionV1DTO, m Module) string {
+	if dto.Ref != "" {
+		return dto.Ref
+	}
+
+	if m.DefaultBranch != "" {
+		return m.DefaultBranch
+	}
+
+	return "HEAD"
+}
+
+func (cmd publishModuleVersionV1Command) handle(ctx context.Context, r publishModuleVersionRepository, resolver refResolver, logger zerolog.Logger, v publishModuleVersionV1CommandValidator) (PublishModuleVersionV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
+		return PublishModuleVersionV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	m, err := r.ById(ctx, cmd.DTO.ModuleId)
+
+	if err != nil {
+		logger.Error().Err(err).Str("command", "publish_module_version").Str("module_id", cmd.DTO.ModuleId).Msg("failed to find module")
+
+		return PublishModuleVersionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if m.RepositoryURL == "" {
+		return PublishModuleVersionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INVALID,
+			ValidationErrors: []ValidationError{
+				{
+					Message: "module has no repository URL, set one with 'ymir module update'",
+					Rule:    "module_has_repository",
+					Field:   "module_id",
+					Value:   cmd.DTO.ModuleId,
+				},
+			},
+		}, nil
+	}
+
+	ref := refFor(cmd.DTO, m)
+	sha, err := resolver.ResolveRef(ctx, m.RepositoryURL, ref)
+
+	if err != nil {
+		logger.Info().Err(err).Str("repository", m.RepositoryURL).Str("ref", ref).Msg("failed to resolve ref")
+
+		return PublishModuleVersionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INVALID,
+			ValidationErrors: []ValidationError{
+				{
+					Message: "could not be resolved to a commit: " + err.Error(),
+					Rule:    "resolvable_ref",
+					Field:   "ref",
+					Value:   ref,
+				},
+			},
+		}, nil
+	}
+
+	addCmd := addModuleVersionV1Command{
+		DTO: AddModuleVersionV1DTO{
+			Version:       cmd.DTO.Version,
+			ModuleId:      m.Id,
+			Source:        sha,
+			RepositoryURL: m.RepositoryURL,
+		},
+	}
+
+	res, err := addCmd.handle(ctx, r, logger, v)
+
+	return PublishModuleVersionV1Response{
+		occurredAt:       occurred,
+		Status:           res.Status,
+		ModuleVersion:    res.ModuleVersion,
+		ResolvedRef:      sha,
+		ValidationErrors: res.ValidationErrors,
+	}, err
+}
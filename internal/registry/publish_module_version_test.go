@@ -0,0 +1,280 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+const publishTestModuleId = "6f1c0bd4-5b8f-4a4e-9d0e-2f4b9f0f9d8a"
+
+type fakeVersionRepository struct {
+	modules    map[string]Module
+	versions   []ModuleVersion
+	updated    []ModuleVersion
+	interfaces []ModuleVersionInterface
+	leased     map[string]time.Time
+	// Versions deleted on purpose, by module id
+	deleted map[string][]string
+}
+
+func (r *fakeVersionRepository) ById(ctx context.Context, id string) (Module, error) {
+	if m, ok := r.modules[id]; ok {
+		return m, nil
+	}
+
+	return Module{}, ErrResourceNotFound{Type: "Module", URI: id}
+}
+
+func (r *fakeVersionRepository) All(ctx context.Context, chunkOpts ChunkingOptions, filters ModuleFilters) ([]Module, error) {
+	mods := []Module{}
+
+	for _, m := range r.modules {
+		mods = append(mods, m)
+	}
+
+	return mods, nil
+}
+
+func (r *fakeVersionRepository) AddVersion(ctx context.Context, mv ModuleVersion) (ModuleVersion, error) {
+	mv.Status = VersionStatuses.Pending
+	r.versions = append(r.versions, mv)
+
+	return mv, nil
+}
+
+func (r *fakeVersionRepository) VersionWasDeleted(ctx context.Context, moduleId string, version string) (bool, error) {
+	for _, deleted := range r.deleted[moduleId] {
+		if deleted == version {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeVersionRepository) VersionByModuleAndValue(ctx context.Context, moduleId string, version string) (ModuleVersion, error) {
+	for _, mv := range r.versions {
+		if mv.ModuleId == moduleId && mv.Version == version {
+			return mv, nil
+		}
+	}
+
+	return ModuleVersion{}, ErrResourceNotFound{Type: "ModuleVersion", URI: moduleId + "@" + version}
+}
+
+func (r *fakeVersionRepository) VersionsByStatus(ctx context.Context, status VersionStatus, chunkOpts ChunkingOptions) ([]ModuleVersion, error) {
+	found := []ModuleVersion{}
+
+	for _, mv := range r.versions {
+		if mv.Status == status {
+			found = append(found, mv)
+		}
+	}
+
+	return found, nil
+}
+
+func (r *fakeVersionRepository) ClaimVersion(ctx context.Context, mv ModuleVersion, from VersionStatus, to VersionStatus) (bool, error) {
+	for i, existing := range r.versions {
+		if existing.Id == mv.Id && existing.Status == from && existing.Source == mv.Source {
+			r.versions[i].Status = to
+			r.lease(mv)
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeVersionRepository) StalledVersions(ctx context.Context, leasedBefore time.Time, chunkOpts ChunkingOptions) ([]ModuleVersion, error) {
+	stalled := []ModuleVersion{}
+
+	for _, mv := range r.versions {
+		if mv.Status == VersionStatuses.Preparing && r.leased[mv.Id].Before(leasedBefore) {
+			stalled = append(stalled, mv)
+		}
+	}
+
+	return stalled, nil
+}
+
+func (r *fakeVersionRepository) ReclaimVersion(ctx context.Context, mv ModuleVersion, leasedBefore time.Time) (bool, error) {
+	for _, existing := range r.versions {
+		if existing.Id == mv.Id && existing.Status == VersionStatuses.Preparing && r.leased[mv.Id].Before(leasedBefore) {
+			r.lease(mv)
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeVersionRepository) lease(mv ModuleVersion) {
+	if r.leased == nil {
+		r.leased = map[string]time.Time{}
+	}
+
+	r.leased[mv.Id] = time.Now()
+}
+
+func (r *fakeVersionRepository) UpdateVersion(ctx context.Context, mv ModuleVersion) (ModuleVersion, error) {
+	for i, existing := range r.versions {
+		if existing.Id == mv.Id {
+			r.versions[i] = mv
+		}
+	}
+
+	r.updated = append(r.updated, mv)
+
+	return mv, nil
+}
+
+func (r *fakeVersionRepository) FinishVersion(ctx context.Context, mv ModuleVersion) (bool, error) {
+	for i, existing := range r.versions {
+		if existing.Id == mv.Id && existing.Status == VersionStatuses.Preparing && existing.Source == mv.Source {
+			r.versions[i].Status = mv.Status
+			r.versions[i].StatusReason = mv.StatusReason
+			r.versions[i].DownloadURL = mv.DownloadURL
+			r.updated = append(r.updated, mv)
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeVersionRepository) SaveVersionInterface(ctx context.Context, i ModuleVersionInterface) error {
+	r.interfaces = append(r.interfaces, i)
+
+	return nil
+}
+
+func (r *fakeVersionRepository) VersionInterface(ctx context.Context, moduleVersionId string) (ModuleVersionInterface, error) {
+	for _, i := range r.interfaces {
+		if i.ModuleVersionId == moduleVersionId {
+			return i, nil
+		}
+	}
+
+	return ModuleVersionInterface{}, ErrResourceNotFound{Type: "ModuleVersionInterface", URI: moduleVersionId}
+}
+
+func (r *fakeVersionRepository) VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) ([]ModuleVersion, error) {
+	found := []ModuleVersion{}
+
+	for _, mv := range r.versions {
+		if mv.ModuleId == moduleId {
+			found = append(found, mv)
+		}
+	}
+
+	return found, nil
+}
+
+type fakeRefResolver struct {
+	refs map[string]string
+}
+
+func (r *fakeRefResolver) ResolveRef(ctx context.Context, repo string, ref string) (string, error) {
+	if sha, ok := r.refs[ref]; ok {
+		return sha, nil
+	}
+
+	return "", errors.New("ref not found")
+}
+
+func Test_publishModuleVersionV1Command_handle(t *testing.T) {
+	resolver := &fakeRefResolver{
+		refs: map[string]string{
+			"v1.0.0": "1111111111111111111111111111111111111111",
+			"main":   "2222222222222222222222222222222222222222",
+			"HEAD":   "3333333333333333333333333333333333333333",
+		},
+	}
+
+	tests := []struct {
+		name           string
+		module         Module
+		dto            PublishModuleVersionV1DTO
+		expectedStatus RegistryHandlerStatus
+		expectedSource string
+		expectedRule   string
+	}{
+		{
+			name:           "ref is resolved",
+			module:         Module{Id: publishTestModuleId, RepositoryURL: "git@github.com:org/modules.git"},
+			dto:            PublishModuleVersionV1DTO{ModuleId: publishTestModuleId, Version: "1.0.0", Ref: "v1.0.0"},
+			expectedStatus: STATUS_CREATED,
+			expectedSource: "1111111111111111111111111111111111111111",
+		},
+		{
+			name:           "falls back to the default branch",
+			module:         Module{Id: publishTestModuleId, RepositoryURL: "git@github.com:org/modules.git", DefaultBranch: "main"},
+			dto:            PublishModuleVersionV1DTO{ModuleId: publishTestModuleId, Version: "1.0.0"},
+			expectedStatus: STATUS_CREATED,
+			expectedSource: "2222222222222222222222222222222222222222",
+		},
+		{
+			name:           "falls back to HEAD",
+			module:         Module{Id: publishTestModuleId, RepositoryURL: "git@github.com:org/modules.git"},
+			dto:            PublishModuleVersionV1DTO{ModuleId: publishTestModuleId, Version: "1.0.0"},
+			expectedStatus: STATUS_CREATED,
+			expectedSource: "3333333333333333333333333333333333333333",
+		},
+		{
+			name:           "module has no repository",
+			module:         Module{Id: publishTestModuleId},
+			dto:            PublishModuleVersionV1DTO{ModuleId: publishTestModuleId, Version: "1.0.0", Ref: "v1.0.0"},
+			expectedStatus: STATUS_INVALID,
+			expectedRule:   "module_has_repository",
+		},
+		{
+			name:           "ref cannot be resolved",
+			module:         Module{Id: publishTestModuleId, RepositoryURL: "git@github.com:org/modules.git"},
+			dto:            PublishModuleVersionV1DTO{ModuleId: publishTestModuleId, Version: "1.0.0", Ref: "nope"},
+			expectedStatus: STATUS_INVALID,
+			expectedRule:   "resolvable_ref",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := &fakeVersionRepository{
+				modules: map[string]Module{
+					test.module.Id: test.module,
+				},
+			}
+
+			cmd := publishModuleVersionV1Command{
+				DTO: test.dto,
+			}
+
+			res, err := cmd.handle(context.Background(), repo, resolver, l, NewCommandValidator(l))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+
+			if test.expectedRule != "" {
+				assert.Len(tt, res.ValidationErrors, 1)
+				assert.Equal(tt, test.expectedRule, res.ValidationErrors[0].Rule)
+				assert.Empty(tt, repo.versions)
+				return
+			}
+
+			assert.Equal(tt, test.expectedSource, res.ResolvedRef)
+			assert.Equal(tt, test.expectedSource, res.ModuleVersion.Source)
+			assert.Equal(tt, test.module.RepositoryURL, res.ModuleVersion.RepositoryURL)
+			assert.Equal(tt, VersionStatuses.Pending, res.ModuleVersion.Status)
+		})
+	}
+}
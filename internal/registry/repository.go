@@ -1,20 +1,135 @@
 package registry
 
+import (
+	"context"
+	"time"
+)
+
 type ModuleRepository interface {
-	ById(id string) (m Module, err error)
-	ByFQN(ModuleFQN) (m Module, err error)
-	All(chunkOpts ChunkingOptions, filters ModuleFilters) ([]Module, error)
-
-	VersionById(id string) (m ModuleVersion, err error)
-	VersionsByModule(moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
-	VersionsByModuleFQN(fqn ModuleFQN, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
-	VersionByModuleAndValue(moduleId string, version string) (mv ModuleVersion, err error)
-	VersionByFQN(fqn ModuleVersionFQN) (mv ModuleVersion, err error)
-
-	AddModule(Module) (Module, error)
-	DeleteModule(Module) error
-
-	AddVersion(ModuleVersion) (m ModuleVersion, err error)
-	DeleteVersionsForModule(Module) error
-	DeleteModuleVersion(ModuleVersion) error
+	ById(ctx context.Context, id string) (m Module, err error)
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+	All(ctx context.Context, chunkOpts ChunkingOptions, filters ModuleFilters) ([]Module, error)
+
+	VersionById(ctx context.Context, id string) (m ModuleVersion, err error)
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	VersionsByModuleFQN(ctx context.Context, fqn ModuleFQN, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	VersionByModuleAndValue(ctx context.Context, moduleId string, version string) (mv ModuleVersion, err error)
+	VersionByFQN(ctx context.Context, fqn ModuleVersionFQN) (mv ModuleVersion, err error)
+	VersionsByStatus(ctx context.Context, status VersionStatus, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	CountVersionsByStatus(ctx context.Context, status VersionStatus) (int, error)
+
+	AddModule(context.Context, Module) (Module, error)
+	UpdateModule(context.Context, Module) (Module, error)
+	DeleteModule(context.Context, Module) error
+
+	AddVersion(context.Context, ModuleVersion) (m ModuleVersion, err error)
+	UpdateVersion(context.Context, ModuleVersion) (m ModuleVersion, err error)
+	ClaimVersion(ctx context.Context, mv ModuleVersion, from VersionStatus, to VersionStatus) (claimed bool, err error)
+	StalledVersions(ctx context.Context, leasedBefore time.Time, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	ReclaimVersion(ctx context.Context, mv ModuleVersion, leasedBefore time.Time) (claimed bool, err error)
+	FinishVersion(ctx context.Context, mv ModuleVersion) (finished bool, err error)
+	DeleteVersionsForModule(context.Context, Module) error
+	DeleteModuleVersion(context.Context, ModuleVersion) error
+	VersionWasDeleted(ctx context.Context, moduleId string, version string) (deleted bool, err error)
+
+	VersionInterface(ctx context.Context, moduleVersionId string) (i ModuleVersionInterface, err error)
+	SaveVersionInterface(context.Context, ModuleVersionInterface) error
+}
+
+type WebhookRepository interface {
+	SubscriptionById(id string) (s WebhookSubscription, err error)
+	AllSubscriptions() ([]WebhookSubscription, error)
+	AddSubscription(WebhookSubscription) (s WebhookSubscription, err error)
+	DeleteSubscription(WebhookSubscription) error
+
+	DeliveriesBySubscription(subscriptionId string, chunkOpts ChunkingOptions) ([]WebhookDelivery, error)
+	DueDeliveries(at time.Time, chunkOpts ChunkingOptions) ([]WebhookDelivery, error)
+	CountDeliveriesByStatus(status DeliveryStatus) (int, error)
+	AddDeliveries([]WebhookDelivery) error
+	ClaimDelivery(d WebhookDelivery, retryAt time.Time) (claimed bool, err error)
+	UpdateDelivery(WebhookDelivery) (d WebhookDelivery, err error)
+	AddDeliveryAttempt(WebhookDeliveryAttempt) error
+}
+
+type ProviderRepository interface {
+	ProviderByFQN(fqn ProviderFQN) (p Provider, err error)
+	AddProvider(Provider) (p Provider, err error)
+
+	VersionsByProvider(providerId string) ([]ProviderVersion, error)
+	ProviderVersionByValue(providerId string, version string) (pv ProviderVersion, err error)
+	AddProviderVersion(ProviderVersion) (pv ProviderVersion, err error)
+
+	GPGKeyById(id string) (k GPGKey, err error)
+	GPGKeysByNamespace(namespace string) ([]GPGKey, error)
+	AllGPGKeys() ([]GPGKey, error)
+	AddGPGKey(GPGKey) (k GPGKey, err error)
+	DeleteGPGKey(GPGKey) error
+	CountVersionsSignedBy(gpgKeyId string) (int, error)
+}
+
+type ProviderMirrorRepository interface {
+	MirroredPackage(a ProviderSourceAddress, version string, os string, arch string) (p MirroredProviderPackage, err error)
+	MirroredPackages(a ProviderSourceAddress, version string) ([]MirroredProviderPackage, error)
+	AddMirroredPackage(MirroredProviderPackage) (p MirroredProviderPackage, err error)
+}
+
+type UpstreamRepository interface {
+	UpstreamModuleByFQN(upstreamURL string, fqn ModuleFQN) (m UpstreamModule, err error)
+	SaveUpstreamModule(UpstreamModule) (m UpstreamModule, err error)
+	UpstreamModuleVersion(upstreamModuleId string, version string) (mv UpstreamModuleVersion, err error)
+	AddUpstreamModuleVersion(UpstreamModuleVersion) (mv UpstreamModuleVersion, err error)
+}
+
+type APITokenRepository interface {
+	TokenById(id string) (t APIToken, err error)
+	TokenByHash(hash string) (t APIToken, err error)
+	AllTokens() ([]APIToken, error)
+	AddToken(APIToken) (t APIToken, err error)
+	// RevokeToken removes the roles granted to the token too
+	RevokeToken(t APIToken, at time.Time) (APIToken, error)
+	TouchToken(t APIToken, at time.Time) error
+}
+
+type UserRepository interface {
+	UserById(id string) (u User, err error)
+	UserByUsername(username string) (u User, err error)
+	AllUsers() ([]User, error)
+	AddUser(User) (u User, err error)
+	// DeleteUser revokes the tokens issued to the user, and removes the roles
+	// granted to them
+	DeleteUser(u User, at time.Time) error
+	AddAuthorizationCode(c AuthorizationCode) error
+	// TakeAuthorizationCode removes the code, so it can only be used once
+	TakeAuthorizationCode(hash string) (c AuthorizationCode, err error)
+}
+
+type NamespaceRepository interface {
+	NamespaceByName(name string) (n Namespace, err error)
+	AllNamespaces() ([]Namespace, error)
+	// AddNamespace grants the owners the admin role in it too
+	AddNamespace(n Namespace, owners []RoleGrant) (Namespace, error)
+	// EnsureNamespace adds the namespace unless it already exists
+	EnsureNamespace(Namespace) error
+	// DeleteNamespace removes the roles granted in it too
+	DeleteNamespace(Namespace) error
+
+	GrantsByNamespace(namespace string) ([]RoleGrant, error)
+	GrantsForSubjects(subjects []Subject) ([]RoleGrant, error)
+	GrantForSubject(namespace string, s Subject) (g RoleGrant, err error)
+	// SaveGrant replaces the role the subject has in the namespace, if any
+	SaveGrant(RoleGrant) (g RoleGrant, err error)
+	DeleteGrant(RoleGrant) error
+}
+
+type TeamRepository interface {
+	TeamByName(name string) (t Team, err error)
+	AllTeams() ([]Team, error)
+	AddTeam(Team) (t Team, err error)
+	// DeleteTeam removes the roles granted to the team too
+	DeleteTeam(Team) error
+
+	TeamMembers(teamId string) ([]User, error)
+	TeamsForUser(userId string) ([]Team, error)
+	AddTeamMember(t Team, u User) error
+	RemoveTeamMember(t Team, u User) error
 }
@@ -0,0 +1,177 @@
+package registry
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/rs/zerolog"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type resolveModuleVersionRepository interface {
+	ById(ctx context.Context, id string) (m Module, err error)
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+}
+
+type resolveModuleVersionV1CommandValidator interface {
+	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+type resolveModuleVersionV1Command struct {
+	DTO ResolveModuleVersionV1DTO
+}
+
+// VersionResolution is the version terraform would choose for a constraint,
+// the highest of the ready versions that match it.
+type VersionResolution struct {
+	Constraint string         `json:"constraint"`
+	Module     Module         `json:"module"`
+	Version    *ModuleVersion `json:"version"`
+	// Every version that matches, highest first
+	Candidates []ModuleVersion `json:"candidates"`
+}
+
+type ResolveModuleVersionV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Resolution       VersionResolution
+	ValidationErrors []ValidationError
+}
+
+func (r ResolveModuleVersionV1Response) GetActionName() string {
+	return "v1.modules.versions.resolve"
+}
+
+func (r ResolveModuleVersionV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ResolveModuleVersionV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ResolveModuleVersionV1Response) GetAuditMeta() map[string]interface{} {
+	resolved := ""
+
+	if r.Resolution.Version != nil {
+		resolved = r.Resolution.Version.Id
+	}
+
+	return map[string]interface{}{
+		"module_id":         r.Resolution.Module.Id,
+		"constraint":        r.Resolution.Constraint,
+		"module_version_id": resolved,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+type ResolveModuleVersionV1DTO struct {
+	ModuleId   string `json:"-" validate:"required,uuid"`
+	Constraint string `json:"constraint" validate:"required,version_constraint"`
+}
+
+type versionCandidate struct {
+	mv     ModuleVersion
+	parsed SemVer
+}
+
+// matchingVersions filters the ready versions down to those the constraint
+// allows. As in terraform, a prerelease only matches a constraint that names
+// a prerelease of the same version.
+func matchingVersions(constraint goversion.Constraints, versions []ModuleVersion) []ModuleVersion {
+	candidates := []versionCandidate{}
+
+	for _, mv := range versions {
+		if mv.Status != VersionStatuses.Ready {
+			continue
+		}
+
+		parsed, err := ParseSemVer(mv.Version)
+
+		if err != nil {
+			continue
+		}
+
+		v, err := goversion.NewVersion(mv.Version)
+
+		if err != nil || !constraint.Check(v) {
+			continue
+		}
+
+		candidates = append(candidates, versionCandidate{mv: mv, parsed: parsed})
+	}
+
+	sort.SliceStable(candidates, func(i int, j int) bool {
+		return candidates[j].parsed.LessThan(candidates[i].parsed)
+	})
+
+	matching := []ModuleVersion{}
+
+	for _, c := range candidates {
+		matching = append(matching, c.mv)
+	}
+
+	return matching
+}
+
+func (cmd resolveModuleVersionV1Command) handle(ctx context.Context, r resolveModuleVersionRepository, logger zerolog.Logger, v resolveModuleVersionV1CommandValidator) (ResolveModuleVersionV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return ResolveModuleVersionV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	m, err := r.ById(ctx, cmd.DTO.ModuleId)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return ResolveModuleVersionV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("module_id", cmd.DTO.ModuleId).Msg("failed to find module")
+
+		return ResolveModuleVersionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	versions, err := r.VersionsByModule(ctx, m.Id, ChunkingOptions{})
+
+	if err != nil {
+		logger.Error().Err(err).Str("module_id", m.Id).Msg("failed to list module versions")
+
+		return ResolveModuleVersionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	// Already checked by the validator
+	constraint, _ := goversion.NewConstraint(cmd.DTO.Constraint)
+	resolution := VersionResolution{
+		Constraint: cmd.DTO.Constraint,
+		Module:     m,
+		Candidates: matchingVersions(constraint, versions),
+	}
+
+	if len(resolution.Candidates) > 0 {
+		resolution.Version = &resolution.Candidates[0]
+	}
+
+	return ResolveModuleVersionV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Resolution: resolution,
+	}, nil
+}
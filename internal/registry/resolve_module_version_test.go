@@ -0,0 +1,133 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+func Test_resolveModuleVersionV1Command_handle(t *testing.T) {
+	ready := VersionStatuses.Ready
+	repo := &fakeVersionRepository{
+		modules: map[string]Module{
+			publishTestModuleId: {Id: publishTestModuleId, Name: "vpc"},
+		},
+		versions: []ModuleVersion{
+			{Id: "a", ModuleId: publishTestModuleId, Version: "3.1.0", Status: ready},
+			{Id: "b", ModuleId: publishTestModuleId, Version: "3.2.0", Status: ready},
+			{Id: "c", ModuleId: publishTestModuleId, Version: "3.10.1", Status: ready},
+			{Id: "d", ModuleId: publishTestModuleId, Version: "3.2.5", Status: ready},
+			{Id: "e", ModuleId: publishTestModuleId, Version: "3.11.0", Status: VersionStatuses.Failed},
+			{Id: "f", ModuleId: publishTestModuleId, Version: "4.0.0-rc.1", Status: ready},
+			{Id: "g", ModuleId: publishTestModuleId, Version: "4.0.0", Status: ready},
+			{Id: "h", ModuleId: publishTestModuleId, Version: "dev-main", Status: ready},
+		},
+	}
+
+	tests := []struct {
+		name               string
+		dto                ResolveModuleVersionV1DTO
+		expectedStatus     RegistryHandlerStatus
+		expectedRules      []string
+		expectedVersion    string
+		expectedCandidates []string
+	}{
+		{
+			name:               "pessimistic minor",
+			dto:                ResolveModuleVersionV1DTO{ModuleId: publishTestModuleId, Constraint: "~> 3.2"},
+			expectedStatus:     STATUS_OKAY,
+			expectedVersion:    "3.10.1",
+			expectedCandidates: []string{"3.10.1", "3.2.5", "3.2.0"},
+		},
+		{
+			name:               "pessimistic patch",
+			dto:                ResolveModuleVersionV1DTO{ModuleId: publishTestModuleId, Constraint: "~> 3.2.0"},
+			expectedStatus:     STATUS_OKAY,
+			expectedVersion:    "3.2.5",
+			expectedCandidates: []string{"3.2.5", "3.2.0"},
+		},
+		{
+			name:               "comma joined with exclusion",
+			dto:                ResolveModuleVersionV1DTO{ModuleId: publishTestModuleId, Constraint: ">= 3.0, < 4.0, != 3.10.1"},
+			expectedStatus:     STATUS_OKAY,
+			expectedVersion:    "3.2.5",
+			expectedCandidates: []string{"3.2.5", "3.2.0", "3.1.0"},
+		},
+		{
+			name:               "prerelease is only matched when named",
+			dto:                ResolveModuleVersionV1DTO{ModuleId: publishTestModuleId, Constraint: "= 4.0.0-rc.1"},
+			expectedStatus:     STATUS_OKAY,
+			expectedVersion:    "4.0.0-rc.1",
+			expectedCandidates: []string{"4.0.0-rc.1"},
+		},
+		{
+			name:               "greater than",
+			dto:                ResolveModuleVersionV1DTO{ModuleId: publishTestModuleId, Constraint: "> 3.10.1"},
+			expectedStatus:     STATUS_OKAY,
+			expectedVersion:    "4.0.0",
+			expectedCandidates: []string{"4.0.0"},
+		},
+		{
+			name:               "nothing matches",
+			dto:                ResolveModuleVersionV1DTO{ModuleId: publishTestModuleId, Constraint: "~> 5.0"},
+			expectedStatus:     STATUS_OKAY,
+			expectedCandidates: []string{},
+		},
+		{
+			name:           "constraint must be valid",
+			dto:            ResolveModuleVersionV1DTO{ModuleId: publishTestModuleId, Constraint: "~> three"},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"version_constraint"},
+		},
+		{
+			name:           "module must exist",
+			dto:            ResolveModuleVersionV1DTO{ModuleId: "0b0e4f54-2a8b-4bfa-8a4a-0f1f9d2f8b11", Constraint: "~> 3.2"},
+			expectedStatus: STATUS_NOT_FOUND,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			cmd := resolveModuleVersionV1Command{
+				DTO: test.dto,
+			}
+
+			res, err := cmd.handle(context.Background(), repo, l, NewCommandValidator(l))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+
+			rules := []string{}
+			for _, e := range res.ValidationErrors {
+				rules = append(rules, e.Rule)
+			}
+
+			if test.expectedRules == nil {
+				test.expectedRules = []string{}
+			}
+
+			assert.Equal(tt, test.expectedRules, rules)
+
+			if res.Status != STATUS_OKAY {
+				return
+			}
+
+			if test.expectedVersion == "" {
+				assert.Nil(tt, res.Resolution.Version)
+			} else {
+				assert.Equal(tt, test.expectedVersion, res.Resolution.Version.Version)
+			}
+
+			candidates := []string{}
+			for _, mv := range res.Resolution.Candidates {
+				candidates = append(candidates, mv.Version)
+			}
+
+			assert.Equal(tt, test.expectedCandidates, candidates)
+		})
+	}
+}
@@ -9,3 +9,6 @@ const STATUS_INVALID RegistryHandlerStatus = "INVALID_PARAMS"
 const STATUS_CREATED RegistryHandlerStatus = "CREATED"
 const STATUS_MODIFIED RegistryHandlerStatus = "MODIFIED"
 const STATUS_CONFLICT RegistryHandlerStatus = "CONFLICT"
+const STATUS_FAILED RegistryHandlerStatus = "FAILED"
+const STATUS_UNAUTHORIZED RegistryHandlerStatus = "UNAUTHORIZED"
+const STATUS_FORBIDDEN RegistryHandlerStatus = "FORBIDDEN"
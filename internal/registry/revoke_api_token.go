@@ -0,0 +1,108 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type revokeAPITokenRepository interface {
+	TokenById(id string) (t APIToken, err error)
+	RevokeToken(t APIToken, at time.Time) (APIToken, error)
+}
+
+type revokeAPITokenV1CommandValidator interface {
+	Validate(cmd interface{}) []ValidationError
+}
+
+// RevokeAPITokenV1DTO stops a token from authenticating, it is kept so the
+// audit log can still be attributed to it.
+type RevokeAPITokenV1DTO struct {
+	Id string `validate:"required,uuid"`
+}
+
+type revokeAPITokenV1Command struct {
+	DTO RevokeAPITokenV1DTO
+}
+
+type RevokeAPITokenV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	APIToken         APIToken
+	ValidationErrors []ValidationError
+}
+
+func (r RevokeAPITokenV1Response) GetActionName() string {
+	return "v1.tokens.revoke"
+}
+
+func (r RevokeAPITokenV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r RevokeAPITokenV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r RevokeAPITokenV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"token_id":          r.APIToken.Id,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (cmd revokeAPITokenV1Command) handle(r revokeAPITokenRepository, logger zerolog.Logger, v revokeAPITokenV1CommandValidator) (RevokeAPITokenV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return RevokeAPITokenV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	t, err := r.TokenById(cmd.DTO.Id)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return RevokeAPITokenV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to find api token")
+
+		return RevokeAPITokenV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	// Revoking again keeps the time it was first revoked
+	if t.RevokedAt != nil {
+		return RevokeAPITokenV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_OKAY,
+			APIToken:   t,
+		}, nil
+	}
+
+	t, err = r.RevokeToken(t, occurred)
+
+	if err != nil {
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to revoke api token")
+
+		return RevokeAPITokenV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return RevokeAPITokenV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		APIToken:   t,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type revokeRoleRepository interface {
+	GrantForSubject(namespace string, s Subject) (g RoleGrant, err error)
+	DeleteGrant(RoleGrant) error
+}
+
+type revokeRoleV1CommandValidator interface {
+	Validate(cmd interface{}) []ValidationError
+}
+
+// RevokeRoleV1DTO removes the role the subject has in the namespace.
+type RevokeRoleV1DTO struct {
+	Namespace string `validate:"required"`
+	Subject   string `validate:"required,subject"`
+}
+
+type revokeRoleV1Command struct {
+	DTO RevokeRoleV1DTO
+}
+
+type RevokeRoleV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Grant            RoleGrant
+	ValidationErrors []ValidationError
+}
+
+func (r RevokeRoleV1Response) GetActionName() string {
+	return "v1.namespaces.revoke"
+}
+
+func (r RevokeRoleV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r RevokeRoleV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r RevokeRoleV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"grant_id":          r.Grant.Id,
+		"namespace":         r.Grant.Namespace,
+		"subject":           r.Grant.Subject,
+		"role":              r.Grant.Role,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+// handle is NOT_FOUND when the subject doesn't exist, or has no role in the
+// namespace.
+func (cmd revokeRoleV1Command) handle(r revokeRoleRepository, s subjectResolver, logger zerolog.Logger, v revokeRoleV1CommandValidator) (RevokeRoleV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return RevokeRoleV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	subject, _, err := s.resolve(cmd.DTO.Subject)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return RevokeRoleV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("subject", cmd.DTO.Subject).Msg("failed to find subject")
+
+		return RevokeRoleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	g, err := r.GrantForSubject(cmd.DTO.Namespace, subject)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return RevokeRoleV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("subject", cmd.DTO.Subject).Msg("failed to find grant")
+
+		return RevokeRoleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if err := r.DeleteGrant(g); err != nil {
+		logger.Error().Err(err).Str("subject", cmd.DTO.Subject).Msg("failed to delete grant")
+
+		return RevokeRoleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return RevokeRoleV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Grant:      g,
+	}, nil
+}
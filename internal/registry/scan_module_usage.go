@@ -0,0 +1,380 @@
+package registry
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type UsageStatus string
+
+type usageStatusesContainer struct {
+	UpToDate          UsageStatus
+	Outdated          UsageStatus
+	Unpinned          UsageStatus
+	Unresolved        UsageStatus
+	UnknownModule     UsageStatus
+	InvalidConstraint UsageStatus
+}
+
+var UsageStatuses usageStatusesContainer = usageStatusesContainer{
+	UpToDate:          "up_to_date",
+	Outdated:          "outdated",
+	Unpinned:          "unpinned",
+	Unresolved:        "unresolved",
+	UnknownModule:     "unknown_module",
+	InvalidConstraint: "invalid_constraint",
+}
+
+type moduleCallScanner interface {
+	ModuleCalls(root string) (calls []ModuleCall, invalid []error, err error)
+}
+
+type scanModuleUsageRepository interface {
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	VersionInterface(ctx context.Context, moduleVersionId string) (i ModuleVersionInterface, err error)
+}
+
+type scanModuleUsageV1CommandValidator interface {
+	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+// ModuleUsage is a module block sourced from this registry, and how its
+// version constraint compares with the versions that are ready.
+type ModuleUsage struct {
+	Name       string      `json:"name"`
+	Source     string      `json:"source"`
+	Constraint string      `json:"constraint"`
+	Pos        SourcePos   `json:"pos"`
+	Module     ModuleFQN   `json:"module"`
+	Status     UsageStatus `json:"status"`
+	// The version terraform would install for the constraint
+	Resolved string `json:"resolved,omitempty"`
+	Latest   string `json:"latest,omitempty"`
+	// Whether upgrading from the resolved version to the latest may break
+	Breaking        bool              `json:"breaking"`
+	BreakingChanges []InterfaceChange `json:"breaking_changes"`
+}
+
+type UsageReport struct {
+	Usages []ModuleUsage `json:"usages"`
+	// Directories that could not be parsed, and why
+	Skipped []string `json:"skipped"`
+}
+
+func (r UsageReport) CountByStatus(s UsageStatus) int {
+	total := 0
+
+	for _, u := range r.Usages {
+		if u.Status == s {
+			total++
+		}
+	}
+
+	return total
+}
+
+func (r UsageReport) CountBreaking() int {
+	total := 0
+
+	for _, u := range r.Usages {
+		if u.Breaking {
+			total++
+		}
+	}
+
+	return total
+}
+
+// ScanModuleUsageV1DTO points at a terraform codebase, only module sources on
+// the hostname are looked up in the registry.
+type ScanModuleUsageV1DTO struct {
+	Directory string `json:"directory" validate:"required"`
+	Hostname  string `json:"hostname" validate:"required"`
+}
+
+type scanModuleUsageV1Command struct {
+	DTO ScanModuleUsageV1DTO
+}
+
+type ScanModuleUsageV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Report           UsageReport
+	ValidationErrors []ValidationError
+}
+
+func (r ScanModuleUsageV1Response) GetActionName() string {
+	return "v1.modules.usage.scan"
+}
+
+func (r ScanModuleUsageV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ScanModuleUsageV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ScanModuleUsageV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"total":             len(r.Report.Usages),
+		"outdated":          r.Report.CountByStatus(UsageStatuses.Outdated),
+		"breaking":          r.Report.CountBreaking(),
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+// ParseRegistrySource splits a registry module source, as in
+// hostname/namespace/name/provider//subdir, the subdirectory is dropped.
+func ParseRegistrySource(source string) (hostname string, fqn ModuleFQN, ok bool) {
+	if i := strings.Index(source, "//"); i >= 0 {
+		source = source[:i]
+	}
+
+	parts := strings.Split(source, "/")
+
+	if len(parts) != 4 {
+		return "", ModuleFQN{}, false
+	}
+
+	for _, p := range parts {
+		if p == "" {
+			return "", ModuleFQN{}, false
+		}
+	}
+
+	return parts[0], ModuleFQN{
+		Namespace: parts[1],
+		Name:      parts[2],
+		Provider:  parts[3],
+	}, true
+}
+
+type usageModule struct {
+	found    bool
+	versions []ModuleVersion
+}
+
+// moduleVersions looks each module up once however many times it is used.
+func moduleVersions(ctx context.Context, r scanModuleUsageRepository, cache map[ModuleFQN]usageModule, fqn ModuleFQN) (usageModule, error) {
+	if m, ok := cache[fqn]; ok {
+		return m, nil
+	}
+
+	m, err := r.ByFQN(ctx, fqn)
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		cache[fqn] = usageModule{}
+
+		return cache[fqn], nil
+	}
+
+	if err != nil {
+		return usageModule{}, err
+	}
+
+	versions, err := r.VersionsByModule(ctx, m.Id, ChunkingOptions{})
+
+	if err != nil {
+		return usageModule{}, err
+	}
+
+	cache[fqn] = usageModule{
+		found:    true,
+		versions: versions,
+	}
+
+	return cache[fqn], nil
+}
+
+// breakingUpgrade compares the interfaces when both were parsed, otherwise
+// the upgrade is assumed to break whenever semver allows it to.
+func breakingUpgrade(ctx context.Context, r scanModuleUsageRepository, from ModuleVersion, to ModuleVersion) (bool, []InterfaceChange, error) {
+	fromIface, fromErr := r.VersionInterface(ctx, from.Id)
+	toIface, toErr := r.VersionInterface(ctx, to.Id)
+
+	for _, err := range []error{fromErr, toErr} {
+		if _, ok := err.(ErrResourceNotFound); err != nil && !ok {
+			return false, nil, err
+		}
+	}
+
+	if fromErr != nil || toErr != nil {
+		return AllowsBreakingChanges(from.Version, to.Version), []InterfaceChange{}, nil
+	}
+
+	changes := BreakingChanges(DiffInterfaces(fromIface.Interface, toIface.Interface))
+
+	return len(changes) > 0, changes, nil
+}
+
+func (cmd scanModuleUsageV1Command) usage(ctx context.Context, r scanModuleUsageRepository, cache map[ModuleFQN]usageModule, call ModuleCall, fqn ModuleFQN) (ModuleUsage, error) {
+	u := ModuleUsage{
+		Name:            call.Name,
+		Source:          call.Source,
+		Constraint:      call.Version,
+		Pos:             call.Pos,
+		Module:          fqn,
+		BreakingChanges: []InterfaceChange{},
+	}
+
+	m, err := moduleVersions(ctx, r, cache, fqn)
+
+	if err != nil {
+		return u, err
+	}
+
+	if !m.found {
+		u.Status = UsageStatuses.UnknownModule
+
+		return u, nil
+	}
+
+	latest, hasLatest := LatestVersion(m.versions)
+
+	if hasLatest {
+		u.Latest = latest.Version
+	}
+
+	if call.Version == "" {
+		// Terraform installs the latest version when there is no constraint
+		u.Status = UsageStatuses.Unpinned
+		u.Resolved = u.Latest
+
+		return u, nil
+	}
+
+	constraint, err := goversion.NewConstraint(call.Version)
+
+	if err != nil {
+		u.Status = UsageStatuses.InvalidConstraint
+
+		return u, nil
+	}
+
+	matching := matchingVersions(constraint, m.versions)
+
+	if len(matching) == 0 {
+		u.Status = UsageStatuses.Unresolved
+
+		return u, nil
+	}
+
+	resolved := matching[0]
+	u.Resolved = resolved.Version
+	u.Status = UsageStatuses.UpToDate
+
+	if !hasLatest || resolved.Id == latest.Id {
+		return u, nil
+	}
+
+	resolvedParsed, _ := ParseSemVer(resolved.Version)
+	latestParsed, _ := ParseSemVer(latest.Version)
+
+	if !resolvedParsed.LessThan(latestParsed) {
+		// A constraint on a prerelease of the next version
+		return u, nil
+	}
+
+	u.Status = UsageStatuses.Outdated
+	u.Breaking, u.BreakingChanges, err = breakingUpgrade(ctx, r, resolved, latest)
+
+	return u, err
+}
+
+func (cmd scanModuleUsageV1Command) handle(ctx context.Context, fs afero.Fs, s moduleCallScanner, r scanModuleUsageRepository, logger zerolog.Logger, v scanModuleUsageV1CommandValidator) (ScanModuleUsageV1Response, error) {
+	occurred := time.Now().UTC()
+
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		if cmd.DTO.Directory == "" {
+			return
+		}
+
+		if exists, _ := afero.DirExists(fs, cmd.DTO.Directory); !exists {
+			sl.ReportError(cmd.DTO.Directory, "directory", "Directory", directoryTag, "")
+		}
+	}, ScanModuleUsageV1DTO{})
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return ScanModuleUsageV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	internalError := func(err error, msg string) (ScanModuleUsageV1Response, error) {
+		logger.Error().Err(err).Str("dir", cmd.DTO.Directory).Msg(msg)
+
+		return ScanModuleUsageV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	calls, invalid, err := s.ModuleCalls(cmd.DTO.Directory)
+
+	if err != nil {
+		return internalError(err, "failed to scan directory for module calls")
+	}
+
+	report := UsageReport{
+		Usages:  []ModuleUsage{},
+		Skipped: []string{},
+	}
+
+	for _, err := range invalid {
+		report.Skipped = append(report.Skipped, err.Error())
+	}
+
+	cache := map[ModuleFQN]usageModule{}
+
+	for _, call := range calls {
+		hostname, fqn, ok := ParseRegistrySource(call.Source)
+
+		if !ok || !strings.EqualFold(hostname, cmd.DTO.Hostname) {
+			continue
+		}
+
+		u, err := cmd.usage(ctx, r, cache, call, fqn)
+
+		if err != nil {
+			return internalError(err, "failed to compare module usage with its versions")
+		}
+
+		report.Usages = append(report.Usages, u)
+	}
+
+	return ScanModuleUsageV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Report:     report,
+	}, nil
+}
+
+func BuildUsageReportTable(usages []ModuleUsage) (h []string, r [][]string) {
+	h = []string{"Location", "Module", "Constraint", "Resolved", "Latest", "Status", "Breaking"}
+
+	for _, u := range usages {
+		r = append(r, []string{
+			u.Pos.String(),
+			u.Source,
+			u.Constraint,
+			u.Resolved,
+			u.Latest,
+			string(u.Status),
+			strconv.FormatBool(u.Breaking),
+		})
+	}
+
+	return
+}
@@ -0,0 +1,217 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeUsageRepository struct {
+	*fakeVersionRepository
+}
+
+func (r fakeUsageRepository) ByFQN(ctx context.Context, fqn ModuleFQN) (Module, error) {
+	for _, m := range r.modules {
+		if m.Namespace == fqn.Namespace && m.Name == fqn.Name && m.Provider == fqn.Provider {
+			return m, nil
+		}
+	}
+
+	return Module{}, ErrResourceNotFound{Type: "Module", URI: fqn.String()}
+}
+
+type fakeModuleCallScanner struct {
+	calls   []ModuleCall
+	invalid []error
+}
+
+func (s *fakeModuleCallScanner) ModuleCalls(root string) ([]ModuleCall, []error, error) {
+	return s.calls, s.invalid, nil
+}
+
+func Test_ParseRegistrySource(t *testing.T) {
+	tests := []struct {
+		source           string
+		expectedHostname string
+		expectedFQN      ModuleFQN
+		expectedOk       bool
+	}{
+		{
+			source:           "registry.example.com/platform/vpc/aws",
+			expectedHostname: "registry.example.com",
+			expectedFQN:      ModuleFQN{Namespace: "platform", Name: "vpc", Provider: "aws"},
+			expectedOk:       true,
+		},
+		{
+			source:           "localhost:8080/platform/vpc/aws//modules/subnets",
+			expectedHostname: "localhost:8080",
+			expectedFQN:      ModuleFQN{Namespace: "platform", Name: "vpc", Provider: "aws"},
+			expectedOk:       true,
+		},
+		{source: "platform/vpc/aws"},
+		{source: "./modules/vpc"},
+		{source: "git::https://example.com/vpc.git//modules"},
+		{source: "registry.example.com//vpc/aws"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(tt *testing.T) {
+			hostname, fqn, ok := ParseRegistrySource(test.source)
+
+			assert.Equal(tt, test.expectedOk, ok)
+			assert.Equal(tt, test.expectedHostname, hostname)
+			assert.Equal(tt, test.expectedFQN, fqn)
+		})
+	}
+}
+
+func Test_scanModuleUsageV1Command_handle(t *testing.T) {
+	ready := VersionStatuses.Ready
+	vpcId := publishTestModuleId
+	labelsId := "0d7e4a0c-3c1e-4b53-8d0a-6e9f2f8a4c55"
+	repo := fakeUsageRepository{&fakeVersionRepository{
+		modules: map[string]Module{
+			vpcId:    {Id: vpcId, Namespace: "platform", Name: "vpc", Provider: "aws"},
+			labelsId: {Id: labelsId, Namespace: "platform", Name: "labels", Provider: "null"},
+		},
+		versions: []ModuleVersion{
+			{Id: "a", ModuleId: vpcId, Version: "1.0.0", Status: ready},
+			{Id: "b", ModuleId: vpcId, Version: "1.2.0", Status: ready},
+			{Id: "c", ModuleId: vpcId, Version: "2.0.0", Status: ready},
+			{Id: "d", ModuleId: vpcId, Version: "2.1.0", Status: VersionStatuses.Pending},
+			{Id: "e", ModuleId: labelsId, Version: "0.1.0", Status: ready},
+			{Id: "f", ModuleId: labelsId, Version: "0.2.0", Status: ready},
+		},
+		interfaces: []ModuleVersionInterface{
+			{ModuleVersionId: "b", Interface: ModuleInterface{
+				Variables: []ModuleVariable{{Name: "cidr", Type: "string", Required: true}},
+			}},
+			{ModuleVersionId: "c", Interface: ModuleInterface{
+				Variables: []ModuleVariable{{Name: "cidr", Type: "string", Required: true}, {Name: "name", Type: "string", Required: true}},
+			}},
+		},
+	}}
+
+	call := func(source string, version string, line int) ModuleCall {
+		return ModuleCall{Name: "m", Source: source, Version: version, Pos: SourcePos{Filename: "main.tf", Line: line}}
+	}
+
+	scanner := &fakeModuleCallScanner{
+		calls: []ModuleCall{
+			call("registry.example.com/platform/vpc/aws", "~> 1.0", 1),
+			call("registry.example.com/platform/vpc/aws", "~> 2.0", 2),
+			call("registry.example.com/platform/vpc/aws", "", 3),
+			call("registry.example.com/platform/vpc/aws", "~> 3.0", 4),
+			call("registry.example.com/platform/vpc/aws", "~> nope", 5),
+			call("registry.terraform.io/platform/vpc/aws", "~> 1.0", 6),
+			call("./modules/vpc", "", 7),
+			call("registry.example.com/platform/missing/aws", "1.0.0", 8),
+			call("registry.example.com/platform/labels/null", "0.1.0", 9),
+			call("REGISTRY.example.com/platform/vpc/aws//modules/subnets", "1.0.0", 10),
+		},
+		invalid: []error{errors.New("module in broken could not be parsed")},
+	}
+
+	type expectedUsage struct {
+		Line            int
+		Status          UsageStatus
+		Resolved        string
+		Latest          string
+		Breaking        bool
+		BreakingChanges int
+	}
+
+	tests := []struct {
+		name           string
+		dto            ScanModuleUsageV1DTO
+		expectedStatus RegistryHandlerStatus
+		expectedRules  []string
+		expectedUsages []expectedUsage
+	}{
+		{
+			name:           "compares usages with the ready versions",
+			dto:            ScanModuleUsageV1DTO{Directory: "/code", Hostname: "registry.example.com"},
+			expectedStatus: STATUS_OKAY,
+			expectedUsages: []expectedUsage{
+				{Line: 1, Status: UsageStatuses.Outdated, Resolved: "1.2.0", Latest: "2.0.0", Breaking: true, BreakingChanges: 1},
+				{Line: 2, Status: UsageStatuses.UpToDate, Resolved: "2.0.0", Latest: "2.0.0"},
+				{Line: 3, Status: UsageStatuses.Unpinned, Resolved: "2.0.0", Latest: "2.0.0"},
+				{Line: 4, Status: UsageStatuses.Unresolved, Latest: "2.0.0"},
+				{Line: 5, Status: UsageStatuses.InvalidConstraint, Latest: "2.0.0"},
+				{Line: 8, Status: UsageStatuses.UnknownModule},
+				// Without parsed interfaces semver decides
+				{Line: 9, Status: UsageStatuses.Outdated, Resolved: "0.1.0", Latest: "0.2.0", Breaking: true},
+				{Line: 10, Status: UsageStatuses.Outdated, Resolved: "1.0.0", Latest: "2.0.0", Breaking: true},
+			},
+		},
+		{
+			name:           "directory and hostname are required",
+			dto:            ScanModuleUsageV1DTO{},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"required", "required"},
+		},
+		{
+			name:           "directory must exist",
+			dto:            ScanModuleUsageV1DTO{Directory: "/nope", Hostname: "registry.example.com"},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"directory"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			fs := afero.NewMemMapFs()
+
+			if err := fs.MkdirAll("/code", 0755); err != nil {
+				tt.Fatal(err)
+			}
+
+			cmd := scanModuleUsageV1Command{
+				DTO: test.dto,
+			}
+
+			res, err := cmd.handle(context.Background(), fs, scanner, repo, l, NewCommandValidator(l))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+
+			rules := []string{}
+			for _, e := range res.ValidationErrors {
+				rules = append(rules, e.Rule)
+			}
+
+			if test.expectedRules == nil {
+				test.expectedRules = []string{}
+			}
+
+			assert.Equal(tt, test.expectedRules, rules)
+
+			if res.Status != STATUS_OKAY {
+				return
+			}
+
+			usages := []expectedUsage{}
+			for _, u := range res.Report.Usages {
+				usages = append(usages, expectedUsage{
+					Line:            u.Pos.Line,
+					Status:          u.Status,
+					Resolved:        u.Resolved,
+					Latest:          u.Latest,
+					Breaking:        u.Breaking,
+					BreakingChanges: len(u.BreakingChanges),
+				})
+			}
+
+			assert.Equal(tt, test.expectedUsages, usages)
+			assert.Equal(tt, []string{"module in broken could not be parsed"}, res.Report.Skipped)
+			assert.Equal(tt, 3, res.Report.CountByStatus(UsageStatuses.Outdated))
+			assert.Equal(tt, 3, res.Report.CountBreaking())
+		})
+	}
+}
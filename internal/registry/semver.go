@@ -0,0 +1,179 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SemVer is a version as defined by semver 2.0, see https://semver.org.
+type SemVer struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	// Build metadata is kept, but plays no part in precedence
+	Build string
+}
+
+var semVerPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)` +
+	`(?:-((?:0|[1-9][0-9]*|[0-9]*[A-Za-z-][0-9A-Za-z-]*)(?:\.(?:0|[1-9][0-9]*|[0-9]*[A-Za-z-][0-9A-Za-z-]*))*))?` +
+	`(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// ParseSemVer parses a version strictly, there is no leading v and numbers
+// may not have leading zeros.
+func ParseSemVer(v string) (SemVer, error) {
+	parts := semVerPattern.FindStringSubmatch(v)
+
+	if parts == nil {
+		return SemVer{}, ErrInvalidSemVer{Version: v}
+	}
+
+	parsed := SemVer{
+		Build: parts[5],
+	}
+
+	for i, dest := range []*uint64{&parsed.Major, &parsed.Minor, &parsed.Patch} {
+		n, err := strconv.ParseUint(parts[i+1], 10, 64)
+
+		if err != nil {
+			return SemVer{}, ErrInvalidSemVer{Version: v}
+		}
+
+		*dest = n
+	}
+
+	if parts[4] != "" {
+		parsed.Prerelease = strings.Split(parts[4], ".")
+	}
+
+	return parsed, nil
+}
+
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+
+	if v.IsPrerelease() {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+
+	return s
+}
+
+func (v SemVer) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+func isNumericIdentifier(id string) bool {
+	for _, c := range id {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return id != ""
+}
+
+func compareUint(a uint64, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func comparePrereleaseIdentifier(a string, b string) int {
+	aNumeric, bNumeric := isNumericIdentifier(a), isNumericIdentifier(b)
+
+	switch {
+	case aNumeric && bNumeric:
+		an, _ := strconv.ParseUint(a, 10, 64)
+		bn, _ := strconv.ParseUint(b, 10, 64)
+
+		return compareUint(an, bn)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// Compare is -1, 0 or 1 as the version has lower, equal or higher precedence
+// than the other.
+func (v SemVer) Compare(o SemVer) int {
+	for _, c := range []int{compareUint(v.Major, o.Major), compareUint(v.Minor, o.Minor), compareUint(v.Patch, o.Patch)} {
+		if c != 0 {
+			return c
+		}
+	}
+
+	// A release has higher precedence than its prereleases
+	switch {
+	case !v.IsPrerelease() && !o.IsPrerelease():
+		return 0
+	case !v.IsPrerelease():
+		return 1
+	case !o.IsPrerelease():
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := comparePrereleaseIdentifier(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareUint(uint64(len(v.Prerelease)), uint64(len(o.Prerelease)))
+}
+
+func (v SemVer) LessThan(o SemVer) bool {
+	return v.Compare(o) < 0
+}
+
+// SortKey orders versions by precedence when compared byte by byte. Numbers
+// are padded to the width of the largest uint64, a release sorts after its
+// prereleases, and numeric identifiers before alphanumeric ones.
+func (v SemVer) SortKey() string {
+	key := fmt.Sprintf("%020d.%020d.%020d", v.Major, v.Minor, v.Patch)
+
+	if !v.IsPrerelease() {
+		return key + "~"
+	}
+
+	ids := []string{}
+
+	for _, id := range v.Prerelease {
+		if isNumericIdentifier(id) {
+			n, _ := strconv.ParseUint(id, 10, 64)
+			ids = append(ids, fmt.Sprintf("0%020d", n))
+		} else {
+			ids = append(ids, "1"+id)
+		}
+	}
+
+	// ! sorts before every character allowed in an identifier, so a shorter
+	// list of identifiers sorts first
+	return key + "-" + strings.Join(ids, "!")
+}
+
+// VersionSortKey is the key module versions are ordered by. Versions that are
+// not semver, such as dev versions, sort before every release.
+func VersionSortKey(version string) string {
+	parsed, err := ParseSemVer(version)
+
+	if err != nil {
+		return ""
+	}
+
+	return parsed.SortKey()
+}
@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseSemVer(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected SemVer
+	}{
+		{version: "1.2.3", expected: SemVer{Major: 1, Minor: 2, Patch: 3}},
+		{version: "0.0.0", expected: SemVer{}},
+		{version: "1.2.0-rc.1", expected: SemVer{Major: 1, Minor: 2, Prerelease: []string{"rc", "1"}}},
+		{version: "1.0.0-alpha-1.0a", expected: SemVer{Major: 1, Prerelease: []string{"alpha-1", "0a"}}},
+		{version: "1.0.0+20130313144700", expected: SemVer{Major: 1, Build: "20130313144700"}},
+		{version: "1.0.0-beta+exp.sha.5114f85", expected: SemVer{Major: 1, Prerelease: []string{"beta"}, Build: "exp.sha.5114f85"}},
+		{version: "18446744073709551615.0.0", expected: SemVer{Major: 18446744073709551615}},
+	}
+
+	for _, test := range tests {
+		parsed, err := ParseSemVer(test.version)
+
+		assert.Nil(t, err, test.version)
+		assert.Equal(t, test.expected, parsed, test.version)
+		assert.Equal(t, test.version, parsed.String())
+	}
+
+	for _, invalid := range []string{
+		"",
+		"1",
+		"1.2",
+		"v1.2.3",
+		"01.2.3",
+		"1.02.3",
+		"1.2.3-",
+		"1.2.3-01",
+		"1.2.3-rc..1",
+		"1.2.3+",
+		"1.2.3-rc_1",
+		"1.2.3.4",
+		"18446744073709551616.0.0",
+		"dev-main",
+	} {
+		_, err := ParseSemVer(invalid)
+
+		assert.IsType(t, ErrInvalidSemVer{}, err, invalid)
+	}
+}
+
+// In order of precedence, as given by semver.org
+var orderedVersions = []string{
+	"1.0.0-alpha",
+	"1.0.0-alpha.1",
+	"1.0.0-alpha.beta",
+	"1.0.0-beta",
+	"1.0.0-beta.2",
+	"1.0.0-beta.11",
+	"1.0.0-rc.1",
+	"1.0.0",
+	"1.0.1-0",
+	"1.0.1-alpha",
+	"1.0.1-alpha.x",
+	"1.0.1-alpha-1",
+	"1.0.1",
+	"1.2.0",
+	"1.10.0",
+	"2.0.0",
+	"10.0.0",
+}
+
+func Test_SemVer_Compare(t *testing.T) {
+	for i, a := range orderedVersions {
+		va, _ := ParseSemVer(a)
+
+		for j, b := range orderedVersions {
+			vb, _ := ParseSemVer(b)
+
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+
+			assert.Equal(t, expected, va.Compare(vb), "%s compared to %s", a, b)
+		}
+	}
+
+	a, _ := ParseSemVer("1.0.0+build.1")
+	b, _ := ParseSemVer("1.0.0+build.2")
+	assert.Equal(t, 0, a.Compare(b))
+}
+
+func Test_SemVer_SortKey(t *testing.T) {
+	shuffled := append([]string{}, orderedVersions...)
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i int, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	// Compared byte by byte, as they are by the database
+	sort.Slice(shuffled, func(i int, j int) bool {
+		return VersionSortKey(shuffled[i]) < VersionSortKey(shuffled[j])
+	})
+
+	assert.Equal(t, orderedVersions, shuffled)
+	assert.Equal(t, VersionSortKey("1.0.0"), VersionSortKey("1.0.0+build.1"))
+	assert.Equal(t, "", VersionSortKey("dev-main"))
+}
@@ -1,9 +1,23 @@
 package registry
 
-type ServiceDiscoveryCommand struct{}
+type ServiceDiscoveryCommand struct {
+	// Login is advertised when `terraform login` is enabled
+	Login *LoginOptions
+}
+
+// LoginServiceDiscovery describes the OAuth client terraform logs in with.
+type LoginServiceDiscovery struct {
+	Client     string   `json:"client"`
+	GrantTypes []string `json:"grant_types"`
+	Authz      string   `json:"authz"`
+	Token      string   `json:"token"`
+	Ports      [2]int   `json:"ports"`
+}
 
 type HandleServiceDiscoveryResponseBody struct {
-	ModuleVersion string `json:"modules.v1"`
+	ModuleVersion   string                 `json:"modules.v1"`
+	ProviderVersion string                 `json:"providers.v1"`
+	Login           *LoginServiceDiscovery `json:"login.v1,omitempty"`
 }
 
 type HandleServiceDiscoveryResponse struct {
@@ -11,11 +25,24 @@ type HandleServiceDiscoveryResponse struct {
 	Body   HandleServiceDiscoveryResponseBody
 }
 
-func (_ ServiceDiscoveryCommand) Handle() HandleServiceDiscoveryResponse {
+func (cmd ServiceDiscoveryCommand) Handle() HandleServiceDiscoveryResponse {
+	body := HandleServiceDiscoveryResponseBody{
+		ModuleVersion:   "/v1/modules/",
+		ProviderVersion: "/v1/providers/",
+	}
+
+	if cmd.Login != nil {
+		body.Login = &LoginServiceDiscovery{
+			Client:     LoginClientId,
+			GrantTypes: []string{"authz_code"},
+			Authz:      "/oauth/authorization",
+			Token:      "/oauth/token",
+			Ports:      cmd.Login.Ports,
+		}
+	}
+
 	return HandleServiceDiscoveryResponse{
 		Status: STATUS_OKAY,
-		Body: HandleServiceDiscoveryResponseBody{
-			ModuleVersion: "/v1/modules/",
-		},
+		Body:   body,
 	}
 }
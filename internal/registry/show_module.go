@@ -1,13 +1,14 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 type showModuleRepository interface {
-	ById(string) (m Module, err error)
+	ById(context.Context, string) (m Module, err error)
 }
 
 type showModuleV1CommandValidator interface {
@@ -50,13 +51,13 @@ type ShowModuleV1DTO struct {
 	Id string `validate:"required,uuid"`
 }
 
-func (dto ShowModuleV1DTO) validate(r showModuleRepository, v showModuleV1CommandValidator) []ValidationError {
+func (dto ShowModuleV1DTO) validate(ctx context.Context, r showModuleRepository, v showModuleV1CommandValidator) []ValidationError {
 	return v.Validate(dto)
 }
 
-func (cmd showModuleV1Command) handle(r showModuleRepository, logger zerolog.Logger, v showModuleV1CommandValidator) (ShowModuleV1Response, error) {
+func (cmd showModuleV1Command) handle(ctx context.Context, r showModuleRepository, logger zerolog.Logger, v showModuleV1CommandValidator) (ShowModuleV1Response, error) {
 	occurred := time.Now().UTC()
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return ShowModuleV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -64,7 +65,7 @@ func (cmd showModuleV1Command) handle(r showModuleRepository, logger zerolog.Log
 		}, nil
 	}
 
-	m, err := r.ById(cmd.DTO.Id)
+	m, err := r.ById(ctx, cmd.DTO.Id)
 
 	if err != nil {
 		if _, ok := err.(ErrResourceNotFound); ok {
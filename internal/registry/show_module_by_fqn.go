@@ -1,13 +1,14 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 type showModuleByFqnRepository interface {
-	ByFQN(ModuleFQN) (m Module, err error)
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
 }
 
 type showModuleByFqnV1CommandValidator interface {
@@ -22,13 +23,13 @@ type ShowModuleV1ByFqnDTO struct {
 	FQN ModuleFQN `validate:"required"`
 }
 
-func (dto ShowModuleV1ByFqnDTO) validate(r showModuleByFqnRepository, v showModuleByFqnV1CommandValidator) []ValidationError {
+func (dto ShowModuleV1ByFqnDTO) validate(ctx context.Context, r showModuleByFqnRepository, v showModuleByFqnV1CommandValidator) []ValidationError {
 	return v.Validate(dto)
 }
 
-func (cmd showModuleV1ByFqnCommand) handle(r showModuleByFqnRepository, logger zerolog.Logger, v showModuleByFqnV1CommandValidator) (ShowModuleV1Response, error) {
+func (cmd showModuleV1ByFqnCommand) handle(ctx context.Context, r showModuleByFqnRepository, logger zerolog.Logger, v showModuleByFqnV1CommandValidator) (ShowModuleV1Response, error) {
 	occurred := time.Now().UTC()
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return ShowModuleV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -36,7 +37,7 @@ func (cmd showModuleV1ByFqnCommand) handle(r showModuleByFqnRepository, logger z
 		}, nil
 	}
 
-	m, err := r.ByFQN(cmd.DTO.FQN)
+	m, err := r.ByFQN(ctx, cmd.DTO.FQN)
 
 	if err != nil {
 		if _, ok := err.(ErrResourceNotFound); ok {
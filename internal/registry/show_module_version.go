@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -8,8 +9,8 @@ import (
 )
 
 type showModuleVersionRepository interface {
-	VersionByFQN(ModuleVersionFQN) (m ModuleVersion, err error)
-	VersionById(string) (m ModuleVersion, err error)
+	VersionByFQN(context.Context, ModuleVersionFQN) (m ModuleVersion, err error)
+	VersionById(context.Context, string) (m ModuleVersion, err error)
 }
 
 type showModuleVersionV1CommandValidator interface {
@@ -52,14 +53,14 @@ type ShowModuleVersionV1DTO struct {
 	Id string `validate:"required,uuid"`
 }
 
-func (dto ShowModuleVersionV1DTO) validate(r showModuleVersionRepository, v showModuleVersionV1CommandValidator) []ValidationError {
+func (dto ShowModuleVersionV1DTO) validate(ctx context.Context, r showModuleVersionRepository, v showModuleVersionV1CommandValidator) []ValidationError {
 	return v.Validate(dto)
 }
 
-func (cmd showModuleVersionV1Command) handle(r showModuleVersionRepository, logger zerolog.Logger, v showModuleVersionV1CommandValidator) (ShowModuleVersionV1Response, error) {
+func (cmd showModuleVersionV1Command) handle(ctx context.Context, r showModuleVersionRepository, logger zerolog.Logger, v showModuleVersionV1CommandValidator) (ShowModuleVersionV1Response, error) {
 	occurred := time.Now().UTC()
 
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return ShowModuleVersionV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -67,7 +68,7 @@ func (cmd showModuleVersionV1Command) handle(r showModuleVersionRepository, logg
 		}, nil
 	}
 
-	mv, err := r.VersionById(cmd.DTO.Id)
+	mv, err := r.VersionById(ctx, cmd.DTO.Id)
 
 	if err != nil {
 		if _, ok := err.(ErrResourceNotFound); ok {
@@ -1,13 +1,14 @@
 package registry
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 type showModuleVersionByFqnRepository interface {
-	VersionByFQN(ModuleVersionFQN) (m ModuleVersion, err error)
+	VersionByFQN(context.Context, ModuleVersionFQN) (m ModuleVersion, err error)
 }
 
 type showModuleVersionByFqnV1CommandValidator interface {
@@ -22,14 +23,14 @@ type showModuleVersionByFqnV1Command struct {
 	DTO ShowModuleVersionByFqnV1DTO
 }
 
-func (dto ShowModuleVersionByFqnV1DTO) validate(r showModuleVersionByFqnRepository, v showModuleVersionByFqnV1CommandValidator) []ValidationError {
+func (dto ShowModuleVersionByFqnV1DTO) validate(ctx context.Context, r showModuleVersionByFqnRepository, v showModuleVersionByFqnV1CommandValidator) []ValidationError {
 	return v.Validate(dto)
 }
 
-func (cmd showModuleVersionByFqnV1Command) handle(r showModuleVersionByFqnRepository, logger zerolog.Logger, v showModuleVersionByFqnV1CommandValidator) (ShowModuleVersionV1Response, error) {
+func (cmd showModuleVersionByFqnV1Command) handle(ctx context.Context, r showModuleVersionByFqnRepository, logger zerolog.Logger, v showModuleVersionByFqnV1CommandValidator) (ShowModuleVersionV1Response, error) {
 	occurred := time.Now().UTC()
 
-	if errs := cmd.DTO.validate(r, v); len(errs) > 0 {
+	if errs := cmd.DTO.validate(ctx, r, v); len(errs) > 0 {
 		return ShowModuleVersionV1Response{
 			occurredAt:       occurred,
 			Status:           STATUS_INVALID,
@@ -37,7 +38,7 @@ func (cmd showModuleVersionByFqnV1Command) handle(r showModuleVersionByFqnReposi
 		}, nil
 	}
 
-	mv, err := r.VersionByFQN(cmd.DTO.FQN)
+	mv, err := r.VersionByFQN(ctx, cmd.DTO.FQN)
 
 	if err != nil {
 		if _, ok := err.(ErrResourceNotFound); ok {
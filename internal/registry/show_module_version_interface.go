@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type showModuleVersionInterfaceRepository interface {
+	VersionById(context.Context, string) (m ModuleVersion, err error)
+	VersionInterface(ctx context.Context, moduleVersionId string) (i ModuleVersionInterface, err error)
+}
+
+type showModuleVersionInterfaceV1CommandValidator interface {
+	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
+	Validate(cmd interface{}) []ValidationError
+	SetCustomMessageHandlers(handlers map[string]CustomValidationMessageHandler)
+}
+
+type showModuleVersionInterfaceV1Command struct {
+	DTO ShowModuleVersionInterfaceV1DTO
+}
+
+type ShowModuleVersionInterfaceV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Interface        ModuleVersionInterface
+	ValidationErrors []ValidationError
+}
+
+func (r ShowModuleVersionInterfaceV1Response) GetActionName() string {
+	return "v1.modules.versions.interface.show"
+}
+
+func (r ShowModuleVersionInterfaceV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ShowModuleVersionInterfaceV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ShowModuleVersionInterfaceV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"module_version_id": r.Interface.ModuleVersionId,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+type ShowModuleVersionInterfaceV1DTO struct {
+	Id string `validate:"required,uuid"`
+}
+
+// handle is NOT_FOUND for versions that have no interface yet, they are only
+// parsed once the archive has been built.
+func (cmd showModuleVersionInterfaceV1Command) handle(ctx context.Context, r showModuleVersionInterfaceRepository, logger zerolog.Logger, v showModuleVersionInterfaceV1CommandValidator) (ShowModuleVersionInterfaceV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return ShowModuleVersionInterfaceV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	notFoundOrError := func(err error, msg string) (ShowModuleVersionInterfaceV1Response, error) {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return ShowModuleVersionInterfaceV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg(msg)
+
+		return ShowModuleVersionInterfaceV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	if _, err := r.VersionById(ctx, cmd.DTO.Id); err != nil {
+		return notFoundOrError(err, "failed to find module version")
+	}
+
+	i, err := r.VersionInterface(ctx, cmd.DTO.Id)
+
+	if err != nil {
+		return notFoundOrError(err, "failed to find module version interface")
+	}
+
+	return ShowModuleVersionInterfaceV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Interface:  i,
+	}, nil
+}
@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type showNamespaceRepository interface {
+	NamespaceByName(name string) (n Namespace, err error)
+	GrantsByNamespace(namespace string) ([]RoleGrant, error)
+}
+
+type ShowNamespaceV1DTO struct {
+	Name string `validate:"required"`
+}
+
+type showNamespaceV1Command struct {
+	DTO ShowNamespaceV1DTO
+}
+
+type ShowNamespaceV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	Namespace  Namespace
+	// Every role granted in the namespace
+	Grants []RoleGrant
+}
+
+func (r ShowNamespaceV1Response) GetActionName() string {
+	return "v1.namespaces.show"
+}
+
+func (r ShowNamespaceV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ShowNamespaceV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ShowNamespaceV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"namespace": r.Namespace.Name,
+	}
+}
+
+func (cmd showNamespaceV1Command) handle(r showNamespaceRepository, logger zerolog.Logger) (ShowNamespaceV1Response, error) {
+	occurred := time.Now().UTC()
+
+	n, err := r.NamespaceByName(cmd.DTO.Name)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return ShowNamespaceV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("namespace", cmd.DTO.Name).Msg("failed to find namespace")
+
+		return ShowNamespaceV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	grants, err := r.GrantsByNamespace(n.Name)
+
+	if err != nil {
+		logger.Error().Err(err).Str("namespace", n.Name).Msg("failed to list namespace grants")
+
+		return ShowNamespaceV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	n.Owners = owners(grants)
+
+	return ShowNamespaceV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Namespace:  n,
+		Grants:     grants,
+	}, nil
+}
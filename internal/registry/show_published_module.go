@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type showPublishedModuleRepository interface {
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+}
+
+type ShowPublishedModuleV1DTO struct {
+	FQN ModuleFQN
+}
+
+type showPublishedModuleV1Command struct {
+	DTO ShowPublishedModuleV1DTO
+}
+
+type ShowPublishedModuleV1Response struct {
+	occurredAt time.Time
+	Status     RegistryHandlerStatus
+	Module     PublishedModule
+}
+
+func (r ShowPublishedModuleV1Response) GetActionName() string {
+	return "v1.modules.published.show"
+}
+
+func (r ShowPublishedModuleV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r ShowPublishedModuleV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r ShowPublishedModuleV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"module_id":         r.Module.Module.Id,
+		"module_version_id": r.Module.Latest.Id,
+	}
+}
+
+// handle is NOT_FOUND for a module without a version ready to download, as
+// far as consumers are concerned it hasn't been published yet.
+func (cmd showPublishedModuleV1Command) handle(ctx context.Context, r showPublishedModuleRepository, l zerolog.Logger) (ShowPublishedModuleV1Response, error) {
+	occurred := time.Now().UTC()
+	m, err := r.ByFQN(ctx, cmd.DTO.FQN)
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		return ShowPublishedModuleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_NOT_FOUND,
+		}, nil
+	}
+
+	if err != nil {
+		l.Error().Err(err).Str("fqn", cmd.DTO.FQN.String()).Msg("error finding module")
+
+		return ShowPublishedModuleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	versions, err := r.VersionsByModule(ctx, m.Id, ChunkingOptions{})
+
+	if err != nil {
+		l.Error().Err(err).Str("fqn", cmd.DTO.FQN.String()).Msg("error listing module versions")
+
+		return ShowPublishedModuleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	p, ok := publishedModule(m, versions)
+
+	if !ok {
+		return ShowPublishedModuleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_NOT_FOUND,
+		}, nil
+	}
+
+	return ShowPublishedModuleV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Module:     p,
+	}, nil
+}
@@ -0,0 +1,308 @@
+package registry
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type TagSyncStatus string
+
+type tagSyncStatusesContainer struct {
+	New      TagSyncStatus
+	Existing TagSyncStatus
+	Invalid  TagSyncStatus
+	Failed   TagSyncStatus
+	Deleted  TagSyncStatus
+}
+
+var TagSyncStatuses tagSyncStatusesContainer = tagSyncStatusesContainer{
+	New:      "new",
+	Existing: "existing",
+	Invalid:  "invalid",
+	Failed:   "failed",
+	Deleted:  "deleted",
+}
+
+type tagLister interface {
+	ListTags(ctx context.Context, repo string) (tags map[string]string, err error)
+}
+
+type syncTagsRepository interface {
+	All(ctx context.Context, chunkOpts ChunkingOptions, filters ModuleFilters) ([]Module, error)
+	AddVersion(context.Context, ModuleVersion) (m ModuleVersion, err error)
+	ById(ctx context.Context, id string) (m Module, err error)
+	VersionByModuleAndValue(ctx context.Context, moduleId string, version string) (mv ModuleVersion, err error)
+	VersionWasDeleted(ctx context.Context, moduleId string, version string) (deleted bool, err error)
+}
+
+type SyncedTag struct {
+	Tag     string        `json:"tag"`
+	Commit  string        `json:"commit"`
+	FQN     ModuleFQN     `json:"fqn"`
+	Version string        `json:"version"`
+	Status  TagSyncStatus `json:"status"`
+	Reason  string        `json:"reason,omitempty"`
+}
+
+type SyncTagsV1DTO struct {
+	RepositoryURL string `json:"repository_url"`
+	DryRun        bool   `json:"dry_run"`
+}
+
+type syncTagsV1Command struct {
+	DTO SyncTagsV1DTO
+}
+
+type SyncTagsV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Report           []SyncedTag
+	ValidationErrors []ValidationError
+}
+
+func (r SyncTagsV1Response) GetActionName() string {
+	return "v1.modules.sync_tags"
+}
+
+func (r SyncTagsV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r SyncTagsV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r SyncTagsV1Response) GetAuditMeta() map[string]interface{} {
+	created := []string{}
+
+	for _, s := range r.Report {
+		if s.Status == TagSyncStatuses.New {
+			created = append(created, s.FQN.String()+"@"+s.Version)
+		}
+	}
+
+	return map[string]interface{}{
+		"total":             len(r.Report),
+		"created_versions":  created,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+func (r SyncTagsV1Response) CountByStatus(s TagSyncStatus) int {
+	total := 0
+
+	for _, t := range r.Report {
+		if t.Status == s {
+			total++
+		}
+	}
+
+	return total
+}
+
+func BuildTagSyncReportTable(report []SyncedTag) (h []string, r [][]string) {
+	h = []string{"Status", "Tag", "Commit", "Module", "Version", "Reason"}
+
+	for _, s := range report {
+		r = append(r, []string{
+			string(s.Status),
+			s.Tag,
+			s.Commit,
+			s.FQN.String(),
+			s.Version,
+			s.Reason,
+		})
+	}
+
+	return
+}
+
+// modulesByRepository groups the modules that can be synced, only those with
+// both a repository and a tag pattern are.
+func (cmd syncTagsV1Command) modulesByRepository(mods []Module) map[string][]Module {
+	grouped := map[string][]Module{}
+
+	for _, m := range mods {
+		if m.RepositoryURL == "" || m.TagPattern == "" {
+			continue
+		}
+
+		if cmd.DTO.RepositoryURL != "" && m.RepositoryURL != cmd.DTO.RepositoryURL {
+			continue
+		}
+
+		grouped[m.RepositoryURL] = append(grouped[m.RepositoryURL], m)
+	}
+
+	return grouped
+}
+
+func (cmd syncTagsV1Command) syncTag(ctx context.Context, r syncTagsRepository, logger zerolog.Logger, v addModuleVersionV1CommandValidator, m Module, tag string, sha string, version string) (SyncedTag, error) {
+	synced := SyncedTag{
+		Tag:     tag,
+		Commit:  sha,
+		FQN:     m.FQN(),
+		Version: version,
+		Status:  TagSyncStatuses.New,
+	}
+
+	_, err := r.VersionByModuleAndValue(ctx, m.Id, version)
+
+	if err == nil {
+		synced.Status = TagSyncStatuses.Existing
+
+		return synced, nil
+	}
+
+	if _, ok := err.(ErrResourceNotFound); !ok {
+		logger.Error().Err(err).Str("module_id", m.Id).Str("version", version).Msg("failed to look up module version")
+
+		return synced, err
+	}
+
+	// The tag is still there, but the version was deleted on purpose
+	deleted, err := r.VersionWasDeleted(ctx, m.Id, version)
+
+	if err != nil {
+		logger.Error().Err(err).Str("module_id", m.Id).Str("version", version).Msg("failed to check if module version was deleted")
+
+		return synced, err
+	}
+
+	if deleted {
+		synced.Status = TagSyncStatuses.Deleted
+		synced.Reason = "the version was deleted, add it again to publish it"
+
+		return synced, nil
+	}
+
+	addCmd := addModuleVersionV1Command{
+		DTO: AddModuleVersionV1DTO{
+			Version:       version,
+			ModuleId:      m.Id,
+			Source:        sha,
+			RepositoryURL: m.RepositoryURL,
+		},
+	}
+
+	var errs []ValidationError
+
+	if cmd.DTO.DryRun {
+		errs = addCmd.DTO.validate(ctx, r, v)
+	} else {
+		res, err := addCmd.handle(ctx, r, logger, v)
+
+		if err != nil {
+			return synced, err
+		}
+
+		errs = res.ValidationErrors
+	}
+
+	if len(errs) > 0 {
+		messages := []string{}
+
+		for _, e := range errs {
+			messages = append(messages, e.Field+": "+e.Message)
+		}
+
+		synced.Status = TagSyncStatuses.Invalid
+		synced.Reason = strings.Join(messages, "; ")
+	}
+
+	return synced, nil
+}
+
+// handle takes a ValidatorBuilder, as each version needs a fresh validator,
+// see discoverModulesV1Command.
+func (cmd syncTagsV1Command) handle(ctx context.Context, lister tagLister, r syncTagsRepository, logger zerolog.Logger, buildValidator ValidatorBuilder) (SyncTagsV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := buildValidator(logger).Validate(cmd.DTO); len(errs) > 0 {
+		return SyncTagsV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	mods, err := r.All(ctx, ChunkingOptions{}, ModuleFilters{})
+
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to list modules")
+
+		return SyncTagsV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	grouped := cmd.modulesByRepository(mods)
+	repos := []string{}
+
+	for repo := range grouped {
+		repos = append(repos, repo)
+	}
+
+	sort.Strings(repos)
+
+	report := []SyncedTag{}
+
+	for _, repo := range repos {
+		tags, err := lister.ListTags(ctx, repo)
+
+		if err != nil {
+			// One unreachable repository shouldn't stop the rest syncing
+			logger.Error().Err(err).Str("repository", repo).Msg("failed to list tags")
+
+			for _, m := range grouped[repo] {
+				report = append(report, SyncedTag{
+					FQN:    m.FQN(),
+					Status: TagSyncStatuses.Failed,
+					Reason: "could not list tags: " + err.Error(),
+				})
+			}
+
+			continue
+		}
+
+		names := []string{}
+
+		for tag := range tags {
+			names = append(names, tag)
+		}
+
+		sort.Strings(names)
+
+		for _, tag := range names {
+			for _, m := range grouped[repo] {
+				version, ok := m.VersionFromTag(tag)
+
+				if !ok {
+					continue
+				}
+
+				synced, err := cmd.syncTag(ctx, r, logger, buildValidator(logger), m, tag, tags[tag], version)
+
+				if err != nil {
+					return SyncTagsV1Response{
+						occurredAt: occurred,
+						Status:     STATUS_INTERNAL_ERROR,
+					}, err
+				}
+
+				report = append(report, synced)
+			}
+		}
+	}
+
+	return SyncTagsV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_OKAY,
+		Report:     report,
+	}, nil
+}
@@ -0,0 +1,150 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeTagLister struct {
+	tags map[string]map[string]string
+}
+
+func (l *fakeTagLister) ListTags(ctx context.Context, repo string) (map[string]string, error) {
+	if tags, ok := l.tags[repo]; ok {
+		return tags, nil
+	}
+
+	return nil, errors.New("repository not found")
+}
+
+func buildTagSyncRepository() *fakeVersionRepository {
+	return &fakeVersionRepository{
+		modules: map[string]Module{
+			"5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f": {
+				Id: "5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f", Provider: "aws", Namespace: "platform", Name: "vpc",
+				RepositoryURL: "git@github.com:org/modules.git", TagPattern: "modules/vpc/v{version}",
+			},
+			"0d9e1f5a-3c57-4f43-8a2e-9b41ad0a4c11": {
+				Id: "0d9e1f5a-3c57-4f43-8a2e-9b41ad0a4c11", Provider: "aws", Namespace: "platform", Name: "dns",
+				RepositoryURL: "git@github.com:org/modules.git", TagPattern: "modules/dns/v{version}",
+			},
+			"a7c4a3f0-8a7e-4b0a-a5a1-4c6a9e0c2d33": {
+				Id: "a7c4a3f0-8a7e-4b0a-a5a1-4c6a9e0c2d33", Provider: "aws", Namespace: "platform", Name: "untagged",
+				RepositoryURL: "git@github.com:org/modules.git",
+			},
+			"e2b1f6d8-1c3a-4f7e-9d2b-6a8c0e4f1b22": {
+				Id: "e2b1f6d8-1c3a-4f7e-9d2b-6a8c0e4f1b22", Provider: "aws", Namespace: "platform", Name: "gone",
+				RepositoryURL: "git@github.com:org/gone.git", TagPattern: "v{version}",
+			},
+		},
+		versions: []ModuleVersion{
+			{Id: "mv-1", ModuleId: "5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f", Version: "1.0.0", Status: VersionStatuses.Ready},
+		},
+	}
+}
+
+var syncTestTags = &fakeTagLister{
+	tags: map[string]map[string]string{
+		"git@github.com:org/modules.git": {
+			"modules/vpc/v1.0.0":  "1111111111111111111111111111111111111111",
+			"modules/vpc/v1.1.0":  "2222222222222222222222222222222222222222",
+			"modules/vpc/vnext":   "3333333333333333333333333333333333333333",
+			"modules/dns/v0.1.0":  "4444444444444444444444444444444444444444",
+			"modules/other/1.0.0": "5555555555555555555555555555555555555555",
+		},
+	},
+}
+
+func Test_syncTagsV1Command_handle(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := buildTagSyncRepository()
+
+	cmd := syncTagsV1Command{
+		DTO: SyncTagsV1DTO{},
+	}
+
+	res, err := cmd.handle(context.Background(), syncTestTags, repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_OKAY, res.Status)
+
+	byTag := map[string]SyncedTag{}
+	for _, s := range res.Report {
+		byTag[s.Tag] = s
+	}
+
+	assert.Len(t, res.Report, 5)
+	assert.Equal(t, TagSyncStatuses.Existing, byTag["modules/vpc/v1.0.0"].Status)
+	assert.Equal(t, TagSyncStatuses.New, byTag["modules/vpc/v1.1.0"].Status)
+	assert.Equal(t, TagSyncStatuses.New, byTag["modules/dns/v0.1.0"].Status)
+	assert.Equal(t, TagSyncStatuses.Invalid, byTag["modules/vpc/vnext"].Status)
+	assert.Contains(t, byTag["modules/vpc/vnext"].Reason, "Version")
+	assert.Equal(t, TagSyncStatuses.Failed, byTag[""].Status)
+	assert.Equal(t, "aws/platform/gone", byTag[""].FQN.String())
+
+	created := map[string]string{}
+	for _, mv := range repo.versions[1:] {
+		created[mv.Version] = mv.Source
+	}
+
+	assert.Equal(t, map[string]string{
+		"1.1.0": "2222222222222222222222222222222222222222",
+		"0.1.0": "4444444444444444444444444444444444444444",
+	}, created)
+
+	// Running again finds everything already exists
+	res, err = cmd.handle(context.Background(), syncTestTags, repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, res.CountByStatus(TagSyncStatuses.New))
+	assert.Equal(t, 3, res.CountByStatus(TagSyncStatuses.Existing))
+}
+
+func Test_syncTagsV1Command_handle_DryRunForRepository(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := buildTagSyncRepository()
+
+	cmd := syncTagsV1Command{
+		DTO: SyncTagsV1DTO{
+			RepositoryURL: "git@github.com:org/modules.git",
+			DryRun:        true,
+		},
+	}
+
+	res, err := cmd.handle(context.Background(), syncTestTags, repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res.CountByStatus(TagSyncStatuses.New))
+	assert.Equal(t, 1, res.CountByStatus(TagSyncStatuses.Invalid))
+	assert.Equal(t, 0, res.CountByStatus(TagSyncStatuses.Failed))
+	assert.Len(t, repo.versions, 1)
+}
+
+func Test_syncTagsV1Command_handle_DeletedVersion(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := buildTagSyncRepository()
+	repo.deleted = map[string][]string{
+		"5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f": {"1.1.0"},
+	}
+
+	cmd := syncTagsV1Command{
+		DTO: SyncTagsV1DTO{
+			RepositoryURL: "git@github.com:org/modules.git",
+		},
+	}
+
+	res, err := cmd.handle(context.Background(), syncTestTags, repo, l, NewCommandValidator)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, res.CountByStatus(TagSyncStatuses.New))
+	assert.Equal(t, 1, res.CountByStatus(TagSyncStatuses.Deleted))
+
+	for _, mv := range repo.versions {
+		assert.NotEqual(t, "1.1.0", mv.Version, "a deleted version is not added back")
+	}
+}
@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type teamMembersRepository interface {
+	TeamByName(name string) (t Team, err error)
+	AddTeamMember(t Team, u User) error
+	RemoveTeamMember(t Team, u User) error
+}
+
+type teamMembersUserRepository interface {
+	UserByUsername(username string) (u User, err error)
+}
+
+type teamMembersV1CommandValidator interface {
+	Validate(cmd interface{}) []ValidationError
+}
+
+type TeamMemberV1DTO struct {
+	Team     string `validate:"required"`
+	Username string `validate:"required"`
+}
+
+type addTeamMemberV1Command struct {
+	DTO TeamMemberV1DTO
+}
+
+type removeTeamMemberV1Command struct {
+	DTO TeamMemberV1DTO
+}
+
+type TeamMemberV1Response struct {
+	occurredAt       time.Time
+	actionName       string
+	Status           RegistryHandlerStatus
+	Team             Team
+	User             User
+	ValidationErrors []ValidationError
+}
+
+func (r TeamMemberV1Response) GetActionName() string {
+	return r.actionName
+}
+
+func (r TeamMemberV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r TeamMemberV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r TeamMemberV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"team_id":           r.Team.Id,
+		"team":              r.Team.Name,
+		"user_id":           r.User.Id,
+		"username":          r.User.Username,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+// changeTeamMember finds the team and user, and makes the change to the
+// team's members. It is NOT_FOUND when either doesn't exist.
+func changeTeamMember(dto TeamMemberV1DTO, action string, change func(Team, User) error, r teamMembersRepository, users teamMembersUserRepository, logger zerolog.Logger, v teamMembersV1CommandValidator) (TeamMemberV1Response, error) {
+	res := TeamMemberV1Response{
+		occurredAt: time.Now().UTC(),
+		actionName: action,
+	}
+
+	if errs := v.Validate(dto); len(errs) > 0 {
+		res.Status = STATUS_INVALID
+		res.ValidationErrors = errs
+
+		return res, nil
+	}
+
+	t, err := r.TeamByName(dto.Team)
+
+	if err == nil {
+		res.Team = t
+		res.User, err = users.UserByUsername(dto.Username)
+	}
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			res.Status = STATUS_NOT_FOUND
+
+			return res, nil
+		}
+
+		logger.Error().Err(err).Str("team", dto.Team).Str("username", dto.Username).Msg("failed to find team member")
+		res.Status = STATUS_INTERNAL_ERROR
+
+		return res, err
+	}
+
+	if err := change(res.Team, res.User); err != nil {
+		logger.Error().Err(err).Str("team", dto.Team).Str("username", dto.Username).Msg("failed to change team members")
+		res.Status = STATUS_INTERNAL_ERROR
+
+		return res, err
+	}
+
+	res.Status = STATUS_OKAY
+
+	return res, nil
+}
+
+func (cmd addTeamMemberV1Command) handle(r teamMembersRepository, users teamMembersUserRepository, logger zerolog.Logger, v teamMembersV1CommandValidator) (TeamMemberV1Response, error) {
+	return changeTeamMember(cmd.DTO, "v1.teams.add_member", r.AddTeamMember, r, users, logger, v)
+}
+
+func (cmd removeTeamMemberV1Command) handle(r teamMembersRepository, users teamMembersUserRepository, logger zerolog.Logger, v teamMembersV1CommandValidator) (TeamMemberV1Response, error) {
+	return changeTeamMember(cmd.DTO, "v1.teams.remove_member", r.RemoveTeamMember, r, users, logger, v)
+}
@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type updateModuleRepository interface {
+	ById(ctx context.Context, id string) (m Module, err error)
+	UpdateModule(context.Context, Module) (Module, error)
+}
+
+type updateModuleV1CommandValidator interface {
+	Validate(cmd interface{}) []ValidationError
+}
+
+type updateModuleV1Command struct {
+	DTO UpdateModuleV1DTO
+}
+
+type UpdateModuleV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Module           Module
+	ValidationErrors []ValidationError
+}
+
+func (r UpdateModuleV1Response) GetActionName() string {
+	return "v1.modules.update"
+}
+
+func (r UpdateModuleV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r UpdateModuleV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r UpdateModuleV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"module_id":         r.Module.Id,
+		"validation_errors": r.ValidationErrors,
+	}
+}
+
+// UpdateModuleV1DTO only changes the fields that are supplied, the FQN of a
+// module cannot be changed as it is how terraform refers to it.
+type UpdateModuleV1DTO struct {
+	Id              string  `json:"-" validate:"required,uuid"`
+	RepositoryURL   *string `json:"repository_url" validate:"omitempty,repository_url"`
+	Path            *string `json:"path" validate:"omitempty,module_path"`
+	DefaultBranch   *string `json:"default_branch"`
+	TagPattern      *string `json:"tag_pattern" validate:"omitempty,tag_pattern"`
+	BreakingChanges *string `json:"breaking_changes" validate:"omitempty,breaking_change_policy"`
+	// Only set by the cli, the API never clones a path on the server
+	AllowLocalRepository bool `json:"-"`
+}
+
+func (dto UpdateModuleV1DTO) applyTo(m Module) Module {
+	if dto.RepositoryURL != nil {
+		m.RepositoryURL = *dto.RepositoryURL
+	}
+
+	if dto.Path != nil {
+		m.Path = CleanModulePath(*dto.Path)
+	}
+
+	if dto.DefaultBranch != nil {
+		m.DefaultBranch = *dto.DefaultBranch
+	}
+
+	if dto.TagPattern != nil {
+		m.TagPattern = *dto.TagPattern
+	}
+
+	if dto.BreakingChanges != nil {
+		m.BreakingChanges = BreakingChangePolicy(*dto.BreakingChanges)
+	}
+
+	return m
+}
+
+func (cmd updateModuleV1Command) handle(ctx context.Context, r updateModuleRepository, logger zerolog.Logger, v updateModuleV1CommandValidator) (UpdateModuleV1Response, error) {
+	occurred := time.Now().UTC()
+
+	if errs := v.Validate(cmd.DTO); len(errs) > 0 {
+		return UpdateModuleV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	m, err := r.ById(ctx, cmd.DTO.Id)
+
+	if err != nil {
+		if _, ok := err.(ErrResourceNotFound); ok {
+			return UpdateModuleV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_NOT_FOUND,
+			}, nil
+		}
+
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to find module")
+
+		return UpdateModuleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	m, err = r.UpdateModule(ctx, cmd.DTO.applyTo(m))
+
+	if err != nil {
+		logger.Error().Err(err).Str("id", cmd.DTO.Id).Msg("failed to update module in store")
+
+		return UpdateModuleV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	return UpdateModuleV1Response{
+		occurredAt: occurred,
+		Status:     STATUS_MODIFIED,
+		Module:     m,
+	}, nil
+}
@@ -0,0 +1,316 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/gpg"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type objectStore interface {
+	Put(key string, r io.Reader) error
+	Delete(key string) error
+}
+
+type uploadProviderVersionRepository interface {
+	ProviderByFQN(fqn ProviderFQN) (p Provider, err error)
+	AddProvider(Provider) (p Provider, err error)
+	ProviderVersionByValue(providerId string, version string) (pv ProviderVersion, err error)
+	AddProviderVersion(ProviderVersion) (pv ProviderVersion, err error)
+	GPGKeysByNamespace(namespace string) ([]GPGKey, error)
+}
+
+type uploadProviderVersionV1CommandValidator interface {
+	RegisterStructLevelValidator(validator.StructLevelFunc, interface{})
+	Validate(cmd interface{}) []ValidationError
+}
+
+// ProviderArchive is a release zip being uploaded, its content is only read
+// once the rest of the upload is known to be valid.
+type ProviderArchive struct {
+	Filename string    `json:"filename" validate:"required"`
+	Content  io.Reader `json:"-"`
+}
+
+// UploadProviderVersionV1DTO carries a provider release as goreleaser builds
+// it: a zip per platform, the SHA256SUMS file listing them, and a detached
+// signature of that file by one of the namespace's gpg keys. The provider is
+// created on its first upload.
+type UploadProviderVersionV1DTO struct {
+	Namespace        string            `json:"namespace" validate:"required"`
+	Type             string            `json:"type" validate:"required"`
+	Version          string            `json:"version" validate:"required,provider_version"`
+	Protocols        []string          `json:"protocols" validate:"dive,required"`
+	Shasums          []byte            `json:"-" validate:"required"`
+	ShasumsSignature []byte            `json:"-" validate:"required"`
+	Archives         []ProviderArchive `json:"archives" validate:"required,min=1,dive"`
+}
+
+func (dto UploadProviderVersionV1DTO) FQN() ProviderFQN {
+	return ProviderFQN{
+		Namespace: dto.Namespace,
+		Type:      dto.Type,
+	}
+}
+
+type uploadProviderVersionV1Command struct {
+	DTO UploadProviderVersionV1DTO
+}
+
+type UploadProviderVersionV1Response struct {
+	occurredAt       time.Time
+	Status           RegistryHandlerStatus
+	Provider         Provider
+	ProviderVersion  ProviderVersion
+	ValidationErrors []ValidationError
+}
+
+func (r UploadProviderVersionV1Response) GetActionName() string {
+	return "v1.providers.versions.upload"
+}
+
+func (r UploadProviderVersionV1Response) GetTimeOfOccurrence() time.Time {
+	return r.occurredAt
+}
+
+func (r UploadProviderVersionV1Response) GetResponseStatus() RegistryHandlerStatus {
+	return r.Status
+}
+
+func (r UploadProviderVersionV1Response) GetAuditMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"provider_id":         r.Provider.Id,
+		"provider_version_id": r.ProviderVersion.Id,
+		"signing_key_id":      r.ProviderVersion.SigningKeyId,
+		"validation_errors":   r.ValidationErrors,
+	}
+}
+
+// validate checks everything short of the archives' checksums, returning the
+// key the release was signed with when it is valid.
+func (dto UploadProviderVersionV1DTO) validate(r uploadProviderVersionRepository, v uploadProviderVersionV1CommandValidator, logger zerolog.Logger) ([]ValidationError, GPGKey) {
+	signer := GPGKey{}
+
+	v.RegisterStructLevelValidator(func(sl validator.StructLevel) {
+		if p, err := r.ProviderByFQN(dto.FQN()); err == nil {
+			if _, err := r.ProviderVersionByValue(p.Id, dto.Version); err == nil {
+				sl.ReportError(dto.Version, "version", "Version", uniqueProviderVersionTag, dto.Version)
+			} else if _, ok := err.(ErrResourceNotFound); !ok {
+				logger.Error().Err(err).Msg("unexpected repository error during validation")
+			}
+		} else if _, ok := err.(ErrResourceNotFound); !ok {
+			logger.Error().Err(err).Msg("unexpected repository error during validation")
+		}
+
+		sums, err := ParseProviderShasums(dto.Shasums)
+
+		if err != nil {
+			sl.ReportError("", "shasums", "Shasums", shasumsTag, "")
+		}
+
+		seen := map[string]bool{}
+
+		for i, a := range dto.Archives {
+			field := fmt.Sprintf("archives[%d]", i)
+
+			if seen[a.Filename] {
+				sl.ReportError(a.Filename, field, "Filename", uniqueProviderArchiveTag, a.Filename)
+				continue
+			}
+
+			seen[a.Filename] = true
+
+			if _, _, ok := ParseProviderArchiveFilename(dto.Type, dto.Version, a.Filename); !ok {
+				sl.ReportError(a.Filename, field, "Filename", providerArchiveNameTag, a.Filename)
+			} else if _, listed := sums[a.Filename]; sums != nil && !listed {
+				sl.ReportError(a.Filename, field, "Filename", listedInShasumsTag, a.Filename)
+			}
+		}
+
+		if len(dto.ShasumsSignature) == 0 {
+			return
+		}
+
+		keys, err := r.GPGKeysByNamespace(dto.Namespace)
+
+		if err != nil {
+			logger.Error().Err(err).Msg("unexpected repository error during validation")
+		}
+
+		armored := []string{}
+
+		for _, k := range keys {
+			armored = append(armored, k.ASCIIArmor)
+		}
+
+		keyId, err := gpg.VerifyDetached(armored, dto.Shasums, dto.ShasumsSignature)
+
+		if err != nil {
+			sl.ReportError("", "shasums_signature", "ShasumsSignature", signedByNamespaceKeyTag, "")
+			return
+		}
+
+		for _, k := range keys {
+			if k.KeyId == keyId {
+				signer = k
+			}
+		}
+	}, UploadProviderVersionV1DTO{})
+
+	return v.Validate(dto), signer
+}
+
+// storeArchive writes the archive to storage, hashing it on the way, so a
+// zip that doesn't match the SHA256SUMS file is never read twice.
+func storeArchive(s objectStore, key string, a ProviderArchive) (string, error) {
+	h := sha256.New()
+
+	if err := s.Put(key, io.TeeReader(a.Content, h)); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func removeStored(s objectStore, keys []string, logger zerolog.Logger) {
+	for _, k := range keys {
+		if err := s.Delete(k); err != nil {
+			logger.Error().Err(err).Str("key", k).Msg("failed to remove provider file from storage")
+		}
+	}
+}
+
+func (cmd uploadProviderVersionV1Command) handle(r uploadProviderVersionRepository, s objectStore, logger zerolog.Logger, v uploadProviderVersionV1CommandValidator) (UploadProviderVersionV1Response, error) {
+	occurred := time.Now().UTC()
+	errs, signer := cmd.DTO.validate(r, v, logger)
+
+	if len(errs) > 0 {
+		return UploadProviderVersionV1Response{
+			occurredAt:       occurred,
+			Status:           STATUS_INVALID,
+			ValidationErrors: errs,
+		}, nil
+	}
+
+	fqn := cmd.DTO.FQN()
+	sums, _ := ParseProviderShasums(cmd.DTO.Shasums)
+	stored := []string{}
+	platforms := []ProviderPlatform{}
+	versionId := uuid.New().String()
+
+	internalError := func(err error, msg string) (UploadProviderVersionV1Response, error) {
+		logger.Error().Err(err).Str("provider", fqn.String()).Str("version", cmd.DTO.Version).Msg(msg)
+		removeStored(s, stored, logger)
+
+		return UploadProviderVersionV1Response{
+			occurredAt: occurred,
+			Status:     STATUS_INTERNAL_ERROR,
+		}, err
+	}
+
+	for i, a := range cmd.DTO.Archives {
+		key := ProviderStorageKey(fqn, cmd.DTO.Version, a.Filename)
+		shasum, err := storeArchive(s, key, a)
+
+		if err != nil {
+			return internalError(err, "failed to store provider archive")
+		}
+
+		stored = append(stored, key)
+
+		if shasum != sums[a.Filename] {
+			removeStored(s, stored, logger)
+
+			return UploadProviderVersionV1Response{
+				occurredAt: occurred,
+				Status:     STATUS_INVALID,
+				ValidationErrors: []ValidationError{
+					{
+						Message: "does not match its sha256 in the SHA256SUMS file",
+						Rule:    shasumMismatchTag,
+						Field:   fmt.Sprintf("archives[%d]", i),
+						Value:   a.Filename,
+					},
+				},
+			}, nil
+		}
+
+		os, arch, _ := ParseProviderArchiveFilename(cmd.DTO.Type, cmd.DTO.Version, a.Filename)
+
+		platforms = append(platforms, ProviderPlatform{
+			Id:                uuid.New().String(),
+			ProviderVersionId: versionId,
+			OS:                os,
+			Arch:              arch,
+			Filename:          a.Filename,
+			Shasum:            shasum,
+			StorageKey:        key,
+		})
+	}
+
+	shasumsKey := ProviderStorageKey(fqn, cmd.DTO.Version, ProviderShasumsFilename(fqn.Type, cmd.DTO.Version))
+	signatureKey := shasumsKey + ".sig"
+
+	if err := s.Put(shasumsKey, bytes.NewReader(cmd.DTO.Shasums)); err != nil {
+		return internalError(err, "failed to store provider shasums")
+	}
+
+	stored = append(stored, shasumsKey)
+
+	if err := s.Put(signatureKey, bytes.NewReader(cmd.DTO.ShasumsSignature)); err != nil {
+		return internalError(err, "failed to store provider shasums signature")
+	}
+
+	stored = append(stored, signatureKey)
+
+	p, err := r.ProviderByFQN(fqn)
+
+	if _, ok := err.(ErrResourceNotFound); ok {
+		p, err = r.AddProvider(Provider{
+			Id:        uuid.New().String(),
+			Namespace: fqn.Namespace,
+			Type:      fqn.Type,
+			CreatedAt: occurred,
+		})
+	}
+
+	if err != nil {
+		return internalError(err, "failed to find or add provider")
+	}
+
+	protocols := cmd.DTO.Protocols
+
+	if len(protocols) == 0 {
+		protocols = DefaultProviderProtocols
+	}
+
+	pv, err := r.AddProviderVersion(ProviderVersion{
+		Id:                  versionId,
+		ProviderId:          p.Id,
+		Version:             cmd.DTO.Version,
+		Protocols:           protocols,
+		SigningKeyId:        signer.Id,
+		ShasumsKey:          shasumsKey,
+		ShasumsSignatureKey: signatureKey,
+		Platforms:           platforms,
+		CreatedAt:           occurred,
+	})
+
+	if err != nil {
+		return internalError(err, "failed to add provider version")
+	}
+
+	return UploadProviderVersionV1Response{
+		occurredAt:      occurred,
+		Status:          STATUS_CREATED,
+		Provider:        p,
+		ProviderVersion: pv,
+	}, nil
+}
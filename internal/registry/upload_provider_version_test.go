@@ -0,0 +1,309 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/svartlfheim/ymir/internal/gpg"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeProviderRepository struct {
+	providers []Provider
+	versions  []ProviderVersion
+	keys      []GPGKey
+}
+
+func (r *fakeProviderRepository) ProviderByFQN(fqn ProviderFQN) (Provider, error) {
+	for _, p := range r.providers {
+		if p.FQN() == fqn {
+			return p, nil
+		}
+	}
+
+	return Provider{}, ErrResourceNotFound{}
+}
+
+func (r *fakeProviderRepository) AddProvider(p Provider) (Provider, error) {
+	r.providers = append(r.providers, p)
+
+	return p, nil
+}
+
+func (r *fakeProviderRepository) ProviderVersionByValue(providerId string, version string) (ProviderVersion, error) {
+	for _, pv := range r.versions {
+		if pv.ProviderId == providerId && pv.Version == version {
+			return pv, nil
+		}
+	}
+
+	return ProviderVersion{}, ErrResourceNotFound{}
+}
+
+func (r *fakeProviderRepository) AddProviderVersion(pv ProviderVersion) (ProviderVersion, error) {
+	r.versions = append(r.versions, pv)
+
+	return pv, nil
+}
+
+func (r *fakeProviderRepository) GPGKeysByNamespace(namespace string) ([]GPGKey, error) {
+	keys := []GPGKey{}
+
+	for _, k := range r.keys {
+		if k.Namespace == namespace {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys, nil
+}
+
+type fakeObjectStore struct {
+	objects map[string][]byte
+}
+
+func (s *fakeObjectStore) Put(key string, r io.Reader) error {
+	b, err := io.ReadAll(r)
+
+	if err != nil {
+		return err
+	}
+
+	s.objects[key] = b
+
+	return nil
+}
+
+func (s *fakeObjectStore) Delete(key string) error {
+	delete(s.objects, key)
+
+	return nil
+}
+
+func buildGPGKey(t *testing.T, namespace string) (*openpgp.Entity, GPGKey) {
+	e, err := openpgp.NewEntity(namespace, "", namespace+"@example.com", nil)
+	assert.Nil(t, err)
+
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, e.Serialize(w))
+	assert.Nil(t, w.Close())
+
+	keyId, err := gpg.KeyId(buf.String())
+	assert.Nil(t, err)
+
+	return e, GPGKey{
+		Id:         "c1f1ef34-5b7c-4a59-9d8a-2b9b6a3f0e10",
+		Namespace:  namespace,
+		KeyId:      keyId,
+		ASCIIArmor: buf.String(),
+	}
+}
+
+func shasumsFor(files map[string]string) []byte {
+	b := new(bytes.Buffer)
+
+	for name, content := range files {
+		sum := sha256.Sum256([]byte(content))
+		fmt.Fprintf(b, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+
+	return b.Bytes()
+}
+
+func sign(t *testing.T, e *openpgp.Entity, message []byte) []byte {
+	sig := new(bytes.Buffer)
+	assert.Nil(t, openpgp.DetachSign(sig, e, bytes.NewReader(message), nil))
+
+	return sig.Bytes()
+}
+
+func Test_uploadProviderVersionV1Command_handle(t *testing.T) {
+	signer, key := buildGPGKey(t, "platform")
+	stranger, _ := buildGPGKey(t, "stranger")
+
+	linux := "terraform-provider-internal_1.0.0_linux_amd64.zip"
+	darwin := "terraform-provider-internal_1.0.0_darwin_arm64.zip"
+	shasums := shasumsFor(map[string]string{
+		linux:  "linux zip",
+		darwin: "darwin zip",
+	})
+
+	archives := func(files ...string) []ProviderArchive {
+		a := []ProviderArchive{}
+
+		for i := 0; i < len(files); i += 2 {
+			a = append(a, ProviderArchive{
+				Filename: files[i],
+				Content:  bytes.NewReader([]byte(files[i+1])),
+			})
+		}
+
+		return a
+	}
+
+	existing := Provider{
+		Id:        "5e9d3e0f-5a36-4d2b-a2c4-58f4b0a0c6a1",
+		Namespace: "platform",
+		Type:      "internal",
+	}
+
+	tests := []struct {
+		name              string
+		dto               UploadProviderVersionV1DTO
+		versions          []ProviderVersion
+		expectedStatus    RegistryHandlerStatus
+		expectedRules     []string
+		expectedPlatforms int
+	}{
+		{
+			name: "uploads a signed release",
+			dto: UploadProviderVersionV1DTO{
+				Namespace:        "platform",
+				Type:             "internal",
+				Version:          "1.0.0",
+				Shasums:          shasums,
+				ShasumsSignature: sign(t, signer, shasums),
+				Archives:         archives(linux, "linux zip", darwin, "darwin zip"),
+			},
+			expectedStatus:    STATUS_CREATED,
+			expectedPlatforms: 2,
+		},
+		{
+			name: "version must be semver",
+			dto: UploadProviderVersionV1DTO{
+				Namespace:        "platform",
+				Type:             "internal",
+				Version:          "v1",
+				Shasums:          shasums,
+				ShasumsSignature: sign(t, signer, shasums),
+				Archives:         archives(),
+			},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"provider_version", "min"},
+		},
+		{
+			name: "archives must be named for the provider version and listed in the shasums",
+			dto: UploadProviderVersionV1DTO{
+				Namespace:        "platform",
+				Type:             "internal",
+				Version:          "1.0.0",
+				Shasums:          shasums,
+				ShasumsSignature: sign(t, signer, shasums),
+				Archives:         archives("internal.zip", "zip", "terraform-provider-internal_1.0.0_windows_amd64.zip", "zip", linux, "linux zip", linux, "linux zip"),
+			},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"provider_archive_name", "listed_in_shasums", "unique_provider_archive"},
+		},
+		{
+			name: "shasums must be signed by a key of the namespace",
+			dto: UploadProviderVersionV1DTO{
+				Namespace:        "platform",
+				Type:             "internal",
+				Version:          "1.0.0",
+				Shasums:          shasums,
+				ShasumsSignature: sign(t, stranger, shasums),
+				Archives:         archives(linux, "linux zip"),
+			},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"signed_by_namespace_key"},
+		},
+		{
+			name: "archives must match their shasum",
+			dto: UploadProviderVersionV1DTO{
+				Namespace:        "platform",
+				Type:             "internal",
+				Version:          "1.0.0",
+				Shasums:          shasums,
+				ShasumsSignature: sign(t, signer, shasums),
+				Archives:         archives(linux, "linux zip", darwin, "tampered zip"),
+			},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"shasum_mismatch"},
+		},
+		{
+			name: "version must not already exist",
+			dto: UploadProviderVersionV1DTO{
+				Namespace:        "platform",
+				Type:             "internal",
+				Version:          "1.0.0",
+				Shasums:          shasums,
+				ShasumsSignature: sign(t, signer, shasums),
+				Archives:         archives(linux, "linux zip"),
+			},
+			versions: []ProviderVersion{
+				{
+					Id:         "0b8f6c33-1d0e-4c55-8a1d-7f7b3d7c2f19",
+					ProviderId: existing.Id,
+					Version:    "1.0.0",
+				},
+			},
+			expectedStatus: STATUS_INVALID,
+			expectedRules:  []string{"unique_provider_version"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := &fakeProviderRepository{
+				keys:     []GPGKey{key},
+				versions: test.versions,
+			}
+
+			if len(test.versions) > 0 {
+				repo.providers = []Provider{existing}
+			}
+
+			store := &fakeObjectStore{
+				objects: map[string][]byte{},
+			}
+
+			cmd := uploadProviderVersionV1Command{
+				DTO: test.dto,
+			}
+
+			res, err := cmd.handle(repo, store, l, NewCommandValidator(l))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expectedStatus, res.Status)
+
+			rules := []string{}
+			for _, e := range res.ValidationErrors {
+				rules = append(rules, e.Rule)
+			}
+
+			if test.expectedRules == nil {
+				test.expectedRules = []string{}
+			}
+
+			assert.Equal(tt, test.expectedRules, rules)
+
+			if res.Status != STATUS_CREATED {
+				assert.Empty(tt, store.objects)
+				assert.Len(tt, repo.versions, len(test.versions))
+				return
+			}
+
+			assert.Len(tt, repo.providers, 1)
+			assert.Equal(tt, repo.providers[0].Id, res.ProviderVersion.ProviderId)
+			assert.Equal(tt, key.Id, res.ProviderVersion.SigningKeyId)
+			assert.Equal(tt, DefaultProviderProtocols, res.ProviderVersion.Protocols)
+			assert.Len(tt, res.ProviderVersion.Platforms, test.expectedPlatforms)
+			assert.Len(tt, store.objects, test.expectedPlatforms+2)
+			assert.Equal(tt, shasums, store.objects[res.ProviderVersion.ShasumsKey])
+
+			p, ok := res.ProviderVersion.Platform("linux", "amd64")
+			assert.True(tt, ok)
+			assert.Equal(tt, []byte("linux zip"), store.objects[p.StorageKey])
+		})
+	}
+}
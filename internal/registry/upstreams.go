@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+)
+
+// Upstream is another module registry, modules in its namespaces that don't
+// exist locally are proxied from it.
+type Upstream struct {
+	URL        string
+	Namespaces []string
+	// How long a module's version list is cached before it is fetched again
+	TTL time.Duration
+}
+
+func (u Upstream) Hostname() string {
+	parsed, err := url.Parse(u.URL)
+
+	if err != nil || parsed.Host == "" {
+		return u.URL
+	}
+
+	return parsed.Host
+}
+
+// UpstreamFor finds the upstream the namespace is proxied from, the first to
+// list it wins.
+func UpstreamFor(upstreams []Upstream, namespace string) (Upstream, bool) {
+	for _, u := range upstreams {
+		for _, ns := range u.Namespaces {
+			if ns == namespace {
+				return u, true
+			}
+		}
+	}
+
+	return Upstream{}, false
+}
+
+// UpstreamArchive is the source of a module version fetched from an upstream,
+// the subdirectory is where the module lives within it.
+type UpstreamArchive struct {
+	Content   io.ReadCloser
+	Extension string
+	Subdir    string
+}
+
+// UpstreamModule caches the versions an upstream has of a module.
+type UpstreamModule struct {
+	Id          string    `json:"id"`
+	UpstreamURL string    `json:"upstream_url"`
+	Namespace   string    `json:"namespace"`
+	Name        string    `json:"name"`
+	Provider    string    `json:"provider"`
+	Versions    []string  `json:"versions"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+func (m UpstreamModule) FQN() ModuleFQN {
+	return ModuleFQN{
+		Namespace: m.Namespace,
+		Name:      m.Name,
+		Provider:  m.Provider,
+	}
+}
+
+func (m UpstreamModule) HasVersion(version string) bool {
+	for _, v := range m.Versions {
+		if v == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expired is true once the versions are older than the ttl.
+func (m UpstreamModule) Expired(ttl time.Duration, now time.Time) bool {
+	return now.Sub(m.FetchedAt) >= ttl
+}
+
+// UpstreamModuleVersion is a version of an upstream module whose archive has
+// been stored locally, it is served from storage from then on.
+type UpstreamModuleVersion struct {
+	Id               string    `json:"id"`
+	UpstreamModuleId string    `json:"upstream_module_id"`
+	Version          string    `json:"version"`
+	Source           string    `json:"source"`
+	StorageKey       string    `json:"storage_key"`
+	Subdir           string    `json:"subdir"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// UpstreamStorageKey is the location of the archive for a proxied module
+// version within storage.
+func UpstreamStorageKey(u Upstream, fqn ModuleVersionFQN, extension string) string {
+	return fmt.Sprintf("upstream/%s/%s/%s/%s/%s%s", u.Hostname(), fqn.Namespace, fqn.Name, fqn.Provider, fqn.Version, extension)
+}
@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User can log in with `terraform login`, the tokens issued to them have the
+// user's scopes.
+type User struct {
+	Id           string       `json:"id"`
+	Username     string       `json:"username"`
+	PasswordHash string       `json:"-"`
+	Scopes       []TokenScope `json:"scopes"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+func HashPassword(password string) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(h), nil
+}
+
+func (u User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+func BuildUsersTable(users []User) (h []string, r [][]string) {
+	h = []string{"ID", "Username", "Scopes", "Created At"}
+
+	for _, u := range users {
+		scopes := []string{}
+
+		for _, s := range u.Scopes {
+			scopes = append(scopes, string(s))
+		}
+
+		r = append(r, []string{
+			u.Id,
+			u.Username,
+			strings.Join(scopes, ", "),
+			u.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return
+}
@@ -1,13 +1,18 @@
 package registry
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	goversion "github.com/hashicorp/go-version"
 	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/gpg"
 	"gopkg.in/go-playground/validator.v9"
 )
 
@@ -20,6 +25,43 @@ const noVersionsExistForIdTag string = "no_versions_exist_for_id"
 const noVersionsExistForModuleFQNTag string = "no_versions_exist_for_module_fqn"
 const uuidTag string = "uuid"
 const versionTag string = "version"
+const modulePathTag string = "module_path"
+const tagPatternTag string = "tag_pattern"
+const repositoryURLTag string = "repository_url"
+const webhookURLTag string = "webhook_url"
+const eventTypeTag string = "event_type"
+const minTag string = "min"
+const providerVersionTag string = "provider_version"
+const gpgPublicKeyTag string = "gpg_public_key"
+const uniqueGPGKeyTag string = "unique_gpg_key"
+const gpgKeyNotInUseTag string = "gpg_key_not_in_use"
+const uniqueProviderVersionTag string = "unique_provider_version"
+const uniqueProviderArchiveTag string = "unique_provider_archive"
+const shasumsTag string = "shasums"
+const providerArchiveNameTag string = "provider_archive_name"
+const listedInShasumsTag string = "listed_in_shasums"
+const signedByNamespaceKeyTag string = "signed_by_namespace_key"
+const shasumMismatchTag string = "shasum_mismatch"
+const directoryTag string = "directory"
+const breakingChangePolicyTag string = "breaking_change_policy"
+const compatibleInterfaceTag string = "compatible_interface"
+const versionConstraintTag string = "version_constraint"
+const tokenScopeTag string = "token_scope"
+const futureTag string = "future"
+const usernameTag string = "username"
+const passwordTag string = "password"
+const uniqueUsernameTag string = "unique_username"
+const namespaceTag string = "namespace"
+const roleTag string = "role"
+const subjectTag string = "subject"
+const uniqueNamespaceTag string = "unique_namespace"
+const uniqueTeamTag string = "unique_team"
+const existingSubjectTag string = "existing_subject"
+const emptyNamespaceTag string = "empty_namespace"
+const loginClientTag string = "login_client"
+const loopbackRedirectTag string = "loopback_redirect"
+const responseTypeTag string = "response_type"
+const pkceMethodTag string = "pkce_method"
 
 type ValidatorBuilder func(l zerolog.Logger) CommandValidator
 
@@ -39,24 +81,24 @@ type ValidationError struct {
 }
 
 type CommandValidator interface {
-	ModuleNameMustBeUnique(r uniqueModuleNameRepository, sl validator.StructLevel, fqn ModuleFQN)
-	ModuleVersionMustBeUniqueForFQN(r moduleVersionMustBeUniqueForFQNRepository, sl validator.StructLevel, fqn ModuleVersionFQN)
-	ModuleVersionMustBeUniqueForId(r moduleVersionMustBeUniqueForIdRepository, sl validator.StructLevel, id string, version string)
-	ModuleMustExistById(r moduleMustExistByIdRepository, sl validator.StructLevel, id string)
-	ModuleMustExistByFQN(r moduleMustExistByFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
-	NoVersionsExistForModuleFQN(r noVersionsExistForModuleFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
-	NoVersionsExistForModuleId(r noVersionsExistForIdRepository, sl validator.StructLevel, id string)
+	ModuleNameMustBeUnique(ctx context.Context, r uniqueModuleNameRepository, sl validator.StructLevel, fqn ModuleFQN)
+	ModuleVersionMustBeUniqueForFQN(ctx context.Context, r moduleVersionMustBeUniqueForFQNRepository, sl validator.StructLevel, fqn ModuleVersionFQN)
+	ModuleVersionMustBeUniqueForId(ctx context.Context, r moduleVersionMustBeUniqueForIdRepository, sl validator.StructLevel, id string, version string)
+	ModuleMustExistById(ctx context.Context, r moduleMustExistByIdRepository, sl validator.StructLevel, id string)
+	ModuleMustExistByFQN(ctx context.Context, r moduleMustExistByFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
+	NoVersionsExistForModuleFQN(ctx context.Context, r noVersionsExistForModuleFQNRepository, sl validator.StructLevel, fqn ModuleFQN)
+	NoVersionsExistForModuleId(ctx context.Context, r noVersionsExistForIdRepository, sl validator.StructLevel, id string)
 	SetCustomMessageHandlers(handlers map[string]CustomValidationMessageHandler)
 	RegisterStructLevelValidator(f validator.StructLevelFunc, t interface{})
 	Validate(cmd interface{}) []ValidationError
 }
 
 type uniqueModuleNameRepository interface {
-	ByFQN(ModuleFQN) (m Module, err error)
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
 }
 
-func (v *commandValidator) ModuleNameMustBeUnique(r uniqueModuleNameRepository, sl validator.StructLevel, fqn ModuleFQN) {
-	_, err := r.ByFQN(fqn)
+func (v *commandValidator) ModuleNameMustBeUnique(ctx context.Context, r uniqueModuleNameRepository, sl validator.StructLevel, fqn ModuleFQN) {
+	_, err := r.ByFQN(ctx, fqn)
 
 	if err == nil {
 		v.logger.Error().Err(err).Msg("validation error")
@@ -70,11 +112,11 @@ func (v *commandValidator) ModuleNameMustBeUnique(r uniqueModuleNameRepository,
 }
 
 type moduleVersionMustBeUniqueForFQNRepository interface {
-	VersionByFQN(fqn ModuleVersionFQN) (mv ModuleVersion, err error)
+	VersionByFQN(ctx context.Context, fqn ModuleVersionFQN) (mv ModuleVersion, err error)
 }
 
-func (v *commandValidator) ModuleVersionMustBeUniqueForFQN(r moduleVersionMustBeUniqueForFQNRepository, sl validator.StructLevel, fqn ModuleVersionFQN) {
-	_, err := r.VersionByFQN(fqn)
+func (v *commandValidator) ModuleVersionMustBeUniqueForFQN(ctx context.Context, r moduleVersionMustBeUniqueForFQNRepository, sl validator.StructLevel, fqn ModuleVersionFQN) {
+	_, err := r.VersionByFQN(ctx, fqn)
 
 	if _, ok := err.(ErrResourceNotFound); !ok {
 		sl.ReportError(fqn.String(), "ns/name/provider@version", "CompositeField", uniqueModuleVersionTag, fqn.String())
@@ -86,11 +128,11 @@ func (v *commandValidator) ModuleVersionMustBeUniqueForFQN(r moduleVersionMustBe
 }
 
 type moduleVersionMustBeUniqueForIdRepository interface {
-	VersionByModuleAndValue(moduleId string, version string) (mv ModuleVersion, err error)
+	VersionByModuleAndValue(ctx context.Context, moduleId string, version string) (mv ModuleVersion, err error)
 }
 
-func (v *commandValidator) ModuleVersionMustBeUniqueForId(r moduleVersionMustBeUniqueForIdRepository, sl validator.StructLevel, id string, version string) {
-	_, err := r.VersionByModuleAndValue(id, version)
+func (v *commandValidator) ModuleVersionMustBeUniqueForId(ctx context.Context, r moduleVersionMustBeUniqueForIdRepository, sl validator.StructLevel, id string, version string) {
+	_, err := r.VersionByModuleAndValue(ctx, id, version)
 
 	if _, ok := err.(ErrResourceNotFound); !ok {
 		sl.ReportError(id, "uuid@version", "CompositeField", uniqueModuleVersionTag, id)
@@ -102,11 +144,11 @@ func (v *commandValidator) ModuleVersionMustBeUniqueForId(r moduleVersionMustBeU
 }
 
 type moduleMustExistByFQNRepository interface {
-	ByFQN(ModuleFQN) (m Module, err error)
+	ByFQN(context.Context, ModuleFQN) (m Module, err error)
 }
 
-func (v *commandValidator) ModuleMustExistByFQN(r moduleMustExistByFQNRepository, sl validator.StructLevel, fqn ModuleFQN) {
-	_, err := r.ByFQN(fqn)
+func (v *commandValidator) ModuleMustExistByFQN(ctx context.Context, r moduleMustExistByFQNRepository, sl validator.StructLevel, fqn ModuleFQN) {
+	_, err := r.ByFQN(ctx, fqn)
 
 	if _, ok := err.(ErrResourceNotFound); ok {
 		sl.ReportError(fqn.String(), "ns/name/provider", "CompositeField", moduleMustExistTag, fqn.String())
@@ -114,11 +156,11 @@ func (v *commandValidator) ModuleMustExistByFQN(r moduleMustExistByFQNRepository
 }
 
 type moduleMustExistByIdRepository interface {
-	ById(id string) (m Module, err error)
+	ById(ctx context.Context, id string) (m Module, err error)
 }
 
-func (v *commandValidator) ModuleMustExistById(r moduleMustExistByIdRepository, sl validator.StructLevel, id string) {
-	_, err := r.ById(id)
+func (v *commandValidator) ModuleMustExistById(ctx context.Context, r moduleMustExistByIdRepository, sl validator.StructLevel, id string) {
+	_, err := r.ById(ctx, id)
 
 	if _, ok := err.(ErrResourceNotFound); ok {
 		sl.ReportError(id, "uuid", "CompositeField", moduleMustExistTag, id)
@@ -126,11 +168,11 @@ func (v *commandValidator) ModuleMustExistById(r moduleMustExistByIdRepository,
 }
 
 type noVersionsExistForIdRepository interface {
-	VersionsByModule(moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	VersionsByModule(ctx context.Context, moduleId string, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
 }
 
-func (v *commandValidator) NoVersionsExistForModuleId(r noVersionsExistForIdRepository, sl validator.StructLevel, id string) {
-	res, err := r.VersionsByModule(id, ChunkingOptions{})
+func (v *commandValidator) NoVersionsExistForModuleId(ctx context.Context, r noVersionsExistForIdRepository, sl validator.StructLevel, id string) {
+	res, err := r.VersionsByModule(ctx, id, ChunkingOptions{})
 
 	if err != nil {
 		v.logger.Error().Err(err).Msg("unexpected repository error during validation")
@@ -142,11 +184,11 @@ func (v *commandValidator) NoVersionsExistForModuleId(r noVersionsExistForIdRepo
 }
 
 type noVersionsExistForModuleFQNRepository interface {
-	VersionsByModuleFQN(fqn ModuleFQN, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
+	VersionsByModuleFQN(ctx context.Context, fqn ModuleFQN, chunkOpts ChunkingOptions) (m []ModuleVersion, err error)
 }
 
-func (v *commandValidator) NoVersionsExistForModuleFQN(r noVersionsExistForModuleFQNRepository, sl validator.StructLevel, fqn ModuleFQN) {
-	res, err := r.VersionsByModuleFQN(fqn, ChunkingOptions{})
+func (v *commandValidator) NoVersionsExistForModuleFQN(ctx context.Context, r noVersionsExistForModuleFQNRepository, sl validator.StructLevel, fqn ModuleFQN) {
+	res, err := r.VersionsByModuleFQN(ctx, fqn, ChunkingOptions{})
 
 	if err != nil {
 		v.logger.Error().Err(err).Msg("unexpected repository error during validation")
@@ -220,7 +262,87 @@ func messageForFieldError(e validator.FieldError) (string, error) {
 	case uuidTag:
 		return "must be a valid uuid", nil
 	case versionTag:
-		return "must be semver (^[0-9]+\\.[0-9]+\\.[0-9]+$) or prefixed with 'dev-'", nil
+		return "must be semver, e.g. 1.2.0 or 1.2.0-rc.1, or prefixed with 'dev-'", nil
+	case modulePathTag:
+		return "must be a relative path within the repository", nil
+	case tagPatternTag:
+		return "must contain " + TagPatternVersionPlaceholder + " exactly once", nil
+	case repositoryURLTag:
+		return "must be an https://, ssh:// or git@<host>:<path> url, local paths are only accepted from the cli", nil
+	case webhookURLTag:
+		return "must be an absolute http or https URL", nil
+	case eventTypeTag:
+		types := []string{}
+		for _, t := range AllEventTypes() {
+			types = append(types, string(t))
+		}
+		return fmt.Sprintf("must be one of [%s]", strings.Join(types, ",")), nil
+	case minTag:
+		return fmt.Sprintf("must contain at least %s item(s)", e.Param()), nil
+	case providerVersionTag:
+		return "must be semver, e.g. 1.0.0 or 1.0.0-beta1", nil
+	case gpgPublicKeyTag:
+		return "must be a single ascii armored gpg public key", nil
+	case uniqueGPGKeyTag:
+		return "this key has already been added to the namespace", nil
+	case gpgKeyNotInUseTag:
+		return "the key has signed provider versions, which could no longer be verified", nil
+	case uniqueProviderVersionTag:
+		return "version already exists for this provider", nil
+	case uniqueProviderArchiveTag:
+		return "the archive was uploaded more than once", nil
+	case shasumsTag:
+		return "must be a SHA256SUMS file, with a sha256 and filename per line", nil
+	case providerArchiveNameTag:
+		return "must be named terraform-provider-{type}_{version}_{os}_{arch}.zip", nil
+	case listedInShasumsTag:
+		return "is not listed in the SHA256SUMS file", nil
+	case signedByNamespaceKeyTag:
+		return "must be a signature of the SHA256SUMS file, by one of the namespace's gpg keys", nil
+	case directoryTag:
+		return "must be an existing directory", nil
+	case versionConstraintTag:
+		return "must be a terraform version constraint, e.g. ~> 3.2 or >= 1.0, < 2.0", nil
+	case breakingChangePolicyTag:
+		return fmt.Sprintf("must be one of [%s,%s]", BreakingChangePolicies.Reject, BreakingChangePolicies.Warn), nil
+	case tokenScopeTag:
+		scopes := []string{}
+		for _, s := range AllTokenScopes() {
+			scopes = append(scopes, string(s))
+		}
+		return fmt.Sprintf("must be one of [%s], or one of them other than admin limited to a namespace as <scope>:<namespace>", strings.Join(scopes, ",")), nil
+	case futureTag:
+		return "must be in the future", nil
+	case usernameTag:
+		return "must be lowercase letters, numbers, '.', '_' or '-', up to 64 characters", nil
+	case passwordTag:
+		return fmt.Sprintf("must be at least %d characters", minPasswordLength), nil
+	case uniqueUsernameTag:
+		return "a user with this username already exists", nil
+	case namespaceTag:
+		return "must be letters, numbers, '_' or '-', up to 64 characters", nil
+	case roleTag:
+		roles := []string{}
+		for _, r := range AllRoles() {
+			roles = append(roles, string(r))
+		}
+		return fmt.Sprintf("must be one of [%s]", strings.Join(roles, ",")), nil
+	case subjectTag:
+		return "must be user:<username>, team:<name> or token:<id>", nil
+	case uniqueNamespaceTag:
+		return "a namespace with this name already exists", nil
+	case uniqueTeamTag:
+		return "a team with this name already exists", nil
+	case existingSubjectTag:
+		return fmt.Sprintf("%s does not exist", e.Param()), nil
+	case emptyNamespaceTag:
+		return "the namespace still has modules, delete them first", nil
+	case loginClientTag:
+		return "must be " + e.Param(), nil
+	case loopbackRedirectTag:
+		return fmt.Sprintf("must be an http url on localhost, with a port in %s", e.Param()), nil
+	case responseTypeTag, pkceMethodTag:
+		return "must be " + e.Param(), nil
 	default:
 		return "", errors.New("type not implemented")
 	}
@@ -240,9 +362,130 @@ func versionValidator(fl validator.FieldLevel) bool {
 		return true
 	}
 
-	matched, err := regexp.MatchString(`^[0-9]+\.[0-9]+\.[0-9]+$`, val)
+	_, err := ParseSemVer(val)
+
+	return err == nil
+}
+
+func modulePathValidator(fl validator.FieldLevel) bool {
+	val := fl.Field().String()
+
+	if val == "" {
+		return true
+	}
+
+	if strings.HasPrefix(val, "/") {
+		return false
+	}
+
+	cleaned := CleanModulePath(val)
+
+	return cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
+
+func tagPatternValidator(fl validator.FieldLevel) bool {
+	val := fl.Field().String()
+
+	return val == "" || strings.Count(val, TagPatternVersionPlaceholder) == 1
+}
+
+var scpRepositoryPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*@[A-Za-z0-9][A-Za-z0-9.-]*:.+$`)
+
+// IsRemoteRepositoryURL is true for the remotes git is allowed to clone from
+// a url that isn't trusted, anything else could read the server's own files
+// or be taken as an option by git.
+func IsRemoteRepositoryURL(val string) bool {
+	if scpRepositoryPattern.MatchString(val) {
+		return true
+	}
+
+	u, err := url.Parse(val)
+
+	return err == nil && (u.Scheme == "https" || u.Scheme == "ssh") && u.Host != "" && !strings.HasPrefix(u.Host, "-")
+}
+
+// repositoryURLValidator only accepts a local path when the command came from
+// the cli, whose user can already read the server's files.
+func repositoryURLValidator(fl validator.FieldLevel) bool {
+	val := fl.Field().String()
+
+	if IsRemoteRepositoryURL(val) {
+		return true
+	}
+
+	allowLocal := fl.Parent().FieldByName("AllowLocalRepository")
+
+	return allowLocal.IsValid() && allowLocal.Bool() && !strings.HasPrefix(val, "-")
+}
+
+func webhookURLValidator(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func eventTypeValidator(fl validator.FieldLevel) bool {
+	return IsEventType(fl.Field().String())
+}
+
+func versionConstraintValidator(fl validator.FieldLevel) bool {
+	_, err := goversion.NewConstraint(fl.Field().String())
+
+	return err == nil
+}
+
+func breakingChangePolicyValidator(fl validator.FieldLevel) bool {
+	return IsBreakingChangePolicy(fl.Field().String())
+}
+
+func tokenScopeValidator(fl validator.FieldLevel) bool {
+	return IsTokenScope(fl.Field().String())
+}
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+func usernameValidator(fl validator.FieldLevel) bool {
+	return usernamePattern.MatchString(fl.Field().String())
+}
+
+var namespacePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+func namespaceValidator(fl validator.FieldLevel) bool {
+	return namespacePattern.MatchString(fl.Field().String())
+}
+
+func roleValidator(fl validator.FieldLevel) bool {
+	return IsRole(fl.Field().String())
+}
+
+func subjectValidator(fl validator.FieldLevel) bool {
+	_, _, ok := ParseSubject(fl.Field().String())
+
+	return ok
+}
+
+const minPasswordLength = 12
+
+func passwordValidator(fl validator.FieldLevel) bool {
+	return len([]rune(fl.Field().String())) >= minPasswordLength
+}
+
+func futureValidator(fl validator.FieldLevel) bool {
+	t, ok := fl.Field().Interface().(time.Time)
 
-	return matched && err == nil
+	return ok && t.After(time.Now())
+}
+
+var providerVersionPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?$`)
+
+func providerVersionValidator(fl validator.FieldLevel) bool {
+	return providerVersionPattern.MatchString(fl.Field().String())
+}
+
+func gpgPublicKeyValidator(fl validator.FieldLevel) bool {
+	_, err := gpg.KeyId(fl.Field().String())
+
+	return err == nil
 }
 
 func buildRequiredModuleVersionRuleMessage(e validator.FieldError) (string, error) {
@@ -270,6 +513,86 @@ func NewCommandValidator(l zerolog.Logger) CommandValidator {
 		l.Error().Err(err).Msg("failed to register version validator")
 	}
 
+	err = v.RegisterValidation(modulePathTag, modulePathValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register module path validator")
+	}
+
+	err = v.RegisterValidation(tagPatternTag, tagPatternValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register tag pattern validator")
+	}
+
+	err = v.RegisterValidation(repositoryURLTag, repositoryURLValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register repository url validator")
+	}
+
+	err = v.RegisterValidation(webhookURLTag, webhookURLValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register webhook url validator")
+	}
+
+	err = v.RegisterValidation(eventTypeTag, eventTypeValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register event type validator")
+	}
+
+	err = v.RegisterValidation(versionConstraintTag, versionConstraintValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register version constraint validator")
+	}
+
+	err = v.RegisterValidation(breakingChangePolicyTag, breakingChangePolicyValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register breaking change policy validator")
+	}
+
+	err = v.RegisterValidation(tokenScopeTag, tokenScopeValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register token scope validator")
+	}
+
+	err = v.RegisterValidation(futureTag, futureValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register future validator")
+	}
+
+	err = v.RegisterValidation(usernameTag, usernameValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register username validator")
+	}
+
+	err = v.RegisterValidation(passwordTag, passwordValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register password validator")
+	}
+
+	err = v.RegisterValidation(namespaceTag, namespaceValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register namespace validator")
+	}
+
+	err = v.RegisterValidation(roleTag, roleValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register role validator")
+	}
+
+	err = v.RegisterValidation(subjectTag, subjectValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register subject validator")
+	}
+
+	err = v.RegisterValidation(providerVersionTag, providerVersionValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register provider version validator")
+	}
+
+	err = v.RegisterValidation(gpgPublicKeyTag, gpgPublicKeyValidator)
+	if err != nil {
+		l.Error().Err(err).Msg("failed to register gpg public key validator")
+	}
+
 	return &commandValidator{
 		validate: v,
 		logger:   l,
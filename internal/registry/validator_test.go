@@ -0,0 +1,171 @@
+package registry
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+func Test_modulePathValidator(t *testing.T) {
+	tests := []struct {
+		path  string
+		valid bool
+	}{
+		{path: "", valid: true},
+		{path: "modules/vpc", valid: true},
+		{path: "modules/vpc/", valid: true},
+		{path: "modules/../vpc", valid: true},
+		{path: "/modules/vpc", valid: false},
+		{path: "..", valid: false},
+		{path: "../vpc", valid: false},
+		{path: "modules/../../vpc", valid: false},
+	}
+
+	v := NewCommandValidator(ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+
+	for _, test := range tests {
+		t.Run(test.path, func(tt *testing.T) {
+			errs := v.Validate(UpdateModuleV1DTO{
+				Id:   "5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f",
+				Path: &test.path,
+			})
+
+			if test.valid {
+				assert.Empty(tt, errs)
+				return
+			}
+
+			assert.Equal(tt, []ValidationError{
+				{
+					Message: "must be a relative path within the repository",
+					Rule:    modulePathTag,
+					Field:   "Path",
+					Value:   "",
+				},
+			}, errs)
+		})
+	}
+}
+
+func Test_repositoryURLValidator(t *testing.T) {
+	tests := []struct {
+		url        string
+		allowLocal bool
+		valid      bool
+	}{
+		{url: "https://github.com/acme/terraform-aws-vpc.git", valid: true},
+		{url: "ssh://git@gitlab.example.com:2222/acme/modules.git", valid: true},
+		{url: "git@github.com:acme/terraform-aws-vpc.git", valid: true},
+		{url: "http://github.com/acme/terraform-aws-vpc.git", valid: false},
+		{url: "file:///srv/git/modules.git", valid: false},
+		{url: "/srv/git/modules", valid: false},
+		{url: "ext::sh -c touch% /tmp/pwned", valid: false},
+		{url: "--upload-pack=touch /tmp/pwned", valid: false},
+		{url: "ssh://-oProxyCommand=touch% /tmp/pwned/x", valid: false},
+		{url: "/srv/git/modules", allowLocal: true, valid: true},
+		{url: "--upload-pack=touch /tmp/pwned", allowLocal: true, valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.url, func(tt *testing.T) {
+			v := NewCommandValidator(ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+			errs := v.Validate(UpdateModuleV1DTO{
+				Id:                   "5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f",
+				RepositoryURL:        &test.url,
+				AllowLocalRepository: test.allowLocal,
+			})
+
+			if test.valid {
+				assert.Empty(tt, errs)
+				return
+			}
+
+			assert.Len(tt, errs, 1)
+			assert.Equal(tt, repositoryURLTag, errs[0].Rule)
+		})
+	}
+}
+
+func Test_CleanModulePath(t *testing.T) {
+	assert.Equal(t, "", CleanModulePath(""))
+	assert.Equal(t, "", CleanModulePath("/"))
+	assert.Equal(t, "", CleanModulePath("."))
+	assert.Equal(t, "modules/vpc", CleanModulePath("/modules/vpc/"))
+	assert.Equal(t, "vpc", CleanModulePath("modules/../vpc"))
+}
+
+func Test_tagPatternValidator(t *testing.T) {
+	tests := []struct {
+		pattern string
+		valid   bool
+	}{
+		{pattern: "v{version}", valid: true},
+		{pattern: "modules/vpc/v{version}", valid: true},
+		{pattern: "{version}-vpc", valid: true},
+		{pattern: "modules/vpc", valid: false},
+		{pattern: "{version}/{version}", valid: false},
+	}
+
+	v := NewCommandValidator(ymirstubs.BuildZerologLogger(new(bytes.Buffer)))
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(tt *testing.T) {
+			errs := v.Validate(UpdateModuleV1DTO{
+				Id:         "5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f",
+				TagPattern: &test.pattern,
+			})
+
+			if test.valid {
+				assert.Empty(tt, errs)
+				return
+			}
+
+			assert.Len(tt, errs, 1)
+			assert.Equal(tt, tagPatternTag, errs[0].Rule)
+			assert.Equal(tt, "must contain {version} exactly once", errs[0].Message)
+		})
+	}
+}
+
+func Test_Module_VersionFromTag(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		tag      string
+		expected string
+		matched  bool
+	}{
+		{pattern: "modules/vpc/v{version}", tag: "modules/vpc/v1.4.0", expected: "1.4.0", matched: true},
+		{pattern: "modules/vpc/v{version}", tag: "modules/vpc-peering/v1.4.0", matched: false},
+		{pattern: "modules/vpc/v{version}", tag: "modules/vpc/v", matched: false},
+		{pattern: "{version}-vpc", tag: "2.0.0-vpc", expected: "2.0.0", matched: true},
+		{pattern: "v{version}", tag: "v1.0.0", expected: "1.0.0", matched: true},
+		{pattern: "", tag: "v1.0.0", matched: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.tag, func(tt *testing.T) {
+			version, matched := Module{TagPattern: test.pattern}.VersionFromTag(test.tag)
+
+			assert.Equal(tt, test.matched, matched)
+			assert.Equal(tt, test.expected, version)
+		})
+	}
+}
+
+func Test_NormaliseRepositoryURL(t *testing.T) {
+	for _, u := range []string{
+		"git@github.com:org/modules.git",
+		"https://github.com/org/modules.git",
+		"https://github.com/org/modules",
+		"https://GitHub.com/org/modules/",
+		"ssh://git@github.com/org/modules.git",
+		"https://token@github.com/org/modules.git",
+	} {
+		assert.Equal(t, "github.com/org/modules", NormaliseRepositoryURL(u), u)
+	}
+
+	assert.Equal(t, "/srv/git/modules", NormaliseRepositoryURL("/srv/git/modules.git"))
+	assert.NotEqual(t, NormaliseRepositoryURL("https://github.com/org/modules"), NormaliseRepositoryURL("https://github.com/Org/modules-other"))
+}
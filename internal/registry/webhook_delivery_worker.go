@@ -0,0 +1,188 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type webhookSender interface {
+	Send(url string, headers map[string]string, body []byte) (statusCode int, err error)
+}
+
+type webhookDeliveryWorkerRepository interface {
+	SubscriptionById(id string) (s WebhookSubscription, err error)
+	DueDeliveries(at time.Time, chunkOpts ChunkingOptions) ([]WebhookDelivery, error)
+	ClaimDelivery(d WebhookDelivery, retryAt time.Time) (claimed bool, err error)
+	UpdateDelivery(WebhookDelivery) (d WebhookDelivery, err error)
+	AddDeliveryAttempt(WebhookDeliveryAttempt) error
+}
+
+const webhookDeliveryBatchSize = 20
+
+// A claimed delivery is retried after this long if the worker sending it
+// dies before recording the attempt.
+const webhookDeliveryLease = 5 * time.Minute
+
+const webhookDeliveryMaxBackoff = time.Hour
+
+// WebhookDeliveryWorker sends the deliveries queued by the WebhookOutbox,
+// retrying failures with an exponential backoff.
+type WebhookDeliveryWorker struct {
+	repo        webhookDeliveryWorkerRepository
+	sender      webhookSender
+	logger      zerolog.Logger
+	interval    time.Duration
+	maxAttempts int
+	heartbeat   heartbeat
+}
+
+// deliveryBackoff is the wait after a failed attempt, 30s doubling each time.
+func deliveryBackoff(attempts int) time.Duration {
+	backoff := 30 * time.Second
+
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+
+		if backoff >= webhookDeliveryMaxBackoff {
+			return webhookDeliveryMaxBackoff
+		}
+	}
+
+	return backoff
+}
+
+func (w *WebhookDeliveryWorker) Run(ctx context.Context) {
+	w.logger.Info().Dur("interval", w.interval).Msg("webhook delivery worker started")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		for w.ProcessDue() == webhookDeliveryBatchSize {
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			w.logger.Info().Msg("webhook delivery worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue sends a batch of the deliveries that are due, returning how many
+// were found.
+func (w *WebhookDeliveryWorker) ProcessDue() int {
+	w.heartbeat.beat()
+
+	now := time.Now().UTC()
+	due, err := w.repo.DueDeliveries(now, ChunkingOptions{
+		Size: webhookDeliveryBatchSize,
+	})
+
+	if err != nil {
+		w.logger.Error().Err(err).Msg("failed to list due webhook deliveries")
+
+		return 0
+	}
+
+	for _, d := range due {
+		claimed, err := w.repo.ClaimDelivery(d, now.Add(webhookDeliveryLease))
+
+		if err != nil {
+			w.logger.Error().Err(err).Str("delivery_id", d.Id).Msg("failed to claim webhook delivery")
+			continue
+		}
+
+		if !claimed {
+			// Another worker got there first
+			continue
+		}
+
+		d.Attempts++
+		w.deliver(d)
+		w.heartbeat.beat()
+	}
+
+	return len(due)
+}
+
+// LastPolled is when the worker last looked for due deliveries or finished
+// sending one, zero until it has started.
+func (w *WebhookDeliveryWorker) LastPolled() time.Time {
+	return w.heartbeat.last()
+}
+
+func (w *WebhookDeliveryWorker) deliver(d WebhookDelivery) {
+	s, err := w.repo.SubscriptionById(d.SubscriptionId)
+
+	if err != nil {
+		// The subscription was deleted after the event was queued
+		w.logger.Error().Err(err).Str("delivery_id", d.Id).Msg("failed to find subscription for webhook delivery")
+		d.Status = DeliveryStatuses.Failed
+		w.update(d)
+
+		return
+	}
+
+	started := time.Now()
+	body := []byte(d.Payload)
+	code, err := w.sender.Send(s.URL, map[string]string{
+		"X-Ymir-Event":         string(d.EventType),
+		"X-Ymir-Delivery":      d.Id,
+		"X-Ymir-Signature-256": s.Sign(body),
+	}, body)
+
+	attempt := WebhookDeliveryAttempt{
+		Id:           uuid.New().String(),
+		DeliveryId:   d.Id,
+		AttemptedAt:  started.UTC(),
+		ResponseCode: code,
+		DurationMs:   time.Since(started).Milliseconds(),
+	}
+
+	if err != nil {
+		attempt.Error = err.Error()
+	} else if !attempt.Succeeded() {
+		attempt.Error = fmt.Sprintf("unexpected response status %d", code)
+	}
+
+	if err := w.repo.AddDeliveryAttempt(attempt); err != nil {
+		w.logger.Error().Err(err).Str("delivery_id", d.Id).Msg("failed to record webhook delivery attempt")
+	}
+
+	switch {
+	case attempt.Succeeded():
+		d.Status = DeliveryStatuses.Delivered
+	case d.Attempts >= w.maxAttempts:
+		w.logger.Info().Str("delivery_id", d.Id).Int("attempts", d.Attempts).Msg("giving up on webhook delivery")
+		d.Status = DeliveryStatuses.Failed
+	default:
+		d.NextAttemptAt = time.Now().UTC().Add(deliveryBackoff(d.Attempts))
+	}
+
+	w.update(d)
+}
+
+func (w *WebhookDeliveryWorker) update(d WebhookDelivery) {
+	if _, err := w.repo.UpdateDelivery(d); err != nil {
+		w.logger.Error().Err(err).Str("delivery_id", d.Id).Msg("failed to update webhook delivery")
+	}
+}
+
+func NewWebhookDeliveryWorker(r webhookDeliveryWorkerRepository, s webhookSender, interval time.Duration, maxAttempts int, l zerolog.Logger) *WebhookDeliveryWorker {
+	return &WebhookDeliveryWorker{
+		repo:        r,
+		sender:      s,
+		logger:      l,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+	}
+}
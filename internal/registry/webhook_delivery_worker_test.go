@@ -0,0 +1,165 @@
+package registry
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type sentWebhook struct {
+	url     string
+	headers map[string]string
+	body    string
+}
+
+type fakeWebhookSender struct {
+	code int
+	err  error
+	sent []sentWebhook
+}
+
+func (s *fakeWebhookSender) Send(url string, headers map[string]string, body []byte) (int, error) {
+	s.sent = append(s.sent, sentWebhook{url: url, headers: headers, body: string(body)})
+
+	return s.code, s.err
+}
+
+func buildDeliveryRepository(attempts int) *fakeWebhookRepository {
+	return &fakeWebhookRepository{
+		subscriptions: []WebhookSubscription{
+			{Id: "sub-1", URL: "https://bot.example.com/ymir", Secret: "shh"},
+		},
+		deliveries: []WebhookDelivery{
+			{
+				Id: "d-1", SubscriptionId: "sub-1", EventType: EventTypes.VersionReady, Payload: `{"id":"e-1"}`,
+				Status: DeliveryStatuses.Pending, Attempts: attempts, NextAttemptAt: time.Now().Add(-time.Minute),
+			},
+			{
+				Id: "d-2", SubscriptionId: "sub-1", EventType: EventTypes.VersionReady, Payload: `{"id":"e-2"}`,
+				Status: DeliveryStatuses.Pending, NextAttemptAt: time.Now().Add(time.Hour),
+			},
+		},
+	}
+}
+
+func Test_WebhookDeliveryWorker_ProcessDue_Delivered(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := buildDeliveryRepository(0)
+	sender := &fakeWebhookSender{code: 204}
+
+	w := NewWebhookDeliveryWorker(repo, sender, time.Second, 3, l)
+
+	assert.Equal(t, 1, w.ProcessDue())
+	assert.Len(t, sender.sent, 1)
+
+	sent := sender.sent[0]
+
+	assert.Equal(t, "https://bot.example.com/ymir", sent.url)
+	assert.Equal(t, `{"id":"e-1"}`, sent.body)
+	assert.Equal(t, "module_version.ready", sent.headers["X-Ymir-Event"])
+	assert.Equal(t, "d-1", sent.headers["X-Ymir-Delivery"])
+	assert.Equal(t, WebhookSubscription{Secret: "shh"}.Sign([]byte(sent.body)), sent.headers["X-Ymir-Signature-256"])
+
+	assert.Equal(t, DeliveryStatuses.Delivered, repo.deliveries[0].Status)
+	assert.Equal(t, 1, repo.deliveries[0].Attempts)
+	assert.Equal(t, DeliveryStatuses.Pending, repo.deliveries[1].Status)
+
+	assert.Len(t, repo.attempts, 1)
+	assert.Equal(t, 204, repo.attempts[0].ResponseCode)
+	assert.Equal(t, "", repo.attempts[0].Error)
+}
+
+func Test_WebhookDeliveryWorker_ProcessDue_Retries(t *testing.T) {
+	tests := []struct {
+		name              string
+		priorAttempts     int
+		code              int
+		err               error
+		expectedStatus    DeliveryStatus
+		expectedError     string
+		expectedRetryWait time.Duration
+	}{
+		{
+			name:              "error response is retried",
+			code:              500,
+			expectedStatus:    DeliveryStatuses.Pending,
+			expectedError:     "unexpected response status 500",
+			expectedRetryWait: 30 * time.Second,
+		},
+		{
+			name:              "unreachable subscriber is retried later each time",
+			priorAttempts:     1,
+			err:               errors.New("connection refused"),
+			expectedStatus:    DeliveryStatuses.Pending,
+			expectedError:     "connection refused",
+			expectedRetryWait: time.Minute,
+		},
+		{
+			name:           "gives up after the last attempt",
+			priorAttempts:  2,
+			code:           404,
+			expectedStatus: DeliveryStatuses.Failed,
+			expectedError:  "unexpected response status 404",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+			repo := buildDeliveryRepository(test.priorAttempts)
+			sender := &fakeWebhookSender{code: test.code, err: test.err}
+
+			w := NewWebhookDeliveryWorker(repo, sender, time.Second, 3, l)
+			started := time.Now().UTC()
+			w.ProcessDue()
+
+			d := repo.deliveries[0]
+
+			assert.Equal(tt, test.expectedStatus, d.Status)
+			assert.Equal(tt, test.priorAttempts+1, d.Attempts)
+			assert.Len(tt, repo.attempts, 1)
+			assert.Equal(tt, test.expectedError, repo.attempts[0].Error)
+
+			if test.expectedRetryWait > 0 {
+				assert.WithinDuration(tt, started.Add(test.expectedRetryWait), d.NextAttemptAt, 5*time.Second)
+			}
+		})
+	}
+}
+
+func Test_WebhookDeliveryWorker_ProcessDue_NotClaimed(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := buildDeliveryRepository(0)
+	repo.claimFails = true
+	sender := &fakeWebhookSender{code: 200}
+
+	w := NewWebhookDeliveryWorker(repo, sender, time.Second, 3, l)
+
+	assert.Equal(t, 1, w.ProcessDue())
+	assert.Empty(t, sender.sent)
+	assert.Equal(t, DeliveryStatuses.Pending, repo.deliveries[0].Status)
+}
+
+func Test_WebhookDeliveryWorker_ProcessDue_SubscriptionDeleted(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := buildDeliveryRepository(0)
+	repo.subscriptions = []WebhookSubscription{}
+	sender := &fakeWebhookSender{code: 200}
+
+	w := NewWebhookDeliveryWorker(repo, sender, time.Second, 3, l)
+	w.ProcessDue()
+
+	assert.Empty(t, sender.sent)
+	assert.Equal(t, DeliveryStatuses.Failed, repo.deliveries[0].Status)
+}
+
+func Test_deliveryBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, deliveryBackoff(1))
+	assert.Equal(t, time.Minute, deliveryBackoff(2))
+	assert.Equal(t, 4*time.Minute, deliveryBackoff(4))
+	assert.Equal(t, time.Hour, deliveryBackoff(20))
+}
@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type webhookOutboxRepository interface {
+	AllSubscriptions() ([]WebhookSubscription, error)
+	AddDeliveries([]WebhookDelivery) error
+}
+
+// WebhookOutbox queues a delivery of each event for every subscription that
+// wants it. Nothing is sent here, that's the WebhookDeliveryWorker's job, so
+// a slow or broken subscriber never holds up the registry.
+type WebhookOutbox struct {
+	repo   webhookOutboxRepository
+	logger zerolog.Logger
+}
+
+func (o *WebhookOutbox) Publish(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	subs, err := o.repo.AllSubscriptions()
+
+	if err != nil {
+		o.logger.Error().Err(err).Msg("failed to list webhook subscriptions")
+
+		return err
+	}
+
+	now := time.Now().UTC()
+	deliveries := []WebhookDelivery{}
+
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+
+		if err != nil {
+			o.logger.Error().Err(err).Str("event_id", e.Id).Msg("failed to encode event")
+
+			return err
+		}
+
+		for _, s := range subs {
+			if !s.Matches(e) {
+				continue
+			}
+
+			deliveries = append(deliveries, WebhookDelivery{
+				Id:             uuid.New().String(),
+				SubscriptionId: s.Id,
+				EventId:        e.Id,
+				EventType:      e.Type,
+				Payload:        string(payload),
+				Status:         DeliveryStatuses.Pending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+			})
+		}
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := o.repo.AddDeliveries(deliveries); err != nil {
+		o.logger.Error().Err(err).Int("deliveries", len(deliveries)).Msg("failed to queue webhook deliveries")
+
+		return err
+	}
+
+	return nil
+}
+
+func NewWebhookOutbox(r webhookOutboxRepository, l zerolog.Logger) *WebhookOutbox {
+	return &WebhookOutbox{
+		repo:   r,
+		logger: l,
+	}
+}
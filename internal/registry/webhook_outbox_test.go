@@ -0,0 +1,261 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ymirstubs "github.com/svartlfheim/ymir/test/stubs"
+)
+
+type fakeWebhookRepository struct {
+	subscriptions []WebhookSubscription
+	deliveries    []WebhookDelivery
+	attempts      []WebhookDeliveryAttempt
+	claimFails    bool
+}
+
+func (r *fakeWebhookRepository) SubscriptionById(id string) (WebhookSubscription, error) {
+	for _, s := range r.subscriptions {
+		if s.Id == id {
+			return s, nil
+		}
+	}
+
+	return WebhookSubscription{}, ErrResourceNotFound{Type: "WebhookSubscription", URI: id}
+}
+
+func (r *fakeWebhookRepository) AllSubscriptions() ([]WebhookSubscription, error) {
+	return r.subscriptions, nil
+}
+
+func (r *fakeWebhookRepository) AddSubscription(s WebhookSubscription) (WebhookSubscription, error) {
+	r.subscriptions = append(r.subscriptions, s)
+
+	return s, nil
+}
+
+func (r *fakeWebhookRepository) DeleteSubscription(s WebhookSubscription) error {
+	kept := []WebhookSubscription{}
+
+	for _, existing := range r.subscriptions {
+		if existing.Id != s.Id {
+			kept = append(kept, existing)
+		}
+	}
+
+	r.subscriptions = kept
+
+	return nil
+}
+
+func (r *fakeWebhookRepository) DeliveriesBySubscription(subscriptionId string, chunkOpts ChunkingOptions) ([]WebhookDelivery, error) {
+	found := []WebhookDelivery{}
+
+	for _, d := range r.deliveries {
+		if d.SubscriptionId == subscriptionId {
+			found = append(found, d)
+		}
+	}
+
+	return found, nil
+}
+
+func (r *fakeWebhookRepository) DueDeliveries(at time.Time, chunkOpts ChunkingOptions) ([]WebhookDelivery, error) {
+	found := []WebhookDelivery{}
+
+	for _, d := range r.deliveries {
+		if d.Status == DeliveryStatuses.Pending && !d.NextAttemptAt.After(at) {
+			found = append(found, d)
+		}
+	}
+
+	return found, nil
+}
+
+func (r *fakeWebhookRepository) AddDeliveries(ds []WebhookDelivery) error {
+	r.deliveries = append(r.deliveries, ds...)
+
+	return nil
+}
+
+func (r *fakeWebhookRepository) ClaimDelivery(d WebhookDelivery, retryAt time.Time) (bool, error) {
+	if r.claimFails {
+		return false, nil
+	}
+
+	for i, existing := range r.deliveries {
+		if existing.Id == d.Id && existing.Attempts == d.Attempts {
+			r.deliveries[i].Attempts++
+			r.deliveries[i].NextAttemptAt = retryAt
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeWebhookRepository) UpdateDelivery(d WebhookDelivery) (WebhookDelivery, error) {
+	for i, existing := range r.deliveries {
+		if existing.Id == d.Id {
+			r.deliveries[i] = d
+		}
+	}
+
+	return d, nil
+}
+
+func (r *fakeWebhookRepository) AddDeliveryAttempt(a WebhookDeliveryAttempt) error {
+	r.attempts = append(r.attempts, a)
+
+	return nil
+}
+
+type fakeAuditLogsRepo struct {
+	saved []string
+}
+
+func (r *fakeAuditLogsRepo) Save(action string, respStatus RegistryHandlerStatus, occurred_at time.Time, meta map[string]interface{}) error {
+	r.saved = append(r.saved, action)
+
+	return nil
+}
+
+type fakeEventPublisher struct {
+	published []Event
+}
+
+func (p *fakeEventPublisher) Publish(events []Event) error {
+	p.published = append(p.published, events...)
+
+	return errors.New("publishing is logged, not returned")
+}
+
+var outboxTestModule = Module{Id: "5b3c0c8e-5bf5-4bd4-a4bb-6e1bd0f83e2f", Provider: "aws", Namespace: "platform", Name: "vpc"}
+
+func Test_WebhookOutbox_Publish(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	repo := &fakeWebhookRepository{
+		subscriptions: []WebhookSubscription{
+			{Id: "everything", URL: "https://a.example.com"},
+			{Id: "ready-only", URL: "https://b.example.com", EventTypes: []EventType{EventTypes.VersionReady}},
+			{Id: "other-namespace", URL: "https://c.example.com", Namespaces: []string{"data"}},
+		},
+	}
+
+	events := BuildModuleVersionV1Response{
+		occurredAt:    time.Now().UTC(),
+		Status:        STATUS_FAILED,
+		Module:        outboxTestModule,
+		ModuleVersion: ModuleVersion{Id: "mv-1", ModuleId: outboxTestModule.Id, Version: "1.0.0", Status: VersionStatuses.Failed},
+	}.GetEvents()
+
+	err := NewWebhookOutbox(repo, l).Publish(events)
+
+	assert.Nil(t, err)
+	assert.Len(t, repo.deliveries, 1)
+
+	d := repo.deliveries[0]
+
+	assert.Equal(t, "everything", d.SubscriptionId)
+	assert.Equal(t, EventTypes.VersionFailed, d.EventType)
+	assert.Equal(t, DeliveryStatuses.Pending, d.Status)
+	assert.Equal(t, events[0].Id, d.EventId)
+
+	payload := Event{}
+	assert.Nil(t, json.Unmarshal([]byte(d.Payload), &payload))
+	assert.Equal(t, "platform", payload.Module.Namespace)
+	assert.Equal(t, "1.0.0", payload.ModuleVersion.Version)
+}
+
+func Test_Auditor_Record_PublishesEvents(t *testing.T) {
+	l := ymirstubs.BuildZerologLogger(new(bytes.Buffer))
+	logs := &fakeAuditLogsRepo{}
+	publisher := &fakeEventPublisher{}
+
+	a := NewAuditor(logs, l, WithEventPublisher(publisher))
+
+	a.Record(DeleteModuleV1Response{
+		Status: STATUS_OKAY,
+		Module: outboxTestModule,
+		DeletedVersions: []ModuleVersion{
+			{Id: "mv-1", Version: "1.0.0"},
+			{Id: "mv-2", Version: "1.1.0"},
+		},
+	})
+	a.Record(ListModulesV1Response{Status: STATUS_OKAY})
+
+	assert.Equal(t, []string{"v1.modules.delete", "v1.modules.list"}, logs.saved)
+	assert.Len(t, publisher.published, 2)
+
+	for _, e := range publisher.published {
+		assert.Equal(t, EventTypes.VersionDeleted, e.Type)
+		assert.Equal(t, outboxTestModule.FQN(), e.Module)
+	}
+}
+
+func Test_GetEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   EventfulAction
+		expected []EventType
+	}{
+		{
+			name:     "built version is ready",
+			action:   BuildModuleVersionV1Response{Status: STATUS_OKAY, ModuleVersion: ModuleVersion{Status: VersionStatuses.Ready}},
+			expected: []EventType{EventTypes.VersionReady},
+		},
+		{
+			name:     "build failed",
+			action:   BuildModuleVersionV1Response{Status: STATUS_FAILED, ModuleVersion: ModuleVersion{Status: VersionStatuses.Failed}},
+			expected: []EventType{EventTypes.VersionFailed},
+		},
+		{
+			name:     "version deleted",
+			action:   DeleteModuleVersionV1Response{Status: STATUS_OKAY},
+			expected: []EventType{EventTypes.VersionDeleted},
+		},
+		{
+			name:     "version not deleted",
+			action:   DeleteModuleVersionV1Response{Status: STATUS_NOT_FOUND},
+			expected: []EventType{},
+		},
+		{
+			name:     "module deleted without versions",
+			action:   DeleteModuleV1Response{Status: STATUS_OKAY},
+			expected: []EventType{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			types := []EventType{}
+
+			for _, e := range test.action.GetEvents() {
+				types = append(types, e.Type)
+			}
+
+			assert.Equal(tt, test.expected, types)
+		})
+	}
+}
+
+func Test_WebhookSubscription_Matches(t *testing.T) {
+	ready := Event{Type: EventTypes.VersionReady, Module: ModuleFQN{Namespace: "platform"}}
+
+	assert.True(t, WebhookSubscription{}.Matches(ready))
+	assert.True(t, WebhookSubscription{EventTypes: []EventType{EventTypes.VersionReady}, Namespaces: []string{"data", "platform"}}.Matches(ready))
+	assert.False(t, WebhookSubscription{EventTypes: []EventType{EventTypes.VersionDeleted}}.Matches(ready))
+	assert.False(t, WebhookSubscription{Namespaces: []string{"data"}}.Matches(ready))
+}
+
+func Test_WebhookSubscription_Sign(t *testing.T) {
+	s := WebhookSubscription{Secret: "It's a Secret to Everybody"}
+
+	// The example from GitHub's webhook docs, which subscribers can reuse
+	assert.Equal(t, "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", s.Sign([]byte("Hello, World!")))
+}
@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WebhookSubscription sends the events matching its filters to a URL. An
+// empty filter matches everything.
+type WebhookSubscription struct {
+	Id         string      `json:"id"`
+	URL        string      `json:"url"`
+	Secret     string      `json:"secret,omitempty"`
+	EventTypes []EventType `json:"event_types"`
+	Namespaces []string    `json:"namespaces"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+func (s WebhookSubscription) Matches(e Event) bool {
+	return s.matchesEventType(e.Type) && s.matchesNamespace(e.Module.Namespace)
+}
+
+func (s WebhookSubscription) matchesEventType(t EventType) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+
+	for _, st := range s.EventTypes {
+		if st == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s WebhookSubscription) matchesNamespace(ns string) bool {
+	if len(s.Namespaces) == 0 {
+		return true
+	}
+
+	for _, sns := range s.Namespaces {
+		if sns == ns {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redacted hides the secret, it is only ever shown when the subscription is
+// created.
+func (s WebhookSubscription) Redacted() WebhookSubscription {
+	s.Secret = ""
+
+	return s
+}
+
+// Sign produces the value of the signature header for a payload, subscribers
+// verify it with the secret they were given.
+func (s WebhookSubscription) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type DeliveryStatus string
+
+type deliveryStatusesContainer struct {
+	Pending   DeliveryStatus
+	Delivered DeliveryStatus
+	Failed    DeliveryStatus
+}
+
+var DeliveryStatuses deliveryStatusesContainer = deliveryStatusesContainer{
+	Pending:   "pending",
+	Delivered: "delivered",
+	Failed:    "failed",
+}
+
+// WebhookDelivery is an event waiting to be (or having been) sent to a
+// subscription. The pending deliveries are the outbox.
+type WebhookDelivery struct {
+	Id             string                   `json:"id"`
+	SubscriptionId string                   `json:"subscription_id"`
+	EventId        string                   `json:"event_id"`
+	EventType      EventType                `json:"event_type"`
+	Payload        string                   `json:"payload"`
+	Status         DeliveryStatus           `json:"status"`
+	Attempts       int                      `json:"attempts"`
+	NextAttemptAt  time.Time                `json:"next_attempt_at"`
+	CreatedAt      time.Time                `json:"created_at"`
+	AttemptLog     []WebhookDeliveryAttempt `json:"attempt_log"`
+}
+
+// WebhookDeliveryAttempt records the outcome of sending a delivery once.
+type WebhookDeliveryAttempt struct {
+	Id           string    `json:"id"`
+	DeliveryId   string    `json:"delivery_id"`
+	AttemptedAt  time.Time `json:"attempted_at"`
+	ResponseCode int       `json:"response_code"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+}
+
+func (a WebhookDeliveryAttempt) Succeeded() bool {
+	return a.Error == "" && a.ResponseCode >= 200 && a.ResponseCode < 300
+}
+
+func BuildWebhookSubscriptionsTable(subs []WebhookSubscription) (h []string, r [][]string) {
+	h = []string{"ID", "URL", "Event Types", "Namespaces", "Created At"}
+
+	for _, s := range subs {
+		types := []string{}
+
+		for _, t := range s.EventTypes {
+			types = append(types, string(t))
+		}
+
+		r = append(r, []string{
+			s.Id,
+			s.URL,
+			strings.Join(types, ", "),
+			strings.Join(s.Namespaces, ", "),
+			s.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return
+}
+
+func BuildWebhookDeliveriesTable(deliveries []WebhookDelivery) (h []string, r [][]string) {
+	h = []string{"ID", "Event Type", "Status", "Attempts", "Created At", "Last Response"}
+
+	for _, d := range deliveries {
+		last := ""
+
+		if n := len(d.AttemptLog); n > 0 {
+			a := d.AttemptLog[n-1]
+			last = fmt.Sprintf("%d %s", a.ResponseCode, a.Error)
+		}
+
+		r = append(r, []string{
+			d.Id,
+			string(d.EventType),
+			string(d.Status),
+			fmt.Sprint(d.Attempts),
+			d.CreatedAt.Format(time.RFC3339),
+			strings.TrimSpace(last),
+		})
+	}
+
+	return
+}
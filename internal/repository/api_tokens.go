@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+)
+
+func BuildAPITokensForPostgres(conn *sqlx.DB, logger zerolog.Logger) *PostgresAPITokens {
+	return &PostgresAPITokens{
+		db:     conn,
+		logger: logger,
+	}
+}
+
+func BuildUsersForPostgres(conn *sqlx.DB, logger zerolog.Logger) *PostgresUsers {
+	return &PostgresUsers{
+		db:     conn,
+		logger: logger,
+	}
+}
+
+func BuildNamespacesForPostgres(conn *sqlx.DB, logger zerolog.Logger) *PostgresNamespaces {
+	return &PostgresNamespaces{
+		db:     conn,
+		logger: logger,
+	}
+}
+
+func BuildTeamsForPostgres(conn *sqlx.DB, logger zerolog.Logger) *PostgresTeams {
+	return &PostgresTeams{
+		db:     conn,
+		logger: logger,
+	}
+}
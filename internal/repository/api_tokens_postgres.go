@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type postgresDbAPIToken struct {
+	Id         string         `db:"id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	TokenHash  string         `db:"token_hash"`
+	Scopes     string         `db:"scopes"` //it's JSONB
+	UserId     sql.NullString `db:"user_id"`
+	ExpiresAt  sql.NullTime   `db:"expires_at"`
+	LastUsedAt sql.NullTime   `db:"last_used_at"`
+	RevokedAt  sql.NullTime   `db:"revoked_at"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+func nullTimeToPointer(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	utc := t.Time.UTC()
+
+	return &utc
+}
+
+func pointerToNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func (pT *postgresDbAPIToken) ToDomainModel() registry.APIToken {
+	scopes := []registry.TokenScope{}
+	// nolint: errcheck
+	json.Unmarshal([]byte(pT.Scopes), &scopes)
+
+	return registry.APIToken{
+		Id:         pT.Id,
+		Name:       pT.Name,
+		Prefix:     pT.Prefix,
+		Hash:       pT.TokenHash,
+		Scopes:     scopes,
+		UserId:     pT.UserId.String,
+		ExpiresAt:  nullTimeToPointer(pT.ExpiresAt),
+		LastUsedAt: nullTimeToPointer(pT.LastUsedAt),
+		RevokedAt:  nullTimeToPointer(pT.RevokedAt),
+		CreatedAt:  pT.CreatedAt,
+	}
+}
+
+func (pT *postgresDbAPIToken) Populate(t registry.APIToken) {
+	scopes, _ := json.Marshal(t.Scopes)
+
+	pT.Id = t.Id
+	pT.Name = t.Name
+	pT.Prefix = t.Prefix
+	pT.TokenHash = t.Hash
+	pT.Scopes = string(scopes)
+	pT.UserId = sql.NullString{String: t.UserId, Valid: t.UserId != ""}
+	pT.ExpiresAt = pointerToNullTime(t.ExpiresAt)
+	pT.LastUsedAt = pointerToNullTime(t.LastUsedAt)
+	pT.RevokedAt = pointerToNullTime(t.RevokedAt)
+	pT.CreatedAt = t.CreatedAt
+}
+
+type PostgresAPITokens struct {
+	db     *sqlx.DB
+	logger zerolog.Logger
+}
+
+func (s *PostgresAPITokens) startTransaction() (*sqlx.Tx, error) {
+	tx, err := s.db.Beginx()
+
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, ErrDbTransaction{
+			Wrapped: err,
+		}
+	}
+
+	return tx, nil
+}
+
+func (s *PostgresAPITokens) commit(tx *sqlx.Tx) error {
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return wrapTransactionError(rollbackErr)
+		}
+
+		return wrapTransactionError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresAPITokens) tokenBy(column string, value string) (t registry.APIToken, err error) {
+	dbToken := &postgresDbAPIToken{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	%s = $1;`,
+		APITokensTableName, column)
+
+	err = s.db.Get(dbToken, q, value)
+
+	if err == sql.ErrNoRows {
+		return t, registry.ErrResourceNotFound{
+			Type: "APIToken",
+			URI:  value,
+		}
+	} else if err != nil {
+		return t, wrapQueryError(err)
+	}
+
+	return dbToken.ToDomainModel(), nil
+}
+
+func (s *PostgresAPITokens) TokenById(id string) (t registry.APIToken, err error) {
+	return s.tokenBy("id", id)
+}
+
+func (s *PostgresAPITokens) TokenByHash(hash string) (t registry.APIToken, err error) {
+	t, err = s.tokenBy("token_hash", hash)
+
+	if nf, ok := err.(registry.ErrResourceNotFound); ok {
+		// Never put the hash in an error that may be logged
+		nf.URI = "<redacted>"
+		return t, nf
+	}
+
+	return t, err
+}
+
+func (s *PostgresAPITokens) AllTokens() (tokens []registry.APIToken, err error) {
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+ORDER BY created_at ASC;`, APITokensTableName)
+
+	rows, err := s.db.Queryx(q)
+
+	if err != nil {
+		return tokens, wrapQueryError(err)
+	}
+
+	tokens = []registry.APIToken{}
+
+	for rows.Next() {
+		dbToken := &postgresDbAPIToken{}
+
+		if err := rows.StructScan(dbToken); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.APIToken{}, wrapHydrationError("APIToken", err)
+		}
+
+		tokens = append(tokens, dbToken.ToDomainModel())
+	}
+
+	return tokens, nil
+}
+
+func (s *PostgresAPITokens) AddToken(new registry.APIToken) (t registry.APIToken, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return t, err
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, name, prefix, token_hash, scopes, user_id, expires_at, last_used_at, revoked_at, created_at)
+VALUES (:id, :name, :prefix, :token_hash, :scopes, :user_id, :expires_at, :last_used_at, :revoked_at, :created_at);`,
+		APITokensTableName)
+
+	dbToken := &postgresDbAPIToken{}
+	dbToken.Populate(new)
+
+	if _, err := tx.NamedExec(insert, dbToken); err != nil {
+		return t, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return t, err
+	}
+
+	return s.TokenById(new.Id)
+}
+
+func (s *PostgresAPITokens) RevokeToken(t registry.APIToken, at time.Time) (registry.APIToken, error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return t, err
+	}
+
+	update := fmt.Sprintf(`
+UPDATE %s SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL;`,
+		APITokensTableName)
+
+	if _, err := tx.Exec(update, at, t.Id); err != nil {
+		return t, wrapTransactionError(err)
+	}
+
+	grants := fmt.Sprintf(`
+DELETE FROM %s WHERE subject_type = $1 AND subject_id = $2;`,
+		RoleGrantsTableName)
+
+	if _, err := tx.Exec(grants, string(registry.SubjectTypes.Token), t.Id); err != nil {
+		return t, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return t, err
+	}
+
+	return s.TokenById(t.Id)
+}
+
+func (s *PostgresAPITokens) TouchToken(t registry.APIToken, at time.Time) error {
+	update := fmt.Sprintf(`
+UPDATE %s SET last_used_at = $1 WHERE id = $2;`,
+		APITokensTableName)
+
+	if _, err := s.db.Exec(update, at, t.Id); err != nil {
+		return wrapQueryError(err)
+	}
+
+	return nil
+}
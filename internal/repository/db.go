@@ -3,6 +3,25 @@ package repository
 const ModulesTableName = "modules"
 const AuditLogsTableName = "audit_logs"
 const ModuleVersionsTableName = "module_versions"
+const DeletedModuleVersionsTableName = "deleted_module_versions"
+const WebhookSubscriptionsTableName = "webhook_subscriptions"
+const WebhookDeliveriesTableName = "webhook_deliveries"
+const WebhookDeliveryAttemptsTableName = "webhook_delivery_attempts"
+const ProvidersTableName = "providers"
+const ProviderVersionsTableName = "provider_versions"
+const ProviderPlatformsTableName = "provider_platforms"
+const GPGKeysTableName = "gpg_keys"
+const MirroredProviderPackagesTableName = "mirrored_provider_packages"
+const UpstreamModulesTableName = "upstream_modules"
+const UpstreamModuleVersionsTableName = "upstream_module_versions"
+const ModuleVersionInterfacesTableName = "module_version_interfaces"
+const APITokensTableName = "api_tokens"
+const UsersTableName = "users"
+const AuthorizationCodesTableName = "authorization_codes"
+const NamespacesTableName = "namespaces"
+const RoleGrantsTableName = "role_grants"
+const TeamsTableName = "teams"
+const TeamMembersTableName = "team_members"
 
 type DbDriver string
 
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type postgresDbModuleVersionInterface struct {
+	ModuleVersionId string    `db:"module_version_id"`
+	Interface       string    `db:"interface"` //it's JSONB
+	ParsedAt        time.Time `db:"parsed_at"`
+}
+
+func (pI *postgresDbModuleVersionInterface) ToDomainModel() registry.ModuleVersionInterface {
+	iface := registry.ModuleInterface{}
+	// nolint: errcheck
+	json.Unmarshal([]byte(pI.Interface), &iface)
+
+	return registry.ModuleVersionInterface{
+		ModuleVersionId: pI.ModuleVersionId,
+		Interface:       iface,
+		ParsedAt:        pI.ParsedAt,
+	}
+}
+
+func (pI *postgresDbModuleVersionInterface) Populate(i registry.ModuleVersionInterface) {
+	iface, _ := json.Marshal(i.Interface)
+
+	pI.ModuleVersionId = i.ModuleVersionId
+	pI.Interface = string(iface)
+	pI.ParsedAt = i.ParsedAt
+}
+
+func (s *PostgresModules) VersionInterface(ctx context.Context, moduleVersionId string) (i registry.ModuleVersionInterface, err error) {
+	dbInterface := &postgresDbModuleVersionInterface{}
+	q := fmt.Sprintf(`
+SELECT
+	*
+FROM
+	%s
+WHERE
+	module_version_id = $1;`,
+		ModuleVersionInterfacesTableName)
+
+	err = s.db.GetContext(ctx, dbInterface, q, moduleVersionId)
+
+	if err == sql.ErrNoRows {
+		return i, registry.ErrResourceNotFound{
+			Type: "ModuleVersionInterface",
+			URI:  moduleVersionId,
+		}
+	} else if err != nil {
+		return i, wrapQueryError(err)
+	}
+
+	return dbInterface.ToDomainModel(), nil
+}
+
+// SaveVersionInterface replaces any interface already stored for the version,
+// as rebuilding a version parses it again.
+func (s *PostgresModules) SaveVersionInterface(ctx context.Context, i registry.ModuleVersionInterface) error {
+	tx, err := s.startTransaction(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	upsert := fmt.Sprintf(`
+INSERT INTO %s (module_version_id, interface, parsed_at)
+VALUES (:module_version_id, :interface, :parsed_at)
+ON CONFLICT (module_version_id)
+DO UPDATE SET interface = EXCLUDED.interface, parsed_at = EXCLUDED.parsed_at;`,
+		ModuleVersionInterfacesTableName)
+
+	dbInterface := &postgresDbModuleVersionInterface{}
+	dbInterface.Populate(i)
+
+	if _, err := tx.NamedExecContext(ctx, upsert, dbInterface); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	return nil
+}
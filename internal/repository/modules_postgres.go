@@ -1,29 +1,43 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog"
 	"github.com/svartlfheim/ymir/internal/registry"
 )
 
 type postgresDbModule struct {
-	Id        string `db:"id"`
-	Name      string `db:"name"`
-	Namespace string `db:"namespace"`
-	Provider  string `db:"provider"`
+	Id              string `db:"id"`
+	Name            string `db:"name"`
+	Namespace       string `db:"namespace"`
+	Provider        string `db:"provider"`
+	RepositoryUrl   string `db:"repository_url"`
+	Path            string `db:"path"`
+	DefaultBranch   string `db:"default_branch"`
+	TagPattern      string `db:"tag_pattern"`
+	BreakingChanges string `db:"breaking_changes"`
 }
 
 func (pM *postgresDbModule) ToDomainModel() registry.Module {
 	return registry.Module{
-		Id:        pM.Id,
-		Name:      pM.Name,
-		Namespace: pM.Namespace,
-		Provider:  pM.Provider,
+		Id:              pM.Id,
+		Name:            pM.Name,
+		Namespace:       pM.Namespace,
+		Provider:        pM.Provider,
+		RepositoryURL:   pM.RepositoryUrl,
+		Path:            pM.Path,
+		DefaultBranch:   pM.DefaultBranch,
+		TagPattern:      pM.TagPattern,
+		BreakingChanges: registry.BreakingChangePolicy(pM.BreakingChanges),
 	}
 }
 
@@ -32,6 +46,15 @@ func (pM *postgresDbModule) Populate(m registry.Module) {
 	pM.Name = m.Name
 	pM.Namespace = m.Namespace
 	pM.Provider = m.Provider
+	pM.RepositoryUrl = m.RepositoryURL
+	pM.Path = m.Path
+	pM.DefaultBranch = m.DefaultBranch
+	pM.TagPattern = m.TagPattern
+	pM.BreakingChanges = string(m.BreakingChanges)
+}
+
+type postgresDbModuleVersionMeta struct {
+	StatusReason string `json:"status_reason,omitempty"`
 }
 
 type postgresDbModuleVersion struct {
@@ -42,7 +65,11 @@ type postgresDbModuleVersion struct {
 	ArchiveId     sql.NullString `db:"archive_id"`
 	RepositoryUrl string         `db:"repository_url"`
 	Status        string         `db:"status"`
-	EventsJSON    string         `db:"meta"`
+	// Orders versions by semver precedence, see registry.VersionSortKey
+	SortKey    string    `db:"sort_key"`
+	EventsJSON string    `db:"meta"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
 }
 
 func (pMV *postgresDbModuleVersion) ToDomainModel() registry.ModuleVersion {
@@ -51,6 +78,11 @@ func (pMV *postgresDbModuleVersion) ToDomainModel() registry.ModuleVersion {
 	if pMV.ArchiveId.Valid {
 		archiveId = pMV.ArchiveId.String
 	}
+
+	meta := postgresDbModuleVersionMeta{}
+	// nolint: errcheck
+	json.Unmarshal([]byte(pMV.EventsJSON), &meta)
+
 	return registry.ModuleVersion{
 		Id:            pMV.Id,
 		ModuleId:      pMV.ModuleId,
@@ -59,6 +91,8 @@ func (pMV *postgresDbModuleVersion) ToDomainModel() registry.ModuleVersion {
 		DownloadURL:   archiveId,
 		RepositoryURL: pMV.RepositoryUrl,
 		Status:        registry.VersionStatus(pMV.Status),
+		StatusReason:  meta.StatusReason,
+		CreatedAt:     pMV.CreatedAt,
 	}
 }
 
@@ -68,12 +102,18 @@ func (pMV *postgresDbModuleVersion) Populate(mv registry.ModuleVersion) {
 	pMV.RepositoryUrl = mv.RepositoryURL
 	pMV.SourceRef = mv.Source
 	pMV.Version = mv.Version
+	pMV.SortKey = registry.VersionSortKey(mv.Version)
 	pMV.Status = string(mv.Status)
 
 	// maybe this is right?
 	if mv.DownloadURL != "" {
 		pMV.ArchiveId = sql.NullString{String: mv.DownloadURL, Valid: true}
 	}
+
+	meta, _ := json.Marshal(postgresDbModuleVersionMeta{
+		StatusReason: mv.StatusReason,
+	})
+	pMV.EventsJSON = string(meta)
 }
 
 type PostgresModules struct {
@@ -81,8 +121,8 @@ type PostgresModules struct {
 	logger zerolog.Logger
 }
 
-func (s *PostgresModules) startTransaction() (*sqlx.Tx, error) {
-	tx, err := s.db.Beginx()
+func (s *PostgresModules) startTransaction(ctx context.Context) (*sqlx.Tx, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
 
 	if err != nil {
 		s.logger.Error().Err(err).Msg("failed to begin transaction")
@@ -94,7 +134,7 @@ func (s *PostgresModules) startTransaction() (*sqlx.Tx, error) {
 	return tx, nil
 }
 
-func (s *PostgresModules) ById(id string) (m registry.Module, err error) {
+func (s *PostgresModules) ById(ctx context.Context, id string) (m registry.Module, err error) {
 	dbModule := &postgresDbModule{}
 	q := fmt.Sprintf(`SELECT
 	*
@@ -104,7 +144,7 @@ WHERE
 	id = $1;`,
 		ModulesTableName)
 
-	err = s.db.Get(dbModule, q, id)
+	err = s.db.GetContext(ctx, dbModule, q, id)
 
 	if err == sql.ErrNoRows {
 		return m, registry.ErrResourceNotFound{
@@ -118,7 +158,7 @@ WHERE
 	return dbModule.ToDomainModel(), nil
 }
 
-func (s *PostgresModules) ByFQN(fqn registry.ModuleFQN) (m registry.Module, err error) {
+func (s *PostgresModules) ByFQN(ctx context.Context, fqn registry.ModuleFQN) (m registry.Module, err error) {
 	dbModule := &postgresDbModule{}
 	q := fmt.Sprintf(`SELECT
 	*
@@ -130,7 +170,7 @@ WHERE
 	name = $3;`,
 		ModulesTableName)
 
-	err = s.db.Get(dbModule, q, fqn.Provider, fqn.Namespace, fqn.Name)
+	err = s.db.GetContext(ctx, dbModule, q, fqn.Provider, fqn.Namespace, fqn.Name)
 
 	if err == sql.ErrNoRows {
 		return m, registry.ErrResourceNotFound{
@@ -144,44 +184,83 @@ WHERE
 	return dbModule.ToDomainModel(), nil
 }
 
+// likePattern matches the value anywhere in a column, with any wildcards in
+// the value itself escaped.
+func likePattern(v string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(v) + "%"
+}
+
 func (s *PostgresModules) buildModulesFilterClause(f registry.ModuleFilters) (clause string, params map[string]interface{}) {
-	if f.Namespace == "" && f.Provider == "" {
+	params = map[string]interface{}{}
+
+	if f.Namespace == "" && f.Provider == "" && f.Query == "" && !f.Published && f.Namespaces == nil {
 		return
 	}
 
 	clauseParts := []string{}
-	params = map[string]interface{}{}
 
 	if f.Namespace != "" {
-		clauseParts = append(clauseParts, " namespace = :namespace")
+		clauseParts = append(clauseParts, " m.namespace = :namespace")
 		params["namespace"] = f.Namespace
 	}
 
 	if f.Provider != "" {
-		clauseParts = append(clauseParts, " provider = :provider")
+		clauseParts = append(clauseParts, " m.provider = :provider")
 		params["provider"] = f.Provider
 	}
 
+	if f.Query != "" {
+		clauseParts = append(clauseParts, " (m.namespace ILIKE :query OR m.name ILIKE :query OR m.provider ILIKE :query)")
+		params["query"] = likePattern(f.Query)
+	}
+
+	if f.Namespaces != nil {
+		clauseParts = append(clauseParts, " m.namespace = ANY(:namespaces)")
+		params["namespaces"] = pq.Array(f.Namespaces)
+	}
+
+	if f.Published {
+		clauseParts = append(clauseParts, fmt.Sprintf(" EXISTS (SELECT 1 FROM %s mv WHERE mv.module_id = m.id AND mv.status = :published_status)", ModuleVersionsTableName))
+		params["published_status"] = string(registry.VersionStatuses.Ready)
+	}
+
 	clause = "WHERE " + strings.Join(clauseParts, " AND ")
 
 	return
 }
 
-func (s *PostgresModules) All(_ registry.ChunkingOptions, f registry.ModuleFilters) (ms []registry.Module, err error) {
+func chunkClause(chunkOpts registry.ChunkingOptions) string {
+	clause := ""
+
+	if chunkOpts.Size > 0 {
+		clause = fmt.Sprintf("LIMIT %d", chunkOpts.Size)
+	}
+
+	if chunkOpts.Offset > 0 {
+		clause += fmt.Sprintf(" OFFSET %d", chunkOpts.Offset)
+	}
+
+	return clause
+}
+
+func (s *PostgresModules) All(ctx context.Context, chunkOpts registry.ChunkingOptions, f registry.ModuleFilters) (ms []registry.Module, err error) {
 	where, params := s.buildModulesFilterClause(f)
 	q := fmt.Sprintf(`SELECT
 	*
 FROM 
 	%s m
 %s
-ORDER BY m.provider ASC, m.namespace ASC, m.name ASC;`, ModulesTableName, where)
+ORDER BY m.provider ASC, m.namespace ASC, m.name ASC
+%s;`, ModulesTableName, where, chunkClause(chunkOpts))
 
-	rows, err := s.db.NamedQuery(q, params)
+	rows, err := s.db.NamedQueryContext(ctx, q, params)
 
 	if err != nil {
 		return ms, wrapQueryError(err)
 	}
 
+	defer rows.Close()
+
 	ms = []registry.Module{}
 
 	for rows.Next() {
@@ -198,10 +277,14 @@ ORDER BY m.provider ASC, m.namespace ASC, m.name ASC;`, ModulesTableName, where)
 		ms = append(ms, dbM.ToDomainModel())
 	}
 
+	if err := rows.Err(); err != nil {
+		return []registry.Module{}, wrapQueryError(err)
+	}
+
 	return ms, nil
 }
 
-func (s *PostgresModules) VersionById(id string) (mv registry.ModuleVersion, err error) {
+func (s *PostgresModules) VersionById(ctx context.Context, id string) (mv registry.ModuleVersion, err error) {
 	dbModuleVersion := &postgresDbModuleVersion{}
 	q := fmt.Sprintf(`
 SELECT
@@ -212,7 +295,7 @@ WHERE
 	id = $1;`,
 		ModuleVersionsTableName)
 
-	err = s.db.Get(dbModuleVersion, q, id)
+	err = s.db.GetContext(ctx, dbModuleVersion, q, id)
 
 	if err == sql.ErrNoRows {
 		return mv, registry.ErrResourceNotFound{
@@ -226,22 +309,31 @@ WHERE
 	return dbModuleVersion.ToDomainModel(), nil
 }
 
-func (s *PostgresModules) VersionsByModule(moduleId string, _ registry.ChunkingOptions) (mVs []registry.ModuleVersion, err error) {
+// versionsOrderClause lists versions by semver precedence, lowest first. The
+// sort keys are compared byte by byte, whatever the database's collation.
+const versionsOrderClause = `ORDER BY
+	sort_key COLLATE "C",
+	version COLLATE "C"`
+
+func (s *PostgresModules) VersionsByModule(ctx context.Context, moduleId string, _ registry.ChunkingOptions) (mVs []registry.ModuleVersion, err error) {
 	q := fmt.Sprintf(`
 SELECT
 	*
 FROM 
 	%s
 WHERE
-	module_id = $1;`,
-		ModuleVersionsTableName)
+	module_id = $1
+%s;`,
+		ModuleVersionsTableName, versionsOrderClause)
 
-	rows, err := s.db.Queryx(q, moduleId)
+	rows, err := s.db.QueryxContext(ctx, q, moduleId)
 
 	if err != nil {
 		return mVs, wrapQueryError(err)
 	}
 
+	defer rows.Close()
+
 	mVs = []registry.ModuleVersion{}
 
 	for rows.Next() {
@@ -258,10 +350,14 @@ WHERE
 		mVs = append(mVs, dbM.ToDomainModel())
 	}
 
+	if err := rows.Err(); err != nil {
+		return []registry.ModuleVersion{}, wrapQueryError(err)
+	}
+
 	return mVs, nil
 }
 
-func (s *PostgresModules) VersionsByModuleFQN(fqn registry.ModuleFQN, _ registry.ChunkingOptions) (mVs []registry.ModuleVersion, err error) {
+func (s *PostgresModules) VersionsByModuleFQN(ctx context.Context, fqn registry.ModuleFQN, _ registry.ChunkingOptions) (mVs []registry.ModuleVersion, err error) {
 	q := fmt.Sprintf(`
 SELECT
 	*
@@ -277,15 +373,18 @@ WHERE
 			provider = $1 AND
 			namespace = $2 AND
 			name = $3
-		);`,
-		ModuleVersionsTableName, ModulesTableName)
+		)
+%s;`,
+		ModuleVersionsTableName, ModulesTableName, versionsOrderClause)
 
-	rows, err := s.db.Queryx(q, fqn.Provider, fqn.Namespace, fqn.Name)
+	rows, err := s.db.QueryxContext(ctx, q, fqn.Provider, fqn.Namespace, fqn.Name)
 
 	if err != nil {
 		return mVs, wrapQueryError(err)
 	}
 
+	defer rows.Close()
+
 	mVs = []registry.ModuleVersion{}
 
 	for rows.Next() {
@@ -302,10 +401,14 @@ WHERE
 		mVs = append(mVs, dbM.ToDomainModel())
 	}
 
+	if err := rows.Err(); err != nil {
+		return []registry.ModuleVersion{}, wrapQueryError(err)
+	}
+
 	return mVs, nil
 }
 
-func (s *PostgresModules) VersionByModuleAndValue(moduleId string, version string) (mv registry.ModuleVersion, err error) {
+func (s *PostgresModules) VersionByModuleAndValue(ctx context.Context, moduleId string, version string) (mv registry.ModuleVersion, err error) {
 	dbModuleVersion := &postgresDbModuleVersion{}
 	q := fmt.Sprintf(`
 SELECT
@@ -317,7 +420,7 @@ WHERE
 	version = $2;
 `, ModuleVersionsTableName)
 
-	err = s.db.Get(dbModuleVersion, q, moduleId, version)
+	err = s.db.GetContext(ctx, dbModuleVersion, q, moduleId, version)
 
 	if err == sql.ErrNoRows {
 		return mv, registry.ErrResourceNotFound{
@@ -331,7 +434,7 @@ WHERE
 	return dbModuleVersion.ToDomainModel(), nil
 }
 
-func (s *PostgresModules) VersionByFQN(fqn registry.ModuleVersionFQN) (mv registry.ModuleVersion, err error) {
+func (s *PostgresModules) VersionByFQN(ctx context.Context, fqn registry.ModuleVersionFQN) (mv registry.ModuleVersion, err error) {
 	dbModuleVersion := &postgresDbModuleVersion{}
 	q := fmt.Sprintf(`
 SELECT
@@ -352,7 +455,7 @@ WHERE
 	);
 `, ModuleVersionsTableName, ModulesTableName)
 
-	err = s.db.Get(dbModuleVersion, q, fqn.Version, fqn.ModuleFQN.Name, fqn.ModuleFQN.Namespace, fqn.ModuleFQN.Provider)
+	err = s.db.GetContext(ctx, dbModuleVersion, q, fqn.Version, fqn.ModuleFQN.Name, fqn.ModuleFQN.Namespace, fqn.ModuleFQN.Provider)
 
 	if err == sql.ErrNoRows {
 		return mv, registry.ErrResourceNotFound{
@@ -367,8 +470,8 @@ WHERE
 	return dbModuleVersion.ToDomainModel(), nil
 }
 
-func (s *PostgresModules) AddModule(mod registry.Module) (m registry.Module, err error) {
-	tx, err := s.startTransaction()
+func (s *PostgresModules) AddModule(ctx context.Context, mod registry.Module) (m registry.Module, err error) {
+	tx, err := s.startTransaction(ctx)
 
 	if err != nil {
 		return m, err
@@ -378,10 +481,30 @@ func (s *PostgresModules) AddModule(mod registry.Module) (m registry.Module, err
 	dbModule.Populate(mod)
 
 	insert := fmt.Sprintf(`
-INSERT INTO %s (id, name, namespace, provider) VALUES (:id, :name, :namespace, :provider);`,
+INSERT INTO %s (
+	id,
+	name,
+	namespace,
+	provider,
+	repository_url,
+	path,
+	default_branch,
+	tag_pattern,
+	breaking_changes
+) VALUES (
+	:id,
+	:name,
+	:namespace,
+	:provider,
+	:repository_url,
+	:path,
+	:default_branch,
+	:tag_pattern,
+	:breaking_changes
+);`,
 		ModulesTableName)
 
-	_, err = tx.NamedExec(insert, dbModule)
+	_, err = tx.NamedExecContext(ctx, insert, dbModule)
 
 	if err != nil {
 		return m, wrapTransactionError(err)
@@ -397,7 +520,7 @@ INSERT INTO %s (id, name, namespace, provider) VALUES (:id, :name, :namespace, :
 		return m, wrapTransactionError(err)
 	}
 
-	m, err = s.ById(mod.Id)
+	m, err = s.ById(ctx, mod.Id)
 
 	if err != nil {
 		if _, ok := err.(registry.ErrResourceNotFound); !ok {
@@ -410,8 +533,48 @@ INSERT INTO %s (id, name, namespace, provider) VALUES (:id, :name, :namespace, :
 	return m, nil
 }
 
-func (s *PostgresModules) DeleteModule(mod registry.Module) (err error) {
-	tx, err := s.startTransaction()
+func (s *PostgresModules) UpdateModule(ctx context.Context, mod registry.Module) (m registry.Module, err error) {
+	tx, err := s.startTransaction(ctx)
+
+	if err != nil {
+		return m, err
+	}
+
+	dbModule := &postgresDbModule{}
+	dbModule.Populate(mod)
+
+	update := fmt.Sprintf(`
+UPDATE %s SET
+	repository_url = :repository_url,
+	path = :path,
+	default_branch = :default_branch,
+	tag_pattern = :tag_pattern,
+	breaking_changes = :breaking_changes
+WHERE
+	id = :id;`,
+		ModulesTableName)
+
+	_, err = tx.NamedExecContext(ctx, update, dbModule)
+
+	if err != nil {
+		return m, wrapTransactionError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return m, wrapTransactionError(rollbackErr)
+		}
+
+		return m, wrapTransactionError(err)
+	}
+
+	return s.ById(ctx, mod.Id)
+}
+
+func (s *PostgresModules) DeleteModule(ctx context.Context, mod registry.Module) (err error) {
+	tx, err := s.startTransaction(ctx)
 
 	if err != nil {
 		return err
@@ -421,7 +584,7 @@ func (s *PostgresModules) DeleteModule(mod registry.Module) (err error) {
 DELETE FROM %s WHERE id = $1`,
 		ModulesTableName)
 
-	_, err = tx.Exec(delete, mod.Id)
+	_, err = tx.ExecContext(ctx, delete, mod.Id)
 
 	if err != nil {
 		return wrapTransactionError(err)
@@ -440,8 +603,8 @@ DELETE FROM %s WHERE id = $1`,
 	return nil
 }
 
-func (s *PostgresModules) AddVersion(new registry.ModuleVersion) (v registry.ModuleVersion, err error) {
-	tx, err := s.startTransaction()
+func (s *PostgresModules) AddVersion(ctx context.Context, new registry.ModuleVersion) (v registry.ModuleVersion, err error) {
+	tx, err := s.startTransaction(ctx)
 
 	if err != nil {
 		return v, err
@@ -455,7 +618,8 @@ INSERT INTO %s (
 	archive_id,
 	repository_url,
 	status,
-	module_id
+	module_id,
+	sort_key
 ) VALUES (
 	:id, 
 	:version, 
@@ -463,7 +627,8 @@ INSERT INTO %s (
 	NULL,
 	:repository_url,
 	:status,
-	:module_id
+	:module_id,
+	:sort_key
 );`,
 		ModuleVersionsTableName)
 
@@ -472,7 +637,18 @@ INSERT INTO %s (
 	dbVModule.Status = string(registry.VersionStatuses.Pending)
 	dbVModule.ArchiveId = sql.NullString{}
 
-	_, err = tx.NamedExec(insert, dbVModule)
+	_, err = tx.NamedExecContext(ctx, insert, dbVModule)
+
+	if err != nil {
+		return v, wrapTransactionError(err)
+	}
+
+	// Adding a deleted version again means it should be synced from now on
+	undelete := fmt.Sprintf(`
+DELETE FROM %s WHERE module_id = $1 AND version = $2`,
+		DeletedModuleVersionsTableName)
+
+	_, err = tx.ExecContext(ctx, undelete, new.ModuleId, new.Version)
 
 	if err != nil {
 		return v, wrapTransactionError(err)
@@ -488,7 +664,7 @@ INSERT INTO %s (
 		return v, wrapTransactionError(err)
 	}
 
-	v, err = s.VersionById(new.Id)
+	v, err = s.VersionById(ctx, new.Id)
 
 	if err != nil {
 		if _, ok := err.(registry.ErrResourceNotFound); !ok {
@@ -501,8 +677,301 @@ INSERT INTO %s (
 	return v, nil
 }
 
-func (s *PostgresModules) DeleteVersionsForModule(mod registry.Module) (err error) {
-	tx, err := s.startTransaction()
+func (s *PostgresModules) VersionsByStatus(ctx context.Context, status registry.VersionStatus, chunkOpts registry.ChunkingOptions) (mVs []registry.ModuleVersion, err error) {
+	limit := ""
+
+	if chunkOpts.Size > 0 {
+		limit = fmt.Sprintf("LIMIT %d", chunkOpts.Size)
+	}
+
+	q := fmt.Sprintf(`
+SELECT
+	*
+FROM 
+	%s
+WHERE
+	status = $1
+ORDER BY created_at ASC
+%s;`,
+		ModuleVersionsTableName, limit)
+
+	rows, err := s.db.QueryxContext(ctx, q, string(status))
+
+	if err != nil {
+		return mVs, wrapQueryError(err)
+	}
+
+	defer rows.Close()
+
+	mVs = []registry.ModuleVersion{}
+
+	for rows.Next() {
+		dbM := &postgresDbModuleVersion{}
+		err := rows.StructScan(dbM)
+
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.ModuleVersion{}, wrapHydrationError("ModuleVersion", err)
+		}
+
+		mVs = append(mVs, dbM.ToDomainModel())
+	}
+
+	if err := rows.Err(); err != nil {
+		return []registry.ModuleVersion{}, wrapQueryError(err)
+	}
+
+	return mVs, nil
+}
+
+func (s *PostgresModules) CountVersionsByStatus(ctx context.Context, status registry.VersionStatus) (int, error) {
+	q := fmt.Sprintf(`
+SELECT
+	COUNT(*)
+FROM
+	%s
+WHERE
+	status = $1;`,
+		ModuleVersionsTableName)
+
+	count := 0
+
+	if err := s.db.GetContext(ctx, &count, q, string(status)); err != nil {
+		return 0, wrapQueryError(err)
+	}
+
+	return count, nil
+}
+
+// ClaimVersion moves a version between statuses, only if it is still in the
+// from status and source. This ensures only one worker will build a pending
+// version, and that it builds the source it was listed with.
+func (s *PostgresModules) ClaimVersion(ctx context.Context, mv registry.ModuleVersion, from registry.VersionStatus, to registry.VersionStatus) (claimed bool, err error) {
+	tx, err := s.startTransaction(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	update := fmt.Sprintf(`
+UPDATE %s SET status = $1, updated_at = now() WHERE id = $2 AND status = $3 AND source_ref = $4`,
+		ModuleVersionsTableName)
+
+	res, err := tx.ExecContext(ctx, update, string(to), mv.Id, string(from), mv.Source)
+
+	if err != nil {
+		return false, wrapTransactionError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return false, wrapTransactionError(rollbackErr)
+		}
+
+		return false, wrapTransactionError(err)
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return false, wrapQueryError(err)
+	}
+
+	return affected == 1, nil
+}
+
+// StalledVersions lists the versions that have been preparing since before
+// leasedBefore, the worker that claimed them is assumed to have died.
+func (s *PostgresModules) StalledVersions(ctx context.Context, leasedBefore time.Time, chunkOpts registry.ChunkingOptions) (mVs []registry.ModuleVersion, err error) {
+	limit := ""
+
+	if chunkOpts.Size > 0 {
+		limit = fmt.Sprintf("LIMIT %d", chunkOpts.Size)
+	}
+
+	q := fmt.Sprintf(`
+SELECT
+	*
+FROM
+	%s
+WHERE
+	status = $1
+	AND updated_at < $2
+ORDER BY updated_at ASC
+%s;`,
+		ModuleVersionsTableName, limit)
+
+	rows, err := s.db.QueryxContext(ctx, q, string(registry.VersionStatuses.Preparing), leasedBefore)
+
+	if err != nil {
+		return mVs, wrapQueryError(err)
+	}
+
+	defer rows.Close()
+
+	mVs = []registry.ModuleVersion{}
+
+	for rows.Next() {
+		dbM := &postgresDbModuleVersion{}
+		err := rows.StructScan(dbM)
+
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.ModuleVersion{}, wrapHydrationError("ModuleVersion", err)
+		}
+
+		mVs = append(mVs, dbM.ToDomainModel())
+	}
+
+	if err := rows.Err(); err != nil {
+		return []registry.ModuleVersion{}, wrapQueryError(err)
+	}
+
+	return mVs, nil
+}
+
+// ReclaimVersion renews the lease on a stalled version, only if it is still
+// stalled. This ensures only one worker will rebuild it.
+func (s *PostgresModules) ReclaimVersion(ctx context.Context, mv registry.ModuleVersion, leasedBefore time.Time) (claimed bool, err error) {
+	tx, err := s.startTransaction(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	update := fmt.Sprintf(`
+UPDATE %s SET updated_at = now() WHERE id = $1 AND status = $2 AND updated_at < $3`,
+		ModuleVersionsTableName)
+
+	res, err := tx.ExecContext(ctx, update, mv.Id, string(registry.VersionStatuses.Preparing), leasedBefore)
+
+	if err != nil {
+		return false, wrapTransactionError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return false, wrapTransactionError(rollbackErr)
+		}
+
+		return false, wrapTransactionError(err)
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return false, wrapQueryError(err)
+	}
+
+	return affected == 1, nil
+}
+
+// FinishVersion records the outcome of a build, only if the version is still
+// preparing the same source. A push may have queued it to be built again
+// while it was building, finished is false when the outcome was discarded.
+func (s *PostgresModules) FinishVersion(ctx context.Context, mv registry.ModuleVersion) (finished bool, err error) {
+	tx, err := s.startTransaction(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	update := fmt.Sprintf(`
+UPDATE %s SET
+	archive_id = $1,
+	status = $2,
+	meta = $3,
+	updated_at = now()
+WHERE
+	id = $4
+	AND status = $5
+	AND source_ref = $6;`,
+		ModuleVersionsTableName)
+
+	dbVModule := &postgresDbModuleVersion{}
+	dbVModule.Populate(mv)
+
+	res, err := tx.ExecContext(
+		ctx,
+		update,
+		dbVModule.ArchiveId,
+		dbVModule.Status,
+		dbVModule.EventsJSON,
+		dbVModule.Id,
+		string(registry.VersionStatuses.Preparing),
+		dbVModule.SourceRef,
+	)
+
+	if err != nil {
+		return false, wrapTransactionError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return false, wrapTransactionError(rollbackErr)
+		}
+
+		return false, wrapTransactionError(err)
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return false, wrapQueryError(err)
+	}
+
+	return affected == 1, nil
+}
+
+func (s *PostgresModules) UpdateVersion(ctx context.Context, mv registry.ModuleVersion) (v registry.ModuleVersion, err error) {
+	tx, err := s.startTransaction(ctx)
+
+	if err != nil {
+		return v, err
+	}
+
+	update := fmt.Sprintf(`
+UPDATE %s SET
+	source_ref = :source_ref,
+	archive_id = :archive_id,
+	repository_url = :repository_url,
+	status = :status,
+	meta = :meta,
+	updated_at = now()
+WHERE
+	id = :id;`,
+		ModuleVersionsTableName)
+
+	dbVModule := &postgresDbModuleVersion{}
+	dbVModule.Populate(mv)
+
+	_, err = tx.NamedExecContext(ctx, update, dbVModule)
+
+	if err != nil {
+		return v, wrapTransactionError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return v, wrapTransactionError(rollbackErr)
+		}
+
+		return v, wrapTransactionError(err)
+	}
+
+	return s.VersionById(ctx, mv.Id)
+}
+
+func (s *PostgresModules) DeleteVersionsForModule(ctx context.Context, mod registry.Module) (err error) {
+	tx, err := s.startTransaction(ctx)
 
 	if err != nil {
 		return err
@@ -512,7 +981,7 @@ func (s *PostgresModules) DeleteVersionsForModule(mod registry.Module) (err erro
 DELETE FROM %s WHERE module_id = $1`,
 		ModuleVersionsTableName)
 
-	_, err = tx.Exec(delete, mod.Id)
+	_, err = tx.ExecContext(ctx, delete, mod.Id)
 
 	if err != nil {
 		return wrapTransactionError(err)
@@ -531,8 +1000,8 @@ DELETE FROM %s WHERE module_id = $1`,
 	return nil
 }
 
-func (s *PostgresModules) DeleteModuleVersion(mv registry.ModuleVersion) error {
-	tx, err := s.startTransaction()
+func (s *PostgresModules) DeleteModuleVersion(ctx context.Context, mv registry.ModuleVersion) error {
+	tx, err := s.startTransaction(ctx)
 
 	if err != nil {
 		return err
@@ -542,7 +1011,19 @@ func (s *PostgresModules) DeleteModuleVersion(mv registry.ModuleVersion) error {
 DELETE FROM %s WHERE id = $1`,
 		ModuleVersionsTableName)
 
-	_, err = tx.Exec(delete, mv.Id)
+	_, err = tx.ExecContext(ctx, delete, mv.Id)
+
+	if err != nil {
+		return wrapTransactionError(err)
+	}
+
+	// Remembered so syncing the tags doesn't add the version back
+	record := fmt.Sprintf(`
+INSERT INTO %s (module_id, version) VALUES ($1, $2)
+ON CONFLICT (module_id, version) DO UPDATE SET deleted_at = now()`,
+		DeletedModuleVersionsTableName)
+
+	_, err = tx.ExecContext(ctx, record, mv.ModuleId, mv.Version)
 
 	if err != nil {
 		return wrapTransactionError(err)
@@ -560,3 +1041,18 @@ DELETE FROM %s WHERE id = $1`,
 
 	return nil
 }
+
+// VersionWasDeleted is true if the version was deleted, and hasn't been added
+// again since.
+func (s *PostgresModules) VersionWasDeleted(ctx context.Context, moduleId string, version string) (deleted bool, err error) {
+	q := fmt.Sprintf(`
+SELECT
+	EXISTS(SELECT 1 FROM %s WHERE module_id = $1 AND version = $2);`,
+		DeletedModuleVersionsTableName)
+
+	if err := s.db.GetContext(ctx, &deleted, q, moduleId, version); err != nil {
+		return false, wrapQueryError(err)
+	}
+
+	return deleted, nil
+}
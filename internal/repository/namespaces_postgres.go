@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type postgresDbNamespace struct {
+	Id        string    `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (pN *postgresDbNamespace) ToDomainModel() registry.Namespace {
+	return registry.Namespace{
+		Id:        pN.Id,
+		Name:      pN.Name,
+		CreatedAt: pN.CreatedAt,
+		Owners:    []registry.RoleGrant{},
+	}
+}
+
+func (pN *postgresDbNamespace) Populate(n registry.Namespace) {
+	pN.Id = n.Id
+	pN.Name = n.Name
+	pN.CreatedAt = n.CreatedAt
+}
+
+type postgresDbRoleGrant struct {
+	Id          string    `db:"id"`
+	Namespace   string    `db:"namespace"`
+	SubjectType string    `db:"subject_type"`
+	SubjectId   string    `db:"subject_id"`
+	SubjectName string    `db:"subject_name"`
+	Role        string    `db:"role"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+func (pG *postgresDbRoleGrant) ToDomainModel() registry.RoleGrant {
+	return registry.RoleGrant{
+		Id:        pG.Id,
+		Namespace: pG.Namespace,
+		Subject: registry.Subject{
+			Type: registry.SubjectType(pG.SubjectType),
+			Id:   pG.SubjectId,
+		},
+		SubjectName: pG.SubjectName,
+		Role:        registry.Role(pG.Role),
+		CreatedAt:   pG.CreatedAt,
+	}
+}
+
+func (pG *postgresDbRoleGrant) Populate(g registry.RoleGrant) {
+	pG.Id = g.Id
+	pG.Namespace = g.Namespace
+	pG.SubjectType = string(g.Subject.Type)
+	pG.SubjectId = g.Subject.Id
+	pG.SubjectName = g.SubjectName
+	pG.Role = string(g.Role)
+	pG.CreatedAt = g.CreatedAt
+}
+
+type PostgresNamespaces struct {
+	db     *sqlx.DB
+	logger zerolog.Logger
+}
+
+func (s *PostgresNamespaces) startTransaction() (*sqlx.Tx, error) {
+	tx, err := s.db.Beginx()
+
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, ErrDbTransaction{
+			Wrapped: err,
+		}
+	}
+
+	return tx, nil
+}
+
+func (s *PostgresNamespaces) commit(tx *sqlx.Tx) error {
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return wrapTransactionError(rollbackErr)
+		}
+
+		return wrapTransactionError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresNamespaces) NamespaceByName(name string) (n registry.Namespace, err error) {
+	dbNamespace := &postgresDbNamespace{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	name = $1;`,
+		NamespacesTableName)
+
+	err = s.db.Get(dbNamespace, q, name)
+
+	if err == sql.ErrNoRows {
+		return n, registry.ErrResourceNotFound{
+			Type: "Namespace",
+			URI:  name,
+		}
+	} else if err != nil {
+		return n, wrapQueryError(err)
+	}
+
+	return dbNamespace.ToDomainModel(), nil
+}
+
+func (s *PostgresNamespaces) AllNamespaces() (namespaces []registry.Namespace, err error) {
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+ORDER BY name ASC;`, NamespacesTableName)
+
+	rows, err := s.db.Queryx(q)
+
+	if err != nil {
+		return namespaces, wrapQueryError(err)
+	}
+
+	namespaces = []registry.Namespace{}
+
+	for rows.Next() {
+		dbNamespace := &postgresDbNamespace{}
+
+		if err := rows.StructScan(dbNamespace); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.Namespace{}, wrapHydrationError("Namespace", err)
+		}
+
+		namespaces = append(namespaces, dbNamespace.ToDomainModel())
+	}
+
+	return namespaces, nil
+}
+
+func (s *PostgresNamespaces) AddNamespace(new registry.Namespace, owners []registry.RoleGrant) (n registry.Namespace, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return n, err
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, name, created_at)
+VALUES (:id, :name, :created_at);`,
+		NamespacesTableName)
+
+	dbNamespace := &postgresDbNamespace{}
+	dbNamespace.Populate(new)
+
+	if _, err := tx.NamedExec(insert, dbNamespace); err != nil {
+		return n, wrapTransactionError(err)
+	}
+
+	insertGrant := fmt.Sprintf(`
+INSERT INTO %s (id, namespace, subject_type, subject_id, subject_name, role, created_at)
+VALUES (:id, :namespace, :subject_type, :subject_id, :subject_name, :role, :created_at);`,
+		RoleGrantsTableName)
+
+	for _, o := range owners {
+		dbGrant := &postgresDbRoleGrant{}
+		dbGrant.Populate(o)
+
+		if _, err := tx.NamedExec(insertGrant, dbGrant); err != nil {
+			return n, wrapTransactionError(err)
+		}
+	}
+
+	if err := s.commit(tx); err != nil {
+		return n, err
+	}
+
+	return s.NamespaceByName(new.Name)
+}
+
+func (s *PostgresNamespaces) EnsureNamespace(n registry.Namespace) error {
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, name, created_at)
+VALUES (:id, :name, :created_at)
+ON CONFLICT (name) DO NOTHING;`,
+		NamespacesTableName)
+
+	dbNamespace := &postgresDbNamespace{}
+	dbNamespace.Populate(n)
+
+	if _, err := s.db.NamedExec(insert, dbNamespace); err != nil {
+		return wrapQueryError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresNamespaces) DeleteNamespace(n registry.Namespace) error {
+	// Its grants go with it
+	remove := fmt.Sprintf(`DELETE FROM %s WHERE id = $1;`, NamespacesTableName)
+
+	if _, err := s.db.Exec(remove, n.Id); err != nil {
+		return wrapQueryError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresNamespaces) grants(q string, args ...interface{}) (grants []registry.RoleGrant, err error) {
+	rows, err := s.db.Queryx(q, args...)
+
+	if err != nil {
+		return grants, wrapQueryError(err)
+	}
+
+	grants = []registry.RoleGrant{}
+
+	for rows.Next() {
+		dbGrant := &postgresDbRoleGrant{}
+
+		if err := rows.StructScan(dbGrant); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.RoleGrant{}, wrapHydrationError("RoleGrant", err)
+		}
+
+		grants = append(grants, dbGrant.ToDomainModel())
+	}
+
+	return grants, nil
+}
+
+func (s *PostgresNamespaces) GrantsByNamespace(namespace string) ([]registry.RoleGrant, error) {
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	namespace = $1
+ORDER BY subject_type ASC, subject_name ASC;`, RoleGrantsTableName)
+
+	return s.grants(q, namespace)
+}
+
+func (s *PostgresNamespaces) GrantsForSubjects(subjects []registry.Subject) ([]registry.RoleGrant, error) {
+	keys := []string{}
+
+	for _, subject := range subjects {
+		keys = append(keys, string(subject.Type)+":"+subject.Id)
+	}
+
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	subject_type || ':' || subject_id = ANY($1)
+ORDER BY namespace ASC;`, RoleGrantsTableName)
+
+	return s.grants(q, pq.Array(keys))
+}
+
+func (s *PostgresNamespaces) GrantForSubject(namespace string, subject registry.Subject) (g registry.RoleGrant, err error) {
+	dbGrant := &postgresDbRoleGrant{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	namespace = $1 AND subject_type = $2 AND subject_id = $3;`,
+		RoleGrantsTableName)
+
+	err = s.db.Get(dbGrant, q, namespace, string(subject.Type), subject.Id)
+
+	if err == sql.ErrNoRows {
+		return g, registry.ErrResourceNotFound{
+			Type: "RoleGrant",
+			URI:  fmt.Sprintf("%s/%s:%s", namespace, subject.Type, subject.Id),
+		}
+	} else if err != nil {
+		return g, wrapQueryError(err)
+	}
+
+	return dbGrant.ToDomainModel(), nil
+}
+
+func (s *PostgresNamespaces) SaveGrant(g registry.RoleGrant) (registry.RoleGrant, error) {
+	upsert := fmt.Sprintf(`
+INSERT INTO %s (id, namespace, subject_type, subject_id, subject_name, role, created_at)
+VALUES (:id, :namespace, :subject_type, :subject_id, :subject_name, :role, :created_at)
+ON CONFLICT (namespace, subject_type, subject_id)
+DO UPDATE SET subject_name = EXCLUDED.subject_name, role = EXCLUDED.role;`,
+		RoleGrantsTableName)
+
+	dbGrant := &postgresDbRoleGrant{}
+	dbGrant.Populate(g)
+
+	if _, err := s.db.NamedExec(upsert, dbGrant); err != nil {
+		return g, wrapQueryError(err)
+	}
+
+	return s.GrantForSubject(g.Namespace, g.Subject)
+}
+
+func (s *PostgresNamespaces) DeleteGrant(g registry.RoleGrant) error {
+	remove := fmt.Sprintf(`DELETE FROM %s WHERE id = $1;`, RoleGrantsTableName)
+
+	if _, err := s.db.Exec(remove, g.Id); err != nil {
+		return wrapQueryError(err)
+	}
+
+	return nil
+}
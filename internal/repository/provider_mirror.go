@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+)
+
+func BuildProviderMirrorForPostgres(conn *sqlx.DB, logger zerolog.Logger) *PostgresProviderMirror {
+	return &PostgresProviderMirror{
+		db:     conn,
+		logger: logger,
+	}
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type postgresDbMirroredProviderPackage struct {
+	Id         string    `db:"id"`
+	Hostname   string    `db:"hostname"`
+	Namespace  string    `db:"namespace"`
+	Type       string    `db:"type"`
+	Version    string    `db:"version"`
+	OS         string    `db:"os"`
+	Arch       string    `db:"arch"`
+	Filename   string    `db:"filename"`
+	Shasum     string    `db:"shasum"`
+	Hash       string    `db:"hash"`
+	StorageKey string    `db:"storage_key"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (pP *postgresDbMirroredProviderPackage) ToDomainModel() registry.MirroredProviderPackage {
+	return registry.MirroredProviderPackage{
+		Id:         pP.Id,
+		Hostname:   pP.Hostname,
+		Namespace:  pP.Namespace,
+		Type:       pP.Type,
+		Version:    pP.Version,
+		OS:         pP.OS,
+		Arch:       pP.Arch,
+		Filename:   pP.Filename,
+		Shasum:     pP.Shasum,
+		Hash:       pP.Hash,
+		StorageKey: pP.StorageKey,
+		CreatedAt:  pP.CreatedAt,
+	}
+}
+
+func (pP *postgresDbMirroredProviderPackage) Populate(p registry.MirroredProviderPackage) {
+	pP.Id = p.Id
+	pP.Hostname = p.Hostname
+	pP.Namespace = p.Namespace
+	pP.Type = p.Type
+	pP.Version = p.Version
+	pP.OS = p.OS
+	pP.Arch = p.Arch
+	pP.Filename = p.Filename
+	pP.Shasum = p.Shasum
+	pP.Hash = p.Hash
+	pP.StorageKey = p.StorageKey
+	pP.CreatedAt = p.CreatedAt
+}
+
+type PostgresProviderMirror struct {
+	db     *sqlx.DB
+	logger zerolog.Logger
+}
+
+func (s *PostgresProviderMirror) startTransaction() (*sqlx.Tx, error) {
+	tx, err := s.db.Beginx()
+
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, ErrDbTransaction{
+			Wrapped: err,
+		}
+	}
+
+	return tx, nil
+}
+
+func (s *PostgresProviderMirror) commit(tx *sqlx.Tx) error {
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return wrapTransactionError(rollbackErr)
+		}
+
+		return wrapTransactionError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresProviderMirror) MirroredPackage(a registry.ProviderSourceAddress, version string, os string, arch string) (p registry.MirroredProviderPackage, err error) {
+	dbPackage := &postgresDbMirroredProviderPackage{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	hostname = $1 AND
+	namespace = $2 AND
+	type = $3 AND
+	version = $4 AND
+	os = $5 AND
+	arch = $6;`,
+		MirroredProviderPackagesTableName)
+
+	err = s.db.Get(dbPackage, q, a.Hostname, a.Namespace, a.Type, version, os, arch)
+
+	if err == sql.ErrNoRows {
+		return p, registry.ErrResourceNotFound{
+			Type: "MirroredProviderPackage",
+			URI:  fmt.Sprintf("%s@%s/%s_%s", a.String(), version, os, arch),
+		}
+	} else if err != nil {
+		return p, wrapQueryError(err)
+	}
+
+	return dbPackage.ToDomainModel(), nil
+}
+
+func (s *PostgresProviderMirror) MirroredPackages(a registry.ProviderSourceAddress, version string) (pkgs []registry.MirroredProviderPackage, err error) {
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	hostname = $1 AND
+	namespace = $2 AND
+	type = $3 AND
+	($4 = '' OR version = $4)
+ORDER BY version ASC, os ASC, arch ASC;`,
+		MirroredProviderPackagesTableName)
+
+	rows, err := s.db.Queryx(q, a.Hostname, a.Namespace, a.Type, version)
+
+	if err != nil {
+		return pkgs, wrapQueryError(err)
+	}
+
+	pkgs = []registry.MirroredProviderPackage{}
+
+	for rows.Next() {
+		dbPackage := &postgresDbMirroredProviderPackage{}
+
+		if err := rows.StructScan(dbPackage); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.MirroredProviderPackage{}, wrapHydrationError("MirroredProviderPackage", err)
+		}
+
+		pkgs = append(pkgs, dbPackage.ToDomainModel())
+	}
+
+	return pkgs, nil
+}
+
+func (s *PostgresProviderMirror) AddMirroredPackage(new registry.MirroredProviderPackage) (p registry.MirroredProviderPackage, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return p, err
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, hostname, namespace, type, version, os, arch, filename, shasum, hash, storage_key, created_at)
+VALUES (:id, :hostname, :namespace, :type, :version, :os, :arch, :filename, :shasum, :hash, :storage_key, :created_at);`,
+		MirroredProviderPackagesTableName)
+
+	dbPackage := &postgresDbMirroredProviderPackage{}
+	dbPackage.Populate(new)
+
+	if _, err := tx.NamedExec(insert, dbPackage); err != nil {
+		return p, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return p, err
+	}
+
+	return s.MirroredPackage(new.Address(), new.Version, new.OS, new.Arch)
+}
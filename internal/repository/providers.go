@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+)
+
+func BuildProvidersForPostgres(conn *sqlx.DB, logger zerolog.Logger) *PostgresProviders {
+	return &PostgresProviders{
+		db:     conn,
+		logger: logger,
+	}
+}
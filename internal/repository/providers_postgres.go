@@ -0,0 +1,504 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type postgresDbProvider struct {
+	Id        string    `db:"id"`
+	Namespace string    `db:"namespace"`
+	Type      string    `db:"type"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (pP *postgresDbProvider) ToDomainModel() registry.Provider {
+	return registry.Provider{
+		Id:        pP.Id,
+		Namespace: pP.Namespace,
+		Type:      pP.Type,
+		CreatedAt: pP.CreatedAt,
+	}
+}
+
+func (pP *postgresDbProvider) Populate(p registry.Provider) {
+	pP.Id = p.Id
+	pP.Namespace = p.Namespace
+	pP.Type = p.Type
+	pP.CreatedAt = p.CreatedAt
+}
+
+type postgresDbProviderVersion struct {
+	Id                  string    `db:"id"`
+	ProviderId          string    `db:"provider_id"`
+	Version             string    `db:"version"`
+	Protocols           string    `db:"protocols"` //it's JSONB
+	SigningKeyId        string    `db:"signing_key_id"`
+	ShasumsKey          string    `db:"shasums_key"`
+	ShasumsSignatureKey string    `db:"shasums_signature_key"`
+	CreatedAt           time.Time `db:"created_at"`
+}
+
+func (pV *postgresDbProviderVersion) ToDomainModel() registry.ProviderVersion {
+	protocols := []string{}
+	// nolint: errcheck
+	json.Unmarshal([]byte(pV.Protocols), &protocols)
+
+	return registry.ProviderVersion{
+		Id:                  pV.Id,
+		ProviderId:          pV.ProviderId,
+		Version:             pV.Version,
+		Protocols:           protocols,
+		SigningKeyId:        pV.SigningKeyId,
+		ShasumsKey:          pV.ShasumsKey,
+		ShasumsSignatureKey: pV.ShasumsSignatureKey,
+		Platforms:           []registry.ProviderPlatform{},
+		CreatedAt:           pV.CreatedAt,
+	}
+}
+
+func (pV *postgresDbProviderVersion) Populate(v registry.ProviderVersion) {
+	protocols, _ := json.Marshal(v.Protocols)
+
+	pV.Id = v.Id
+	pV.ProviderId = v.ProviderId
+	pV.Version = v.Version
+	pV.Protocols = string(protocols)
+	pV.SigningKeyId = v.SigningKeyId
+	pV.ShasumsKey = v.ShasumsKey
+	pV.ShasumsSignatureKey = v.ShasumsSignatureKey
+	pV.CreatedAt = v.CreatedAt
+}
+
+type postgresDbProviderPlatform struct {
+	Id                string `db:"id"`
+	ProviderVersionId string `db:"provider_version_id"`
+	OS                string `db:"os"`
+	Arch              string `db:"arch"`
+	Filename          string `db:"filename"`
+	Shasum            string `db:"shasum"`
+	StorageKey        string `db:"storage_key"`
+}
+
+func (pP *postgresDbProviderPlatform) ToDomainModel() registry.ProviderPlatform {
+	return registry.ProviderPlatform{
+		Id:                pP.Id,
+		ProviderVersionId: pP.ProviderVersionId,
+		OS:                pP.OS,
+		Arch:              pP.Arch,
+		Filename:          pP.Filename,
+		Shasum:            pP.Shasum,
+		StorageKey:        pP.StorageKey,
+	}
+}
+
+func (pP *postgresDbProviderPlatform) Populate(p registry.ProviderPlatform) {
+	pP.Id = p.Id
+	pP.ProviderVersionId = p.ProviderVersionId
+	pP.OS = p.OS
+	pP.Arch = p.Arch
+	pP.Filename = p.Filename
+	pP.Shasum = p.Shasum
+	pP.StorageKey = p.StorageKey
+}
+
+type postgresDbGPGKey struct {
+	Id         string    `db:"id"`
+	Namespace  string    `db:"namespace"`
+	KeyId      string    `db:"key_id"`
+	ASCIIArmor string    `db:"ascii_armor"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (pK *postgresDbGPGKey) ToDomainModel() registry.GPGKey {
+	return registry.GPGKey{
+		Id:         pK.Id,
+		Namespace:  pK.Namespace,
+		KeyId:      pK.KeyId,
+		ASCIIArmor: pK.ASCIIArmor,
+		CreatedAt:  pK.CreatedAt,
+	}
+}
+
+func (pK *postgresDbGPGKey) Populate(k registry.GPGKey) {
+	pK.Id = k.Id
+	pK.Namespace = k.Namespace
+	pK.KeyId = k.KeyId
+	pK.ASCIIArmor = k.ASCIIArmor
+	pK.CreatedAt = k.CreatedAt
+}
+
+type PostgresProviders struct {
+	db     *sqlx.DB
+	logger zerolog.Logger
+}
+
+func (s *PostgresProviders) startTransaction() (*sqlx.Tx, error) {
+	tx, err := s.db.Beginx()
+
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, ErrDbTransaction{
+			Wrapped: err,
+		}
+	}
+
+	return tx, nil
+}
+
+func (s *PostgresProviders) commit(tx *sqlx.Tx) error {
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return wrapTransactionError(rollbackErr)
+		}
+
+		return wrapTransactionError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresProviders) ProviderByFQN(fqn registry.ProviderFQN) (p registry.Provider, err error) {
+	dbProvider := &postgresDbProvider{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	namespace = $1 AND
+	type = $2;`,
+		ProvidersTableName)
+
+	err = s.db.Get(dbProvider, q, fqn.Namespace, fqn.Type)
+
+	if err == sql.ErrNoRows {
+		return p, registry.ErrResourceNotFound{
+			Type: "Provider",
+			URI:  fqn.String(),
+		}
+	} else if err != nil {
+		return p, wrapQueryError(err)
+	}
+
+	return dbProvider.ToDomainModel(), nil
+}
+
+func (s *PostgresProviders) AddProvider(new registry.Provider) (p registry.Provider, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return p, err
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, namespace, type, created_at)
+VALUES (:id, :namespace, :type, :created_at);`,
+		ProvidersTableName)
+
+	dbProvider := &postgresDbProvider{}
+	dbProvider.Populate(new)
+
+	if _, err := tx.NamedExec(insert, dbProvider); err != nil {
+		return p, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return p, err
+	}
+
+	return s.ProviderByFQN(new.FQN())
+}
+
+func (s *PostgresProviders) queryVersions(q string, args ...interface{}) (pvs []registry.ProviderVersion, err error) {
+	rows, err := s.db.Queryx(q, args...)
+
+	if err != nil {
+		return pvs, wrapQueryError(err)
+	}
+
+	pvs = []registry.ProviderVersion{}
+
+	for rows.Next() {
+		dbVersion := &postgresDbProviderVersion{}
+
+		if err := rows.StructScan(dbVersion); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.ProviderVersion{}, wrapHydrationError("ProviderVersion", err)
+		}
+
+		pvs = append(pvs, dbVersion.ToDomainModel())
+	}
+
+	return s.attachPlatforms(pvs)
+}
+
+func (s *PostgresProviders) attachPlatforms(pvs []registry.ProviderVersion) ([]registry.ProviderVersion, error) {
+	if len(pvs) == 0 {
+		return pvs, nil
+	}
+
+	ids := []string{}
+	byId := map[string]int{}
+
+	for i, pv := range pvs {
+		ids = append(ids, pv.Id)
+		byId[pv.Id] = i
+	}
+
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	provider_version_id = ANY($1)
+ORDER BY os ASC, arch ASC;`,
+		ProviderPlatformsTableName)
+
+	rows, err := s.db.Queryx(q, pq.Array(ids))
+
+	if err != nil {
+		return pvs, wrapQueryError(err)
+	}
+
+	for rows.Next() {
+		dbPlatform := &postgresDbProviderPlatform{}
+
+		if err := rows.StructScan(dbPlatform); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return pvs, wrapHydrationError("ProviderPlatform", err)
+		}
+
+		i := byId[dbPlatform.ProviderVersionId]
+		pvs[i].Platforms = append(pvs[i].Platforms, dbPlatform.ToDomainModel())
+	}
+
+	return pvs, nil
+}
+
+func (s *PostgresProviders) VersionsByProvider(providerId string) ([]registry.ProviderVersion, error) {
+	q := fmt.Sprintf(`
+SELECT
+	*
+FROM
+	%s
+WHERE
+	provider_id = $1
+ORDER BY created_at ASC;`,
+		ProviderVersionsTableName)
+
+	return s.queryVersions(q, providerId)
+}
+
+func (s *PostgresProviders) ProviderVersionByValue(providerId string, version string) (pv registry.ProviderVersion, err error) {
+	q := fmt.Sprintf(`
+SELECT
+	*
+FROM
+	%s
+WHERE
+	provider_id = $1 AND
+	version = $2;`,
+		ProviderVersionsTableName)
+
+	pvs, err := s.queryVersions(q, providerId, version)
+
+	if err != nil {
+		return pv, err
+	}
+
+	if len(pvs) == 0 {
+		return pv, registry.ErrResourceNotFound{
+			Type: "ProviderVersion",
+			URI:  fmt.Sprintf("%s@%s", providerId, version),
+		}
+	}
+
+	return pvs[0], nil
+}
+
+// AddProviderVersion stores the version along with its platforms.
+func (s *PostgresProviders) AddProviderVersion(new registry.ProviderVersion) (pv registry.ProviderVersion, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return pv, err
+	}
+
+	insertVersion := fmt.Sprintf(`
+INSERT INTO %s (id, provider_id, version, protocols, signing_key_id, shasums_key, shasums_signature_key, created_at)
+VALUES (:id, :provider_id, :version, :protocols, :signing_key_id, :shasums_key, :shasums_signature_key, :created_at);`,
+		ProviderVersionsTableName)
+
+	dbVersion := &postgresDbProviderVersion{}
+	dbVersion.Populate(new)
+
+	if _, err := tx.NamedExec(insertVersion, dbVersion); err != nil {
+		// nolint: errcheck
+		tx.Rollback()
+
+		return pv, wrapTransactionError(err)
+	}
+
+	insertPlatform := fmt.Sprintf(`
+INSERT INTO %s (id, provider_version_id, os, arch, filename, shasum, storage_key)
+VALUES (:id, :provider_version_id, :os, :arch, :filename, :shasum, :storage_key);`,
+		ProviderPlatformsTableName)
+
+	for _, p := range new.Platforms {
+		dbPlatform := &postgresDbProviderPlatform{}
+		dbPlatform.Populate(p)
+
+		if _, err := tx.NamedExec(insertPlatform, dbPlatform); err != nil {
+			// nolint: errcheck
+			tx.Rollback()
+
+			return pv, wrapTransactionError(err)
+		}
+	}
+
+	if err := s.commit(tx); err != nil {
+		return pv, err
+	}
+
+	return s.ProviderVersionByValue(new.ProviderId, new.Version)
+}
+
+func (s *PostgresProviders) queryKeys(q string, args ...interface{}) (keys []registry.GPGKey, err error) {
+	rows, err := s.db.Queryx(q, args...)
+
+	if err != nil {
+		return keys, wrapQueryError(err)
+	}
+
+	keys = []registry.GPGKey{}
+
+	for rows.Next() {
+		dbKey := &postgresDbGPGKey{}
+
+		if err := rows.StructScan(dbKey); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.GPGKey{}, wrapHydrationError("GPGKey", err)
+		}
+
+		keys = append(keys, dbKey.ToDomainModel())
+	}
+
+	return keys, nil
+}
+
+func (s *PostgresProviders) GPGKeyById(id string) (k registry.GPGKey, err error) {
+	dbKey := &postgresDbGPGKey{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	id = $1;`,
+		GPGKeysTableName)
+
+	err = s.db.Get(dbKey, q, id)
+
+	if err == sql.ErrNoRows {
+		return k, registry.ErrResourceNotFound{
+			Type: "GPGKey",
+			URI:  id,
+		}
+	} else if err != nil {
+		return k, wrapQueryError(err)
+	}
+
+	return dbKey.ToDomainModel(), nil
+}
+
+func (s *PostgresProviders) GPGKeysByNamespace(namespace string) ([]registry.GPGKey, error) {
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	namespace = $1
+ORDER BY created_at ASC;`,
+		GPGKeysTableName)
+
+	return s.queryKeys(q, namespace)
+}
+
+func (s *PostgresProviders) AllGPGKeys() ([]registry.GPGKey, error) {
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+ORDER BY namespace ASC, created_at ASC;`,
+		GPGKeysTableName)
+
+	return s.queryKeys(q)
+}
+
+func (s *PostgresProviders) AddGPGKey(new registry.GPGKey) (k registry.GPGKey, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return k, err
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, namespace, key_id, ascii_armor, created_at)
+VALUES (:id, :namespace, :key_id, :ascii_armor, :created_at);`,
+		GPGKeysTableName)
+
+	dbKey := &postgresDbGPGKey{}
+	dbKey.Populate(new)
+
+	if _, err := tx.NamedExec(insert, dbKey); err != nil {
+		return k, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return k, err
+	}
+
+	return s.GPGKeyById(new.Id)
+}
+
+func (s *PostgresProviders) DeleteGPGKey(k registry.GPGKey) error {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return err
+	}
+
+	delete := fmt.Sprintf(`
+DELETE FROM %s WHERE id = $1`,
+		GPGKeysTableName)
+
+	if _, err := tx.Exec(delete, k.Id); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	return s.commit(tx)
+}
+
+func (s *PostgresProviders) CountVersionsSignedBy(gpgKeyId string) (count int, err error) {
+	q := fmt.Sprintf(`SELECT
+	COUNT(*)
+FROM
+	%s
+WHERE
+	signing_key_id = $1;`,
+		ProviderVersionsTableName)
+
+	if err := s.db.Get(&count, q, gpgKeyId); err != nil {
+		return 0, wrapQueryError(err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type postgresDbTeam struct {
+	Id        string    `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (pT *postgresDbTeam) ToDomainModel() registry.Team {
+	return registry.Team{
+		Id:        pT.Id,
+		Name:      pT.Name,
+		CreatedAt: pT.CreatedAt,
+		Members:   []registry.User{},
+	}
+}
+
+func (pT *postgresDbTeam) Populate(t registry.Team) {
+	pT.Id = t.Id
+	pT.Name = t.Name
+	pT.CreatedAt = t.CreatedAt
+}
+
+type PostgresTeams struct {
+	db     *sqlx.DB
+	logger zerolog.Logger
+}
+
+func (s *PostgresTeams) TeamByName(name string) (t registry.Team, err error) {
+	dbTeam := &postgresDbTeam{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	name = $1;`,
+		TeamsTableName)
+
+	err = s.db.Get(dbTeam, q, name)
+
+	if err == sql.ErrNoRows {
+		return t, registry.ErrResourceNotFound{
+			Type: "Team",
+			URI:  name,
+		}
+	} else if err != nil {
+		return t, wrapQueryError(err)
+	}
+
+	return dbTeam.ToDomainModel(), nil
+}
+
+func (s *PostgresTeams) teams(q string, args ...interface{}) (teams []registry.Team, err error) {
+	rows, err := s.db.Queryx(q, args...)
+
+	if err != nil {
+		return teams, wrapQueryError(err)
+	}
+
+	teams = []registry.Team{}
+
+	for rows.Next() {
+		dbTeam := &postgresDbTeam{}
+
+		if err := rows.StructScan(dbTeam); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.Team{}, wrapHydrationError("Team", err)
+		}
+
+		teams = append(teams, dbTeam.ToDomainModel())
+	}
+
+	return teams, nil
+}
+
+func (s *PostgresTeams) AllTeams() ([]registry.Team, error) {
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+ORDER BY name ASC;`, TeamsTableName)
+
+	return s.teams(q)
+}
+
+func (s *PostgresTeams) TeamsForUser(userId string) ([]registry.Team, error) {
+	q := fmt.Sprintf(`SELECT
+	t.*
+FROM
+	%s t
+INNER JOIN %s m ON m.team_id = t.id
+WHERE
+	m.user_id = $1
+ORDER BY t.name ASC;`, TeamsTableName, TeamMembersTableName)
+
+	return s.teams(q, userId)
+}
+
+func (s *PostgresTeams) AddTeam(new registry.Team) (t registry.Team, err error) {
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, name, created_at)
+VALUES (:id, :name, :created_at);`,
+		TeamsTableName)
+
+	dbTeam := &postgresDbTeam{}
+	dbTeam.Populate(new)
+
+	if _, err := s.db.NamedExec(insert, dbTeam); err != nil {
+		return t, wrapQueryError(err)
+	}
+
+	return s.TeamByName(new.Name)
+}
+
+func (s *PostgresTeams) DeleteTeam(t registry.Team) error {
+	tx, err := s.db.Beginx()
+
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return wrapTransactionError(err)
+	}
+
+	grants := fmt.Sprintf(`
+DELETE FROM %s WHERE subject_type = $1 AND subject_id = $2;`,
+		RoleGrantsTableName)
+
+	if _, err := tx.Exec(grants, string(registry.SubjectTypes.Team), t.Id); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	// Its members go with it
+	remove := fmt.Sprintf(`DELETE FROM %s WHERE id = $1;`, TeamsTableName)
+
+	if _, err := tx.Exec(remove, t.Id); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresTeams) TeamMembers(teamId string) (users []registry.User, err error) {
+	q := fmt.Sprintf(`SELECT
+	u.*
+FROM
+	%s u
+INNER JOIN %s m ON m.user_id = u.id
+WHERE
+	m.team_id = $1
+ORDER BY u.username ASC;`, UsersTableName, TeamMembersTableName)
+
+	rows, err := s.db.Queryx(q, teamId)
+
+	if err != nil {
+		return users, wrapQueryError(err)
+	}
+
+	users = []registry.User{}
+
+	for rows.Next() {
+		dbUser := &postgresDbUser{}
+
+		if err := rows.StructScan(dbUser); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.User{}, wrapHydrationError("User", err)
+		}
+
+		users = append(users, dbUser.ToDomainModel())
+	}
+
+	return users, nil
+}
+
+func (s *PostgresTeams) AddTeamMember(t registry.Team, u registry.User) error {
+	insert := fmt.Sprintf(`
+INSERT INTO %s (team_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (team_id, user_id) DO NOTHING;`,
+		TeamMembersTableName)
+
+	if _, err := s.db.Exec(insert, t.Id, u.Id); err != nil {
+		return wrapQueryError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresTeams) RemoveTeamMember(t registry.Team, u registry.User) error {
+	remove := fmt.Sprintf(`DELETE FROM %s WHERE team_id = $1 AND user_id = $2;`, TeamMembersTableName)
+
+	if _, err := s.db.Exec(remove, t.Id, u.Id); err != nil {
+		return wrapQueryError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+)
+
+func BuildUpstreamsForPostgres(conn *sqlx.DB, logger zerolog.Logger) *PostgresUpstreams {
+	return &PostgresUpstreams{
+		db:     conn,
+		logger: logger,
+	}
+}
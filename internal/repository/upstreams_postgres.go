@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type postgresDbUpstreamModule struct {
+	Id          string    `db:"id"`
+	UpstreamURL string    `db:"upstream_url"`
+	Namespace   string    `db:"namespace"`
+	Name        string    `db:"name"`
+	Provider    string    `db:"provider"`
+	Versions    string    `db:"versions"` //it's JSONB
+	FetchedAt   time.Time `db:"fetched_at"`
+}
+
+func (pM *postgresDbUpstreamModule) ToDomainModel() registry.UpstreamModule {
+	versions := []string{}
+	// nolint: errcheck
+	json.Unmarshal([]byte(pM.Versions), &versions)
+
+	return registry.UpstreamModule{
+		Id:          pM.Id,
+		UpstreamURL: pM.UpstreamURL,
+		Namespace:   pM.Namespace,
+		Name:        pM.Name,
+		Provider:    pM.Provider,
+		Versions:    versions,
+		FetchedAt:   pM.FetchedAt,
+	}
+}
+
+func (pM *postgresDbUpstreamModule) Populate(m registry.UpstreamModule) {
+	versions, _ := json.Marshal(m.Versions)
+
+	pM.Id = m.Id
+	pM.UpstreamURL = m.UpstreamURL
+	pM.Namespace = m.Namespace
+	pM.Name = m.Name
+	pM.Provider = m.Provider
+	pM.Versions = string(versions)
+	pM.FetchedAt = m.FetchedAt
+}
+
+type postgresDbUpstreamModuleVersion struct {
+	Id               string    `db:"id"`
+	UpstreamModuleId string    `db:"upstream_module_id"`
+	Version          string    `db:"version"`
+	Source           string    `db:"source"`
+	StorageKey       string    `db:"storage_key"`
+	Subdir           string    `db:"subdir"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+func (pMV *postgresDbUpstreamModuleVersion) ToDomainModel() registry.UpstreamModuleVersion {
+	return registry.UpstreamModuleVersion{
+		Id:               pMV.Id,
+		UpstreamModuleId: pMV.UpstreamModuleId,
+		Version:          pMV.Version,
+		Source:           pMV.Source,
+		StorageKey:       pMV.StorageKey,
+		Subdir:           pMV.Subdir,
+		CreatedAt:        pMV.CreatedAt,
+	}
+}
+
+func (pMV *postgresDbUpstreamModuleVersion) Populate(mv registry.UpstreamModuleVersion) {
+	pMV.Id = mv.Id
+	pMV.UpstreamModuleId = mv.UpstreamModuleId
+	pMV.Version = mv.Version
+	pMV.Source = mv.Source
+	pMV.StorageKey = mv.StorageKey
+	pMV.Subdir = mv.Subdir
+	pMV.CreatedAt = mv.CreatedAt
+}
+
+type PostgresUpstreams struct {
+	db     *sqlx.DB
+	logger zerolog.Logger
+}
+
+func (s *PostgresUpstreams) startTransaction() (*sqlx.Tx, error) {
+	tx, err := s.db.Beginx()
+
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, ErrDbTransaction{
+			Wrapped: err,
+		}
+	}
+
+	return tx, nil
+}
+
+func (s *PostgresUpstreams) commit(tx *sqlx.Tx) error {
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return wrapTransactionError(rollbackErr)
+		}
+
+		return wrapTransactionError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresUpstreams) UpstreamModuleByFQN(upstreamURL string, fqn registry.ModuleFQN) (m registry.UpstreamModule, err error) {
+	dbModule := &postgresDbUpstreamModule{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	upstream_url = $1 AND
+	namespace = $2 AND
+	name = $3 AND
+	provider = $4;`,
+		UpstreamModulesTableName)
+
+	err = s.db.Get(dbModule, q, upstreamURL, fqn.Namespace, fqn.Name, fqn.Provider)
+
+	if err == sql.ErrNoRows {
+		return m, registry.ErrResourceNotFound{
+			Type: "UpstreamModule",
+			URI:  fmt.Sprintf("%s/%s", upstreamURL, fqn.String()),
+		}
+	} else if err != nil {
+		return m, wrapQueryError(err)
+	}
+
+	return dbModule.ToDomainModel(), nil
+}
+
+// SaveUpstreamModule inserts the module, or replaces the cached versions of
+// the one already stored for the upstream.
+func (s *PostgresUpstreams) SaveUpstreamModule(new registry.UpstreamModule) (m registry.UpstreamModule, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return m, err
+	}
+
+	upsert := fmt.Sprintf(`
+INSERT INTO %s (id, upstream_url, namespace, name, provider, versions, fetched_at)
+VALUES (:id, :upstream_url, :namespace, :name, :provider, :versions, :fetched_at)
+ON CONFLICT (upstream_url, namespace, name, provider)
+DO UPDATE SET versions = EXCLUDED.versions, fetched_at = EXCLUDED.fetched_at;`,
+		UpstreamModulesTableName)
+
+	dbModule := &postgresDbUpstreamModule{}
+	dbModule.Populate(new)
+
+	if _, err := tx.NamedExec(upsert, dbModule); err != nil {
+		return m, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return m, err
+	}
+
+	return s.UpstreamModuleByFQN(new.UpstreamURL, new.FQN())
+}
+
+func (s *PostgresUpstreams) UpstreamModuleVersion(upstreamModuleId string, version string) (mv registry.UpstreamModuleVersion, err error) {
+	dbVersion := &postgresDbUpstreamModuleVersion{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	upstream_module_id = $1 AND
+	version = $2;`,
+		UpstreamModuleVersionsTableName)
+
+	err = s.db.Get(dbVersion, q, upstreamModuleId, version)
+
+	if err == sql.ErrNoRows {
+		return mv, registry.ErrResourceNotFound{
+			Type: "UpstreamModuleVersion",
+			URI:  fmt.Sprintf("%s@%s", upstreamModuleId, version),
+		}
+	} else if err != nil {
+		return mv, wrapQueryError(err)
+	}
+
+	return dbVersion.ToDomainModel(), nil
+}
+
+func (s *PostgresUpstreams) AddUpstreamModuleVersion(new registry.UpstreamModuleVersion) (mv registry.UpstreamModuleVersion, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return mv, err
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, upstream_module_id, version, source, storage_key, subdir, created_at)
+VALUES (:id, :upstream_module_id, :version, :source, :storage_key, :subdir, :created_at);`,
+		UpstreamModuleVersionsTableName)
+
+	dbVersion := &postgresDbUpstreamModuleVersion{}
+	dbVersion.Populate(new)
+
+	if _, err := tx.NamedExec(insert, dbVersion); err != nil {
+		return mv, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return mv, err
+	}
+
+	return s.UpstreamModuleVersion(new.UpstreamModuleId, new.Version)
+}
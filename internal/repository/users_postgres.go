@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type postgresDbUser struct {
+	Id           string    `db:"id"`
+	Username     string    `db:"username"`
+	PasswordHash string    `db:"password_hash"`
+	Scopes       string    `db:"scopes"` //it's JSONB
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+func (pU *postgresDbUser) ToDomainModel() registry.User {
+	scopes := []registry.TokenScope{}
+	// nolint: errcheck
+	json.Unmarshal([]byte(pU.Scopes), &scopes)
+
+	return registry.User{
+		Id:           pU.Id,
+		Username:     pU.Username,
+		PasswordHash: pU.PasswordHash,
+		Scopes:       scopes,
+		CreatedAt:    pU.CreatedAt,
+	}
+}
+
+func (pU *postgresDbUser) Populate(u registry.User) {
+	scopes, _ := json.Marshal(u.Scopes)
+
+	pU.Id = u.Id
+	pU.Username = u.Username
+	pU.PasswordHash = u.PasswordHash
+	pU.Scopes = string(scopes)
+	pU.CreatedAt = u.CreatedAt
+}
+
+type postgresDbAuthorizationCode struct {
+	CodeHash      string    `db:"code_hash"`
+	UserId        string    `db:"user_id"`
+	RedirectURI   string    `db:"redirect_uri"`
+	CodeChallenge string    `db:"code_challenge"`
+	ExpiresAt     time.Time `db:"expires_at"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+func (pC *postgresDbAuthorizationCode) ToDomainModel() registry.AuthorizationCode {
+	return registry.AuthorizationCode{
+		Hash:          pC.CodeHash,
+		UserId:        pC.UserId,
+		RedirectURI:   pC.RedirectURI,
+		CodeChallenge: pC.CodeChallenge,
+		ExpiresAt:     pC.ExpiresAt,
+		CreatedAt:     pC.CreatedAt,
+	}
+}
+
+func (pC *postgresDbAuthorizationCode) Populate(c registry.AuthorizationCode) {
+	pC.CodeHash = c.Hash
+	pC.UserId = c.UserId
+	pC.RedirectURI = c.RedirectURI
+	pC.CodeChallenge = c.CodeChallenge
+	pC.ExpiresAt = c.ExpiresAt
+	pC.CreatedAt = c.CreatedAt
+}
+
+type PostgresUsers struct {
+	db     *sqlx.DB
+	logger zerolog.Logger
+}
+
+func (s *PostgresUsers) startTransaction() (*sqlx.Tx, error) {
+	tx, err := s.db.Beginx()
+
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, ErrDbTransaction{
+			Wrapped: err,
+		}
+	}
+
+	return tx, nil
+}
+
+func (s *PostgresUsers) commit(tx *sqlx.Tx) error {
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return wrapTransactionError(rollbackErr)
+		}
+
+		return wrapTransactionError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresUsers) userBy(column string, value string) (u registry.User, err error) {
+	dbUser := &postgresDbUser{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	%s = $1;`,
+		UsersTableName, column)
+
+	err = s.db.Get(dbUser, q, value)
+
+	if err == sql.ErrNoRows {
+		return u, registry.ErrResourceNotFound{
+			Type: "User",
+			URI:  value,
+		}
+	} else if err != nil {
+		return u, wrapQueryError(err)
+	}
+
+	return dbUser.ToDomainModel(), nil
+}
+
+func (s *PostgresUsers) UserById(id string) (u registry.User, err error) {
+	return s.userBy("id", id)
+}
+
+func (s *PostgresUsers) UserByUsername(username string) (u registry.User, err error) {
+	return s.userBy("username", username)
+}
+
+func (s *PostgresUsers) AllUsers() (users []registry.User, err error) {
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+ORDER BY username ASC;`, UsersTableName)
+
+	rows, err := s.db.Queryx(q)
+
+	if err != nil {
+		return users, wrapQueryError(err)
+	}
+
+	users = []registry.User{}
+
+	for rows.Next() {
+		dbUser := &postgresDbUser{}
+
+		if err := rows.StructScan(dbUser); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.User{}, wrapHydrationError("User", err)
+		}
+
+		users = append(users, dbUser.ToDomainModel())
+	}
+
+	return users, nil
+}
+
+func (s *PostgresUsers) AddUser(new registry.User) (u registry.User, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return u, err
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, username, password_hash, scopes, created_at)
+VALUES (:id, :username, :password_hash, :scopes, :created_at);`,
+		UsersTableName)
+
+	dbUser := &postgresDbUser{}
+	dbUser.Populate(new)
+
+	if _, err := tx.NamedExec(insert, dbUser); err != nil {
+		return u, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return u, err
+	}
+
+	return s.UserById(new.Id)
+}
+
+func (s *PostgresUsers) DeleteUser(u registry.User, at time.Time) error {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return err
+	}
+
+	revoke := fmt.Sprintf(`
+UPDATE %s SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL;`,
+		APITokensTableName)
+
+	if _, err := tx.Exec(revoke, at, u.Id); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	// The roles granted to the user, and the tokens they were issued
+	grants := fmt.Sprintf(`
+DELETE FROM %s
+WHERE
+	(subject_type = $1 AND subject_id = $2)
+	OR (subject_type = $3 AND subject_id IN (SELECT id FROM %s WHERE user_id = $2));`,
+		RoleGrantsTableName, APITokensTableName)
+
+	if _, err := tx.Exec(grants, string(registry.SubjectTypes.User), u.Id, string(registry.SubjectTypes.Token)); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	// Its authorization codes and team memberships go with it
+	remove := fmt.Sprintf(`DELETE FROM %s WHERE id = $1;`, UsersTableName)
+
+	if _, err := tx.Exec(remove, u.Id); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	return s.commit(tx)
+}
+
+func (s *PostgresUsers) AddAuthorizationCode(c registry.AuthorizationCode) error {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return err
+	}
+
+	// Codes that were never exchanged are cleared out as new ones are added
+	expired := fmt.Sprintf(`DELETE FROM %s WHERE expires_at < $1;`, AuthorizationCodesTableName)
+
+	if _, err := tx.Exec(expired, c.CreatedAt); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (code_hash, user_id, redirect_uri, code_challenge, expires_at, created_at)
+VALUES (:code_hash, :user_id, :redirect_uri, :code_challenge, :expires_at, :created_at);`,
+		AuthorizationCodesTableName)
+
+	dbCode := &postgresDbAuthorizationCode{}
+	dbCode.Populate(c)
+
+	if _, err := tx.NamedExec(insert, dbCode); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	return s.commit(tx)
+}
+
+func (s *PostgresUsers) TakeAuthorizationCode(hash string) (c registry.AuthorizationCode, err error) {
+	dbCode := &postgresDbAuthorizationCode{}
+	q := fmt.Sprintf(`DELETE FROM %s WHERE code_hash = $1 RETURNING *;`, AuthorizationCodesTableName)
+
+	err = s.db.Get(dbCode, q, hash)
+
+	if err == sql.ErrNoRows {
+		return c, registry.ErrResourceNotFound{
+			Type: "AuthorizationCode",
+			// Never put the hash in an error that may be logged
+			URI: "<redacted>",
+		}
+	} else if err != nil {
+		return c, wrapQueryError(err)
+	}
+
+	return dbCode.ToDomainModel(), nil
+}
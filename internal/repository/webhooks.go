@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+)
+
+func BuildWebhooksForPostgres(conn *sqlx.DB, logger zerolog.Logger) *PostgresWebhooks {
+	return &PostgresWebhooks{
+		db:     conn,
+		logger: logger,
+	}
+}
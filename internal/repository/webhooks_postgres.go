@@ -0,0 +1,499 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type postgresDbWebhookSubscription struct {
+	Id         string    `db:"id"`
+	URL        string    `db:"url"`
+	Secret     string    `db:"secret"`
+	EventTypes string    `db:"event_types"` //it's JSONB
+	Namespaces string    `db:"namespaces"`  //it's JSONB
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (pS *postgresDbWebhookSubscription) ToDomainModel() registry.WebhookSubscription {
+	types := []registry.EventType{}
+	// nolint: errcheck
+	json.Unmarshal([]byte(pS.EventTypes), &types)
+
+	namespaces := []string{}
+	// nolint: errcheck
+	json.Unmarshal([]byte(pS.Namespaces), &namespaces)
+
+	return registry.WebhookSubscription{
+		Id:         pS.Id,
+		URL:        pS.URL,
+		Secret:     pS.Secret,
+		EventTypes: types,
+		Namespaces: namespaces,
+		CreatedAt:  pS.CreatedAt,
+	}
+}
+
+func (pS *postgresDbWebhookSubscription) Populate(s registry.WebhookSubscription) {
+	types, _ := json.Marshal(s.EventTypes)
+	namespaces, _ := json.Marshal(s.Namespaces)
+
+	pS.Id = s.Id
+	pS.URL = s.URL
+	pS.Secret = s.Secret
+	pS.EventTypes = string(types)
+	pS.Namespaces = string(namespaces)
+	pS.CreatedAt = s.CreatedAt
+}
+
+type postgresDbWebhookDelivery struct {
+	Id             string    `db:"id"`
+	SubscriptionId string    `db:"subscription_id"`
+	EventId        string    `db:"event_id"`
+	EventType      string    `db:"event_type"`
+	Payload        string    `db:"payload"` //it's JSONB
+	Status         string    `db:"status"`
+	Attempts       int       `db:"attempts"`
+	NextAttemptAt  time.Time `db:"next_attempt_at"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+func (pD *postgresDbWebhookDelivery) ToDomainModel() registry.WebhookDelivery {
+	return registry.WebhookDelivery{
+		Id:             pD.Id,
+		SubscriptionId: pD.SubscriptionId,
+		EventId:        pD.EventId,
+		EventType:      registry.EventType(pD.EventType),
+		Payload:        pD.Payload,
+		Status:         registry.DeliveryStatus(pD.Status),
+		Attempts:       pD.Attempts,
+		NextAttemptAt:  pD.NextAttemptAt,
+		CreatedAt:      pD.CreatedAt,
+		AttemptLog:     []registry.WebhookDeliveryAttempt{},
+	}
+}
+
+func (pD *postgresDbWebhookDelivery) Populate(d registry.WebhookDelivery) {
+	pD.Id = d.Id
+	pD.SubscriptionId = d.SubscriptionId
+	pD.EventId = d.EventId
+	pD.EventType = string(d.EventType)
+	pD.Payload = d.Payload
+	pD.Status = string(d.Status)
+	pD.Attempts = d.Attempts
+	pD.NextAttemptAt = d.NextAttemptAt
+	pD.CreatedAt = d.CreatedAt
+}
+
+type postgresDbWebhookDeliveryAttempt struct {
+	Id           string    `db:"id"`
+	DeliveryId   string    `db:"delivery_id"`
+	AttemptedAt  time.Time `db:"attempted_at"`
+	ResponseCode int       `db:"response_code"`
+	Error        string    `db:"error"`
+	DurationMs   int64     `db:"duration_ms"`
+}
+
+func (pA *postgresDbWebhookDeliveryAttempt) ToDomainModel() registry.WebhookDeliveryAttempt {
+	return registry.WebhookDeliveryAttempt{
+		Id:           pA.Id,
+		DeliveryId:   pA.DeliveryId,
+		AttemptedAt:  pA.AttemptedAt,
+		ResponseCode: pA.ResponseCode,
+		Error:        pA.Error,
+		DurationMs:   pA.DurationMs,
+	}
+}
+
+func (pA *postgresDbWebhookDeliveryAttempt) Populate(a registry.WebhookDeliveryAttempt) {
+	pA.Id = a.Id
+	pA.DeliveryId = a.DeliveryId
+	pA.AttemptedAt = a.AttemptedAt
+	pA.ResponseCode = a.ResponseCode
+	pA.Error = a.Error
+	pA.DurationMs = a.DurationMs
+}
+
+type PostgresWebhooks struct {
+	db     *sqlx.DB
+	logger zerolog.Logger
+}
+
+func (s *PostgresWebhooks) startTransaction() (*sqlx.Tx, error) {
+	tx, err := s.db.Beginx()
+
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, ErrDbTransaction{
+			Wrapped: err,
+		}
+	}
+
+	return tx, nil
+}
+
+func (s *PostgresWebhooks) commit(tx *sqlx.Tx) error {
+	if err := tx.Commit(); err != nil {
+		rollbackErr := tx.Rollback()
+
+		if rollbackErr != nil {
+			return wrapTransactionError(rollbackErr)
+		}
+
+		return wrapTransactionError(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresWebhooks) SubscriptionById(id string) (sub registry.WebhookSubscription, err error) {
+	dbSub := &postgresDbWebhookSubscription{}
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	id = $1;`,
+		WebhookSubscriptionsTableName)
+
+	err = s.db.Get(dbSub, q, id)
+
+	if err == sql.ErrNoRows {
+		return sub, registry.ErrResourceNotFound{
+			Type: "WebhookSubscription",
+			URI:  id,
+		}
+	} else if err != nil {
+		return sub, wrapQueryError(err)
+	}
+
+	return dbSub.ToDomainModel(), nil
+}
+
+func (s *PostgresWebhooks) AllSubscriptions() (subs []registry.WebhookSubscription, err error) {
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+ORDER BY created_at ASC;`, WebhookSubscriptionsTableName)
+
+	rows, err := s.db.Queryx(q)
+
+	if err != nil {
+		return subs, wrapQueryError(err)
+	}
+
+	subs = []registry.WebhookSubscription{}
+
+	for rows.Next() {
+		dbSub := &postgresDbWebhookSubscription{}
+
+		if err := rows.StructScan(dbSub); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.WebhookSubscription{}, wrapHydrationError("WebhookSubscription", err)
+		}
+
+		subs = append(subs, dbSub.ToDomainModel())
+	}
+
+	return subs, nil
+}
+
+func (s *PostgresWebhooks) AddSubscription(new registry.WebhookSubscription) (sub registry.WebhookSubscription, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return sub, err
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, url, secret, event_types, namespaces, created_at)
+VALUES (:id, :url, :secret, :event_types, :namespaces, :created_at);`,
+		WebhookSubscriptionsTableName)
+
+	dbSub := &postgresDbWebhookSubscription{}
+	dbSub.Populate(new)
+
+	if _, err := tx.NamedExec(insert, dbSub); err != nil {
+		return sub, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return sub, err
+	}
+
+	return s.SubscriptionById(new.Id)
+}
+
+// DeleteSubscription also removes its deliveries and their attempts, the
+// foreign keys cascade.
+func (s *PostgresWebhooks) DeleteSubscription(sub registry.WebhookSubscription) error {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return err
+	}
+
+	delete := fmt.Sprintf(`
+DELETE FROM %s WHERE id = $1`,
+		WebhookSubscriptionsTableName)
+
+	if _, err := tx.Exec(delete, sub.Id); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	return s.commit(tx)
+}
+
+func (s *PostgresWebhooks) queryDeliveries(q string, args ...interface{}) (ds []registry.WebhookDelivery, err error) {
+	rows, err := s.db.Queryx(q, args...)
+
+	if err != nil {
+		return ds, wrapQueryError(err)
+	}
+
+	ds = []registry.WebhookDelivery{}
+
+	for rows.Next() {
+		dbD := &postgresDbWebhookDelivery{}
+
+		if err := rows.StructScan(dbD); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return []registry.WebhookDelivery{}, wrapHydrationError("WebhookDelivery", err)
+		}
+
+		ds = append(ds, dbD.ToDomainModel())
+	}
+
+	return ds, nil
+}
+
+func (s *PostgresWebhooks) attachAttempts(ds []registry.WebhookDelivery) ([]registry.WebhookDelivery, error) {
+	if len(ds) == 0 {
+		return ds, nil
+	}
+
+	ids := []string{}
+	byId := map[string]int{}
+
+	for i, d := range ds {
+		ids = append(ids, d.Id)
+		byId[d.Id] = i
+	}
+
+	q := fmt.Sprintf(`SELECT
+	*
+FROM
+	%s
+WHERE
+	delivery_id = ANY($1)
+ORDER BY attempted_at ASC;`,
+		WebhookDeliveryAttemptsTableName)
+
+	rows, err := s.db.Queryx(q, pq.Array(ids))
+
+	if err != nil {
+		return ds, wrapQueryError(err)
+	}
+
+	for rows.Next() {
+		dbA := &postgresDbWebhookDeliveryAttempt{}
+
+		if err := rows.StructScan(dbA); err != nil {
+			s.logger.Error().Err(err).Msg("failed to scan row")
+			return ds, wrapHydrationError("WebhookDeliveryAttempt", err)
+		}
+
+		i := byId[dbA.DeliveryId]
+		ds[i].AttemptLog = append(ds[i].AttemptLog, dbA.ToDomainModel())
+	}
+
+	return ds, nil
+}
+
+func (s *PostgresWebhooks) DeliveriesBySubscription(subscriptionId string, chunkOpts registry.ChunkingOptions) ([]registry.WebhookDelivery, error) {
+	limit := ""
+
+	if chunkOpts.Size > 0 {
+		limit = fmt.Sprintf("LIMIT %d", chunkOpts.Size)
+	}
+
+	q := fmt.Sprintf(`
+SELECT
+	*
+FROM
+	%s
+WHERE
+	subscription_id = $1
+ORDER BY created_at DESC
+%s;`,
+		WebhookDeliveriesTableName, limit)
+
+	ds, err := s.queryDeliveries(q, subscriptionId)
+
+	if err != nil {
+		return ds, err
+	}
+
+	return s.attachAttempts(ds)
+}
+
+func (s *PostgresWebhooks) DueDeliveries(at time.Time, chunkOpts registry.ChunkingOptions) ([]registry.WebhookDelivery, error) {
+	limit := ""
+
+	if chunkOpts.Size > 0 {
+		limit = fmt.Sprintf("LIMIT %d", chunkOpts.Size)
+	}
+
+	q := fmt.Sprintf(`
+SELECT
+	*
+FROM
+	%s
+WHERE
+	status = $1 AND
+	next_attempt_at <= $2
+ORDER BY next_attempt_at ASC
+%s;`,
+		WebhookDeliveriesTableName, limit)
+
+	return s.queryDeliveries(q, string(registry.DeliveryStatuses.Pending), at)
+}
+
+func (s *PostgresWebhooks) CountDeliveriesByStatus(status registry.DeliveryStatus) (int, error) {
+	q := fmt.Sprintf(`
+SELECT
+	COUNT(*)
+FROM
+	%s
+WHERE
+	status = $1;`,
+		WebhookDeliveriesTableName)
+
+	count := 0
+
+	if err := s.db.Get(&count, q, string(status)); err != nil {
+		return 0, wrapQueryError(err)
+	}
+
+	return count, nil
+}
+
+func (s *PostgresWebhooks) AddDeliveries(ds []registry.WebhookDelivery) error {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at)
+VALUES (:id, :subscription_id, :event_id, :event_type, :payload, :status, :attempts, :next_attempt_at, :created_at, now());`,
+		WebhookDeliveriesTableName)
+
+	for _, d := range ds {
+		dbD := &postgresDbWebhookDelivery{}
+		dbD.Populate(d)
+
+		if _, err := tx.NamedExec(insert, dbD); err != nil {
+			// nolint: errcheck
+			tx.Rollback()
+
+			return wrapTransactionError(err)
+		}
+	}
+
+	return s.commit(tx)
+}
+
+// ClaimDelivery counts an attempt against a delivery and pushes its next
+// attempt back, only if no other worker has done so first.
+func (s *PostgresWebhooks) ClaimDelivery(d registry.WebhookDelivery, retryAt time.Time) (claimed bool, err error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return false, err
+	}
+
+	update := fmt.Sprintf(`
+UPDATE %s SET attempts = attempts + 1, next_attempt_at = $1, updated_at = now() WHERE id = $2 AND status = $3 AND attempts = $4`,
+		WebhookDeliveriesTableName)
+
+	res, err := tx.Exec(update, retryAt, d.Id, string(registry.DeliveryStatuses.Pending), d.Attempts)
+
+	if err != nil {
+		return false, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return false, wrapQueryError(err)
+	}
+
+	return affected == 1, nil
+}
+
+func (s *PostgresWebhooks) UpdateDelivery(d registry.WebhookDelivery) (registry.WebhookDelivery, error) {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return d, err
+	}
+
+	update := fmt.Sprintf(`
+UPDATE %s SET
+	status = :status,
+	attempts = :attempts,
+	next_attempt_at = :next_attempt_at,
+	updated_at = now()
+WHERE
+	id = :id;`,
+		WebhookDeliveriesTableName)
+
+	dbD := &postgresDbWebhookDelivery{}
+	dbD.Populate(d)
+
+	if _, err := tx.NamedExec(update, dbD); err != nil {
+		return d, wrapTransactionError(err)
+	}
+
+	if err := s.commit(tx); err != nil {
+		return d, err
+	}
+
+	return d, nil
+}
+
+func (s *PostgresWebhooks) AddDeliveryAttempt(a registry.WebhookDeliveryAttempt) error {
+	tx, err := s.startTransaction()
+
+	if err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf(`
+INSERT INTO %s (id, delivery_id, attempted_at, response_code, error, duration_ms)
+VALUES (:id, :delivery_id, :attempted_at, :response_code, :error, :duration_ms);`,
+		WebhookDeliveryAttemptsTableName)
+
+	dbA := &postgresDbWebhookDeliveryAttempt{}
+	dbA.Populate(a)
+
+	if _, err := tx.NamedExec(insert, dbA); err != nil {
+		return wrapTransactionError(err)
+	}
+
+	return s.commit(tx)
+}
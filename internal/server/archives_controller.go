@@ -0,0 +1,83 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/archive"
+	"github.com/svartlfheim/ymir/internal/storage"
+)
+
+type archiveStore interface {
+	Open(key string) (io.ReadCloser, error)
+}
+
+// ArchivesController serves the module archives built into storage, these
+// are the URLs handed to terraform in the X-Terraform-Get header. It serves
+// the files of provider releases too.
+type ArchivesController struct {
+	logger zerolog.Logger
+	store  archiveStore
+	// signer is set when module archives can only be downloaded from the
+	// signed URLs handed out by the protocol endpoints
+	signer *archive.URLSigner
+}
+
+func archiveContentType(key string) string {
+	switch {
+	case strings.HasSuffix(key, ".tar.gz"):
+		return "application/gzip"
+	case strings.HasSuffix(key, ".zip"):
+		return "application/zip"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+func (c *ArchivesController) GetArchive(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	if c.signer != nil && archive.IsModuleKey(key) && !c.signer.Verify(r.URL.Path, r.URL.Query(), time.Now()) {
+		w.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	f, err := c.store.Open(key)
+
+	if err != nil {
+		switch err.(type) {
+		case storage.ErrObjectNotFound, storage.ErrInvalidKey:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			c.logger.Error().Err(err).Str("key", key).Str("action", "Archives.GetArchive").Msg("failed to open archive")
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	defer f.Close()
+
+	w.Header().Set("Content-Type", archiveContentType(key))
+
+	if _, err := io.Copy(w, f); err != nil {
+		c.logger.Error().Err(err).Str("key", key).Str("action", "Archives.GetArchive").Msg("failed to write archive")
+	}
+}
+
+func (c *ArchivesController) RegisterRoutes(r muxRouter) {
+	r.HandleFunc("/archives/{key:.+}", c.GetArchive).Methods("GET")
+}
+
+func NewArchivesController(l zerolog.Logger, s archiveStore, signer *archive.URLSigner) *ArchivesController {
+	return &ArchivesController{
+		logger: l,
+		store:  s,
+		signer: signer,
+	}
+}
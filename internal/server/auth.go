@@ -0,0 +1,310 @@
+package server
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type bearerAuthenticator interface {
+	AuthenticateAPITokenV1(dto registry.AuthenticateAPITokenV1DTO) (registry.AuthenticateAPITokenV1Response, error)
+	AuthenticateOIDCTokenV1(dto registry.AuthenticateOIDCTokenV1DTO) (registry.AuthenticateOIDCTokenV1Response, error)
+}
+
+// namespacedRoutes are the management API routes for the modules and
+// providers in a namespace, by method and route template. The command bus
+// checks the actor's scopes, and roles in the namespace, permit the command.
+// Every other route needs the admin scope for every namespace.
+var namespacedRoutes = map[string]bool{
+	"GET /api/v1/modules":                               true,
+	"POST /api/v1/modules":                              true,
+	"GET /api/v1/modules/{id}":                          true,
+	"PATCH /api/v1/modules/{id}":                        true,
+	"DELETE /api/v1/modules/{id}":                       true,
+	"GET /api/v1/modules/{id}/resolve":                  true,
+	"GET /api/v1/modules/{module_id}/versions":          true,
+	"POST /api/v1/modules/{module_id}/versions":         true,
+	"POST /api/v1/modules/{module_id}/publish":          true,
+	"GET /api/v1/module-versions/{id}":                  true,
+	"DELETE /api/v1/module-versions/{id}":               true,
+	"GET /api/v1/module-versions/{id}/interface":        true,
+	"GET /api/v1/providers/{namespace}/{type}/versions": true,
+
+	"POST /api/v1/providers/{namespace}/{type}/versions/{version}": true,
+
+	"GET /api/v1/namespaces":                                 true,
+	"GET /api/v1/namespaces/{namespace}":                     true,
+	"DELETE /api/v1/namespaces/{namespace}":                  true,
+	"POST /api/v1/namespaces/{namespace}/grants":             true,
+	"DELETE /api/v1/namespaces/{namespace}/grants/{subject}": true,
+}
+
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+
+	if route == nil {
+		return ""
+	}
+
+	tpl, err := route.GetPathTemplate()
+
+	if err != nil {
+		return ""
+	}
+
+	return tpl
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+
+	if len(h) < 7 || !strings.EqualFold(h[:7], "bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(h[7:])
+
+	return token, token != ""
+}
+
+func handleAuthErrorResponse(w http.ResponseWriter, code int, challenge string, message string) {
+	errResp := ErrorResponse{}
+	errResp.Add("authorization", message)
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(errResp)
+}
+
+// authenticateBearer is the actor a bearer token authenticates as. Ymir's own
+// tokens are recognised by their prefix, anything else is tried as an OIDC
+// token.
+func authenticateBearer(a bearerAuthenticator, token string) (registry.Actor, registry.RegistryHandlerStatus, error) {
+	if strings.HasPrefix(token, registry.APITokenPrefix) {
+		res, err := a.AuthenticateAPITokenV1(registry.AuthenticateAPITokenV1DTO{
+			Token: token,
+		})
+
+		return res.APIToken.Actor(), res.Status, err
+	}
+
+	res, err := a.AuthenticateOIDCTokenV1(registry.AuthenticateOIDCTokenV1DTO{
+		Token: token,
+	})
+
+	return res.Actor, res.Status, err
+}
+
+type apiAuthenticator interface {
+	bearerAuthenticator
+	AuthenticateCertificateV1(dto registry.AuthenticateCertificateV1DTO) (registry.AuthenticateCertificateV1Response, error)
+}
+
+// clientCertificate is the certificate the client presented, when the server
+// has verified it against its client CA.
+func clientCertificate(r *http.Request) (*x509.Certificate, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	return r.TLS.VerifiedChains[0][0], true
+}
+
+// authenticateAPIRequest is the actor the request's bearer token, or verified
+// client certificate when there isn't a token, authenticates as. It is false
+// when the request has neither.
+func authenticateAPIRequest(a apiAuthenticator, r *http.Request) (registry.Actor, registry.RegistryHandlerStatus, bool, error) {
+	if token, ok := bearerToken(r); ok {
+		actor, status, err := authenticateBearer(a, token)
+
+		return actor, status, true, err
+	}
+
+	cert, ok := clientCertificate(r)
+
+	if !ok {
+		return registry.Actor{}, "", false, nil
+	}
+
+	res, err := a.AuthenticateCertificateV1(registry.AuthenticateCertificateV1DTO{
+		Certificate: cert,
+	})
+
+	// A certificate no rule matches is treated as if it wasn't presented
+	return res.Actor, res.Status, err != nil || res.Status != registry.STATUS_UNAUTHORIZED, err
+}
+
+// tokenAuthMiddleware requires an API or OIDC token, or a client certificate
+// matching a certificate rule, and adds its actor to the request context. The
+// command bus decides what the actor may do on the namespaced routes, the
+// rest need the admin scope.
+func tokenAuthMiddleware(a apiAuthenticator, l zerolog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actor, status, ok, err := authenticateAPIRequest(a, r)
+
+			if err != nil {
+				l.Error().Err(err).Str("action", "Auth.AuthenticateToken").Msg("command failed")
+
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if !ok {
+				handleAuthErrorResponse(w, http.StatusUnauthorized, `Bearer realm="ymir"`, "a bearer token is required")
+				return
+			}
+
+			if status != registry.STATUS_OKAY {
+				handleAuthErrorResponse(w, http.StatusUnauthorized, `Bearer realm="ymir", error="invalid_token"`, "the token is invalid, expired or revoked")
+				return
+			}
+
+			if !namespacedRoutes[r.Method+" "+routeTemplate(r)] && !actor.Allows(registry.TokenScopes.Admin, "") {
+				challenge := fmt.Sprintf(`Bearer realm="ymir", error="insufficient_scope", scope="%s"`, registry.TokenScopes.Admin)
+				handleAuthErrorResponse(w, http.StatusForbidden, challenge, fmt.Sprintf("the token needs the %s scope", registry.TokenScopes.Admin))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(registry.ContextWithActor(r.Context(), actor)))
+		})
+	}
+}
+
+// actingBus is the command bus acting for the actor the request was
+// authenticated as, so that its commands are only allowed when the actor is
+// permitted to run them.
+func actingBus(r *http.Request, cb *registry.CommandBus) *registry.CommandBus {
+	a, ok := registry.ActorFromContext(r.Context())
+
+	if !ok {
+		return cb
+	}
+
+	return cb.As(a)
+}
+
+func handleForbiddenResponse(w http.ResponseWriter) {
+	errResp := ErrorResponse{}
+	errResp.Add("authorization", "the token's scopes, and the roles granted in the namespace, don't permit this")
+
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(errResp)
+}
+
+// attributed adds the actor the request was authenticated as to the action's
+// audit meta.
+func attributed(r *http.Request, action registry.AuditableAction) registry.AuditableAction {
+	a, ok := registry.ActorFromContext(r.Context())
+
+	if !ok {
+		return action
+	}
+
+	return registry.AttributedAction{
+		AuditableAction: action,
+		Actor:           a,
+	}
+}
+
+// registryErrors is how the terraform registry protocol describes errors.
+type registryErrors struct {
+	Errors []string `json:"errors"`
+}
+
+func handleRegistryAuthErrorResponse(w http.ResponseWriter, code int, challenge string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", challenge)
+	w.WriteHeader(code)
+
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(registryErrors{
+		Errors: []string{message},
+	})
+}
+
+// requestedNamespace is the namespace of the modules a protocol request is
+// for, it is empty for a list or search across every namespace.
+func requestedNamespace(r *http.Request) string {
+	if ns := mux.Vars(r)["namespace"]; ns != "" {
+		return ns
+	}
+
+	return r.URL.Query().Get("namespace")
+}
+
+type registryAuthenticator interface {
+	bearerAuthenticator
+	AuthorizeV1(dto registry.AuthorizeV1DTO) (registry.AuthorizeV1Response, error)
+}
+
+// registryAuthMiddleware requires the token terraform sends from its
+// `credentials` block to be able to read the requested namespace, through its
+// scopes or a role in the namespace. Requests across every namespace need a
+// token that can read them all.
+func registryAuthMiddleware(a registryAuthenticator, l zerolog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+
+			if !ok {
+				handleRegistryAuthErrorResponse(w, http.StatusUnauthorized, `Bearer realm="ymir"`, "Unauthorized: a token is required, add one to a credentials block for this host in the terraform cli config")
+				return
+			}
+
+			actor, status, err := authenticateBearer(a, token)
+
+			if err != nil {
+				l.Error().Err(err).Str("action", "Auth.AuthenticateRegistryToken").Msg("command failed")
+
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if status != registry.STATUS_OKAY {
+				handleRegistryAuthErrorResponse(w, http.StatusUnauthorized, `Bearer realm="ymir", error="invalid_token"`, "Unauthorized: the token is invalid, expired or revoked")
+				return
+			}
+
+			ns := requestedNamespace(r)
+			scope := registry.TokenScopes.ModulesRead
+
+			if ns != "" {
+				scope = registry.NamespaceReadScope(ns)
+			}
+
+			allowed := actor.Allows(registry.TokenScopes.ModulesRead, ns)
+
+			if !allowed && ns != "" {
+				res, err := a.AuthorizeV1(registry.AuthorizeV1DTO{
+					Actor:     actor,
+					Scope:     registry.TokenScopes.ModulesRead,
+					Namespace: ns,
+				})
+
+				if err != nil {
+					l.Error().Err(err).Str("action", "Auth.AuthorizeRegistryToken").Msg("command failed")
+
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+
+				allowed = res.Status == registry.STATUS_OKAY
+			}
+
+			if !allowed {
+				challenge := fmt.Sprintf(`Bearer realm="ymir", error="insufficient_scope", scope="%s"`, scope)
+				handleRegistryAuthErrorResponse(w, http.StatusForbidden, challenge, fmt.Sprintf("Forbidden: the token needs the %s scope", scope))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(registry.ContextWithActor(r.Context(), actor)))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/svartlfheim/ymir/internal/health"
+)
+
+// HealthController answers the probes of an orchestrator such as Kubernetes,
+// they aren't authenticated.
+type HealthController struct {
+	checks  []health.Check
+	timeout time.Duration
+}
+
+// Healthz is OK while the process is serving requests at all.
+func (c *HealthController) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(health.Report{
+		Status: health.StatusOK,
+		Checks: map[string]health.Result{},
+	})
+}
+
+// Readyz is OK when every dependency needed to serve requests is, otherwise it
+// is a 503 so that no requests are routed here until they are.
+func (c *HealthController) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := health.Run(r.Context(), c.checks, c.timeout)
+	code := http.StatusOK
+
+	if !report.OK() {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(report)
+}
+
+func (c *HealthController) RegisterRoutes(r muxRouter) {
+	r.HandleFunc("/healthz", c.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", c.Readyz).Methods("GET")
+}
+
+// NewHealthController runs the checks on each readiness probe, a check that
+// takes longer than the timeout fails.
+func NewHealthController(checks []health.Check, timeout time.Duration) *HealthController {
+	return &HealthController{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
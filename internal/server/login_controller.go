@@ -0,0 +1,241 @@
+package server
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Log in to Ymir</title>
+	<style>
+		body { font-family: sans-serif; max-width: 24em; margin: 4em auto; }
+		label, input, button { display: block; width: 100%; margin-bottom: 0.5em; }
+		.error { color: #b00020; }
+	</style>
+</head>
+<body>
+	<h1>Log in to Ymir</h1>
+	{{range .Errors}}<p class="error">{{.}}</p>{{end}}
+	{{if .Request}}
+	<p>Terraform is asking for a token to use with this registry.</p>
+	<form method="post" action="/oauth/authorization">
+		<input type="hidden" name="client_id" value="{{.Request.ClientId}}">
+		<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+		<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+		<input type="hidden" name="state" value="{{.Request.State}}">
+		<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+		<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+		<label for="username">Username</label>
+		<input id="username" name="username" value="{{.Username}}" autocomplete="username" autofocus required>
+		<label for="password">Password</label>
+		<input id="password" name="password" type="password" autocomplete="current-password" required>
+		<button type="submit">Log in</button>
+	</form>
+	{{end}}
+</body>
+</html>
+`))
+
+type loginPageData struct {
+	// The form is only shown for a request that can complete
+	Request  *registry.LoginRequestV1DTO
+	Username string
+	Errors   []string
+}
+
+// LoginController lets `terraform login` obtain a token, with the OAuth
+// authorization code flow advertised as login.v1 in service discovery.
+type LoginController struct {
+	logger  zerolog.Logger
+	cb      *registry.CommandBus
+	auditor requestAuditor
+}
+
+func loginRequestFromForm(r *http.Request) registry.LoginRequestV1DTO {
+	return registry.LoginRequestV1DTO{
+		ClientId:            r.FormValue("client_id"),
+		RedirectURI:         r.FormValue("redirect_uri"),
+		ResponseType:        r.FormValue("response_type"),
+		State:               r.FormValue("state"),
+		CodeChallenge:       r.FormValue("code_challenge"),
+		CodeChallengeMethod: r.FormValue("code_challenge_method"),
+	}
+}
+
+func (c *LoginController) renderLoginPage(w http.ResponseWriter, code int, data loginPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(code)
+
+	if err := loginPage.Execute(w, data); err != nil {
+		c.logger.Error().Err(err).Str("action", "Login.RenderLoginPage").Msg("failed to render login page")
+	}
+}
+
+func validationMessages(errs []registry.ValidationError) []string {
+	msgs := []string{}
+
+	for _, e := range errs {
+		msgs = append(msgs, e.Field+" "+e.Message)
+	}
+
+	return msgs
+}
+
+func (c *LoginController) ShowLogin(w http.ResponseWriter, r *http.Request) {
+	req := loginRequestFromForm(r)
+	res := c.cb.ValidateLoginRequestV1(req)
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		c.renderLoginPage(w, http.StatusOK, loginPageData{
+			Request: &req,
+		})
+	case registry.STATUS_INVALID:
+		c.renderLoginPage(w, http.StatusBadRequest, loginPageData{
+			Errors: append([]string{"This login request can't be completed, run terraform login again."}, validationMessages(res.ValidationErrors)...),
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (c *LoginController) Login(w http.ResponseWriter, r *http.Request) {
+	req := loginRequestFromForm(r)
+	username := r.FormValue("username")
+
+	res, err := c.cb.AuthorizeLoginV1(registry.AuthorizeLoginV1DTO{
+		LoginRequestV1DTO: req,
+		Username:          username,
+		Password:          r.FormValue("password"),
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Login.Login").Msg("command failed")
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(res)
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		http.Redirect(w, r, res.RedirectURL, http.StatusFound)
+	case registry.STATUS_UNAUTHORIZED:
+		c.renderLoginPage(w, http.StatusUnauthorized, loginPageData{
+			Request:  &req,
+			Username: username,
+			Errors:   []string{"The username or password is incorrect."},
+		})
+	case registry.STATUS_INVALID:
+		// Only a missing username or password can be corrected in the form
+		if c.cb.ValidateLoginRequestV1(req).Status == registry.STATUS_OKAY {
+			c.renderLoginPage(w, http.StatusBadRequest, loginPageData{
+				Request:  &req,
+				Username: username,
+				Errors:   validationMessages(res.ValidationErrors),
+			})
+
+			return
+		}
+
+		c.renderLoginPage(w, http.StatusBadRequest, loginPageData{
+			Errors: append([]string{"This login request can't be completed, run terraform login again."}, validationMessages(res.ValidationErrors)...),
+		})
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Login.Login").Msg("unhandled response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in,omitempty"`
+}
+
+type tokenErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func (c *LoginController) Token(w http.ResponseWriter, r *http.Request) {
+	res, err := c.cb.ExchangeLoginCodeV1(registry.ExchangeLoginCodeV1DTO{
+		GrantType:    r.PostFormValue("grant_type"),
+		Code:         r.PostFormValue("code"),
+		ClientId:     r.PostFormValue("client_id"),
+		RedirectURI:  r.PostFormValue("redirect_uri"),
+		CodeVerifier: r.PostFormValue("code_verifier"),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Login.Token").Msg("command failed")
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(res)
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		body := tokenResponse{
+			AccessToken: res.Secret,
+			TokenType:   "bearer",
+		}
+
+		if res.APIToken.ExpiresAt != nil {
+			body.ExpiresIn = int(res.APIToken.ExpiresAt.Sub(res.APIToken.CreatedAt).Seconds())
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(body)
+	case registry.STATUS_INVALID:
+		code := http.StatusBadRequest
+
+		if res.Error == registry.OAuthErrors.InvalidClient {
+			code = http.StatusUnauthorized
+		}
+
+		w.WriteHeader(code)
+
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(tokenErrorResponse{
+			Error: res.Error,
+		})
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Login.Token").Msg("unhandled response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (c *LoginController) RegisterRoutes(r muxRouter) {
+	r.HandleFunc("/oauth/authorization", c.ShowLogin).Methods("GET")
+	r.HandleFunc("/oauth/authorization", c.Login).Methods("POST")
+	r.HandleFunc("/oauth/token", c.Token).Methods("POST")
+}
+
+func NewLoginController(l zerolog.Logger, cb *registry.CommandBus, a requestAuditor) *LoginController {
+	return &LoginController{
+		logger:  l,
+		cb:      cb,
+		auditor: a,
+	}
+}
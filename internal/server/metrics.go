@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type requestObserver interface {
+	ObserveRequest(method string, route string, code int, took time.Duration)
+}
+
+type downloadCounter interface {
+	ModuleDownloaded(namespace string, name string, provider string)
+}
+
+// statusRecorder keeps the status code a handler responds with.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// MetricsMiddleware observes each request by the template of the route it
+// matched, so that a route's requests are counted together whatever their
+// path parameters.
+func MetricsMiddleware(o requestObserver) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+
+			next.ServeHTTP(rec, r)
+
+			o.ObserveRequest(r.Method, routeTemplate(r), rec.code, time.Since(started))
+		})
+	}
+}
+
+// MetricsController serves the metrics on the registry's own port, when they
+// aren't served on a port of their own.
+type MetricsController struct {
+	handler http.Handler
+}
+
+func (c *MetricsController) RegisterRoutes(r muxRouter) {
+	r.HandleFunc("/metrics", c.handler.ServeHTTP).Methods("GET")
+}
+
+func NewMetricsController(h http.Handler) *MetricsController {
+	return &MetricsController{
+		handler: h,
+	}
+}
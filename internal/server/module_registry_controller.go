@@ -1,11 +1,16 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/archive"
 	"github.com/svartlfheim/ymir/internal/registry"
 )
 
@@ -13,6 +18,10 @@ type ModuleRegistryController struct {
 	logger     zerolog.Logger
 	moduleRepo registry.ModuleRepository
 	cb         *registry.CommandBus
+	// signer is set when the protocol endpoints require a token, the archive
+	// URLs are signed then, as terraform doesn't send it when downloading
+	signer    *archive.URLSigner
+	downloads downloadCounter
 }
 
 type ModuleVersionListVersionItem struct {
@@ -28,9 +37,79 @@ type ModuleVersionList struct {
 	Modules []ModuleVersionListItem `json:"modules"`
 }
 
+// PublicModule is a module at its latest version, shaped as the public
+// registry returns them. Ymir doesn't track owners, descriptions or
+// downloads, they are always empty.
+type PublicModule struct {
+	Id          string    `json:"id"`
+	Owner       string    `json:"owner"`
+	Namespace   string    `json:"namespace"`
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Provider    string    `json:"provider"`
+	Description string    `json:"description"`
+	Source      string    `json:"source"`
+	PublishedAt time.Time `json:"published_at"`
+	Downloads   int       `json:"downloads"`
+	Verified    bool      `json:"verified"`
+}
+
+type PublicModuleDetail struct {
+	PublicModule
+	Providers []string `json:"providers"`
+	Versions  []string `json:"versions"`
+}
+
+type PublicModuleListMeta struct {
+	Limit         int    `json:"limit"`
+	CurrentOffset int    `json:"current_offset"`
+	NextOffset    *int   `json:"next_offset,omitempty"`
+	PrevOffset    *int   `json:"prev_offset,omitempty"`
+	NextURL       string `json:"next_url,omitempty"`
+	PrevURL       string `json:"prev_url,omitempty"`
+}
+
+type PublicModuleList struct {
+	Meta    PublicModuleListMeta `json:"meta"`
+	Modules []PublicModule       `json:"modules"`
+}
+
+const defaultModuleListLimit = 15
+const maxModuleListLimit = 100
+
+func toPublicModule(p registry.PublishedModule) PublicModule {
+	return PublicModule{
+		Id:          fmt.Sprintf("%s/%s/%s/%s", p.Module.Namespace, p.Module.Name, p.Module.Provider, p.Latest.Version),
+		Namespace:   p.Module.Namespace,
+		Name:        p.Module.Name,
+		Version:     p.Latest.Version,
+		Provider:    p.Module.Provider,
+		Source:      p.Module.RepositoryURL,
+		PublishedAt: p.Latest.CreatedAt,
+	}
+}
+
+// pageURL is the request's URL at another offset, for the links between
+// pages.
+func pageURL(r *http.Request, offset int) string {
+	q := r.URL.Query()
+	q.Set("offset", strconv.Itoa(offset))
+
+	return r.URL.Path + "?" + q.Encode()
+}
+
+func queryInt(r *http.Request, key string, def int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(key))
+
+	if err != nil || v < 0 {
+		return def
+	}
+
+	return v
+}
+
 func (c *ModuleRegistryController) WellKnown(w http.ResponseWriter, r *http.Request) {
-	cmd := registry.ServiceDiscoveryCommand{}
-	resp := cmd.Handle()
+	resp := c.cb.ServiceDiscoveryV1()
 
 	w.Header().Set("Content-Type", "application/json")
 
@@ -47,19 +126,66 @@ func (c *ModuleRegistryController) WellKnown(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(resp.Body)
 }
 
+func writeModuleVersionList(w http.ResponseWriter, fqn registry.ModuleFQN, versions []string) {
+	item := ModuleVersionListItem{
+		Source:   fmt.Sprintf("%s/%s/%s", fqn.Namespace, fqn.Name, fqn.Provider),
+		Versions: []ModuleVersionListVersionItem{},
+	}
+
+	for _, v := range versions {
+		item.Versions = append(item.Versions, ModuleVersionListVersionItem{
+			Version: v,
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(ModuleVersionList{
+		Modules: []ModuleVersionListItem{item},
+	})
+}
+
+// listUpstreamModuleVersions answers for a module that doesn't exist locally,
+// from the upstream its namespace is proxied from.
+func (c *ModuleRegistryController) listUpstreamModuleVersions(ctx context.Context, w http.ResponseWriter, fqn registry.ModuleFQN) {
+	res, err := c.cb.ListUpstreamModuleVersionsV1(ctx, registry.ListUpstreamModuleVersionsV1DTO{
+		FQN: fqn,
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "ModuleRegistry.ListModuleVersions").Msg("upstream command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		writeModuleVersionList(w, fqn, res.Module.Versions)
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+	case registry.STATUS_FAILED:
+		w.WriteHeader(http.StatusBadGateway)
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "ModuleRegistry.ListModuleVersions").Msg("unhandled upstream response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
 func (c *ModuleRegistryController) ListModuleVersions(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	ns := params["namespace"]
-	name := params["name"]
-	provider := params["provider"]
+	fqn := registry.ModuleFQN{
+		Name:      params["name"],
+		Namespace: params["namespace"],
+		Provider:  params["provider"],
+	}
 
-	res, err := c.cb.ListModuleVersionsV1ByFqn(registry.ListModuleVersionsByFqnV1DTO{
-		FQN: registry.ModuleFQN{
-			Name:      name,
-			Namespace: ns,
-			Provider:  provider,
-		},
+	res, err := c.cb.ListModuleVersionsV1ByFqn(r.Context(), registry.ListModuleVersionsByFqnV1DTO{
+		FQN: fqn,
 	})
 
 	w.Header().Set("Content-Type", "application/json")
@@ -74,21 +200,16 @@ func (c *ModuleRegistryController) ListModuleVersions(w http.ResponseWriter, r *
 
 	switch res.Status {
 	case registry.STATUS_OKAY:
-		list := []ModuleVersionListVersionItem{}
+		versions := []string{}
 
 		for _, v := range res.List {
-			list = append(list, ModuleVersionListVersionItem{
-				Version: v.Version,
-			})
+			versions = append(versions, v.Version)
 		}
 
-		w.WriteHeader(http.StatusOK)
-
-		//nolint:errcheck
-		json.NewEncoder(w).Encode(list)
+		writeModuleVersionList(w, fqn, versions)
 		return
 	case registry.STATUS_NOT_FOUND:
-		w.WriteHeader(http.StatusNotFound)
+		c.listUpstreamModuleVersions(r.Context(), w, fqn)
 		return
 	default:
 		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.GetModule").Msg("unhandle response")
@@ -98,6 +219,61 @@ func (c *ModuleRegistryController) ListModuleVersions(w http.ResponseWriter, r *
 	}
 }
 
+// archiveLocation signs the location of an archive, when the protocol
+// endpoints require a token.
+func (c *ModuleRegistryController) archiveLocation(location string) string {
+	if c.signer == nil {
+		return location
+	}
+
+	return c.signer.Sign(location, time.Now())
+}
+
+// downloadUpstreamModule answers for a module version that can't be served
+// locally, when its namespace is proxied. It is false when the upstream
+// doesn't have it either, the local response stands then.
+func (c *ModuleRegistryController) downloadUpstreamModule(ctx context.Context, w http.ResponseWriter, ns string, name string, provider string, version string) bool {
+	res, err := c.cb.DownloadUpstreamModuleV1(ctx, registry.DownloadUpstreamModuleV1DTO{
+		FQN: registry.ModuleVersionFQN{
+			ModuleFQN: registry.ModuleFQN{
+				Namespace: ns,
+				Name:      name,
+				Provider:  provider,
+			},
+			Version: version,
+		},
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "ModuleRegistry.DownloadModule").Msg("upstream command failed")
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return true
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		location := archive.DownloadPath(res.ModuleVersion.StorageKey)
+
+		if res.ModuleVersion.Subdir != "" {
+			location += "//" + res.ModuleVersion.Subdir
+		}
+
+		w.Header().Set("X-Terraform-Get", c.archiveLocation(location))
+		w.WriteHeader(http.StatusNoContent)
+		c.downloads.ModuleDownloaded(ns, name, provider)
+	case registry.STATUS_NOT_FOUND:
+		return false
+	case registry.STATUS_FAILED:
+		w.WriteHeader(http.StatusBadGateway)
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "ModuleRegistry.DownloadModule").Msg("unhandled upstream response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	return true
+}
+
 func (c *ModuleRegistryController) DownloadModule(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
@@ -113,7 +289,7 @@ func (c *ModuleRegistryController) DownloadModule(w http.ResponseWriter, r *http
 		Version:   version,
 	}
 
-	resp, err := cmd.Handle(c.moduleRepo, registry.NewCommandValidator(c.logger), c.logger)
+	resp, err := cmd.Handle(r.Context(), c.moduleRepo, registry.NewCommandValidator(c.logger), c.logger)
 
 	w.Header().Set("Content-Type", "application/json")
 
@@ -124,6 +300,10 @@ func (c *ModuleRegistryController) DownloadModule(w http.ResponseWriter, r *http
 		return
 	}
 
+	if resp.Status != registry.STATUS_OKAY && c.downloadUpstreamModule(r.Context(), w, ns, name, provider, version) {
+		return
+	}
+
 	if resp.Status == registry.STATUS_NOT_FOUND {
 		c.logger.Debug().Str("version", version).Str("namespace", ns).Str("name", name).Str("provider", provider).Msg("module not found for download")
 		w.WriteHeader(http.StatusNotFound)
@@ -138,23 +318,202 @@ func (c *ModuleRegistryController) DownloadModule(w http.ResponseWriter, r *http
 		return
 	}
 
-	w.Header().Set("X-Terraform-Get", resp.LocationURI)
+	w.Header().Set("X-Terraform-Get", c.archiveLocation(resp.LocationURI))
 	w.WriteHeader(http.StatusNoContent)
+	c.downloads.ModuleDownloaded(ns, name, provider)
 
 	//nolint:errcheck
 	json.NewEncoder(w).Encode(params)
 }
 
+// listModules answers list and search requests, paged by the offset and
+// limit in the query string.
+func (c *ModuleRegistryController) listModules(w http.ResponseWriter, r *http.Request, namespace string, query string) {
+	limit := queryInt(r, "limit", defaultModuleListLimit)
+
+	if limit == 0 {
+		limit = defaultModuleListLimit
+	}
+
+	if limit > maxModuleListLimit {
+		limit = maxModuleListLimit
+	}
+
+	offset := queryInt(r, "offset", 0)
+
+	if namespace == "" {
+		namespace = r.URL.Query().Get("namespace")
+	}
+
+	res, err := c.cb.ListPublishedModulesV1(r.Context(), registry.ListPublishedModulesV1DTO{
+		Namespace: namespace,
+		Provider:  r.URL.Query().Get("provider"),
+		Query:     query,
+		ChunkOpts: registry.ChunkingOptions{
+			Size:   limit,
+			Offset: offset,
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil || res.Status != registry.STATUS_OKAY {
+		c.logger.Error().Err(err).Str("status", string(res.Status)).Str("action", "ModuleRegistry.ListModules").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	list := PublicModuleList{
+		Meta: PublicModuleListMeta{
+			Limit:         limit,
+			CurrentOffset: offset,
+		},
+		Modules: []PublicModule{},
+	}
+
+	for _, p := range res.List {
+		list.Modules = append(list.Modules, toPublicModule(p))
+	}
+
+	if res.HasMore {
+		next := offset + limit
+		list.Meta.NextOffset = &next
+		list.Meta.NextURL = pageURL(r, next)
+	}
+
+	if offset > 0 {
+		prev := offset - limit
+
+		if prev < 0 {
+			prev = 0
+		}
+
+		list.Meta.PrevOffset = &prev
+		list.Meta.PrevURL = pageURL(r, prev)
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(list)
+}
+
+func (c *ModuleRegistryController) ListModules(w http.ResponseWriter, r *http.Request) {
+	c.listModules(w, r, mux.Vars(r)["namespace"], "")
+}
+
+func (c *ModuleRegistryController) SearchModules(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+
+	if q == "" {
+		w.Header().Set("Content-Type", "application/json")
+		handleValidationErrorsResponse([]registry.ValidationError{
+			{
+				Field:   "q",
+				Rule:    "required",
+				Message: "the search query is required",
+			},
+		}, http.StatusBadRequest, w)
+
+		return
+	}
+
+	c.listModules(w, r, "", q)
+}
+
+func (c *ModuleRegistryController) showLatest(w http.ResponseWriter, r *http.Request) (registry.PublishedModule, bool) {
+	params := mux.Vars(r)
+
+	res, err := c.cb.ShowPublishedModuleV1(r.Context(), registry.ShowPublishedModuleV1DTO{
+		FQN: registry.ModuleFQN{
+			Namespace: params["namespace"],
+			Name:      params["name"],
+			Provider:  params["provider"],
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "ModuleRegistry.ShowLatest").Msg("command failed")
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return registry.PublishedModule{}, false
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		return res.Module, true
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "ModuleRegistry.ShowLatest").Msg("unhandled response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	return registry.PublishedModule{}, false
+}
+
+func (c *ModuleRegistryController) LatestModule(w http.ResponseWriter, r *http.Request) {
+	p, ok := c.showLatest(w, r)
+
+	if !ok {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(PublicModuleDetail{
+		PublicModule: toPublicModule(p),
+		Providers:    []string{p.Module.Provider},
+		Versions:     p.Versions,
+	})
+}
+
+// DownloadLatestModule redirects to the download of the latest version.
+func (c *ModuleRegistryController) DownloadLatestModule(w http.ResponseWriter, r *http.Request) {
+	p, ok := c.showLatest(w, r)
+
+	if !ok {
+		return
+	}
+
+	location := fmt.Sprintf("/v1/modules/%s/%s/%s/%s/download", p.Module.Namespace, p.Module.Name, p.Module.Provider, p.Latest.Version)
+
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusFound)
+}
+
 func (c *ModuleRegistryController) RegisterRoutes(r muxRouter) {
 	r.HandleFunc("/.well-known/terraform.json", c.WellKnown)
-	r.HandleFunc("/v1/modules/{namespace}/{name}/{provider}/versions", c.ListModuleVersions)
-	r.HandleFunc("/v1/modules/{namespace}/{name}/{provider}/{version}/download", c.DownloadModule)
+
+	modules := r.PathPrefix("/v1/modules").Subrouter()
+
+	if c.signer != nil {
+		modules.Use(registryAuthMiddleware(c.cb, c.logger))
+	}
+
+	modules.HandleFunc("", c.ListModules).Methods("GET")
+	modules.HandleFunc("/search", c.SearchModules).Methods("GET")
+	modules.HandleFunc("/{namespace}", c.ListModules).Methods("GET")
+	modules.HandleFunc("/{namespace}/{name}/{provider}", c.LatestModule).Methods("GET")
+	modules.HandleFunc("/{namespace}/{name}/{provider}/download", c.DownloadLatestModule).Methods("GET")
+	modules.HandleFunc("/{namespace}/{name}/{provider}/versions", c.ListModuleVersions)
+	modules.HandleFunc("/{namespace}/{name}/{provider}/{version}/download", c.DownloadModule)
 }
 
-func NewModuleRegistryController(l zerolog.Logger, moduleRepo registry.ModuleRepository, cb *registry.CommandBus) *ModuleRegistryController {
+// NewModuleRegistryController builds the controller for the terraform
+// protocol endpoints, they require a token when given a signer for the
+// archive URLs.
+func NewModuleRegistryController(l zerolog.Logger, moduleRepo registry.ModuleRepository, cb *registry.CommandBus, signer *archive.URLSigner, downloads downloadCounter) *ModuleRegistryController {
 	return &ModuleRegistryController{
 		logger:     l,
 		moduleRepo: moduleRepo,
 		cb:         cb,
+		signer:     signer,
+		downloads:  downloads,
 	}
 }
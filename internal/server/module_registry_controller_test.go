@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type downloadableModules struct {
+	registry.ModuleRepository
+	module   registry.Module
+	versions []registry.ModuleVersion
+}
+
+func (r downloadableModules) ByFQN(ctx context.Context, fqn registry.ModuleFQN) (registry.Module, error) {
+	if fqn != r.module.FQN() {
+		return registry.Module{}, registry.ErrResourceNotFound{Type: "Module", URI: fqn.String()}
+	}
+
+	return r.module, nil
+}
+
+func (r downloadableModules) VersionByFQN(ctx context.Context, fqn registry.ModuleVersionFQN) (registry.ModuleVersion, error) {
+	if fqn.ModuleFQN == r.module.FQN() {
+		for _, mv := range r.versions {
+			if mv.Version == fqn.Version {
+				return mv, nil
+			}
+		}
+	}
+
+	return registry.ModuleVersion{}, registry.ErrResourceNotFound{Type: "ModuleVersion", URI: fqn.String()}
+}
+
+type recordedDownloads struct {
+	modules []string
+}
+
+func (d *recordedDownloads) ModuleDownloaded(namespace string, name string, provider string) {
+	d.modules = append(d.modules, namespace+"/"+name+"/"+provider)
+}
+
+func Test_ModuleRegistryController_DownloadModule(t *testing.T) {
+	repo := downloadableModules{
+		module: registry.Module{
+			Id:        "5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10",
+			Name:      "vpc",
+			Namespace: "platform",
+			Provider:  "aws",
+		},
+		versions: []registry.ModuleVersion{
+			{
+				Id:          "0d3e2d55-57bb-4ac2-8f4b-8e8a3d1e7f01",
+				Version:     "1.0.0",
+				ModuleId:    "5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10",
+				DownloadURL: "/archives/modules/platform/vpc/aws/1.0.0.tar.gz",
+				Status:      registry.VersionStatuses.Ready,
+			},
+			{
+				Id:       "8c6f0f0e-3f43-4b8e-a3a4-6d1bb5c1d602",
+				Version:  "1.1.0",
+				ModuleId: "5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10",
+				Status:   registry.VersionStatuses.Pending,
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		code     int
+		location string
+	}{
+		{
+			name:     "ready version",
+			path:     "/v1/modules/platform/vpc/aws/1.0.0/download",
+			code:     http.StatusNoContent,
+			location: "/archives/modules/platform/vpc/aws/1.0.0.tar.gz",
+		},
+		{
+			name: "version still building",
+			path: "/v1/modules/platform/vpc/aws/1.1.0/download",
+			code: http.StatusNotFound,
+		},
+		{
+			name: "missing version",
+			path: "/v1/modules/platform/vpc/aws/2.0.0/download",
+			code: http.StatusNotFound,
+		},
+	}
+
+	downloads := &recordedDownloads{}
+	cb := registry.NewCommandBus(registry.WithLogger(zerolog.Nop()))
+	router := mux.NewRouter()
+	NewModuleRegistryController(zerolog.Nop(), repo, cb, nil, downloads).RegisterRoutes(router)
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+
+			assert.Equal(tt, test.code, w.Code)
+			assert.Equal(tt, test.location, w.Header().Get("X-Terraform-Get"))
+		})
+	}
+
+	assert.Equal(t, []string{"platform/vpc/aws"}, downloads.modules)
+}
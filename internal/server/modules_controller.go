@@ -20,7 +20,7 @@ type requestAuditor interface {
 }
 
 func (c *ModulesController) ListModules(w http.ResponseWriter, r *http.Request) {
-	res, err := c.cb.ListModulesV1FromDTO(registry.ListModulesV1DTO{})
+	res, err := actingBus(r, c.cb).ListModulesV1FromDTO(r.Context(), registry.ListModulesV1DTO{})
 
 	if err != nil {
 		c.logger.Error().Err(err).Str("action", "Modules.ListModules").Msg("command failed")
@@ -30,12 +30,15 @@ func (c *ModulesController) ListModules(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	go c.auditor.Record(res)
+	c.auditor.Record(attributed(r, res))
 
 	switch res.Status {
 	case registry.STATUS_OKAY:
 		handleResourceResponse(res.List, http.StatusOK, w)
 		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
 	default:
 		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.ListModules").Msg("unhandled response")
 
@@ -56,7 +59,7 @@ func (c *ModulesController) PostModule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := c.cb.AddModuleV1FromDTO(dto)
+	res, err := actingBus(r, c.cb).AddModuleV1FromDTO(r.Context(), dto)
 
 	if err != nil {
 		c.logger.Error().Err(err).Str("action", "Modules.PostModule").Msg("command failed")
@@ -66,7 +69,7 @@ func (c *ModulesController) PostModule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	go c.auditor.Record(res)
+	c.auditor.Record(attributed(r, res))
 
 	switch res.Status {
 	case registry.STATUS_INVALID:
@@ -75,6 +78,9 @@ func (c *ModulesController) PostModule(w http.ResponseWriter, r *http.Request) {
 	case registry.STATUS_CREATED:
 		handleResourceResponse(res.Module, http.StatusCreated, w)
 		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
 	default:
 		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.PostModule").Msg("unhandled response")
 
@@ -87,7 +93,7 @@ func (c *ModulesController) GetModule(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
 
-	res, err := c.cb.ShowModuleV1ByID(registry.ShowModuleV1DTO{
+	res, err := actingBus(r, c.cb).ShowModuleV1ByID(r.Context(), registry.ShowModuleV1DTO{
 		Id: id,
 	})
 
@@ -99,7 +105,7 @@ func (c *ModulesController) GetModule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	go c.auditor.Record(res)
+	c.auditor.Record(attributed(r, res))
 
 	switch res.Status {
 	case registry.STATUS_INVALID:
@@ -111,6 +117,9 @@ func (c *ModulesController) GetModule(w http.ResponseWriter, r *http.Request) {
 	case registry.STATUS_NOT_FOUND:
 		w.WriteHeader(http.StatusNotFound)
 		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
 	default:
 		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.GetModule").Msg("unhandled response")
 
@@ -119,6 +128,93 @@ func (c *ModulesController) GetModule(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (c *ModulesController) ResolveModuleVersion(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := actingBus(r, c.cb).ResolveModuleVersionV1(r.Context(), registry.ResolveModuleVersionV1DTO{
+		ModuleId:   params["id"],
+		Constraint: r.URL.Query().Get("constraint"),
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Modules.ResolveModuleVersion").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusBadRequest, w)
+		return
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.Resolution, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.ResolveModuleVersion").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *ModulesController) PatchModule(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	dto := registry.UpdateModuleV1DTO{}
+	err := json.NewDecoder(r.Body).Decode(&dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Modules.PatchModule").Msg("failed to parse request body")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	dto.Id = params["id"]
+
+	res, err := actingBus(r, c.cb).UpdateModuleV1(r.Context(), dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Modules.PatchModule").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusUnprocessableEntity, w)
+		return
+	case registry.STATUS_MODIFIED:
+		handleResourceResponse(res.Module, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.PatchModule").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (c *ModulesController) DeleteModule(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
@@ -136,7 +232,7 @@ func (c *ModulesController) DeleteModule(w http.ResponseWriter, r *http.Request)
 	c.logger.Info().Bool("should", dto.DeleteVersions).Msg("delete versions")
 	dto.Id = id
 
-	res, err := c.cb.DeleteModuleV1ById(dto)
+	res, err := actingBus(r, c.cb).DeleteModuleV1ById(r.Context(), dto)
 
 	if err != nil {
 		c.logger.Error().Err(err).Str("action", "Modules.DeleteModule").Msg("command failed")
@@ -146,7 +242,7 @@ func (c *ModulesController) DeleteModule(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	go c.auditor.Record(res)
+	c.auditor.Record(attributed(r, res))
 
 	switch res.Status {
 	case registry.STATUS_INVALID:
@@ -158,6 +254,9 @@ func (c *ModulesController) DeleteModule(w http.ResponseWriter, r *http.Request)
 	case registry.STATUS_NOT_FOUND:
 		w.WriteHeader(http.StatusNotFound)
 		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
 	default:
 		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.GetModule").Msg("unhandled response")
 
@@ -174,7 +273,7 @@ func (c *ModulesController) ListModuleVersions(w http.ResponseWriter, r *http.Re
 		ModuleId: moduleId,
 	}
 
-	res, err := c.cb.ListModuleVersionsV1ById(dto)
+	res, err := actingBus(r, c.cb).ListModuleVersionsV1ById(r.Context(), dto)
 
 	if err != nil {
 		c.logger.Error().Err(err).Str("action", "Modules.ListModuleVersions").Msg("command failed")
@@ -184,7 +283,7 @@ func (c *ModulesController) ListModuleVersions(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	go c.auditor.Record(res)
+	c.auditor.Record(attributed(r, res))
 
 	switch res.Status {
 	case registry.STATUS_OKAY:
@@ -193,6 +292,9 @@ func (c *ModulesController) ListModuleVersions(w http.ResponseWriter, r *http.Re
 	case registry.STATUS_NOT_FOUND:
 		w.WriteHeader(http.StatusNotFound)
 		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
 	default:
 		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.GetModule").Msg("unhandled response")
 
@@ -217,7 +319,7 @@ func (c *ModulesController) CreateModuleVersion(w http.ResponseWriter, r *http.R
 
 	dto.ModuleId = moduleId
 
-	res, err := c.cb.AddModuleVersionV1ForModuleId(dto)
+	res, err := actingBus(r, c.cb).AddModuleVersionV1ForModuleId(r.Context(), dto)
 
 	if err != nil {
 		c.logger.Error().Err(err).Str("action", "Modules.ListModuleVersions").Msg("command failed")
@@ -227,7 +329,7 @@ func (c *ModulesController) CreateModuleVersion(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	go c.auditor.Record(res)
+	c.auditor.Record(attributed(r, res))
 
 	switch res.Status {
 	case registry.STATUS_INVALID:
@@ -236,6 +338,9 @@ func (c *ModulesController) CreateModuleVersion(w http.ResponseWriter, r *http.R
 	case registry.STATUS_CREATED:
 		handleResourceResponse(res.ModuleVersion, http.StatusCreated, w)
 		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
 	default:
 		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.ListModuleVersions").Msg("unhandled response")
 
@@ -244,11 +349,56 @@ func (c *ModulesController) CreateModuleVersion(w http.ResponseWriter, r *http.R
 	}
 }
 
+func (c *ModulesController) PublishModuleVersion(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	dto := registry.PublishModuleVersionV1DTO{}
+	err := json.NewDecoder(r.Body).Decode(&dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Modules.PublishModuleVersion").Msg("failed to parse request body")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	dto.ModuleId = params["module_id"]
+
+	res, err := actingBus(r, c.cb).PublishModuleVersionV1(r.Context(), dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Modules.PublishModuleVersion").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusUnprocessableEntity, w)
+		return
+	case registry.STATUS_CREATED:
+		handleResourceResponse(res.ModuleVersion, http.StatusAccepted, w)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.PublishModuleVersion").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (c *ModulesController) GetModuleVersion(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
 
-	res, err := c.cb.ShowModuleVersionV1ById(registry.ShowModuleVersionV1DTO{
+	res, err := actingBus(r, c.cb).ShowModuleVersionV1ById(r.Context(), registry.ShowModuleVersionV1DTO{
 		Id: id,
 	})
 
@@ -260,7 +410,7 @@ func (c *ModulesController) GetModuleVersion(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	go c.auditor.Record(res)
+	c.auditor.Record(attributed(r, res))
 
 	switch res.Status {
 	case registry.STATUS_INVALID:
@@ -272,6 +422,9 @@ func (c *ModulesController) GetModuleVersion(w http.ResponseWriter, r *http.Requ
 	case registry.STATUS_NOT_FOUND:
 		w.WriteHeader(http.StatusNotFound)
 		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
 	default:
 		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.GetModuleVersion").Msg("unhandled response")
 
@@ -280,11 +433,50 @@ func (c *ModulesController) GetModuleVersion(w http.ResponseWriter, r *http.Requ
 	}
 }
 
+func (c *ModulesController) GetModuleVersionInterface(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	res, err := actingBus(r, c.cb).ShowModuleVersionInterfaceV1(r.Context(), registry.ShowModuleVersionInterfaceV1DTO{
+		Id: id,
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Modules.GetModuleVersionInterface").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusBadRequest, w)
+		return
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.Interface, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.GetModuleVersionInterface").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (c *ModulesController) DeleteModuleVersion(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
 
-	res, err := c.cb.DeleteModuleVersionV1ById(registry.DeleteModuleVersionV1DTO{
+	res, err := actingBus(r, c.cb).DeleteModuleVersionV1ById(r.Context(), registry.DeleteModuleVersionV1DTO{
 		Id: id,
 	})
 
@@ -296,7 +488,7 @@ func (c *ModulesController) DeleteModuleVersion(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	go c.auditor.Record(res)
+	c.auditor.Record(attributed(r, res))
 
 	switch res.Status {
 	case registry.STATUS_INVALID:
@@ -308,6 +500,9 @@ func (c *ModulesController) DeleteModuleVersion(w http.ResponseWriter, r *http.R
 	case registry.STATUS_NOT_FOUND:
 		w.WriteHeader(http.StatusNotFound)
 		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
 	default:
 		c.logger.Error().Str("status", string(res.Status)).Str("action", "Modules.DeleteModuleVersion").Msg("unhandled response")
 
@@ -318,18 +513,22 @@ func (c *ModulesController) DeleteModuleVersion(w http.ResponseWriter, r *http.R
 
 func (c *ModulesController) RegisterRoutes(r muxRouter) {
 	api := r.PathPrefix("/api").Subrouter()
-	api.Use(apiMiddleware)
+	api.Use(apiMiddleware, tokenAuthMiddleware(c.cb, c.logger))
 
 	api.HandleFunc("/v1/modules", c.ListModules).Methods("GET")
 	api.HandleFunc("/v1/modules", c.PostModule).Methods("POST")
 	api.HandleFunc("/v1/modules/{id}", c.GetModule).Methods("GET")
+	api.HandleFunc("/v1/modules/{id}", c.PatchModule).Methods("PATCH")
 	api.HandleFunc("/v1/modules/{id}", c.DeleteModule).Methods("DELETE")
+	api.HandleFunc("/v1/modules/{id}/resolve", c.ResolveModuleVersion).Methods("GET")
 
 	api.HandleFunc("/v1/modules/{module_id}/versions", c.ListModuleVersions).Methods("GET")
 	api.HandleFunc("/v1/modules/{module_id}/versions", c.CreateModuleVersion).Methods("POST")
+	api.HandleFunc("/v1/modules/{module_id}/publish", c.PublishModuleVersion).Methods("POST")
 
 	api.HandleFunc("/v1/module-versions/{id}", c.GetModuleVersion).Methods("GET")
 	api.HandleFunc("/v1/module-versions/{id}", c.DeleteModuleVersion).Methods("DELETE")
+	api.HandleFunc("/v1/module-versions/{id}/interface", c.GetModuleVersionInterface).Methods("GET")
 }
 
 func NewModulesController(l zerolog.Logger, cb *registry.CommandBus, a requestAuditor) *ModulesController {
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+// NamespacesController manages namespaces, and the roles granted in them.
+type NamespacesController struct {
+	logger  zerolog.Logger
+	cb      *registry.CommandBus
+	auditor requestAuditor
+}
+
+// namespaceWithGrants is a namespace as it is shown, with every role granted
+// in it.
+type namespaceWithGrants struct {
+	registry.Namespace
+	Grants []registry.RoleGrant `json:"grants"`
+}
+
+func (c *NamespacesController) ListNamespaces(w http.ResponseWriter, r *http.Request) {
+	res, err := actingBus(r, c.cb).ListNamespacesV1()
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Namespaces.ListNamespaces").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.List, http.StatusOK, w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Namespaces.ListNamespaces").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *NamespacesController) PostNamespace(w http.ResponseWriter, r *http.Request) {
+	dto := registry.CreateNamespaceV1DTO{}
+	err := json.NewDecoder(r.Body).Decode(&dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Namespaces.PostNamespace").Msg("failed to parse request body")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	res, err := actingBus(r, c.cb).CreateNamespaceV1(dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Namespaces.PostNamespace").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusUnprocessableEntity, w)
+		return
+	case registry.STATUS_CREATED:
+		handleResourceResponse(res.Namespace, http.StatusCreated, w)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Namespaces.PostNamespace").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *NamespacesController) GetNamespace(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := actingBus(r, c.cb).ShowNamespaceV1(registry.ShowNamespaceV1DTO{
+		Name: params["namespace"],
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Namespaces.GetNamespace").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		handleResourceResponse(namespaceWithGrants{
+			Namespace: res.Namespace,
+			Grants:    res.Grants,
+		}, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Namespaces.GetNamespace").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *NamespacesController) DeleteNamespace(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := actingBus(r, c.cb).DeleteNamespaceV1(r.Context(), registry.DeleteNamespaceV1DTO{
+		Name: params["namespace"],
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Namespaces.DeleteNamespace").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusConflict, w)
+		return
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.Namespace, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Namespaces.DeleteNamespace").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *NamespacesController) PostGrant(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	dto := registry.GrantRoleV1DTO{}
+	err := json.NewDecoder(r.Body).Decode(&dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Namespaces.PostGrant").Msg("failed to parse request body")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	dto.Namespace = params["namespace"]
+
+	res, err := actingBus(r, c.cb).GrantRoleV1(dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Namespaces.PostGrant").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusUnprocessableEntity, w)
+		return
+	case registry.STATUS_CREATED:
+		handleResourceResponse(res.Grant, http.StatusCreated, w)
+		return
+	case registry.STATUS_MODIFIED:
+		handleResourceResponse(res.Grant, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Namespaces.PostGrant").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *NamespacesController) DeleteGrant(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := actingBus(r, c.cb).RevokeRoleV1(registry.RevokeRoleV1DTO{
+		Namespace: params["namespace"],
+		Subject:   params["subject"],
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Namespaces.DeleteGrant").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusBadRequest, w)
+		return
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.Grant, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Namespaces.DeleteGrant").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *NamespacesController) RegisterRoutes(r muxRouter) {
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(apiMiddleware, tokenAuthMiddleware(c.cb, c.logger))
+
+	api.HandleFunc("/v1/namespaces", c.ListNamespaces).Methods("GET")
+	api.HandleFunc("/v1/namespaces", c.PostNamespace).Methods("POST")
+	api.HandleFunc("/v1/namespaces/{namespace}", c.GetNamespace).Methods("GET")
+	api.HandleFunc("/v1/namespaces/{namespace}", c.DeleteNamespace).Methods("DELETE")
+	api.HandleFunc("/v1/namespaces/{namespace}/grants", c.PostGrant).Methods("POST")
+	api.HandleFunc("/v1/namespaces/{namespace}/grants/{subject}", c.DeleteGrant).Methods("DELETE")
+}
+
+func NewNamespacesController(l zerolog.Logger, cb *registry.CommandBus, a requestAuditor) *NamespacesController {
+	return &NamespacesController{
+		logger:  l,
+		cb:      cb,
+		auditor: a,
+	}
+}
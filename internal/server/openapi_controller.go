@@ -0,0 +1,29 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPIDocument describes the management API and the terraform protocol
+// endpoints, the tests check the controllers' responses against it.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// OpenAPIController serves the OpenAPI document, it isn't authenticated.
+type OpenAPIController struct {
+	// No dependencies necessary here
+}
+
+func (c *OpenAPIController) Document(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	//nolint:errcheck
+	w.Write(openAPIDocument)
+}
+
+func (c *OpenAPIController) RegisterRoutes(r muxRouter) {
+	r.HandleFunc("/api/openapi.json", c.Document).Methods("GET")
+}
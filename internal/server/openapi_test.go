@@ -0,0 +1,742 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/svartlfheim/ymir/internal/archive"
+	"github.com/svartlfheim/ymir/internal/registry"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const apiToken = "ymir_0123456789abcdef"
+
+var created = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+type fakeModuleRepository struct {
+	registry.ModuleRepository
+	modules    []registry.Module
+	versions   []registry.ModuleVersion
+	interfaces []registry.ModuleVersionInterface
+}
+
+func (r *fakeModuleRepository) ById(ctx context.Context, id string) (registry.Module, error) {
+	for _, m := range r.modules {
+		if m.Id == id {
+			return m, nil
+		}
+	}
+
+	return registry.Module{}, registry.ErrResourceNotFound{Type: "Module", URI: id}
+}
+
+func (r *fakeModuleRepository) ByFQN(ctx context.Context, fqn registry.ModuleFQN) (registry.Module, error) {
+	for _, m := range r.modules {
+		if m.FQN() == fqn {
+			return m, nil
+		}
+	}
+
+	return registry.Module{}, registry.ErrResourceNotFound{Type: "Module", URI: fqn.String()}
+}
+
+func (r *fakeModuleRepository) All(ctx context.Context, chunkOpts registry.ChunkingOptions, filters registry.ModuleFilters) ([]registry.Module, error) {
+	mods := []registry.Module{}
+
+	for _, m := range r.modules {
+		if filters.Namespace == "" || filters.Namespace == m.Namespace {
+			mods = append(mods, m)
+		}
+	}
+
+	return mods, nil
+}
+
+func (r *fakeModuleRepository) VersionById(ctx context.Context, id string) (registry.ModuleVersion, error) {
+	for _, mv := range r.versions {
+		if mv.Id == id {
+			return mv, nil
+		}
+	}
+
+	return registry.ModuleVersion{}, registry.ErrResourceNotFound{Type: "ModuleVersion", URI: id}
+}
+
+func (r *fakeModuleRepository) VersionsByModule(ctx context.Context, moduleId string, chunkOpts registry.ChunkingOptions) ([]registry.ModuleVersion, error) {
+	mvs := []registry.ModuleVersion{}
+
+	for _, mv := range r.versions {
+		if mv.ModuleId == moduleId {
+			mvs = append(mvs, mv)
+		}
+	}
+
+	return mvs, nil
+}
+
+func (r *fakeModuleRepository) VersionsByModuleFQN(ctx context.Context, fqn registry.ModuleFQN, chunkOpts registry.ChunkingOptions) ([]registry.ModuleVersion, error) {
+	m, err := r.ByFQN(ctx, fqn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.VersionsByModule(ctx, m.Id, chunkOpts)
+}
+
+func (r *fakeModuleRepository) VersionByModuleAndValue(ctx context.Context, moduleId string, version string) (registry.ModuleVersion, error) {
+	for _, mv := range r.versions {
+		if mv.ModuleId == moduleId && mv.Version == version {
+			return mv, nil
+		}
+	}
+
+	return registry.ModuleVersion{}, registry.ErrResourceNotFound{Type: "ModuleVersion", URI: version}
+}
+
+func (r *fakeModuleRepository) VersionByFQN(ctx context.Context, fqn registry.ModuleVersionFQN) (registry.ModuleVersion, error) {
+	m, err := r.ByFQN(ctx, fqn.ModuleFQN)
+
+	if err != nil {
+		return registry.ModuleVersion{}, err
+	}
+
+	return r.VersionByModuleAndValue(ctx, m.Id, fqn.Version)
+}
+
+func (r *fakeModuleRepository) AddModule(ctx context.Context, m registry.Module) (registry.Module, error) {
+	r.modules = append(r.modules, m)
+
+	return m, nil
+}
+
+func (r *fakeModuleRepository) UpdateModule(ctx context.Context, m registry.Module) (registry.Module, error) {
+	return m, nil
+}
+
+func (r *fakeModuleRepository) VersionInterface(ctx context.Context, moduleVersionId string) (registry.ModuleVersionInterface, error) {
+	for _, i := range r.interfaces {
+		if i.ModuleVersionId == moduleVersionId {
+			return i, nil
+		}
+	}
+
+	return registry.ModuleVersionInterface{}, registry.ErrResourceNotFound{Type: "ModuleVersionInterface", URI: moduleVersionId}
+}
+
+type fakeNamespaceRepository struct {
+	registry.NamespaceRepository
+	namespaces []registry.Namespace
+	grants     []registry.RoleGrant
+}
+
+func (r *fakeNamespaceRepository) NamespaceByName(name string) (registry.Namespace, error) {
+	for _, n := range r.namespaces {
+		if n.Name == name {
+			return n, nil
+		}
+	}
+
+	return registry.Namespace{}, registry.ErrResourceNotFound{Type: "Namespace", URI: name}
+}
+
+func (r *fakeNamespaceRepository) AllNamespaces() ([]registry.Namespace, error) {
+	return r.namespaces, nil
+}
+
+func (r *fakeNamespaceRepository) EnsureNamespace(n registry.Namespace) error {
+	return nil
+}
+
+func (r *fakeNamespaceRepository) GrantsByNamespace(namespace string) ([]registry.RoleGrant, error) {
+	grants := []registry.RoleGrant{}
+
+	for _, g := range r.grants {
+		if g.Namespace == namespace {
+			grants = append(grants, g)
+		}
+	}
+
+	return grants, nil
+}
+
+type fakeProviderRepository struct {
+	registry.ProviderRepository
+	provider registry.Provider
+	version  registry.ProviderVersion
+	key      registry.GPGKey
+}
+
+func (r *fakeProviderRepository) ProviderByFQN(fqn registry.ProviderFQN) (registry.Provider, error) {
+	if fqn != r.provider.FQN() {
+		return registry.Provider{}, registry.ErrResourceNotFound{Type: "Provider", URI: fqn.String()}
+	}
+
+	return r.provider, nil
+}
+
+func (r *fakeProviderRepository) VersionsByProvider(providerId string) ([]registry.ProviderVersion, error) {
+	return []registry.ProviderVersion{r.version}, nil
+}
+
+func (r *fakeProviderRepository) ProviderVersionByValue(providerId string, version string) (registry.ProviderVersion, error) {
+	if version != r.version.Version {
+		return registry.ProviderVersion{}, registry.ErrResourceNotFound{Type: "ProviderVersion", URI: version}
+	}
+
+	return r.version, nil
+}
+
+func (r *fakeProviderRepository) GPGKeyById(id string) (registry.GPGKey, error) {
+	return r.key, nil
+}
+
+func (r *fakeProviderRepository) AllGPGKeys() ([]registry.GPGKey, error) {
+	return []registry.GPGKey{r.key}, nil
+}
+
+type fakeMirrorRepository struct {
+	registry.ProviderMirrorRepository
+	packages []registry.MirroredProviderPackage
+}
+
+func (r *fakeMirrorRepository) MirroredPackages(a registry.ProviderSourceAddress, version string) ([]registry.MirroredProviderPackage, error) {
+	pkgs := []registry.MirroredProviderPackage{}
+
+	for _, p := range r.packages {
+		if p.Address() == a && (version == "" || p.Version == version) {
+			pkgs = append(pkgs, p)
+		}
+	}
+
+	return pkgs, nil
+}
+
+type fakeWebhookRepository struct {
+	registry.WebhookRepository
+	subscription registry.WebhookSubscription
+	delivery     registry.WebhookDelivery
+}
+
+func (r *fakeWebhookRepository) SubscriptionById(id string) (registry.WebhookSubscription, error) {
+	if id != r.subscription.Id {
+		return registry.WebhookSubscription{}, registry.ErrResourceNotFound{Type: "WebhookSubscription", URI: id}
+	}
+
+	return r.subscription, nil
+}
+
+func (r *fakeWebhookRepository) AllSubscriptions() ([]registry.WebhookSubscription, error) {
+	return []registry.WebhookSubscription{r.subscription}, nil
+}
+
+func (r *fakeWebhookRepository) DeliveriesBySubscription(subscriptionId string, chunkOpts registry.ChunkingOptions) ([]registry.WebhookDelivery, error) {
+	return []registry.WebhookDelivery{r.delivery}, nil
+}
+
+type fakeAPITokenRepository struct {
+	registry.APITokenRepository
+	token registry.APIToken
+}
+
+func (r *fakeAPITokenRepository) TokenByHash(hash string) (registry.APIToken, error) {
+	if hash != r.token.Hash {
+		return registry.APIToken{}, registry.ErrResourceNotFound{Type: "APIToken", URI: "<redacted>"}
+	}
+
+	return r.token, nil
+}
+
+func (r *fakeAPITokenRepository) TouchToken(t registry.APIToken, at time.Time) error {
+	return nil
+}
+
+type fakeAuditor struct{}
+
+func (a fakeAuditor) Record(action registry.AuditableAction) {}
+
+type fakeDownloadCounter struct{}
+
+func (c fakeDownloadCounter) ModuleDownloaded(namespace string, name string, provider string) {}
+
+func fakeRepositories() (*fakeModuleRepository, []registry.WithDependency) {
+	modules := &fakeModuleRepository{
+		modules: []registry.Module{
+			{
+				Id:              "5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10",
+				Name:            "vpc",
+				Namespace:       "platform",
+				Provider:        "aws",
+				RepositoryURL:   "https://github.com/acme/terraform-aws-vpc",
+				DefaultBranch:   "main",
+				TagPattern:      "v{version}",
+				BreakingChanges: registry.BreakingChangePolicies.Reject,
+			},
+		},
+		versions: []registry.ModuleVersion{
+			{
+				Id:            "0d3e2d55-57bb-4ac2-8f4b-8e8a3d1e7f01",
+				Version:       "1.0.0",
+				ModuleId:      "5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10",
+				Source:        "https://github.com/acme/terraform-aws-vpc",
+				DownloadURL:   "/archives/modules/platform/vpc/aws/1.0.0.tar.gz",
+				RepositoryURL: "https://github.com/acme/terraform-aws-vpc",
+				Status:        registry.VersionStatuses.Ready,
+				CreatedAt:     created,
+			},
+			{
+				Id:            "8c6f0f0e-3f43-4b8e-a3a4-6d1bb5c1d602",
+				Version:       "1.1.0",
+				ModuleId:      "5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10",
+				Source:        "https://github.com/acme/terraform-aws-vpc",
+				RepositoryURL: "https://github.com/acme/terraform-aws-vpc",
+				Status:        registry.VersionStatuses.Failed,
+				StatusReason:  "the ref v1.1.0 doesn't exist",
+				CreatedAt:     created,
+			},
+		},
+		interfaces: []registry.ModuleVersionInterface{
+			{
+				ModuleVersionId: "0d3e2d55-57bb-4ac2-8f4b-8e8a3d1e7f01",
+				Interface: registry.ModuleInterface{
+					RequiredVersion: []string{">= 1.0"},
+					RequiredProviders: []registry.ProviderRequirement{
+						{Name: "aws", Source: "hashicorp/aws", VersionConstraints: []string{"~> 4.0"}},
+					},
+					Variables: []registry.ModuleVariable{
+						{Name: "cidr", Type: "string", Default: json.RawMessage(`"10.0.0.0/16"`), Pos: registry.SourcePos{Filename: "variables.tf", Line: 1}},
+						{Name: "name", Required: true, Pos: registry.SourcePos{Filename: "variables.tf", Line: 5}},
+					},
+					Outputs: []registry.ModuleOutput{
+						{Name: "vpc_id", Pos: registry.SourcePos{Filename: "outputs.tf", Line: 1}},
+					},
+					Resources: []registry.ModuleResource{
+						{Mode: "managed", Type: "aws_vpc", Name: "this", Pos: registry.SourcePos{Filename: "main.tf", Line: 1}},
+					},
+					ModuleCalls: []registry.ModuleCall{},
+				},
+				ParsedAt: created,
+			},
+		},
+	}
+
+	owner := registry.RoleGrant{
+		Id:          "f3a9a1c8-2b1e-4c55-9a57-4d8f1c2e9b03",
+		Namespace:   "platform",
+		Subject:     registry.Subject{Type: registry.SubjectTypes.Team, Id: "6f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c04"},
+		SubjectName: "platform",
+		Role:        registry.Roles.Admin,
+		CreatedAt:   created,
+	}
+
+	namespaces := &fakeNamespaceRepository{
+		namespaces: []registry.Namespace{
+			{Id: "2e4c6a8b-0d1f-4e3a-9b5c-7d9e1f3a5b05", Name: "platform", CreatedAt: created},
+		},
+		grants: []registry.RoleGrant{owner},
+	}
+
+	key := registry.GPGKey{
+		Id:         "9b8a7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c06",
+		Namespace:  "acme",
+		KeyId:      "51852D87348FFC4C",
+		ASCIIArmor: "-----BEGIN PGP PUBLIC KEY BLOCK-----",
+		CreatedAt:  created,
+	}
+
+	providers := &fakeProviderRepository{
+		provider: registry.Provider{
+			Id:        "c4d5e6f7-a8b9-4c0d-9e1f-2a3b4c5d6e07",
+			Namespace: "acme",
+			Type:      "widget",
+			CreatedAt: created,
+		},
+		version: registry.ProviderVersion{
+			Id:                  "d5e6f7a8-b9c0-4d1e-8f2a-3b4c5d6e7f08",
+			ProviderId:          "c4d5e6f7-a8b9-4c0d-9e1f-2a3b4c5d6e07",
+			Version:             "2.0.0",
+			Protocols:           []string{"5.0"},
+			SigningKeyId:        key.Id,
+			ShasumsKey:          "providers/acme/widget/2.0.0/terraform-provider-widget_2.0.0_SHA256SUMS",
+			ShasumsSignatureKey: "providers/acme/widget/2.0.0/terraform-provider-widget_2.0.0_SHA256SUMS.sig",
+			Platforms: []registry.ProviderPlatform{
+				{
+					Id:                "e6f7a8b9-c0d1-4e2f-9a3b-4c5d6e7f8a09",
+					ProviderVersionId: "d5e6f7a8-b9c0-4d1e-8f2a-3b4c5d6e7f08",
+					OS:                "linux",
+					Arch:              "amd64",
+					Filename:          "terraform-provider-widget_2.0.0_linux_amd64.zip",
+					Shasum:            "5f3c0b0f8b1f1b0e5c4d3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d",
+					StorageKey:        "providers/acme/widget/2.0.0/terraform-provider-widget_2.0.0_linux_amd64.zip",
+				},
+			},
+			CreatedAt: created,
+		},
+		key: key,
+	}
+
+	mirror := &fakeMirrorRepository{
+		packages: []registry.MirroredProviderPackage{
+			{
+				Id:         "f7a8b9c0-d1e2-4f3a-8b4c-5d6e7f8a9b10",
+				Hostname:   "registry.terraform.io",
+				Namespace:  "hashicorp",
+				Type:       "aws",
+				Version:    "4.0.0",
+				OS:         "linux",
+				Arch:       "amd64",
+				Filename:   "terraform-provider-aws_4.0.0_linux_amd64.zip",
+				Shasum:     "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b",
+				Hash:       "h1:YWJjZGVmZ2hpamtsbW5vcHFyc3R1dnd4eXo=",
+				StorageKey: "mirror/registry.terraform.io/hashicorp/aws/terraform-provider-aws_4.0.0_linux_amd64.zip",
+				CreatedAt:  created,
+			},
+		},
+	}
+
+	subscription := registry.WebhookSubscription{
+		Id:         "a8b9c0d1-e2f3-4a4b-9c5d-6e7f8a9b0c11",
+		URL:        "https://ci.example.com/hooks/ymir",
+		EventTypes: []registry.EventType{registry.EventTypes.VersionReady},
+		Namespaces: []string{},
+		CreatedAt:  created,
+	}
+
+	webhooks := &fakeWebhookRepository{
+		subscription: subscription,
+		delivery: registry.WebhookDelivery{
+			Id:             "b9c0d1e2-f3a4-4b5c-8d6e-7f8a9b0c1d12",
+			SubscriptionId: subscription.Id,
+			EventId:        "c0d1e2f3-a4b5-4c6d-9e7f-8a9b0c1d2e13",
+			EventType:      registry.EventTypes.VersionReady,
+			Payload:        `{"type":"module_version.ready"}`,
+			Status:         registry.DeliveryStatuses.Delivered,
+			Attempts:       1,
+			NextAttemptAt:  created,
+			CreatedAt:      created,
+			AttemptLog: []registry.WebhookDeliveryAttempt{
+				{
+					Id:           "d1e2f3a4-b5c6-4d7e-8f8a-9b0c1d2e3f14",
+					DeliveryId:   "b9c0d1e2-f3a4-4b5c-8d6e-7f8a9b0c1d12",
+					AttemptedAt:  created,
+					ResponseCode: 200,
+					DurationMs:   35,
+				},
+			},
+		},
+	}
+
+	tokens := &fakeAPITokenRepository{
+		token: registry.APIToken{
+			Id:        "e2f3a4b5-c6d7-4e8f-9a0b-1c2d3e4f5a15",
+			Name:      "ci",
+			Hash:      registry.HashAPIToken(apiToken),
+			Scopes:    []registry.TokenScope{registry.TokenScopes.Admin},
+			CreatedAt: created,
+		},
+	}
+
+	return modules, []registry.WithDependency{
+		registry.WithLogger(zerolog.Nop()),
+		registry.WithCommandValidatorBuilder(registry.NewCommandValidator),
+		registry.WithModuleRepo(modules),
+		registry.WithNamespaceRepo(namespaces),
+		registry.WithProviderRepo(providers),
+		registry.WithProviderMirrorRepo(mirror),
+		registry.WithWebhookRepo(webhooks),
+		registry.WithAPITokenRepo(tokens),
+	}
+}
+
+// documentedServer routes the requests described by the OpenAPI document to
+// the real controllers, backed by fake repositories.
+func documentedServer(signer *archive.URLSigner) *mux.Router {
+	l := zerolog.Nop()
+	modules, deps := fakeRepositories()
+	cb := registry.NewCommandBus(deps...)
+	a := fakeAuditor{}
+
+	return NewServer([]Controller{
+		&OpenAPIController{},
+		NewModulesController(l, cb, a),
+		NewWebhookSubscriptionsController(l, cb, a),
+		NewProvidersController(l, cb, a),
+		NewNamespacesController(l, cb, a),
+		NewModuleRegistryController(l, modules, cb, signer, fakeDownloadCounter{}),
+		NewProviderRegistryController(l, cb),
+		NewProviderMirrorController(l, cb),
+	}).(*mux.Router)
+}
+
+func openAPISpec(t *testing.T) map[string]interface{} {
+	spec := map[string]interface{}{}
+
+	assert.Nil(t, json.Unmarshal(openAPIDocument, &spec))
+
+	return spec
+}
+
+func child(node interface{}, keys ...string) (map[string]interface{}, bool) {
+	for _, k := range keys {
+		m, ok := node.(map[string]interface{})
+
+		if !ok {
+			return nil, false
+		}
+
+		node, ok = m[k]
+
+		if !ok {
+			return nil, false
+		}
+	}
+
+	m, ok := node.(map[string]interface{})
+
+	return m, ok
+}
+
+// resolve follows a reference to the components of the document.
+func resolve(spec map[string]interface{}, node map[string]interface{}) map[string]interface{} {
+	ref, ok := node["$ref"].(string)
+
+	if !ok {
+		return node
+	}
+
+	resolved, _ := child(spec, strings.Split(strings.TrimPrefix(ref, "#/"), "/")...)
+
+	return resolved
+}
+
+// validate checks the document against one of the schemas in the document,
+// the schema's references are resolved within the document.
+func validate(t *testing.T, spec map[string]interface{}, schema map[string]interface{}, document []byte) {
+	root := map[string]interface{}{}
+
+	for k, v := range spec {
+		root[k] = v
+	}
+
+	for k, v := range schema {
+		root[k] = v
+	}
+
+	res, err := gojsonschema.Validate(gojsonschema.NewGoLoader(root), gojsonschema.NewBytesLoader(document))
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	for _, e := range res.Errors() {
+		t.Errorf("%s doesn't match the schema: %s", string(document), e.String())
+	}
+}
+
+func routeTemplateOf(t *testing.T, router *mux.Router, r *http.Request) string {
+	match := mux.RouteMatch{}
+
+	if !assert.True(t, router.Match(r, &match), "%s %s isn't routed", r.Method, r.URL.Path) {
+		return ""
+	}
+
+	tpl, err := match.Route.GetPathTemplate()
+
+	assert.Nil(t, err)
+
+	return tpl
+}
+
+func Test_OpenAPI_Responses(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		signer *archive.URLSigner
+		token  string
+		code   int
+	}{
+		{name: "openapi document", method: "GET", path: "/api/openapi.json", code: 200},
+		{name: "list modules", method: "GET", path: "/api/v1/modules", token: apiToken, code: 200},
+		{name: "list modules without a token", method: "GET", path: "/api/v1/modules", code: 401},
+		{name: "list modules with an unknown token", method: "GET", path: "/api/v1/modules", token: "ymir_unknown", code: 401},
+		{
+			name:   "add module",
+			method: "POST",
+			path:   "/api/v1/modules",
+			body:   `{"namespace": "platform", "name": "dns", "provider": "aws", "repository_url": "https://github.com/acme/terraform-aws-dns", "tag_pattern": "v{version}", "breaking_changes": "warn"}`,
+			token:  apiToken,
+			code:   201,
+		},
+		{name: "add invalid module", method: "POST", path: "/api/v1/modules", body: `{"namespace": "platform", "name": "vpc"}`, token: apiToken, code: 422},
+		{name: "add malformed module", method: "POST", path: "/api/v1/modules", body: `{"namespace": `, token: apiToken, code: 400},
+		{name: "show module", method: "GET", path: "/api/v1/modules/5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10", token: apiToken, code: 200},
+		{name: "show missing module", method: "GET", path: "/api/v1/modules/7f0e6d5c-4b3a-4291-8807-f6e5d4c3b2a1", token: apiToken, code: 404},
+		{name: "show module by invalid id", method: "GET", path: "/api/v1/modules/vpc", token: apiToken, code: 400},
+		{name: "update module", method: "PATCH", path: "/api/v1/modules/5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10", body: `{"default_branch": "trunk"}`, token: apiToken, code: 200},
+		{name: "resolve version", method: "GET", path: "/api/v1/modules/5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10/resolve?constraint=~%3E%201.0", token: apiToken, code: 200},
+		{name: "resolve unmatched version", method: "GET", path: "/api/v1/modules/5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10/resolve?constraint=%3E%3D%202.0", token: apiToken, code: 200},
+		{name: "resolve without a constraint", method: "GET", path: "/api/v1/modules/5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10/resolve", token: apiToken, code: 400},
+		{name: "list module versions", method: "GET", path: "/api/v1/modules/5a2b7bd0-6b0f-4f7e-9d43-0a6a4a3c1f10/versions", token: apiToken, code: 200},
+		{name: "show module version", method: "GET", path: "/api/v1/module-versions/8c6f0f0e-3f43-4b8e-a3a4-6d1bb5c1d602", token: apiToken, code: 200},
+		{name: "show module version interface", method: "GET", path: "/api/v1/module-versions/0d3e2d55-57bb-4ac2-8f4b-8e8a3d1e7f01/interface", token: apiToken, code: 200},
+		{name: "list namespaces", method: "GET", path: "/api/v1/namespaces", token: apiToken, code: 200},
+		{name: "show namespace", method: "GET", path: "/api/v1/namespaces/platform", token: apiToken, code: 200},
+		{name: "list provider versions", method: "GET", path: "/api/v1/providers/acme/widget/versions", token: apiToken, code: 200},
+		{name: "list gpg keys", method: "GET", path: "/api/v1/gpg-keys", token: apiToken, code: 200},
+		{name: "list webhook subscriptions", method: "GET", path: "/api/v1/webhook-subscriptions", token: apiToken, code: 200},
+		{name: "list webhook deliveries", method: "GET", path: "/api/v1/webhook-subscriptions/a8b9c0d1-e2f3-4a4b-9c5d-6e7f8a9b0c11/deliveries", token: apiToken, code: 200},
+		{name: "service discovery", method: "GET", path: "/.well-known/terraform.json", code: 200},
+		{name: "list published modules", method: "GET", path: "/v1/modules?limit=1", code: 200},
+		{name: "list published modules in namespace", method: "GET", path: "/v1/modules/platform", code: 200},
+		{name: "search published modules", method: "GET", path: "/v1/modules/search?q=vpc", code: 200},
+		{name: "search without a query", method: "GET", path: "/v1/modules/search", code: 400},
+		{name: "show published module", method: "GET", path: "/v1/modules/platform/vpc/aws", code: 200},
+		{name: "show unpublished module", method: "GET", path: "/v1/modules/platform/dns/aws", code: 404},
+		{name: "download latest module", method: "GET", path: "/v1/modules/platform/vpc/aws/download", code: 302},
+		{name: "list published module versions", method: "GET", path: "/v1/modules/platform/vpc/aws/versions", code: 200},
+		{name: "download module", method: "GET", path: "/v1/modules/platform/vpc/aws/1.0.0/download", code: 204},
+		{name: "download failed module version", method: "GET", path: "/v1/modules/platform/vpc/aws/1.1.0/download", code: 404},
+		{name: "list published modules without a token", method: "GET", path: "/v1/modules", signer: archive.NewURLSigner([]byte("secret"), time.Minute), code: 401},
+		{name: "list available provider versions", method: "GET", path: "/v1/providers/acme/widget/versions", code: 200},
+		{name: "find provider package", method: "GET", path: "/v1/providers/acme/widget/2.0.0/download/linux/amd64", code: 200},
+		{name: "find missing provider package", method: "GET", path: "/v1/providers/acme/widget/2.0.0/download/darwin/arm64", code: 404},
+		{name: "list mirrored versions", method: "GET", path: "/mirror/v1/providers/registry.terraform.io/hashicorp/aws/index.json", code: 200},
+		{name: "list mirrored archives", method: "GET", path: "/mirror/v1/providers/registry.terraform.io/hashicorp/aws/4.0.0.json", code: 200},
+	}
+
+	spec := openAPISpec(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			router := documentedServer(test.signer)
+			r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+
+			if test.token != "" {
+				r.Header.Set("Authorization", "Bearer "+test.token)
+			}
+
+			tpl := routeTemplateOf(tt, router, r)
+			op, ok := child(spec, "paths", tpl, strings.ToLower(test.method))
+
+			if !assert.True(tt, ok, "%s %s isn't documented", test.method, tpl) {
+				return
+			}
+
+			// Only the bodies that are accepted have to match the schema
+			if req, ok := child(op, "requestBody", "content", "application/json", "schema"); ok && test.code < 300 {
+				validate(tt, spec, req, []byte(test.body))
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if !assert.Equal(tt, test.code, w.Code, w.Body.String()) {
+				return
+			}
+
+			res, ok := child(op, "responses", strconv.Itoa(w.Code))
+
+			if !assert.True(tt, ok, "the %d response of %s %s isn't documented", w.Code, test.method, tpl) {
+				return
+			}
+
+			res = resolve(spec, res)
+
+			if headers, ok := child(res, "headers"); ok {
+				for name := range headers {
+					h, _ := child(headers, name)
+
+					if h["required"] == true {
+						assert.NotEmpty(tt, w.Header().Get(name), "the %s header is documented", name)
+					}
+				}
+			}
+
+			schema, ok := child(res, "content", "application/json", "schema")
+
+			if !ok {
+				// Terraform is sent the params after the 204, which net/http discards
+				if w.Code != http.StatusNoContent {
+					assert.Empty(tt, w.Body.String(), "the response has a body that isn't documented")
+				}
+
+				return
+			}
+
+			assert.Contains(tt, w.Header().Get("Content-Type"), "application/json")
+			validate(tt, spec, schema, w.Body.Bytes())
+		})
+	}
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+func Test_OpenAPI_DocumentsEveryRoute(t *testing.T) {
+	spec := openAPISpec(t)
+	router := documentedServer(nil)
+
+	routed := []string{}
+
+	//nolint:errcheck
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+
+		tpl, err := route.GetPathTemplate()
+
+		if err != nil {
+			return nil
+		}
+
+		// Routes without methods answer any, terraform only sends a GET
+		methods, err := route.GetMethods()
+
+		if err != nil {
+			methods = []string{"GET"}
+		}
+
+		for _, m := range methods {
+			routed = append(routed, m+" "+tpl)
+		}
+
+		return nil
+	})
+
+	documented := []string{}
+	paths, _ := child(spec, "paths")
+
+	for tpl := range paths {
+		ops, _ := child(paths, tpl)
+
+		for m := range ops {
+			if m == "parameters" {
+				continue
+			}
+
+			documented = append(documented, strings.ToUpper(m)+" "+tpl)
+
+			// Every documented path is routed to the route it describes
+			r := httptest.NewRequest(strings.ToUpper(m), pathParam.ReplaceAllString(tpl, "$1"), nil)
+			assert.Equal(t, tpl, routeTemplateOf(t, router, r))
+		}
+	}
+
+	sort.Strings(routed)
+	sort.Strings(documented)
+
+	assert.Equal(t, routed, documented)
+}
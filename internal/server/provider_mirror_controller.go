@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/archive"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+// ProviderMirrorController implements terraform's provider network mirror
+// protocol, runners use it with:
+//
+//	provider_installation {
+//	  network_mirror {
+//	    url = "https://ymir.example.com/mirror/v1/providers/"
+//	  }
+//	}
+type ProviderMirrorController struct {
+	logger zerolog.Logger
+	cb     *registry.CommandBus
+}
+
+type MirrorVersionIndex struct {
+	Versions map[string]struct{} `json:"versions"`
+}
+
+type MirrorArchive struct {
+	URL    string   `json:"url"`
+	Hashes []string `json:"hashes"`
+}
+
+type MirrorVersionArchives struct {
+	Archives map[string]MirrorArchive `json:"archives"`
+}
+
+func addressFromVars(params map[string]string) registry.ProviderSourceAddress {
+	return registry.ProviderSourceAddress{
+		Hostname:  params["hostname"],
+		Namespace: params["namespace"],
+		Type:      params["type"],
+	}
+}
+
+func (c *ProviderMirrorController) ListVersions(w http.ResponseWriter, r *http.Request) {
+	res, err := c.cb.ListMirroredPackagesV1(registry.ListMirroredPackagesV1DTO{
+		Address: addressFromVars(mux.Vars(r)),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "ProviderMirror.ListVersions").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		index := MirrorVersionIndex{
+			Versions: map[string]struct{}{},
+		}
+
+		for _, v := range res.Versions() {
+			index.Versions[v] = struct{}{}
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(index)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "ProviderMirror.ListVersions").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *ProviderMirrorController) ListArchives(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := c.cb.ListMirroredPackagesV1(registry.ListMirroredPackagesV1DTO{
+		Address: addressFromVars(params),
+		Version: params["version"],
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "ProviderMirror.ListArchives").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		archives := MirrorVersionArchives{
+			Archives: map[string]MirrorArchive{},
+		}
+
+		for _, p := range res.List {
+			archives.Archives[p.OS+"_"+p.Arch] = MirrorArchive{
+				URL:    archive.DownloadPath(p.StorageKey),
+				Hashes: p.Hashes(),
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(archives)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "ProviderMirror.ListArchives").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *ProviderMirrorController) RegisterRoutes(r muxRouter) {
+	r.HandleFunc("/mirror/v1/providers/{hostname}/{namespace}/{type}/index.json", c.ListVersions).Methods("GET")
+	r.HandleFunc("/mirror/v1/providers/{hostname}/{namespace}/{type}/{version}.json", c.ListArchives).Methods("GET")
+}
+
+func NewProviderMirrorController(l zerolog.Logger, cb *registry.CommandBus) *ProviderMirrorController {
+	return &ProviderMirrorController{
+		logger: l,
+		cb:     cb,
+	}
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/archive"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+// ProviderRegistryController implements terraform's provider registry
+// protocol, the files it links to are served by the ArchivesController.
+type ProviderRegistryController struct {
+	logger zerolog.Logger
+	cb     *registry.CommandBus
+}
+
+type ProviderPlatformItem struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+type ProviderVersionListItem struct {
+	Version   string                 `json:"version"`
+	Protocols []string               `json:"protocols"`
+	Platforms []ProviderPlatformItem `json:"platforms"`
+}
+
+type ProviderVersionList struct {
+	Versions []ProviderVersionListItem `json:"versions"`
+}
+
+type ProviderGPGPublicKey struct {
+	KeyId          string  `json:"key_id"`
+	ASCIIArmor     string  `json:"ascii_armor"`
+	TrustSignature string  `json:"trust_signature"`
+	Source         string  `json:"source"`
+	SourceURL      *string `json:"source_url"`
+}
+
+type ProviderSigningKeys struct {
+	GPGPublicKeys []ProviderGPGPublicKey `json:"gpg_public_keys"`
+}
+
+type ProviderDownload struct {
+	Protocols           []string            `json:"protocols"`
+	OS                  string              `json:"os"`
+	Arch                string              `json:"arch"`
+	Filename            string              `json:"filename"`
+	DownloadURL         string              `json:"download_url"`
+	ShasumsURL          string              `json:"shasums_url"`
+	ShasumsSignatureURL string              `json:"shasums_signature_url"`
+	Shasum              string              `json:"shasum"`
+	SigningKeys         ProviderSigningKeys `json:"signing_keys"`
+}
+
+func (c *ProviderRegistryController) ListVersions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := c.cb.ListProviderVersionsV1(registry.ListProviderVersionsV1DTO{
+		FQN: registry.ProviderFQN{
+			Namespace: params["namespace"],
+			Type:      params["type"],
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "ProviderRegistry.ListVersions").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		list := ProviderVersionList{
+			Versions: []ProviderVersionListItem{},
+		}
+
+		for _, pv := range res.List {
+			platforms := []ProviderPlatformItem{}
+
+			for _, p := range pv.Platforms {
+				platforms = append(platforms, ProviderPlatformItem{
+					OS:   p.OS,
+					Arch: p.Arch,
+				})
+			}
+
+			list.Versions = append(list.Versions, ProviderVersionListItem{
+				Version:   pv.Version,
+				Protocols: pv.Protocols,
+				Platforms: platforms,
+			})
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(list)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "ProviderRegistry.ListVersions").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *ProviderRegistryController) Download(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := c.cb.DownloadProviderV1(registry.DownloadProviderV1DTO{
+		FQN: registry.ProviderFQN{
+			Namespace: params["namespace"],
+			Type:      params["type"],
+		},
+		Version: params["version"],
+		OS:      params["os"],
+		Arch:    params["arch"],
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "ProviderRegistry.Download").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		// Terraform resolves these relative to the URL of this response
+		d := ProviderDownload{
+			Protocols:           res.ProviderVersion.Protocols,
+			OS:                  res.Platform.OS,
+			Arch:                res.Platform.Arch,
+			Filename:            res.Platform.Filename,
+			DownloadURL:         archive.DownloadPath(res.Platform.StorageKey),
+			ShasumsURL:          archive.DownloadPath(res.ProviderVersion.ShasumsKey),
+			ShasumsSignatureURL: archive.DownloadPath(res.ProviderVersion.ShasumsSignatureKey),
+			Shasum:              res.Platform.Shasum,
+			SigningKeys: ProviderSigningKeys{
+				GPGPublicKeys: []ProviderGPGPublicKey{
+					{
+						KeyId:      res.SigningKey.KeyId,
+						ASCIIArmor: res.SigningKey.ASCIIArmor,
+						Source:     res.SigningKey.Namespace,
+					},
+				},
+			},
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(d)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "ProviderRegistry.Download").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *ProviderRegistryController) RegisterRoutes(r muxRouter) {
+	r.HandleFunc("/v1/providers/{namespace}/{type}/versions", c.ListVersions).Methods("GET")
+	r.HandleFunc("/v1/providers/{namespace}/{type}/{version}/download/{os}/{arch}", c.Download).Methods("GET")
+}
+
+func NewProviderRegistryController(l zerolog.Logger, cb *registry.CommandBus) *ProviderRegistryController {
+	return &ProviderRegistryController{
+		logger: l,
+		cb:     cb,
+	}
+}
@@ -0,0 +1,288 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+// maxProviderUploadMemory is how much of a provider upload is held in memory,
+// the rest of the release zips are buffered to temporary files.
+const maxProviderUploadMemory = 32 << 20
+
+// ProvidersController manages providers and the gpg keys their releases are
+// signed with, terraform itself talks to the ProviderRegistryController.
+type ProvidersController struct {
+	logger  zerolog.Logger
+	cb      *registry.CommandBus
+	auditor requestAuditor
+}
+
+func readFormFile(form *multipart.Form, field string) ([]byte, error) {
+	files := form.File[field]
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	f, err := files[0].Open()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+func (c *ProvidersController) PostVersion(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	if err := r.ParseMultipartForm(maxProviderUploadMemory); err != nil {
+		c.logger.Error().Err(err).Str("action", "Providers.PostVersion").Msg("failed to parse request body")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	//nolint:errcheck
+	defer r.MultipartForm.RemoveAll()
+
+	shasums, err := readFormFile(r.MultipartForm, "shasums")
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Providers.PostVersion").Msg("failed to read shasums")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	signature, err := readFormFile(r.MultipartForm, "signature")
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Providers.PostVersion").Msg("failed to read signature")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	archives := []registry.ProviderArchive{}
+
+	for _, fh := range r.MultipartForm.File["archives"] {
+		f, err := fh.Open()
+
+		if err != nil {
+			c.logger.Error().Err(err).Str("action", "Providers.PostVersion").Msg("failed to read archive")
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		defer f.Close()
+
+		archives = append(archives, registry.ProviderArchive{
+			Filename: fh.Filename,
+			Content:  f,
+		})
+	}
+
+	res, err := actingBus(r, c.cb).UploadProviderVersionV1(registry.UploadProviderVersionV1DTO{
+		Namespace:        params["namespace"],
+		Type:             params["type"],
+		Version:          params["version"],
+		Protocols:        r.MultipartForm.Value["protocols"],
+		Shasums:          shasums,
+		ShasumsSignature: signature,
+		Archives:         archives,
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Providers.PostVersion").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusUnprocessableEntity, w)
+		return
+	case registry.STATUS_CREATED:
+		handleResourceResponse(res.ProviderVersion, http.StatusCreated, w)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Providers.PostVersion").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *ProvidersController) ListVersions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := actingBus(r, c.cb).ListProviderVersionsV1(registry.ListProviderVersionsV1DTO{
+		FQN: registry.ProviderFQN{
+			Namespace: params["namespace"],
+			Type:      params["type"],
+		},
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Providers.ListVersions").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.List, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case registry.STATUS_FORBIDDEN:
+		handleForbiddenResponse(w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Providers.ListVersions").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *ProvidersController) ListKeys(w http.ResponseWriter, r *http.Request) {
+	res, err := c.cb.ListGPGKeysV1(registry.ListGPGKeysV1DTO{
+		Namespace: r.URL.Query().Get("namespace"),
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Providers.ListKeys").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.List, http.StatusOK, w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Providers.ListKeys").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *ProvidersController) PostKey(w http.ResponseWriter, r *http.Request) {
+	dto := registry.AddGPGKeyV1DTO{}
+	err := json.NewDecoder(r.Body).Decode(&dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Providers.PostKey").Msg("failed to parse request body")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	res, err := c.cb.AddGPGKeyV1(dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Providers.PostKey").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusUnprocessableEntity, w)
+		return
+	case registry.STATUS_CREATED:
+		handleResourceResponse(res.Key, http.StatusCreated, w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Providers.PostKey").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *ProvidersController) DeleteKey(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := c.cb.DeleteGPGKeyV1(registry.DeleteGPGKeyV1DTO{
+		Id: params["id"],
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Providers.DeleteKey").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusBadRequest, w)
+		return
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.Key, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Providers.DeleteKey").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *ProvidersController) RegisterRoutes(r muxRouter) {
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(apiMiddleware, tokenAuthMiddleware(c.cb, c.logger))
+
+	api.HandleFunc("/v1/providers/{namespace}/{type}/versions", c.ListVersions).Methods("GET")
+	api.HandleFunc("/v1/providers/{namespace}/{type}/versions/{version}", c.PostVersion).Methods("POST")
+	api.HandleFunc("/v1/gpg-keys", c.ListKeys).Methods("GET")
+	api.HandleFunc("/v1/gpg-keys", c.PostKey).Methods("POST")
+	api.HandleFunc("/v1/gpg-keys/{id}", c.DeleteKey).Methods("DELETE")
+}
+
+func NewProvidersController(l zerolog.Logger, cb *registry.CommandBus, a requestAuditor) *ProvidersController {
+	return &ProvidersController{
+		logger:  l,
+		cb:      cb,
+		auditor: a,
+	}
+}
@@ -22,8 +22,11 @@ func apiMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func NewServer(controllers []Controller) http.Handler {
+// NewServer routes requests to the controllers, through the middlewares for
+// any route that matches.
+func NewServer(controllers []Controller, middlewares ...mux.MiddlewareFunc) http.Handler {
 	r := mux.NewRouter()
+	r.Use(middlewares...)
 
 	// Ensure routes with a trailing slash get redirected
 	r.StrictSlash(true)
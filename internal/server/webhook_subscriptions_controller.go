@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+// WebhookSubscriptionsController manages the subscriptions to the registry's
+// own events, not to be confused with the WebhooksController, which receives
+// webhooks from git forges.
+type WebhookSubscriptionsController struct {
+	logger  zerolog.Logger
+	cb      *registry.CommandBus
+	auditor requestAuditor
+}
+
+func (c *WebhookSubscriptionsController) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	res, err := c.cb.ListWebhookSubscriptionsV1()
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "WebhookSubscriptions.ListSubscriptions").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.List, http.StatusOK, w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "WebhookSubscriptions.ListSubscriptions").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *WebhookSubscriptionsController) PostSubscription(w http.ResponseWriter, r *http.Request) {
+	dto := registry.AddWebhookSubscriptionV1DTO{}
+	err := json.NewDecoder(r.Body).Decode(&dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "WebhookSubscriptions.PostSubscription").Msg("failed to parse request body")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	res, err := c.cb.AddWebhookSubscriptionV1(dto)
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "WebhookSubscriptions.PostSubscription").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusUnprocessableEntity, w)
+		return
+	case registry.STATUS_CREATED:
+		// The only time the secret is returned
+		handleResourceResponse(res.Subscription, http.StatusCreated, w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "WebhookSubscriptions.PostSubscription").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *WebhookSubscriptionsController) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := c.cb.DeleteWebhookSubscriptionV1(registry.DeleteWebhookSubscriptionV1DTO{
+		Id: params["id"],
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "WebhookSubscriptions.DeleteSubscription").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusBadRequest, w)
+		return
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.Subscription, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "WebhookSubscriptions.DeleteSubscription").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *WebhookSubscriptionsController) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	res, err := c.cb.ListWebhookDeliveriesV1(registry.ListWebhookDeliveriesV1DTO{
+		SubscriptionId: params["id"],
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "WebhookSubscriptions.ListDeliveries").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(attributed(r, res))
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusBadRequest, w)
+		return
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.List, http.StatusOK, w)
+		return
+	case registry.STATUS_NOT_FOUND:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "WebhookSubscriptions.ListDeliveries").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *WebhookSubscriptionsController) RegisterRoutes(r muxRouter) {
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(apiMiddleware, tokenAuthMiddleware(c.cb, c.logger))
+
+	api.HandleFunc("/v1/webhook-subscriptions", c.ListSubscriptions).Methods("GET")
+	api.HandleFunc("/v1/webhook-subscriptions", c.PostSubscription).Methods("POST")
+	api.HandleFunc("/v1/webhook-subscriptions/{id}", c.DeleteSubscription).Methods("DELETE")
+	api.HandleFunc("/v1/webhook-subscriptions/{id}/deliveries", c.ListDeliveries).Methods("GET")
+}
+
+func NewWebhookSubscriptionsController(l zerolog.Logger, cb *registry.CommandBus, a requestAuditor) *WebhookSubscriptionsController {
+	return &WebhookSubscriptionsController{
+		logger:  l,
+		cb:      cb,
+		auditor: a,
+	}
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/svartlfheim/ymir/internal/config"
+	"github.com/svartlfheim/ymir/internal/registry"
+	"github.com/svartlfheim/ymir/internal/webhook"
+)
+
+// Forges send more than the push itself, but nothing we need is anywhere
+// near this size.
+const maxWebhookBodyBytes = 5 << 20
+
+// WebhooksController receives push and tag webhooks from git forges, and
+// publishes (or rebuilds) the versions of the modules in the pushed repository.
+type WebhooksController struct {
+	logger  zerolog.Logger
+	cb      *registry.CommandBus
+	auditor requestAuditor
+	secrets map[webhook.Source]string
+}
+
+func (c *WebhooksController) ReceiveWebhook(w http.ResponseWriter, r *http.Request) {
+	source, err := webhook.ParseSource(mux.Vars(r)["source"])
+
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	secret := c.secrets[source]
+
+	if secret == "" {
+		// Webhooks are only accepted from the forges that have been set up
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodyBytes))
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Webhooks.ReceiveWebhook").Msg("failed to read request body")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	if err := webhook.Verify(source, secret, r.Header, body); err != nil {
+		c.logger.Info().Err(err).Str("action", "Webhooks.ReceiveWebhook").Msg("rejected webhook")
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	event, err := webhook.Parse(source, r.Header, body)
+
+	if err != nil {
+		switch err.(type) {
+		case webhook.ErrIgnoredEvent:
+			// Forges flag a webhook as failing unless it gets a 2xx, pings
+			// included
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			c.logger.Info().Err(err).Str("action", "Webhooks.ReceiveWebhook").Msg("failed to parse webhook")
+			w.WriteHeader(http.StatusBadRequest)
+		}
+
+		return
+	}
+
+	res, err := c.cb.HandlePushV1(r.Context(), registry.HandlePushV1DTO{
+		RepositoryURLs: event.RepositoryURLs,
+		Ref:            event.Ref,
+		Commit:         event.Commit,
+		ChangedPaths:   event.ChangedPaths,
+		Deleted:        event.Deleted,
+	})
+
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", "Webhooks.ReceiveWebhook").Msg("command failed")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	c.auditor.Record(res)
+
+	switch res.Status {
+	case registry.STATUS_INVALID:
+		handleValidationErrorsResponse(res.ValidationErrors, http.StatusUnprocessableEntity, w)
+		return
+	case registry.STATUS_OKAY:
+		handleResourceResponse(res.Versions, http.StatusOK, w)
+		return
+	default:
+		c.logger.Error().Str("status", string(res.Status)).Str("action", "Webhooks.ReceiveWebhook").Msg("unhandled response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *WebhooksController) RegisterRoutes(r muxRouter) {
+	r.HandleFunc("/webhooks/{source}", c.ReceiveWebhook).Methods("POST")
+}
+
+func NewWebhooksController(l zerolog.Logger, cb *registry.CommandBus, a requestAuditor, cfg config.WebhooksConfig) *WebhooksController {
+	return &WebhooksController{
+		logger:  l,
+		cb:      cb,
+		auditor: a,
+		secrets: map[webhook.Source]string{
+			webhook.SourceGithub: cfg.Github.Secret,
+			webhook.SourceGitlab: cfg.Gitlab.Secret,
+			webhook.SourceGitea:  cfg.Gitea.Secret,
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package storage
+
+import "fmt"
+
+type ErrDriverNotImplemented struct {
+	Driver string
+}
+
+func (e ErrDriverNotImplemented) Error() string {
+	if e.Driver == "" {
+		return "storage driver was not set"
+	}
+
+	return fmt.Sprintf("storage driver: '%s' is not implemented", e.Driver)
+}
+
+type ErrObjectNotFound struct {
+	Key string
+}
+
+func (e ErrObjectNotFound) Error() string {
+	return fmt.Sprintf("object not found in storage: %s", e.Key)
+}
+
+type ErrInvalidKey struct {
+	Key string
+}
+
+func (e ErrInvalidKey) Error() string {
+	return fmt.Sprintf("invalid storage key: '%s'", e.Key)
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/svartlfheim/ymir/internal/config"
+)
+
+const DriverFS = "fs"
+const DriverInMemory = "inmemory"
+
+// AferoStorage stores objects as files, keyed by their path. The same
+// implementation backs both the fs and inmemory drivers.
+type AferoStorage struct {
+	fs afero.Fs
+}
+
+func cleanKey(key string) (string, error) {
+	cleaned := filepath.ToSlash(filepath.Clean("/" + key))
+
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", ErrInvalidKey{
+			Key: key,
+		}
+	}
+
+	return cleaned, nil
+}
+
+func (s *AferoStorage) Put(key string, r io.Reader) error {
+	p, err := cleanKey(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.fs.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	return afero.WriteReader(s.fs, p, r)
+}
+
+func (s *AferoStorage) Open(key string) (io.ReadCloser, error) {
+	p, err := cleanKey(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := s.fs.Open(p)
+
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound{
+			Key: key,
+		}
+	}
+
+	return f, err
+}
+
+func (s *AferoStorage) Exists(key string) (bool, error) {
+	p, err := cleanKey(key)
+
+	if err != nil {
+		return false, err
+	}
+
+	return afero.Exists(s.fs, p)
+}
+
+func (s *AferoStorage) Delete(key string) error {
+	p, err := cleanKey(key)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.fs.Remove(p)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Ping checks the objects can be reached, such as the volume the fs driver
+// stores them on being mounted.
+func (s *AferoStorage) Ping() error {
+	_, err := s.fs.Stat("/")
+
+	return err
+}
+
+func NewInMemoryStorage() *AferoStorage {
+	return &AferoStorage{
+		fs: afero.NewMemMapFs(),
+	}
+}
+
+func NewFSStorage(fs afero.Fs, path string) *AferoStorage {
+	return &AferoStorage{
+		fs: afero.NewBasePathFs(fs, path),
+	}
+}
+
+func New(fs afero.Fs, cfg config.StorageConfig) (*AferoStorage, error) {
+	switch cfg.Driver {
+	case DriverFS:
+		return NewFSStorage(fs, cfg.Options.FS.Path), nil
+	case DriverInMemory:
+		return NewInMemoryStorage(), nil
+	default:
+		return nil, ErrDriverNotImplemented{
+			Driver: cfg.Driver,
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/svartlfheim/ymir/internal/config"
+)
+
+func Test_AferoStorage_PutOpenDelete(t *testing.T) {
+	s := NewInMemoryStorage()
+
+	err := s.Put("modules/platform/vpc/aws/1.0.0.tar.gz", strings.NewReader("archive"))
+	assert.Nil(t, err)
+
+	exists, err := s.Exists("modules/platform/vpc/aws/1.0.0.tar.gz")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	f, err := s.Open("modules/platform/vpc/aws/1.0.0.tar.gz")
+	assert.Nil(t, err)
+
+	b, err := io.ReadAll(f)
+	assert.Nil(t, err)
+	assert.Equal(t, "archive", string(b))
+	f.Close()
+
+	assert.Nil(t, s.Delete("modules/platform/vpc/aws/1.0.0.tar.gz"))
+	// Deleting twice is not an error
+	assert.Nil(t, s.Delete("modules/platform/vpc/aws/1.0.0.tar.gz"))
+
+	_, err = s.Open("modules/platform/vpc/aws/1.0.0.tar.gz")
+	assert.IsType(t, ErrObjectNotFound{}, err)
+}
+
+func Test_AferoStorage_InvalidKeys(t *testing.T) {
+	s := NewInMemoryStorage()
+
+	for _, key := range []string{"", "/", "../escape", "modules/../../escape"} {
+		t.Run(key, func(tt *testing.T) {
+			err := s.Put(key, new(bytes.Buffer))
+
+			assert.IsType(tt, ErrInvalidKey{}, err)
+		})
+	}
+}
+
+func Test_NewFSStorage_StaysWithinPath(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	s := NewFSStorage(fs, "/opt/archives")
+
+	assert.Nil(t, s.Put("modules/a.tar.gz", strings.NewReader("a")))
+
+	exists, err := afero.Exists(fs, "/opt/archives/modules/a.tar.gz")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+}
+
+func Test_New(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.StorageConfig
+		expectedErr error
+	}{
+		{
+			name: "fs",
+			cfg: config.StorageConfig{
+				Driver: DriverFS,
+			},
+		},
+		{
+			name: "inmemory",
+			cfg: config.StorageConfig{
+				Driver: DriverInMemory,
+			},
+		},
+		{
+			name: "unknown",
+			cfg: config.StorageConfig{
+				Driver: "s3",
+			},
+			expectedErr: ErrDriverNotImplemented{
+				Driver: "s3",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			s, err := New(afero.NewMemMapFs(), test.cfg)
+
+			if test.expectedErr != nil {
+				assert.Equal(tt, test.expectedErr, err)
+				assert.Nil(tt, s)
+				return
+			}
+
+			assert.Nil(tt, err)
+			assert.NotNil(tt, s)
+		})
+	}
+}
+
+func Test_AferoStorage_Ping(t *testing.T) {
+	assert.Nil(t, NewInMemoryStorage().Ping())
+
+	fs := afero.NewMemMapFs()
+	assert.NotNil(t, NewFSStorage(fs, "/mnt/archives").Ping(), "the path doesn't exist")
+
+	assert.Nil(t, fs.MkdirAll("/mnt/archives", 0755))
+	assert.Nil(t, NewFSStorage(fs, "/mnt/archives").Ping())
+}
@@ -0,0 +1,36 @@
+package upstream
+
+import "fmt"
+
+type ErrUnsupportedSource struct {
+	Source string
+}
+
+func (e ErrUnsupportedSource) Error() string {
+	return fmt.Sprintf("upstream source: '%s' is not supported", e.Source)
+}
+
+type ErrUnexpectedStatus struct {
+	URL    string
+	Status int
+}
+
+func (e ErrUnexpectedStatus) Error() string {
+	return fmt.Sprintf("upstream responded to %s with status %d", e.URL, e.Status)
+}
+
+type ErrModulesNotSupported struct {
+	URL string
+}
+
+func (e ErrModulesNotSupported) Error() string {
+	return fmt.Sprintf("upstream %s does not advertise the modules.v1 service", e.URL)
+}
+
+type ErrMissingDownloadLocation struct {
+	URL string
+}
+
+func (e ErrMissingDownloadLocation) Error() string {
+	return fmt.Sprintf("upstream response from %s has no X-Terraform-Get header", e.URL)
+}
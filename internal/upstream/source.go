@@ -0,0 +1,139 @@
+package upstream
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type sourceKind string
+
+const (
+	gitSource  sourceKind = "git"
+	httpSource sourceKind = "http"
+)
+
+// source is a module source address, as terraform would hand it to go-getter.
+// Only git repositories and http archives are supported.
+type source struct {
+	kind      sourceKind
+	url       string
+	ref       string
+	extension string
+	subdir    string
+}
+
+// splitSubdir separates the `//subdir` from a source address, the query
+// string stays with the address.
+func splitSubdir(src string) (string, string) {
+	stop := len(src)
+
+	if i := strings.Index(src, "?"); i > -1 {
+		stop = i
+	}
+
+	offset := 0
+
+	if i := strings.Index(src[:stop], "://"); i > -1 {
+		offset = i + 3
+	}
+
+	i := strings.Index(src[offset:stop], "//")
+
+	if i == -1 {
+		return src, ""
+	}
+
+	i += offset
+
+	return src[:i] + src[stop:], src[i+2 : stop]
+}
+
+func archiveExtension(p string) string {
+	switch {
+	case strings.HasSuffix(p, ".tar.gz"), strings.HasSuffix(p, ".tgz"):
+		return ".tar.gz"
+	case strings.HasSuffix(p, ".zip"):
+		return ".zip"
+	default:
+		return ""
+	}
+}
+
+func parseGitSource(raw string, src string, subdir string) (source, error) {
+	s := source{
+		kind:      gitSource,
+		url:       src,
+		extension: ".tar.gz",
+		subdir:    subdir,
+	}
+
+	if i := strings.Index(src, "?"); i > -1 {
+		q, err := url.ParseQuery(src[i+1:])
+
+		if err != nil {
+			return source{}, ErrUnsupportedSource{Source: raw}
+		}
+
+		s.url = src[:i]
+		s.ref = q.Get("ref")
+	}
+
+	// The upstream isn't trusted, a local path or file:// url would archive
+	// the server's own repositories, and git would take a leading - as an
+	// option
+	if !registry.IsRemoteRepositoryURL(s.url) || strings.HasPrefix(s.ref, "-") {
+		return source{}, ErrUnsupportedSource{Source: raw}
+	}
+
+	return s, nil
+}
+
+func parseHTTPSource(raw string, src string, subdir string) (source, error) {
+	u, err := url.Parse(src)
+
+	if err != nil {
+		return source{}, ErrUnsupportedSource{Source: raw}
+	}
+
+	q := u.Query()
+	extension := archiveExtension(path.Base(u.Path))
+
+	if a := q.Get("archive"); a != "" {
+		extension = archiveExtension("." + a)
+		q.Del("archive")
+		u.RawQuery = q.Encode()
+	}
+
+	if extension == "" {
+		return source{}, ErrUnsupportedSource{Source: raw}
+	}
+
+	return source{
+		kind:      httpSource,
+		url:       u.String(),
+		extension: extension,
+		subdir:    subdir,
+	}, nil
+}
+
+func parseSource(raw string) (source, error) {
+	src, subdir := splitSubdir(raw)
+
+	switch {
+	case strings.HasPrefix(src, "git::"):
+		return parseGitSource(raw, strings.TrimPrefix(src, "git::"), subdir)
+	case strings.Contains(src, "::"):
+		return source{}, ErrUnsupportedSource{Source: raw}
+	case strings.HasPrefix(src, "github.com/"):
+		return parseGitSource(raw, "https://"+src, subdir)
+	case strings.HasPrefix(src, "git@"):
+		return parseGitSource(raw, src, subdir)
+	case strings.HasPrefix(src, "http://"), strings.HasPrefix(src, "https://"):
+		return parseHTTPSource(raw, src, subdir)
+	default:
+		return source{}, ErrUnsupportedSource{Source: raw}
+	}
+}
@@ -0,0 +1,311 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/svartlfheim/ymir/internal/archive"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type gitCheckout interface {
+	Checkout(ctx context.Context, repo string, ref string) (dir string, cleanup func(), err error)
+}
+
+// Client talks to other registries over the modules.v1 protocol, and fetches
+// the sources they point at.
+type Client struct {
+	client *http.Client
+	git    gitCheckout
+}
+
+type discovery struct {
+	ModulesV1 string `json:"modules.v1"`
+}
+
+type moduleVersions struct {
+	Modules []struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	} `json:"modules"`
+}
+
+func (c *Client) get(u string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "ymir-upstream")
+
+	return c.client.Do(req)
+}
+
+func drain(resp *http.Response) {
+	// nolint: errcheck
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+// modulesURL finds where the upstream serves modules.v1 from, using the
+// service discovery document at the root of its host.
+func (c *Client) modulesURL(baseURL string) (*url.URL, error) {
+	base, err := url.Parse(baseURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wellKnown := base.ResolveReference(&url.URL{Path: "/.well-known/terraform.json"})
+	resp, err := c.get(wellKnown.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer drain(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrUnexpectedStatus{URL: wellKnown.String(), Status: resp.StatusCode}
+	}
+
+	d := discovery{}
+
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, err
+	}
+
+	if d.ModulesV1 == "" {
+		return nil, ErrModulesNotSupported{URL: baseURL}
+	}
+
+	modules, err := url.Parse(d.ModulesV1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	modules = wellKnown.ResolveReference(modules)
+
+	if !strings.HasSuffix(modules.Path, "/") {
+		modules.Path += "/"
+	}
+
+	return modules, nil
+}
+
+func (c *Client) moduleURL(baseURL string, fqn registry.ModuleFQN, suffix string) (string, error) {
+	modules, err := c.modulesURL(baseURL)
+
+	if err != nil {
+		return "", err
+	}
+
+	p := fmt.Sprintf("%s/%s/%s/%s", url.PathEscape(fqn.Namespace), url.PathEscape(fqn.Name), url.PathEscape(fqn.Provider), suffix)
+
+	return modules.ResolveReference(&url.URL{Path: p}).String(), nil
+}
+
+// ModuleVersions lists the versions the upstream has of the module, it is an
+// ErrResourceNotFound when the upstream doesn't have the module.
+func (c *Client) ModuleVersions(baseURL string, fqn registry.ModuleFQN) ([]string, error) {
+	u, err := c.moduleURL(baseURL, fqn, "versions")
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.get(u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer drain(resp)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, registry.ErrResourceNotFound{Type: "UpstreamModule", URI: u}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrUnexpectedStatus{URL: u, Status: resp.StatusCode}
+	}
+
+	list := moduleVersions{}
+
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+
+	versions := []string{}
+
+	for _, m := range list.Modules {
+		for _, v := range m.Versions {
+			versions = append(versions, v.Version)
+		}
+	}
+
+	return versions, nil
+}
+
+// ModuleSource is the source address the upstream gives for the version, a
+// relative address is resolved against the download endpoint as terraform
+// would.
+func (c *Client) ModuleSource(baseURL string, fqn registry.ModuleVersionFQN) (string, error) {
+	u, err := c.moduleURL(baseURL, fqn.ModuleFQN, url.PathEscape(fqn.Version)+"/download")
+
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.get(u)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer drain(resp)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", registry.ErrResourceNotFound{Type: "UpstreamModuleVersion", URI: u}
+	}
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return "", ErrUnexpectedStatus{URL: u, Status: resp.StatusCode}
+	}
+
+	location := resp.Header.Get("X-Terraform-Get")
+
+	if location == "" {
+		return "", ErrMissingDownloadLocation{URL: u}
+	}
+
+	if strings.HasPrefix(location, "/") || strings.HasPrefix(location, "./") || strings.HasPrefix(location, "../") {
+		rel, err := url.Parse(location)
+
+		if err != nil {
+			return "", err
+		}
+
+		return resp.Request.URL.ResolveReference(rel).String(), nil
+	}
+
+	return location, nil
+}
+
+// checkoutReader streams a tar.gz of a checkout, closing it removes the
+// checkout once the archive is no longer being written.
+type checkoutReader struct {
+	*io.PipeReader
+	done    chan struct{}
+	cleanup func()
+	once    sync.Once
+}
+
+func (r *checkoutReader) Close() error {
+	err := r.PipeReader.Close()
+
+	r.once.Do(func() {
+		<-r.done
+		r.cleanup()
+	})
+
+	return err
+}
+
+// fetchGit is given the same timeout as a request, the archive is written
+// from the checkout once git has finished.
+func (c *Client) fetchGit(s source) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if c.client.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), c.client.Timeout)
+	}
+
+	defer cancel()
+
+	dir, cleanup, err := c.git.Checkout(ctx, s.url, s.ref)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		pw.CloseWithError(archive.TarGz(dir, pw))
+	}()
+
+	return &checkoutReader{
+		PipeReader: pr,
+		done:       done,
+		cleanup:    cleanup,
+	}, nil
+}
+
+func (c *Client) fetchHTTP(s source) (io.ReadCloser, error) {
+	resp, err := c.get(s.url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		drain(resp)
+
+		return nil, ErrUnexpectedStatus{URL: s.url, Status: resp.StatusCode}
+	}
+
+	return resp.Body, nil
+}
+
+// FetchSource downloads the archive at the source address. Git repositories
+// are checked out and archived as a tar.gz.
+func (c *Client) FetchSource(raw string) (registry.UpstreamArchive, error) {
+	s, err := parseSource(raw)
+
+	if err != nil {
+		return registry.UpstreamArchive{}, err
+	}
+
+	var content io.ReadCloser
+
+	switch s.kind {
+	case gitSource:
+		content, err = c.fetchGit(s)
+	default:
+		content, err = c.fetchHTTP(s)
+	}
+
+	if err != nil {
+		return registry.UpstreamArchive{}, err
+	}
+
+	return registry.UpstreamArchive{
+		Content:   content,
+		Extension: s.extension,
+		Subdir:    s.subdir,
+	}, nil
+}
+
+// NewClient builds a client, the timeout applies to each request made to an
+// upstream including the download of an archive.
+func NewClient(timeout time.Duration, git gitCheckout) *Client {
+	return &Client{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		git: git,
+	}
+}
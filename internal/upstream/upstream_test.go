@@ -0,0 +1,315 @@
+package upstream
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/svartlfheim/ymir/internal/registry"
+)
+
+type fakeCheckout struct {
+	dir       string
+	repo      string
+	ref       string
+	deadline  time.Time
+	cleanedUp bool
+}
+
+func (c *fakeCheckout) Checkout(ctx context.Context, repo string, ref string) (string, func(), error) {
+	c.repo = repo
+	c.ref = ref
+	c.deadline, _ = ctx.Deadline()
+
+	return c.dir, func() { c.cleanedUp = true }, nil
+}
+
+// buildFakeUpstream serves the modules.v1 protocol from under /modules/, with
+// the archive of each version served by the upstream itself.
+func buildFakeUpstream(t *testing.T) *httptest.Server {
+	r := mux.NewRouter()
+
+	r.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"modules.v1":"/modules/"}`)
+	})
+
+	r.HandleFunc("/modules/hashicorp/consul/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"modules":[{"source":"hashicorp/consul/aws","versions":[{"version":"0.1.0"},{"version":"0.2.0"}]}]}`)
+	})
+
+	r.HandleFunc("/modules/hashicorp/consul/aws/0.1.0/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Terraform-Get", "/archives/consul-0.1.0.tar.gz//modules/agent")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r.HandleFunc("/modules/hashicorp/consul/aws/0.2.0/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Terraform-Get", "git::https://example.com/consul.git?ref=v0.2.0")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r.HandleFunc("/modules/hashicorp/broken/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	r.HandleFunc("/archives/consul-0.1.0.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "archive")
+	})
+
+	s := httptest.NewServer(r)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func consulFQN() registry.ModuleFQN {
+	return registry.ModuleFQN{
+		Namespace: "hashicorp",
+		Name:      "consul",
+		Provider:  "aws",
+	}
+}
+
+func TestClient_ModuleVersions(t *testing.T) {
+	s := buildFakeUpstream(t)
+	c := NewClient(5*time.Second, &fakeCheckout{})
+
+	versions, err := c.ModuleVersions(s.URL, consulFQN())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"0.1.0", "0.2.0"}, versions)
+
+	_, err = c.ModuleVersions(s.URL, registry.ModuleFQN{Namespace: "hashicorp", Name: "missing", Provider: "aws"})
+
+	assert.IsType(t, registry.ErrResourceNotFound{}, err)
+
+	_, err = c.ModuleVersions(s.URL, registry.ModuleFQN{Namespace: "hashicorp", Name: "broken", Provider: "aws"})
+
+	assert.IsType(t, ErrUnexpectedStatus{}, err)
+}
+
+func TestClient_ModuleVersions_WithoutModulesService(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"providers.v1":"/v1/providers/"}`)
+	}))
+	defer s.Close()
+
+	_, err := NewClient(5*time.Second, &fakeCheckout{}).ModuleVersions(s.URL, consulFQN())
+
+	assert.IsType(t, ErrModulesNotSupported{}, err)
+}
+
+func TestClient_ModuleSource(t *testing.T) {
+	s := buildFakeUpstream(t)
+	c := NewClient(5*time.Second, &fakeCheckout{})
+
+	source, err := c.ModuleSource(s.URL, registry.ModuleVersionFQN{ModuleFQN: consulFQN(), Version: "0.1.0"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, s.URL+"/archives/consul-0.1.0.tar.gz//modules/agent", source)
+
+	source, err = c.ModuleSource(s.URL, registry.ModuleVersionFQN{ModuleFQN: consulFQN(), Version: "0.2.0"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "git::https://example.com/consul.git?ref=v0.2.0", source)
+
+	_, err = c.ModuleSource(s.URL, registry.ModuleVersionFQN{ModuleFQN: consulFQN(), Version: "9.9.9"})
+
+	assert.IsType(t, registry.ErrResourceNotFound{}, err)
+}
+
+func TestClient_FetchSource_HTTP(t *testing.T) {
+	s := buildFakeUpstream(t)
+	c := NewClient(5*time.Second, &fakeCheckout{})
+
+	a, err := c.FetchSource(s.URL + "/archives/consul-0.1.0.tar.gz//modules/agent")
+
+	assert.Nil(t, err)
+
+	defer a.Content.Close()
+
+	content, err := io.ReadAll(a.Content)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "archive", string(content))
+	assert.Equal(t, ".tar.gz", a.Extension)
+	assert.Equal(t, "modules/agent", a.Subdir)
+
+	_, err = c.FetchSource(s.URL + "/archives/missing.zip")
+
+	assert.IsType(t, ErrUnexpectedStatus{}, err)
+}
+
+func TestClient_FetchSource_Git(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte("# consul"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	checkout := &fakeCheckout{dir: dir}
+	c := NewClient(5*time.Second, checkout)
+	started := time.Now()
+
+	a, err := c.FetchSource("git::https://example.com/consul.git//agent?ref=v0.2.0")
+
+	assert.Nil(t, err)
+
+	gz, err := gzip.NewReader(a.Content)
+
+	assert.Nil(t, err)
+
+	names := []string{}
+	tr := tar.NewReader(gz)
+
+	for {
+		h, err := tr.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		assert.Nil(t, err)
+		names = append(names, h.Name)
+	}
+
+	assert.Nil(t, a.Content.Close())
+	assert.Equal(t, []string{"main.tf"}, names)
+	assert.Equal(t, "https://example.com/consul.git", checkout.repo)
+	assert.Equal(t, "v0.2.0", checkout.ref)
+	assert.WithinDuration(t, started.Add(5*time.Second), checkout.deadline, time.Second)
+	assert.Equal(t, ".tar.gz", a.Extension)
+	assert.Equal(t, "agent", a.Subdir)
+	assert.True(t, checkout.cleanedUp)
+}
+
+func Test_parseSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected source
+		err      error
+	}{
+		{
+			name: "http tar.gz",
+			raw:  "https://example.com/consul.tar.gz",
+			expected: source{
+				kind:      httpSource,
+				url:       "https://example.com/consul.tar.gz",
+				extension: ".tar.gz",
+			},
+		},
+		{
+			name: "http tgz with subdir",
+			raw:  "https://example.com/consul.tgz//modules/agent",
+			expected: source{
+				kind:      httpSource,
+				url:       "https://example.com/consul.tgz",
+				extension: ".tar.gz",
+				subdir:    "modules/agent",
+			},
+		},
+		{
+			name: "http with archive param",
+			raw:  "https://example.com/download?archive=zip&token=abc",
+			expected: source{
+				kind:      httpSource,
+				url:       "https://example.com/download?token=abc",
+				extension: ".zip",
+			},
+		},
+		{
+			name: "forced git with ref and subdir",
+			raw:  "git::https://example.com/consul.git//agent?ref=v1.0.0",
+			expected: source{
+				kind:      gitSource,
+				url:       "https://example.com/consul.git",
+				ref:       "v1.0.0",
+				extension: ".tar.gz",
+				subdir:    "agent",
+			},
+		},
+		{
+			name: "github shorthand",
+			raw:  "github.com/hashicorp/terraform-aws-consul?ref=v0.1.0",
+			expected: source{
+				kind:      gitSource,
+				url:       "https://github.com/hashicorp/terraform-aws-consul",
+				ref:       "v0.1.0",
+				extension: ".tar.gz",
+			},
+		},
+		{
+			name: "scp like git",
+			raw:  "git@github.com:hashicorp/terraform-aws-consul.git",
+			expected: source{
+				kind:      gitSource,
+				url:       "git@github.com:hashicorp/terraform-aws-consul.git",
+				extension: ".tar.gz",
+			},
+		},
+		{
+			name: "http without an archive",
+			raw:  "https://example.com/consul",
+			err:  ErrUnsupportedSource{Source: "https://example.com/consul"},
+		},
+		{
+			name: "other forced getter",
+			raw:  "s3::https://s3.amazonaws.com/bucket/consul.zip",
+			err:  ErrUnsupportedSource{Source: "s3::https://s3.amazonaws.com/bucket/consul.zip"},
+		},
+		{
+			name: "local path",
+			raw:  "./modules/consul",
+			err:  ErrUnsupportedSource{Source: "./modules/consul"},
+		},
+		{
+			name: "forced git with an option",
+			raw:  "git::--upload-pack=touch /tmp/pwned",
+			err:  ErrUnsupportedSource{Source: "git::--upload-pack=touch /tmp/pwned"},
+		},
+		{
+			name: "forced git with a ref option",
+			raw:  "git::https://example.com/consul.git?ref=--orphan=main",
+			err:  ErrUnsupportedSource{Source: "git::https://example.com/consul.git?ref=--orphan=main"},
+		},
+		{
+			name: "forced git with a local path",
+			raw:  "git::/srv/git/other-repo",
+			err:  ErrUnsupportedSource{Source: "git::/srv/git/other-repo"},
+		},
+		{
+			name: "forced git with a file url",
+			raw:  "git::file:///srv/git/other-repo",
+			err:  ErrUnsupportedSource{Source: "git::file:///srv/git/other-repo"},
+		},
+		{
+			name: "forced git over ssh",
+			raw:  "git::ssh://git@example.com/consul.git?ref=v1.0.0",
+			expected: source{
+				kind:      gitSource,
+				url:       "ssh://git@example.com/consul.git",
+				ref:       "v1.0.0",
+				extension: ".tar.gz",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			s, err := parseSource(test.raw)
+
+			assert.Equal(tt, test.err, err)
+			assert.Equal(tt, test.expected, s)
+		})
+	}
+}
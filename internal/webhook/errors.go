@@ -0,0 +1,36 @@
+package webhook
+
+import "fmt"
+
+type ErrUnsupportedSource struct {
+	Source string
+}
+
+func (e ErrUnsupportedSource) Error() string {
+	return fmt.Sprintf("webhook source: '%s' is not supported", e.Source)
+}
+
+type ErrInvalidSignature struct {
+	Source Source
+}
+
+func (e ErrInvalidSignature) Error() string {
+	return fmt.Sprintf("webhook signature for %s is missing or invalid", e.Source)
+}
+
+type ErrIgnoredEvent struct {
+	Event string
+}
+
+func (e ErrIgnoredEvent) Error() string {
+	return fmt.Sprintf("webhook event: '%s' is ignored", e.Event)
+}
+
+type ErrMalformedPayload struct {
+	Source  Source
+	Wrapped error
+}
+
+func (e ErrMalformedPayload) Error() string {
+	return fmt.Sprintf("webhook payload from %s could not be parsed: %s", e.Source, e.Wrapped.Error())
+}
@@ -0,0 +1,218 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+type Source string
+
+const (
+	SourceGithub Source = "github"
+	SourceGitlab Source = "gitlab"
+	SourceGitea  Source = "gitea"
+)
+
+func ParseSource(s string) (Source, error) {
+	switch Source(s) {
+	case SourceGithub, SourceGitlab, SourceGitea:
+		return Source(s), nil
+	default:
+		return "", ErrUnsupportedSource{
+			Source: s,
+		}
+	}
+}
+
+// PushEvent is the part of a push (or tag push) webhook that we care about,
+// it's the same regardless of the forge that sent it.
+type PushEvent struct {
+	// All of the URLs the repository is known by, the module may have been
+	// registered with any of them
+	RepositoryURLs []string
+	Ref            string
+	Commit         string
+	Deleted        bool
+	ChangedPaths   []string
+}
+
+func hmacSha256Hex(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the request came from the forge, using the secret configured
+// for the source. GitHub and Gitea sign the body with an HMAC, GitLab sends
+// the secret back as a token instead.
+func Verify(s Source, secret string, h http.Header, body []byte) error {
+	var expected, actual string
+
+	switch s {
+	case SourceGithub:
+		expected = "sha256=" + hmacSha256Hex(secret, body)
+		actual = h.Get("X-Hub-Signature-256")
+	case SourceGitea:
+		expected = hmacSha256Hex(secret, body)
+		actual = h.Get("X-Gitea-Signature")
+	case SourceGitlab:
+		expected = secret
+		actual = h.Get("X-Gitlab-Token")
+	default:
+		return ErrUnsupportedSource{
+			Source: string(s),
+		}
+	}
+
+	if secret == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) != 1 {
+		return ErrInvalidSignature{
+			Source: s,
+		}
+	}
+
+	return nil
+}
+
+type commitPayload struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+func changedPaths(commits []commitPayload) []string {
+	seen := map[string]bool{}
+	paths := []string{}
+
+	for _, c := range commits {
+		for _, group := range [][]string{c.Added, c.Removed, c.Modified} {
+			for _, p := range group {
+				if !seen[p] {
+					seen[p] = true
+					paths = append(paths, p)
+				}
+			}
+		}
+	}
+
+	return paths
+}
+
+// GitHub and Gitea share the same push payload shape.
+type githubPushPayload struct {
+	Ref        string `json:"ref"`
+	After      string `json:"after"`
+	Deleted    bool   `json:"deleted"`
+	HeadCommit *struct {
+		Id string `json:"id"`
+	} `json:"head_commit"`
+	Repository struct {
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+	Commits []commitPayload `json:"commits"`
+}
+
+type gitlabPushPayload struct {
+	Ref         string `json:"ref"`
+	After       string `json:"after"`
+	CheckoutSha string `json:"checkout_sha"`
+	Project     struct {
+		GitSSHURL  string `json:"git_ssh_url"`
+		GitHTTPURL string `json:"git_http_url"`
+		WebURL     string `json:"web_url"`
+	} `json:"project"`
+	Commits []commitPayload `json:"commits"`
+}
+
+const zeroSha = "0000000000000000000000000000000000000000"
+
+func parseGithub(s Source, body []byte) (PushEvent, error) {
+	p := githubPushPayload{}
+
+	if err := json.Unmarshal(body, &p); err != nil {
+		return PushEvent{}, ErrMalformedPayload{
+			Source:  s,
+			Wrapped: err,
+		}
+	}
+
+	// For an annotated tag, after is the tag object rather than the commit
+	commit := p.After
+
+	if p.HeadCommit != nil && p.HeadCommit.Id != "" {
+		commit = p.HeadCommit.Id
+	}
+
+	return PushEvent{
+		RepositoryURLs: []string{p.Repository.CloneURL, p.Repository.SSHURL, p.Repository.HTMLURL},
+		Ref:            p.Ref,
+		Commit:         commit,
+		Deleted:        p.Deleted || p.After == zeroSha,
+		ChangedPaths:   changedPaths(p.Commits),
+	}, nil
+}
+
+func parseGitlab(body []byte) (PushEvent, error) {
+	p := gitlabPushPayload{}
+
+	if err := json.Unmarshal(body, &p); err != nil {
+		return PushEvent{}, ErrMalformedPayload{
+			Source:  SourceGitlab,
+			Wrapped: err,
+		}
+	}
+
+	commit := p.CheckoutSha
+
+	if commit == "" {
+		commit = p.After
+	}
+
+	return PushEvent{
+		RepositoryURLs: []string{p.Project.GitHTTPURL, p.Project.GitSSHURL, p.Project.WebURL},
+		Ref:            p.Ref,
+		Commit:         commit,
+		Deleted:        p.After == zeroSha,
+		ChangedPaths:   changedPaths(p.Commits),
+	}, nil
+}
+
+func eventName(s Source, h http.Header) string {
+	switch s {
+	case SourceGithub:
+		return h.Get("X-GitHub-Event")
+	case SourceGitea:
+		return h.Get("X-Gitea-Event")
+	case SourceGitlab:
+		return h.Get("X-Gitlab-Event")
+	default:
+		return ""
+	}
+}
+
+// Parse turns a push or tag push webhook into a PushEvent. Any other event
+// (e.g. the ping sent when a webhook is created) returns ErrIgnoredEvent.
+func Parse(s Source, h http.Header, body []byte) (PushEvent, error) {
+	event := eventName(s, h)
+
+	switch {
+	case (s == SourceGithub || s == SourceGitea) && event == "push":
+		return parseGithub(s, body)
+	case s == SourceGitlab && (event == "Push Hook" || event == "Tag Push Hook"):
+		return parseGitlab(body)
+	case s != SourceGithub && s != SourceGitea && s != SourceGitlab:
+		return PushEvent{}, ErrUnsupportedSource{
+			Source: string(s),
+		}
+	default:
+		return PushEvent{}, ErrIgnoredEvent{
+			Event: event,
+		}
+	}
+}
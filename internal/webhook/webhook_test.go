@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadFixture(t *testing.T, name string) []byte {
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func headers(kv ...string) http.Header {
+	h := http.Header{}
+
+	for i := 0; i < len(kv); i += 2 {
+		h.Set(kv[i], kv[i+1])
+	}
+
+	return h
+}
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   Source
+		headers  http.Header
+		fixture  string
+		expected PushEvent
+	}{
+		{
+			name:    "github branch push",
+			source:  SourceGithub,
+			headers: headers("X-GitHub-Event", "push"),
+			fixture: "github_push.json",
+			expected: PushEvent{
+				RepositoryURLs: []string{"https://github.com/org/modules.git", "git@github.com:org/modules.git", "https://github.com/org/modules"},
+				Ref:            "refs/heads/main",
+				Commit:         "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+				ChangedPaths:   []string{"modules/vpc/flow_logs.tf", "modules/vpc/variables.tf", "README.md"},
+			},
+		},
+		{
+			name:    "github annotated tag uses the commit, not the tag object",
+			source:  SourceGithub,
+			headers: headers("X-GitHub-Event", "push"),
+			fixture: "github_tag.json",
+			expected: PushEvent{
+				RepositoryURLs: []string{"https://github.com/org/modules.git", "git@github.com:org/modules.git", "https://github.com/org/modules"},
+				Ref:            "refs/tags/modules/vpc/v1.4.0",
+				Commit:         "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+				ChangedPaths:   []string{},
+			},
+		},
+		{
+			name:    "github branch deleted",
+			source:  SourceGithub,
+			headers: headers("X-GitHub-Event", "push"),
+			fixture: "github_delete_branch.json",
+			expected: PushEvent{
+				RepositoryURLs: []string{"https://github.com/org/modules.git", "git@github.com:org/modules.git", "https://github.com/org/modules"},
+				Ref:            "refs/heads/feature/old",
+				Commit:         "0000000000000000000000000000000000000000",
+				Deleted:        true,
+				ChangedPaths:   []string{},
+			},
+		},
+		{
+			name:    "gitlab branch push",
+			source:  SourceGitlab,
+			headers: headers("X-Gitlab-Event", "Push Hook"),
+			fixture: "gitlab_push.json",
+			expected: PushEvent{
+				RepositoryURLs: []string{"https://gitlab.example.com/platform/modules.git", "git@gitlab.example.com:platform/modules.git", "https://gitlab.example.com/platform/modules"},
+				Ref:            "refs/heads/feature/flow-logs",
+				Commit:         "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+				ChangedPaths:   []string{"modules/dns/zone.tf", "modules/dns/main.tf"},
+			},
+		},
+		{
+			name:    "gitlab tag push",
+			source:  SourceGitlab,
+			headers: headers("X-Gitlab-Event", "Tag Push Hook"),
+			fixture: "gitlab_tag.json",
+			expected: PushEvent{
+				RepositoryURLs: []string{"https://gitlab.example.com/platform/modules.git", "git@gitlab.example.com:platform/modules.git", "https://gitlab.example.com/platform/modules"},
+				Ref:            "refs/tags/modules/dns/v0.2.0",
+				Commit:         "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+				ChangedPaths:   []string{},
+			},
+		},
+		{
+			name:    "gitea branch push",
+			source:  SourceGitea,
+			headers: headers("X-Gitea-Event", "push"),
+			fixture: "gitea_push.json",
+			expected: PushEvent{
+				RepositoryURLs: []string{"https://gitea.example.com/org/modules.git", "git@gitea.example.com:org/modules.git", "https://gitea.example.com/org/modules"},
+				Ref:            "refs/heads/main",
+				Commit:         "bffeb74224043ba2feb48d137756c8a9331c449a",
+				ChangedPaths:   []string{"modules/vpc/outputs.tf"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			e, err := Parse(test.source, test.headers, loadFixture(tt, test.fixture))
+
+			assert.Nil(tt, err)
+			assert.Equal(tt, test.expected, e)
+		})
+	}
+}
+
+func Test_Parse_IgnoredEvents(t *testing.T) {
+	_, err := Parse(SourceGithub, headers("X-GitHub-Event", "ping"), loadFixture(t, "github_ping.json"))
+	assert.Equal(t, ErrIgnoredEvent{Event: "ping"}, err)
+
+	_, err = Parse(SourceGitlab, headers("X-Gitlab-Event", "Merge Request Hook"), []byte("{}"))
+	assert.Equal(t, ErrIgnoredEvent{Event: "Merge Request Hook"}, err)
+
+	_, err = Parse(SourceGithub, headers("X-GitHub-Event", "push"), []byte("{"))
+	assert.IsType(t, ErrMalformedPayload{}, err)
+}
+
+func Test_Verify(t *testing.T) {
+	body := loadFixture(t, "github_push.json")
+	secret := "It's a Secret to Everybody"
+	sig := hmacSha256Hex(secret, body)
+
+	tests := []struct {
+		name    string
+		source  Source
+		secret  string
+		headers http.Header
+		valid   bool
+	}{
+		{name: "github valid", source: SourceGithub, secret: secret, headers: headers("X-Hub-Signature-256", "sha256="+sig), valid: true},
+		{name: "github wrong secret", source: SourceGithub, secret: "nope", headers: headers("X-Hub-Signature-256", "sha256="+sig)},
+		{name: "github missing signature", source: SourceGithub, secret: secret, headers: headers()},
+		{name: "gitea valid", source: SourceGitea, secret: secret, headers: headers("X-Gitea-Signature", sig), valid: true},
+		{name: "gitea prefixed signature", source: SourceGitea, secret: secret, headers: headers("X-Gitea-Signature", "sha256="+sig)},
+		{name: "gitlab valid", source: SourceGitlab, secret: secret, headers: headers("X-Gitlab-Token", secret), valid: true},
+		{name: "gitlab wrong token", source: SourceGitlab, secret: secret, headers: headers("X-Gitlab-Token", "nope")},
+		{name: "no secret configured", source: SourceGitlab, secret: "", headers: headers("X-Gitlab-Token", "")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			err := Verify(test.source, test.secret, test.headers, body)
+
+			if test.valid {
+				assert.Nil(tt, err)
+				return
+			}
+
+			assert.Equal(tt, ErrInvalidSignature{Source: test.source}, err)
+		})
+	}
+}
+
+func Test_hmacSha256Hex(t *testing.T) {
+	// The example from GitHub's documentation on validating deliveries
+	assert.Equal(t, "757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", hmacSha256Hex("It's a Secret to Everybody", []byte("Hello, World!")))
+}